@@ -8,10 +8,13 @@
 // - 高性能读写操作，基于LSM树结构
 // - 支持TTL过期机制
 // - 队列操作（FIFO/LIFO）
-// - 哈希表操作（通过复合键实现）
+// - 哈希表操作（字段以\x00h\x00key\x00field复合键保存，兼容旧版本的key:field）
+// - 集合操作（成员以key\x00member复合键保存）
 // - 事务支持（读写事务）
+// - 进程内发布订阅（只在同一个缓存实例内传递）
 // - 线程安全的并发访问
-// - 自动垃圾回收和压缩
+// - 后台定期回收值日志，间隔和可回收比例由ValueLogGCInterval/ValueLogGCRatio配置
+// - Compact手动压缩LSM树并回收值日志
 // - 本地文件存储，无需外部依赖
 //
 // 使用场景：
@@ -24,15 +27,23 @@ package badgerdb
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
+	"io"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gophertool/tool/db/cache/config"
 	_interface "github.com/gophertool/tool/db/cache/interface"
 
 	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/options"
+	"github.com/dgraph-io/badger/pb"
 )
 
 // 包初始化时注册BadgerDB驱动
@@ -40,13 +51,34 @@ func init() {
 	_interface.RegisterDriver(config.CacheDriverBadger, NewBadgerStore)
 }
 
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
 // BadgerDb BadgerDB缓存实现结构体
 type BadgerDb struct {
-	db         *badger.DB // BadgerDB实例
-	queueMutex sync.Map   // 用于队列操作的互斥锁映射
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db         *badger.DB                // BadgerDB实例
+	queueMutex sync.Map                  // 用于队列操作的互斥锁映射
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+	gcRatio    float64                   // 值日志垃圾回收的可回收比例
+	gcStop     chan struct{}             // 关闭后停止后台垃圾回收
+	gcDone     chan struct{}             // 后台垃圾回收退出后关闭
+	gcOnce     sync.Once
+	legacyHash atomic.Bool // 数据库中可能有key:field形式的旧哈希表字段
 }
 
-// LPush 将元素插入到列表头部
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*BadgerDb)(nil)
+
+// 确保原生支持事务选项
+var _ _interface.TxBeginner = (*BadgerDb)(nil)
+
+// LPush 将元素插入到列表头部，元素和头索引在同一个事务中写入
 // 参数：
 //
 //	key - 列表键名
@@ -59,45 +91,20 @@ func (b *BadgerDb) LPush(key string, value string) error {
 	b.lock(key)
 	defer b.unlock(key)
 
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	headVal, err := b.Get(headKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		// 列表不存在，初始化
-		if err := b.Set(headKey, "0", 0); err != nil {
-			return err
-		}
-		if err := b.Set(tailKey, "1", 0); err != nil {
+	return b.db.Update(func(txn *badger.Txn) error {
+		headIndex, tailIndex, err := listBounds(txn, key)
+		if err != nil {
 			return err
 		}
-		if err := b.Set(key+":0", value, 0); err != nil {
+		headIndex--
+		if err := txn.Set(listElemKey(key, headIndex), []byte(value)); err != nil {
 			return err
 		}
-		return nil
-	} else if err != nil {
-		return err
-	}
-
-	// 解析头索引
-	headIndex, err := strconv.ParseInt(headVal, 10, 64)
-	if err != nil {
-		return err
-	}
-
-	// 头索引减一
-	headIndex--
-
-	// 存储新元素
-	if err := b.Set(key+":"+strconv.FormatInt(headIndex, 10), value, 0); err != nil {
-		return err
-	}
-
-	// 更新头索引
-	return b.Set(headKey, strconv.FormatInt(headIndex, 10), 0)
+		return setListBounds(txn, key, headIndex, tailIndex)
+	})
 }
 
-// RPush 将元素插入到列表尾部
+// RPush 将元素插入到列表尾部，元素和尾索引在同一个事务中写入
 // 参数：
 //
 //	key - 列表键名
@@ -110,42 +117,48 @@ func (b *BadgerDb) RPush(key string, value string) error {
 	b.lock(key)
 	defer b.unlock(key)
 
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	tailVal, err := b.Get(tailKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		// 列表不存在，初始化
-		if err := b.Set(headKey, "0", 0); err != nil {
-			return err
-		}
-		if err := b.Set(tailKey, "1", 0); err != nil {
+	return b.db.Update(func(txn *badger.Txn) error {
+		headIndex, tailIndex, err := listBounds(txn, key)
+		if err != nil {
 			return err
 		}
-		if err := b.Set(key+":0", value, 0); err != nil {
+		if err := txn.Set(listElemKey(key, tailIndex), []byte(value)); err != nil {
 			return err
 		}
-		return nil
-	} else if err != nil {
-		return err
-	}
-
-	// 解析尾索引
-	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
-	if err != nil {
-		return err
-	}
-
-	// 存储新元素
-	if err := b.Set(key+":"+strconv.FormatInt(tailIndex, 10), value, 0); err != nil {
-		return err
-	}
+		return setListBounds(txn, key, headIndex, tailIndex+1)
+	})
+}
 
-	// 增加尾索引
-	tailIndex++
+// RPushCapped 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) RPushCapped(key string, value string, maxLen int64) error {
+	b.lock(key)
+	defer b.unlock(key)
 
-	// 更新尾索引
-	return b.Set(tailKey, strconv.FormatInt(tailIndex, 10), 0)
+	return b.db.Update(func(txn *badger.Txn) error {
+		headIndex, tailIndex, err := listBounds(txn, key)
+		if err != nil {
+			return err
+		}
+		if err := txn.Set(listElemKey(key, tailIndex), []byte(value)); err != nil {
+			return err
+		}
+		tailIndex++
+		for ; maxLen > 0 && tailIndex-headIndex > maxLen; headIndex++ {
+			if err := txn.Delete(listElemKey(key, headIndex)); err != nil {
+				return err
+			}
+		}
+		return setListBounds(txn, key, headIndex, tailIndex)
+	})
 }
 
 // LPop 弹出列表头部元素
@@ -158,120 +171,49 @@ func (b *BadgerDb) RPush(key string, value string) error {
 //	string - 弹出的元素值
 //	error - 操作错误
 func (b *BadgerDb) LPop(key string) (string, error) {
-	b.lock(key)
-	defer b.unlock(key)
-
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	headVal, err := b.Get(headKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		return "", _interface.ErrKeyNotFound
-	} else if err != nil {
-		return "", err
-	}
-
-	tailVal, err := b.Get(tailKey)
-	if err != nil {
-		return "", err
-	}
-
-	// 解析索引
-	headIndex, err := strconv.ParseInt(headVal, 10, 64)
-	if err != nil {
-		return "", err
-	}
-
-	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
-	if err != nil {
-		return "", err
-	}
-
-	// 检查列表是否为空
-	if headIndex >= tailIndex {
-		return "", _interface.ErrKeyNotFound
-	}
-
-	// 获取头部元素，修复key格式问题
-	elementKey := key + ":" + strconv.FormatInt(headIndex, 10)
-	value, err := b.Get(elementKey)
-	if err != nil {
-		return "", err
-	}
-
-	// 删除元素
-	if err := b.Delete(elementKey); err != nil {
-		return "", err
-	}
-
-	// 增加头索引
-	headIndex++
-
-	// 更新头索引
-	if err := b.Set(headKey, strconv.FormatInt(headIndex, 10), 0); err != nil {
-		return "", err
-	}
-
-	return value, nil
+	return b.pop(key, true)
 }
 
 // RPop 弹出列表尾部元素
 func (b *BadgerDb) RPop(key string) (string, error) {
-	b.lock(key)
-	defer b.unlock(key)
-
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	headVal, err := b.Get(headKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		return "", _interface.ErrKeyNotFound
-	} else if err != nil {
-		return "", err
-	}
-
-	tailVal, err := b.Get(tailKey)
-	if err != nil {
-		return "", err
-	}
-
-	// 解析索引
-	headIndex, err := strconv.ParseInt(headVal, 10, 64)
-	if err != nil {
-		return "", err
-	}
-
-	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
-	if err != nil {
-		return "", err
-	}
-
-	// 检查列表是否为空
-	if headIndex >= tailIndex {
-		return "", _interface.ErrKeyNotFound
-	}
-
-	// 减少尾索引
-	tailIndex--
+	return b.pop(key, false)
+}
 
-	// 获取尾部元素
-	elementKey := key + ":" + strconv.FormatInt(tailIndex, 10)
-	value, err := b.Get(elementKey)
-	if err != nil {
+// pop 在同一个事务中读取并删除头部或尾部元素、更新索引，列表为空时返回ErrKeyNotFound
+func (b *BadgerDb) pop(key string, head bool) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
 		return "", err
 	}
 
-	// 删除元素
-	if err := b.Delete(elementKey); err != nil {
-		return "", err
-	}
+	b.lock(key)
+	defer b.unlock(key)
 
-	// 更新尾索引
-	if err := b.Set(tailKey, strconv.FormatInt(tailIndex, 10), 0); err != nil {
-		return "", err
-	}
+	var value string
+	err := b.db.Update(func(txn *badger.Txn) error {
+		headIndex, tailIndex, err := listBounds(txn, key)
+		if err != nil {
+			return err
+		}
+		if headIndex >= tailIndex {
+			return _interface.ErrKeyNotFound
+		}
 
-	return value, nil
+		index := headIndex
+		if head {
+			headIndex++
+		} else {
+			tailIndex--
+			index = tailIndex
+		}
+		if value, err = txnGet(txn, listElemKey(key, index)); err != nil {
+			return err
+		}
+		if err := txn.Delete(listElemKey(key, index)); err != nil {
+			return err
+		}
+		return setListBounds(txn, key, headIndex, tailIndex)
+	})
+	return value, err
 }
 
 func (b *BadgerDb) lock(key string) {
@@ -298,123 +240,357 @@ func (b *BadgerDb) Pop(key string) (string, error) {
 }
 
 // PopAll 取出并清空整个列表
+// 元素在一个只读事务中读取，删除通过WriteBatch批量写入，列表很长时不会超出单个事务的大小限制
 func (b *BadgerDb) PopAll(key string) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
+	}
+
 	b.lock(key)
 	defer b.unlock(key)
 
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	headVal, err := b.Get(headKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		return []string{}, nil
-	} else if err != nil {
-		return nil, err
+	result := []string{}
+	var headIndex, tailIndex int64
+	err := b.db.View(func(txn *badger.Txn) error {
+		var err error
+		headIndex, tailIndex, err = listBounds(txn, key)
+		if err != nil {
+			return err
+		}
+		for i := headIndex; i < tailIndex; i++ {
+			value, err := txnGet(txn, listElemKey(key, i))
+			if errors.Is(err, _interface.ErrKeyNotFound) {
+				continue // 跳过缺失的元素
+			}
+			if err != nil {
+				return err
+			}
+			result = append(result, value)
+		}
+		return nil
+	})
+	if err != nil || headIndex >= tailIndex {
+		return result, err
 	}
 
-	tailVal, err := b.Get(tailKey)
-	if err != nil {
-		return nil, err
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+	for i := headIndex; i < tailIndex; i++ {
+		if err := wb.Delete(listElemKey(key, i)); err != nil {
+			return nil, err
+		}
 	}
-
-	// 解析索引
-	headIndex, err := strconv.ParseInt(headVal, 10, 64)
-	if err != nil {
+	if err := wb.Delete([]byte(key + ":head")); err != nil {
 		return nil, err
 	}
-
-	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
-	if err != nil {
+	if err := wb.Delete([]byte(key + ":tail")); err != nil {
 		return nil, err
 	}
-
-	// 检查列表是否为空
-	if headIndex >= tailIndex {
-		return []string{}, nil
-	}
-
-	// 获取所有元素
-	result := make([]string, 0, tailIndex-headIndex)
-	for i := headIndex; i < tailIndex; i++ {
-		elementKey := key + ":" + strconv.FormatInt(i, 10)
-		value, err := b.Get(elementKey)
-		if err != nil {
-			continue // 跳过获取失败的元素
-		}
-		result = append(result, value)
-
-		// 删除元素
-		_ = b.Delete(elementKey)
+	if err := wb.Flush(); err != nil {
+		return nil, err
 	}
-
-	// 重置列表
-	_ = b.Delete(headKey)
-	_ = b.Delete(tailKey)
-
 	return result, nil
 }
 
 // Len 获取列表长度
 func (b *BadgerDb) Len(key string) (int64, error) {
-	headKey := key + ":head"
-	tailKey := key + ":tail"
-
-	headVal, err := b.Get(headKey)
-	if errors.Is(err, _interface.ErrKeyNotFound) {
-		return 0, nil
-	} else if err != nil {
+	if err := b.delayQueue.Promote(b, key); err != nil {
 		return 0, err
 	}
 
-	tailVal, err := b.Get(tailKey)
+	headIndex, tailIndex, err := b.listBounds(key)
 	if err != nil {
 		return 0, err
 	}
+	return tailIndex - headIndex, nil
+}
 
-	// 解析索引
-	headIndex, err := strconv.ParseInt(headVal, 10, 64)
-	if err != nil {
-		return 0, err
+// LRange 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (b *BadgerDb) LRange(key string, start, stop int64) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
 	}
 
-	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
+	headIndex, tailIndex, err := b.listBounds(key)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// 计算长度
-	return tailIndex - headIndex, nil
+	lo, hi := _interface.ListRange(start, stop, tailIndex-headIndex)
+	result := make([]string, 0, hi-lo)
+	for i := headIndex + lo; i < headIndex+hi; i++ {
+		value, err := b.Get(key + ":" + strconv.FormatInt(i, 10))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
 }
 
-func (b *BadgerDb) Close() {
-	_ = b.db.Close()
+// LIndex 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (b *BadgerDb) LIndex(key string, index int64) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+
+	headIndex, tailIndex, err := b.listBounds(key)
+	if err != nil {
+		return "", err
+	}
+
+	i, ok := _interface.ListIndex(index, tailIndex-headIndex)
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return b.Get(key + ":" + strconv.FormatInt(headIndex+i, 10))
 }
 
-// Get 获取指定key的值
+// LRem 删除列表中等于value的元素，保留的元素从头部开始重新连续存放
 // 参数：
 //
-//	key - 键名
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
 //
 // 返回值：
 //
-//	string - 键对应的值
-//	error - 操作错误，键不存在时返回ErrKeyNotFound
-func (b *BadgerDb) Get(key string) (string, error) {
-	var val []byte
-	err := b.db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
+//	int64 - 删除的数量
+//	error - 操作错误
+func (b *BadgerDb) LRem(key string, count int64, value string) (int64, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return 0, err
+	}
+
+	b.lock(key)
+	defer b.unlock(key)
+
+	headIndex, tailIndex, err := b.listBounds(key)
+	if err != nil {
+		return 0, err
+	}
+
+	items := make([]string, 0, tailIndex-headIndex)
+	for i := headIndex; i < tailIndex; i++ {
+		item, err := b.Get(key + ":" + strconv.FormatInt(i, 10))
 		if err != nil {
-			return err
+			return 0, err
 		}
-		val, err = item.ValueCopy(nil)
-		return err
-	})
-	// 统一错误处理：将BadgerDB特定错误转换为接口标准错误
-	if errors.Is(err, badger.ErrKeyNotFound) {
-		return "", _interface.ErrKeyNotFound
+		items = append(items, item)
 	}
-	return string(val), err
-}
+
+	kept, removed := _interface.ListRem(items, count, value)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	err = b.db.Update(func(txn *badger.Txn) error {
+		for i := headIndex; i < tailIndex; i++ {
+			if err := txn.Delete([]byte(key + ":" + strconv.FormatInt(i, 10))); err != nil {
+				return err
+			}
+		}
+		for i, item := range kept {
+			if err := txn.Set([]byte(key+":"+strconv.FormatInt(headIndex+int64(i), 10)), []byte(item)); err != nil {
+				return err
+			}
+		}
+		return setListBounds(txn, key, headIndex, headIndex+int64(len(kept)))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// LTrim 只保留列表中下标在[start, stop]范围内的元素
+func (b *BadgerDb) LTrim(key string, start, stop int64) error {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return err
+	}
+
+	b.lock(key)
+	defer b.unlock(key)
+
+	headIndex, tailIndex, err := b.listBounds(key)
+	if err != nil || headIndex >= tailIndex {
+		return err
+	}
+
+	lo, hi := _interface.ListRange(start, stop, tailIndex-headIndex)
+	return b.db.Update(func(txn *badger.Txn) error {
+		for i := headIndex; i < tailIndex; i++ {
+			if i >= headIndex+lo && i < headIndex+hi {
+				continue
+			}
+			if err := txn.Delete([]byte(key + ":" + strconv.FormatInt(i, 10))); err != nil {
+				return err
+			}
+		}
+		return setListBounds(txn, key, headIndex+lo, headIndex+hi)
+	})
+}
+
+// listBounds 读取列表的头尾索引，列表不存在时返回0, 0
+func (b *BadgerDb) listBounds(key string) (headIndex, tailIndex int64, err error) {
+	err = b.db.View(func(txn *badger.Txn) error {
+		headIndex, tailIndex, err = listBounds(txn, key)
+		return err
+	})
+	return headIndex, tailIndex, err
+}
+
+// listBounds 在事务中读取列表的头尾索引，列表不存在时返回0, 0
+func listBounds(txn *badger.Txn, key string) (int64, int64, error) {
+	headVal, err := txnGet(txn, []byte(key+":head"))
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+
+	tailVal, err := txnGet(txn, []byte(key+":tail"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	headIndex, err := strconv.ParseInt(headVal, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	tailIndex, err := strconv.ParseInt(tailVal, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if tailIndex < headIndex {
+		tailIndex = headIndex
+	}
+	return headIndex, tailIndex, nil
+}
+
+// listElemKey 返回列表元素的键名
+func listElemKey(key string, index int64) []byte {
+	return []byte(key + ":" + strconv.FormatInt(index, 10))
+}
+
+// txnGet 在事务中读取键的值，键不存在时返回ErrKeyNotFound
+func txnGet(txn *badger.Txn, key []byte) (string, error) {
+	item, err := txn.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	val, err := item.ValueCopy(nil)
+	return string(val), err
+}
+
+// setListBounds 在事务中保存列表的头尾索引，列表为空时与PopAll一样删除索引
+func setListBounds(txn *badger.Txn, key string, headIndex, tailIndex int64) error {
+	if headIndex >= tailIndex {
+		if err := txn.Delete([]byte(key + ":head")); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(key + ":tail"))
+	}
+	if err := txn.Set([]byte(key+":head"), []byte(strconv.FormatInt(headIndex, 10))); err != nil {
+		return err
+	}
+	return txn.Set([]byte(key+":tail"), []byte(strconv.FormatInt(tailIndex, 10)))
+}
+
+// PopAck 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，进程重启后仍然有效
+// 参数：
+//
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BadgerDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return b.ackQueue.PopAck(b, key, visibility)
+}
+
+// Ack 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (b *BadgerDb) Ack(key, receipt string) error {
+	return b.ackQueue.Ack(b, key, receipt)
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，进程重启后仍然有效；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) PushDelayed(key, value string, delay time.Duration) error {
+	return b.delayQueue.PushDelayed(b, key, value, delay)
+}
+
+func (b *BadgerDb) Close() {
+	b.gcOnce.Do(func() {
+		close(b.gcStop)
+		<-b.gcDone
+	})
+	b.expiry.Close()
+	_ = b.db.Close()
+	b.broker.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (b *BadgerDb) Get(key string) (string, error) {
+	var val []byte
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		val, err = item.ValueCopy(nil)
+		return err
+	})
+	// 统一错误处理：将BadgerDB特定错误转换为接口标准错误
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(val), err
+}
 
 func (b *BadgerDb) Set(key string, value string, ttl time.Duration) error {
 	return b.db.Update(func(txn *badger.Txn) error {
@@ -426,80 +602,738 @@ func (b *BadgerDb) Set(key string, value string, ttl time.Duration) error {
 	})
 }
 
-func (b *BadgerDb) Delete(key string) error {
-	return b.db.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(key))
-	})
+func (b *BadgerDb) Delete(key string) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
+func (b *BadgerDb) Exists(key string) (bool, error) {
+	err := b.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(key))
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *BadgerDb) Expire(key string, ttl time.Duration) error {
+	// 实现逻辑：先获取旧值，再重新设置 TTL
+	return b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		e := badger.NewEntry([]byte(key), val).WithTTL(ttl)
+		return txn.SetEntry(e)
+	})
+}
+
+// TTL 获取key的剩余过期时间，BadgerDB的过期时间精确到秒
+// key不是普通键值而是哈希表时，字段各自保存过期时间，返回其中最长的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在时返回TTLNotFound
+//	error - 操作错误
+func (b *BadgerDb) TTL(key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			ttl = b.hashTTL(txn, key)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ttl = remainingTTL(item.ExpiresAt())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// remainingTTL 将BadgerDB的过期时间（Unix秒）转换为剩余过期时间
+func remainingTTL(expiresAt uint64) time.Duration {
+	if expiresAt == 0 {
+		return _interface.TTLNoExpiry
+	}
+	ttl := time.Until(time.Unix(int64(expiresAt), 0))
+	if ttl <= 0 {
+		return _interface.TTLNotFound
+	}
+	return ttl
+}
+
+// SetNX key不存在时设置key-value并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (b *BadgerDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := b.db.Update(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(key))
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		e := badger.NewEntry([]byte(key), []byte(value))
+		if ttl > 0 {
+			e.WithTTL(ttl)
+		}
+		ok = true
+		return txn.SetEntry(e)
+	})
+	return ok && err == nil, err
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 读取之后key被并发的事务修改时，BadgerDB提交冲突，此时返回false
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (b *BadgerDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		current, err := item.ValueCopy(nil)
+		if err != nil || string(current) != oldValue {
+			return err
+		}
+		e := badger.NewEntry([]byte(key), []byte(newValue))
+		if ttl > 0 {
+			e.WithTTL(ttl)
+		}
+		ok = true
+		return txn.SetEntry(e)
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return false, nil
+	}
+	return ok && err == nil, err
+}
+
+// GetSet 设置新值并返回旧值
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (b *BadgerDb) GetSet(key string, value string) (string, error) {
+	var old []byte
+	var found bool
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == nil {
+			found = true
+			if old, err = item.ValueCopy(nil); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		return txn.Set([]byte(key), []byte(value))
+	})
+	if err == nil && !found {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(old), err
+}
+
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (b *BadgerDb) GetDel(key string) (string, error) {
+	var val []byte
+	err := b.db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		if val, err = item.ValueCopy(nil); err != nil {
+			return err
+		}
+		return txn.Delete([]byte(key))
+	})
+	// 统一错误处理：将BadgerDB特定错误转换为接口标准错误
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(val), err
+}
+
+// Append 在同一个事务中读取key的值并追加value，见AppendContext
+func (b *BadgerDb) Append(key string, value string) (int64, error) {
+	return b.AppendContext(context.Background(), key, value)
+}
+
+// AppendContext 在同一个事务中读取key的值并追加value，key不存在时创建不过期的key，已有的过期时间保持不变
+// 与并发的写入冲突时按随机退避重新读取并重试，直到追加成功或ctx结束
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误或ctx.Err()
+func (b *BadgerDb) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	var n int64
+	err := _interface.RetryConflict(ctx, func() (bool, error) {
+		err := b.db.Update(func(txn *badger.Txn) error {
+			var expiresAt uint64
+			data := []byte(value)
+			item, err := txn.Get([]byte(key))
+			if err == nil {
+				if data, err = item.ValueCopy(nil); err != nil {
+					return err
+				}
+				data = append(data, value...)
+				expiresAt = item.ExpiresAt()
+			} else if !errors.Is(err, badger.ErrKeyNotFound) {
+				return err
+			}
+			e := badger.NewEntry([]byte(key), data)
+			e.ExpiresAt = expiresAt
+			n = int64(len(data))
+			return txn.SetEntry(e)
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在时返回0
+func (b *BadgerDb) StrLen(key string) (int64, error) {
+	var n int64
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		n = item.ValueSize()
+		return nil
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (b *BadgerDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := b.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (b *BadgerDb) GetObject(key string, v any) error {
+	raw, err := b.Get(key)
+	if err != nil {
+		return err
+	}
+	return b.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys 遍历匹配pattern的key，通配符语法与Redis一致
+// 哈希表字段的复合键不会被遍历到，队列元素和集合成员以key:index、key\x00member等复合键保存，也会被遍历到
+// 每批读取scanBatchSize个key后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) Keys(pattern string, fn func(key string) bool) error {
+	prefix := _interface.PatternPrefix(pattern)
+	start := prefix
+	for {
+		var keys []string
+		err := b.db.View(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek([]byte(start)); it.ValidForPrefix([]byte(prefix)) && len(keys) < scanBatchSize; it.Next() {
+				keys = append(keys, string(it.Item().Key()))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// Iterate 遍历键名以prefix开头的键值
+// 遍历范围与Keys一致；每批读取scanBatchSize个键值后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	start := prefix
+	for {
+		var keys, values []string
+		err := b.db.View(func(txn *badger.Txn) error {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchSize = scanBatchSize
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek([]byte(start)); it.ValidForPrefix([]byte(prefix)) && len(keys) < scanBatchSize; it.Next() {
+				item := it.Item()
+				value, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, string(item.Key()))
+				values = append(values, string(value))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, key := range keys {
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if !fn(key, values[i]) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// DeleteByPrefix 删除所有以prefix开头的key，包括键名以prefix开头的哈希表，基于BadgerDB的DropPrefix
+// DropPrefix执行期间会阻塞写入，适合低频的整体失效，不适合在热路径上频繁调用
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) DeleteByPrefix(prefix string) error {
+	if err := b.db.DropPrefix([]byte(prefix), []byte(_interface.HashKeyPrefix+prefix)); err != nil {
+		return err
+	}
+	if prefix != "" {
+		return nil
+	}
+	// 数据库已经清空，重新写入新编码的标记
+	if err := b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(_interface.HashEncodingMarker), nil)
+	}); err != nil {
+		return err
+	}
+	b.legacyHash.Store(false)
+	return nil
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或已过期时返回ErrKeyNotFound
+func (b *BadgerDb) HGet(key, field string) (string, error) {
+	val, err := b.Get(_interface.HashKey(key, field))
+	if errors.Is(err, _interface.ErrKeyNotFound) && b.legacyHash.Load() {
+		return b.Get(_interface.LegacyHashKey(key, field))
+	}
+	return val, err
+}
+
+// HSet 设置哈希表中的field-value，过期时间只作用于该字段
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 字段的过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) HSet(key, field, value string, ttl time.Duration) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return b.hset(txn, key, field, value, ttl)
+	})
+}
+
+// HDel 删除哈希表中的field，字段不存在时不报错
+func (b *BadgerDb) HDel(key, field string) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return b.hdel(txn, key, field)
+	})
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有未过期的字段和值的映射
+//	error - 操作错误
+func (b *BadgerDb) HGetAll(key string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := b.db.View(func(txn *badger.Txn) error {
+		return b.iterateHash(txn, key, true, func(field string, item *badger.Item) error {
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			result[field] = string(val)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// HLen 获取哈希表中未过期的field数量，哈希表不存在时返回0
+func (b *BadgerDb) HLen(key string) (int64, error) {
+	fields, err := b.HKeys(key)
+	return int64(len(fields)), err
+}
+
+// HExists 判断哈希表中是否存在未过期的field
+func (b *BadgerDb) HExists(key, field string) (bool, error) {
+	_, err := b.HGet(key, field)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HKeys 获取哈希表中所有未过期的field，按字段名排序
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (b *BadgerDb) HKeys(key string) ([]string, error) {
+	seen := make(map[string]struct{})
+	err := b.db.View(func(txn *badger.Txn) error {
+		return b.iterateHash(txn, key, false, func(field string, _ *badger.Item) error {
+			seen[field] = struct{}{}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(seen))
+	for field := range seen {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// hset 在事务中写入哈希表字段，兼容模式下同时删除同名的旧字段
+func (b *BadgerDb) hset(txn *badger.Txn, key, field, value string, ttl time.Duration) error {
+	e := badger.NewEntry([]byte(_interface.HashKey(key, field)), []byte(value))
+	if ttl > 0 {
+		e.WithTTL(ttl)
+	}
+	if err := txn.SetEntry(e); err != nil {
+		return err
+	}
+	if b.legacyHash.Load() {
+		return txn.Delete([]byte(_interface.LegacyHashKey(key, field)))
+	}
+	return nil
 }
 
-func (b *BadgerDb) Exists(key string) (bool, error) {
-	err := b.db.View(func(txn *badger.Txn) error {
-		_, err := txn.Get([]byte(key))
+// hdel 在事务中删除哈希表字段，兼容模式下同时删除同名的旧字段
+func (b *BadgerDb) hdel(txn *badger.Txn, key, field string) error {
+	if err := txn.Delete([]byte(_interface.HashKey(key, field))); err != nil {
 		return err
-	})
-	if errors.Is(err, badger.ErrKeyNotFound) {
-		return false, nil
 	}
-	return err == nil, err
+	if b.legacyHash.Load() {
+		return txn.Delete([]byte(_interface.LegacyHashKey(key, field)))
+	}
+	return nil
 }
 
-func (b *BadgerDb) Expire(key string, ttl time.Duration) error {
-	// 实现逻辑：先获取旧值，再重新设置 TTL
-	return b.db.Update(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil {
+// iterateHash 遍历哈希表未过期的字段，fn的参数为字段名和保存字段的条目
+// 兼容模式下先遍历key:field形式的旧字段，同名的新字段随后遍历，调用方按字段名覆盖即可
+func (b *BadgerDb) iterateHash(txn *badger.Txn, key string, prefetch bool, fn func(field string, item *badger.Item) error) error {
+	prefixes := [][]byte{[]byte(_interface.HashFieldPrefix(key))}
+	if b.legacyHash.Load() {
+		prefixes = append([][]byte{[]byte(_interface.LegacyHashKey(key, ""))}, prefixes...)
+	}
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = prefetch
+	for _, prefix := range prefixes {
+		if err := func() error {
+			it := txn.NewIterator(opts)
+			defer it.Close()
+
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				if err := fn(string(bytes.TrimPrefix(item.Key(), prefix)), item); err != nil {
+					return err
+				}
+			}
+			return nil
+		}(); err != nil {
 			return err
 		}
-		val, err := item.ValueCopy(nil)
-		if err != nil {
-			return err
+	}
+	return nil
+}
+
+// hashTTL 返回哈希表字段中最长的剩余过期时间，任意字段没有过期时间时返回TTLNoExpiry
+func (b *BadgerDb) hashTTL(txn *badger.Txn, key string) time.Duration {
+	ttl := _interface.TTLNotFound
+	_ = b.iterateHash(txn, key, false, func(_ string, item *badger.Item) error {
+		if ttl == _interface.TTLNoExpiry {
+			return nil
 		}
-		e := badger.NewEntry([]byte(key), val).WithTTL(ttl)
-		return txn.SetEntry(e)
+		if d := remainingTTL(item.ExpiresAt()); d == _interface.TTLNoExpiry || d > ttl {
+			ttl = d
+		}
+		return nil
 	})
+	return ttl
 }
 
-func (b *BadgerDb) HGet(key, field string) (string, error) {
-	compositeKey := key + ":" + field
-	return b.Get(compositeKey)
+// initHashEncoding 检查哈希表字段的编码
+// 数据库中有新编码的标记，或者数据库为空（写入标记）时只使用新编码，否则进入兼容模式；只读模式下不写入标记
+func (b *BadgerDb) initHashEncoding(readOnly bool) error {
+	var empty bool
+	err := b.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(_interface.HashEncodingMarker))
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Rewind()
+		empty = !it.Valid()
+		b.legacyHash.Store(!empty)
+		return nil
+	})
+	if err != nil || !empty || readOnly {
+		return err
+	}
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(_interface.HashEncodingMarker), nil)
+	})
 }
-func (b *BadgerDb) HSet(key, field, value string, ttl time.Duration) error {
-	compositeKey := key + ":" + field
-	return b.Set(compositeKey, value, ttl)
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) SAdd(key, member string) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(setKey(key, member), nil)
+	})
 }
 
-func (b *BadgerDb) HDel(key, field string) error {
-	compositeKey := key + ":" + field
-	return b.Delete(compositeKey)
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) SRem(key, member string) error {
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(setKey(key, member))
+	})
 }
 
-func (b *BadgerDb) HGetAll(key string) (map[string]string, error) {
-	result := make(map[string]string)
-	prefix := []byte(key + ":")
+// SMembers 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (b *BadgerDb) SMembers(key string) ([]string, error) {
+	members := []string{}
+	prefix := setKey(key, "")
 
 	err := b.db.View(func(txn *badger.Txn) error {
-		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
 		defer it.Close()
 
 		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			item := it.Item()
-			field := string(bytes.TrimPrefix(item.Key(), prefix))
-			val, err := item.ValueCopy(nil)
-			if err != nil {
-				return err
-			}
-			result[field] = string(val)
+			members = append(members, string(it.Item().Key()[len(prefix):]))
 		}
 		return nil
 	})
+	return members, err
+}
 
-	return result, err
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (b *BadgerDb) SIsMember(key, member string) (bool, error) {
+	err := b.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(setKey(key, member))
+		return err
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// setKey 生成集合成员对应的复合键，使用\x00分隔，避免与普通键冲突
+func setKey(key, member string) []byte {
+	return []byte(key + "\x00" + member)
 }
 
 type badgerTx struct {
+	b   *BadgerDb
 	txn *badger.Txn
 }
 
+func (tx *badgerTx) Get(key string) (string, error) {
+	return txnGet(tx.txn, []byte(key))
+}
+
+func (tx *badgerTx) Exists(key string) (bool, error) {
+	_, err := tx.txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (tx *badgerTx) Set(key string, value string, ttl time.Duration) error {
 	e := badger.NewEntry([]byte(key), []byte(value))
 	if ttl > 0 {
@@ -510,8 +1344,35 @@ func (tx *badgerTx) Set(key string, value string, ttl time.Duration) error {
 func (tx *badgerTx) Delete(key string) error {
 	return tx.txn.Delete([]byte(key))
 }
+
+// Expire 使用新的过期时间重新写入当前值，key 不存在时不做任何操作
+func (tx *badgerTx) Expire(key string, ttl time.Duration) error {
+	val, err := tx.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Set(key, val, ttl)
+}
+
+// HSet 哈希表字段与非事务的HSet一样保存为独立的复合键
+func (tx *badgerTx) HSet(key, field, value string, ttl time.Duration) error {
+	return tx.b.hset(tx.txn, key, field, value, ttl)
+}
+
+func (tx *badgerTx) HDel(key, field string) error {
+	return tx.b.hdel(tx.txn, key, field)
+}
+
+// Commit 提交事务，事务内读取过的key被并发的事务修改时返回ErrTxConflict
 func (tx *badgerTx) Commit() error {
-	return tx.txn.Commit()
+	err := tx.txn.Commit()
+	if errors.Is(err, badger.ErrConflict) {
+		return _interface.ErrTxConflict
+	}
+	return err
 }
 
 func (tx *badgerTx) Rollback() error {
@@ -519,8 +1380,312 @@ func (tx *badgerTx) Rollback() error {
 	return nil
 }
 
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) Publish(channel string, payload string) error {
+	return b.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BadgerDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return b.broker.Subscribe(channel)
+}
+
+// SubscribeExpired 订阅键过期事件
+// 订阅期间后台每秒扫描一次所有键的过期时间；BadgerDB的过期时间精确到秒，事件最多延迟约2秒。
+// 哈希表字段过期时通知哈希表的key，队列元素同样保存为独立的键，它们过期时也会通知
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BadgerDb) SubscribeExpired() (<-chan string, func()) {
+	return b.expiry.Subscribe(b.sweepExpired)
+}
+
+// sweepExpired 返回过期时间（Unix秒）在[since, until)之间的键
+// 过期的键对普通迭代不可见，因此使用AllVersions读取每个键的最新版本；被删除的键最新版本没有过期时间，不会被通知
+func (b *BadgerDb) sweepExpired(since, until time.Time) []string {
+	lower, upper := uint64(since.Unix()), uint64(until.Unix())
+	if lower >= upper {
+		return nil
+	}
+
+	var keys []string
+	_ = b.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.AllVersions = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var last []byte
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			if last != nil && bytes.Equal(item.Key(), last) {
+				continue // 同一个键的旧版本
+			}
+			last = item.KeyCopy(last)
+			if expiresAt := item.ExpiresAt(); expiresAt >= lower && expiresAt < upper {
+				key := string(last)
+				if hash, _, ok := _interface.ParseHashKey(key); ok {
+					key = hash
+				}
+				keys = append(keys, key)
+			}
+		}
+		return nil
+	})
+	return keys
+}
+
 func (b *BadgerDb) BeginTx() (_interface.Tx, error) {
-	return &badgerTx{txn: b.db.NewTransaction(true)}, nil // 读写事务
+	return &badgerTx{b: b, txn: b.db.NewTransaction(true)}, nil // 读写事务
+}
+
+// BeginTxWatch 开启监视keys的事务
+// BadgerDB是乐观事务，开启时在事务内读取keys，提交前keys被其他事务修改时BadgerDB检测到冲突，Commit返回ErrTxConflict
+func (b *BadgerDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	txn := b.db.NewTransaction(true)
+	for _, key := range keys {
+		if _, err := txn.Get([]byte(key)); err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			txn.Discard()
+			return nil, err
+		}
+	}
+	return &badgerTx{b: b, txn: txn}, nil
+}
+
+// BeginTxOptions 按选项开启事务
+// BadgerDB的事务基于快照，读写事务提交时检测读写冲突，可以满足所有隔离级别；
+// 只读事务读取开启时的快照，不参与冲突检测
+func (b *BadgerDb) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !opts.ReadOnly {
+		return b.BeginTx()
+	}
+	return _interface.ReadOnlyTx(&badgerTx{b: b, txn: b.db.NewTransaction(false)}), nil
+}
+
+// Stats 返回BadgerDB的统计信息
+// 键数量为各层SST文件中的键数之和，不包括尚未落盘的内存表，且包含旧版本和哈希表、队列的复合键
+// 返回值：
+//
+//	Stats - 统计信息，LSM树各层的字节数无法获取，为StatsUnknown
+//	error - 操作错误
+func (b *BadgerDb) Stats() (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverBadger)
+	lsm, vlog := b.db.Size()
+	stats.DiskBytes = lsm + vlog
+	stats.Extra = map[string]string{
+		"lsm_bytes":  strconv.FormatInt(lsm, 10),
+		"vlog_bytes": strconv.FormatInt(vlog, 10),
+	}
+
+	var keys int64
+	for _, table := range b.db.Tables(true) {
+		keys += int64(table.KeyCount)
+		for len(stats.Levels) <= table.Level {
+			stats.Levels = append(stats.Levels, _interface.LevelStats{Level: len(stats.Levels), Bytes: _interface.StatsUnknown})
+		}
+		stats.Levels[table.Level].Tables++
+	}
+	stats.Keys = keys
+	return stats, nil
+}
+
+// maxBackupList 备份流中单个KVList的最大字节数，避免损坏的数据导致分配过大的内存
+const maxBackupList = 1 << 30
+
+// bitDelete BadgerDB备份中删除标记的元数据位，与badger内部的定义一致
+const bitDelete byte = 1 << 0
+
+// Backup 使用BadgerDB的原生格式备份所有数据，已删除和已过期的键不会写入
+// 参数：
+//
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BadgerDb) Backup(w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverBadger)
+	if err != nil {
+		return err
+	}
+	if _, err := b.db.Backup(bw, 0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore 读取BadgerDB原生格式的备份，以新的版本写入每个key的最新值，键的过期时间保持备份时的值
+// DB.Load会保留备份中的版本号，恢复到已有数据的数据库时会被更新的版本覆盖，因此这里自行解析备份流
+// 参数：
+//
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是BadgerDB的备份时返回ErrBackupFormat
+func (b *BadgerDb) Restore(r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer b.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverBadger)
+	if err != nil {
+		return err
+	}
+	wb := b.db.NewWriteBatch()
+	defer wb.Cancel()
+
+	now := uint64(time.Now().Unix())
+	var last []byte
+	var restored, marked bool
+	for {
+		// 原生格式由小端序的长度和protobuf编码的KVList组成
+		var size uint64
+		if err := binary.Read(br, binary.LittleEndian, &size); err == io.EOF {
+			break
+		} else if err != nil || size > maxBackupList {
+			return _interface.ErrBackupFormat
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return _interface.ErrBackupFormat
+		}
+		var list pb.KVList
+		if err := list.Unmarshal(buf); err != nil {
+			return _interface.ErrBackupFormat
+		}
+
+		// 同一个key的多个版本按版本从新到旧排列，只恢复最新的版本
+		for _, kv := range list.Kv {
+			if bytes.Equal(kv.Key, last) {
+				continue
+			}
+			last = kv.Key
+			if (len(kv.Meta) > 0 && kv.Meta[0]&bitDelete != 0) || (kv.ExpiresAt > 0 && kv.ExpiresAt <= now) {
+				continue
+			}
+			e := &badger.Entry{Key: kv.Key, Value: kv.Value, ExpiresAt: kv.ExpiresAt}
+			if len(kv.UserMeta) > 0 {
+				e.UserMeta = kv.UserMeta[0]
+			}
+			if err := wb.SetEntry(e); err != nil {
+				return err
+			}
+			restored = true
+			marked = marked || string(kv.Key) == _interface.HashEncodingMarker
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+	if !restored || marked {
+		return nil
+	}
+	// 旧版本的备份没有新编码的标记，其中的哈希表字段是key:field形式，恢复后进入兼容模式
+	b.legacyHash.Store(true)
+	return b.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(_interface.HashEncodingMarker))
+	})
+}
+
+const (
+	// defaultGCInterval 后台值日志垃圾回收的默认间隔
+	defaultGCInterval = 10 * time.Minute
+	// defaultGCRatio 默认的可回收比例，与Badger文档推荐的值一致
+	defaultGCRatio = 0.5
+)
+
+// runGC 定期回收值日志，每次运行到没有可重写的文件为止
+func (b *BadgerDb) runGC(interval time.Duration) {
+	defer close(b.gcDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.gcStop:
+			return
+		case <-ticker.C:
+			for b.db.RunValueLogGC(b.gcRatio) == nil {
+				select {
+				case <-b.gcStop:
+					return
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Compact 手动压缩LSM树并回收值日志，释放已删除和已过期数据占用的磁盘空间
+// 压缩期间会暂停后台合并，建议在访问量低时调用
+// 返回值：
+//
+//	error - 操作错误，没有可回收的数据时返回nil，后台垃圾回收正在运行时返回badger.ErrRejected
+func (b *BadgerDb) Compact() error {
+	if err := b.db.Flatten(1); err != nil {
+		return err
+	}
+	for {
+		err := b.db.RunValueLogGC(b.gcRatio)
+		if errors.Is(err, badger.ErrNoRewrite) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// badgerOptions 将配置转换为badger.Options，未设置的调优选项保持Badger的默认值
+func badgerOptions(config config.Cache) badger.Options {
+	tuning := config.Badger
+	opts := badger.DefaultOptions(config.Path).
+		WithLogger(nil).                   // 禁用日志以提高性能
+		WithSyncWrites(tuning.SyncWrites). // 默认异步写入提高性能
+		WithTruncate(true).                // 启动时清理损坏的数据，只读模式下Badger会忽略
+		WithReadOnly(tuning.ReadOnly)
+	if tuning.LoadTablesToRAM {
+		opts = opts.WithTableLoadingMode(options.LoadToRAM)
+	}
+	if tuning.MaxTableSize > 0 {
+		opts = opts.WithMaxTableSize(tuning.MaxTableSize)
+	}
+	if tuning.NumMemtables > 0 {
+		opts = opts.WithNumMemtables(tuning.NumMemtables)
+	}
+	if tuning.ValueThreshold > 0 {
+		opts = opts.WithValueThreshold(tuning.ValueThreshold)
+	}
+	if tuning.ValueLogFileSize > 0 {
+		opts = opts.WithValueLogFileSize(tuning.ValueLogFileSize)
+	}
+	if tuning.NumVersionsToKeep > 0 {
+		opts = opts.WithNumVersionsToKeep(tuning.NumVersionsToKeep)
+	}
+	if tuning.NumCompactors > 0 {
+		opts = opts.WithNumCompactors(tuning.NumCompactors)
+	}
+	return opts
 }
 
 // NewBadgerStore 创建BadgerDB缓存实例的工厂函数
@@ -533,13 +1698,38 @@ func (b *BadgerDb) BeginTx() (_interface.Tx, error) {
 //	Cache - 缓存接口实例
 //	error - 创建错误
 func NewBadgerStore(config config.Cache) (_interface.Cache, error) {
-	opts := badger.DefaultOptions(config.Path).
-		WithLogger(nil).       // 禁用日志以提高性能
-		WithSyncWrites(false). // 异步写入提高性能
-		WithTruncate(true)     // 启动时清理损坏的数据
-	db, err := badger.Open(opts)
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	db, err := badger.Open(badgerOptions(config))
 	if err != nil {
 		return nil, err
 	}
-	return &BadgerDb{db: db}, nil
+
+	b := &BadgerDb{
+		db:      db,
+		codec:   codec,
+		gcRatio: config.ValueLogGCRatio,
+		gcStop:  make(chan struct{}),
+		gcDone:  make(chan struct{}),
+	}
+	b.CtxAdapter = _interface.NewCtxAdapter(b)
+	if b.gcRatio <= 0 || b.gcRatio >= 1 {
+		b.gcRatio = defaultGCRatio
+	}
+	interval := config.ValueLogGCInterval
+	if interval == 0 {
+		interval = defaultGCInterval
+	}
+	if err := b.initHashEncoding(config.Badger.ReadOnly); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if interval > 0 && !config.Badger.ReadOnly {
+		go b.runGC(interval)
+	} else {
+		close(b.gcDone)
+	}
+	return b, nil
 }
@@ -0,0 +1,1388 @@
+// bbolt包：基于bbolt的单文件缓存实现
+// 提供键值存储、哈希表操作、队列操作和事务支持
+//
+// bbolt是etcd维护的BoltDB分支，纯Go实现的B+树Key-Value存储，经过大量生产环境验证
+// 本包实现了Cache接口，提供统一的缓存操作API
+//
+// 主要特性：
+// - 单文件存储，纯Go实现
+// - 支持TTL过期（过期时间编码在值中，读取时过滤）
+// - 哈希表操作（每个哈希表对应一个子bucket，HGetAll直接遍历bucket）
+// - 队列操作（每个队列对应一个子bucket，尾部位置由bucket序列号生成）
+// - 集合操作（每个集合对应一个子bucket，成员为键）
+// - 事务支持（读写事务）
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+// - 线程安全，写事务串行执行
+//
+// bucket结构：
+// - kv：键值数据
+// - hash/<key>：哈希表数据，field为键
+// - list/<key>：队列数据，8字节有序位置为键
+// - set/<key>：集合数据，member为键
+//
+// 作者: gophertool
+package bbolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// 包初始化时注册bbolt驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverBbolt, NewBboltStore)
+}
+
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
+// 顶层bucket名称
+var (
+	kvBucket   = []byte("kv")
+	hashBucket = []byte("hash")
+	listBucket = []byte("list")
+	setBucket  = []byte("set")
+)
+
+// BboltDb bbolt缓存实现结构体
+type BboltDb struct {
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db         *bolt.DB                  // bbolt实例
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*BboltDb)(nil)
+
+// 确保原生支持事务选项
+var _ _interface.TxBeginner = (*BboltDb)(nil)
+
+// Close 关闭数据库
+func (b *BboltDb) Close() {
+	b.expiry.Close()
+	_ = b.db.Close()
+	b.broker.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (b *BboltDb) Get(key string) (string, error) {
+	var val string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		v, ok := decodeValue(tx.Bucket(kvBucket).Get([]byte(key)))
+		if !ok {
+			return _interface.ErrKeyNotFound
+		}
+		val = v
+		return nil
+	})
+	return val, err
+}
+
+func (b *BboltDb) Set(key string, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(kvBucket).Put([]byte(key), encodeValue(value, ttl))
+	})
+}
+
+func (b *BboltDb) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(kvBucket).Delete([]byte(key))
+	})
+}
+
+func (b *BboltDb) Exists(key string) (bool, error) {
+	_, err := b.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Expire 设置key的过期时间
+// 参数：
+//
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (b *BboltDb) Expire(key string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		val, ok := decodeValue(bucket.Get([]byte(key)))
+		if !ok {
+			return _interface.ErrKeyNotFound
+		}
+		return bucket.Put([]byte(key), encodeValue(val, ttl))
+	})
+}
+
+// TTL 获取key的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在或已过期时返回TTLNotFound
+//	error - 操作错误
+func (b *BboltDb) TTL(key string) (time.Duration, error) {
+	var ttl time.Duration
+	err := b.db.View(func(tx *bolt.Tx) error {
+		ttl = remainingTTL(tx.Bucket(kvBucket).Get([]byte(key)))
+		return nil
+	})
+	return ttl, err
+}
+
+// SetNX key不存在或已过期时设置key-value并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (b *BboltDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		if _, exists := decodeValue(bucket.Get([]byte(key))); exists {
+			return nil
+		}
+		ok = true
+		return bucket.Put([]byte(key), encodeValue(value, ttl))
+	})
+	return ok && err == nil, err
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (b *BboltDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		if current, exists := decodeValue(bucket.Get([]byte(key))); !exists || current != oldValue {
+			return nil
+		}
+		ok = true
+		return bucket.Put([]byte(key), encodeValue(newValue, ttl))
+	})
+	return ok && err == nil, err
+}
+
+// GetSet 设置新值并返回旧值，新值不过期
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (b *BboltDb) GetSet(key string, value string) (string, error) {
+	var old string
+	var found bool
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		old, found = decodeValue(bucket.Get([]byte(key)))
+		return bucket.Put([]byte(key), encodeValue(value, 0))
+	})
+	if err == nil && !found {
+		return "", _interface.ErrKeyNotFound
+	}
+	return old, err
+}
+
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (b *BboltDb) GetDel(key string) (string, error) {
+	var val string
+	var found bool
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		val, found = decodeValue(bucket.Get([]byte(key)))
+		return bucket.Delete([]byte(key))
+	})
+	if err == nil && !found {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// Append 在同一个事务中读取key的值并追加value，key不存在时创建不过期的key，已有的过期时间保持不变
+// 参数：
+//
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (b *BboltDb) Append(key string, value string) (int64, error) {
+	var n int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(kvBucket)
+		data := bucket.Get([]byte(key))
+		if _, ok := decodeValue(data); ok {
+			// 保留编码在值开头的过期时间，bbolt返回的切片在事务内不能修改
+			data = append(bytes.Clone(data), value...)
+		} else {
+			data = encodeValue(value, 0)
+		}
+		n = int64(len(data) - 8)
+		return bucket.Put([]byte(key), data)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在或已过期时返回0
+func (b *BboltDb) StrLen(key string) (int64, error) {
+	var n int64
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(kvBucket).Get([]byte(key))
+		if _, ok := decodeValue(data); ok {
+			n = int64(len(data) - 8)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (b *BboltDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := b.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (b *BboltDb) GetObject(key string, v any) error {
+	raw, err := b.Get(key)
+	if err != nil {
+		return err
+	}
+	return b.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys 遍历匹配pattern的key，通配符语法与Redis一致
+// 只遍历键值，不包括哈希表、队列和集合；每批读取scanBatchSize个key后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) Keys(pattern string, fn func(key string) bool) error {
+	prefix := []byte(_interface.PatternPrefix(pattern))
+	start := prefix
+	for {
+		var keys []string
+		var next []byte
+		err := b.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(kvBucket).Cursor()
+			scanned := 0
+			for k, v := c.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+				if scanned == scanBatchSize {
+					next = bytes.Clone(k)
+					break
+				}
+				scanned++
+				if _, ok := decodeValue(v); ok {
+					keys = append(keys, string(k))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		start = next
+	}
+}
+
+// Iterate 遍历键名以prefix开头的键值
+// 只遍历键值，不包括哈希表、队列和集合；每批读取scanBatchSize个键值后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	start := []byte(prefix)
+	for {
+		var keys, values []string
+		var next []byte
+		err := b.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(kvBucket).Cursor()
+			scanned := 0
+			for k, v := c.Seek(start); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = c.Next() {
+				if scanned == scanBatchSize {
+					next = bytes.Clone(k)
+					break
+				}
+				scanned++
+				if value, ok := decodeValue(v); ok {
+					keys = append(keys, string(k))
+					values = append(values, value)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, key := range keys {
+			if !fn(key, values[i]) {
+				return nil
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		start = next
+	}
+}
+
+// DeleteByPrefix 删除所有以prefix开头的key，包括键值、哈希表、队列和集合，在同一个事务中完成
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) DeleteByPrefix(prefix string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{kvBucket, hashBucket, listBucket, setBucket} {
+			bucket := tx.Bucket(name)
+			// 遍历期间删除会使游标失效，先收集再删除
+			var keys [][]byte
+			c := bucket.Cursor()
+			for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+				keys = append(keys, bytes.Clone(k))
+			}
+			for _, k := range keys {
+				var err error
+				if bytes.Equal(name, kvBucket) {
+					err = bucket.Delete(k)
+				} else {
+					err = bucket.DeleteBucket(k)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或已过期时返回ErrKeyNotFound
+func (b *BboltDb) HGet(key, field string) (string, error) {
+	var val string
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(hashBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return _interface.ErrKeyNotFound
+		}
+		v, ok := decodeValue(bucket.Get([]byte(field)))
+		if !ok {
+			return _interface.ErrKeyNotFound
+		}
+		val = v
+		return nil
+	})
+	return val, err
+}
+
+func (b *BboltDb) HSet(key, field, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(hashBucket).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(field), encodeValue(value, ttl))
+	})
+}
+
+// HDel 删除哈希表中的field，字段全部删除后子bucket也会被删除
+func (b *BboltDb) HDel(key, field string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(hashBucket)
+		bucket := parent.Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		if err := bucket.Delete([]byte(field)); err != nil {
+			return err
+		}
+		if k, _ := bucket.Cursor().First(); k == nil {
+			return parent.DeleteBucket([]byte(key))
+		}
+		return nil
+	})
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有未过期的字段和值的映射
+//	error - 操作错误
+func (b *BboltDb) HGetAll(key string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(hashBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if val, ok := decodeValue(v); ok {
+				result[string(k)] = val
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+// HLen 获取哈希表中未过期的field数量，哈希表不存在时返回0
+func (b *BboltDb) HLen(key string) (int64, error) {
+	fields, err := b.HKeys(key)
+	return int64(len(fields)), err
+}
+
+// HExists 判断哈希表中是否存在未过期的field
+func (b *BboltDb) HExists(key, field string) (bool, error) {
+	_, err := b.HGet(key, field)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HKeys 获取哈希表中所有未过期的field，按字段名排序
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (b *BboltDb) HKeys(key string) ([]string, error) {
+	fields := []string{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(hashBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if _, ok := decodeValue(v); ok {
+				fields = append(fields, string(k))
+			}
+			return nil
+		})
+	})
+	return fields, err
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) SAdd(key, member string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(setBucket).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(member), nil)
+	})
+}
+
+// SRem 从集合删除成员，成员全部删除后子bucket也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) SRem(key, member string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(setBucket)
+		bucket := parent.Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		if err := bucket.Delete([]byte(member)); err != nil {
+			return err
+		}
+		if k, _ := bucket.Cursor().First(); k == nil {
+			return parent.DeleteBucket([]byte(key))
+		}
+		return nil
+	})
+}
+
+// SMembers 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (b *BboltDb) SMembers(key string) ([]string, error) {
+	members := []string{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(setBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			members = append(members, string(k))
+			return nil
+		})
+	})
+	return members, err
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (b *BboltDb) SIsMember(key, member string) (bool, error) {
+	var ok bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(setBucket).Bucket([]byte(key)); bucket != nil {
+			ok = bucket.Get([]byte(member)) != nil
+		}
+		return nil
+	})
+	return ok, err
+}
+
+// Push 添加元素到列表尾部
+func (b *BboltDb) Push(key string, value string) error {
+	return b.RPush(key, value)
+}
+
+// LPush 将元素插入到列表头部，位置为当前头部元素的位置减1
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) LPush(key string, value string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(listBucket).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		var pos int64
+		if k, _ := bucket.Cursor().First(); k != nil {
+			pos = decodePos(k) - 1
+		}
+		return bucket.Put(encodePos(pos), []byte(value))
+	})
+}
+
+// RPush 将元素插入到列表尾部，位置由bucket的序列号生成，始终大于已有元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) RPush(key string, value string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(listBucket).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(encodePos(int64(seq)), []byte(value))
+	})
+}
+
+// RPushCapped 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) RPushCapped(key string, value string, maxLen int64) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(listBucket).CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(encodePos(int64(seq)), []byte(value)); err != nil {
+			return err
+		}
+		if maxLen <= 0 {
+			return nil
+		}
+
+		var positions [][]byte
+		if err := bucket.ForEach(func(k, _ []byte) error {
+			positions = append(positions, append([]byte{}, k...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, pos := range positions[:max(int64(len(positions))-maxLen, 0)] {
+			if err := bucket.Delete(pos); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Pop 移除并返回列表第一个元素
+func (b *BboltDb) Pop(key string) (string, error) {
+	return b.LPop(key)
+}
+
+// LPop 弹出列表头部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BboltDb) LPop(key string) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+	return b.pop(key, true)
+}
+
+// RPop 弹出列表尾部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BboltDb) RPop(key string) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+	return b.pop(key, false)
+}
+
+// PopAll 取出并清空整个列表
+func (b *BboltDb) PopAll(key string) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		parent := tx.Bucket(listBucket)
+		bucket := parent.Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		if err := bucket.ForEach(func(_, v []byte) error {
+			result = append(result, string(v))
+			return nil
+		}); err != nil {
+			return err
+		}
+		return parent.DeleteBucket([]byte(key))
+	})
+	return result, err
+}
+
+// Len 获取列表长度
+func (b *BboltDb) Len(key string) (int64, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return 0, err
+	}
+
+	var length int64
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(listBucket).Bucket([]byte(key)); bucket != nil {
+			length = int64(bucket.Stats().KeyN)
+		}
+		return nil
+	})
+	return length, err
+}
+
+// LRange 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (b *BboltDb) LRange(key string, start, stop int64) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(listBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		lo, hi := _interface.ListRange(start, stop, int64(bucket.Stats().KeyN))
+		var i int64
+		cursor := bucket.Cursor()
+		for k, v := cursor.First(); k != nil && i < hi; k, v = cursor.Next() {
+			if i >= lo {
+				result = append(result, string(v))
+			}
+			i++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// LIndex 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (b *BboltDb) LIndex(key string, index int64) (string, error) {
+	items, err := b.LRange(key, index, index)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", _interface.ErrKeyNotFound
+	}
+	return items[0], nil
+}
+
+// LRem 删除列表中等于value的元素
+// 保留的元素按顺序写回原有的前len(kept)个位置，多余的位置被删除，元素之间的相对顺序不变
+// 参数：
+//
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (b *BboltDb) LRem(key string, count int64, value string) (int64, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(listBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		var positions [][]byte
+		var items []string
+		if err := bucket.ForEach(func(k, v []byte) error {
+			positions = append(positions, append([]byte{}, k...))
+			items = append(items, string(v))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		var kept []string
+		kept, removed = _interface.ListRem(items, count, value)
+		for i, pos := range positions {
+			var err error
+			if i < len(kept) {
+				err = bucket.Put(pos, []byte(kept[i]))
+			} else {
+				err = bucket.Delete(pos)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// LTrim 只保留列表中下标在[start, stop]范围内的元素
+func (b *BboltDb) LTrim(key string, start, stop int64) error {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(listBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		var positions [][]byte
+		if err := bucket.ForEach(func(k, _ []byte) error {
+			positions = append(positions, append([]byte{}, k...))
+			return nil
+		}); err != nil {
+			return err
+		}
+		lo, hi := _interface.ListRange(start, stop, int64(len(positions)))
+		for i, pos := range positions {
+			if int64(i) >= lo && int64(i) < hi {
+				continue
+			}
+			if err := bucket.Delete(pos); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PopAck 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，进程重启后仍然有效
+// 参数：
+//
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BboltDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return b.ackQueue.PopAck(b, key, visibility)
+}
+
+// Ack 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (b *BboltDb) Ack(key, receipt string) error {
+	return b.ackQueue.Ack(b, key, receipt)
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，进程重启后仍然有效；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) PushDelayed(key, value string, delay time.Duration) error {
+	return b.delayQueue.PushDelayed(b, key, value, delay)
+}
+
+// pop 删除并返回列表头部（head为true）或尾部的元素
+// 列表为空时保留子bucket，避免队列频繁进出时反复创建和删除bucket
+func (b *BboltDb) pop(key string, head bool) (string, error) {
+	var val string
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(listBucket).Bucket([]byte(key))
+		if bucket == nil {
+			return _interface.ErrKeyNotFound
+		}
+		cursor := bucket.Cursor()
+		var k, v []byte
+		if head {
+			k, v = cursor.First()
+		} else {
+			k, v = cursor.Last()
+		}
+		if k == nil {
+			return _interface.ErrKeyNotFound
+		}
+		val = string(v)
+		return cursor.Delete()
+	})
+	return val, err
+}
+
+// boltTx bbolt事务实现
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+func (tx *boltTx) Get(key string) (string, error) {
+	val, ok := decodeValue(tx.tx.Bucket(kvBucket).Get([]byte(key)))
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+func (tx *boltTx) Exists(key string) (bool, error) {
+	_, ok := decodeValue(tx.tx.Bucket(kvBucket).Get([]byte(key)))
+	return ok, nil
+}
+
+func (tx *boltTx) Set(key string, value string, ttl time.Duration) error {
+	return tx.tx.Bucket(kvBucket).Put([]byte(key), encodeValue(value, ttl))
+}
+
+func (tx *boltTx) Delete(key string) error {
+	return tx.tx.Bucket(kvBucket).Delete([]byte(key))
+}
+
+// Expire key 不存在时不做任何操作
+func (tx *boltTx) Expire(key string, ttl time.Duration) error {
+	bucket := tx.tx.Bucket(kvBucket)
+	val, ok := decodeValue(bucket.Get([]byte(key)))
+	if !ok {
+		return nil
+	}
+	return bucket.Put([]byte(key), encodeValue(val, ttl))
+}
+
+func (tx *boltTx) HSet(key, field, value string, ttl time.Duration) error {
+	bucket, err := tx.tx.Bucket(hashBucket).CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(field), encodeValue(value, ttl))
+}
+
+// HDel 与非事务的HDel一样，字段全部删除后子bucket也会被删除
+func (tx *boltTx) HDel(key, field string) error {
+	parent := tx.tx.Bucket(hashBucket)
+	bucket := parent.Bucket([]byte(key))
+	if bucket == nil {
+		return nil
+	}
+	if err := bucket.Delete([]byte(field)); err != nil {
+		return err
+	}
+	if k, _ := bucket.Cursor().First(); k == nil {
+		return parent.DeleteBucket([]byte(key))
+	}
+	return nil
+}
+
+func (tx *boltTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx *boltTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) Publish(channel string, payload string) error {
+	return b.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BboltDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return b.broker.Subscribe(channel)
+}
+
+// SubscribeExpired 订阅键值数据的过期事件
+// 订阅期间后台每秒扫描一次键值数据的过期时间；哈希表的过期不通知
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BboltDb) SubscribeExpired() (<-chan string, func()) {
+	return b.expiry.Subscribe(b.sweepExpired)
+}
+
+// sweepExpired 返回过期时间在(since, until]之间的键
+// 读取时已经过滤了过期数据，这里只读取不删除
+func (b *BboltDb) sweepExpired(since, until time.Time) []string {
+	lower, upper := uint64(since.UnixNano()), uint64(until.UnixNano())
+	var keys []string
+	_ = b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(kvBucket).ForEach(func(k, v []byte) error {
+			if len(v) >= 8 {
+				if expiresAt := binary.BigEndian.Uint64(v); expiresAt > lower && expiresAt <= upper {
+					keys = append(keys, string(k))
+				}
+			}
+			return nil
+		})
+	})
+	return keys
+}
+
+func (b *BboltDb) BeginTx() (_interface.Tx, error) {
+	tx, err := b.db.Begin(true)
+	if err != nil {
+		return nil, err
+	}
+	return &boltTx{tx: tx}, nil
+}
+
+// BeginTxWatch bbolt的读写事务独占数据库，提交之前keys不会被修改，与BeginTx相同
+func (b *BboltDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return b.BeginTx()
+}
+
+// BeginTxOptions 按选项开启事务
+// bbolt同一时间只有一个写事务，可以满足所有隔离级别；只读事务读取开启时的快照，多个只读事务可以并发执行
+func (b *BboltDb) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !opts.ReadOnly {
+		return b.BeginTx()
+	}
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return _interface.ReadOnlyTx(&boltTx{tx: tx}), nil
+}
+
+// encodePos 将位置编码为8字节大端序并翻转符号位，使负数排在正数之前
+func encodePos(pos int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(pos)^(1<<63))
+}
+
+func decodePos(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63))
+}
+
+// encodeValue 编码值，前8字节为过期时间（Unix纳秒，0表示不过期）
+func encodeValue(value string, ttl time.Duration) []byte {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+	b := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(expiresAt))
+	return append(b, value...)
+}
+
+// decodeValue 解码值，不存在、已过期或格式错误时ok为false
+func decodeValue(data []byte) (string, bool) {
+	if len(data) < 8 {
+		return "", false
+	}
+	expiresAt := binary.BigEndian.Uint64(data)
+	if expiresAt != 0 && expiresAt <= uint64(time.Now().UnixNano()) {
+		return "", false
+	}
+	return string(data[8:]), true
+}
+
+// remainingTTL 根据编码后的值计算剩余过期时间
+func remainingTTL(data []byte) time.Duration {
+	if _, ok := decodeValue(data); !ok {
+		return _interface.TTLNotFound
+	}
+	expiresAt := int64(binary.BigEndian.Uint64(data))
+	if expiresAt == 0 {
+		return _interface.TTLNoExpiry
+	}
+	if ttl := time.Until(time.Unix(0, expiresAt)); ttl > 0 {
+		return ttl
+	}
+	return _interface.TTLNotFound
+}
+
+// Stats 返回bbolt的统计信息
+// 键数量只统计键值，哈希表、队列和集合的数量在Extra中
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 操作错误
+func (b *BboltDb) Stats() (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverBbolt)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		stats.Keys = int64(tx.Bucket(kvBucket).Stats().KeyN)
+		stats.DiskBytes = tx.Size()
+		stats.Extra = map[string]string{
+			"hashes": strconv.Itoa(tx.Bucket(hashBucket).Stats().BucketN - 1),
+			"lists":  strconv.Itoa(tx.Bucket(listBucket).Stats().BucketN - 1),
+			"sets":   strconv.Itoa(tx.Bucket(setBucket).Stats().BucketN - 1),
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Backup 写入bbolt数据文件的一致性快照
+// 参数：
+//
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BboltDb) Backup(w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverBbolt)
+	if err != nil {
+		return err
+	}
+	err = b.db.View(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(bw)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore 恢复bbolt数据文件快照
+// bbolt不能直接加载数据文件，先写入临时文件再在一个事务中将其中的bucket合并到当前数据库，
+// 哈希表、队列和集合按整个子bucket替换
+// 参数：
+//
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是bbolt的备份时返回ErrBackupFormat
+func (b *BboltDb) Restore(r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer b.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverBbolt)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "bbolt-restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, br)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	src, err := bolt.Open(f.Name(), 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("%w: %v", _interface.ErrBackupFormat, err)
+	}
+	defer src.Close()
+	return src.View(func(stx *bolt.Tx) error {
+		return b.db.Update(func(tx *bolt.Tx) error {
+			return stx.ForEach(func(name []byte, sb *bolt.Bucket) error {
+				dst, err := tx.CreateBucketIfNotExists(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(dst, sb)
+			})
+		})
+	})
+}
+
+// copyBucket 将src中的键值和子bucket复制到dst，同名的子bucket先删除再复制
+func copyBucket(dst, src *bolt.Bucket) error {
+	if src.Sequence() > dst.Sequence() {
+		if err := dst.SetSequence(src.Sequence()); err != nil {
+			return err
+		}
+	}
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+		if dst.Bucket(k) != nil {
+			if err := dst.DeleteBucket(k); err != nil {
+				return err
+			}
+		}
+		child, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(child, src.Bucket(k))
+	})
+}
+
+func NewBboltStore(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(config.Path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建顶层bucket
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{kvBucket, hashBucket, listBucket, setBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	b := &BboltDb{db: db, codec: codec}
+	b.CtxAdapter = _interface.NewCtxAdapter(b)
+	return b, nil
+}
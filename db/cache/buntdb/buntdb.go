@@ -6,20 +6,30 @@
 //
 // 主要特性：
 // - 纯内存存储，读写性能极佳
-// - 支持持久化到文件
+// - 支持持久化到文件，同步策略和自动收缩阈值由Bunt配置项调整，Shrink手动收缩数据文件
 // - 支持TTL过期
 // - 队列操作（FIFO/LIFO）
-// - 哈希表操作
+// - 哈希表操作（字段以\x00h\x00key\x00field复合键保存，兼容旧版本的key:field）
+// - 集合操作（成员以key\x00member复合键保存）
 // - 事务支持
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+// - 纯内存模式下按MaxEntries和MaxBytes限制键值的容量，超出时按EvictionPolicy淘汰
 // - 线程安全
 //
 // 作者: gophertool
 package buntdb
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gophertool/tool/db/cache/config"
@@ -33,15 +43,36 @@ func init() {
 	_interface.RegisterDriver(config.CacheDriverBuntdb, NewBuntStore)
 }
 
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
 // BuntDb BuntDB缓存实现结构体
 type BuntDb struct {
-	db         *buntdb.DB // BuntDB实例
-	queueMutex sync.Map   // 用于队列操作的互斥锁映射
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db         *buntdb.DB                // BuntDB实例
+	path       string                    // 数据文件路径，":memory:"表示不落盘
+	queueMutex sync.Map                  // 用于队列操作的互斥锁映射
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+	legacyHash atomic.Bool               // 数据库中可能有key:field形式的旧哈希表字段
+	evictor    *_interface.Evictor       // 纯内存模式下键值的容量限制，没有配置限制时为nil
 }
 
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*BuntDb)(nil)
+
+// 确保原生支持事务选项
+var _ _interface.TxBeginner = (*BuntDb)(nil)
+
 // Close 关闭数据库连接
 func (b *BuntDb) Close() {
 	_ = b.db.Close()
+	b.broker.Close()
+	b.expiry.Close()
 }
 
 // Get 获取指定key的值
@@ -67,6 +98,9 @@ func (b *BuntDb) Get(key string) (string, error) {
 	if errors.Is(err, buntdb.ErrNotFound) {
 		return "", _interface.ErrKeyNotFound
 	}
+	if err == nil {
+		b.evictor.Touch(key)
+	}
 	return val, err
 }
 
@@ -76,14 +110,17 @@ func (b *BuntDb) Set(key string, value string, ttl time.Duration) error {
 		if ttl > 0 {
 			opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
 		}
-		_, _, err := tx.Set(key, value, opts)
-		return err
+		if _, _, err := tx.Set(key, value, opts); err != nil {
+			return err
+		}
+		return b.track(tx, key, value)
 	})
 }
 
 func (b *BuntDb) Delete(key string) error {
 	return b.db.Update(func(tx *buntdb.Tx) error {
 		_, err := tx.Delete(key)
+		b.evictor.Remove(key)
 		return err
 	})
 }
@@ -110,248 +147,903 @@ func (b *BuntDb) Expire(key string, ttl time.Duration) error {
 	})
 }
 
-func (b *BuntDb) HGet(key, field string) (string, error) {
-	compositeKey := key + ":" + field
-	return b.Get(compositeKey)
-}
-
-func (b *BuntDb) HSet(key, field, value string, ttl time.Duration) error {
-	compositeKey := key + ":" + field
-	return b.Set(compositeKey, value, ttl)
-}
-
-func (b *BuntDb) HDel(key, field string) error {
-	compositeKey := key + ":" + field
-	return b.Delete(compositeKey)
-}
-
-func (b *BuntDb) HGetAll(key string) (map[string]string, error) {
-	result := make(map[string]string)
-	prefix := key + ":"
-
+// TTL 获取key的剩余过期时间
+// key不是普通键值而是哈希表时，字段各自保存过期时间，返回其中最长的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在时返回TTLNotFound
+//	error - 操作错误
+func (b *BuntDb) TTL(key string) (time.Duration, error) {
+	var ttl time.Duration
 	err := b.db.View(func(tx *buntdb.Tx) error {
-		return tx.AscendKeys(prefix+"*", func(k, v string) bool {
-			field := k[len(prefix):]
-			result[field] = v
-			return true
-		})
-	})
-
-	return result, err
-}
-
-func (b *BuntDb) Push(key string, value string) error {
-	return b.RPush(key, value)
-}
-
-func (b *BuntDb) LPush(key string, value string) error {
-	b.lock(key)
-	defer b.unlock(key)
-
-	return b.db.Update(func(tx *buntdb.Tx) error {
-		headKey := key + ":head"
-		tailKey := key + ":tail"
-
-		var head int64 = 0
-		var tail int64 = 0
-
-		if headVal, err := tx.Get(headKey); err == nil {
-			if h, err := strconv.ParseInt(headVal, 10, 64); err == nil {
-				head = h
-			}
-		}
-
-		if tailVal, err := tx.Get(tailKey); err == nil {
-			if t, err := strconv.ParseInt(tailVal, 10, 64); err == nil {
-				tail = t
-			}
-		}
-
-		head--
-
-		elemKey := key + ":elem:" + strconv.FormatInt(head, 10)
-		_, _, err := tx.Set(elemKey, value, nil)
-		if err != nil {
+		d, err := tx.TTL(key)
+		switch {
+		case errors.Is(err, buntdb.ErrNotFound):
+			ttl, err = b.hashTTL(tx, key)
 			return err
-		}
-
-		_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
-		if err != nil {
+		case err != nil:
 			return err
+		case d < 0:
+			ttl = _interface.TTLNoExpiry
+		default:
+			ttl = d
 		}
-
-		if head == tail-1 {
-			_, _, err = tx.Set(tailKey, strconv.FormatInt(tail, 10), nil)
-		}
-
-		return err
+		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+	return ttl, nil
 }
 
-func (b *BuntDb) RPush(key string, value string) error {
-	b.lock(key)
-	defer b.unlock(key)
-
-	return b.db.Update(func(tx *buntdb.Tx) error {
-		headKey := key + ":head"
-		tailKey := key + ":tail"
-
-		var head int64 = 0
-		var tail int64 = 0
-
-		if headVal, err := tx.Get(headKey); err == nil {
-			if h, err := strconv.ParseInt(headVal, 10, 64); err == nil {
-				head = h
-			}
-		}
-
-		if tailVal, err := tx.Get(tailKey); err == nil {
-			if t, err := strconv.ParseInt(tailVal, 10, 64); err == nil {
-				tail = t
-			}
+// SetNX key不存在时设置key-value并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (b *BuntDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	var ok bool
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Get(key)
+		if err == nil {
+			return nil
 		}
-
-		elemKey := key + ":elem:" + strconv.FormatInt(tail, 10)
-		_, _, err := tx.Set(elemKey, value, nil)
-		if err != nil {
+		if !errors.Is(err, buntdb.ErrNotFound) {
 			return err
 		}
-
-		tail++
-
-		_, _, err = tx.Set(tailKey, strconv.FormatInt(tail, 10), nil)
-		if err != nil {
-			return err
+		var opts *buntdb.SetOptions
+		if ttl > 0 {
+			opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
 		}
-
-		if tail-1 == head {
-			_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
+		if _, _, err = tx.Set(key, value, opts); err != nil {
+			return err
 		}
-
-		return err
+		ok = true
+		return b.track(tx, key, value)
 	})
-}
-func (b *BuntDb) Pop(key string) (string, error) {
-	return b.LPop(key)
+	return ok, err
 }
 
-// LPop 弹出列表头部元素
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
 // 参数：
 //
-//	key - 列表键名
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
 //
 // 返回值：
 //
-//	string - 弹出的元素值
-//	error - 操作错误，列表为空时返回ErrKeyNotFound
-func (b *BuntDb) LPop(key string) (string, error) {
-	b.lock(key)
-	defer b.unlock(key)
-
-	var result string
-
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (b *BuntDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	var ok bool
 	err := b.db.Update(func(tx *buntdb.Tx) error {
-		headKey := key + ":head"
-		tailKey := key + ":tail"
-
-		headVal, err := tx.Get(headKey)
-		if err != nil {
-			return err
-		}
-
-		tailVal, err := tx.Get(tailKey)
-		if err != nil {
-			return err
-		}
-
-		head, err := strconv.ParseInt(headVal, 10, 64)
-		if err != nil {
-			return err
+		current, err := tx.Get(key)
+		if errors.Is(err, buntdb.ErrNotFound) {
+			return nil
 		}
-
-		tail, err := strconv.ParseInt(tailVal, 10, 64)
-		if err != nil {
+		if err != nil || current != oldValue {
 			return err
 		}
-
-		if head >= tail {
-			return buntdb.ErrNotFound
+		var opts *buntdb.SetOptions
+		if ttl > 0 {
+			opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
 		}
-
-		elemKey := key + ":elem:" + strconv.FormatInt(head, 10)
-		val, err := tx.Get(elemKey)
-		if err != nil {
+		if _, _, err = tx.Set(key, newValue, opts); err != nil {
 			return err
 		}
+		ok = true
+		return b.track(tx, key, newValue)
+	})
+	return ok, err
+}
 
-		result = val
-
-		_, err = tx.Delete(elemKey)
-		if err != nil {
+// GetSet 设置新值并返回旧值
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (b *BuntDb) GetSet(key string, value string) (string, error) {
+	var old string
+	var replaced bool
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		var err error
+		if old, replaced, err = tx.Set(key, value, nil); err != nil {
 			return err
 		}
+		return b.track(tx, key, value)
+	})
+	if err == nil && !replaced {
+		return "", _interface.ErrKeyNotFound
+	}
+	return old, err
+}
 
-		head++
-
-		_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
-
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (b *BuntDb) GetDel(key string) (string, error) {
+	var val string
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		var err error
+		val, err = tx.Delete(key)
+		b.evictor.Remove(key)
 		return err
 	})
-
 	// 统一错误处理：将BuntDB特定错误转换为接口标准错误
 	if errors.Is(err, buntdb.ErrNotFound) {
 		return "", _interface.ErrKeyNotFound
 	}
-
-	return result, err
+	return val, err
 }
 
-// RPop 弹出列表尾部元素
+// Append 在同一个事务中读取key的值并追加value，key不存在时创建不过期的key，已有的过期时间保持不变
 // 参数：
 //
-//	key - 列表键名
+//	key - 键名
+//	value - 要追加的值
 //
 // 返回值：
 //
-//	string - 弹出的元素值
-//	error - 操作错误，列表为空时返回ErrKeyNotFound
-func (b *BuntDb) RPop(key string) (string, error) {
-	b.lock(key)
-	defer b.unlock(key)
-
-	var result string
-
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (b *BuntDb) Append(key string, value string) (int64, error) {
 	err := b.db.Update(func(tx *buntdb.Tx) error {
-		headKey := key + ":head"
-		tailKey := key + ":tail"
-
-		headVal, err := tx.Get(headKey)
-		if err != nil {
-			return err
-		}
-
-		tailVal, err := tx.Get(tailKey)
-		if err != nil {
-			return err
-		}
-
-		head, err := strconv.ParseInt(headVal, 10, 64)
-		if err != nil {
+		var opts *buntdb.SetOptions
+		old, err := tx.Get(key)
+		if err == nil {
+			value = old + value
+			if ttl, err := tx.TTL(key); err == nil && ttl > 0 {
+				opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+			}
+		} else if !errors.Is(err, buntdb.ErrNotFound) {
 			return err
 		}
-
-		tail, err := strconv.ParseInt(tailVal, 10, 64)
-		if err != nil {
+		if _, _, err := tx.Set(key, value, opts); err != nil {
 			return err
 		}
+		return b.track(tx, key, value)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(value)), nil
+}
 
-		if head >= tail {
-			return buntdb.ErrNotFound
-		}
+// StrLen 返回key的值的长度（字节数），key不存在时返回0
+func (b *BuntDb) StrLen(key string) (int64, error) {
+	val, err := b.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return int64(len(val)), err
+}
 
-		tail--
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (b *BuntDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := b.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Set(key, string(data), ttl)
+}
 
-		elemKey := key + ":elem:" + strconv.FormatInt(tail, 10)
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (b *BuntDb) GetObject(key string, v any) error {
+	raw, err := b.Get(key)
+	if err != nil {
+		return err
+	}
+	return b.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys 遍历匹配pattern的key，通配符语法与Redis一致
+// 哈希表字段的复合键不会被遍历到，队列元素和集合成员以key:index、key\x00member等复合键保存，也会被遍历到
+// 每批读取scanBatchSize个key后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) Keys(pattern string, fn func(key string) bool) error {
+	prefix := _interface.PatternPrefix(pattern)
+	start := prefix
+	for {
+		var keys []string
+		err := b.db.View(func(tx *buntdb.Tx) error {
+			return tx.AscendGreaterOrEqual("", start, func(k, v string) bool {
+				if !strings.HasPrefix(k, prefix) {
+					return false
+				}
+				keys = append(keys, k)
+				return len(keys) < scanBatchSize
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// Iterate 遍历键名以prefix开头的键值
+// 遍历范围与Keys一致；每批读取scanBatchSize个键值后结束事务再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	start := prefix
+	for {
+		var keys, values []string
+		err := b.db.View(func(tx *buntdb.Tx) error {
+			return tx.AscendGreaterOrEqual("", start, func(k, v string) bool {
+				if !strings.HasPrefix(k, prefix) {
+					return false
+				}
+				keys = append(keys, k)
+				values = append(values, v)
+				return len(keys) < scanBatchSize
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, key := range keys {
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if !fn(key, values[i]) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// DeleteByPrefix 删除所有以prefix开头的key，包括键名以prefix开头的哈希表，在同一个事务中遍历并删除
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) DeleteByPrefix(prefix string) error {
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		// 遍历期间不能修改数据，先收集再删除
+		var keys []string
+		for _, start := range []string{prefix, _interface.HashKeyPrefix + prefix} {
+			err := tx.AscendGreaterOrEqual("", start, func(k, v string) bool {
+				if !strings.HasPrefix(k, start) {
+					return false
+				}
+				keys = append(keys, k)
+				return true
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			if _, err := tx.Delete(key); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+				return err
+			}
+		}
+		if prefix == "" {
+			// 数据库已经清空，重新写入新编码的标记
+			_, _, err := tx.Set(_interface.HashEncodingMarker, "", nil)
+			return err
+		}
+		return nil
+	})
+	if err == nil {
+		b.evictor.RemovePrefix(prefix)
+	}
+	if err == nil && prefix == "" {
+		b.legacyHash.Store(false)
+	}
+	return err
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或已过期时返回ErrKeyNotFound
+func (b *BuntDb) HGet(key, field string) (string, error) {
+	val, err := b.Get(_interface.HashKey(key, field))
+	if errors.Is(err, _interface.ErrKeyNotFound) && b.legacyHash.Load() {
+		return b.Get(_interface.LegacyHashKey(key, field))
+	}
+	return val, err
+}
+
+// HSet 设置哈希表中的field-value，过期时间只作用于该字段
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 字段的过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) HSet(key, field, value string, ttl time.Duration) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		return b.hset(tx, key, field, value, ttl)
+	})
+}
+
+// HDel 删除哈希表中的field，字段不存在时不报错
+func (b *BuntDb) HDel(key, field string) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		return b.hdel(tx, key, field)
+	})
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有未过期的字段和值的映射
+//	error - 操作错误
+func (b *BuntDb) HGetAll(key string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		return b.ascendHash(tx, key, func(field, _, value string) {
+			result[field] = value
+		})
+	})
+	return result, err
+}
+
+// HLen 获取哈希表中未过期的field数量，哈希表不存在时返回0
+func (b *BuntDb) HLen(key string) (int64, error) {
+	fields, err := b.HGetAll(key)
+	return int64(len(fields)), err
+}
+
+// HExists 判断哈希表中是否存在未过期的field
+func (b *BuntDb) HExists(key, field string) (bool, error) {
+	_, err := b.HGet(key, field)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HKeys 获取哈希表中所有未过期的field，按字段名排序
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (b *BuntDb) HKeys(key string) ([]string, error) {
+	hash, err := b.HGetAll(key)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// hset 在事务中写入哈希表字段，兼容模式下同时删除同名的旧字段
+func (b *BuntDb) hset(tx *buntdb.Tx, key, field, value string, ttl time.Duration) error {
+	var opts *buntdb.SetOptions
+	if ttl > 0 {
+		opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+	}
+	if _, _, err := tx.Set(_interface.HashKey(key, field), value, opts); err != nil {
+		return err
+	}
+	if b.legacyHash.Load() {
+		if _, err := tx.Delete(_interface.LegacyHashKey(key, field)); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// hdel 在事务中删除哈希表字段，兼容模式下同时删除同名的旧字段
+func (b *BuntDb) hdel(tx *buntdb.Tx, key, field string) error {
+	keys := []string{_interface.HashKey(key, field)}
+	if b.legacyHash.Load() {
+		keys = append(keys, _interface.LegacyHashKey(key, field))
+	}
+	for _, k := range keys {
+		if _, err := tx.Delete(k); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ascendHash 遍历哈希表未过期的字段，fn的参数为字段名、保存字段的键和字段值
+// 兼容模式下先遍历key:field形式的旧字段，同名的新字段随后遍历，调用方按字段名覆盖即可
+func (b *BuntDb) ascendHash(tx *buntdb.Tx, key string, fn func(field, k, value string)) error {
+	prefixes := []string{_interface.HashFieldPrefix(key)}
+	if b.legacyHash.Load() {
+		prefixes = append([]string{_interface.LegacyHashKey(key, "")}, prefixes...)
+	}
+	for _, prefix := range prefixes {
+		err := tx.AscendGreaterOrEqual("", prefix, func(k, v string) bool {
+			if !strings.HasPrefix(k, prefix) {
+				return false
+			}
+			fn(k[len(prefix):], k, v)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashTTL 返回哈希表字段中最长的剩余过期时间，任意字段没有过期时间时返回TTLNoExpiry
+func (b *BuntDb) hashTTL(tx *buntdb.Tx, key string) (time.Duration, error) {
+	ttl := _interface.TTLNotFound
+	var err error
+	aerr := b.ascendHash(tx, key, func(_, k, _ string) {
+		if err != nil || ttl == _interface.TTLNoExpiry {
+			return
+		}
+		var d time.Duration
+		if d, err = tx.TTL(k); err != nil {
+			return
+		}
+		if d < 0 {
+			ttl = _interface.TTLNoExpiry
+		} else if d > ttl {
+			ttl = d
+		}
+	})
+	if aerr != nil {
+		return 0, aerr
+	}
+	return ttl, err
+}
+
+// initHashEncoding 检查哈希表字段的编码
+// 数据库中有新编码的标记，或者数据库为空（写入标记）时只使用新编码，否则进入兼容模式
+func (b *BuntDb) initHashEncoding() error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Get(_interface.HashEncodingMarker)
+		if !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+		n, err := tx.Len()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			b.legacyHash.Store(true)
+			return nil
+		}
+		_, _, err = tx.Set(_interface.HashEncodingMarker, "", nil)
+		return err
+	})
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) SAdd(key, member string) error {
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		_, _, err := tx.Set(setKey(key, member), "", nil)
+		return err
+	})
+}
+
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) SRem(key, member string) error {
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		_, err := tx.Delete(setKey(key, member))
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// SMembers 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (b *BuntDb) SMembers(key string) ([]string, error) {
+	members := []string{}
+	prefix := setKey(key, "")
+
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendGreaterOrEqual("", prefix, func(k, v string) bool {
+			if !strings.HasPrefix(k, prefix) {
+				return false
+			}
+			members = append(members, k[len(prefix):])
+			return true
+		})
+	})
+	return members, err
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (b *BuntDb) SIsMember(key, member string) (bool, error) {
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		_, err := tx.Get(setKey(key, member))
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// setKey 生成集合成员对应的复合键，使用\x00分隔，避免与普通键冲突
+func setKey(key, member string) string {
+	return key + "\x00" + member
+}
+
+func (b *BuntDb) Push(key string, value string) error {
+	return b.RPush(key, value)
+}
+
+func (b *BuntDb) LPush(key string, value string) error {
+	b.lock(key)
+	defer b.unlock(key)
+
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		headKey := key + ":head"
+		tailKey := key + ":tail"
+
+		var head int64 = 0
+		var tail int64 = 0
+
+		if headVal, err := tx.Get(headKey); err == nil {
+			if h, err := strconv.ParseInt(headVal, 10, 64); err == nil {
+				head = h
+			}
+		}
+
+		if tailVal, err := tx.Get(tailKey); err == nil {
+			if t, err := strconv.ParseInt(tailVal, 10, 64); err == nil {
+				tail = t
+			}
+		}
+
+		head--
+
+		elemKey := key + ":elem:" + strconv.FormatInt(head, 10)
+		_, _, err := tx.Set(elemKey, value, nil)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
+		if err != nil {
+			return err
+		}
+
+		if head == tail-1 {
+			_, _, err = tx.Set(tailKey, strconv.FormatInt(tail, 10), nil)
+		}
+
+		return err
+	})
+}
+
+func (b *BuntDb) RPush(key string, value string) error {
+	b.lock(key)
+	defer b.unlock(key)
+
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		headKey := key + ":head"
+		tailKey := key + ":tail"
+
+		var head int64 = 0
+		var tail int64 = 0
+
+		if headVal, err := tx.Get(headKey); err == nil {
+			if h, err := strconv.ParseInt(headVal, 10, 64); err == nil {
+				head = h
+			}
+		}
+
+		if tailVal, err := tx.Get(tailKey); err == nil {
+			if t, err := strconv.ParseInt(tailVal, 10, 64); err == nil {
+				tail = t
+			}
+		}
+
+		elemKey := key + ":elem:" + strconv.FormatInt(tail, 10)
+		_, _, err := tx.Set(elemKey, value, nil)
+		if err != nil {
+			return err
+		}
+
+		tail++
+
+		_, _, err = tx.Set(tailKey, strconv.FormatInt(tail, 10), nil)
+		if err != nil {
+			return err
+		}
+
+		if tail-1 == head {
+			_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
+		}
+
+		return err
+	})
+}
+
+// RPushCapped 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) RPushCapped(key string, value string, maxLen int64) error {
+	b.lock(key)
+	defer b.unlock(key)
+
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		head, tail, err := listBounds(tx, key)
+		if err != nil {
+			return err
+		}
+		if _, _, err := tx.Set(listElemKey(key, tail), value, nil); err != nil {
+			return err
+		}
+		tail++
+		for ; maxLen > 0 && tail-head > maxLen; head++ {
+			if _, err := tx.Delete(listElemKey(key, head)); err != nil {
+				return err
+			}
+		}
+		return setListBounds(tx, key, head, tail)
+	})
+}
+
+func (b *BuntDb) Pop(key string) (string, error) {
+	return b.LPop(key)
+}
+
+// LPop 弹出列表头部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BuntDb) LPop(key string) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+
+	b.lock(key)
+	defer b.unlock(key)
+
+	var result string
+
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		headKey := key + ":head"
+		tailKey := key + ":tail"
+
+		headVal, err := tx.Get(headKey)
+		if err != nil {
+			return err
+		}
+
+		tailVal, err := tx.Get(tailKey)
+		if err != nil {
+			return err
+		}
+
+		head, err := strconv.ParseInt(headVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		tail, err := strconv.ParseInt(tailVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if head >= tail {
+			return buntdb.ErrNotFound
+		}
+
+		elemKey := key + ":elem:" + strconv.FormatInt(head, 10)
+		val, err := tx.Get(elemKey)
+		if err != nil {
+			return err
+		}
+
+		result = val
+
+		_, err = tx.Delete(elemKey)
+		if err != nil {
+			return err
+		}
+
+		head++
+
+		_, _, err = tx.Set(headKey, strconv.FormatInt(head, 10), nil)
+
+		return err
+	})
+
+	// 统一错误处理：将BuntDB特定错误转换为接口标准错误
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+
+	return result, err
+}
+
+// RPop 弹出列表尾部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BuntDb) RPop(key string) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+
+	b.lock(key)
+	defer b.unlock(key)
+
+	var result string
+
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		headKey := key + ":head"
+		tailKey := key + ":tail"
+
+		headVal, err := tx.Get(headKey)
+		if err != nil {
+			return err
+		}
+
+		tailVal, err := tx.Get(tailKey)
+		if err != nil {
+			return err
+		}
+
+		head, err := strconv.ParseInt(headVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		tail, err := strconv.ParseInt(tailVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		if head >= tail {
+			return buntdb.ErrNotFound
+		}
+
+		tail--
+
+		elemKey := key + ":elem:" + strconv.FormatInt(tail, 10)
 		val, err := tx.Get(elemKey)
 		if err != nil {
 			return err
@@ -378,6 +1070,10 @@ func (b *BuntDb) RPop(key string) (string, error) {
 }
 
 func (b *BuntDb) PopAll(key string) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
+	}
+
 	b.lock(key)
 	defer b.unlock(key)
 
@@ -412,73 +1108,307 @@ func (b *BuntDb) PopAll(key string) ([]string, error) {
 			return nil // Queue is empty, return empty result
 		}
 
-		// Get all elements from head to tail-1
+		// Get all elements from head to tail-1
+		for i := head; i < tail; i++ {
+			elemKey := key + ":elem:" + strconv.FormatInt(i, 10)
+			val, err := tx.Get(elemKey)
+			if err != nil {
+				continue // Skip if element doesn't exist
+			}
+
+			result = append(result, val)
+
+			// Delete the element
+			_, err = tx.Delete(elemKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Reset indices (we'll use 0 for both head and tail to indicate empty queue)
+		_, _, err = tx.Set(headKey, "0", nil)
+		if err != nil {
+			return err
+		}
+
+		_, _, err = tx.Set(tailKey, "0", nil)
+		return err
+	})
+
+	return result, err
+}
+
+func (b *BuntDb) Len(key string) (int64, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return 0, err
+	}
+
+	var length int64 = 0
+
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		// Get current head and tail indices
+		headKey := key + ":head"
+		tailKey := key + ":tail"
+
+		headVal, err := tx.Get(headKey)
+		if err != nil {
+			return nil // Queue doesn't exist, length is 0
+		}
+
+		tailVal, err := tx.Get(tailKey)
+		if err != nil {
+			return nil // Queue doesn't exist, length is 0
+		}
+
+		head, err := strconv.ParseInt(headVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		tail, err := strconv.ParseInt(tailVal, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		// Calculate length
+		if tail > head {
+			length = tail - head
+		}
+
+		return nil
+	})
+
+	return length, err
+}
+
+// LRange 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (b *BuntDb) LRange(key string, start, stop int64) ([]string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		head, tail, err := listBounds(tx, key)
+		if err != nil {
+			return err
+		}
+		lo, hi := _interface.ListRange(start, stop, tail-head)
+		for i := head + lo; i < head+hi; i++ {
+			val, err := tx.Get(listElemKey(key, i))
+			if err != nil {
+				return err
+			}
+			result = append(result, val)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// LIndex 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (b *BuntDb) LIndex(key string, index int64) (string, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return "", err
+	}
+
+	var result string
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		head, tail, err := listBounds(tx, key)
+		if err != nil {
+			return err
+		}
+		i, ok := _interface.ListIndex(index, tail-head)
+		if !ok {
+			return buntdb.ErrNotFound
+		}
+		result, err = tx.Get(listElemKey(key, head+i))
+		return err
+	})
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return result, err
+}
+
+// LRem 删除列表中等于value的元素，保留的元素从头部开始重新连续存放
+// 参数：
+//
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (b *BuntDb) LRem(key string, count int64, value string) (int64, error) {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return 0, err
+	}
+
+	b.lock(key)
+	defer b.unlock(key)
+
+	var removed int64
+	err := b.db.Update(func(tx *buntdb.Tx) error {
+		head, tail, err := listBounds(tx, key)
+		if err != nil {
+			return err
+		}
+		items := make([]string, 0, tail-head)
 		for i := head; i < tail; i++ {
-			elemKey := key + ":elem:" + strconv.FormatInt(i, 10)
-			val, err := tx.Get(elemKey)
+			val, err := tx.Get(listElemKey(key, i))
 			if err != nil {
-				continue // Skip if element doesn't exist
+				return err
 			}
+			items = append(items, val)
+		}
 
-			result = append(result, val)
-
-			// Delete the element
-			_, err = tx.Delete(elemKey)
-			if err != nil {
+		var kept []string
+		kept, removed = _interface.ListRem(items, count, value)
+		if removed == 0 {
+			return nil
+		}
+		for i := head; i < tail; i++ {
+			if _, err := tx.Delete(listElemKey(key, i)); err != nil {
 				return err
 			}
 		}
-
-		// Reset indices (we'll use 0 for both head and tail to indicate empty queue)
-		_, _, err = tx.Set(headKey, "0", nil)
-		if err != nil {
-			return err
+		for i, val := range kept {
+			if _, _, err := tx.Set(listElemKey(key, head+int64(i)), val, nil); err != nil {
+				return err
+			}
 		}
-
-		_, _, err = tx.Set(tailKey, "0", nil)
-		return err
+		return setListBounds(tx, key, head, head+int64(len(kept)))
 	})
-
-	return result, err
+	return removed, err
 }
 
-func (b *BuntDb) Len(key string) (int64, error) {
-	var length int64 = 0
+// LTrim 只保留列表中下标在[start, stop]范围内的元素
+func (b *BuntDb) LTrim(key string, start, stop int64) error {
+	if err := b.delayQueue.Promote(b, key); err != nil {
+		return err
+	}
 
-	err := b.db.View(func(tx *buntdb.Tx) error {
-		// Get current head and tail indices
-		headKey := key + ":head"
-		tailKey := key + ":tail"
+	b.lock(key)
+	defer b.unlock(key)
 
-		headVal, err := tx.Get(headKey)
-		if err != nil {
-			return nil // Queue doesn't exist, length is 0
+	return b.db.Update(func(tx *buntdb.Tx) error {
+		head, tail, err := listBounds(tx, key)
+		if err != nil || head >= tail {
+			return err
 		}
-
-		tailVal, err := tx.Get(tailKey)
-		if err != nil {
-			return nil // Queue doesn't exist, length is 0
+		lo, hi := _interface.ListRange(start, stop, tail-head)
+		for i := head; i < tail; i++ {
+			if i >= head+lo && i < head+hi {
+				continue
+			}
+			if _, err := tx.Delete(listElemKey(key, i)); err != nil {
+				return err
+			}
 		}
-
-		head, err := strconv.ParseInt(headVal, 10, 64)
-		if err != nil {
-			return err
+		if lo >= hi {
+			return setListBounds(tx, key, 0, 0)
 		}
+		return setListBounds(tx, key, head+lo, head+hi)
+	})
+}
 
-		tail, err := strconv.ParseInt(tailVal, 10, 64)
-		if err != nil {
-			return err
-		}
+// listElemKey 生成列表元素对应的键
+func listElemKey(key string, i int64) string {
+	return key + ":elem:" + strconv.FormatInt(i, 10)
+}
 
-		// Calculate length
-		if tail > head {
-			length = tail - head
-		}
+// listBounds 读取列表的头尾下标，列表不存在时返回0, 0
+func listBounds(tx *buntdb.Tx, key string) (int64, int64, error) {
+	headVal, err := tx.Get(key + ":head")
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	tailVal, err := tx.Get(key + ":tail")
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	head, err := strconv.ParseInt(headVal, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	tail, err := strconv.ParseInt(tailVal, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	if tail < head {
+		tail = head
+	}
+	return head, tail, nil
+}
 
-		return nil
-	})
+// setListBounds 保存列表的头尾下标
+func setListBounds(tx *buntdb.Tx, key string, head, tail int64) error {
+	if _, _, err := tx.Set(key+":head", strconv.FormatInt(head, 10), nil); err != nil {
+		return err
+	}
+	_, _, err := tx.Set(key+":tail", strconv.FormatInt(tail, 10), nil)
+	return err
+}
 
-	return length, err
+// PopAck 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，进程重启后仍然有效
+// 参数：
+//
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (b *BuntDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return b.ackQueue.PopAck(b, key, visibility)
+}
+
+// Ack 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (b *BuntDb) Ack(key, receipt string) error {
+	return b.ackQueue.Ack(b, key, receipt)
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，进程重启后仍然有效；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) PushDelayed(key, value string, delay time.Duration) error {
+	return b.delayQueue.PushDelayed(b, key, value, delay)
 }
 
 func (b *BuntDb) lock(key string) {
@@ -494,8 +1424,41 @@ func (b *BuntDb) unlock(key string) {
 	}
 }
 
+// track 记录键值的写入，并在同一个事务中删除超出容量限制被淘汰的键值
+// 只跟踪Set/SetNX/CAS/GetSet/SetObject和事务写入的键值，哈希表、集合和队列不计入容量也不会被淘汰
+func (b *BuntDb) track(tx *buntdb.Tx, key, value string) error {
+	for _, victim := range b.evictor.Track(key, int64(len(key)+len(value))) {
+		if _, err := tx.Delete(victim); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
 type buntTx struct {
+	b  *BuntDb
 	tx *buntdb.Tx
+
+	// 事务中写入和删除的键值，提交时更新容量限制的跟踪信息
+	written map[string]string
+	deleted map[string]bool
+}
+
+func (tx *buntTx) Get(key string) (string, error) {
+	val, err := tx.tx.Get(key)
+	// 统一错误处理：将BuntDB特定错误转换为接口标准错误
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+func (tx *buntTx) Exists(key string) (bool, error) {
+	_, err := tx.tx.Get(key)
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
 }
 
 func (tx *buntTx) Set(key string, value string, ttl time.Duration) error {
@@ -503,16 +1466,58 @@ func (tx *buntTx) Set(key string, value string, ttl time.Duration) error {
 	if ttl > 0 {
 		opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
 	}
-	_, _, err := tx.tx.Set(key, value, opts)
-	return err
+	if _, _, err := tx.tx.Set(key, value, opts); err != nil {
+		return err
+	}
+	if tx.b.evictor != nil {
+		tx.written[key] = value
+		delete(tx.deleted, key)
+	}
+	return nil
 }
 
 func (tx *buntTx) Delete(key string) error {
 	_, err := tx.tx.Delete(key)
+	if tx.b.evictor != nil {
+		tx.deleted[key] = true
+		delete(tx.written, key)
+	}
 	return err
 }
 
+// Expire 使用新的过期时间重新写入当前值，key 不存在时不做任何操作
+func (tx *buntTx) Expire(key string, ttl time.Duration) error {
+	val, err := tx.tx.Get(key)
+	if errors.Is(err, buntdb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Set(key, val, ttl)
+}
+
+// HSet 哈希表字段与非事务的HSet一样保存为独立的复合键
+func (tx *buntTx) HSet(key, field, value string, ttl time.Duration) error {
+	return tx.b.hset(tx.tx, key, field, value, ttl)
+}
+
+// HDel 删除哈希表字段，字段不存在时不报错
+func (tx *buntTx) HDel(key, field string) error {
+	return tx.b.hdel(tx.tx, key, field)
+}
+
+// Commit 提交前在同一个事务中淘汰超出容量限制的键值
 func (tx *buntTx) Commit() error {
+	for key := range tx.deleted {
+		tx.b.evictor.Remove(key)
+	}
+	for key, value := range tx.written {
+		if err := tx.b.track(tx.tx, key, value); err != nil {
+			_ = tx.tx.Rollback()
+			return err
+		}
+	}
 	return tx.tx.Commit()
 }
 
@@ -520,18 +1525,255 @@ func (tx *buntTx) Rollback() error {
 	return tx.tx.Rollback()
 }
 
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) Publish(channel string, payload string) error {
+	return b.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BuntDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return b.broker.Subscribe(channel)
+}
+
+// SubscribeExpired 订阅键过期事件
+// 事件来自BuntDB每秒一次的后台过期清理；哈希表字段过期时通知哈希表的key，队列元素同样保存为独立的键，它们过期时也会通知
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (b *BuntDb) SubscribeExpired() (<-chan string, func()) {
+	return b.expiry.Subscribe(nil)
+}
+
+// onExpired 在BuntDB后台清理过期键的事务内被调用，删除过期键并通知订阅者
+func (b *BuntDb) onExpired(key, _ string, tx *buntdb.Tx) error {
+	// 与BuntDB默认的清理逻辑一致，已过期的键删除时会返回ErrNotFound
+	if _, err := tx.Delete(key); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+		return err
+	}
+	b.evictor.Remove(key)
+	if hash, _, ok := _interface.ParseHashKey(key); ok {
+		key = hash
+	}
+	b.expiry.Notify(key)
+	return nil
+}
+
 func (b *BuntDb) BeginTx() (_interface.Tx, error) {
 	tx, err := b.db.Begin(true)
 	if err != nil {
 		return nil, err
 	}
-	return &buntTx{tx: tx}, nil
+	return &buntTx{b: b, tx: tx, written: map[string]string{}, deleted: map[string]bool{}}, nil
+}
+
+// BeginTxWatch BuntDB的读写事务独占数据库，提交之前keys不会被修改，与BeginTx相同
+func (b *BuntDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return b.BeginTx()
+}
+
+// BeginTxOptions 按选项开启事务
+// BuntDB同一时间只有一个写事务，可以满足所有隔离级别；只读事务只持有读锁，多个只读事务可以并发执行
+func (b *BuntDb) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !opts.ReadOnly {
+		return b.BeginTx()
+	}
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return _interface.ReadOnlyTx(&buntTx{b: b, tx: tx}), nil
+}
+
+// Stats 返回BuntDB的统计信息
+// 键数量包含哈希表字段和队列元素的复合键；数据保存在内存中，磁盘占用为数据文件的大小
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 操作错误
+func (b *BuntDb) Stats() (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverBuntdb)
+	err := b.db.View(func(tx *buntdb.Tx) error {
+		n, err := tx.Len()
+		stats.Keys = int64(n)
+		return err
+	})
+	stats.Evictions = b.evictor.Evictions()
+	if err != nil {
+		return stats, err
+	}
+	if b.path != ":memory:" {
+		if info, err := os.Stat(b.path); err == nil {
+			stats.DiskBytes = info.Size()
+		}
+	}
+	return stats, nil
+}
+
+// Backup 使用BuntDB的数据文件格式备份所有数据
+// 参数：
+//
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) Backup(w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverBuntdb)
+	if err != nil {
+		return err
+	}
+	if err := b.db.Save(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore 恢复BuntDB数据文件格式的备份，同名的键被覆盖并保留备份中的剩余过期时间
+// 开启持久化的数据库不能直接Load，因此先加载到临时的内存数据库，再在一个事务中写入
+// 纯内存模式下恢复的键值不计入容量限制，之后再次写入时才会被跟踪
+// 参数：
+//
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是BuntDB的备份时返回ErrBackupFormat
+func (b *BuntDb) Restore(r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer b.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverBuntdb)
+	if err != nil {
+		return err
+	}
+	src, err := buntdb.Open(":memory:")
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := src.Load(br); err != nil {
+		return fmt.Errorf("%w: %v", _interface.ErrBackupFormat, err)
+	}
+
+	return src.View(func(stx *buntdb.Tx) error {
+		return b.db.Update(func(tx *buntdb.Tx) error {
+			// 旧版本的备份没有新编码的标记，其中的哈希表字段是key:field形式，恢复后进入兼容模式
+			if _, err := stx.Get(_interface.HashEncodingMarker); errors.Is(err, buntdb.ErrNotFound) {
+				if n, _ := stx.Len(); n > 0 {
+					if _, err := tx.Delete(_interface.HashEncodingMarker); err != nil && !errors.Is(err, buntdb.ErrNotFound) {
+						return err
+					}
+					b.legacyHash.Store(true)
+				}
+			}
+			var err error
+			if aerr := stx.Ascend("", func(key, value string) bool {
+				var opts *buntdb.SetOptions
+				if ttl, _ := stx.TTL(key); ttl > 0 {
+					opts = &buntdb.SetOptions{Expires: true, TTL: ttl}
+				}
+				_, _, err = tx.Set(key, value, opts)
+				return err == nil
+			}); aerr != nil {
+				return aerr
+			}
+			return err
+		})
+	})
+}
+
+// Shrink 立即重写数据文件，去掉已删除和被覆盖的记录，纯内存模式下不做任何操作
+// 返回值：
+//
+//	error - 操作错误
+func (b *BuntDb) Shrink() error {
+	if b.path == ":memory:" {
+		return nil
+	}
+	return b.db.Shrink()
+}
+
+// buntSyncPolicy 将配置的同步策略转换为buntdb.SyncPolicy
+func buntSyncPolicy(policy string) (buntdb.SyncPolicy, error) {
+	switch policy {
+	case "", config.BuntSyncEverySecond:
+		return buntdb.EverySecond, nil
+	case config.BuntSyncNever:
+		return buntdb.Never, nil
+	case config.BuntSyncAlways:
+		return buntdb.Always, nil
+	}
+	return 0, fmt.Errorf("buntdb: unknown sync policy %q", policy)
 }
 
 func NewBuntStore(config config.Cache) (_interface.Cache, error) {
-	db, err := buntdb.Open(config.Path)
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	syncPolicy, err := buntSyncPolicy(config.Bunt.SyncPolicy)
+	if err != nil {
+		return nil, err
+	}
+	path := config.Path
+	if config.Bunt.InMemory {
+		path = ":memory:"
+	}
+	if path != ":memory:" && (config.MaxEntries > 0 || config.MaxBytes > 0) {
+		return nil, errors.New("buntdb: max_entries and max_bytes require an in-memory database")
+	}
+	evictor, err := _interface.NewEvictor(config.EvictionPolicy, config.MaxEntries, config.MaxBytes)
 	if err != nil {
 		return nil, err
 	}
-	return &BuntDb{db: db}, nil
+	db, err := buntdb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BuntDb{db: db, path: path, codec: codec, evictor: evictor}
+	b.CtxAdapter = _interface.NewCtxAdapter(b)
+	var cfg buntdb.Config
+	if err := db.ReadConfig(&cfg); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	cfg.SyncPolicy = syncPolicy
+	cfg.AutoShrinkDisabled = config.Bunt.AutoShrinkDisabled
+	if config.Bunt.AutoShrinkPercentage > 0 {
+		cfg.AutoShrinkPercentage = config.Bunt.AutoShrinkPercentage
+	}
+	if config.Bunt.AutoShrinkMinSize > 0 {
+		cfg.AutoShrinkMinSize = config.Bunt.AutoShrinkMinSize
+	}
+	cfg.OnExpiredSync = b.onExpired
+	if err := db.SetConfig(cfg); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err := b.initHashEncoding(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return b, nil
 }
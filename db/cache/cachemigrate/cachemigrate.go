@@ -0,0 +1,251 @@
+// cachemigrate包：缓存驱动之间的数据迁移
+// 通过Cache接口逐个读取源缓存的数据并写入目标缓存，源和目标可以是任意两种驱动，
+// 例如从单机的BuntDB迁移到Redis
+//
+// 主要特性：
+// - 键值和哈希表保留剩余过期时间，迁移过程中过期的键会被跳过
+// - 哈希表、集合按整个key复制，队列保持元素顺序，源队列的元素不会被移除
+// - 定期回调迁移进度，可以通过上下文取消
+//
+// 使用限制：
+// - Cache接口无法区分key的类型，哈希表、集合和队列的key需要在Options中列出
+// - Cache接口无法非破坏地读取队列，队列先整体弹出再放回源队列头部，期间消费者读不到这些元素
+// - 字段各自过期的驱动（BadgerDB、BuntDB、etcd）以最长的字段过期时间迁移整个哈希表，驱动的TTL读不到哈希表的过期时间时迁移后的哈希表不过期
+//
+// 作者: gophertool
+package cachemigrate
+
+import (
+	"context"
+	"errors"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// defaultProgressEvery 默认每迁移多少个key回调一次进度
+const defaultProgressEvery = 100
+
+// Options 迁移选项
+type Options struct {
+	Pattern       string         // 迁移的键值匹配的通配符，空字符串表示所有键值
+	Hashes        []string       // 需要迁移的哈希表
+	Sets          []string       // 需要迁移的集合
+	Queues        []string       // 需要迁移的队列
+	ProgressEvery int            // 每迁移多少个key回调一次进度，0表示100
+	OnProgress    func(Progress) // 进度回调，迁移结束时总会回调一次
+}
+
+// Progress 迁移进度
+type Progress struct {
+	Keys    int64  // 已迁移的键值数
+	Hashes  int64  // 已迁移的哈希表数
+	Sets    int64  // 已迁移的集合数
+	Queues  int64  // 已迁移的队列数
+	Items   int64  // 已迁移的队列元素数
+	Skipped int64  // 迁移过程中已过期或被删除而跳过的键值数
+	Current string // 最近迁移的key
+}
+
+// total 已处理的key总数
+func (p Progress) total() int64 {
+	return p.Keys + p.Hashes + p.Sets + p.Queues + p.Skipped
+}
+
+// migrator 一次迁移的状态
+type migrator struct {
+	ctx      context.Context
+	src, dst _interface.Cache
+	opts     Options
+	progress Progress
+}
+
+// Migrate 将src中的数据迁移到dst，dst中的同名key会被覆盖
+// 参数：
+//
+//	ctx - 上下文，取消后在下一个key之前停止迁移
+//	src - 源缓存
+//	dst - 目标缓存
+//	opts - 迁移选项
+//
+// 返回值：
+//
+//	Progress - 停止时的迁移进度
+//	error - 读取或写入错误，已迁移的数据不会回滚
+func Migrate(ctx context.Context, src, dst _interface.Cache, opts Options) (Progress, error) {
+	if opts.ProgressEvery <= 0 {
+		opts.ProgressEvery = defaultProgressEvery
+	}
+	m := &migrator{ctx: ctx, src: src, dst: dst, opts: opts}
+	err := m.run()
+	if opts.OnProgress != nil {
+		opts.OnProgress(m.progress)
+	}
+	return m.progress, err
+}
+
+func (m *migrator) run() error {
+	keys, err := m.keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := m.step(key, m.copyKey); err != nil {
+			return err
+		}
+	}
+	for _, key := range m.opts.Hashes {
+		if err := m.step(key, m.copyHash); err != nil {
+			return err
+		}
+	}
+	for _, key := range m.opts.Sets {
+		if err := m.step(key, m.copySet); err != nil {
+			return err
+		}
+	}
+	for _, key := range m.opts.Queues {
+		if err := m.step(key, m.copyQueue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keys 收集需要迁移的键值，遍历期间不读写缓存，避免在驱动的遍历事务中嵌套操作
+// Redis、BuntDB和BadgerDB等驱动的Keys也会返回哈希表、集合和队列的key或内部复合键，
+// Options中列出的key以及以其加":"或"\x00"开头的复合键会被排除
+func (m *migrator) keys() ([]string, error) {
+	exclude := make(map[string]struct{})
+	for _, list := range [][]string{m.opts.Hashes, m.opts.Sets, m.opts.Queues} {
+		for _, key := range list {
+			exclude[key] = struct{}{}
+		}
+	}
+	var keys []string
+	err := m.src.Keys(m.opts.Pattern, func(key string) bool {
+		if !excluded(exclude, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, err
+}
+
+// excluded 判断key是否为exclude中的key或其复合键
+func excluded(exclude map[string]struct{}, key string) bool {
+	if _, ok := exclude[key]; ok {
+		return true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != ':' && key[i] != '\x00' {
+			continue
+		}
+		if _, ok := exclude[key[:i]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// step 检查上下文，迁移一个key并按间隔回调进度
+func (m *migrator) step(key string, migrate func(key string) error) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+	if err := migrate(key); err != nil {
+		return err
+	}
+	m.progress.Current = key
+	if m.opts.OnProgress != nil && m.progress.total()%int64(m.opts.ProgressEvery) == 0 {
+		m.opts.OnProgress(m.progress)
+	}
+	return nil
+}
+
+// copyKey 复制键值和剩余过期时间
+func (m *migrator) copyKey(key string) error {
+	value, err := m.src.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		m.progress.Skipped++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ttl, err := m.src.TTL(key)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ttl == _interface.TTLNotFound:
+		m.progress.Skipped++
+		return nil
+	case ttl < 0:
+		ttl = 0
+	}
+	if err := m.dst.Set(key, value, ttl); err != nil {
+		return err
+	}
+	m.progress.Keys++
+	return nil
+}
+
+// copyHash 复制哈希表的所有字段和剩余过期时间
+// 字段各自保存过期时间的驱动，TTL返回其中最长的剩余过期时间，所有字段使用这个过期时间
+func (m *migrator) copyHash(key string) error {
+	fields, err := m.src.HGetAll(key)
+	if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return err
+	}
+	ttl, err := m.src.TTL(key)
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	for field, value := range fields {
+		if err := m.dst.HSet(key, field, value, ttl); err != nil {
+			return err
+		}
+	}
+	m.progress.Hashes++
+	return nil
+}
+
+// copySet 复制集合的所有成员
+func (m *migrator) copySet(key string) error {
+	members, err := m.src.SMembers(key)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if err := m.dst.SAdd(key, member); err != nil {
+			return err
+		}
+	}
+	m.progress.Sets++
+	return nil
+}
+
+// copyQueue 弹出源队列的所有元素，按原顺序放回源队列头部后依次追加到目标队列的右边
+// 放回使用LPush，期间新推入源队列的元素仍然排在这些元素之后
+func (m *migrator) copyQueue(key string) error {
+	items, err := m.src.PopAll(key)
+	if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return err
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if err := m.src.LPush(key, items[i]); err != nil {
+			return err
+		}
+	}
+	for _, value := range items {
+		if err := m.dst.RPush(key, value); err != nil {
+			return err
+		}
+		m.progress.Items++
+	}
+	m.progress.Queues++
+	return nil
+}
@@ -8,14 +8,35 @@
 // - Redis：分布式内存缓存，支持集群和持久化
 // - BadgerDB：高性能本地LSM树存储
 // - BuntDB：快速内存数据库，支持持久化
+// - Memcached：分布式内存缓存，仅支持键值和哈希表操作
+// - etcd：强一致的分布式键值存储，适合配置管理和服务协调
+// - SQLite：嵌入式单文件数据库，可查询且崩溃安全
+// - Pebble：RocksDB风格的LSM树存储，BadgerDB的替代方案
+// - Memory：纯内存缓存，支持LRU淘汰，适合测试和小型进程
+// - Ristretto：高吞吐内存缓存，基于开销淘汰，适合读多写少的热点数据
+// - bbolt：纯Go的单文件B+树存储，哈希表和队列使用独立的bucket
 //
 // 配置参数说明：
 // - Driver：缓存驱动类型标识
-// - Path：本地存储路径（BadgerDB/BuntDB使用）
-// - Host：服务器地址（Redis使用）
-// - Port：服务器端口（Redis使用）
-// - Password：认证密码（Redis使用）
+// - Path：本地存储路径（BadgerDB/BuntDB/SQLite/Pebble/bbolt使用）
+// - Host：服务器地址（Redis/Memcached/etcd使用）
+// - Port：服务器端口（Redis/Memcached/etcd使用）
+// - Username：认证用户名，Redis 6的ACL用户（Redis/etcd使用）
+// - Password：认证密码（Redis/etcd使用）
 // - DB：数据库编号（Redis使用）
+// - SentinelMasterName：Sentinel监控的主节点名称，设置后通过Sentinel连接并自动故障转移（Redis使用）
+// - SentinelAddrs：Sentinel节点地址列表，格式为host:port（Redis使用）
+// - MaxEntries：最大条目数，0表示不限制（Memory/Ristretto/纯内存模式的BuntDB使用）
+// - MaxBytes：最大字节数，0表示不限制（Memory/Ristretto/纯内存模式的BuntDB使用）
+// - EvictionPolicy：超出MaxEntries或MaxBytes时的淘汰策略，可选lru/lfu/random，为空时使用lru（Memory/BuntDB使用）
+// - Codec：SetObject/GetObject使用的值编码方式，可选json/gob/msgpack，为空时使用json（所有驱动使用）
+// - DefaultTTL：写入时ttl为0所使用的过期时间，0表示不过期；ttl为NoExpiry的写入不受影响（所有驱动使用）
+// - PreloadFile：种子文件路径，New创建实例后从JSON或CSV种子文件批量写入键值，为空时不预热（所有驱动使用）
+// - ValueLogGCInterval：后台值日志垃圾回收的间隔，0表示10分钟，负数表示关闭（BadgerDB使用）
+// - ValueLogGCRatio：值日志文件中可回收数据超过该比例时才重写，0表示0.5（BadgerDB使用）
+// - Badger：BadgerDB的调优选项，如只读模式、内存表大小和值日志文件大小（BadgerDB使用）
+// - Bunt：BuntDB的持久化选项，如同步策略、自动收缩阈值和纯内存模式（BuntDB使用）
+// - Redis：Redis的连接池和超时选项，如连接池大小、最小空闲连接数和读写超时（Redis使用）
 //
 // 使用示例：
 //
@@ -25,13 +46,56 @@
 //	    Port:   "6379",
 //	}
 //
+//	// Redis Sentinel高可用部署
+//	cfg := config.Cache{
+//	    Driver:             config.CacheDriverRedis,
+//	    SentinelMasterName: "mymaster",
+//	    SentinelAddrs:      []string{"10.0.0.1:26379", "10.0.0.2:26379"},
+//	}
+//
+//	// 也可以从一个URL解析，便于通过环境变量配置
+//	cfg, err := config.ParseURL(os.Getenv("CACHE_URL"))
+//
+//	// 或者从CACHE_*环境变量、配置文件中的一节加载，并填充默认值和校验
+//	cfg, err := config.FromEnv("CACHE")
+//	cfg, err := config.LoadFile("app.yaml", "storage.cache")
+//
 // 作者: gophertool
 package config
 
+import "time"
+
+const (
+	CacheDriverRedis     = "redis"
+	CacheDriverBadger    = "badger"
+	CacheDriverBuntdb    = "buntdb"
+	CacheDriverMemcached = "memcached"
+	CacheDriverEtcd      = "etcd"
+	CacheDriverSqlite    = "sqlite"
+	CacheDriverPebble    = "pebble"
+	CacheDriverMemory    = "memory"
+	CacheDriverRistretto = "ristretto"
+	CacheDriverBbolt     = "bbolt"
+)
+
+// BuntDB的同步策略
+const (
+	BuntSyncNever       = "never"       // 不主动同步，由操作系统决定何时落盘
+	BuntSyncEverySecond = "everysecond" // 每秒同步一次
+	BuntSyncAlways      = "always"      // 每次写入都同步
+)
+
+// 超出容量限制时的淘汰策略
+const (
+	EvictionLRU    = "lru"    // 淘汰最久未访问的条目
+	EvictionLFU    = "lfu"    // 近似LFU，淘汰抽样条目中访问次数最少的
+	EvictionRandom = "random" // 随机淘汰
+)
+
 const (
-	CacheDriverRedis  = "redis"
-	CacheDriverBadger = "badger"
-	CacheDriverBuntdb = "buntdb"
+	CodecJSON    = "json"
+	CodecGob     = "gob"
+	CodecMsgpack = "msgpack"
 )
 
 type Cache struct {
@@ -39,6 +103,61 @@ type Cache struct {
 	Path     string
 	Host     string
 	Port     string
+	Username string
 	Password string
 	DB       int
+
+	SentinelMasterName string
+	SentinelAddrs      []string
+
+	MaxEntries     int
+	MaxBytes       int64
+	EvictionPolicy string
+
+	Codec string
+
+	DefaultTTL time.Duration
+
+	PreloadFile string
+
+	ValueLogGCInterval time.Duration
+	ValueLogGCRatio    float64
+
+	Badger BadgerOptions
+	Bunt   BuntOptions
+	Redis  RedisOptions
+}
+
+// BadgerOptions BadgerDB的调优选项，数值字段为0时使用Badger的默认值
+type BadgerOptions struct {
+	ReadOnly          bool  // 只读打开，写入返回错误，不运行后台值日志回收；多个进程可以同时只读打开
+	SyncWrites        bool  // 每次写入都同步到磁盘，默认异步写入
+	LoadTablesToRAM   bool  // 将SST文件完整加载到内存，默认使用mmap
+	MaxTableSize      int64 // 内存表和SST文件的大小，默认64MB
+	NumMemtables      int   // 内存表的最大数量，默认5
+	ValueThreshold    int   // 大于该字节数的值保存在值日志中，默认32
+	ValueLogFileSize  int64 // 单个值日志文件的大小，默认1GB
+	NumVersionsToKeep int   // 每个key保留的版本数，默认1
+	NumCompactors     int   // 后台合并的并发数，默认2
+}
+
+// BuntOptions BuntDB的持久化选项，数值字段为0时使用BuntDB的默认值
+type BuntOptions struct {
+	InMemory             bool   // 数据只保存在内存中，忽略Path，与Path为":memory:"等效
+	SyncPolicy           string // 同步到磁盘的策略，可选never/everysecond/always，为空时使用everysecond
+	AutoShrinkPercentage int    // 数据文件超过上次收缩后大小的该百分比时自动收缩，默认100
+	AutoShrinkMinSize    int    // 自动收缩要求的最小文件字节数，默认32MB
+	AutoShrinkDisabled   bool   // 关闭后台自动收缩
+}
+
+// RedisOptions Redis的连接池和超时选项，字段为0时使用默认值
+type RedisOptions struct {
+	PoolSize        int           // 连接池的最大连接数，默认200
+	MinIdleConns    int           // 保持的最小空闲连接数，默认100，负数表示不保持空闲连接
+	MaxIdleConns    int           // 最大空闲连接数，默认不限制
+	ConnMaxIdleTime time.Duration // 空闲连接的最长保留时间，默认30分钟，负数表示不关闭空闲连接
+	DialTimeout     time.Duration // 建立连接的超时时间，默认5秒
+	ReadTimeout     time.Duration // 读取响应的超时时间，默认3秒，负数表示不设置超时
+	WriteTimeout    time.Duration // 发送命令的超时时间，默认3秒，负数表示不设置超时
+	PoolTimeout     time.Duration // 连接池已满时等待空闲连接的超时时间，默认为ReadTimeout加1秒
 }
@@ -0,0 +1,244 @@
+// config包：从环境变量和配置文件加载缓存配置
+// 应用不需要各自编写读取环境变量和配置文件的代码：
+// - FromEnv读取CACHE_URL、CACHE_DRIVER、CACHE_HOST等环境变量
+// - LoadFile读取JSON或YAML配置文件中的一节
+// 两者都先解析URL（如果设置了），再用单独的字段覆盖，最后填充默认值并校验
+//
+// 字段名与ParseURL的查询参数一致，另外包括driver、path、host、port、username、password和sentinel_addrs，
+// 环境变量名为前缀加上大写的字段名，如CACHE_POOL_SIZE
+//
+// 作者: gophertool
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvPrefix FromEnv的默认环境变量前缀
+const DefaultEnvPrefix = "CACHE"
+
+// connParams 连接信息字段，URL中这些信息由URL本身给出，环境变量和配置文件中单独设置
+var connParams = map[string]func(c *Cache, v string) error{
+	"driver":   func(c *Cache, v string) error { c.Driver = v; return nil },
+	"path":     func(c *Cache, v string) error { c.Path = v; return nil },
+	"host":     func(c *Cache, v string) error { c.Host = v; return nil },
+	"port":     func(c *Cache, v string) error { c.Port = v; return nil },
+	"username": func(c *Cache, v string) error { c.Username = v; return nil },
+	"password": func(c *Cache, v string) error { c.Password = v; return nil },
+	"sentinel_addrs": func(c *Cache, v string) error {
+		c.SentinelAddrs = strings.Split(v, ",")
+		return nil
+	},
+}
+
+// FromEnv 从环境变量加载缓存配置
+// 先解析<prefix>_URL，再用<prefix>_DRIVER、<prefix>_HOST、<prefix>_POOL_SIZE等变量覆盖对应的字段
+// 参数：
+//
+//	prefix - 环境变量前缀，为空时使用DefaultEnvPrefix
+//
+// 返回值：
+//
+//	Cache - 填充了默认值的配置
+//	error - 变量的值无效或配置校验失败
+func FromEnv(prefix string) (Cache, error) {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	var c Cache
+	if raw := os.Getenv(prefix + "_URL"); raw != "" {
+		var err error
+		if c, err = ParseURL(raw); err != nil {
+			return Cache{}, err
+		}
+	}
+	for _, params := range []map[string]func(c *Cache, v string) error{connParams, optionParams} {
+		for name, set := range params {
+			name = prefix + "_" + strings.ToUpper(name)
+			if v, ok := os.LookupEnv(name); ok {
+				if err := set(&c, v); err != nil {
+					return Cache{}, fmt.Errorf("config: invalid %s: %w", name, err)
+				}
+			}
+		}
+	}
+
+	c.applyDefaults()
+	return c, c.Validate()
+}
+
+// LoadFile 从JSON或YAML配置文件中加载缓存配置，文件类型由扩展名（.json/.yaml/.yml）决定
+// 一节中可以设置url，其他字段覆盖从url解析的值；未知的字段返回错误
+// 参数：
+//
+//	path - 配置文件路径
+//	section - 缓存配置所在的节，多级用.分隔，如"storage.cache"；为空时使用整个文件
+//
+// 返回值：
+//
+//	Cache - 填充了默认值的配置
+//	error - 文件读取或解析失败、节不存在、字段无效或配置校验失败
+func LoadFile(path, section string) (Cache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Cache{}, err
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err != nil {
+			return Cache{}, fmt.Errorf("config: parse %s: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if doc, err = parseYAML(data); err != nil {
+			return Cache{}, err
+		}
+	default:
+		return Cache{}, fmt.Errorf("config: unsupported config file type %q", ext)
+	}
+
+	if section != "" {
+		for _, name := range strings.Split(section, ".") {
+			sub, ok := doc[name].(map[string]any)
+			if !ok {
+				return Cache{}, fmt.Errorf("config: section %q not found in %s", section, path)
+			}
+			doc = sub
+		}
+	}
+	return fromMap(doc)
+}
+
+// fromMap 用配置文件中一节的字段生成配置
+func fromMap(m map[string]any) (Cache, error) {
+	var c Cache
+	if raw, ok := m["url"]; ok && raw != nil {
+		s, ok := raw.(string)
+		if !ok {
+			return Cache{}, errors.New("config: url must be a string")
+		}
+		var err error
+		if c, err = ParseURL(s); err != nil {
+			return Cache{}, err
+		}
+	}
+
+	for name, raw := range m {
+		if name == "url" || raw == nil {
+			continue
+		}
+		set, ok := connParams[name]
+		if !ok {
+			if set, ok = optionParams[name]; !ok {
+				return Cache{}, fmt.Errorf("config: unknown field %q", name)
+			}
+		}
+		v, err := scalarString(raw)
+		if err != nil {
+			return Cache{}, fmt.Errorf("config: invalid field %s: %w", name, err)
+		}
+		if err := set(&c, v); err != nil {
+			return Cache{}, fmt.Errorf("config: invalid field %s: %w", name, err)
+		}
+	}
+
+	c.applyDefaults()
+	return c, c.Validate()
+}
+
+// scalarString 把配置文件中的值转换为字符串，序列用逗号连接
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unexpected value of type %T", v)
+	}
+}
+
+// applyDefaults 为网络驱动填充默认的主机和端口，使用Sentinel时不需要
+func (c *Cache) applyDefaults() {
+	port, ok := defaultPorts[c.Driver]
+	if !ok || c.SentinelMasterName != "" {
+		return
+	}
+	if c.Host == "" {
+		c.Host = "localhost"
+	}
+	if c.Port == "" {
+		c.Port = port
+	}
+}
+
+// Validate 检查配置是否完整有效
+// 未知的驱动类型不报错，应用可以通过RegisterDriver注册自定义驱动
+//
+// 返回值：
+//
+//	error - 第一个发现的问题
+func (c Cache) Validate() error {
+	switch c.Driver {
+	case "":
+		return errors.New("config: cache driver is required")
+	case CacheDriverBadger, CacheDriverSqlite, CacheDriverPebble, CacheDriverBbolt:
+		if c.Path == "" {
+			return fmt.Errorf("config: %s requires a path", c.Driver)
+		}
+	case CacheDriverBuntdb:
+		if c.Path == "" && !c.Bunt.InMemory {
+			return errors.New("config: buntdb requires a path or in_memory")
+		}
+	}
+
+	if c.SentinelMasterName != "" && len(c.SentinelAddrs) == 0 {
+		return errors.New("config: sentinel_master requires sentinel_addrs")
+	}
+	if c.DB < 0 {
+		return fmt.Errorf("config: invalid db %d", c.DB)
+	}
+	if c.MaxEntries < 0 || c.MaxBytes < 0 {
+		return errors.New("config: max_entries and max_bytes must not be negative")
+	}
+	switch c.EvictionPolicy {
+	case "", EvictionLRU, EvictionLFU, EvictionRandom:
+	default:
+		return fmt.Errorf("config: unknown eviction policy %q", c.EvictionPolicy)
+	}
+	if c.DefaultTTL < 0 {
+		return fmt.Errorf("config: invalid default_ttl %v", c.DefaultTTL)
+	}
+	if c.ValueLogGCRatio < 0 || c.ValueLogGCRatio >= 1 {
+		return fmt.Errorf("config: value_log_gc_ratio %v must be in [0, 1)", c.ValueLogGCRatio)
+	}
+	switch c.Bunt.SyncPolicy {
+	case "", BuntSyncNever, BuntSyncEverySecond, BuntSyncAlways:
+	default:
+		return fmt.Errorf("config: unknown buntdb sync policy %q", c.Bunt.SyncPolicy)
+	}
+	return nil
+}
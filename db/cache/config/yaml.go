@@ -0,0 +1,239 @@
+// config包：配置文件使用的YAML子集解析
+// 只实现配置文件需要的部分，避免为读取几个字段引入完整的YAML依赖：
+// - 以空格缩进的块映射和块序列，序列可以与上一级键对齐
+// - 普通、单引号和双引号标量，null和~表示空值
+// - 单行的流序列，如[a, b]
+// - 以#开头的注释和文档开始标记---
+//
+// 锚点、别名、标签、多行字符串和流映射返回错误，标量统一解析为字符串，由调用方按字段类型转换
+//
+// 作者: gophertool
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine 去掉注释和空行之后的一行
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// parseYAML 将YAML文档解析为map[string]any，值为map[string]any、[]any、string或nil
+func parseYAML(data []byte) (map[string]any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(stripYAMLComment(strings.TrimRight(raw, "\r")), " \t")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("config: yaml line %d: tabs are not allowed in indentation", i+1)
+		}
+		lines = append(lines, yamlLine{indent: len(raw) - len(text), text: text, num: i + 1})
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	v, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("config: yaml line %d: unexpected indentation", lines[next].num)
+	}
+	root, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("config: yaml document must be a mapping")
+	}
+	return root, nil
+}
+
+// parseYAMLBlock 解析从lines[i]开始、缩进为indent的块，返回块的值和下一个未解析的行
+func parseYAMLBlock(lines []yamlLine, i, indent int) (any, int, error) {
+	if isYAMLSeqItem(lines[i].text) {
+		return parseYAMLSeq(lines, i, indent)
+	}
+
+	m := map[string]any{}
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if isYAMLSeqItem(line.text) {
+			return nil, 0, fmt.Errorf("config: yaml line %d: unexpected sequence item", line.num)
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, 0, fmt.Errorf("config: yaml line %d: expected key: value", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, 0, fmt.Errorf("config: yaml line %d: duplicate key %q", line.num, key)
+		}
+		i++
+
+		switch {
+		case rest != "":
+			v, err := parseYAMLScalar(rest, line.num)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+		case i < len(lines) && lines[i].indent > indent:
+			v, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = v, next
+		case i < len(lines) && lines[i].indent == indent && isYAMLSeqItem(lines[i].text):
+			// 序列可以与键对齐
+			v, next, err := parseYAMLSeq(lines, i, indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key], i = v, next
+		default:
+			m[key] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("config: yaml line %d: unexpected indentation", lines[i].num)
+	}
+	return m, i, nil
+}
+
+// parseYAMLSeq 解析缩进为indent的块序列，元素只能是标量或下一级的块
+func parseYAMLSeq(lines []yamlLine, i, indent int) ([]any, int, error) {
+	seq := []any{}
+	for i < len(lines) && lines[i].indent == indent && isYAMLSeqItem(lines[i].text) {
+		line := lines[i]
+		item := strings.TrimSpace(line.text[1:])
+		i++
+		if item == "" {
+			if i >= len(lines) || lines[i].indent <= indent {
+				seq = append(seq, nil)
+				continue
+			}
+			v, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			seq, i = append(seq, v), next
+			continue
+		}
+		if _, _, ok := splitYAMLKey(item); ok {
+			return nil, 0, fmt.Errorf("config: yaml line %d: mappings inside sequence items are not supported", line.num)
+		}
+		v, err := parseYAMLScalar(item, line.num)
+		if err != nil {
+			return nil, 0, err
+		}
+		seq = append(seq, v)
+	}
+	return seq, i, nil
+}
+
+// parseYAMLScalar 解析标量或单行的流序列
+func parseYAMLScalar(s string, num int) (any, error) {
+	switch s[0] {
+	case '"':
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("config: yaml line %d: invalid double-quoted string", num)
+		}
+		return v, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("config: yaml line %d: invalid single-quoted string", num)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case '[':
+		if s[len(s)-1] != ']' {
+			return nil, fmt.Errorf("config: yaml line %d: unterminated flow sequence", num)
+		}
+		seq := []any{}
+		if inner := strings.TrimSpace(s[1 : len(s)-1]); inner != "" {
+			for _, item := range strings.Split(inner, ",") {
+				item = strings.TrimSpace(item)
+				if item == "" || strings.ContainsAny(item[:1], "[{") {
+					return nil, fmt.Errorf("config: yaml line %d: unsupported flow sequence item", num)
+				}
+				v, err := parseYAMLScalar(item, num)
+				if err != nil {
+					return nil, err
+				}
+				seq = append(seq, v)
+			}
+		}
+		return seq, nil
+	case '{', '|', '>', '&', '*', '!':
+		return nil, fmt.Errorf("config: yaml line %d: unsupported yaml syntax %q", num, s[:1])
+	}
+	if s == "null" || s == "~" {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// splitYAMLKey 在引号之外的第一个": "（或行尾的":"）处把一行拆成键和值
+func splitYAMLKey(text string) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' '):
+			key := strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false
+			}
+			if k, err := parseYAMLScalar(key, 0); err == nil {
+				if s, ok := k.(string); ok {
+					key = s
+				}
+			}
+			return key, strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment 去掉引号之外以#开头的注释，#前面必须是行首或空白
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '[' || line[i-1] == ',' || line[i-1] == '-' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func isYAMLSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
@@ -0,0 +1,875 @@
+// cache包：静态加密装饰器
+// 使用AES-GCM加密写入底层缓存的值，适合缓存令牌、凭证等敏感数据
+//
+// 主要特性：
+// - 键值、哈希表字段值、队列元素和发布的消息使用随机nonce加密，相同的明文每次加密结果不同
+// - 集合成员使用由明文派生的nonce确定性加密，SRem和SIsMember仍然可以按成员查找
+// - 键名（哈希表还包括字段名、消息包括频道名）作为附加数据参与认证，密文不能被复制到其他键下读取
+// - 键名、哈希表字段名和频道名不加密，Keys、DeleteByPrefix和TTL等操作不受影响
+// - 密钥可以直接传入，也可以通过KeyFunc从KMS等外部服务获取
+// - 密文以base64编码保存，所有驱动都能安全存储
+// - 底层缓存实现了CacheCtx时，返回的实例同样实现CacheCtx
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// ErrDecrypt 值无法解密，密钥不匹配或数据被篡改
+var ErrDecrypt = errors.New("cache: value decryption failed")
+
+// EncryptionOptions 加密装饰器的选项
+type EncryptionOptions struct {
+	Key     []byte                 // AES密钥，16/24/32字节分别对应AES-128/192/256
+	KeyFunc func() ([]byte, error) // 获取密钥的回调，例如从KMS解密数据密钥；Key为空时使用
+	Codec   _interface.Codec       // SetObject/GetObject的编码方式，为nil时使用JSONCodec
+}
+
+// WithEncryption 返回在c之上加了静态加密的缓存实例
+// 参数：
+//
+//	c - 底层缓存实例
+//	opts - 加密选项，Key和KeyFunc至少设置一个
+//
+// 返回值：
+//
+//	_interface.Cache - 加密的缓存实例，c实现了CacheCtx时同样实现CacheCtx
+//	error - 获取密钥失败或密钥长度无效
+func WithEncryption(c _interface.Cache, opts EncryptionOptions) (_interface.Cache, error) {
+	key := opts.Key
+	if len(key) == 0 {
+		if opts.KeyFunc == nil {
+			return nil, errors.New("cache: encryption key or KeyFunc is required")
+		}
+		var err error
+		if key, err = opts.KeyFunc(); err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	codec := opts.Codec
+	if codec == nil {
+		codec = _interface.JSONCodec{}
+	}
+
+	// 集合成员的nonce由独立的子密钥派生，不直接使用加密密钥做HMAC
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("cache set member nonce"))
+	e := &encrypted{c: c, aead: aead, nonceKey: mac.Sum(nil), codec: codec}
+	if cc, ok := c.(_interface.CacheCtx); ok {
+		return &encryptedCtx{encrypted: e, cc: cc}, nil
+	}
+	return e, nil
+}
+
+// encrypted 加密装饰器，实现Cache接口
+type encrypted struct {
+	c        _interface.Cache
+	aead     cipher.AEAD
+	nonceKey []byte // 派生集合成员nonce的HMAC密钥
+	codec    _interface.Codec
+}
+
+// binding 绑定到key（哈希表还包括字段名）的加解密操作
+// key和字段名作为AES-GCM的附加数据参与认证，密文被复制到其他key或字段下时无法解密
+type binding struct {
+	e   *encrypted
+	key string
+	ad  []byte
+}
+
+// bound 返回绑定到key的加解密操作，哈希表的值额外传入字段名
+func (e *encrypted) bound(key string, field ...string) binding {
+	// 每一部分前加上长度，不同的key和字段组合不会得到相同的附加数据
+	var ad []byte
+	for _, part := range append([]string{key}, field...) {
+		ad = binary.AppendUvarint(ad, uint64(len(part)))
+		ad = append(ad, part...)
+	}
+	return binding{e: e, key: key, ad: ad}
+}
+
+// seal 使用随机nonce加密
+func (b binding) seal(plain string) string {
+	aead := b.e.aead
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	_, _ = rand.Read(nonce)
+	return base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), b.ad))
+}
+
+// sealMember 使用由附加数据和明文派生的nonce加密，同一个集合中相同的明文加密结果相同
+func (b binding) sealMember(plain string) string {
+	aead := b.e.aead
+	mac := hmac.New(sha256.New, b.e.nonceKey)
+	mac.Write(b.ad)
+	mac.Write([]byte(plain))
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	return base64.RawStdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), b.ad))
+}
+
+// open 解密seal或sealMember的结果
+func (b binding) open(sealed string) (string, error) {
+	aead := b.e.aead
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, b.ad)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// openResult 解密读取操作的返回值，读取出错时原样返回错误
+func (b binding) openResult(sealed string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return b.open(sealed)
+}
+
+func (b binding) openAll(sealed []string, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]string, len(sealed))
+	for i, v := range sealed {
+		if plain[i], err = b.open(v); err != nil {
+			return nil, err
+		}
+	}
+	return plain, nil
+}
+
+// openMap 解密哈希表的所有值，每个值使用各自的字段名作为附加数据
+func (b binding) openMap(sealed map[string]string, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	plain := make(map[string]string, len(sealed))
+	for field, v := range sealed {
+		if plain[field], err = b.e.bound(b.key, field).open(v); err != nil {
+			return nil, err
+		}
+	}
+	return plain, nil
+}
+
+// matching 按LRem的语义返回列表中应删除的元素的密文
+// 队列元素使用随机nonce加密，相同的明文密文不同，只能解密整个列表后逐个比较
+func (b binding) matching(sealed []string, count int64, value string) ([]string, error) {
+	plain, err := b.openAll(sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	var targets []string
+	for j := range plain {
+		i := j
+		if count < 0 {
+			i = len(plain) - 1 - j
+		}
+		if plain[i] != value {
+			continue
+		}
+		targets = append(targets, sealed[i])
+		if int64(len(targets)) == limit {
+			break
+		}
+	}
+	return targets, nil
+}
+
+// openMessage 解密订阅收到的消息，无法解密的消息被丢弃
+func (e *encrypted) openMessage(msg _interface.Message) (_interface.Message, bool) {
+	payload, err := e.bound(msg.Channel).open(msg.Payload)
+	if err != nil {
+		return msg, false
+	}
+	msg.Payload = payload
+	return msg, true
+}
+
+// Close 加密装饰器不拥有底层缓存，不做任何操作，底层缓存由创建它的一方关闭
+func (e *encrypted) Close() {}
+
+func (e *encrypted) Get(key string) (string, error) {
+	return e.bound(key).openResult(e.c.Get(key))
+}
+
+func (e *encrypted) Set(key string, value string, ttl time.Duration) error {
+	return e.c.Set(key, e.bound(key).seal(value), ttl)
+}
+
+func (e *encrypted) Delete(key string) error {
+	return e.c.Delete(key)
+}
+
+func (e *encrypted) Exists(key string) (bool, error) {
+	return e.c.Exists(key)
+}
+
+func (e *encrypted) Expire(key string, ttl time.Duration) error {
+	return e.c.Expire(key, ttl)
+}
+
+func (e *encrypted) TTL(key string) (time.Duration, error) {
+	return e.c.TTL(key)
+}
+
+func (e *encrypted) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return e.c.SetNX(key, e.bound(key).seal(value), ttl)
+}
+
+// CAS 密文每次加密都不同，先读取并解密当前值进行比较，再以读到的密文作为旧值调用底层的CAS
+func (e *encrypted) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return e.cas(key, oldValue, newValue, e.c.Get, func(oldSealed, newSealed string) (bool, error) {
+		return e.c.CAS(key, oldSealed, newSealed, ttl)
+	})
+}
+
+// cas 使用get读取当前的密文，解密后与oldValue相同时调用swap写入新的密文
+func (e *encrypted) cas(key, oldValue, newValue string, get func(key string) (string, error), swap func(oldSealed, newSealed string) (bool, error)) (bool, error) {
+	sealed, err := get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	b := e.bound(key)
+	current, err := b.open(sealed)
+	if err != nil || current != oldValue {
+		return false, err
+	}
+	return swap(sealed, b.seal(newValue))
+}
+
+// GetSet 设置新值并返回旧值，key不存在时仍会写入新值并返回ErrKeyNotFound
+func (e *encrypted) GetSet(key string, value string) (string, error) {
+	return e.bound(key).openResult(e.c.GetSet(key, e.bound(key).seal(value)))
+}
+
+func (e *encrypted) GetDel(key string) (string, error) {
+	return e.bound(key).openResult(e.c.GetDel(key))
+}
+
+// Append 密文不能直接拼接，读取并解密当前值，追加之后重新加密，以读到的密文作为旧值调用底层的CAS写入
+// 剩余的过期时间通过TTL读取后传给CAS，底层不支持TTL时追加后的key不过期
+func (e *encrypted) Append(key string, value string) (int64, error) {
+	return e.append(context.Background(), key, value, e.c.Get, e.c.TTL, e.c.SetNX, e.c.CAS)
+}
+
+// append 使用get读取当前的密文，追加后通过cas写入，key不存在时通过setNX创建，冲突时按随机退避重试直到成功或ctx结束
+func (e *encrypted) append(ctx context.Context, key, value string,
+	get func(key string) (string, error),
+	ttl func(key string) (time.Duration, error),
+	setNX func(key, value string, ttl time.Duration) (bool, error),
+	cas func(key, oldValue, newValue string, ttl time.Duration) (bool, error),
+) (int64, error) {
+	b := e.bound(key)
+	var n int64
+	err := _interface.RetryConflict(ctx, func() (bool, error) {
+		sealed, err := get(key)
+		if errors.Is(err, _interface.ErrKeyNotFound) {
+			n = int64(len(value))
+			return setNX(key, b.seal(value), 0)
+		}
+		if err != nil {
+			return false, err
+		}
+		current, err := b.open(sealed)
+		if err != nil {
+			return false, err
+		}
+		remaining, err := ttl(key)
+		if err != nil || remaining < 0 {
+			remaining = 0
+		}
+		n = int64(len(current) + len(value))
+		return cas(key, sealed, b.seal(current+value), remaining)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// StrLen 返回解密后的值的长度（字节数），key不存在时返回0
+func (e *encrypted) StrLen(key string) (int64, error) {
+	return strLen(e.Get(key))
+}
+
+// strLen 返回Get结果的长度，ErrKeyNotFound视为长度为0
+func strLen(value string, err error) (int64, error) {
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return int64(len(value)), err
+}
+
+func (e *encrypted) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := e.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.Set(key, string(data), ttl)
+}
+
+func (e *encrypted) GetObject(key string, v any) error {
+	raw, err := e.Get(key)
+	if err != nil {
+		return err
+	}
+	return e.codec.Unmarshal([]byte(raw), v)
+}
+
+func (e *encrypted) Keys(pattern string, fn func(key string) bool) error {
+	return e.c.Keys(pattern, fn)
+}
+
+// Iterate 遍历键名以prefix开头的键值并解密，值无法解密时停止遍历并返回ErrDecrypt
+// 遍历范围包括队列元素、集合成员复合键的驱动（BadgerDB、BuntDB）需要用prefix排除这些键
+func (e *encrypted) Iterate(prefix string, fn func(key, value string) bool) error {
+	return e.iterate(prefix, fn, e.c.Iterate)
+}
+
+// iterate 使用iter遍历底层缓存，解密后调用fn
+func (e *encrypted) iterate(prefix string, fn func(key, value string) bool, iter func(prefix string, fn func(key, value string) bool) error) error {
+	var openErr error
+	err := iter(prefix, func(key, sealed string) bool {
+		value, err := e.bound(key).open(sealed)
+		if err != nil {
+			openErr = fmt.Errorf("%w: %s", err, key)
+			return false
+		}
+		return fn(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	return openErr
+}
+
+func (e *encrypted) DeleteByPrefix(prefix string) error {
+	return e.c.DeleteByPrefix(prefix)
+}
+
+func (e *encrypted) HGet(key, field string) (string, error) {
+	return e.bound(key, field).openResult(e.c.HGet(key, field))
+}
+
+func (e *encrypted) HSet(key, field, value string, ttl time.Duration) error {
+	return e.c.HSet(key, field, e.bound(key, field).seal(value), ttl)
+}
+
+func (e *encrypted) HDel(key, field string) error {
+	return e.c.HDel(key, field)
+}
+
+func (e *encrypted) HGetAll(key string) (map[string]string, error) {
+	return e.bound(key).openMap(e.c.HGetAll(key))
+}
+
+// HLen 字段名不加密，直接使用底层缓存的结果
+func (e *encrypted) HLen(key string) (int64, error) {
+	return e.c.HLen(key)
+}
+
+func (e *encrypted) HExists(key, field string) (bool, error) {
+	return e.c.HExists(key, field)
+}
+
+func (e *encrypted) HKeys(key string) ([]string, error) {
+	return e.c.HKeys(key)
+}
+
+func (e *encrypted) SAdd(key, member string) error {
+	return e.c.SAdd(key, e.bound(key).sealMember(member))
+}
+
+func (e *encrypted) SRem(key, member string) error {
+	return e.c.SRem(key, e.bound(key).sealMember(member))
+}
+
+func (e *encrypted) SMembers(key string) ([]string, error) {
+	return e.bound(key).openAll(e.c.SMembers(key))
+}
+
+func (e *encrypted) SIsMember(key, member string) (bool, error) {
+	return e.c.SIsMember(key, e.bound(key).sealMember(member))
+}
+
+func (e *encrypted) Push(key string, value string) error {
+	return e.c.Push(key, e.bound(key).seal(value))
+}
+
+func (e *encrypted) LPush(key string, value string) error {
+	return e.c.LPush(key, e.bound(key).seal(value))
+}
+
+func (e *encrypted) RPush(key string, value string) error {
+	return e.c.RPush(key, e.bound(key).seal(value))
+}
+
+func (e *encrypted) RPushCapped(key string, value string, maxLen int64) error {
+	return e.c.RPushCapped(key, e.bound(key).seal(value), maxLen)
+}
+
+func (e *encrypted) Pop(key string) (string, error) {
+	return e.bound(key).openResult(e.c.Pop(key))
+}
+
+func (e *encrypted) LPop(key string) (string, error) {
+	return e.bound(key).openResult(e.c.LPop(key))
+}
+
+func (e *encrypted) RPop(key string) (string, error) {
+	return e.bound(key).openResult(e.c.RPop(key))
+}
+
+func (e *encrypted) PopAll(key string) ([]string, error) {
+	return e.bound(key).openAll(e.c.PopAll(key))
+}
+
+func (e *encrypted) Len(key string) (int64, error) {
+	return e.c.Len(key)
+}
+
+func (e *encrypted) LRange(key string, start, stop int64) ([]string, error) {
+	return e.bound(key).openAll(e.c.LRange(key, start, stop))
+}
+
+func (e *encrypted) LIndex(key string, index int64) (string, error) {
+	return e.bound(key).openResult(e.c.LIndex(key, index))
+}
+
+// LRem 读取并解密整个列表，找出要删除的元素后按密文逐个删除
+// 每个密文都是唯一的，删除不会影响其他元素；但整个操作不是原子的，并发写入同一个列表时结果可能与底层缓存的LRem不同
+func (e *encrypted) LRem(key string, count int64, value string) (int64, error) {
+	sealed, err := e.c.LRange(key, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	targets, err := e.bound(key).matching(sealed, count, value)
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, target := range targets {
+		n, err := e.c.LRem(key, 0, target)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+func (e *encrypted) LTrim(key string, start, stop int64) error {
+	return e.c.LTrim(key, start, stop)
+}
+
+func (e *encrypted) PopAck(key string, visibility time.Duration) (string, string, error) {
+	value, receipt, err := e.c.PopAck(key, visibility)
+	if err != nil {
+		return "", "", err
+	}
+	value, err = e.bound(key).open(value)
+	return value, receipt, err
+}
+
+func (e *encrypted) Ack(key, receipt string) error {
+	return e.c.Ack(key, receipt)
+}
+
+func (e *encrypted) PushDelayed(key, value string, delay time.Duration) error {
+	return e.c.PushDelayed(key, e.bound(key).seal(value), delay)
+}
+
+func (e *encrypted) Publish(channel string, payload string) error {
+	return e.c.Publish(channel, e.bound(channel).seal(payload))
+}
+
+// Subscribe 订阅频道，收到的消息已解密，无法解密的消息被丢弃
+func (e *encrypted) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	msgs, cancel := e.c.Subscribe(channel)
+	return forward(msgs, cancel, e.openMessage)
+}
+
+func (e *encrypted) SubscribeExpired() (<-chan string, func()) {
+	return e.c.SubscribeExpired()
+}
+
+func (e *encrypted) Stats() (_interface.Stats, error) {
+	return e.c.Stats()
+}
+
+// Backup 备份底层缓存的数据，值保持加密状态，恢复后需要使用相同的密钥读取
+func (e *encrypted) Backup(w io.Writer) error {
+	return e.c.Backup(w)
+}
+
+func (e *encrypted) Restore(r io.Reader) error {
+	return e.c.Restore(r)
+}
+
+func (e *encrypted) BeginTx() (_interface.Tx, error) {
+	tx, err := e.c.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{tx: tx, e: e}, nil
+}
+
+func (e *encrypted) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	tx, err := e.c.BeginTxWatch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{tx: tx, e: e}, nil
+}
+
+// BeginTxOptions 按选项开启底层缓存的事务
+func (e *encrypted) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	tx, err := _interface.BeginTx(ctx, e.c, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{tx: tx, e: e}, nil
+}
+
+// encryptedTx 加密事务，加密事务内写入的值
+type encryptedTx struct {
+	tx _interface.Tx
+	e  *encrypted
+}
+
+func (t *encryptedTx) Get(key string) (string, error) {
+	return t.e.bound(key).openResult(t.tx.Get(key))
+}
+
+func (t *encryptedTx) Exists(key string) (bool, error) {
+	return t.tx.Exists(key)
+}
+
+func (t *encryptedTx) Set(key string, value string, ttl time.Duration) error {
+	return t.tx.Set(key, t.e.bound(key).seal(value), ttl)
+}
+
+func (t *encryptedTx) Delete(key string) error {
+	return t.tx.Delete(key)
+}
+
+func (t *encryptedTx) Expire(key string, ttl time.Duration) error {
+	return t.tx.Expire(key, ttl)
+}
+
+func (t *encryptedTx) HSet(key, field, value string, ttl time.Duration) error {
+	return t.tx.HSet(key, field, t.e.bound(key, field).seal(value), ttl)
+}
+
+func (t *encryptedTx) HDel(key, field string) error {
+	return t.tx.HDel(key, field)
+}
+
+func (t *encryptedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *encryptedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// encryptedCtx 底层缓存实现了CacheCtx时使用的加密装饰器
+type encryptedCtx struct {
+	*encrypted
+	cc _interface.CacheCtx
+}
+
+func (e *encryptedCtx) GetContext(ctx context.Context, key string) (string, error) {
+	return e.bound(key).openResult(e.cc.GetContext(ctx, key))
+}
+
+func (e *encryptedCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return e.cc.SetContext(ctx, key, e.bound(key).seal(value), ttl)
+}
+
+func (e *encryptedCtx) DeleteContext(ctx context.Context, key string) error {
+	return e.cc.DeleteContext(ctx, key)
+}
+
+func (e *encryptedCtx) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return e.cc.ExistsContext(ctx, key)
+}
+
+func (e *encryptedCtx) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	return e.cc.ExpireContext(ctx, key, ttl)
+}
+
+func (e *encryptedCtx) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	return e.cc.TTLContext(ctx, key)
+}
+
+func (e *encryptedCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return e.cc.SetNXContext(ctx, key, e.bound(key).seal(value), ttl)
+}
+
+func (e *encryptedCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	get := func(key string) (string, error) { return e.cc.GetContext(ctx, key) }
+	return e.cas(key, oldValue, newValue, get, func(oldSealed, newSealed string) (bool, error) {
+		return e.cc.CASContext(ctx, key, oldSealed, newSealed, ttl)
+	})
+}
+
+func (e *encryptedCtx) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	return e.bound(key).openResult(e.cc.GetSetContext(ctx, key, e.bound(key).seal(value)))
+}
+
+func (e *encryptedCtx) GetDelContext(ctx context.Context, key string) (string, error) {
+	return e.bound(key).openResult(e.cc.GetDelContext(ctx, key))
+}
+
+func (e *encryptedCtx) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	return e.append(ctx, key, value,
+		func(key string) (string, error) { return e.cc.GetContext(ctx, key) },
+		func(key string) (time.Duration, error) { return e.cc.TTLContext(ctx, key) },
+		func(key, value string, ttl time.Duration) (bool, error) {
+			return e.cc.SetNXContext(ctx, key, value, ttl)
+		},
+		func(key, oldValue, newValue string, ttl time.Duration) (bool, error) {
+			return e.cc.CASContext(ctx, key, oldValue, newValue, ttl)
+		})
+}
+
+func (e *encryptedCtx) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return strLen(e.GetContext(ctx, key))
+}
+
+func (e *encryptedCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := e.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.SetContext(ctx, key, string(data), ttl)
+}
+
+func (e *encryptedCtx) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := e.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return e.codec.Unmarshal([]byte(raw), v)
+}
+
+func (e *encryptedCtx) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	return e.cc.KeysContext(ctx, pattern, fn)
+}
+
+func (e *encryptedCtx) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	return e.iterate(prefix, fn, func(prefix string, fn func(key, value string) bool) error {
+		return e.cc.IterateContext(ctx, prefix, fn)
+	})
+}
+
+func (e *encryptedCtx) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	return e.cc.DeleteByPrefixContext(ctx, prefix)
+}
+
+func (e *encryptedCtx) HGetContext(ctx context.Context, key, field string) (string, error) {
+	return e.bound(key, field).openResult(e.cc.HGetContext(ctx, key, field))
+}
+
+func (e *encryptedCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	return e.cc.HSetContext(ctx, key, field, e.bound(key, field).seal(value), ttl)
+}
+
+func (e *encryptedCtx) HDelContext(ctx context.Context, key, field string) error {
+	return e.cc.HDelContext(ctx, key, field)
+}
+
+func (e *encryptedCtx) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	return e.bound(key).openMap(e.cc.HGetAllContext(ctx, key))
+}
+
+func (e *encryptedCtx) HLenContext(ctx context.Context, key string) (int64, error) {
+	return e.cc.HLenContext(ctx, key)
+}
+
+func (e *encryptedCtx) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	return e.cc.HExistsContext(ctx, key, field)
+}
+
+func (e *encryptedCtx) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	return e.cc.HKeysContext(ctx, key)
+}
+
+func (e *encryptedCtx) SAddContext(ctx context.Context, key, member string) error {
+	return e.cc.SAddContext(ctx, key, e.bound(key).sealMember(member))
+}
+
+func (e *encryptedCtx) SRemContext(ctx context.Context, key, member string) error {
+	return e.cc.SRemContext(ctx, key, e.bound(key).sealMember(member))
+}
+
+func (e *encryptedCtx) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return e.bound(key).openAll(e.cc.SMembersContext(ctx, key))
+}
+
+func (e *encryptedCtx) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	return e.cc.SIsMemberContext(ctx, key, e.bound(key).sealMember(member))
+}
+
+func (e *encryptedCtx) PushContext(ctx context.Context, key string, value string) error {
+	return e.cc.PushContext(ctx, key, e.bound(key).seal(value))
+}
+
+func (e *encryptedCtx) LPushContext(ctx context.Context, key string, value string) error {
+	return e.cc.LPushContext(ctx, key, e.bound(key).seal(value))
+}
+
+func (e *encryptedCtx) RPushContext(ctx context.Context, key string, value string) error {
+	return e.cc.RPushContext(ctx, key, e.bound(key).seal(value))
+}
+
+func (e *encryptedCtx) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	return e.cc.RPushCappedContext(ctx, key, e.bound(key).seal(value), maxLen)
+}
+
+func (e *encryptedCtx) PopContext(ctx context.Context, key string) (string, error) {
+	return e.bound(key).openResult(e.cc.PopContext(ctx, key))
+}
+
+func (e *encryptedCtx) LPopContext(ctx context.Context, key string) (string, error) {
+	return e.bound(key).openResult(e.cc.LPopContext(ctx, key))
+}
+
+func (e *encryptedCtx) RPopContext(ctx context.Context, key string) (string, error) {
+	return e.bound(key).openResult(e.cc.RPopContext(ctx, key))
+}
+
+func (e *encryptedCtx) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	return e.bound(key).openAll(e.cc.PopAllContext(ctx, key))
+}
+
+func (e *encryptedCtx) LenContext(ctx context.Context, key string) (int64, error) {
+	return e.cc.LenContext(ctx, key)
+}
+
+func (e *encryptedCtx) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return e.bound(key).openAll(e.cc.LRangeContext(ctx, key, start, stop))
+}
+
+func (e *encryptedCtx) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return e.bound(key).openResult(e.cc.LIndexContext(ctx, key, index))
+}
+
+func (e *encryptedCtx) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	sealed, err := e.cc.LRangeContext(ctx, key, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	targets, err := e.bound(key).matching(sealed, count, value)
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, target := range targets {
+		n, err := e.cc.LRemContext(ctx, key, 0, target)
+		if err != nil {
+			return removed, err
+		}
+		removed += n
+	}
+	return removed, nil
+}
+
+func (e *encryptedCtx) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	return e.cc.LTrimContext(ctx, key, start, stop)
+}
+
+func (e *encryptedCtx) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	value, receipt, err := e.cc.PopAckContext(ctx, key, visibility)
+	if err != nil {
+		return "", "", err
+	}
+	value, err = e.bound(key).open(value)
+	return value, receipt, err
+}
+
+func (e *encryptedCtx) AckContext(ctx context.Context, key, receipt string) error {
+	return e.cc.AckContext(ctx, key, receipt)
+}
+
+func (e *encryptedCtx) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	return e.cc.PushDelayedContext(ctx, key, e.bound(key).seal(value), delay)
+}
+
+func (e *encryptedCtx) PublishContext(ctx context.Context, channel string, payload string) error {
+	return e.cc.PublishContext(ctx, channel, e.bound(channel).seal(payload))
+}
+
+func (e *encryptedCtx) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	msgs, cancel := e.cc.SubscribeContext(ctx, channel)
+	return forward(msgs, cancel, e.openMessage)
+}
+
+func (e *encryptedCtx) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return e.cc.SubscribeExpiredContext(ctx)
+}
+
+func (e *encryptedCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return e.cc.StatsContext(ctx)
+}
+
+func (e *encryptedCtx) BackupContext(ctx context.Context, w io.Writer) error {
+	return e.cc.BackupContext(ctx, w)
+}
+
+func (e *encryptedCtx) RestoreContext(ctx context.Context, r io.Reader) error {
+	return e.cc.RestoreContext(ctx, r)
+}
+
+func (e *encryptedCtx) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	tx, err := e.cc.BeginTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{tx: tx, e: e.encrypted}, nil
+}
+
+func (e *encryptedCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx, err := e.cc.BeginTxWatchContext(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedTx{tx: tx, e: e.encrypted}, nil
+}
@@ -0,0 +1,1431 @@
+// etcd包：基于etcd的分布式缓存实现
+// 提供键值存储、哈希表操作和事务支持
+//
+// etcd是一个强一致的分布式键值存储，常用于配置管理和服务协调
+// 本包实现了Cache和CacheCtx接口，让集群部署中的配置/协调场景也能使用统一的缓存操作API
+//
+// 主要特性：
+// - 强一致的分布式存储
+// - TTL通过租约（Lease）实现
+// - 哈希表操作（通过前缀复合键 \x00h\x00key\x00field 实现，兼容旧版本的 key:field）
+// - 集合操作（通过前缀复合键 key\x00member 实现）
+// - 事务支持（提交时在一个etcd事务中原子执行）
+// - 发布订阅（基于Watch，频道对应 __pubsub/<channel> 键）
+// - 原生上下文支持，可用于超时控制和链路追踪
+//
+// 限制：
+// - 不支持队列操作，Push/Pop/LPush/RPush/RPushCapped/LPop/RPop/PopAll/Len/LRange/LIndex/LRem/LTrim/PopAck/Ack返回ErrUnsupported
+//
+// 作者: gophertool
+package etcd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// 包初始化时注册etcd驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverEtcd, NewEtcdClient)
+}
+
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
+// channelPrefix 发布订阅频道对应的key前缀
+const channelPrefix = "__pubsub/"
+
+// dialTimeout 连接etcd集群的超时时间
+const dialTimeout = 5 * time.Second
+
+// EtcdDb etcd缓存实现结构体
+type EtcdDb struct {
+	db         *clientv3.Client // etcd客户端实例
+	codec      _interface.Codec // SetObject/GetObject的编码方式
+	legacyHash atomic.Bool      // 集群中可能有key:field形式的旧哈希表字段
+}
+
+func (e *EtcdDb) Close() {
+	_ = e.db.Close()
+}
+
+// GetContext 获取指定key的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (e *EtcdDb) GetContext(ctx context.Context, key string) (string, error) {
+	resp, err := e.db.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+// SetContext 设置key-value，ttl大于0时绑定一个新的租约
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	opts, err := e.leaseOptions(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	_, err = e.db.Put(ctx, key, value, opts...)
+	return err
+}
+
+func (e *EtcdDb) DeleteContext(ctx context.Context, key string) error {
+	_, err := e.db.Delete(ctx, key)
+	return err
+}
+
+func (e *EtcdDb) ExistsContext(ctx context.Context, key string) (bool, error) {
+	resp, err := e.db.Get(ctx, key, clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	return resp.Count > 0, nil
+}
+
+// ExpireContext 设置key的过期时间
+// etcd的租约只能在写入时绑定，因此会以新的租约重新写入原值，
+// 写入前比较修改版本，避免覆盖并发写入的新值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (e *EtcdDb) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	resp, err := e.db.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) == 0 {
+		return _interface.ErrKeyNotFound
+	}
+	kv := resp.Kvs[0]
+
+	opts, err := e.leaseOptions(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	txnResp, err := e.db.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)).
+		Then(clientv3.OpPut(key, string(kv.Value), opts...)).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !txnResp.Succeeded {
+		// 读取之后key被修改或删除，按最新状态重试
+		return e.ExpireContext(ctx, key, ttl)
+	}
+	return nil
+}
+
+// TTLContext 获取key的剩余过期时间，通过查询key绑定的租约实现，精确到秒
+// key不是普通键值而是哈希表时，字段各自绑定租约，返回其中最长的剩余过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在时返回TTLNotFound
+//	error - 操作错误
+func (e *EtcdDb) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	resp, err := e.db.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return e.hashTTL(ctx, key)
+	}
+	leaseID := clientv3.LeaseID(resp.Kvs[0].Lease)
+	if leaseID == clientv3.NoLease {
+		return _interface.TTLNoExpiry, nil
+	}
+
+	lease, err := e.db.TimeToLive(ctx, leaseID)
+	if err != nil {
+		return 0, err
+	}
+	// 租约已过期时TTL为-1
+	if lease.TTL <= 0 {
+		return _interface.TTLNotFound, nil
+	}
+	return time.Duration(lease.TTL) * time.Second, nil
+}
+
+// KeysContext 遍历匹配pattern的key，通配符语法与Redis一致
+// 哈希表字段的复合键不会被遍历到；按key分页读取，每页scanBatchSize个
+// 参数：
+//
+//	ctx - 上下文
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	prefix := _interface.PatternPrefix(pattern)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	start := prefix
+	if start == "" {
+		start = "\x00"
+	}
+	for {
+		resp, err := e.db.Get(ctx, start,
+			clientv3.WithRange(end),
+			clientv3.WithKeysOnly(),
+			clientv3.WithLimit(scanBatchSize),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			key := string(kv.Key)
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// IterateContext 遍历键名以prefix开头的键值
+// 遍历范围与Keys一致；按key分页读取，每页scanBatchSize个
+// 参数：
+//
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	start := prefix
+	if start == "" {
+		start = "\x00"
+	}
+	for {
+		resp, err := e.db.Get(ctx, start,
+			clientv3.WithRange(end),
+			clientv3.WithLimit(scanBatchSize),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			key := string(kv.Key)
+			if strings.HasPrefix(key, _interface.HashKeyPrefix) {
+				continue
+			}
+			if !fn(key, string(kv.Value)) {
+				return nil
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return nil
+		}
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// DeleteByPrefixContext 删除所有以prefix开头的key，包括键名以prefix开头的哈希表，在一个etcd事务中范围删除
+// 参数：
+//
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	if prefix == "" {
+		if _, err := e.db.Delete(ctx, "\x00", clientv3.WithFromKey()); err != nil {
+			return err
+		}
+		// 集群已经清空，重新写入新编码的标记
+		if _, err := e.db.Put(ctx, _interface.HashEncodingMarker, ""); err != nil {
+			return err
+		}
+		e.legacyHash.Store(false)
+		return nil
+	}
+	_, err := e.db.Txn(ctx).Then(
+		clientv3.OpDelete(prefix, clientv3.WithPrefix()),
+		clientv3.OpDelete(_interface.HashKeyPrefix+prefix, clientv3.WithPrefix()),
+	).Commit()
+	return err
+}
+
+// SetNXContext key不存在时设置key-value并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (e *EtcdDb) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	opts, err := e.leaseOptions(ctx, ttl)
+	if err != nil {
+		return false, err
+	}
+	resp, err := e.db.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, value, opts...)).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// CASContext key的当前值等于oldValue时设置为newValue并设置过期时间
+// 比较和写入在同一个etcd事务中执行
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (e *EtcdDb) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	opts, err := e.leaseOptions(ctx, ttl)
+	if err != nil {
+		return false, err
+	}
+	resp, err := e.db.Txn(ctx).
+		If(clientv3.Compare(clientv3.Value(key), "=", oldValue)).
+		Then(clientv3.OpPut(key, newValue, opts...)).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+// GetSetContext 设置新值并返回旧值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (e *EtcdDb) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	resp, err := e.db.Put(ctx, key, value, clientv3.WithPrevKV())
+	if err != nil {
+		return "", err
+	}
+	if resp.PrevKv == nil {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(resp.PrevKv.Value), nil
+}
+
+// GetDelContext 获取并删除指定key
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (e *EtcdDb) GetDelContext(ctx context.Context, key string) (string, error) {
+	resp, err := e.db.Delete(ctx, key, clientv3.WithPrevKV())
+	if err != nil {
+		return "", err
+	}
+	if len(resp.PrevKvs) == 0 {
+		return "", _interface.ErrKeyNotFound
+	}
+	return string(resp.PrevKvs[0].Value), nil
+}
+
+// AppendContext 读取key的值并在修订版本不变的条件下写入追加后的值，key不存在时创建不过期的key，
+// 已有的租约保持不变；与并发的写入冲突时按随机退避重新读取并重试，直到追加成功或ctx结束
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误或ctx.Err()
+func (e *EtcdDb) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	var n int64
+	err := _interface.RetryConflict(ctx, func() (bool, error) {
+		resp, err := e.db.Get(ctx, key)
+		if err != nil {
+			return false, err
+		}
+		data := value
+		cmp := clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		var opts []clientv3.OpOption
+		if len(resp.Kvs) > 0 {
+			kv := resp.Kvs[0]
+			data = string(kv.Value) + value
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)
+			if kv.Lease != 0 {
+				opts = append(opts, clientv3.WithLease(clientv3.LeaseID(kv.Lease)))
+			}
+		}
+		txnResp, err := e.db.Txn(ctx).If(cmp).Then(clientv3.OpPut(key, data, opts...)).Commit()
+		if err != nil {
+			return false, err
+		}
+		n = int64(len(data))
+		return txnResp.Succeeded, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// StrLenContext 返回key的值的长度（字节数），key不存在时返回0
+func (e *EtcdDb) StrLenContext(ctx context.Context, key string) (int64, error) {
+	resp, err := e.db.Get(ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		return 0, err
+	}
+	return int64(len(resp.Kvs[0].Value)), nil
+}
+
+// SetObjectContext 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (e *EtcdDb) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := e.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return e.SetContext(ctx, key, string(data), ttl)
+}
+
+// GetObjectContext 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (e *EtcdDb) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := e.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return e.codec.Unmarshal([]byte(raw), v)
+}
+
+// HGetContext 获取哈希表中指定field的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在时返回ErrKeyNotFound
+func (e *EtcdDb) HGetContext(ctx context.Context, key, field string) (string, error) {
+	val, err := e.GetContext(ctx, _interface.HashKey(key, field))
+	if errors.Is(err, _interface.ErrKeyNotFound) && e.legacyHash.Load() {
+		return e.GetContext(ctx, _interface.LegacyHashKey(key, field))
+	}
+	return val, err
+}
+
+// HSetContext 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 字段的过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	ops, err := e.hsetOps(ctx, key, field, value, ttl)
+	if err != nil {
+		return err
+	}
+	_, err = e.db.Txn(ctx).Then(ops...).Commit()
+	return err
+}
+
+// HDelContext 删除哈希表中的field，字段不存在时不报错
+func (e *EtcdDb) HDelContext(ctx context.Context, key, field string) error {
+	_, err := e.db.Txn(ctx).Then(e.hdelOps(key, field)...).Commit()
+	return err
+}
+
+// HGetAllContext 获取哈希表中所有的field和value
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射
+//	error - 操作错误
+func (e *EtcdDb) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := e.rangeHash(ctx, key, false, func(field string, value []byte, _ clientv3.LeaseID) {
+		result[field] = string(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// HLenContext 获取哈希表中field的数量
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	int64 - 字段数量，哈希表不存在时返回0
+//	error - 操作错误
+func (e *EtcdDb) HLenContext(ctx context.Context, key string) (int64, error) {
+	fields, err := e.HKeysContext(ctx, key)
+	return int64(len(fields)), err
+}
+
+// HExistsContext 判断哈希表中是否存在field
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	bool - 字段是否存在
+//	error - 操作错误
+func (e *EtcdDb) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	_, err := e.HGetContext(ctx, key, field)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HKeysContext 获取哈希表中所有的field，按字段名排序
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (e *EtcdDb) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	seen := make(map[string]struct{})
+	err := e.rangeHash(ctx, key, true, func(field string, _ []byte, _ clientv3.LeaseID) {
+		seen[field] = struct{}{}
+	})
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(seen))
+	for field := range seen {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// SAddContext 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) SAddContext(ctx context.Context, key, member string) error {
+	_, err := e.db.Put(ctx, setKey(key, member), "")
+	return err
+}
+
+// SRemContext 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) SRemContext(ctx context.Context, key, member string) error {
+	_, err := e.db.Delete(ctx, setKey(key, member))
+	return err
+}
+
+// SMembersContext 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (e *EtcdDb) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	prefix := setKey(key, "")
+	resp, err := e.db.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		members = append(members, strings.TrimPrefix(string(kv.Key), prefix))
+	}
+	return members, nil
+}
+
+// SIsMemberContext 判断member是否为集合成员
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (e *EtcdDb) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	resp, err := e.db.Get(ctx, setKey(key, member), clientv3.WithCountOnly())
+	if err != nil {
+		return false, err
+	}
+	return resp.Count > 0, nil
+}
+
+// etcd没有列表结构，队列操作统一返回ErrUnsupported
+
+func (e *EtcdDb) PushContext(ctx context.Context, key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LPushContext(ctx context.Context, key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) RPushContext(ctx context.Context, key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) PopContext(ctx context.Context, key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LPopContext(ctx context.Context, key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) RPopContext(ctx context.Context, key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LenContext(ctx context.Context, key string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	return "", "", _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) AckContext(ctx context.Context, key, receipt string) error {
+	return _interface.ErrUnsupported
+}
+
+func (e *EtcdDb) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	return _interface.ErrUnsupported
+}
+
+// PublishContext 向频道发布消息，通过写入频道对应的key触发订阅者的Watch
+// 频道对应的key会保留最后一条消息
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) PublishContext(ctx context.Context, channel string, payload string) error {
+	_, err := e.db.Put(ctx, channelKey(channel), payload)
+	return err
+}
+
+// SubscribeContext 通过Watch频道对应的key订阅频道，上下文取消或超时时自动取消订阅
+// 返回前等待Watch建立，保证之后发布的消息都能收到；订阅失败时返回已关闭的消息通道
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅后被关闭
+//	func() - 取消订阅函数
+func (e *EtcdDb) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	out := make(chan _interface.Message)
+	watchCtx, cancel := context.WithCancel(ctx)
+	watch := e.db.Watch(watchCtx, channelKey(channel), clientv3.WithFilterDelete(), clientv3.WithCreatedNotify())
+	if resp, ok := <-watch; !ok || !resp.Created {
+		cancel()
+		close(out)
+		return out, func() {}
+	}
+
+	go func() {
+		defer close(out)
+		for resp := range watch {
+			for _, ev := range resp.Events {
+				select {
+				case out <- _interface.Message{Channel: channel, Payload: string(ev.Kv.Value)}:
+				case <-watchCtx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, cancel
+}
+
+// SubscribeExpiredContext etcd的租约到期和主动删除产生相同的DELETE事件，无法区分，返回已关闭的通道
+func (e *EtcdDb) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return _interface.ClosedExpiry(), func() {}
+}
+
+func (e *EtcdDb) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &etcdTx{db: e, ctx: ctx}, nil
+}
+
+// BeginTxWatchContext 开启监视keys的事务
+// 开启时记录keys的修订版本，提交时作为etcd事务的比较条件，keys被修改或删除时Commit返回ErrTxConflict
+func (e *EtcdDb) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx := &etcdTx{db: e, ctx: ctx}
+	for _, key := range keys {
+		resp, err := e.db.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		var rev int64 // 不存在的key修订版本为0
+		if len(resp.Kvs) > 0 {
+			rev = resp.Kvs[0].ModRevision
+		}
+		tx.cmps = append(tx.cmps, clientv3.Compare(clientv3.ModRevision(key), "=", rev))
+	}
+	return tx, nil
+}
+
+// etcdTx etcd事务实现
+// 操作先缓存在内存中，Commit时在一个etcd事务中原子执行
+type etcdTx struct {
+	db   *EtcdDb
+	ctx  context.Context
+	ops  []clientv3.Op
+	cmps []clientv3.Cmp // BeginTxWatch监视的key的修订版本比较
+}
+
+func (tx *etcdTx) Get(key string) (string, error) {
+	value, ok, err := tx.get(key)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (tx *etcdTx) Exists(key string) (bool, error) {
+	_, ok, err := tx.get(key)
+	return ok, err
+}
+
+func (tx *etcdTx) Set(key string, value string, ttl time.Duration) error {
+	opts, err := tx.db.leaseOptions(tx.ctx, ttl)
+	if err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, clientv3.OpPut(key, value, opts...))
+	return nil
+}
+
+func (tx *etcdTx) Delete(key string) error {
+	tx.ops = append(tx.ops, clientv3.OpDelete(key))
+	return nil
+}
+
+// Expire 使用新的租约重新写入当前值，key 不存在时不做任何操作
+func (tx *etcdTx) Expire(key string, ttl time.Duration) error {
+	value, ok, err := tx.get(key)
+	if err != nil || !ok {
+		return err
+	}
+	return tx.Set(key, value, ttl)
+}
+
+func (tx *etcdTx) HSet(key, field, value string, ttl time.Duration) error {
+	ops, err := tx.db.hsetOps(tx.ctx, key, field, value, ttl)
+	if err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, ops...)
+	return nil
+}
+
+func (tx *etcdTx) HDel(key, field string) error {
+	tx.ops = append(tx.ops, tx.db.hdelOps(key, field)...)
+	return nil
+}
+
+// Commit 在一个etcd事务中执行所有操作，监视的key的修订版本变化时返回ErrTxConflict
+func (tx *etcdTx) Commit() error {
+	ops := tx.ops
+	tx.ops = nil
+	if len(ops) == 0 && len(tx.cmps) == 0 {
+		return nil
+	}
+	resp, err := tx.db.db.Txn(tx.ctx).If(tx.cmps...).Then(ops...).Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return _interface.ErrTxConflict
+	}
+	return nil
+}
+
+func (tx *etcdTx) Rollback() error {
+	tx.ops = nil
+	return nil
+}
+
+// get 读取key在事务内的值，事务内已经写入或删除的key以事务内的操作为准
+func (tx *etcdTx) get(key string) (string, bool, error) {
+	for i := len(tx.ops) - 1; i >= 0; i-- {
+		op := tx.ops[i]
+		if string(op.KeyBytes()) != key {
+			continue
+		}
+		if op.IsDelete() {
+			return "", false, nil
+		}
+		if op.IsPut() {
+			return string(op.ValueBytes()), true, nil
+		}
+	}
+	resp, err := tx.db.db.Get(tx.ctx, key)
+	if err != nil || len(resp.Kvs) == 0 {
+		return "", false, err
+	}
+	return string(resp.Kvs[0].Value), true, nil
+}
+
+// leaseOptions ttl大于0时创建租约并返回绑定租约的写入选项
+// etcd租约以秒为单位，不足1秒向上取整
+func (e *EtcdDb) leaseOptions(ctx context.Context, ttl time.Duration) ([]clientv3.OpOption, error) {
+	if ttl <= 0 {
+		return nil, nil
+	}
+	lease, err := e.db.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return nil, err
+	}
+	return []clientv3.OpOption{clientv3.WithLease(lease.ID)}, nil
+}
+
+// channelKey 生成发布订阅频道对应的key
+func channelKey(channel string) string {
+	return channelPrefix + channel
+}
+
+// setKey 生成集合成员对应的复合键，使用\x00分隔，避免与普通键冲突
+func setKey(key, member string) string {
+	return key + "\x00" + member
+}
+
+// hsetOps 返回写入哈希表字段的操作，兼容模式下同时删除同名的旧字段
+func (e *EtcdDb) hsetOps(ctx context.Context, key, field, value string, ttl time.Duration) ([]clientv3.Op, error) {
+	opts, err := e.leaseOptions(ctx, ttl)
+	if err != nil {
+		return nil, err
+	}
+	ops := []clientv3.Op{clientv3.OpPut(_interface.HashKey(key, field), value, opts...)}
+	if e.legacyHash.Load() {
+		ops = append(ops, clientv3.OpDelete(_interface.LegacyHashKey(key, field)))
+	}
+	return ops, nil
+}
+
+// hdelOps 返回删除哈希表字段的操作，兼容模式下同时删除同名的旧字段
+func (e *EtcdDb) hdelOps(key, field string) []clientv3.Op {
+	ops := []clientv3.Op{clientv3.OpDelete(_interface.HashKey(key, field))}
+	if e.legacyHash.Load() {
+		ops = append(ops, clientv3.OpDelete(_interface.LegacyHashKey(key, field)))
+	}
+	return ops
+}
+
+// rangeHash 读取哈希表的字段，fn的参数为字段名、字段值和绑定的租约
+// 兼容模式下先读取key:field形式的旧字段，同名的新字段随后读取，调用方按字段名覆盖即可
+func (e *EtcdDb) rangeHash(ctx context.Context, key string, keysOnly bool, fn func(field string, value []byte, lease clientv3.LeaseID)) error {
+	prefixes := []string{_interface.HashFieldPrefix(key)}
+	if e.legacyHash.Load() {
+		prefixes = append([]string{_interface.LegacyHashKey(key, "")}, prefixes...)
+	}
+	opts := []clientv3.OpOption{clientv3.WithPrefix()}
+	if keysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	for _, prefix := range prefixes {
+		resp, err := e.db.Get(ctx, prefix, opts...)
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			fn(strings.TrimPrefix(string(kv.Key), prefix), kv.Value, clientv3.LeaseID(kv.Lease))
+		}
+	}
+	return nil
+}
+
+// hashTTL 返回哈希表字段中最长的剩余过期时间，任意字段没有绑定租约时返回TTLNoExpiry
+func (e *EtcdDb) hashTTL(ctx context.Context, key string) (time.Duration, error) {
+	var ids []clientv3.LeaseID
+	err := e.rangeHash(ctx, key, true, func(_ string, _ []byte, lease clientv3.LeaseID) {
+		ids = append(ids, lease)
+	})
+	if err != nil {
+		return 0, err
+	}
+	leases := make(map[clientv3.LeaseID]time.Duration)
+	ttl := _interface.TTLNotFound
+	for _, id := range ids {
+		d, err := e.leaseTTL(ctx, leases, id)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case d == 0:
+			return _interface.TTLNoExpiry, nil
+		case d > ttl:
+			ttl = d
+		}
+	}
+	return ttl, nil
+}
+
+// initHashEncoding 检查哈希表字段的编码
+// 集群中有新编码的标记，或者集群为空（写入标记）时只使用新编码，否则进入兼容模式
+func (e *EtcdDb) initHashEncoding(ctx context.Context) error {
+	resp, err := e.db.Get(ctx, _interface.HashEncodingMarker)
+	if err != nil || len(resp.Kvs) > 0 {
+		return err
+	}
+	resp, err = e.db.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithKeysOnly(), clientv3.WithLimit(1))
+	if err != nil {
+		return err
+	}
+	if len(resp.Kvs) > 0 {
+		e.legacyHash.Store(true)
+		return nil
+	}
+	_, err = e.db.Put(ctx, _interface.HashEncodingMarker, "")
+	return err
+}
+
+// 不带上下文的方法使用 context.Background()，与带上下文的方法共用实现
+
+func (e *EtcdDb) Get(key string) (string, error) {
+	return e.GetContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Set(key string, value string, ttl time.Duration) error {
+	return e.SetContext(context.Background(), key, value, ttl)
+}
+
+func (e *EtcdDb) Delete(key string) error {
+	return e.DeleteContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Exists(key string) (bool, error) {
+	return e.ExistsContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Expire(key string, ttl time.Duration) error {
+	return e.ExpireContext(context.Background(), key, ttl)
+}
+
+func (e *EtcdDb) TTL(key string) (time.Duration, error) {
+	return e.TTLContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Keys(pattern string, fn func(key string) bool) error {
+	return e.KeysContext(context.Background(), pattern, fn)
+}
+
+func (e *EtcdDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	return e.IterateContext(context.Background(), prefix, fn)
+}
+
+func (e *EtcdDb) DeleteByPrefix(prefix string) error {
+	return e.DeleteByPrefixContext(context.Background(), prefix)
+}
+
+func (e *EtcdDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return e.SetNXContext(context.Background(), key, value, ttl)
+}
+
+func (e *EtcdDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return e.CASContext(context.Background(), key, oldValue, newValue, ttl)
+}
+
+func (e *EtcdDb) GetSet(key string, value string) (string, error) {
+	return e.GetSetContext(context.Background(), key, value)
+}
+
+func (e *EtcdDb) GetDel(key string) (string, error) {
+	return e.GetDelContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Append(key string, value string) (int64, error) {
+	return e.AppendContext(context.Background(), key, value)
+}
+
+func (e *EtcdDb) StrLen(key string) (int64, error) {
+	return e.StrLenContext(context.Background(), key)
+}
+
+func (e *EtcdDb) SetObject(key string, v any, ttl time.Duration) error {
+	return e.SetObjectContext(context.Background(), key, v, ttl)
+}
+
+func (e *EtcdDb) GetObject(key string, v any) error {
+	return e.GetObjectContext(context.Background(), key, v)
+}
+
+func (e *EtcdDb) HGet(key, field string) (string, error) {
+	return e.HGetContext(context.Background(), key, field)
+}
+
+func (e *EtcdDb) HSet(key, field, value string, ttl time.Duration) error {
+	return e.HSetContext(context.Background(), key, field, value, ttl)
+}
+
+func (e *EtcdDb) HDel(key, field string) error {
+	return e.HDelContext(context.Background(), key, field)
+}
+
+func (e *EtcdDb) HGetAll(key string) (map[string]string, error) {
+	return e.HGetAllContext(context.Background(), key)
+}
+
+func (e *EtcdDb) HLen(key string) (int64, error) {
+	return e.HLenContext(context.Background(), key)
+}
+
+func (e *EtcdDb) HExists(key, field string) (bool, error) {
+	return e.HExistsContext(context.Background(), key, field)
+}
+
+func (e *EtcdDb) HKeys(key string) ([]string, error) {
+	return e.HKeysContext(context.Background(), key)
+}
+
+func (e *EtcdDb) SAdd(key, member string) error {
+	return e.SAddContext(context.Background(), key, member)
+}
+
+func (e *EtcdDb) SRem(key, member string) error {
+	return e.SRemContext(context.Background(), key, member)
+}
+
+func (e *EtcdDb) SMembers(key string) ([]string, error) {
+	return e.SMembersContext(context.Background(), key)
+}
+
+func (e *EtcdDb) SIsMember(key, member string) (bool, error) {
+	return e.SIsMemberContext(context.Background(), key, member)
+}
+
+func (e *EtcdDb) Push(key string, value string) error {
+	return e.PushContext(context.Background(), key, value)
+}
+
+func (e *EtcdDb) LPush(key string, value string) error {
+	return e.LPushContext(context.Background(), key, value)
+}
+
+func (e *EtcdDb) RPush(key string, value string) error {
+	return e.RPushContext(context.Background(), key, value)
+}
+
+func (e *EtcdDb) RPushCapped(key string, value string, maxLen int64) error {
+	return e.RPushCappedContext(context.Background(), key, value, maxLen)
+}
+
+func (e *EtcdDb) Pop(key string) (string, error) {
+	return e.PopContext(context.Background(), key)
+}
+
+func (e *EtcdDb) LPop(key string) (string, error) {
+	return e.LPopContext(context.Background(), key)
+}
+
+func (e *EtcdDb) RPop(key string) (string, error) {
+	return e.RPopContext(context.Background(), key)
+}
+
+func (e *EtcdDb) PopAll(key string) ([]string, error) {
+	return e.PopAllContext(context.Background(), key)
+}
+
+func (e *EtcdDb) Len(key string) (int64, error) {
+	return e.LenContext(context.Background(), key)
+}
+
+func (e *EtcdDb) LRange(key string, start, stop int64) ([]string, error) {
+	return e.LRangeContext(context.Background(), key, start, stop)
+}
+
+func (e *EtcdDb) LIndex(key string, index int64) (string, error) {
+	return e.LIndexContext(context.Background(), key, index)
+}
+
+func (e *EtcdDb) LRem(key string, count int64, value string) (int64, error) {
+	return e.LRemContext(context.Background(), key, count, value)
+}
+
+func (e *EtcdDb) LTrim(key string, start, stop int64) error {
+	return e.LTrimContext(context.Background(), key, start, stop)
+}
+
+func (e *EtcdDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return e.PopAckContext(context.Background(), key, visibility)
+}
+
+func (e *EtcdDb) Ack(key, receipt string) error {
+	return e.AckContext(context.Background(), key, receipt)
+}
+
+func (e *EtcdDb) PushDelayed(key, value string, delay time.Duration) error {
+	return e.PushDelayedContext(context.Background(), key, value, delay)
+}
+
+func (e *EtcdDb) Publish(channel string, payload string) error {
+	return e.PublishContext(context.Background(), channel, payload)
+}
+
+func (e *EtcdDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return e.SubscribeContext(context.Background(), channel)
+}
+
+func (e *EtcdDb) SubscribeExpired() (<-chan string, func()) {
+	return e.SubscribeExpiredContext(context.Background())
+}
+
+func (e *EtcdDb) BeginTx() (_interface.Tx, error) {
+	return e.BeginTxContext(context.Background())
+}
+
+func (e *EtcdDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return e.BeginTxWatchContext(context.Background(), keys...)
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*EtcdDb)(nil)
+
+// StatsContext 返回etcd的统计信息
+// 键数量为集群中所有键的数量，包含哈希表字段的复合键；磁盘占用为第一个端点上后端数据库的大小
+// 参数：
+//
+//	ctx - 上下文
+//
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 操作错误
+func (e *EtcdDb) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverEtcd)
+	resp, err := e.db.Get(ctx, "\x00", clientv3.WithFromKey(), clientv3.WithCountOnly())
+	if err != nil {
+		return stats, err
+	}
+	stats.Keys = resp.Count
+
+	if endpoints := e.db.Endpoints(); len(endpoints) > 0 {
+		status, err := e.db.Status(ctx, endpoints[0])
+		if err != nil {
+			return stats, err
+		}
+		stats.DiskBytes = status.DbSize
+	}
+	return stats, nil
+}
+
+func (e *EtcdDb) Stats() (_interface.Stats, error) {
+	return e.StatsContext(context.Background())
+}
+
+// backupPageSize Backup每次读取的key数量
+const backupPageSize = 1000
+
+// BackupContext 分页读取所有key，将值和租约的剩余时间写入记录流
+// 参数：
+//
+//	ctx - 上下文
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (e *EtcdDb) BackupContext(ctx context.Context, w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverEtcd)
+	if err != nil {
+		return err
+	}
+	leases := make(map[clientv3.LeaseID]time.Duration)
+	start := "\x00"
+	for {
+		resp, err := e.db.Get(ctx, start, clientv3.WithFromKey(), clientv3.WithLimit(backupPageSize))
+		if err != nil {
+			return err
+		}
+		for _, kv := range resp.Kvs {
+			ttl, err := e.leaseTTL(ctx, leases, clientv3.LeaseID(kv.Lease))
+			if err != nil {
+				return err
+			}
+			if ttl < 0 {
+				continue // 租约已过期，key即将被删除
+			}
+			if err := bw.WriteRecord(_interface.BackupRecord{Key: kv.Key, Value: kv.Value, TTL: ttl}); err != nil {
+				return err
+			}
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return bw.Flush()
+		}
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// leaseTTL 查询租约的剩余时间，结果缓存在leases中；没有租约返回0，租约已过期返回-1
+func (e *EtcdDb) leaseTTL(ctx context.Context, leases map[clientv3.LeaseID]time.Duration, id clientv3.LeaseID) (time.Duration, error) {
+	if id == clientv3.NoLease {
+		return 0, nil
+	}
+	if ttl, ok := leases[id]; ok {
+		return ttl, nil
+	}
+	resp, err := e.db.TimeToLive(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	ttl := time.Duration(-1)
+	if resp.TTL > 0 {
+		ttl = time.Duration(resp.TTL) * time.Second
+	}
+	leases[id] = ttl
+	return ttl, nil
+}
+
+// RestoreContext 写入备份中的每个key，带过期时间的key使用新的租约
+// 参数：
+//
+//	ctx - 上下文
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是etcd的备份时返回ErrBackupFormat
+func (e *EtcdDb) RestoreContext(ctx context.Context, r io.Reader) error {
+	br, err := _interface.NewBackupReader(r, config.CacheDriverEtcd)
+	if err != nil {
+		return err
+	}
+	var restored, marked bool
+	for {
+		rec, err := br.ReadRecord()
+		if err == io.EOF {
+			if !restored || marked {
+				return nil
+			}
+			// 旧版本的备份没有新编码的标记，其中的哈希表字段是key:field形式，恢复后进入兼容模式
+			e.legacyHash.Store(true)
+			_, err := e.db.Delete(ctx, _interface.HashEncodingMarker)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		opts, err := e.leaseOptions(ctx, rec.TTL)
+		if err != nil {
+			return err
+		}
+		if _, err := e.db.Put(ctx, string(rec.Key), string(rec.Value), opts...); err != nil {
+			return err
+		}
+		restored = true
+		marked = marked || string(rec.Key) == _interface.HashEncodingMarker
+	}
+}
+
+func (e *EtcdDb) Backup(w io.Writer) error {
+	return e.BackupContext(context.Background(), w)
+}
+
+func (e *EtcdDb) Restore(r io.Reader) error {
+	return e.RestoreContext(context.Background(), r)
+}
+
+func NewEtcdClient(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	addr := config.Host + ":" + config.Port
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{addr},
+		Username:    config.Username,
+		Password:    config.Password,
+		DialTimeout: dialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查集群是否可用
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	if _, err := client.Status(ctx, addr); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	e := &EtcdDb{db: client, codec: codec}
+	if err := e.initHashEncoding(ctx); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return e, nil
+}
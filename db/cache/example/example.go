@@ -35,8 +35,15 @@ import (
 
 	// 导入所有实现以确保驱动注册
 	_ "github.com/gophertool/tool/db/cache/badgerdb"
+	_ "github.com/gophertool/tool/db/cache/bbolt"
 	_ "github.com/gophertool/tool/db/cache/buntdb"
+	_ "github.com/gophertool/tool/db/cache/etcd"
+	_ "github.com/gophertool/tool/db/cache/memcached"
+	_ "github.com/gophertool/tool/db/cache/memory"
+	_ "github.com/gophertool/tool/db/cache/pebble"
 	_ "github.com/gophertool/tool/db/cache/redis"
+	_ "github.com/gophertool/tool/db/cache/ristretto"
+	_ "github.com/gophertool/tool/db/cache/sqlite"
 )
 
 // ExampleRedisUsage Redis缓存使用示例
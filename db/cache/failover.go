@@ -0,0 +1,915 @@
+// cache包：故障转移缓存
+// 在主缓存之外配置一个备用缓存，主缓存出现故障（如Redis短暂不可用）时读写自动转到备用缓存，主缓存恢复后再切回
+//
+// 主要特性：
+// - 主缓存的操作返回故障错误时，在备用缓存上重试同一个操作，调用方不感知主缓存的故障
+// - 主缓存连续失败FailureThreshold次后切换到备用缓存，之后的操作直接访问备用缓存，不再等待主缓存超时
+// - 后台定期检查主缓存的健康状态：使用主缓存时检查失败同样计入连续失败次数；使用备用缓存时连续RecoveryChecks次检查成功后切回主缓存
+// - ErrKeyNotFound、ErrUnsupported、ErrTxConflict等表示操作结果的错误不是故障，可以通过IsFailure自定义
+// - 调用方的上下文已取消或超时时不重试，也不计入连续失败次数
+// - 配置Metrics后通过Prometheus上报当前使用的缓存和切换次数
+// - Failover总是实现CacheCtx，没有实现CacheCtx的缓存只在操作前检查上下文
+//
+// 使用限制：
+// - 两个缓存之间不同步数据，切换后读不到另一个缓存上的数据，切回主缓存后备用缓存上的写入不会合并回主缓存
+// - 主缓存超时的写入可能已经生效，在备用缓存上重试后两个缓存都有这次写入，Push等非幂等操作需要调用方容忍重复
+// - Keys和Iterate只在还没有调用过fn时重试；Backup、Restore、订阅和已开启的事务只使用开始时的缓存，不重试
+// - PopAck返回的回执只能在同一个缓存上Ack，切换之后Ack返回的错误由驱动决定
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+const (
+	// FailoverPrimary 使用主缓存
+	FailoverPrimary = "primary"
+	// FailoverSecondary 使用备用缓存
+	FailoverSecondary = "secondary"
+
+	// defaultRecoveryChecks 切回主缓存之前默认需要连续成功的健康检查次数
+	defaultRecoveryChecks = 3
+	// failoverHealthKey 健康检查时查询的键
+	failoverHealthKey = "cache:failover:health"
+)
+
+// failoverResultErrors 表示操作结果而不是缓存故障的错误，默认不触发故障转移
+var failoverResultErrors = []error{
+	_interface.ErrKeyNotFound,
+	_interface.ErrUnsupported,
+	_interface.ErrTxConflict,
+	_interface.ErrTxReadOnly,
+	_interface.ErrBloomExists,
+	_interface.ErrBloomFormat,
+	_interface.ErrBackupFormat,
+	_interface.ErrPreloadFormat,
+	_interface.ErrUnsupportedCodec,
+	ErrDecrypt,
+	ErrCrossShard,
+}
+
+// FailoverOptions 故障转移缓存的选项
+type FailoverOptions struct {
+	FailureThreshold    int                            // 主缓存连续失败多少次后切换到备用缓存，0表示1
+	RecoveryChecks      int                            // 切换后主缓存连续多少次健康检查成功才切回，0表示3
+	HealthCheckInterval time.Duration                  // 健康检查间隔，0表示10秒，负数表示不检查，此时只能通过CheckHealth切回
+	HealthCheckTimeout  time.Duration                  // 单次健康检查的超时，0表示2秒
+	IsFailure           func(err error) bool           // 判断主缓存返回的错误是否为故障，为nil时除表示操作结果的错误外都是故障
+	Codec               _interface.Codec               // SetObject/GetObject的编码方式，为nil时使用JSONCodec
+	OnFailover          func(active string, err error) // 切换缓存的回调，切到备用缓存时err为主缓存的错误，切回主缓存时为nil
+	Metrics             *Metrics                       // 上报当前使用的缓存和切换次数的指标，可以为nil
+	Name                string                         // 指标中failover标签的值，为空时使用"default"
+}
+
+// Failover 故障转移缓存，实现CacheCtx接口，可以并发使用
+type Failover struct {
+	primary   _interface.CacheCtx
+	secondary _interface.CacheCtx
+	opts      FailoverOptions
+
+	onSecondary atomic.Bool  // 是否正在使用备用缓存
+	failures    atomic.Int64 // 主缓存的连续失败次数
+	failovers   atomic.Int64 // 切换到备用缓存的次数
+
+	mu         sync.Mutex
+	recoveries int       // 使用备用缓存期间主缓存连续成功的健康检查次数
+	err        error     // 最近一次健康检查的错误
+	checkedAt  time.Time // 最近一次健康检查的时间
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// 确保实现了带上下文的缓存接口、事务选项接口和布隆过滤器接口
+var (
+	_ _interface.CacheCtx   = (*Failover)(nil)
+	_ _interface.TxBeginner = (*Failover)(nil)
+	_ _interface.Bloom      = (*Failover)(nil)
+)
+
+// NewFailover 创建故障转移缓存
+// 参数：
+//
+//	primary - 主缓存，正常情况下所有操作的目标
+//	secondary - 备用缓存，主缓存故障期间的操作目标
+//	opts - 故障转移选项
+//
+// 返回值：
+//
+//	*Failover - 故障转移缓存实例，Close停止健康检查，primary和secondary由创建它们的一方关闭
+//	error - primary或secondary为nil
+func NewFailover(primary, secondary _interface.Cache, opts FailoverOptions) (*Failover, error) {
+	if primary == nil || secondary == nil {
+		return nil, errors.New("cache: failover needs a primary and a secondary cache")
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 1
+	}
+	if opts.RecoveryChecks <= 0 {
+		opts.RecoveryChecks = defaultRecoveryChecks
+	}
+	if opts.HealthCheckInterval == 0 {
+		opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = isFailoverError
+	}
+	if opts.Codec == nil {
+		opts.Codec = _interface.JSONCodec{}
+	}
+	if opts.Name == "" {
+		opts.Name = "default"
+	}
+
+	f := &Failover{
+		primary:   asCacheCtx(primary),
+		secondary: asCacheCtx(secondary),
+		opts:      opts,
+		stop:      make(chan struct{}),
+	}
+	if opts.Metrics != nil {
+		opts.Metrics.mu.Lock()
+		opts.Metrics.failovers[f] = struct{}{}
+		opts.Metrics.mu.Unlock()
+	}
+	if opts.HealthCheckInterval > 0 {
+		f.wg.Add(1)
+		go f.healthLoop()
+	}
+	return f, nil
+}
+
+// isFailoverError 默认的故障判断，表示操作结果的错误之外的错误（连接失败、超时等）都是故障
+func isFailoverError(err error) bool {
+	for _, target := range failoverResultErrors {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// Active 返回当前使用的缓存，FailoverPrimary或FailoverSecondary
+func (f *Failover) Active() string {
+	if f.onSecondary.Load() {
+		return FailoverSecondary
+	}
+	return FailoverPrimary
+}
+
+// Failovers 返回切换到备用缓存的次数
+func (f *Failover) Failovers() int64 {
+	return f.failovers.Load()
+}
+
+// PrimaryHealth 返回主缓存最近一次健康检查的时间和错误，还没有检查过时时间为零值
+func (f *Failover) PrimaryHealth() (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.checkedAt, f.err
+}
+
+// healthLoop 定期检查主缓存
+func (f *Failover) healthLoop() {
+	defer f.wg.Done()
+	ticker := time.NewTicker(f.opts.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.CheckHealth()
+		}
+	}
+}
+
+// CheckHealth 立即检查主缓存的健康状态，检查方式是带超时地查询一个键是否存在
+// 使用主缓存时检查失败计入连续失败次数；使用备用缓存时连续RecoveryChecks次成功后切回主缓存
+//
+// 返回值：
+//
+//	error - 主缓存的检查错误，健康时为nil
+func (f *Failover) CheckHealth() error {
+	ctx, cancel := context.WithTimeout(context.Background(), f.opts.HealthCheckTimeout)
+	_, err := f.primary.ExistsContext(ctx, failoverHealthKey)
+	cancel()
+
+	f.mu.Lock()
+	f.err = err
+	f.checkedAt = time.Now()
+	recovered := false
+	switch {
+	case err != nil:
+		f.recoveries = 0
+	case f.onSecondary.Load():
+		f.recoveries++
+		if f.recoveries >= f.opts.RecoveryChecks {
+			f.recoveries = 0
+			f.failures.Store(0)
+			recovered = f.onSecondary.CompareAndSwap(true, false)
+		}
+	}
+	f.mu.Unlock()
+
+	switch {
+	case recovered:
+		if f.opts.OnFailover != nil {
+			f.opts.OnFailover(FailoverPrimary, nil)
+		}
+	case err != nil && !f.onSecondary.Load():
+		f.primaryFailed(err)
+	}
+	return err
+}
+
+// primaryFailed 记录主缓存的一次故障，连续失败次数达到FailureThreshold时切换到备用缓存
+func (f *Failover) primaryFailed(err error) {
+	if f.failures.Add(1) < int64(f.opts.FailureThreshold) {
+		return
+	}
+	f.mu.Lock()
+	f.recoveries = 0
+	switched := f.onSecondary.CompareAndSwap(false, true)
+	f.mu.Unlock()
+	if !switched {
+		return
+	}
+	f.failovers.Add(1)
+	if f.opts.OnFailover != nil {
+		f.opts.OnFailover(FailoverSecondary, err)
+	}
+}
+
+// fallback 记录主缓存操作的结果，返回是否需要在备用缓存上重试
+func (f *Failover) fallback(ctx context.Context, err error) bool {
+	if err != nil && ctx.Err() != nil {
+		// 调用方的上下文已结束，无法判断主缓存是否正常，也无法重试
+		return false
+	}
+	if err == nil || !f.opts.IsFailure(err) {
+		// 主缓存正常响应，清零连续失败次数；只在非零时写入，避免并发操作争用同一个缓存行
+		if f.failures.Load() != 0 {
+			f.failures.Store(0)
+		}
+		return false
+	}
+	f.primaryFailed(err)
+	return true
+}
+
+// active 返回当前使用的缓存
+func (f *Failover) active() _interface.CacheCtx {
+	if f.onSecondary.Load() {
+		return f.secondary
+	}
+	return f.primary
+}
+
+// failoverDo 在当前使用的缓存上执行op，主缓存返回故障错误时在备用缓存上重试
+func failoverDo[T any](f *Failover, ctx context.Context, op func(c _interface.CacheCtx) (T, error)) (T, error) {
+	if f.onSecondary.Load() {
+		return op(f.secondary)
+	}
+	v, err := op(f.primary)
+	if !f.fallback(ctx, err) {
+		return v, err
+	}
+	return op(f.secondary)
+}
+
+// exec 在当前使用的缓存上执行只返回错误的op，见failoverDo
+func (f *Failover) exec(ctx context.Context, op func(c _interface.CacheCtx) error) error {
+	_, err := failoverDo(f, ctx, func(c _interface.CacheCtx) (struct{}, error) {
+		return struct{}{}, op(c)
+	})
+	return err
+}
+
+// Close 停止健康检查和指标采集，主缓存和备用缓存由创建它们的一方关闭
+func (f *Failover) Close() {
+	select {
+	case <-f.stop:
+		return
+	default:
+		close(f.stop)
+	}
+	f.wg.Wait()
+	if m := f.opts.Metrics; m != nil {
+		m.mu.Lock()
+		delete(m.failovers, f)
+		m.mu.Unlock()
+	}
+}
+
+func (f *Failover) Get(key string) (string, error) {
+	return f.GetContext(context.Background(), key)
+}
+
+func (f *Failover) Set(key string, value string, ttl time.Duration) error {
+	return f.SetContext(context.Background(), key, value, ttl)
+}
+
+func (f *Failover) Delete(key string) error {
+	return f.DeleteContext(context.Background(), key)
+}
+
+func (f *Failover) Exists(key string) (bool, error) {
+	return f.ExistsContext(context.Background(), key)
+}
+
+func (f *Failover) Expire(key string, ttl time.Duration) error {
+	return f.ExpireContext(context.Background(), key, ttl)
+}
+
+func (f *Failover) TTL(key string) (time.Duration, error) {
+	return f.TTLContext(context.Background(), key)
+}
+
+func (f *Failover) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return f.SetNXContext(context.Background(), key, value, ttl)
+}
+
+func (f *Failover) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return f.CASContext(context.Background(), key, oldValue, newValue, ttl)
+}
+
+func (f *Failover) GetSet(key string, value string) (string, error) {
+	return f.GetSetContext(context.Background(), key, value)
+}
+
+func (f *Failover) GetDel(key string) (string, error) {
+	return f.GetDelContext(context.Background(), key)
+}
+
+func (f *Failover) Append(key string, value string) (int64, error) {
+	return f.AppendContext(context.Background(), key, value)
+}
+
+func (f *Failover) StrLen(key string) (int64, error) {
+	return f.StrLenContext(context.Background(), key)
+}
+
+func (f *Failover) SetObject(key string, v any, ttl time.Duration) error {
+	return f.SetObjectContext(context.Background(), key, v, ttl)
+}
+
+func (f *Failover) GetObject(key string, v any) error {
+	return f.GetObjectContext(context.Background(), key, v)
+}
+
+func (f *Failover) Keys(pattern string, fn func(key string) bool) error {
+	return f.KeysContext(context.Background(), pattern, fn)
+}
+
+func (f *Failover) Iterate(prefix string, fn func(key, value string) bool) error {
+	return f.IterateContext(context.Background(), prefix, fn)
+}
+
+func (f *Failover) DeleteByPrefix(prefix string) error {
+	return f.DeleteByPrefixContext(context.Background(), prefix)
+}
+
+func (f *Failover) HGet(key, field string) (string, error) {
+	return f.HGetContext(context.Background(), key, field)
+}
+
+func (f *Failover) HSet(key, field, value string, ttl time.Duration) error {
+	return f.HSetContext(context.Background(), key, field, value, ttl)
+}
+
+func (f *Failover) HDel(key, field string) error {
+	return f.HDelContext(context.Background(), key, field)
+}
+
+func (f *Failover) HGetAll(key string) (map[string]string, error) {
+	return f.HGetAllContext(context.Background(), key)
+}
+
+func (f *Failover) HLen(key string) (int64, error) {
+	return f.HLenContext(context.Background(), key)
+}
+
+func (f *Failover) HExists(key, field string) (bool, error) {
+	return f.HExistsContext(context.Background(), key, field)
+}
+
+func (f *Failover) HKeys(key string) ([]string, error) {
+	return f.HKeysContext(context.Background(), key)
+}
+
+func (f *Failover) SAdd(key, member string) error {
+	return f.SAddContext(context.Background(), key, member)
+}
+
+func (f *Failover) SRem(key, member string) error {
+	return f.SRemContext(context.Background(), key, member)
+}
+
+func (f *Failover) SMembers(key string) ([]string, error) {
+	return f.SMembersContext(context.Background(), key)
+}
+
+func (f *Failover) SIsMember(key, member string) (bool, error) {
+	return f.SIsMemberContext(context.Background(), key, member)
+}
+
+func (f *Failover) Push(key string, value string) error {
+	return f.PushContext(context.Background(), key, value)
+}
+
+func (f *Failover) LPush(key string, value string) error {
+	return f.LPushContext(context.Background(), key, value)
+}
+
+func (f *Failover) RPush(key string, value string) error {
+	return f.RPushContext(context.Background(), key, value)
+}
+
+func (f *Failover) RPushCapped(key string, value string, maxLen int64) error {
+	return f.RPushCappedContext(context.Background(), key, value, maxLen)
+}
+
+func (f *Failover) Pop(key string) (string, error) {
+	return f.PopContext(context.Background(), key)
+}
+
+func (f *Failover) LPop(key string) (string, error) {
+	return f.LPopContext(context.Background(), key)
+}
+
+func (f *Failover) RPop(key string) (string, error) {
+	return f.RPopContext(context.Background(), key)
+}
+
+func (f *Failover) PopAll(key string) ([]string, error) {
+	return f.PopAllContext(context.Background(), key)
+}
+
+func (f *Failover) Len(key string) (int64, error) {
+	return f.LenContext(context.Background(), key)
+}
+
+func (f *Failover) LRange(key string, start, stop int64) ([]string, error) {
+	return f.LRangeContext(context.Background(), key, start, stop)
+}
+
+func (f *Failover) LIndex(key string, index int64) (string, error) {
+	return f.LIndexContext(context.Background(), key, index)
+}
+
+func (f *Failover) LRem(key string, count int64, value string) (int64, error) {
+	return f.LRemContext(context.Background(), key, count, value)
+}
+
+func (f *Failover) LTrim(key string, start, stop int64) error {
+	return f.LTrimContext(context.Background(), key, start, stop)
+}
+
+func (f *Failover) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return f.PopAckContext(context.Background(), key, visibility)
+}
+
+func (f *Failover) Ack(key, receipt string) error {
+	return f.AckContext(context.Background(), key, receipt)
+}
+
+func (f *Failover) PushDelayed(key, value string, delay time.Duration) error {
+	return f.PushDelayedContext(context.Background(), key, value, delay)
+}
+
+func (f *Failover) Publish(channel string, payload string) error {
+	return f.PublishContext(context.Background(), channel, payload)
+}
+
+func (f *Failover) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return f.SubscribeContext(context.Background(), channel)
+}
+
+func (f *Failover) SubscribeExpired() (<-chan string, func()) {
+	return f.SubscribeExpiredContext(context.Background())
+}
+
+func (f *Failover) Stats() (_interface.Stats, error) {
+	return f.StatsContext(context.Background())
+}
+
+func (f *Failover) Backup(w io.Writer) error {
+	return f.BackupContext(context.Background(), w)
+}
+
+func (f *Failover) Restore(r io.Reader) error {
+	return f.RestoreContext(context.Background(), r)
+}
+
+func (f *Failover) BeginTx() (_interface.Tx, error) {
+	return f.BeginTxContext(context.Background())
+}
+
+func (f *Failover) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return f.BeginTxWatchContext(context.Background(), keys...)
+}
+
+func (f *Failover) GetContext(ctx context.Context, key string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.GetContext(ctx, key)
+	})
+}
+
+func (f *Failover) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.SetContext(ctx, key, value, ttl)
+	})
+}
+
+func (f *Failover) DeleteContext(ctx context.Context, key string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.DeleteContext(ctx, key)
+	})
+}
+
+func (f *Failover) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (bool, error) {
+		return c.ExistsContext(ctx, key)
+	})
+}
+
+func (f *Failover) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.ExpireContext(ctx, key, ttl)
+	})
+}
+
+func (f *Failover) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (time.Duration, error) {
+		return c.TTLContext(ctx, key)
+	})
+}
+
+func (f *Failover) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (bool, error) {
+		return c.SetNXContext(ctx, key, value, ttl)
+	})
+}
+
+func (f *Failover) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (bool, error) {
+		return c.CASContext(ctx, key, oldValue, newValue, ttl)
+	})
+}
+
+func (f *Failover) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.GetSetContext(ctx, key, value)
+	})
+}
+
+func (f *Failover) GetDelContext(ctx context.Context, key string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.GetDelContext(ctx, key)
+	})
+}
+
+func (f *Failover) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (int64, error) {
+		return c.AppendContext(ctx, key, value)
+	})
+}
+
+func (f *Failover) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (int64, error) {
+		return c.StrLenContext(ctx, key)
+	})
+}
+
+func (f *Failover) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.DeleteByPrefixContext(ctx, prefix)
+	})
+}
+
+func (f *Failover) HGetContext(ctx context.Context, key, field string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.HGetContext(ctx, key, field)
+	})
+}
+
+func (f *Failover) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.HSetContext(ctx, key, field, value, ttl)
+	})
+}
+
+func (f *Failover) HDelContext(ctx context.Context, key, field string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.HDelContext(ctx, key, field)
+	})
+}
+
+func (f *Failover) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (map[string]string, error) {
+		return c.HGetAllContext(ctx, key)
+	})
+}
+
+func (f *Failover) HLenContext(ctx context.Context, key string) (int64, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (int64, error) {
+		return c.HLenContext(ctx, key)
+	})
+}
+
+func (f *Failover) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (bool, error) {
+		return c.HExistsContext(ctx, key, field)
+	})
+}
+
+func (f *Failover) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) ([]string, error) {
+		return c.HKeysContext(ctx, key)
+	})
+}
+
+func (f *Failover) SAddContext(ctx context.Context, key, member string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.SAddContext(ctx, key, member)
+	})
+}
+
+func (f *Failover) SRemContext(ctx context.Context, key, member string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.SRemContext(ctx, key, member)
+	})
+}
+
+func (f *Failover) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) ([]string, error) {
+		return c.SMembersContext(ctx, key)
+	})
+}
+
+func (f *Failover) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (bool, error) {
+		return c.SIsMemberContext(ctx, key, member)
+	})
+}
+
+func (f *Failover) PushContext(ctx context.Context, key string, value string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.PushContext(ctx, key, value)
+	})
+}
+
+func (f *Failover) LPushContext(ctx context.Context, key string, value string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.LPushContext(ctx, key, value)
+	})
+}
+
+func (f *Failover) RPushContext(ctx context.Context, key string, value string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.RPushContext(ctx, key, value)
+	})
+}
+
+func (f *Failover) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.RPushCappedContext(ctx, key, value, maxLen)
+	})
+}
+
+func (f *Failover) PopContext(ctx context.Context, key string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.PopContext(ctx, key)
+	})
+}
+
+func (f *Failover) LPopContext(ctx context.Context, key string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.LPopContext(ctx, key)
+	})
+}
+
+func (f *Failover) RPopContext(ctx context.Context, key string) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.RPopContext(ctx, key)
+	})
+}
+
+func (f *Failover) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) ([]string, error) {
+		return c.PopAllContext(ctx, key)
+	})
+}
+
+func (f *Failover) LenContext(ctx context.Context, key string) (int64, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (int64, error) {
+		return c.LenContext(ctx, key)
+	})
+}
+
+func (f *Failover) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) ([]string, error) {
+		return c.LRangeContext(ctx, key, start, stop)
+	})
+}
+
+func (f *Failover) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		return c.LIndexContext(ctx, key, index)
+	})
+}
+
+func (f *Failover) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (int64, error) {
+		return c.LRemContext(ctx, key, count, value)
+	})
+}
+
+func (f *Failover) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.LTrimContext(ctx, key, start, stop)
+	})
+}
+
+func (f *Failover) AckContext(ctx context.Context, key, receipt string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.AckContext(ctx, key, receipt)
+	})
+}
+
+func (f *Failover) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.PushDelayedContext(ctx, key, value, delay)
+	})
+}
+
+func (f *Failover) PublishContext(ctx context.Context, channel string, payload string) error {
+	return f.exec(ctx, func(c _interface.CacheCtx) error {
+		return c.PublishContext(ctx, channel, payload)
+	})
+}
+
+// SetObjectContext 使用配置的Codec编码v并设置key的值
+func (f *Failover) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := f.opts.Codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return f.SetContext(ctx, key, string(data), ttl)
+}
+
+// GetObjectContext 获取key的值并使用配置的Codec解码，解码错误不会被当作缓存故障
+func (f *Failover) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := f.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return f.opts.Codec.Unmarshal([]byte(raw), v)
+}
+
+// KeysContext 遍历当前使用的缓存，主缓存在调用fn之前失败时遍历备用缓存
+func (f *Failover) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	if f.onSecondary.Load() {
+		return f.secondary.KeysContext(ctx, pattern, fn)
+	}
+	called := false
+	err := f.primary.KeysContext(ctx, pattern, func(key string) bool {
+		called = true
+		return fn(key)
+	})
+	if !f.fallback(ctx, err) || called {
+		return err
+	}
+	return f.secondary.KeysContext(ctx, pattern, fn)
+}
+
+// IterateContext 遍历当前使用的缓存，主缓存在调用fn之前失败时遍历备用缓存
+func (f *Failover) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	if f.onSecondary.Load() {
+		return f.secondary.IterateContext(ctx, prefix, fn)
+	}
+	called := false
+	err := f.primary.IterateContext(ctx, prefix, func(key, value string) bool {
+		called = true
+		return fn(key, value)
+	})
+	if !f.fallback(ctx, err) || called {
+		return err
+	}
+	return f.secondary.IterateContext(ctx, prefix, fn)
+}
+
+func (f *Failover) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	var receipt string
+	value, err := failoverDo(f, ctx, func(c _interface.CacheCtx) (string, error) {
+		value, r, err := c.PopAckContext(ctx, key, visibility)
+		receipt = r
+		return value, err
+	})
+	return value, receipt, err
+}
+
+// SubscribeContext 订阅当前使用的缓存上的频道，切换缓存后不会重新订阅
+func (f *Failover) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	return f.active().SubscribeContext(ctx, channel)
+}
+
+// SubscribeExpiredContext 订阅当前使用的缓存的键过期通知，切换缓存后不会重新订阅
+func (f *Failover) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return f.active().SubscribeExpiredContext(ctx)
+}
+
+// StatsContext 返回当前使用的缓存的统计信息，Extra中的"failover.active"为当前使用的缓存
+func (f *Failover) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	var active string
+	stats, err := failoverDo(f, ctx, func(c _interface.CacheCtx) (_interface.Stats, error) {
+		active = FailoverPrimary
+		if c == f.secondary {
+			active = FailoverSecondary
+		}
+		return c.StatsContext(ctx)
+	})
+	if err != nil {
+		return stats, err
+	}
+	extra := make(map[string]string, len(stats.Extra)+1)
+	maps.Copy(extra, stats.Extra)
+	extra["failover.active"] = active
+	stats.Extra = extra
+	return stats, nil
+}
+
+// BackupContext 备份当前使用的缓存，失败时不重试，w中可能已经写入了部分数据
+func (f *Failover) BackupContext(ctx context.Context, w io.Writer) error {
+	return f.active().BackupContext(ctx, w)
+}
+
+// RestoreContext 恢复到当前使用的缓存，失败时不重试，r中的数据可能已经被读取
+func (f *Failover) RestoreContext(ctx context.Context, r io.Reader) error {
+	return f.active().RestoreContext(ctx, r)
+}
+
+// BeginTxContext 在当前使用的缓存上开启事务，主缓存开启失败时在备用缓存上开启
+func (f *Failover) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (_interface.Tx, error) {
+		return c.BeginTxContext(ctx)
+	})
+}
+
+// BeginTxOptions 按选项在当前使用的缓存上开启事务，主缓存开启失败时在备用缓存上开启
+func (f *Failover) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (_interface.Tx, error) {
+		return _interface.BeginTx(ctx, c, opts)
+	})
+}
+
+// BeginTxWatchContext 在当前使用的缓存上开启监视keys的事务，主缓存开启失败时在备用缓存上开启
+func (f *Failover) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	return failoverDo(f, ctx, func(c _interface.CacheCtx) (_interface.Tx, error) {
+		return c.BeginTxWatchContext(ctx, keys...)
+	})
+}
+
+// BFReserve 在当前使用的缓存上创建布隆过滤器，缓存没有原生实现时返回ErrUnsupported
+func (f *Failover) BFReserve(key string, errorRate float64, capacity int64) error {
+	return f.exec(context.Background(), func(c _interface.CacheCtx) error {
+		if b, ok := nativeBloom(c); ok {
+			return b.BFReserve(key, errorRate, capacity)
+		}
+		return _interface.ErrUnsupported
+	})
+}
+
+// BFAdd 向当前使用的缓存上的布隆过滤器添加元素，缓存没有原生实现时返回ErrUnsupported
+func (f *Failover) BFAdd(key, item string) (bool, error) {
+	return failoverDo(f, context.Background(), func(c _interface.CacheCtx) (bool, error) {
+		if b, ok := nativeBloom(c); ok {
+			return b.BFAdd(key, item)
+		}
+		return false, _interface.ErrUnsupported
+	})
+}
+
+// BFExists 判断元素是否可能在当前使用的缓存上的布隆过滤器中，缓存没有原生实现时返回ErrUnsupported
+func (f *Failover) BFExists(key, item string) (bool, error) {
+	return failoverDo(f, context.Background(), func(c _interface.CacheCtx) (bool, error) {
+		if b, ok := nativeBloom(c); ok {
+			return b.BFExists(key, item)
+		}
+		return false, _interface.ErrUnsupported
+	})
+}
@@ -0,0 +1,129 @@
+// interface包：带确认的队列弹出
+// 为没有原生脚本能力的驱动提供PopAck/Ack实现，基于Cache的队列和哈希表操作
+//
+// 弹出的元素以回执为字段保存在 <key>:pending 哈希表中，值为"截止时间（Unix毫秒）:元素"；
+// 每次PopAck前先把已超过截止时间、仍未确认的元素放回队列头部，
+// 因此处理元素的进程崩溃后，元素会在可见性超时之后被重新弹出
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AckQueue 基于Cache实现的带确认队列，零值可直接使用
+// 所有操作在同一个互斥锁内串行执行，只适用于单进程访问的嵌入式驱动
+type AckQueue struct {
+	mu sync.Mutex
+}
+
+// PendingKey 返回保存未确认元素的哈希表键名
+func PendingKey(key string) string {
+	return key + ":pending"
+}
+
+// NewReceipt 生成随机的回执
+func NewReceipt() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// PopAck 弹出队列头部元素并登记为未确认
+// 参数：
+//
+//	c - 缓存实例
+//	key - 队列键名
+//	visibility - 可见性超时，超时未确认的元素会重新回到队列头部
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，队列为空时返回ErrKeyNotFound
+func (q *AckQueue) PopAck(c Cache, key string, visibility time.Duration) (string, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.requeueExpired(c, key); err != nil {
+		return "", "", err
+	}
+
+	value, err := c.LPop(key)
+	if err != nil {
+		return "", "", err
+	}
+	receipt := NewReceipt()
+	deadline := time.Now().Add(visibility).UnixMilli()
+	if err := c.HSet(PendingKey(key), receipt, strconv.FormatInt(deadline, 10)+":"+value, 0); err != nil {
+		// 登记失败时把元素放回队列，避免丢失
+		_ = c.LPush(key, value)
+		return "", "", err
+	}
+	return value, receipt, nil
+}
+
+// Ack 确认元素已处理完成
+// 参数：
+//
+//	c - 缓存实例
+//	key - 队列键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到队列时返回ErrKeyNotFound
+func (q *AckQueue) Ack(c Cache, key, receipt string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	pending := PendingKey(key)
+	if _, err := c.HGet(pending, receipt); err != nil {
+		return err
+	}
+	return c.HDel(pending, receipt)
+}
+
+// requeueExpired 把已超时的未确认元素放回队列头部
+func (q *AckQueue) requeueExpired(c Cache, key string) error {
+	pending := PendingKey(key)
+	entries, err := c.HGetAll(pending)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for receipt, entry := range entries {
+		deadline, value, err := parsePending(entry)
+		if err != nil {
+			return err
+		}
+		if deadline > now {
+			continue
+		}
+		if err := c.LPush(key, value); err != nil {
+			return err
+		}
+		if err := c.HDel(pending, receipt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePending 解析"截止时间:元素"格式的未确认记录
+func parsePending(entry string) (int64, string, error) {
+	deadline, value, ok := strings.Cut(entry, ":")
+	if !ok {
+		return 0, "", errors.New("invalid pending entry")
+	}
+	ms, err := strconv.ParseInt(deadline, 10, 64)
+	return ms, value, err
+}
@@ -0,0 +1,169 @@
+// interface包：备份与恢复的数据流格式
+// 备份流以魔数和驱动名称开头，恢复时校验驱动一致，避免把一种驱动的备份恢复到另一种驱动
+//
+// 头部之后是驱动自己的格式：
+// - BadgerDB、BuntDB、bbolt和SQLite使用原生的备份格式，直接写入BackupWriter
+// - 其他驱动使用WriteRecord写入的记录流，每条记录包含键、值和剩余过期时间
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrBackupFormat 备份数据格式错误或与驱动不匹配
+var ErrBackupFormat = errors.New("cache: invalid backup format")
+
+// backupMagic 备份流的魔数，最后一个字节为格式版本
+const backupMagic = "GTCACHE\x01"
+
+// maxBackupField 记录中单个字段的最大长度，避免损坏的数据导致分配过大的内存
+const maxBackupField = 1 << 30
+
+// BackupRecord 记录流中的一条记录
+type BackupRecord struct {
+	Key   []byte        // 键名，由驱动决定是否包含内部前缀
+	Value []byte        // 值，由驱动决定编码方式
+	TTL   time.Duration // 剩余过期时间，0表示不过期
+}
+
+// BackupWriter 写入备份流，创建时写入头部
+type BackupWriter struct {
+	w *bufio.Writer
+}
+
+// NewBackupWriter 创建备份流并写入头部
+// 参数：
+//
+//	w - 备份数据的输出
+//	driver - 驱动名称
+//
+// 返回值：
+//
+//	*BackupWriter - 备份流，写完后需要调用Flush
+//	error - 写入头部的错误
+func NewBackupWriter(w io.Writer, driver string) (*BackupWriter, error) {
+	bw := &BackupWriter{w: bufio.NewWriter(w)}
+	if _, err := bw.w.WriteString(backupMagic); err != nil {
+		return nil, err
+	}
+	if err := bw.writeField([]byte(driver)); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// Write 写入驱动原生格式的备份数据
+func (b *BackupWriter) Write(p []byte) (int, error) {
+	return b.w.Write(p)
+}
+
+// WriteRecord 写入一条记录
+func (b *BackupWriter) WriteRecord(rec BackupRecord) error {
+	if err := b.writeField(rec.Key); err != nil {
+		return err
+	}
+	if err := b.writeField(rec.Value); err != nil {
+		return err
+	}
+	var buf [binary.MaxVarintLen64]byte
+	_, err := b.w.Write(buf[:binary.PutVarint(buf[:], int64(rec.TTL))])
+	return err
+}
+
+// Flush 将缓冲的数据写入底层输出
+func (b *BackupWriter) Flush() error {
+	return b.w.Flush()
+}
+
+// writeField 写入带长度前缀的字段
+func (b *BackupWriter) writeField(p []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+	if _, err := b.w.Write(buf[:binary.PutUvarint(buf[:], uint64(len(p)))]); err != nil {
+		return err
+	}
+	_, err := b.w.Write(p)
+	return err
+}
+
+// BackupReader 读取备份流，创建时校验头部
+type BackupReader struct {
+	r *bufio.Reader
+}
+
+// NewBackupReader 读取并校验备份流的头部
+// 参数：
+//
+//	r - 备份数据的输入
+//	driver - 恢复目标的驱动名称
+//
+// 返回值：
+//
+//	*BackupReader - 备份流
+//	error - 头部无效或驱动不匹配时返回ErrBackupFormat
+func NewBackupReader(r io.Reader, driver string) (*BackupReader, error) {
+	br := &BackupReader{r: bufio.NewReader(r)}
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(br.r, magic); err != nil || string(magic) != backupMagic {
+		return nil, ErrBackupFormat
+	}
+	name, err := br.readField()
+	if err != nil {
+		return nil, ErrBackupFormat
+	}
+	if string(name) != driver {
+		return nil, fmt.Errorf("%w: backup of driver %q cannot be restored into %q", ErrBackupFormat, name, driver)
+	}
+	return br, nil
+}
+
+// Read 读取驱动原生格式的备份数据
+func (b *BackupReader) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+// ReadRecord 读取一条记录
+// 返回值：
+//
+//	BackupRecord - 读取到的记录
+//	error - 没有更多记录时返回io.EOF，数据损坏时返回ErrBackupFormat
+func (b *BackupReader) ReadRecord() (BackupRecord, error) {
+	if _, err := b.r.Peek(1); err == io.EOF {
+		return BackupRecord{}, io.EOF
+	}
+	key, err := b.readField()
+	if err != nil {
+		return BackupRecord{}, ErrBackupFormat
+	}
+	value, err := b.readField()
+	if err != nil {
+		return BackupRecord{}, ErrBackupFormat
+	}
+	ttl, err := binary.ReadVarint(b.r)
+	if err != nil {
+		return BackupRecord{}, ErrBackupFormat
+	}
+	return BackupRecord{Key: key, Value: value, TTL: time.Duration(ttl)}, nil
+}
+
+// readField 读取带长度前缀的字段
+func (b *BackupReader) readField() ([]byte, error) {
+	n, err := binary.ReadUvarint(b.r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxBackupField {
+		return nil, ErrBackupFormat
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(b.r, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,226 @@
+// interface包：布隆过滤器
+// 用于去重（已抓取的URL、已处理的ID等），不需要保存每一个键，代价是存在一定的误判率：
+// BFExists返回false时元素一定没有添加过，返回true时元素可能没有添加过
+//
+// 实现方式：
+// - 驱动实现了Bloom接口时使用驱动的原生实现，如加载了RedisBloom模块的Redis
+// - 否则（或原生实现返回ErrUnsupported时）把位图保存在键的值中，通过Get和CAS更新，适用于所有驱动
+//
+// 位图的值以"BF"、版本号和哈希函数个数开头，之后是位图本身；
+// CAS冲突时重新读取并重试，写入时保留键原有的过期时间
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+const (
+	// BloomDefaultErrorRate BFAdd自动创建过滤器时使用的误判率
+	BloomDefaultErrorRate = 0.01
+	// BloomDefaultCapacity BFAdd自动创建过滤器时使用的预期元素数量
+	BloomDefaultCapacity = 10000
+
+	bloomMagic      = "BF\x01"
+	bloomHeaderSize = len(bloomMagic) + 1
+	// bloomMaxRetries 位图实现CAS冲突时的最大重试次数
+	bloomMaxRetries = 32
+)
+
+var (
+	// ErrBloomExists BFReserve的键已经存在
+	ErrBloomExists = errors.New("bloom filter already exists")
+
+	// ErrBloomFormat 键的值不是布隆过滤器
+	ErrBloomFormat = errors.New("value is not a bloom filter")
+)
+
+// Bloom 原生支持布隆过滤器的驱动实现的接口
+// 驱动在运行时发现不支持（如Redis没有加载RedisBloom模块）时返回ErrUnsupported，由BFAdd等函数改用位图实现
+type Bloom interface {
+	// BFReserve 按误判率和预期元素数量创建过滤器，键已存在时返回ErrBloomExists
+	BFReserve(key string, errorRate float64, capacity int64) error
+	// BFAdd 添加元素，元素之前不存在时返回true，过滤器不存在时按默认参数创建
+	BFAdd(key, item string) (bool, error)
+	// BFExists 判断元素是否可能存在，过滤器不存在时返回false
+	BFExists(key, item string) (bool, error)
+}
+
+// BFReserve 按误判率和预期元素数量创建布隆过滤器
+// 参数：
+//
+//	c - 缓存实例
+//	key - 过滤器的键名
+//	errorRate - 误判率，范围为(0, 1)
+//	capacity - 预期元素数量，超出后误判率会逐渐升高
+//
+// 返回值：
+//
+//	error - 参数无效、键已存在时返回ErrBloomExists
+func BFReserve(c Cache, key string, errorRate float64, capacity int64) error {
+	if errorRate <= 0 || errorRate >= 1 || capacity <= 0 {
+		return fmt.Errorf("invalid bloom filter parameters: error rate %v, capacity %d", errorRate, capacity)
+	}
+	if b, ok := c.(Bloom); ok {
+		if err := b.BFReserve(key, errorRate, capacity); !errors.Is(err, ErrUnsupported) {
+			return err
+		}
+	}
+	ok, err := c.SetNX(key, string(newBloomBitset(errorRate, capacity)), 0)
+	if err == nil && !ok {
+		return ErrBloomExists
+	}
+	return err
+}
+
+// BFAdd 向布隆过滤器添加元素，过滤器不存在时按BloomDefaultErrorRate和BloomDefaultCapacity创建
+// 参数：
+//
+//	c - 缓存实例
+//	key - 过滤器的键名
+//	item - 元素
+//
+// 返回值：
+//
+//	bool - 元素之前不存在时返回true，可能存在时返回false
+//	error - 操作错误，键的值不是过滤器时返回ErrBloomFormat
+func BFAdd(c Cache, key, item string) (bool, error) {
+	if b, ok := c.(Bloom); ok {
+		if added, err := b.BFAdd(key, item); !errors.Is(err, ErrUnsupported) {
+			return added, err
+		}
+	}
+
+	for range bloomMaxRetries {
+		old, err := c.Get(key)
+		if errors.Is(err, ErrKeyNotFound) {
+			filter := newBloomBitset(BloomDefaultErrorRate, BloomDefaultCapacity)
+			filter.add(item)
+			created, err := c.SetNX(key, string(filter), 0)
+			if err != nil || created {
+				return created, err
+			}
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		filter, err := parseBloomBitset(old)
+		if err != nil {
+			return false, err
+		}
+		if !filter.add(item) {
+			return false, nil
+		}
+		// CAS会覆盖过期时间，写入时保留原有的剩余过期时间
+		ttl, err := c.TTL(key)
+		if err != nil || ttl < 0 {
+			ttl = NoExpiry
+		}
+		swapped, err := c.CAS(key, old, string(filter), ttl)
+		if err != nil || swapped {
+			return swapped, err
+		}
+	}
+	return false, ErrTxConflict
+}
+
+// BFExists 判断元素是否可能在布隆过滤器中
+// 参数：
+//
+//	c - 缓存实例
+//	key - 过滤器的键名
+//	item - 元素
+//
+// 返回值：
+//
+//	bool - 元素可能存在时返回true，一定不存在或过滤器不存在时返回false
+//	error - 操作错误，键的值不是过滤器时返回ErrBloomFormat
+func BFExists(c Cache, key, item string) (bool, error) {
+	if b, ok := c.(Bloom); ok {
+		if exists, err := b.BFExists(key, item); !errors.Is(err, ErrUnsupported) {
+			return exists, err
+		}
+	}
+
+	value, err := c.Get(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	filter, err := parseBloomBitset(value)
+	if err != nil {
+		return false, err
+	}
+	return filter.contains(item), nil
+}
+
+// bloomBitset 保存在值中的布隆过滤器：头部之后是位图
+type bloomBitset []byte
+
+// newBloomBitset 按误判率和预期元素数量计算位图大小和哈希函数个数
+func newBloomBitset(errorRate float64, capacity int64) bloomBitset {
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(bits / float64(capacity) * math.Ln2))
+	k = min(max(k, 1), math.MaxUint8)
+
+	filter := make(bloomBitset, bloomHeaderSize+(int(bits)+7)/8)
+	copy(filter, bloomMagic)
+	filter[len(bloomMagic)] = byte(k)
+	return filter
+}
+
+// parseBloomBitset 校验值的头部，返回的位图是value的副本，可以直接修改
+func parseBloomBitset(value string) (bloomBitset, error) {
+	if len(value) <= bloomHeaderSize || value[:len(bloomMagic)] != bloomMagic || value[len(bloomMagic)] == 0 {
+		return nil, ErrBloomFormat
+	}
+	return bloomBitset(value), nil
+}
+
+// positions 使用双重哈希计算元素对应的k个位
+func (f bloomBitset) positions(item string) []uint64 {
+	h := fnv.New128a()
+	h.Write([]byte(item))
+	sum := h.Sum(nil)
+	h1, h2 := binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:])
+
+	m := uint64(len(f)-bloomHeaderSize) * 8
+	k := int(f[len(bloomMagic)])
+	positions := make([]uint64, k)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % m
+	}
+	return positions
+}
+
+// add 设置元素对应的位，返回是否有位从0变为1
+func (f bloomBitset) add(item string) bool {
+	changed := false
+	bits := f[bloomHeaderSize:]
+	for _, pos := range f.positions(item) {
+		if bits[pos/8]&(1<<(pos%8)) == 0 {
+			bits[pos/8] |= 1 << (pos % 8)
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (f bloomBitset) contains(item string) bool {
+	bits := f[bloomHeaderSize:]
+	for _, pos := range f.positions(item) {
+		if bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+// interface包：进程内的发布订阅
+// 为没有原生发布订阅能力的嵌入式驱动提供Publish/Subscribe实现
+//
+// 消息只在同一个进程、同一个缓存实例内传递，不会持久化；
+// 每个订阅者有独立的缓冲区，缓冲区满时丢弃新消息，发布方不会被慢订阅者阻塞
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBuffer 每个订阅者的消息缓冲区大小
+const subscriberBuffer = 64
+
+// Message 发布订阅的消息
+type Message struct {
+	Channel string // 频道名称
+	Payload string // 消息内容
+}
+
+// Broker 进程内的消息代理，零值可直接使用
+type Broker struct {
+	mu   sync.Mutex
+	subs map[string]map[*subscriber]struct{} // 频道到订阅者集合的映射
+}
+
+// subscriber 单个订阅者
+type subscriber struct {
+	ch   chan Message
+	once sync.Once
+}
+
+func (s *subscriber) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Publish 向频道的所有订阅者发送消息，没有订阅者时消息被丢弃
+func (b *Broker) Publish(channel, payload string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	msg := Message{Channel: channel, Payload: payload}
+	for sub := range b.subs[channel] {
+		select {
+		case sub.ch <- msg:
+		default:
+			// 订阅者缓冲区已满，丢弃消息
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅频道
+// 返回值：
+//
+//	<-chan Message - 消息通道，取消订阅或Broker关闭后被关闭
+//	func() - 取消订阅函数，可重复调用
+func (b *Broker) Subscribe(channel string) (<-chan Message, func()) {
+	sub := &subscriber{ch: make(chan Message, subscriberBuffer)}
+
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[string]map[*subscriber]struct{})
+	}
+	if b.subs[channel] == nil {
+		b.subs[channel] = make(map[*subscriber]struct{})
+	}
+	b.subs[channel][sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub.ch, func() { b.unsubscribe(channel, sub) }
+}
+
+// SubscribeContext 订阅频道，上下文取消或超时时自动取消订阅
+func (b *Broker) SubscribeContext(ctx context.Context, channel string) (<-chan Message, func()) {
+	return subscribeContext(ctx, func() (<-chan Message, func()) {
+		return b.Subscribe(channel)
+	})
+}
+
+// Close 关闭所有订阅者的消息通道，之后仍可以重新订阅
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			sub.close()
+		}
+	}
+	b.subs = nil
+}
+
+func (b *Broker) unsubscribe(channel string, sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if subs, ok := b.subs[channel]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(b.subs, channel)
+		}
+	}
+	sub.close()
+}
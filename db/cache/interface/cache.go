@@ -12,10 +12,25 @@
 // - 错误定义：统一的错误类型定义
 //
 // 支持的操作类型：
-// - 基本键值操作（Get/Set/Delete/Exists/Expire）
-// - 哈希表操作（HGet/HSet/HDel/HGetAll）
-// - 队列操作（Push/Pop/LPush/RPush/LPop/RPop/PopAll/Len）
-// - 事务操作（BeginTx/Commit/Rollback）
+// - 基本键值操作（Get/Set/Delete/Exists/Expire/TTL）
+// - 条件和交换操作（SetNX/GetSet/GetDel）
+// - 字符串追加（Append/StrLen）
+// - 对象存取（SetObject/GetObject），编码方式由配置的Codec决定
+// - 键遍历、键值遍历和批量删除（Keys/Iterate/DeleteByPrefix）
+// - 哈希表操作（HGet/HSet/HDel/HGetAll/HLen/HExists/HKeys）
+// - 集合操作（SAdd/SRem/SMembers/SIsMember）
+// - 队列操作（Push/Pop/LPush/RPush/RPushCapped/LPop/RPop/PopAll/Len/PopAck/Ack/PushDelayed）
+// - 列表读取和编辑（LRange/LIndex/LRem/LTrim），下标语义与Redis一致
+// - 发布订阅（Publish/Subscribe）
+// - 键过期通知（SubscribeExpired）
+// - 事务操作（BeginTx/Commit/Rollback），BeginTx函数按TxOptions开启只读事务，驱动可以通过TxBeginner接口提供原生实现
+// - 统计信息（Stats）
+// - 备份与恢复（Backup/Restore）
+// - 带上下文的操作（CacheCtx接口，GetContext/SetContext等）
+// - 默认过期时间（配置了DefaultTTL时由New统一应用，NoExpiry表示不过期）
+// - 布隆过滤器（BFReserve/BFAdd/BFExists函数，驱动可以通过Bloom接口提供原生实现）
+// - 命令管道（Pipeline函数，驱动可以通过PipelineRunner接口提供原生实现）
+// - 种子文件预热（Preload/PreloadFile函数，配置了PreloadFile时由New在创建实例后执行）
 //
 // 设计模式：
 // - 工厂模式：统一创建不同类型的缓存实例
@@ -26,8 +41,10 @@
 package _interface
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/gophertool/tool/db/cache/config"
@@ -46,6 +63,34 @@ type Cache interface {
 	Exists(key string) (bool, error)
 	// Expire 设置 key 的过期时间
 	Expire(key string, ttl time.Duration) error
+	// TTL 获取 key 的剩余过期时间，key 没有过期时间时返回 TTLNoExpiry，不存在时返回 TTLNotFound
+	TTL(key string) (time.Duration, error)
+
+	// SetNX key 不存在时设置 key-value 并设置过期时间，返回是否设置成功
+	SetNX(key string, value string, ttl time.Duration) (bool, error)
+	// CAS key 的当前值等于 oldValue 时设置为 newValue 并设置过期时间，返回是否设置成功；key 不存在时返回 false
+	CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error)
+	// GetSet 设置新值并返回旧值，新值不过期；key 不存在时仍会写入新值并返回 ErrKeyNotFound
+	GetSet(key string, value string) (string, error)
+	// GetDel 获取并删除指定 key，key 不存在时返回 ErrKeyNotFound
+	GetDel(key string) (string, error)
+	// Append 将 value 追加到 key 的值末尾并返回追加后的长度（字节数），key 不存在时创建不过期的 key，已有的过期时间保持不变
+	Append(key string, value string) (int64, error)
+	// StrLen 返回 key 的值的长度（字节数），key 不存在时返回 0
+	StrLen(key string) (int64, error)
+	// SetObject 使用配置的Codec编码v并设置key的值
+	SetObject(key string, v any, ttl time.Duration) error
+	// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+	GetObject(key string, v any) error
+
+	// Keys 遍历匹配 pattern 的 key，fn 返回 false 时停止遍历；
+	// 键值、哈希表和队列分开存储的驱动只遍历键值
+	Keys(pattern string, fn func(key string) bool) error
+	// Iterate 遍历键名以 prefix 开头的键值，fn 返回 false 时停止遍历；
+	// 驱动分批读取，不会一次性把所有值加载到内存，遍历的范围与 Keys 一致
+	Iterate(prefix string, fn func(key, value string) bool) error
+	// DeleteByPrefix 删除所有以 prefix 开头的 key，用于整个命名空间的缓存失效
+	DeleteByPrefix(prefix string) error
 
 	// HGet 获取哈希表中指定 field 的值
 	HGet(key, field string) (string, error)
@@ -55,6 +100,21 @@ type Cache interface {
 	HDel(key, field string) error
 	// HGetAll 获取哈希表中所有的 field 和 value
 	HGetAll(key string) (map[string]string, error)
+	// HLen 获取哈希表中 field 的数量，哈希表不存在时返回 0
+	HLen(key string) (int64, error)
+	// HExists 判断哈希表中是否存在 field
+	HExists(key, field string) (bool, error)
+	// HKeys 获取哈希表中所有的 field，哈希表不存在时返回空切片
+	HKeys(key string) ([]string, error)
+
+	// SAdd 向集合添加成员，成员已存在时不做任何操作
+	SAdd(key, member string) error
+	// SRem 从集合删除成员
+	SRem(key, member string) error
+	// SMembers 获取集合的所有成员，集合不存在时返回空切片
+	SMembers(key string) ([]string, error)
+	// SIsMember 判断 member 是否为集合成员
+	SIsMember(key, member string) (bool, error)
 
 	// Push 向队列中推入元素（默认实现）
 	Push(key string, value string) error
@@ -62,6 +122,8 @@ type Cache interface {
 	LPush(key string, value string) error
 	// RPush 将元素插入到列表右边
 	RPush(key string, value string) error
+	// RPushCapped 将元素插入到列表右边，列表长度超过 maxLen 时从左边删除最早的元素，适合保存最近 N 条记录；maxLen 不大于 0 时等同于 RPush
+	RPushCapped(key string, value string, maxLen int64) error
 	// Pop 弹出队列中的元素（默认实现）
 	Pop(key string) (string, error)
 	// LPop 弹出列表最左边的元素
@@ -72,23 +134,198 @@ type Cache interface {
 	PopAll(key string) ([]string, error)
 	// Len 获取队列长度
 	Len(key string) (int64, error)
+	// LRange 获取队列中下标在 [start, stop] 范围内的元素，不移除元素；负数下标表示从尾部倒数，队列不存在时返回空切片
+	LRange(key string, start, stop int64) ([]string, error)
+	// LIndex 获取队列中指定下标的元素，负数下标表示从尾部倒数，下标超出范围时返回 ErrKeyNotFound
+	LIndex(key string, index int64) (string, error)
+	// LRem 删除队列中等于 value 的元素，count 大于 0 时从头部删除 count 个，小于 0 时从尾部删除 -count 个，等于 0 时全部删除，返回删除的数量
+	LRem(key string, count int64, value string) (int64, error)
+	// LTrim 只保留队列中下标在 [start, stop] 范围内的元素，范围为空时删除整个队列
+	LTrim(key string, start, stop int64) error
+	// PopAck 弹出列表最左边的元素并返回回执，visibility 内没有 Ack 的元素会重新回到列表左边
+	PopAck(key string, visibility time.Duration) (string, string, error)
+	// Ack 确认 PopAck 弹出的元素已处理完成，回执不存在或已超时时返回 ErrKeyNotFound
+	Ack(key, receipt string) error
+	// PushDelayed 推入延迟元素，delay 之后元素才会出现在列表右边并可以被弹出
+	PushDelayed(key, value string, delay time.Duration) error
+
+	// Publish 向频道发布消息
+	Publish(channel string, payload string) error
+	// Subscribe 订阅频道，返回消息通道和取消订阅函数，取消订阅后消息通道被关闭
+	Subscribe(channel string) (<-chan Message, func())
+	// SubscribeExpired 订阅键过期事件，返回过期键名通道和取消订阅函数；不支持的驱动返回已关闭的通道
+	SubscribeExpired() (<-chan string, func())
+
+	// Stats 返回驱动的统计信息，驱动无法提供的数值字段为StatsUnknown
+	Stats() (Stats, error)
+
+	// Backup 将所有数据写入w，备份流以驱动名称开头，只能恢复到同一种驱动
+	Backup(w io.Writer) error
+
+	// Restore 从r读取Backup写入的数据并写入缓存，已有的同名键会被覆盖，其他键保留
+	Restore(r io.Reader) error
 
 	// BeginTx 开启事务操作
 	BeginTx() (Tx, error) // 事务操作
+	// BeginTxWatch 开启监视 keys 的事务，keys 在开启事务之后、提交之前被修改时 Commit 返回 ErrTxConflict 且不执行事务
+	BeginTxWatch(keys ...string) (Tx, error)
 }
 
 // Tx 事务接口
+// 基于存储引擎事务的驱动（BadgerDB、BuntDB、bbolt、Pebble、SQLite、etcd）在事务内读取时能看到事务内尚未提交的写入；
+// Redis 事务基于 MULTI，命令在提交时才执行，内存、Ristretto 和 Memcached 的事务操作同样在提交时才执行，
+// 这些驱动在事务内读取的是已提交的值
 type Tx interface {
+	// Get 获取指定 key 的值，key 不存在时返回 ErrKeyNotFound
+	Get(key string) (string, error)
+	// Exists 判断 key 是否存在
+	Exists(key string) (bool, error)
 	// Set 设置 key-value 并设置过期时间
 	Set(key string, value string, ttl time.Duration) error
 	// Delete 删除指定 key
 	Delete(key string) error
+	// Expire 设置 key 的过期时间，key 不存在时不做任何操作
+	Expire(key string, ttl time.Duration) error
+	// HSet 设置哈希表中的 field-value，ttl 为整个哈希表的过期时间，0 表示保持原有的过期时间
+	HSet(key, field, value string, ttl time.Duration) error
+	// HDel 删除哈希表中的 field
+	HDel(key, field string) error
 	// Commit 提交事务
 	Commit() error
 	// Rollback 回滚事务
 	Rollback() error
 }
 
+// CacheCtx 支持上下文的缓存接口，所有内置驱动都实现了该接口
+// 每个方法与 Cache 中的同名方法（去掉 Context 后缀）行为一致，
+// 上下文已取消或超时时返回上下文的错误，Redis 驱动还会将上下文传递给客户端用于链路追踪
+type CacheCtx interface {
+	Cache
+
+	// GetContext 获取指定 key 的值
+	GetContext(ctx context.Context, key string) (string, error)
+	// SetContext 设置 key-value 并设置过期时间
+	SetContext(ctx context.Context, key string, value string, ttl time.Duration) error
+	// DeleteContext 删除指定 key
+	DeleteContext(ctx context.Context, key string) error
+	// ExistsContext 判断 key 是否存在
+	ExistsContext(ctx context.Context, key string) (bool, error)
+	// ExpireContext 设置 key 的过期时间
+	ExpireContext(ctx context.Context, key string, ttl time.Duration) error
+	// TTLContext 获取 key 的剩余过期时间
+	TTLContext(ctx context.Context, key string) (time.Duration, error)
+
+	// SetNXContext key 不存在时设置 key-value 并设置过期时间，返回是否设置成功
+	SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error)
+	// CASContext key 的当前值等于 oldValue 时设置为 newValue 并设置过期时间，返回是否设置成功
+	CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error)
+	// GetSetContext 设置新值并返回旧值
+	GetSetContext(ctx context.Context, key string, value string) (string, error)
+	// GetDelContext 获取并删除指定 key
+	GetDelContext(ctx context.Context, key string) (string, error)
+	// AppendContext 将 value 追加到 key 的值末尾并返回追加后的长度
+	AppendContext(ctx context.Context, key string, value string) (int64, error)
+	// StrLenContext 返回 key 的值的长度
+	StrLenContext(ctx context.Context, key string) (int64, error)
+	// SetObjectContext 使用配置的Codec编码v并设置key的值
+	SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error
+	// GetObjectContext 获取key的值并使用配置的Codec解码到v指向的值
+	GetObjectContext(ctx context.Context, key string, v any) error
+
+	// KeysContext 遍历匹配 pattern 的 key
+	KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error
+	// IterateContext 遍历键名以 prefix 开头的键值
+	IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error
+	// DeleteByPrefixContext 删除所有以 prefix 开头的 key
+	DeleteByPrefixContext(ctx context.Context, prefix string) error
+
+	// HGetContext 获取哈希表中指定 field 的值
+	HGetContext(ctx context.Context, key, field string) (string, error)
+	// HSetContext 设置哈希表中的 field-value，并设置过期时间
+	HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error
+	// HDelContext 删除哈希表中的一个或多个 field
+	HDelContext(ctx context.Context, key, field string) error
+	// HGetAllContext 获取哈希表中所有的 field 和 value
+	HGetAllContext(ctx context.Context, key string) (map[string]string, error)
+	// HLenContext 获取哈希表中 field 的数量，哈希表不存在时返回 0
+	HLenContext(ctx context.Context, key string) (int64, error)
+	// HExistsContext 判断哈希表中是否存在 field
+	HExistsContext(ctx context.Context, key, field string) (bool, error)
+	// HKeysContext 获取哈希表中所有的 field，哈希表不存在时返回空切片
+	HKeysContext(ctx context.Context, key string) ([]string, error)
+
+	// SAddContext 向集合添加成员
+	SAddContext(ctx context.Context, key, member string) error
+	// SRemContext 从集合删除成员
+	SRemContext(ctx context.Context, key, member string) error
+	// SMembersContext 获取集合的所有成员
+	SMembersContext(ctx context.Context, key string) ([]string, error)
+	// SIsMemberContext 判断 member 是否为集合成员
+	SIsMemberContext(ctx context.Context, key, member string) (bool, error)
+
+	// PushContext 向队列中推入元素（默认实现）
+	PushContext(ctx context.Context, key string, value string) error
+	// LPushContext 将元素插入到列表左边
+	LPushContext(ctx context.Context, key string, value string) error
+	// RPushContext 将元素插入到列表右边
+	RPushContext(ctx context.Context, key string, value string) error
+	// RPushCappedContext 将元素插入到列表右边并把列表长度限制在 maxLen 以内
+	RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error
+	// PopContext 弹出队列中的元素（默认实现）
+	PopContext(ctx context.Context, key string) (string, error)
+	// LPopContext 弹出列表最左边的元素
+	LPopContext(ctx context.Context, key string) (string, error)
+	// RPopContext 弹出列表最右边的元素
+	RPopContext(ctx context.Context, key string) (string, error)
+	// PopAllContext 弹出队列中所有元素
+	PopAllContext(ctx context.Context, key string) ([]string, error)
+	// LenContext 获取队列长度
+	LenContext(ctx context.Context, key string) (int64, error)
+	// LRangeContext 获取队列中下标在 [start, stop] 范围内的元素，不移除元素；负数下标表示从尾部倒数，队列不存在时返回空切片
+	LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error)
+	// LIndexContext 获取队列中指定下标的元素，负数下标表示从尾部倒数，下标超出范围时返回 ErrKeyNotFound
+	LIndexContext(ctx context.Context, key string, index int64) (string, error)
+	// LRemContext 删除队列中等于 value 的元素，count 大于 0 时从头部删除 count 个，小于 0 时从尾部删除 -count 个，等于 0 时全部删除，返回删除的数量
+	LRemContext(ctx context.Context, key string, count int64, value string) (int64, error)
+	// LTrimContext 只保留队列中下标在 [start, stop] 范围内的元素，范围为空时删除整个队列
+	LTrimContext(ctx context.Context, key string, start, stop int64) error
+	// PopAckContext 弹出列表最左边的元素并返回回执
+	PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error)
+	// AckContext 确认 PopAck 弹出的元素已处理完成
+	AckContext(ctx context.Context, key, receipt string) error
+	// PushDelayedContext 推入延迟元素
+	PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error
+
+	// PublishContext 向频道发布消息
+	PublishContext(ctx context.Context, channel string, payload string) error
+	// SubscribeContext 订阅频道，上下文取消或超时时自动取消订阅
+	SubscribeContext(ctx context.Context, channel string) (<-chan Message, func())
+	// SubscribeExpiredContext 订阅键过期事件，上下文取消或超时时自动取消订阅
+	SubscribeExpiredContext(ctx context.Context) (<-chan string, func())
+
+	// StatsContext 返回驱动的统计信息
+	StatsContext(ctx context.Context) (Stats, error)
+
+	// BackupContext 将所有数据写入w
+	BackupContext(ctx context.Context, w io.Writer) error
+
+	// RestoreContext 从r读取Backup写入的数据并写入缓存
+	RestoreContext(ctx context.Context, r io.Reader) error
+
+	// BeginTxContext 开启事务操作
+	BeginTxContext(ctx context.Context) (Tx, error)
+	// BeginTxWatchContext 开启监视 keys 的事务
+	BeginTxWatchContext(ctx context.Context, keys ...string) (Tx, error)
+}
+
+// TTL 的特殊返回值，与 Redis PTTL 命令的 -1/-2 约定一致
+const (
+	// TTLNoExpiry key 存在但没有设置过期时间
+	TTLNoExpiry time.Duration = -1
+	// TTLNotFound key 不存在或已过期
+	TTLNotFound time.Duration = -2
+)
+
 // NewStoreFunc 创建缓存实例的函数类型
 type NewStoreFunc func(config config.Cache) (Cache, error)
 
@@ -98,6 +335,12 @@ var (
 
 	// ErrUnsupportedDriver 不支持的驱动类型
 	ErrUnsupportedDriver = errors.New("unsupported cache driver")
+
+	// ErrUnsupported 驱动不支持该操作
+	ErrUnsupported = errors.New("operation not supported by cache driver")
+
+	// ErrTxConflict 事务监视的key在提交前被修改，事务没有执行
+	ErrTxConflict = errors.New("transaction aborted: watched key changed")
 )
 
 // 存储不同驱动的构造函数
@@ -109,6 +352,8 @@ func RegisterDriver(driverName string, newFunc NewStoreFunc) {
 }
 
 // New 根据配置创建缓存实例的工厂函数
+// 配置了DefaultTTL时，返回的实例在写入的ttl为0时使用DefaultTTL
+// 配置了PreloadFile时，创建实例后从种子文件预热，预热失败时关闭实例并返回错误
 // 参数：
 //
 //	cfg - 缓存配置，包含驱动类型、连接信息等
@@ -132,9 +377,34 @@ func New(cfg config.Cache) (Cache, error) {
 		return nil, fmt.Errorf("创建%s缓存实例失败: %w", cfg.Driver, err)
 	}
 
+	if cfg.DefaultTTL > 0 {
+		cache = withDefaultTTL(cache, cfg.DefaultTTL)
+	}
+	if cfg.PreloadFile != "" {
+		if _, err := PreloadFile(cache, cfg.PreloadFile); err != nil {
+			cache.Close()
+			return nil, fmt.Errorf("预热%s缓存失败: %w", cfg.Driver, err)
+		}
+	}
 	return cache, nil
 }
 
+// NewContext 根据配置创建支持上下文的缓存实例
+// 驱动没有实现 CacheCtx 时关闭已创建的实例并返回 ErrUnsupportedDriver
+func NewContext(cfg config.Cache) (CacheCtx, error) {
+	cache, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheCtx, ok := cache.(CacheCtx)
+	if !ok {
+		cache.Close()
+		return nil, fmt.Errorf("%w: %s 不支持上下文", ErrUnsupportedDriver, cfg.Driver)
+	}
+	return cacheCtx, nil
+}
+
 // GetRegisteredDrivers 获取已注册的所有驱动名称
 func GetRegisteredDrivers() []string {
 	drivers := make([]string, 0, len(storeFactories))
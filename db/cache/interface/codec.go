@@ -0,0 +1,101 @@
+// interface包：值编解码
+// 缓存只保存字符串，需要存取结构化数据的组件通过Codec把值编码为字节
+//
+// 内置的编码方式：
+// - json：可读、可跨语言使用，默认编码方式
+// - gob：Go原生二进制编码，只能在Go程序之间使用
+// - msgpack：紧凑的二进制编码，可跨语言使用
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gophertool/tool/db/cache/config"
+	"github.com/gophertool/tool/encoding/msgpack"
+)
+
+// Codec 值编解码接口，编码结果以字符串形式保存在缓存中
+type Codec interface {
+	// Marshal 将v编码为字节
+	Marshal(v any) ([]byte, error)
+	// Unmarshal 将data解码到v指向的值
+	Unmarshal(data []byte, v any) error
+}
+
+// ErrUnsupportedCodec 不支持的编码方式
+var ErrUnsupportedCodec = errors.New("unsupported cache codec")
+
+// 存储已注册的编码方式
+var codecs = map[string]Codec{
+	config.CodecJSON:    JSONCodec{},
+	config.CodecGob:     GobCodec{},
+	config.CodecMsgpack: MsgpackCodec{},
+}
+
+// RegisterCodec 注册编码方式，同名的编码方式会被覆盖
+func RegisterCodec(name string, codec Codec) {
+	codecs[name] = codec
+}
+
+// GetCodec 根据名称获取编码方式，名称为空时返回JSONCodec
+// 参数：
+//
+//	name - 编码方式名称，对应config.Cache的Codec字段
+//
+// 返回值：
+//
+//	Codec - 编码方式
+//	error - 名称未注册时返回ErrUnsupportedCodec
+func GetCodec(name string) (Codec, error) {
+	if name == "" {
+		return JSONCodec{}, nil
+	}
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCodec, name)
+	}
+	return codec, nil
+}
+
+// JSONCodec 使用encoding/json编解码，编码结果可读、可跨语言使用
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// GobCodec 使用encoding/gob编解码，接口类型的值需要先通过gob.Register注册
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// MsgpackCodec 使用MessagePack编解码，编码结果比JSON更紧凑，类型映射见encoding/msgpack包
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
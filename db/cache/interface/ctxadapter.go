@@ -0,0 +1,477 @@
+// interface包：带上下文的缓存操作适配
+// 本地驱动的操作不会阻塞在网络上，带上下文的方法只需要在执行前检查上下文是否已取消或超时，
+// 之后的行为与不带上下文的方法一致。CtxAdapter统一提供这些方法，驱动嵌入后即实现CacheCtx
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// CtxAdapter 基于Cache实现CacheCtx中带上下文的方法
+// 驱动在结构体中嵌入CtxAdapter，并在构造时使用NewCtxAdapter传入自身
+type CtxAdapter struct {
+	cache Cache
+}
+
+// NewCtxAdapter 创建带上下文方法的适配器
+// 参数：
+//
+//	cache - 实际执行操作的缓存，通常是嵌入适配器的驱动自身
+//
+// 返回值：
+//
+//	CtxAdapter - 适配器
+func NewCtxAdapter(cache Cache) CtxAdapter {
+	return CtxAdapter{cache: cache}
+}
+
+// GetContext 带上下文的Get
+func (a CtxAdapter) GetContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.Get(key)
+}
+
+// SetContext 带上下文的Set
+func (a CtxAdapter) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Set(key, value, ttl)
+}
+
+// DeleteContext 带上下文的Delete
+func (a CtxAdapter) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Delete(key)
+}
+
+// ExistsContext 带上下文的Exists
+func (a CtxAdapter) ExistsContext(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.cache.Exists(key)
+}
+
+// ExpireContext 带上下文的Expire
+func (a CtxAdapter) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Expire(key, ttl)
+}
+
+// TTLContext 带上下文的TTL
+func (a CtxAdapter) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.TTL(key)
+}
+
+// SetNXContext 带上下文的SetNX
+func (a CtxAdapter) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.cache.SetNX(key, value, ttl)
+}
+
+// CASContext 带上下文的CAS
+func (a CtxAdapter) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.cache.CAS(key, oldValue, newValue, ttl)
+}
+
+// GetSetContext 带上下文的GetSet
+func (a CtxAdapter) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.GetSet(key, value)
+}
+
+// GetDelContext 带上下文的GetDel
+func (a CtxAdapter) GetDelContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.GetDel(key)
+}
+
+// AppendContext 带上下文的Append
+func (a CtxAdapter) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.Append(key, value)
+}
+
+// StrLenContext 带上下文的StrLen
+func (a CtxAdapter) StrLenContext(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.StrLen(key)
+}
+
+// SetObjectContext 带上下文的SetObject
+func (a CtxAdapter) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.SetObject(key, v, ttl)
+}
+
+// GetObjectContext 带上下文的GetObject
+func (a CtxAdapter) GetObjectContext(ctx context.Context, key string, v any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.GetObject(key, v)
+}
+
+// KeysContext 带上下文的Keys
+func (a CtxAdapter) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Keys(pattern, fn)
+}
+
+// IterateContext 带上下文的Iterate，遍历期间上下文取消或超时时停止遍历并返回上下文的错误
+func (a CtxAdapter) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := a.cache.Iterate(prefix, func(key, value string) bool {
+		return ctx.Err() == nil && fn(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// DeleteByPrefixContext 带上下文的DeleteByPrefix
+func (a CtxAdapter) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.DeleteByPrefix(prefix)
+}
+
+// HGetContext 带上下文的HGet
+func (a CtxAdapter) HGetContext(ctx context.Context, key, field string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.HGet(key, field)
+}
+
+// HSetContext 带上下文的HSet
+func (a CtxAdapter) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.HSet(key, field, value, ttl)
+}
+
+// HDelContext 带上下文的HDel
+func (a CtxAdapter) HDelContext(ctx context.Context, key, field string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.HDel(key, field)
+}
+
+// HGetAllContext 带上下文的HGetAll
+func (a CtxAdapter) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.HGetAll(key)
+}
+
+// HLenContext 带上下文的HLen
+func (a CtxAdapter) HLenContext(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.HLen(key)
+}
+
+// HExistsContext 带上下文的HExists
+func (a CtxAdapter) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.cache.HExists(key, field)
+}
+
+// HKeysContext 带上下文的HKeys
+func (a CtxAdapter) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.HKeys(key)
+}
+
+// SAddContext 带上下文的SAdd
+func (a CtxAdapter) SAddContext(ctx context.Context, key, member string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.SAdd(key, member)
+}
+
+// SRemContext 带上下文的SRem
+func (a CtxAdapter) SRemContext(ctx context.Context, key, member string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.SRem(key, member)
+}
+
+// SMembersContext 带上下文的SMembers
+func (a CtxAdapter) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.SMembers(key)
+}
+
+// SIsMemberContext 带上下文的SIsMember
+func (a CtxAdapter) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.cache.SIsMember(key, member)
+}
+
+// PushContext 带上下文的Push
+func (a CtxAdapter) PushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Push(key, value)
+}
+
+// LPushContext 带上下文的LPush
+func (a CtxAdapter) LPushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.LPush(key, value)
+}
+
+// RPushContext 带上下文的RPush
+func (a CtxAdapter) RPushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.RPush(key, value)
+}
+
+// RPushCappedContext 带上下文的RPushCapped
+func (a CtxAdapter) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.RPushCapped(key, value, maxLen)
+}
+
+// PopContext 带上下文的Pop
+func (a CtxAdapter) PopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.Pop(key)
+}
+
+// LPopContext 带上下文的LPop
+func (a CtxAdapter) LPopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.LPop(key)
+}
+
+// RPopContext 带上下文的RPop
+func (a CtxAdapter) RPopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.RPop(key)
+}
+
+// PopAllContext 带上下文的PopAll
+func (a CtxAdapter) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.PopAll(key)
+}
+
+// LenContext 带上下文的Len
+func (a CtxAdapter) LenContext(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.Len(key)
+}
+
+// LRangeContext 带上下文的LRange
+func (a CtxAdapter) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.LRange(key, start, stop)
+}
+
+// LIndexContext 带上下文的LIndex
+func (a CtxAdapter) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.cache.LIndex(key, index)
+}
+
+// LRemContext 带上下文的LRem
+func (a CtxAdapter) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return a.cache.LRem(key, count, value)
+}
+
+// LTrimContext 带上下文的LTrim
+func (a CtxAdapter) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.LTrim(key, start, stop)
+}
+
+// PopAckContext 带上下文的PopAck
+func (a CtxAdapter) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return a.cache.PopAck(key, visibility)
+}
+
+// AckContext 带上下文的Ack
+func (a CtxAdapter) AckContext(ctx context.Context, key, receipt string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Ack(key, receipt)
+}
+
+// PushDelayedContext 带上下文的PushDelayed
+func (a CtxAdapter) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.PushDelayed(key, value, delay)
+}
+
+// PublishContext 带上下文的Publish
+func (a CtxAdapter) PublishContext(ctx context.Context, channel string, payload string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Publish(channel, payload)
+}
+
+// SubscribeContext 带上下文的Subscribe，上下文取消或超时时自动取消订阅
+func (a CtxAdapter) SubscribeContext(ctx context.Context, channel string) (<-chan Message, func()) {
+	return subscribeContext(ctx, func() (<-chan Message, func()) {
+		return a.cache.Subscribe(channel)
+	})
+}
+
+// SubscribeExpiredContext 带上下文的SubscribeExpired，上下文取消或超时时自动取消订阅
+func (a CtxAdapter) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return subscribeContext(ctx, a.cache.SubscribeExpired)
+}
+
+// BeginTxContext 带上下文的BeginTx
+func (a CtxAdapter) BeginTxContext(ctx context.Context) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.BeginTx()
+}
+
+// BeginTxWatchContext 带上下文的BeginTxWatch
+func (a CtxAdapter) BeginTxWatchContext(ctx context.Context, keys ...string) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.cache.BeginTxWatch(keys...)
+}
+
+// StatsContext 带上下文的Stats
+func (a CtxAdapter) StatsContext(ctx context.Context) (Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return Stats{}, err
+	}
+	return a.cache.Stats()
+}
+
+// BackupContext 带上下文的Backup
+func (a CtxAdapter) BackupContext(ctx context.Context, w io.Writer) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Backup(w)
+}
+
+// RestoreContext 带上下文的Restore
+func (a CtxAdapter) RestoreContext(ctx context.Context, rd io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.cache.Restore(rd)
+}
+
+// subscribeContext 调用subscribe订阅，上下文取消或超时时自动取消订阅
+// 上下文已经结束时不订阅，直接返回已关闭的通道
+func subscribeContext[T any](ctx context.Context, subscribe func() (<-chan T, func())) (<-chan T, func()) {
+	if ctx.Err() != nil {
+		ch := make(chan T)
+		close(ch)
+		return ch, func() {}
+	}
+
+	ch, cancel := subscribe()
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			stop()
+		case <-done:
+		}
+	}()
+	return ch, stop
+}
@@ -0,0 +1,236 @@
+// interface包：默认过期时间
+// 配置了DefaultTTL时，New返回的缓存实例在调用方传入的ttl为0时使用DefaultTTL，
+// 运维人员可以统一限制缓存数据的最长生存时间，而不需要修改每个调用点
+//
+// 生效的操作：
+// - Set/SetNX/CAS/SetObject及其带上下文的版本
+// - HSet，ttl为0时不再保持哈希表原有的过期时间，而是设置为DefaultTTL
+// - 事务中的Set/HSet
+// - GetSet，写入后再单独设置过期时间，两步之间不是原子的
+//
+// Expire不受影响，队列、集合等没有ttl参数的操作也不受影响；
+// 确实需要永久保存的数据使用NoExpiry作为ttl
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// NoExpiry 写入时作为ttl使用，表示不过期，同时不使用配置的DefaultTTL
+// 与TTL返回的TTLNoExpiry值相同，没有配置DefaultTTL时与0等效
+const NoExpiry time.Duration = -1
+
+// withDefaultTTL 返回在c之上应用默认过期时间的缓存实例，c实现了CacheCtx时同样实现CacheCtx
+func withDefaultTTL(c Cache, ttl time.Duration) Cache {
+	if cc, ok := c.(CacheCtx); ok {
+		return &defaultTTLCtx{CacheCtx: cc, ttl: ttl}
+	}
+	return &defaultTTL{Cache: c, ttl: ttl}
+}
+
+// resolveTTL 将调用方传入的ttl换算为传给驱动的ttl：0使用默认值，NoExpiry换算为0
+func resolveTTL(ttl, defaultTTL time.Duration) time.Duration {
+	switch ttl {
+	case 0:
+		return defaultTTL
+	case NoExpiry:
+		return 0
+	}
+	return ttl
+}
+
+// defaultTTL 默认过期时间装饰器，实现Cache接口
+type defaultTTL struct {
+	Cache
+	ttl time.Duration
+}
+
+func (d *defaultTTL) Set(key string, value string, ttl time.Duration) error {
+	return d.Cache.Set(key, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTL) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return d.Cache.SetNX(key, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTL) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return d.Cache.CAS(key, oldValue, newValue, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTL) GetSet(key string, value string) (string, error) {
+	old, err := d.Cache.GetSet(key, value)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return old, err
+	}
+	if expireErr := d.Cache.Expire(key, d.ttl); expireErr != nil {
+		return old, expireErr
+	}
+	return old, err
+}
+
+func (d *defaultTTL) SetObject(key string, v any, ttl time.Duration) error {
+	return d.Cache.SetObject(key, v, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTL) HSet(key, field, value string, ttl time.Duration) error {
+	return d.Cache.HSet(key, field, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTL) BeginTx() (Tx, error) {
+	tx, err := d.Cache.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	return &ttlTx{Tx: tx, ttl: d.ttl}, nil
+}
+
+func (d *defaultTTL) BeginTxWatch(keys ...string) (Tx, error) {
+	tx, err := d.Cache.BeginTxWatch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &ttlTx{Tx: tx, ttl: d.ttl}, nil
+}
+
+func (d *defaultTTL) BeginTxOptions(ctx context.Context, opts TxOptions) (Tx, error) {
+	tx, err := BeginTx(ctx, d.Cache, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &ttlTx{Tx: tx, ttl: d.ttl}, nil
+}
+
+func (d *defaultTTL) PipelineContext(ctx context.Context, fn func(p Pipeliner) error) error {
+	return PipelineContext(ctx, d.Cache, func(p Pipeliner) error {
+		return fn(&ttlPipeliner{Pipeliner: p, ttl: d.ttl})
+	})
+}
+
+// ttlTx 应用默认过期时间的事务
+type ttlTx struct {
+	Tx
+	ttl time.Duration
+}
+
+func (t *ttlTx) Set(key string, value string, ttl time.Duration) error {
+	return t.Tx.Set(key, value, resolveTTL(ttl, t.ttl))
+}
+
+func (t *ttlTx) HSet(key, field, value string, ttl time.Duration) error {
+	return t.Tx.HSet(key, field, value, resolveTTL(ttl, t.ttl))
+}
+
+// ttlPipeliner 应用默认过期时间的命令管道
+type ttlPipeliner struct {
+	Pipeliner
+	ttl time.Duration
+}
+
+func (p *ttlPipeliner) Set(key string, value string, ttl time.Duration) *PipeStatusCmd {
+	return p.Pipeliner.Set(key, value, resolveTTL(ttl, p.ttl))
+}
+
+func (p *ttlPipeliner) HSet(key, field, value string, ttl time.Duration) *PipeStatusCmd {
+	return p.Pipeliner.HSet(key, field, value, resolveTTL(ttl, p.ttl))
+}
+
+// defaultTTLCtx 底层缓存实现了CacheCtx时使用的装饰器，不带上下文的方法与defaultTTL一致
+type defaultTTLCtx struct {
+	CacheCtx
+	ttl time.Duration
+}
+
+func (d *defaultTTLCtx) plain() *defaultTTL {
+	return &defaultTTL{Cache: d.CacheCtx, ttl: d.ttl}
+}
+
+func (d *defaultTTLCtx) Set(key string, value string, ttl time.Duration) error {
+	return d.plain().Set(key, value, ttl)
+}
+
+func (d *defaultTTLCtx) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return d.plain().SetNX(key, value, ttl)
+}
+
+func (d *defaultTTLCtx) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return d.plain().CAS(key, oldValue, newValue, ttl)
+}
+
+func (d *defaultTTLCtx) GetSet(key string, value string) (string, error) {
+	return d.plain().GetSet(key, value)
+}
+
+func (d *defaultTTLCtx) SetObject(key string, v any, ttl time.Duration) error {
+	return d.plain().SetObject(key, v, ttl)
+}
+
+func (d *defaultTTLCtx) HSet(key, field, value string, ttl time.Duration) error {
+	return d.plain().HSet(key, field, value, ttl)
+}
+
+func (d *defaultTTLCtx) BeginTx() (Tx, error) {
+	return d.plain().BeginTx()
+}
+
+func (d *defaultTTLCtx) BeginTxWatch(keys ...string) (Tx, error) {
+	return d.plain().BeginTxWatch(keys...)
+}
+
+func (d *defaultTTLCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return d.CacheCtx.SetContext(ctx, key, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTLCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return d.CacheCtx.SetNXContext(ctx, key, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTLCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return d.CacheCtx.CASContext(ctx, key, oldValue, newValue, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTLCtx) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	old, err := d.CacheCtx.GetSetContext(ctx, key, value)
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return old, err
+	}
+	if expireErr := d.CacheCtx.ExpireContext(ctx, key, d.ttl); expireErr != nil {
+		return old, expireErr
+	}
+	return old, err
+}
+
+func (d *defaultTTLCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	return d.CacheCtx.SetObjectContext(ctx, key, v, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTLCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	return d.CacheCtx.HSetContext(ctx, key, field, value, resolveTTL(ttl, d.ttl))
+}
+
+func (d *defaultTTLCtx) BeginTxContext(ctx context.Context) (Tx, error) {
+	tx, err := d.CacheCtx.BeginTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ttlTx{Tx: tx, ttl: d.ttl}, nil
+}
+
+func (d *defaultTTLCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (Tx, error) {
+	tx, err := d.CacheCtx.BeginTxWatchContext(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &ttlTx{Tx: tx, ttl: d.ttl}, nil
+}
+
+func (d *defaultTTLCtx) BeginTxOptions(ctx context.Context, opts TxOptions) (Tx, error) {
+	return d.plain().BeginTxOptions(ctx, opts)
+}
+
+func (d *defaultTTLCtx) PipelineContext(ctx context.Context, fn func(p Pipeliner) error) error {
+	return d.plain().PipelineContext(ctx, fn)
+}
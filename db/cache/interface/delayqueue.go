@@ -0,0 +1,125 @@
+// interface包：延迟队列
+// 为没有有序集合的驱动提供PushDelayed实现，基于Cache的队列和哈希表操作
+//
+// 延迟元素保存在 <key>:delayed 哈希表中，字段为"就绪时间（Unix毫秒，定长补零）:随机编号"，值为元素；
+// 字段按字典序排列即按就绪时间排列，形成时间戳索引。
+// 驱动在弹出或读取队列长度前调用Promote，把已到就绪时间的元素按就绪顺序追加到队列尾部。
+// DelayQueue记录每个队列最早的就绪时间，没有到期元素时Promote不读取哈希表
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DelayQueue 基于Cache实现的延迟队列，零值可直接使用
+// 所有操作在同一个互斥锁内串行执行，只适用于单进程访问的嵌入式驱动
+type DelayQueue struct {
+	mu   sync.Mutex
+	next map[string]int64 // 各队列最早的就绪时间（Unix毫秒），0表示没有延迟元素；不在映射中表示尚未读取过
+}
+
+// DelayedKey 返回保存延迟元素的键名
+func DelayedKey(key string) string {
+	return key + ":delayed"
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在队列尾部
+// 参数：
+//
+//	c - 缓存实例
+//	key - 队列键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入队列
+//
+// 返回值：
+//
+//	error - 操作错误
+func (q *DelayQueue) PushDelayed(c Cache, key, value string, delay time.Duration) error {
+	if delay <= 0 {
+		return c.RPush(key, value)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ready := time.Now().Add(delay).UnixMilli()
+	if err := c.HSet(DelayedKey(key), fmt.Sprintf("%020d:%s", ready, NewReceipt()), value, 0); err != nil {
+		return err
+	}
+	if next, ok := q.next[key]; ok && (next == 0 || ready < next) {
+		q.next[key] = ready
+	}
+	return nil
+}
+
+// Promote 把已到就绪时间的延迟元素按就绪顺序追加到队列尾部
+// 参数：
+//
+//	c - 缓存实例
+//	key - 队列键名
+//
+// 返回值：
+//
+//	error - 操作错误
+func (q *DelayQueue) Promote(c Cache, key string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	if next, ok := q.next[key]; ok && (next == 0 || next > now) {
+		return nil
+	}
+
+	delayed := DelayedKey(key)
+	entries, err := c.HGetAll(delayed)
+	if err != nil {
+		return err
+	}
+
+	ready := make([]string, 0, len(entries))
+	var next int64
+	for field := range entries {
+		ms, _, _ := strings.Cut(field, ":")
+		at, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid delayed entry %q", field)
+		}
+		if at <= now {
+			ready = append(ready, field)
+		} else if next == 0 || at < next {
+			next = at
+		}
+	}
+	sort.Strings(ready)
+
+	for _, field := range ready {
+		// 先推入再删除索引，中途失败时元素不会丢失
+		if err := c.RPush(key, entries[field]); err != nil {
+			return err
+		}
+		if err := c.HDel(delayed, field); err != nil {
+			return err
+		}
+	}
+
+	if q.next == nil {
+		q.next = make(map[string]int64)
+	}
+	q.next[key] = next
+	return nil
+}
+
+// Reset 清除记录的就绪时间，下一次Promote重新读取哈希表
+// 延迟元素不是通过PushDelayed写入时（例如Restore）需要调用
+func (q *DelayQueue) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.next = nil
+}
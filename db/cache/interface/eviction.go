@@ -0,0 +1,215 @@
+// interface包：容量限制和淘汰策略
+// 按条目数或字节数限制容量的驱动共用淘汰策略的实现：
+// - lru：淘汰最久未访问的条目（默认）
+// - lfu：近似LFU，随机抽取evictionSamples个条目，淘汰其中访问次数最少的
+// - random：随机淘汰一个条目
+//
+// 最近写入或访问的条目只有在它是唯一的条目时才会被淘汰，避免刚写入的值立即被淘汰。
+// 没有内置淘汰机制的驱动（如纯内存模式的BuntDB）使用Evictor跟踪条目的大小和访问情况
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gophertool/tool/db/cache/config"
+)
+
+// evictionSamples LFU策略每次淘汰时抽样的条目数
+const evictionSamples = 5
+
+// CheckEvictionPolicy 检查淘汰策略是否有效，空字符串表示LRU
+func CheckEvictionPolicy(policy string) error {
+	switch policy {
+	case "", config.EvictionLRU, config.EvictionLFU, config.EvictionRandom:
+		return nil
+	}
+	return fmt.Errorf("unknown eviction policy %q", policy)
+}
+
+// EvictionVictim 按淘汰策略选出要淘汰的链表节点
+// 参数：
+//
+//	policy - 淘汰策略，空字符串表示LRU
+//	order - 按访问顺序排列的链表，最近访问的在前
+//	items - 所有节点的索引，LFU和随机策略从中抽样
+//	hits - 返回节点的访问次数，只在LFU策略下调用
+//
+// 返回值：
+//
+//	*list.Element - 要淘汰的节点，链表为空时返回nil
+func EvictionVictim(policy string, order *list.List, items map[string]*list.Element, hits func(elem *list.Element) uint64) *list.Element {
+	newest := order.Front()
+	if order.Len() <= 1 || policy == "" || policy == config.EvictionLRU {
+		return order.Back()
+	}
+
+	// map的遍历顺序是随机的，跳过最近访问的节点后取前几个作为样本
+	var victim *list.Element
+	samples := 0
+	for _, elem := range items {
+		if elem == newest {
+			continue
+		}
+		if policy == config.EvictionRandom {
+			return elem
+		}
+		if victim == nil || hits(elem) < hits(victim) {
+			victim = elem
+		}
+		if samples++; samples == evictionSamples {
+			break
+		}
+	}
+	return victim
+}
+
+// Evictor 跟踪条目的大小和访问情况，超出容量限制时选出要淘汰的条目
+// 驱动在写入时调用Track、读取时调用Touch、删除时调用Remove，并删除Track返回的条目；
+// 方法可以并发调用，nil的Evictor表示不限制容量，所有方法都不做任何操作
+type Evictor struct {
+	mu         sync.Mutex
+	policy     string
+	maxEntries int
+	maxBytes   int64
+	items      map[string]*list.Element
+	order      *list.List // 按访问顺序排列，最近访问的在前
+	bytes      int64
+	evictions  int64
+}
+
+// evictItem Evictor跟踪的条目
+type evictItem struct {
+	key  string
+	size int64
+	hits uint64
+}
+
+// NewEvictor 创建Evictor
+// 参数：
+//
+//	policy - 淘汰策略，可选lru/lfu/random，为空时使用lru
+//	maxEntries - 最大条目数，0表示不限制
+//	maxBytes - 最大字节数，0表示不限制
+//
+// 返回值：
+//
+//	*Evictor - 两个限制都为0时返回nil
+//	error - 淘汰策略无效
+func NewEvictor(policy string, maxEntries int, maxBytes int64) (*Evictor, error) {
+	if err := CheckEvictionPolicy(policy); err != nil {
+		return nil, err
+	}
+	if maxEntries <= 0 && maxBytes <= 0 {
+		return nil, nil
+	}
+	return &Evictor{
+		policy:     policy,
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}, nil
+}
+
+// Track 记录条目的写入，返回超出容量限制需要淘汰的条目，淘汰的条目已不再被跟踪
+// 参数：
+//
+//	key - 条目的键
+//	size - 条目占用的字节数
+//
+// 返回值：
+//
+//	[]string - 需要由调用方删除的条目
+func (e *Evictor) Track(key string, size int64) []string {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if elem, ok := e.items[key]; ok {
+		item := elem.Value.(*evictItem)
+		e.bytes += size - item.size
+		item.size = size
+		item.hits++
+		e.order.MoveToFront(elem)
+	} else {
+		e.items[key] = e.order.PushFront(&evictItem{key: key, size: size})
+		e.bytes += size
+	}
+
+	var victims []string
+	for e.order.Len() > 0 &&
+		((e.maxEntries > 0 && e.order.Len() > e.maxEntries) || (e.maxBytes > 0 && e.bytes > e.maxBytes)) {
+		elem := EvictionVictim(e.policy, e.order, e.items, func(elem *list.Element) uint64 {
+			return elem.Value.(*evictItem).hits
+		})
+		victims = append(victims, e.removeElement(elem))
+		e.evictions++
+	}
+	return victims
+}
+
+// Touch 记录条目被访问，没有被跟踪的条目不做任何操作
+func (e *Evictor) Touch(key string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if elem, ok := e.items[key]; ok {
+		elem.Value.(*evictItem).hits++
+		e.order.MoveToFront(elem)
+	}
+}
+
+// Remove 停止跟踪已删除的条目
+func (e *Evictor) Remove(key string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if elem, ok := e.items[key]; ok {
+		e.removeElement(elem)
+	}
+}
+
+// RemovePrefix 停止跟踪键以prefix开头的条目，空字符串表示所有条目
+func (e *Evictor) RemovePrefix(prefix string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, elem := range e.items {
+		if strings.HasPrefix(key, prefix) {
+			e.removeElement(elem)
+		}
+	}
+}
+
+// Evictions 返回因超出容量限制而淘汰的条目数
+func (e *Evictor) Evictions() int64 {
+	if e == nil {
+		return 0
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.evictions
+}
+
+func (e *Evictor) removeElement(elem *list.Element) string {
+	item := e.order.Remove(elem).(*evictItem)
+	delete(e.items, item.key)
+	e.bytes -= item.size
+	return item.key
+}
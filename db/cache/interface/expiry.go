@@ -0,0 +1,157 @@
+// interface包：键过期通知
+// 为没有原生过期事件的嵌入式驱动提供SubscribeExpired实现
+//
+// 通知是按需开启的：第一个订阅者出现时才启动后台扫描，最后一个订阅者取消后停止，
+// 没有订阅者时不产生任何额外开销。每个订阅者有独立的缓冲区，缓冲区满时丢弃新事件
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// expiryInterval 后台扫描过期键的间隔
+const expiryInterval = time.Second
+
+// SweepFunc 扫描在(since, until]时间窗口内过期的键，返回需要通知的键名
+type SweepFunc func(since, until time.Time) []string
+
+// ExpiryNotifier 键过期事件的订阅管理，零值可直接使用
+type ExpiryNotifier struct {
+	mu   sync.Mutex
+	subs map[chan string]struct{}
+	stop chan struct{} // 关闭时停止后台扫描
+	done chan struct{} // 后台扫描退出后关闭
+}
+
+// Active 是否有订阅者，驱动可以据此跳过不必要的通知
+func (n *ExpiryNotifier) Active() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.subs) > 0
+}
+
+// Notify 向所有订阅者发送过期的键名
+func (n *ExpiryNotifier) Notify(keys ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, key := range keys {
+		for ch := range n.subs {
+			select {
+			case ch <- key:
+			default:
+				// 订阅者缓冲区已满，丢弃事件
+			}
+		}
+	}
+}
+
+// Subscribe 订阅过期事件
+// 参数：
+//
+//	sweep - 后台扫描函数，为nil时只通过Notify发送事件
+//
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭后被关闭
+//	func() - 取消订阅函数，可重复调用
+func (n *ExpiryNotifier) Subscribe(sweep SweepFunc) (<-chan string, func()) {
+	ch := make(chan string, subscriberBuffer)
+
+	n.mu.Lock()
+	if n.subs == nil {
+		n.subs = make(map[chan string]struct{})
+	}
+	n.subs[ch] = struct{}{}
+	if sweep != nil && n.stop == nil {
+		n.stop = make(chan struct{})
+		n.done = make(chan struct{})
+		go n.run(sweep, n.stop, n.done)
+	}
+	n.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() { once.Do(func() { n.unsubscribe(ch) }) }
+}
+
+// SubscribeContext 订阅过期事件，上下文取消或超时时自动取消订阅
+func (n *ExpiryNotifier) SubscribeContext(ctx context.Context, sweep SweepFunc) (<-chan string, func()) {
+	return subscribeContext(ctx, func() (<-chan string, func()) {
+		return n.Subscribe(sweep)
+	})
+}
+
+// Close 关闭所有订阅者的通道并等待后台扫描退出，之后仍可以重新订阅
+func (n *ExpiryNotifier) Close() {
+	n.mu.Lock()
+	for ch := range n.subs {
+		close(ch)
+	}
+	n.subs = nil
+	done := n.halt()
+	n.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// ClosedExpiry 返回已关闭的过期事件通道，供不支持过期通知的驱动使用
+func ClosedExpiry() <-chan string {
+	ch := make(chan string)
+	close(ch)
+	return ch
+}
+
+func (n *ExpiryNotifier) unsubscribe(ch chan string) {
+	n.mu.Lock()
+	if _, ok := n.subs[ch]; ok {
+		delete(n.subs, ch)
+		close(ch)
+	}
+	var done chan struct{}
+	if len(n.subs) == 0 {
+		done = n.halt()
+	}
+	n.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// halt 通知后台扫描停止并返回其退出信号，调用方需持有锁
+func (n *ExpiryNotifier) halt() chan struct{} {
+	if n.stop == nil {
+		return nil
+	}
+	close(n.stop)
+	done := n.done
+	n.stop, n.done = nil, nil
+	return done
+}
+
+// run 定期扫描过期键并通知订阅者
+func (n *ExpiryNotifier) run(sweep SweepFunc, stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	since := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case until := <-ticker.C:
+			if keys := sweep(since, until); len(keys) > 0 {
+				n.Notify(keys...)
+			}
+			since = until
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// interface包：哈希表字段的复合键编码
+// 没有原生哈希表的驱动（BuntDB、BadgerDB、etcd）把每个字段保存为一个独立的键。
+// 早期版本使用key:field形式的复合键，会与普通键（如user:1）以及队列的key:head、key:tail等内部键冲突，
+// HGetAll会把它们当作字段返回。现在字段保存为"\x00h\x00"+key+"\x00"+field，
+// 以\x00开头的前缀不会与普通键冲突，key和field之间的\x00也保证不同哈希表的字段互不重叠。
+//
+// 旧数据的兼容：驱动打开数据库时检查HashEncodingMarker，数据库为空时写入标记并只使用新编码；
+// 已有数据但没有标记的数据库进入兼容模式，读取时同时读取key:field形式的旧字段，
+// 写入和删除字段时删除对应的旧字段，字段随着写入逐步迁移到新编码
+//
+// 作者: gophertool
+package _interface
+
+import "strings"
+
+// HashKeyPrefix 哈希表字段复合键的前缀，驱动遍历普通键时应跳过以它开头的键
+const HashKeyPrefix = "\x00h\x00"
+
+// HashEncodingMarker 使用新编码的数据库中保存的标记键，它本身也以HashKeyPrefix开头
+const HashEncodingMarker = HashKeyPrefix + "v2"
+
+// HashKey 生成哈希表字段对应的复合键
+// 参数：
+//
+//	key - 哈希表键名，不能包含\x00
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 复合键
+func HashKey(key, field string) string {
+	return HashFieldPrefix(key) + field
+}
+
+// HashFieldPrefix 返回哈希表所有字段复合键的公共前缀，用于遍历一个哈希表的字段
+func HashFieldPrefix(key string) string {
+	return HashKeyPrefix + key + "\x00"
+}
+
+// ParseHashKey 从复合键中解析出哈希表键名和字段名
+// 参数：
+//
+//	k - 驱动中保存的键
+//
+// 返回值：
+//
+//	string - 哈希表键名
+//	string - 字段名
+//	bool - k是否为哈希表字段的复合键
+func ParseHashKey(k string) (string, string, bool) {
+	if !strings.HasPrefix(k, HashKeyPrefix) {
+		return "", "", false
+	}
+	key, field, ok := strings.Cut(k[len(HashKeyPrefix):], "\x00")
+	return key, field, ok
+}
+
+// LegacyHashKey 生成兼容模式下旧版本的key:field复合键
+func LegacyHashKey(key, field string) string {
+	return key + ":" + field
+}
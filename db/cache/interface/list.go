@@ -0,0 +1,95 @@
+// interface包：列表下标计算
+// LRange/LIndex/LRem/LTrim的下标语义与Redis一致：下标从0开始，负数表示从尾部倒数（-1为最后一个元素），
+// 范围的两端都包含在内，超出列表的部分被截断。没有原生列表的驱动用这里的函数把下标换算到自己的存储上
+//
+// 作者: gophertool
+package _interface
+
+// ListRange 将[start, stop]范围换算为长度为n的列表中的半开区间[lo, hi)
+// 参数：
+//
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//	n - 列表长度
+//
+// 返回值：
+//
+//	int64 - 区间起点
+//	int64 - 区间终点（不包含），范围为空时不大于起点
+func ListRange(start, stop, n int64) (int64, int64) {
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop {
+		return 0, 0
+	}
+	return start, stop + 1
+}
+
+// ListIndex 将下标换算为长度为n的列表中的位置
+// 参数：
+//
+//	index - 下标，负数表示从尾部倒数
+//	n - 列表长度
+//
+// 返回值：
+//
+//	int64 - 位置
+//	bool - 下标是否在列表范围内
+func ListIndex(index, n int64) (int64, bool) {
+	if index < 0 {
+		index += n
+	}
+	return index, index >= 0 && index < n
+}
+
+// ListRem 按LRem的语义删除items中等于value的元素
+// 参数：
+//
+//	items - 列表的所有元素，按从头到尾的顺序
+//	count - 大于0时从头部开始删除count个，小于0时从尾部开始删除-count个，等于0时删除全部
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	[]string - 保留的元素，顺序不变
+//	int64 - 删除的数量
+func ListRem(items []string, count int64, value string) ([]string, int64) {
+	remove := make([]bool, len(items))
+	var removed int64
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	for j := range items {
+		i := j
+		if count < 0 {
+			i = len(items) - 1 - j
+		}
+		if items[i] != value {
+			continue
+		}
+		remove[i] = true
+		removed++
+		if removed == limit {
+			break
+		}
+	}
+
+	kept := make([]string, 0, len(items)-int(removed))
+	for i, item := range items {
+		if !remove[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, removed
+}
@@ -0,0 +1,148 @@
+// interface包：键名通配符匹配
+// 与Redis的KEYS/SCAN命令使用相同的通配符语法，供不支持原生模式匹配的驱动使用
+//
+// 支持的通配符：
+// - * 匹配任意长度（包括0）的任意字符
+// - ? 匹配任意单个字符
+// - [abc] 匹配括号中的任意字符，[^abc] 匹配括号外的任意字符，[a-z] 匹配字符范围
+// - \ 转义下一个字符
+//
+// 作者: gophertool
+package _interface
+
+import "strings"
+
+// MatchPattern 判断key是否匹配通配符pattern，按字节匹配
+// 参数：
+//
+//	pattern - 通配符模式，空字符串等同于*
+//	key - 键名
+//
+// 返回值：
+//
+//	bool - 是否匹配
+func MatchPattern(pattern, key string) bool {
+	if pattern == "" {
+		return true
+	}
+	return matchPattern(pattern, key)
+}
+
+// PatternPrefix 返回pattern中第一个通配符之前的字面前缀
+// 驱动可以只遍历以该前缀开头的键，再用MatchPattern过滤
+func PatternPrefix(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*', '?', '[':
+			return b.String()
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			b.WriteByte(pattern[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// EscapePattern 转义字符串中的通配符，使其在模式中按字面匹配
+func EscapePattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// matchPattern 通配符匹配，遇到*时回溯
+func matchPattern(pattern, key string) bool {
+	// starP/starK 记录最近一个*的位置和它当前匹配到的key位置，用于回溯
+	starP, starK := -1, 0
+	p, k := 0, 0
+	for k < len(key) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starP, starK = p, k
+				p++
+				continue
+			case '?':
+				p++
+				k++
+				continue
+			case '[':
+				if next, ok := matchClass(pattern, p, key[k]); ok {
+					p = next
+					k++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == key[k] {
+					p += 2
+					k++
+					continue
+				}
+			default:
+				if pattern[p] == key[k] {
+					p++
+					k++
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		// 让上一个*多匹配一个字符后重试
+		starK++
+		p, k = starP+1, starK
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass 匹配从pattern[start]开始的字符集合[...]
+// 返回集合之后的位置和c是否属于该集合；集合没有闭合时]视为延伸到模式末尾
+func matchClass(pattern string, start int, c byte) (int, bool) {
+	i := start + 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+	matched := false
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == c {
+				matched = true
+			}
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+		default:
+			if pattern[i] == c {
+				matched = true
+			}
+		}
+	}
+	if i < len(pattern) {
+		i++ // 跳过]
+	}
+	return i, matched != negate
+}
@@ -0,0 +1,232 @@
+// interface包：命令管道
+// Pipeline把一组命令合并执行，调用方不需要为了减少网络往返而使用事务：
+// - 驱动实现了PipelineRunner时使用驱动的原生实现，如Redis把所有命令放在一次往返中发送
+// - 否则在fn返回之后按顺序逐个执行命令，本地驱动没有网络往返，结果与原生实现相同
+//
+// 管道不是事务：命令之间可能穿插其他客户端的命令，某个命令失败不影响其他命令。
+// fn只负责排队，命令的结果在Pipeline返回之后才可以读取；fn返回错误时不执行任何命令
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPipelinePending 管道中的命令还没有执行
+var ErrPipelinePending = errors.New("pipeline command not executed")
+
+// PipeCmd 管道中一个命令的结果
+type PipeCmd[T any] struct {
+	val T
+	err error
+}
+
+// PipeStatusCmd 只返回错误的命令的结果
+type PipeStatusCmd = PipeCmd[struct{}]
+
+// NewPipeCmd 创建尚未执行的命令结果，由驱动在排队时调用
+func NewPipeCmd[T any]() *PipeCmd[T] {
+	return &PipeCmd[T]{err: ErrPipelinePending}
+}
+
+// SetResult 设置命令的结果，由驱动在执行管道之后调用
+func (c *PipeCmd[T]) SetResult(val T, err error) {
+	c.val, c.err = val, err
+}
+
+// Result 返回命令的值和错误，命令没有执行时返回ErrPipelinePending
+func (c *PipeCmd[T]) Result() (T, error) {
+	return c.val, c.err
+}
+
+// Val 返回命令的值，出错时为零值
+func (c *PipeCmd[T]) Val() T {
+	return c.val
+}
+
+// Err 返回命令的错误
+func (c *PipeCmd[T]) Err() error {
+	return c.err
+}
+
+// Pipeliner 管道中可以排队的命令，每个命令与Cache中的同名方法行为一致
+type Pipeliner interface {
+	Get(key string) *PipeCmd[string]
+	Set(key string, value string, ttl time.Duration) *PipeStatusCmd
+	Delete(key string) *PipeStatusCmd
+	Exists(key string) *PipeCmd[bool]
+	Expire(key string, ttl time.Duration) *PipeStatusCmd
+	TTL(key string) *PipeCmd[time.Duration]
+	HGet(key, field string) *PipeCmd[string]
+	HSet(key, field, value string, ttl time.Duration) *PipeStatusCmd
+	HDel(key, field string) *PipeStatusCmd
+	SAdd(key, member string) *PipeStatusCmd
+	SRem(key, member string) *PipeStatusCmd
+	LPush(key string, value string) *PipeStatusCmd
+	RPush(key string, value string) *PipeStatusCmd
+}
+
+// PipelineRunner 原生支持命令管道的驱动实现的接口
+type PipelineRunner interface {
+	// PipelineContext 调用fn排队命令，fn返回nil时一次执行所有命令
+	PipelineContext(ctx context.Context, fn func(p Pipeliner) error) error
+}
+
+// Pipeline 使用context.Background()执行命令管道，见PipelineContext
+func Pipeline(c Cache, fn func(p Pipeliner) error) error {
+	return PipelineContext(context.Background(), c, fn)
+}
+
+// PipelineContext 执行命令管道
+// 参数：
+//
+//	ctx - 上下文，逐个执行时每个命令执行前检查
+//	c - 缓存实例
+//	fn - 排队命令的函数，返回错误时不执行任何命令
+//
+// 返回值：
+//
+//	error - fn返回的错误，或第一个失败的命令的错误；ErrKeyNotFound只体现在命令的结果中，不作为管道的错误
+func PipelineContext(ctx context.Context, c Cache, fn func(p Pipeliner) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r, ok := c.(PipelineRunner); ok {
+		return r.PipelineContext(ctx, fn)
+	}
+
+	cc, ok := c.(CacheCtx)
+	if !ok {
+		cc = ctxCache{Cache: c, CtxAdapter: NewCtxAdapter(c)}
+	}
+	b := &batch{}
+	if err := fn(b); err != nil {
+		return err
+	}
+	var first error
+	for _, op := range b.ops {
+		if err := op(ctx, cc); first == nil && err != nil && !errors.Is(err, ErrKeyNotFound) {
+			first = err
+		}
+	}
+	return first
+}
+
+// PipelineError 返回第一个失败的命令的错误，供驱动的原生实现汇总结果
+func PipelineError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// ctxCache 为没有实现CacheCtx的缓存提供带上下文的方法
+type ctxCache struct {
+	Cache
+	CtxAdapter
+}
+
+// batch 逐个执行的命令管道
+type batch struct {
+	ops []func(ctx context.Context, c CacheCtx) error
+}
+
+// queue 排队一个命令，执行时设置cmd的结果
+func queue[T any](b *batch, run func(ctx context.Context, c CacheCtx) (T, error)) *PipeCmd[T] {
+	cmd := NewPipeCmd[T]()
+	b.ops = append(b.ops, func(ctx context.Context, c CacheCtx) error {
+		cmd.SetResult(run(ctx, c))
+		return cmd.err
+	})
+	return cmd
+}
+
+// status 排队一个只返回错误的命令
+func status(b *batch, run func(ctx context.Context, c CacheCtx) error) *PipeStatusCmd {
+	return queue(b, func(ctx context.Context, c CacheCtx) (struct{}, error) {
+		return struct{}{}, run(ctx, c)
+	})
+}
+
+func (b *batch) Get(key string) *PipeCmd[string] {
+	return queue(b, func(ctx context.Context, c CacheCtx) (string, error) {
+		return c.GetContext(ctx, key)
+	})
+}
+
+func (b *batch) Set(key string, value string, ttl time.Duration) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.SetContext(ctx, key, value, ttl)
+	})
+}
+
+func (b *batch) Delete(key string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.DeleteContext(ctx, key)
+	})
+}
+
+func (b *batch) Exists(key string) *PipeCmd[bool] {
+	return queue(b, func(ctx context.Context, c CacheCtx) (bool, error) {
+		return c.ExistsContext(ctx, key)
+	})
+}
+
+func (b *batch) Expire(key string, ttl time.Duration) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.ExpireContext(ctx, key, ttl)
+	})
+}
+
+func (b *batch) TTL(key string) *PipeCmd[time.Duration] {
+	return queue(b, func(ctx context.Context, c CacheCtx) (time.Duration, error) {
+		return c.TTLContext(ctx, key)
+	})
+}
+
+func (b *batch) HGet(key, field string) *PipeCmd[string] {
+	return queue(b, func(ctx context.Context, c CacheCtx) (string, error) {
+		return c.HGetContext(ctx, key, field)
+	})
+}
+
+func (b *batch) HSet(key, field, value string, ttl time.Duration) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.HSetContext(ctx, key, field, value, ttl)
+	})
+}
+
+func (b *batch) HDel(key, field string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.HDelContext(ctx, key, field)
+	})
+}
+
+func (b *batch) SAdd(key, member string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.SAddContext(ctx, key, member)
+	})
+}
+
+func (b *batch) SRem(key, member string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.SRemContext(ctx, key, member)
+	})
+}
+
+func (b *batch) LPush(key string, value string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.LPushContext(ctx, key, value)
+	})
+}
+
+func (b *batch) RPush(key string, value string) *PipeStatusCmd {
+	return status(b, func(ctx context.Context, c CacheCtx) error {
+		return c.RPushContext(ctx, key, value)
+	})
+}
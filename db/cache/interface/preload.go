@@ -0,0 +1,302 @@
+// interface包：从种子文件预热缓存
+// Preload在服务启动时把种子文件中的键值批量写入缓存，用于分发预置的字典数据或恢复之前导出的快照
+//
+// 支持的格式：
+// - JSON：对象数组或每行一个对象（JSON Lines），对象包含key、value和可选的ttl
+// - CSV：每行key,value[,ttl]，第一行为key,value[,ttl]表头时跳过
+//
+// ttl可以是time.ParseDuration支持的字符串（如"1h30m"），也可以是表示秒数的整数，省略或为0时与Set的ttl为0相同。
+// 条目按批通过PipelineContext写入，Redis每批只需要一次网络往返；数据流式读取，种子文件不需要整体读入内存。
+// 配置了config.Cache.PreloadFile时New在创建实例后自动预热
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPreloadFormat 种子数据格式错误
+var ErrPreloadFormat = errors.New("cache: invalid preload data")
+
+// defaultPreloadBatch 默认每批写入的条目数
+const defaultPreloadBatch = 500
+
+// PreloadFormat 种子数据的格式
+type PreloadFormat string
+
+const (
+	// PreloadAuto 根据第一个非空白字符判断格式，'['或'{'为JSON，否则为CSV
+	PreloadAuto PreloadFormat = ""
+	// PreloadJSON JSON对象数组或JSON Lines
+	PreloadJSON PreloadFormat = "json"
+	// PreloadCSV key,value[,ttl]格式的CSV
+	PreloadCSV PreloadFormat = "csv"
+)
+
+// PreloadOptions 预热选项
+type PreloadOptions struct {
+	Format    PreloadFormat // 种子数据的格式，默认自动判断
+	BatchSize int           // 每批写入的条目数，0表示500
+}
+
+// PreloadEntry 种子数据中的一个条目
+type PreloadEntry struct {
+	Key   string        // 键名
+	Value string        // 值
+	TTL   time.Duration // 过期时间，0与Set的ttl为0相同
+}
+
+// Preload 使用context.Background()从种子数据预热缓存，见PreloadContext
+func Preload(c Cache, r io.Reader, opts PreloadOptions) (int, error) {
+	return PreloadContext(context.Background(), c, r, opts)
+}
+
+// PreloadFile 从种子文件预热缓存，.csv文件按CSV读取，.json、.jsonl和.ndjson文件按JSON读取，其他扩展名自动判断
+// 参数：
+//
+//	c - 缓存实例
+//	path - 种子文件路径
+//
+// 返回值：
+//
+//	int - 成功写入的条目数
+//	error - 打开文件的错误或PreloadContext返回的错误
+func PreloadFile(c Cache, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var opts PreloadOptions
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		opts.Format = PreloadCSV
+	case ".json", ".jsonl", ".ndjson":
+		opts.Format = PreloadJSON
+	}
+	return Preload(c, f, opts)
+}
+
+// PreloadContext 从种子数据预热缓存
+// 参数：
+//
+//	ctx - 上下文，每批写入前检查
+//	c - 缓存实例
+//	r - 种子数据，JSON或CSV格式
+//	opts - 预热选项
+//
+// 返回值：
+//
+//	int - 成功写入的条目数，出错时为出错之前已写入的条目数
+//	error - 读取错误、格式错误（ErrPreloadFormat，包含条目的序号）或第一个写入失败的错误
+func PreloadContext(ctx context.Context, c Cache, r io.Reader, opts PreloadOptions) (int, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPreloadBatch
+	}
+
+	br := bufio.NewReader(r)
+	first, err := skipSpace(br)
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	format := opts.Format
+	if format == PreloadAuto {
+		format = PreloadCSV
+		if first == '[' || first == '{' {
+			format = PreloadJSON
+		}
+	}
+	var next func() (PreloadEntry, error)
+	switch format {
+	case PreloadJSON:
+		if next, err = jsonPreloadReader(br, first == '['); err != nil {
+			return 0, err
+		}
+	case PreloadCSV:
+		next = csvPreloadReader(br)
+	default:
+		return 0, fmt.Errorf("%w: unknown format %q", ErrPreloadFormat, format)
+	}
+
+	loaded := 0
+	batch := make([]PreloadEntry, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := PipelineContext(ctx, c, func(p Pipeliner) error {
+			for _, e := range batch {
+				p.Set(e.Key, e.Value, e.TTL)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		loaded += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		e, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return loaded, err
+		}
+		if batch = append(batch, e); len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return loaded, err
+			}
+		}
+	}
+	return loaded, flush()
+}
+
+// skipSpace 跳过UTF-8 BOM和前导空白，返回第一个非空白字符但不消耗它
+func skipSpace(br *bufio.Reader) (byte, error) {
+	if p, _ := br.Peek(3); bytes.Equal(p, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3)
+	}
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+// jsonPreloadEntry JSON种子数据中的一个对象
+type jsonPreloadEntry struct {
+	Key   *string         `json:"key"`
+	Value *string         `json:"value"`
+	TTL   json.RawMessage `json:"ttl"`
+}
+
+// jsonPreloadReader 逐个读取JSON对象数组或JSON Lines中的条目
+func jsonPreloadReader(r io.Reader, array bool) (func() (PreloadEntry, error), error) {
+	dec := json.NewDecoder(r)
+	if array {
+		// 消耗开头的'['
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrPreloadFormat, err)
+		}
+	}
+	index := 0
+	return func() (PreloadEntry, error) {
+		if array && !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return PreloadEntry{}, fmt.Errorf("%w: %v", ErrPreloadFormat, err)
+			}
+			return PreloadEntry{}, io.EOF
+		}
+		index++
+		var raw jsonPreloadEntry
+		if err := dec.Decode(&raw); err != nil {
+			if err == io.EOF && !array {
+				return PreloadEntry{}, io.EOF
+			}
+			return PreloadEntry{}, fmt.Errorf("%w: entry %d: %v", ErrPreloadFormat, index, err)
+		}
+		if raw.Key == nil || raw.Value == nil {
+			return PreloadEntry{}, fmt.Errorf("%w: entry %d: key and value are required", ErrPreloadFormat, index)
+		}
+		ttl, err := parseJSONTTL(raw.TTL)
+		if err != nil {
+			return PreloadEntry{}, fmt.Errorf("%w: entry %d: %v", ErrPreloadFormat, index, err)
+		}
+		return PreloadEntry{Key: *raw.Key, Value: *raw.Value, TTL: ttl}, nil
+	}, nil
+}
+
+// parseJSONTTL 解析JSON中的ttl，可以是字符串或表示秒数的整数
+func parseJSONTTL(raw json.RawMessage) (time.Duration, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, err
+		}
+		return parseTTL(s)
+	}
+	return parseTTL(string(raw))
+}
+
+// csvPreloadReader 逐行读取key,value[,ttl]格式的CSV，第一行为表头时跳过
+func csvPreloadReader(r io.Reader) func() (PreloadEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	first := true
+	return func() (PreloadEntry, error) {
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				return PreloadEntry{}, io.EOF
+			}
+			if err != nil {
+				return PreloadEntry{}, fmt.Errorf("%w: %v", ErrPreloadFormat, err)
+			}
+			line, _ := cr.FieldPos(0)
+			if len(rec) != 2 && len(rec) != 3 {
+				return PreloadEntry{}, fmt.Errorf("%w: line %d: expected key,value[,ttl], got %d fields", ErrPreloadFormat, line, len(rec))
+			}
+			if first {
+				first = false
+				if strings.EqualFold(rec[0], "key") && strings.EqualFold(rec[1], "value") {
+					continue
+				}
+			}
+			e := PreloadEntry{Key: rec[0], Value: rec[1]}
+			if len(rec) == 3 {
+				if e.TTL, err = parseTTL(rec[2]); err != nil {
+					return PreloadEntry{}, fmt.Errorf("%w: line %d: %v", ErrPreloadFormat, line, err)
+				}
+			}
+			return e, nil
+		}
+	}
+}
+
+// parseTTL 解析time.ParseDuration格式或表示秒数的整数，空字符串表示不过期
+func parseTTL(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	var ttl time.Duration
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		ttl = time.Duration(secs) * time.Second
+	} else if ttl, err = time.ParseDuration(s); err != nil {
+		return 0, fmt.Errorf("invalid ttl %q", s)
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("negative ttl %q", s)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,51 @@
+// interface包：乐观并发写入的重试
+// Append等读取后写入的操作在与并发的写入冲突时需要重新读取并重试，操作本身不能因为冲突而失败，
+// RetryConflict统一实现重试，两次尝试之间按随机的退避时间等待，避免多个写入者同时重试再次冲突
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// conflictBackoffMin 第一次重试前的最大等待时间
+	conflictBackoffMin = 100 * time.Microsecond
+	// conflictBackoffMax 重试等待时间的上限
+	conflictBackoffMax = 10 * time.Millisecond
+)
+
+// RetryConflict 反复调用attempt直到写入成功、返回错误或ctx结束，ctx已结束时不调用attempt
+// 每次冲突后等待[0, backoff)内的随机时间，backoff从100微秒开始每次翻倍，最多10毫秒
+// 参数：
+//
+//	ctx - 上下文，取消或超时后停止重试
+//	attempt - 读取并尝试写入，写入成功返回true，与并发的写入冲突返回false
+//
+// 返回值：
+//
+//	error - attempt返回的错误或ctx.Err()
+func RetryConflict(ctx context.Context, attempt func() (bool, error)) error {
+	backoff := conflictBackoffMin
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ok, err := attempt()
+		if err != nil || ok {
+			return err
+		}
+
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, conflictBackoffMax)
+	}
+}
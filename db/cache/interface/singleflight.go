@@ -0,0 +1,71 @@
+// interface包：加载合并与负缓存
+// 为GetOrLoad类的读穿透加载提供并发合并（singleflight）和"不存在"结果的缓存
+//
+// 同一个key的并发加载只执行一次，其他调用等待并共享结果，避免缓存失效瞬间大量请求同时访问数据源。
+// 加载结果为ErrKeyNotFound时，可以在 <key>:notfound 键上记录一段时间，期间不再重复加载
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"errors"
+	"sync"
+)
+
+// errPanicked 正在执行的调用发生panic时，等待者收到的错误
+var errPanicked = errors.New("cache: load function panicked")
+
+// NegativeKey 返回记录"不存在"结果的键名
+func NegativeKey(key string) string {
+	return key + ":notfound"
+}
+
+// Group 合并相同key的并发调用，零值可直接使用
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call 正在执行的调用
+type call struct {
+	wg  sync.WaitGroup
+	val any
+	err error
+}
+
+// Do 执行fn并返回结果，同一个key已有调用在执行时等待其完成并共享结果
+// 参数：
+//
+//	key - 合并调用的键
+//	fn - 实际执行的函数
+//
+// 返回值：
+//
+//	any - fn的返回值
+//	error - fn返回的错误
+func (g *Group) Do(key string, fn func() (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	// fn发生panic时也要唤醒等待者并清理，panic继续向上传播
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+	c.err = errPanicked
+	c.val, c.err = fn()
+	return c.val, c.err
+}
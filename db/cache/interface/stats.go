@@ -0,0 +1,48 @@
+// interface包：缓存统计信息
+// 将各驱动的内部统计归一化为统一的结构，用于监控面板和容量规划
+//
+// 作者: gophertool
+package _interface
+
+// StatsUnknown 驱动无法提供的数值统计项
+const StatsUnknown int64 = -1
+
+// Stats 缓存实例的统计信息，驱动无法提供的数值字段为StatsUnknown
+type Stats struct {
+	Driver      string            // 驱动名称，与config.Cache的Driver一致
+	Keys        int64             // 键数量的估计值，哈希表、队列按驱动的存储方式计入
+	MemoryBytes int64             // 内存占用字节数
+	DiskBytes   int64             // 磁盘占用字节数
+	Evictions   int64             // 因超出容量限制而被淘汰的条目数（Memory/Ristretto/BuntDB）
+	Levels      []LevelStats      // LSM树各层的统计（BadgerDB/Pebble）
+	Pool        *PoolStats        // 连接池统计（Redis）
+	Extra       map[string]string // 驱动特有的其他信息
+}
+
+// LevelStats LSM树一层的统计信息
+type LevelStats struct {
+	Level  int   // 层号，0为最上层
+	Tables int64 // SST文件数
+	Bytes  int64 // SST文件总字节数
+}
+
+// PoolStats 连接池统计信息
+type PoolStats struct {
+	Hits       uint32 // 从池中取到空闲连接的次数
+	Misses     uint32 // 池中没有空闲连接的次数
+	Timeouts   uint32 // 等待连接超时的次数
+	TotalConns uint32 // 连接总数
+	IdleConns  uint32 // 空闲连接数
+	StaleConns uint32 // 被移除的过期连接数
+}
+
+// NewStats 返回所有数值字段为StatsUnknown的统计信息，驱动在此基础上填充能提供的字段
+func NewStats(driver string) Stats {
+	return Stats{
+		Driver:      driver,
+		Keys:        StatsUnknown,
+		MemoryBytes: StatsUnknown,
+		DiskBytes:   StatsUnknown,
+		Evictions:   StatsUnknown,
+	}
+}
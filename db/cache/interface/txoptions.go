@@ -0,0 +1,147 @@
+// interface包：事务选项
+// BeginTx函数按TxOptions开启事务，只读事务和隔离级别提示让调用方表明意图，驱动可以据此选择代价更低的事务：
+// - BadgerDB开启只读事务，不参与冲突检测，提交时不会返回ErrTxConflict
+// - BuntDB和bbolt开启只读事务，只持有读锁，多个只读事务可以并发执行，不会阻塞在写事务之后
+// - SQLite在独立的只读连接池上开启事务，WAL模式下多个只读事务可以与写事务并发执行
+// - 其他驱动（以及没有原生实现的装饰器）开启普通事务，只读由包装的事务保证
+//
+// 只读事务的写操作返回ErrTxReadOnly，Commit与Rollback相同，只释放事务持有的资源
+//
+// 作者: gophertool
+package _interface
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTxReadOnly 在只读事务中写入
+var ErrTxReadOnly = errors.New("transaction is read-only")
+
+// IsolationLevel 事务隔离级别提示
+type IsolationLevel int
+
+const (
+	// IsolationDefault 驱动默认的隔离级别，见Tx接口的说明
+	IsolationDefault IsolationLevel = iota
+	// IsolationSnapshot 事务内的读取看到开启事务时的一致快照
+	IsolationSnapshot
+	// IsolationSerializable 事务的效果与按某种顺序逐个执行相同
+	IsolationSerializable
+)
+
+// String 返回隔离级别的名称
+func (l IsolationLevel) String() string {
+	switch l {
+	case IsolationDefault:
+		return "default"
+	case IsolationSnapshot:
+		return "snapshot"
+	case IsolationSerializable:
+		return "serializable"
+	}
+	return "unknown"
+}
+
+// TxOptions 事务选项
+type TxOptions struct {
+	ReadOnly  bool           // 只读事务，写操作返回ErrTxReadOnly
+	Isolation IsolationLevel // 要求的最低隔离级别，驱动可以提供更强的隔离，无法满足时BeginTx返回ErrUnsupported
+}
+
+// TxBeginner 原生支持事务选项的驱动实现的接口
+type TxBeginner interface {
+	// BeginTxOptions 按选项开启事务，无法满足隔离级别时返回ErrUnsupported
+	BeginTxOptions(ctx context.Context, opts TxOptions) (Tx, error)
+}
+
+// BeginTx 按选项开启事务
+// 驱动实现了TxBeginner时使用驱动的原生实现；否则只支持IsolationDefault，
+// 开启普通事务（c实现了CacheCtx时使用BeginTxContext），只读事务由ReadOnlyTx包装
+// 参数：
+//
+//	ctx - 上下文
+//	c - 缓存实例
+//	opts - 事务选项
+//
+// 返回值：
+//
+//	Tx - 事务，使用完毕后必须调用Commit或Rollback
+//	error - 上下文已取消、无法满足隔离级别时返回ErrUnsupported或开启事务失败
+func BeginTx(ctx context.Context, c Cache, opts TxOptions) (Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if b, ok := c.(TxBeginner); ok {
+		return b.BeginTxOptions(ctx, opts)
+	}
+	if opts.Isolation != IsolationDefault {
+		return nil, ErrUnsupported
+	}
+
+	var tx Tx
+	var err error
+	if cc, ok := c.(CacheCtx); ok {
+		tx, err = cc.BeginTxContext(ctx)
+	} else {
+		tx, err = c.BeginTx()
+	}
+	if err != nil || !opts.ReadOnly {
+		return tx, err
+	}
+	return ReadOnlyTx(tx), nil
+}
+
+// ReadOnlyTx 包装只读事务：写操作返回ErrTxReadOnly且不会传递给tx，Commit回滚tx
+// 参数：
+//
+//	tx - 被包装的事务，驱动开启的只读事务或普通事务
+//
+// 返回值：
+//
+//	Tx - 只读事务
+func ReadOnlyTx(tx Tx) Tx {
+	return readOnlyTx{tx: tx}
+}
+
+type readOnlyTx struct {
+	tx Tx
+}
+
+func (tx readOnlyTx) Get(key string) (string, error) {
+	return tx.tx.Get(key)
+}
+
+func (tx readOnlyTx) Exists(key string) (bool, error) {
+	return tx.tx.Exists(key)
+}
+
+func (tx readOnlyTx) Set(key string, value string, ttl time.Duration) error {
+	return ErrTxReadOnly
+}
+
+func (tx readOnlyTx) Delete(key string) error {
+	return ErrTxReadOnly
+}
+
+func (tx readOnlyTx) Expire(key string, ttl time.Duration) error {
+	return ErrTxReadOnly
+}
+
+func (tx readOnlyTx) HSet(key, field, value string, ttl time.Duration) error {
+	return ErrTxReadOnly
+}
+
+func (tx readOnlyTx) HDel(key, field string) error {
+	return ErrTxReadOnly
+}
+
+// Commit 只读事务没有需要提交的写入，回滚并释放资源
+func (tx readOnlyTx) Commit() error {
+	return tx.tx.Rollback()
+}
+
+func (tx readOnlyTx) Rollback() error {
+	return tx.tx.Rollback()
+}
@@ -0,0 +1,80 @@
+// interface包：监视key的乐观事务
+// 为没有原生乐观事务的驱动提供BeginTxWatch的冲突检测：
+// 开启事务时记录被监视的key的值，提交前重新读取并比较，任意一个key的值或存在性变化时放弃提交。
+// 比较的是值而不是版本，key被修改后又改回原值的情况无法检测
+//
+// 作者: gophertool
+package _interface
+
+import "errors"
+
+// WatchSnapshot 被监视的key在事务开始时的值
+type WatchSnapshot map[string]watchedValue
+
+type watchedValue struct {
+	value  string
+	exists bool
+}
+
+// NewWatchSnapshot 读取被监视的key的当前值
+// 参数：
+//
+//	get - 读取key的函数，key不存在时返回ErrKeyNotFound
+//	keys - 被监视的key
+//
+// 返回值：
+//
+//	WatchSnapshot - 快照
+//	error - 读取错误
+func NewWatchSnapshot(get func(key string) (string, error), keys ...string) (WatchSnapshot, error) {
+	w := make(WatchSnapshot, len(keys))
+	for _, key := range keys {
+		v, err := readWatched(get, key)
+		if err != nil {
+			return nil, err
+		}
+		w[key] = v
+	}
+	return w, nil
+}
+
+// Keys 返回被监视的key
+func (w WatchSnapshot) Keys() []string {
+	keys := make([]string, 0, len(w))
+	for key := range w {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Check 重新读取被监视的key并与快照比较
+// 参数：
+//
+//	get - 读取key的函数，调用方需保证检查和提交之间key不会被修改
+//
+// 返回值：
+//
+//	error - 任意一个key发生变化时返回ErrTxConflict，读取失败时返回读取错误
+func (w WatchSnapshot) Check(get func(key string) (string, error)) error {
+	for key, old := range w {
+		v, err := readWatched(get, key)
+		if err != nil {
+			return err
+		}
+		if v != old {
+			return ErrTxConflict
+		}
+	}
+	return nil
+}
+
+func readWatched(get func(key string) (string, error), key string) (watchedValue, error) {
+	value, err := get(key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return watchedValue{}, nil
+	}
+	if err != nil {
+		return watchedValue{}, err
+	}
+	return watchedValue{value: value, exists: true}, nil
+}
@@ -0,0 +1,107 @@
+// cache包：读穿透加载
+// 缓存未命中时调用加载函数获取值并回写缓存，避免调用方各自实现"查缓存-查数据源-回写"的流程
+//
+// 主要特性：
+// - 同一个key的并发加载只执行一次，其他调用共享结果，消除缓存失效瞬间的惊群
+// - 加载函数返回ErrKeyNotFound时可以缓存"不存在"的结果，期间不再访问数据源
+// - 回写缓存失败不影响返回加载到的值
+//
+// 作者: gophertool
+package cache
+
+import (
+	"errors"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// LoaderOptions 读穿透加载的选项
+type LoaderOptions struct {
+	NegativeTTL time.Duration // "不存在"结果的缓存时间，0表示不缓存
+}
+
+// Loader 读穿透加载器，同一个Loader上的并发加载会被合并
+type Loader struct {
+	c           _interface.Cache
+	negativeTTL time.Duration
+	group       _interface.Group
+}
+
+// NewLoader 创建读穿透加载器
+// 参数：
+//
+//	c - 缓存实例
+//	opts - 加载选项
+//
+// 返回值：
+//
+//	*Loader - 加载器实例
+func NewLoader(c _interface.Cache, opts LoaderOptions) *Loader {
+	return &Loader{c: c, negativeTTL: opts.NegativeTTL}
+}
+
+// GetOrLoad 获取key的值，缓存未命中时调用load加载并以ttl回写缓存
+// 参数：
+//
+//	key - 键名
+//	ttl - 回写缓存的过期时间
+//	load - 加载函数，数据源中不存在时应返回ErrKeyNotFound
+//
+// 返回值：
+//
+//	string - 缓存中的值或加载到的值
+//	error - 读取或加载错误，不存在时返回ErrKeyNotFound
+func (l *Loader) GetOrLoad(key string, ttl time.Duration, load func() (string, error)) (string, error) {
+	v, err := l.Load(key,
+		func() (any, error) { return l.c.Get(key) },
+		func() (any, error) { return load() },
+		func(value any) error { return l.c.Set(key, value.(string), ttl) })
+	value, _ := v.(string)
+	return value, err
+}
+
+// Load 读穿透加载的通用流程，值的读取、加载和回写由调用方提供，供typedcache等包装类型复用
+// 参数：
+//
+//	key - 键名，用于合并并发加载和记录"不存在"的结果
+//	get - 读取缓存，未命中时返回ErrKeyNotFound
+//	load - 加载函数，数据源中不存在时应返回ErrKeyNotFound
+//	store - 回写缓存，失败不影响返回加载到的值
+//
+// 返回值：
+//
+//	any - get或load返回的值
+//	error - 读取或加载错误，不存在时返回ErrKeyNotFound
+func (l *Loader) Load(key string, get func() (any, error), load func() (any, error), store func(value any) error) (any, error) {
+	value, err := get()
+	if !errors.Is(err, _interface.ErrKeyNotFound) {
+		return value, err
+	}
+
+	return l.group.Do(key, func() (any, error) {
+		if l.negativeTTL > 0 {
+			if ok, _ := l.c.Exists(_interface.NegativeKey(key)); ok {
+				return nil, _interface.ErrKeyNotFound
+			}
+		}
+
+		value, err := load()
+		if errors.Is(err, _interface.ErrKeyNotFound) && l.negativeTTL > 0 {
+			_ = l.c.Set(_interface.NegativeKey(key), "", l.negativeTTL)
+		}
+		if err != nil {
+			return value, err
+		}
+		_ = store(value)
+		return value, nil
+	})
+}
+
+// Forget 删除key的缓存值和"不存在"记录，下次GetOrLoad会重新加载
+func (l *Loader) Forget(key string) error {
+	if err := l.c.Delete(key); err != nil {
+		return err
+	}
+	return l.c.Delete(_interface.NegativeKey(key))
+}
@@ -0,0 +1,773 @@
+// memcached包：基于Memcached的分布式缓存实现
+// 提供键值存储、哈希表操作和事务支持
+//
+// Memcached是一个简单高效的分布式内存缓存，只支持键值存储
+// 本包实现了Cache接口，方便已经部署Memcached的团队使用统一的缓存操作API
+//
+// 主要特性：
+// - 高性能的内存存储
+// - 支持TTL过期
+// - 哈希表操作（整个哈希表以JSON编码保存在一个key中，通过CAS保证并发更新安全）
+// - 集合操作（以成员为字段、值为空的哈希表保存）
+// - 事务支持（操作缓存在内存中，提交时依次执行，不保证原子性）
+//
+// 限制：
+// - 不支持队列操作，Push/Pop/LPush/RPush/RPushCapped/LPop/RPop/PopAll/Len/LRange/LIndex/LRem/LTrim/PopAck/Ack返回ErrUnsupported
+// - 未设置TTL的HSet/HDel会清除哈希表原有的过期时间
+// - 不支持查询剩余过期时间和遍历key，TTL、Keys和DeleteByPrefix返回ErrUnsupported
+// - 不支持发布订阅，Publish返回ErrUnsupported，Subscribe返回已关闭的消息通道
+//
+// 作者: gophertool
+package memcached
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// 包初始化时注册Memcached驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverMemcached, NewMemcachedClient)
+}
+
+// maxRelativeExpiration Memcached相对过期时间的上限（30天），超过后会被当作Unix时间戳
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// maxCASRetries CAS更新冲突时的最大重试次数
+const maxCASRetries = 16
+
+// MemcachedDb Memcached缓存实现结构体
+type MemcachedDb struct {
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db    *memcache.Client // Memcached客户端实例
+	codec _interface.Codec // SetObject/GetObject的编码方式
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*MemcachedDb)(nil)
+
+// Close 关闭客户端连接
+func (m *MemcachedDb) Close() {
+	_ = m.db.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (m *MemcachedDb) Get(key string) (string, error) {
+	item, err := m.db.Get(key)
+	if err != nil {
+		return "", convertError(err)
+	}
+	return string(item.Value), nil
+}
+
+func (m *MemcachedDb) Set(key string, value string, ttl time.Duration) error {
+	return m.db.Set(&memcache.Item{Key: key, Value: []byte(value), Expiration: expiration(ttl)})
+}
+
+func (m *MemcachedDb) Delete(key string) error {
+	err := m.db.Delete(key)
+	// 与其他驱动保持一致：删除不存在的key不返回错误
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return nil
+	}
+	return err
+}
+
+func (m *MemcachedDb) Exists(key string) (bool, error) {
+	_, err := m.db.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (m *MemcachedDb) Expire(key string, ttl time.Duration) error {
+	return convertError(m.db.Touch(key, expiration(ttl)))
+}
+
+// TTL Memcached不提供查询过期时间的命令，返回ErrUnsupported
+func (m *MemcachedDb) TTL(key string) (time.Duration, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+// SetNX key不存在时设置key-value并设置过期时间，基于Memcached的add命令
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (m *MemcachedDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	err := m.db.Add(&memcache.Item{Key: key, Value: []byte(value), Expiration: expiration(ttl)})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 基于Memcached的gets/cas命令，读取之后key被其他客户端修改时返回false
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (m *MemcachedDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	item, err := m.db.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil || string(item.Value) != oldValue {
+		return false, err
+	}
+	item.Value = []byte(newValue)
+	item.Expiration = expiration(ttl)
+	err = m.db.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) || errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetSet 设置新值并返回旧值，通过CAS保证读取和写入之间没有其他写入
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (m *MemcachedDb) GetSet(key string, value string) (string, error) {
+	for i := 0; i < maxCASRetries; i++ {
+		item, err := m.db.Get(key)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			err = m.db.Add(&memcache.Item{Key: key, Value: []byte(value)})
+			if errors.Is(err, memcache.ErrNotStored) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			return "", _interface.ErrKeyNotFound
+		}
+		if err != nil {
+			return "", err
+		}
+
+		old := string(item.Value)
+		item.Value = []byte(value)
+		item.Expiration = 0
+		err = m.db.CompareAndSwap(item)
+		if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) || errors.Is(err, memcache.ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return old, nil
+	}
+	return "", memcache.ErrCASConflict
+}
+
+// GetDel 获取并删除指定key
+// 并发调用时只有删除成功的一方返回值，其他调用返回ErrKeyNotFound
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (m *MemcachedDb) GetDel(key string) (string, error) {
+	item, err := m.db.Get(key)
+	if err != nil {
+		return "", convertError(err)
+	}
+	if err := m.db.Delete(key); err != nil {
+		return "", convertError(err)
+	}
+	return string(item.Value), nil
+}
+
+// Append 使用Memcached的append命令在服务端追加value，key不存在时使用add命令创建不过期的key，
+// 已有的过期时间保持不变
+// append命令不返回追加后的值，长度通过之后的一次读取获得，可能包含其他客户端并发追加的内容
+// 参数：
+//
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (m *MemcachedDb) Append(key string, value string) (int64, error) {
+	for i := 0; i < maxCASRetries; i++ {
+		err := m.db.Append(&memcache.Item{Key: key, Value: []byte(value)})
+		if errors.Is(err, memcache.ErrNotStored) {
+			// key不存在，与并发的创建竞争，失败时重新追加
+			err = m.db.Add(&memcache.Item{Key: key, Value: []byte(value)})
+			if errors.Is(err, memcache.ErrNotStored) {
+				continue
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+		return m.StrLen(key)
+	}
+	return 0, memcache.ErrCASConflict
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在时返回0
+func (m *MemcachedDb) StrLen(key string) (int64, error) {
+	item, err := m.db.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(item.Value)), nil
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (m *MemcachedDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := m.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (m *MemcachedDb) GetObject(key string, v any) error {
+	raw, err := m.Get(key)
+	if err != nil {
+		return err
+	}
+	return m.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys Memcached不支持遍历key，返回ErrUnsupported
+func (m *MemcachedDb) Keys(pattern string, fn func(key string) bool) error {
+	return _interface.ErrUnsupported
+}
+
+// Iterate Memcached不支持遍历key，返回ErrUnsupported
+func (m *MemcachedDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	return _interface.ErrUnsupported
+}
+
+// DeleteByPrefix Memcached不支持遍历key，返回ErrUnsupported
+func (m *MemcachedDb) DeleteByPrefix(prefix string) error {
+	return _interface.ErrUnsupported
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，哈希表或字段不存在时返回ErrKeyNotFound
+func (m *MemcachedDb) HGet(key, field string) (string, error) {
+	hash, _, err := m.getHash(key)
+	if err != nil {
+		return "", err
+	}
+	val, ok := hash[field]
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// HSet 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 整个哈希表的过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemcachedDb) HSet(key, field, value string, ttl time.Duration) error {
+	return m.updateHash(key, ttl, func(hash map[string]string) {
+		hash[field] = value
+	})
+}
+
+// HDel 删除哈希表中的field，字段全部删除后哈希表本身也会被删除
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemcachedDb) HDel(key, field string) error {
+	return m.updateHash(key, 0, func(hash map[string]string) {
+		delete(hash, field)
+	})
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射，哈希表不存在时返回空映射
+//	error - 操作错误
+func (m *MemcachedDb) HGetAll(key string) (map[string]string, error) {
+	hash, _, err := m.getHash(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return make(map[string]string), nil
+	}
+	return hash, err
+}
+
+// HLen 获取哈希表中field的数量，哈希表不存在时返回0
+func (m *MemcachedDb) HLen(key string) (int64, error) {
+	hash, err := m.HGetAll(key)
+	return int64(len(hash)), err
+}
+
+// HExists 判断哈希表中是否存在field
+func (m *MemcachedDb) HExists(key, field string) (bool, error) {
+	hash, err := m.HGetAll(key)
+	if err != nil {
+		return false, err
+	}
+	_, ok := hash[field]
+	return ok, nil
+}
+
+// HKeys 获取哈希表中所有的field，字段顺序不固定，哈希表不存在时返回空切片
+func (m *MemcachedDb) HKeys(key string) ([]string, error) {
+	hash, err := m.HGetAll(key)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemcachedDb) SAdd(key, member string) error {
+	return m.updateHash(key, 0, func(hash map[string]string) {
+		hash[member] = ""
+	})
+}
+
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemcachedDb) SRem(key, member string) error {
+	return m.updateHash(key, 0, func(hash map[string]string) {
+		delete(hash, member)
+	})
+}
+
+// SMembers 获取集合的所有成员，成员顺序不固定
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (m *MemcachedDb) SMembers(key string) ([]string, error) {
+	hash, err := m.HGetAll(key)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(hash))
+	for member := range hash {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (m *MemcachedDb) SIsMember(key, member string) (bool, error) {
+	hash, err := m.HGetAll(key)
+	if err != nil {
+		return false, err
+	}
+	_, ok := hash[member]
+	return ok, nil
+}
+
+// getHash 读取并解码哈希表，同时返回原始条目用于CAS更新
+func (m *MemcachedDb) getHash(key string) (map[string]string, *memcache.Item, error) {
+	item, err := m.db.Get(key)
+	if err != nil {
+		return nil, nil, convertError(err)
+	}
+	hash := make(map[string]string)
+	if err := json.Unmarshal(item.Value, &hash); err != nil {
+		return nil, nil, err
+	}
+	return hash, item, nil
+}
+
+// updateHash 以CAS方式读取-修改-写回哈希表，并发冲突时重试
+func (m *MemcachedDb) updateHash(key string, ttl time.Duration, update func(hash map[string]string)) error {
+	for i := 0; i < maxCASRetries; i++ {
+		hash, item, err := m.getHash(key)
+		if errors.Is(err, _interface.ErrKeyNotFound) {
+			hash = make(map[string]string)
+		} else if err != nil {
+			return err
+		}
+
+		update(hash)
+
+		// 哈希表为空时删除整个key
+		if len(hash) == 0 {
+			if item == nil {
+				return nil
+			}
+			return m.Delete(key)
+		}
+
+		data, err := json.Marshal(hash)
+		if err != nil {
+			return err
+		}
+
+		if item == nil {
+			// 哈希表不存在，使用Add避免覆盖并发创建的哈希表
+			err = m.db.Add(&memcache.Item{Key: key, Value: data, Expiration: expiration(ttl)})
+		} else {
+			item.Value = data
+			item.Expiration = expiration(ttl)
+			err = m.db.CompareAndSwap(item)
+		}
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, memcache.ErrNotStored), errors.Is(err, memcache.ErrCASConflict), errors.Is(err, memcache.ErrCacheMiss):
+			continue
+		default:
+			return err
+		}
+	}
+	return memcache.ErrCASConflict
+}
+
+// Memcached不支持列表结构，队列操作统一返回ErrUnsupported
+
+func (m *MemcachedDb) Push(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LPush(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) RPush(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) RPushCapped(key string, value string, maxLen int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) Pop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LPop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) RPop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) PopAll(key string) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) Len(key string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LRange(key string, start, stop int64) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LIndex(key string, index int64) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LRem(key string, count int64, value string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) LTrim(key string, start, stop int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return "", "", _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) Ack(key, receipt string) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) PushDelayed(key, value string, delay time.Duration) error {
+	return _interface.ErrUnsupported
+}
+
+// memcachedTx Memcached事务实现
+// Memcached没有事务，操作先缓存在内存中，Commit时按顺序执行
+type memcachedTx struct {
+	db    *MemcachedDb
+	ops   []func() error
+	watch _interface.WatchSnapshot // BeginTxWatch监视的key，为nil表示不监视
+}
+
+// Get 事务内的写入在提交时才执行，读取的是已提交的值
+func (tx *memcachedTx) Get(key string) (string, error) {
+	return tx.db.Get(key)
+}
+
+func (tx *memcachedTx) Exists(key string) (bool, error) {
+	return tx.db.Exists(key)
+}
+
+func (tx *memcachedTx) Set(key string, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.Set(key, value, ttl)
+	})
+	return nil
+}
+
+func (tx *memcachedTx) Delete(key string) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.Delete(key)
+	})
+	return nil
+}
+
+// Expire 在提交时执行，key 不存在时不做任何操作
+func (tx *memcachedTx) Expire(key string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		if err := tx.db.Expire(key, ttl); !errors.Is(err, _interface.ErrKeyNotFound) {
+			return err
+		}
+		return nil
+	})
+	return nil
+}
+
+func (tx *memcachedTx) HSet(key, field, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.HSet(key, field, value, ttl)
+	})
+	return nil
+}
+
+func (tx *memcachedTx) HDel(key, field string) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.HDel(key, field)
+	})
+	return nil
+}
+
+// Commit 按顺序执行所有操作，监视的key被修改时返回ErrTxConflict
+func (tx *memcachedTx) Commit() error {
+	ops := tx.ops
+	tx.ops = nil
+	if err := tx.watch.Check(tx.db.Get); err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if err := op(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *memcachedTx) Rollback() error {
+	tx.ops = nil
+	return nil
+}
+
+// Memcached没有发布订阅机制，Publish返回ErrUnsupported，Subscribe返回已关闭的消息通道
+
+func (m *MemcachedDb) Publish(channel string, payload string) error {
+	return _interface.ErrUnsupported
+}
+
+func (m *MemcachedDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	ch := make(chan _interface.Message)
+	close(ch)
+	return ch, func() {}
+}
+
+// SubscribeExpired Memcached不提供过期事件，返回已关闭的通道
+func (m *MemcachedDb) SubscribeExpired() (<-chan string, func()) {
+	return _interface.ClosedExpiry(), func() {}
+}
+
+func (m *MemcachedDb) BeginTx() (_interface.Tx, error) {
+	return &memcachedTx{db: m}, nil
+}
+
+// BeginTxWatch 开启监视keys的事务，提交前比较keys的值，与开启时不同则返回ErrTxConflict
+// Memcached无法原子地比较多个key，比较之后、写入之前的修改检测不到
+func (m *MemcachedDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	watch, err := _interface.NewWatchSnapshot(m.Get, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &memcachedTx{db: m, watch: watch}, nil
+}
+
+// expiration 将TTL转换为Memcached的过期时间
+// 不足1秒向上取整为1秒，超过30天时转换为Unix时间戳
+func expiration(ttl time.Duration) int32 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > maxRelativeExpiration {
+		return int32(time.Now().Add(ttl).Unix())
+	}
+	return int32(math.Ceil(ttl.Seconds()))
+}
+
+// convertError 将Memcached特定错误转换为接口标准错误
+func convertError(err error) error {
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return _interface.ErrKeyNotFound
+	}
+	return err
+}
+
+// Stats Memcached客户端不支持stats命令
+// 返回值：
+//
+//	Stats - 所有数值字段为StatsUnknown的统计信息
+//	error - 总是ErrUnsupported
+func (m *MemcachedDb) Stats() (_interface.Stats, error) {
+	return _interface.NewStats(config.CacheDriverMemcached), _interface.ErrUnsupported
+}
+
+// Backup Memcached不支持遍历键
+// 返回值：
+//
+//	error - 总是ErrUnsupported
+func (m *MemcachedDb) Backup(w io.Writer) error {
+	return _interface.ErrUnsupported
+}
+
+// Restore Memcached没有可恢复的备份
+// 返回值：
+//
+//	error - 总是ErrUnsupported
+func (m *MemcachedDb) Restore(r io.Reader) error {
+	return _interface.ErrUnsupported
+}
+
+func NewMemcachedClient(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	addr := config.Host + ":" + config.Port
+	client := memcache.New(addr)
+	client.MaxIdleConns = 100
+	if err := client.Ping(); err != nil {
+		return nil, err
+	}
+	m := &MemcachedDb{db: client, codec: codec}
+	m.CtxAdapter = _interface.NewCtxAdapter(m)
+	return m, nil
+}
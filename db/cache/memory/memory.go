@@ -0,0 +1,1413 @@
+// memory包：纯内存缓存实现，支持LRU/LFU/随机淘汰
+// 提供键值存储、哈希表操作、队列操作和事务支持
+//
+// 数据只保存在进程内存中，不写文件，也不依赖外部服务
+// 本包实现了Cache接口，适合测试和小型进程使用
+//
+// 主要特性：
+// - 无文件、无外部依赖
+// - 支持TTL过期（访问时惰性删除）
+// - 按条目数（MaxEntries）和字节数（MaxBytes）限制容量，超出时按EvictionPolicy淘汰（默认LRU），淘汰数量计入Stats
+// - 队列操作（FIFO/LIFO）
+// - 哈希表操作
+// - 集合操作
+// - 事务支持（提交时在锁内一次性执行）
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+// - 键过期通知（订阅期间后台定期清理过期条目）
+// - 线程安全
+//
+// 键值、哈希表、队列和集合各自使用独立的命名空间，同名的key互不影响；
+// 淘汰以一个键值、一个哈希表、一个队列或一个集合为单位
+//
+// 作者: gophertool
+package memory
+
+import (
+	"container/list"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// 包初始化时注册内存驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverMemory, NewMemoryStore)
+}
+
+// 条目类型，同时作为内部键的前缀区分命名空间
+const (
+	kindString = 's'
+	kindHash   = 'h'
+	kindList   = 'l'
+	kindSet    = 'S'
+)
+
+// entry 缓存条目
+type entry struct {
+	id        string              // 内部键：类型前缀 + key
+	value     string              // 键值数据
+	hash      map[string]string   // 哈希表数据
+	list      []string            // 队列数据
+	set       map[string]struct{} // 集合数据
+	expiresAt time.Time           // 过期时间，零值表示不过期
+	size      int64               // 占用的字节数
+	hits      uint64              // 访问次数，LFU淘汰使用
+}
+
+// expired 判断条目是否已过期
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryDb 内存缓存实现结构体
+type MemoryDb struct {
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	mu         sync.Mutex
+	items      map[string]*list.Element  // 内部键到LRU链表节点的映射
+	lru        *list.List                // LRU链表，最近使用的在前
+	bytes      int64                     // 当前占用的字节数
+	maxEntries int                       // 最大条目数，0表示不限制
+	maxBytes   int64                     // 最大字节数，0表示不限制
+	policy     string                    // 超出容量限制时的淘汰策略
+	evictions  int64                     // 因超出容量限制而淘汰的条目数
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*MemoryDb)(nil)
+
+// Close 清空所有数据
+func (m *MemoryDb) Close() {
+	// 先停止过期扫描，扫描过程中需要获取m.mu
+	m.expiry.Close()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = make(map[string]*list.Element)
+	m.lru.Init()
+	m.bytes = 0
+	m.broker.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (m *MemoryDb) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.get(key)
+}
+
+func (m *MemoryDb) Set(key string, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, value, ttl)
+	m.evict()
+	return nil
+}
+
+func (m *MemoryDb) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(kindString, key)
+	return nil
+}
+
+func (m *MemoryDb) Exists(key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lookup(kindString, key) != nil, nil
+}
+
+// Expire 设置key的过期时间
+// 参数：
+//
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (m *MemoryDb) Expire(key string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindString, key)
+	if e == nil {
+		return _interface.ErrKeyNotFound
+	}
+	e.expiresAt = expiresAt(ttl)
+	return nil
+}
+
+// TTL 获取key的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在或已过期时返回TTLNotFound
+//	error - 操作错误
+func (m *MemoryDb) TTL(key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindString, key)
+	if e == nil {
+		return _interface.TTLNotFound, nil
+	}
+	if e.expiresAt.IsZero() {
+		return _interface.TTLNoExpiry, nil
+	}
+	if ttl := time.Until(e.expiresAt); ttl > 0 {
+		return ttl, nil
+	}
+	return _interface.TTLNotFound, nil
+}
+
+// SetNX key不存在或已过期时设置key-value并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (m *MemoryDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lookup(kindString, key) != nil {
+		return false, nil
+	}
+	m.set(key, value, ttl)
+	m.evict()
+	return true, nil
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (m *MemoryDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindString, key)
+	if e == nil || e.value != oldValue {
+		return false, nil
+	}
+	m.set(key, newValue, ttl)
+	m.evict()
+	return true, nil
+}
+
+// GetSet 设置新值并返回旧值，新值不过期
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (m *MemoryDb) GetSet(key string, value string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var old string
+	e := m.lookup(kindString, key)
+	if e != nil {
+		old = e.value
+	}
+	m.set(key, value, 0)
+	m.evict()
+	if e == nil {
+		return "", _interface.ErrKeyNotFound
+	}
+	return old, nil
+}
+
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (m *MemoryDb) GetDel(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindString, key)
+	if e == nil {
+		return "", _interface.ErrKeyNotFound
+	}
+	m.remove(kindString, key)
+	return e.value, nil
+}
+
+// Append 将value追加到key的值末尾，key不存在时创建不过期的key，已有的过期时间保持不变
+// 参数：
+//
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (m *MemoryDb) Append(key string, value string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindString, key)
+	if e == nil {
+		e = m.add(kindString, key)
+	}
+	m.resize(e, int64(len(value)))
+	e.value += value
+	n := int64(len(e.value))
+	m.evict()
+	return n, nil
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在或已过期时返回0
+func (m *MemoryDb) StrLen(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.lookup(kindString, key); e != nil {
+		return int64(len(e.value)), nil
+	}
+	return 0, nil
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (m *MemoryDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := m.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return m.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (m *MemoryDb) GetObject(key string, v any) error {
+	raw, err := m.Get(key)
+	if err != nil {
+		return err
+	}
+	return m.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys 遍历匹配pattern的key，通配符语法与Redis一致
+// 只遍历键值，不包括哈希表、队列和集合；先在锁内收集匹配的key再逐个调用fn，fn中可以读写缓存
+// 参数：
+//
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) Keys(pattern string, fn func(key string) bool) error {
+	m.mu.Lock()
+	now := time.Now()
+	var keys []string
+	for id, el := range m.items {
+		e := el.Value.(*entry)
+		if id[0] != kindString || e.expired(now) {
+			continue
+		}
+		if key := id[1:]; _interface.MatchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, key := range keys {
+		if !fn(key) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Iterate 遍历键名以prefix开头的键值
+// 只遍历键值，不包括哈希表、队列和集合；值本身已在内存中，在锁内收集的是对值的引用而不是副本，
+// 收集完成后逐个调用fn，fn中可以读写缓存
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	type pair struct{ key, value string }
+
+	m.mu.Lock()
+	now := time.Now()
+	var pairs []pair
+	for id, el := range m.items {
+		e := el.Value.(*entry)
+		if id[0] != kindString || e.expired(now) {
+			continue
+		}
+		if key := id[1:]; strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, pair{key, e.value})
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range pairs {
+		if !fn(p.key, p.value) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// DeleteByPrefix 删除所有以prefix开头的key，包括键值、哈希表、队列和集合
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) DeleteByPrefix(prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, el := range m.items {
+		if strings.HasPrefix(id[1:], prefix) {
+			m.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或哈希表已过期时返回ErrKeyNotFound
+func (m *MemoryDb) HGet(key, field string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindHash, key)
+	if e == nil {
+		return "", _interface.ErrKeyNotFound
+	}
+	val, ok := e.hash[field]
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// HSet 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 整个哈希表的过期时间，0表示保持原有的过期时间
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) HSet(key, field, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hset(key, field, value, ttl)
+	m.evict()
+	return nil
+}
+
+// HDel 删除哈希表中的field，字段全部删除后哈希表本身也会被删除
+func (m *MemoryDb) HDel(key, field string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hdel(key, field)
+	return nil
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射（副本）
+//	error - 操作错误
+func (m *MemoryDb) HGetAll(key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]string)
+	if e := m.lookup(kindHash, key); e != nil {
+		for field, value := range e.hash {
+			result[field] = value
+		}
+	}
+	return result, nil
+}
+
+// HLen 获取哈希表中field的数量
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	int64 - 字段数量，哈希表不存在或已过期时返回0
+//	error - 操作错误
+func (m *MemoryDb) HLen(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.lookup(kindHash, key); e != nil {
+		return int64(len(e.hash)), nil
+	}
+	return 0, nil
+}
+
+// HExists 判断哈希表中是否存在field
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	bool - 字段是否存在
+//	error - 操作错误
+func (m *MemoryDb) HExists(key, field string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.lookup(kindHash, key); e != nil {
+		_, ok := e.hash[field]
+		return ok, nil
+	}
+	return false, nil
+}
+
+// HKeys 获取哈希表中所有的field，字段顺序不固定
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (m *MemoryDb) HKeys(key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fields := []string{}
+	if e := m.lookup(kindHash, key); e != nil {
+		for field := range e.hash {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) SAdd(key, member string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindSet, key)
+	if e == nil {
+		e = m.add(kindSet, key)
+		e.set = make(map[string]struct{})
+	}
+	if _, ok := e.set[member]; !ok {
+		e.set[member] = struct{}{}
+		m.resize(e, int64(len(member)))
+	}
+	m.evict()
+	return nil
+}
+
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) SRem(key, member string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindSet, key)
+	if e == nil {
+		return nil
+	}
+	if _, ok := e.set[member]; ok {
+		delete(e.set, member)
+		m.resize(e, -int64(len(member)))
+	}
+	if len(e.set) == 0 {
+		m.remove(kindSet, key)
+	}
+	return nil
+}
+
+// SMembers 获取集合的所有成员，成员顺序不固定
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (m *MemoryDb) SMembers(key string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	members := []string{}
+	if e := m.lookup(kindSet, key); e != nil {
+		for member := range e.set {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (m *MemoryDb) SIsMember(key, member string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindSet, key)
+	if e == nil {
+		return false, nil
+	}
+	_, ok := e.set[member]
+	return ok, nil
+}
+
+// Push 添加元素到列表尾部
+func (m *MemoryDb) Push(key string, value string) error {
+	return m.RPush(key, value)
+}
+
+// LPush 将元素插入到列表头部
+func (m *MemoryDb) LPush(key string, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.listEntry(key)
+	e.list = append([]string{value}, e.list...)
+	m.resize(e, int64(len(value)))
+	m.evict()
+	return nil
+}
+
+// RPush 将元素插入到列表尾部
+func (m *MemoryDb) RPush(key string, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.listEntry(key)
+	e.list = append(e.list, value)
+	m.resize(e, int64(len(value)))
+	m.evict()
+	return nil
+}
+
+// RPushCapped 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) RPushCapped(key string, value string, maxLen int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.listEntry(key)
+	e.list = append(e.list, value)
+	m.resize(e, int64(len(value)))
+	if n := int64(len(e.list)); maxLen > 0 && n > maxLen {
+		m.setList(e, key, append([]string{}, e.list[n-maxLen:]...))
+	}
+	m.evict()
+	return nil
+}
+
+// Pop 移除并返回列表第一个元素
+func (m *MemoryDb) Pop(key string) (string, error) {
+	return m.LPop(key)
+}
+
+// LPop 弹出列表头部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (m *MemoryDb) LPop(key string) (string, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return "", err
+	}
+	return m.pop(key, true)
+}
+
+// RPop 弹出列表尾部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (m *MemoryDb) RPop(key string) (string, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return "", err
+	}
+	return m.pop(key, false)
+}
+
+// PopAll 取出并清空整个列表
+func (m *MemoryDb) PopAll(key string) ([]string, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil {
+		return []string{}, nil
+	}
+	result := e.list
+	m.remove(kindList, key)
+	return result, nil
+}
+
+// Len 获取列表长度
+func (m *MemoryDb) Len(key string) (int64, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil {
+		return 0, nil
+	}
+	return int64(len(e.list)), nil
+}
+
+// LRange 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素（副本），列表不存在时返回空切片
+//	error - 操作错误
+func (m *MemoryDb) LRange(key string, start, stop int64) ([]string, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil {
+		return []string{}, nil
+	}
+	lo, hi := _interface.ListRange(start, stop, int64(len(e.list)))
+	return append([]string{}, e.list[lo:hi]...), nil
+}
+
+// LIndex 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (m *MemoryDb) LIndex(key string, index int64) (string, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.lookup(kindList, key); e != nil {
+		if i, ok := _interface.ListIndex(index, int64(len(e.list))); ok {
+			return e.list[i], nil
+		}
+	}
+	return "", _interface.ErrKeyNotFound
+}
+
+// LRem 删除列表中等于value的元素
+// 参数：
+//
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (m *MemoryDb) LRem(key string, count int64, value string) (int64, error) {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil {
+		return 0, nil
+	}
+	kept, removed := _interface.ListRem(e.list, count, value)
+	m.setList(e, key, kept)
+	return removed, nil
+}
+
+// LTrim 只保留列表中下标在[start, stop]范围内的元素
+func (m *MemoryDb) LTrim(key string, start, stop int64) error {
+	if err := m.delayQueue.Promote(m, key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil {
+		return nil
+	}
+	lo, hi := _interface.ListRange(start, stop, int64(len(e.list)))
+	m.setList(e, key, append([]string{}, e.list[lo:hi]...))
+	return nil
+}
+
+// PopAck 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，与其他数据一样可能被淘汰
+// 参数：
+//
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (m *MemoryDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return m.ackQueue.PopAck(m, key, visibility)
+}
+
+// Ack 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (m *MemoryDb) Ack(key, receipt string) error {
+	return m.ackQueue.Ack(m, key, receipt)
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，与其他数据一样可能被淘汰；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) PushDelayed(key, value string, delay time.Duration) error {
+	return m.delayQueue.PushDelayed(m, key, value, delay)
+}
+
+// memoryTx 内存缓存事务实现
+// 操作先缓存起来，Commit时在锁内一次性执行，其他协程看不到中间状态
+type memoryTx struct {
+	db    *MemoryDb
+	ops   []func()
+	watch _interface.WatchSnapshot // BeginTxWatch监视的key，为nil表示不监视
+}
+
+// Get 事务内的写入在提交时才执行，读取的是已提交的值
+func (tx *memoryTx) Get(key string) (string, error) {
+	return tx.db.Get(key)
+}
+
+func (tx *memoryTx) Exists(key string) (bool, error) {
+	return tx.db.Exists(key)
+}
+
+func (tx *memoryTx) Set(key string, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() {
+		tx.db.set(key, value, ttl)
+	})
+	return nil
+}
+
+func (tx *memoryTx) Delete(key string) error {
+	tx.ops = append(tx.ops, func() {
+		tx.db.remove(kindString, key)
+	})
+	return nil
+}
+
+func (tx *memoryTx) Expire(key string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() {
+		if e := tx.db.lookup(kindString, key); e != nil {
+			e.expiresAt = expiresAt(ttl)
+		}
+	})
+	return nil
+}
+
+func (tx *memoryTx) HSet(key, field, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() {
+		tx.db.hset(key, field, value, ttl)
+	})
+	return nil
+}
+
+func (tx *memoryTx) HDel(key, field string) error {
+	tx.ops = append(tx.ops, func() {
+		tx.db.hdel(key, field)
+	})
+	return nil
+}
+
+// Commit 在锁内检查监视的key并执行所有操作，监视的key被修改时返回ErrTxConflict
+func (tx *memoryTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+
+	if err := tx.watch.Check(tx.db.get); err != nil {
+		tx.ops = nil
+		return err
+	}
+	for _, op := range tx.ops {
+		op()
+	}
+	tx.ops = nil
+	tx.db.evict()
+	return nil
+}
+
+func (tx *memoryTx) Rollback() error {
+	tx.ops = nil
+	return nil
+}
+
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) Publish(channel string, payload string) error {
+	return m.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (m *MemoryDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return m.broker.Subscribe(channel)
+}
+
+// SubscribeExpired 订阅键值数据的过期事件
+// 订阅期间后台每秒清理一次过期条目，访问时惰性删除的过期键同样会通知；哈希表、队列和集合的过期不通知
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (m *MemoryDb) SubscribeExpired() (<-chan string, func()) {
+	return m.expiry.Subscribe(m.sweepExpired)
+}
+
+// sweepExpired 删除所有已过期的条目，返回其中键值数据的键名
+func (m *MemoryDb) sweepExpired(_, until time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var keys []string
+	for _, elem := range m.items {
+		e := elem.Value.(*entry)
+		if !e.expired(until) {
+			continue
+		}
+		m.removeElement(elem)
+		if e.id[0] == kindString {
+			keys = append(keys, e.id[1:])
+		}
+	}
+	return keys
+}
+
+func (m *MemoryDb) BeginTx() (_interface.Tx, error) {
+	return &memoryTx{db: m}, nil
+}
+
+// BeginTxWatch 开启监视keys的事务，提交时在锁内比较keys的值，与开启时不同则返回ErrTxConflict
+func (m *MemoryDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watch, err := _interface.NewWatchSnapshot(m.get, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryTx{db: m, watch: watch}, nil
+}
+
+// get 读取键值数据，调用方需持有锁
+func (m *MemoryDb) get(key string) (string, error) {
+	e := m.lookup(kindString, key)
+	if e == nil {
+		return "", _interface.ErrKeyNotFound
+	}
+	return e.value, nil
+}
+
+// set 写入键值数据，调用方需持有锁
+func (m *MemoryDb) set(key string, value string, ttl time.Duration) {
+	e := m.lookup(kindString, key)
+	if e == nil {
+		e = m.add(kindString, key)
+	}
+	m.resize(e, int64(len(value)-len(e.value)))
+	e.value = value
+	e.expiresAt = expiresAt(ttl)
+}
+
+// hset 写入哈希表字段，调用方需持有锁
+func (m *MemoryDb) hset(key, field, value string, ttl time.Duration) {
+	e := m.lookup(kindHash, key)
+	if e == nil {
+		e = m.add(kindHash, key)
+		e.hash = make(map[string]string)
+	}
+	if old, ok := e.hash[field]; ok {
+		m.resize(e, -int64(len(field)+len(old)))
+	}
+	e.hash[field] = value
+	m.resize(e, int64(len(field)+len(value)))
+	if ttl > 0 {
+		e.expiresAt = expiresAt(ttl)
+	}
+}
+
+// hdel 删除哈希表字段，字段全部删除后哈希表本身也会被删除，调用方需持有锁
+func (m *MemoryDb) hdel(key, field string) {
+	e := m.lookup(kindHash, key)
+	if e == nil {
+		return
+	}
+	if old, ok := e.hash[field]; ok {
+		delete(e.hash, field)
+		m.resize(e, -int64(len(field)+len(old)))
+	}
+	if len(e.hash) == 0 {
+		m.remove(kindHash, key)
+	}
+}
+
+// pop 删除并返回列表头部（head为true）或尾部的元素
+func (m *MemoryDb) pop(key string, head bool) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := m.lookup(kindList, key)
+	if e == nil || len(e.list) == 0 {
+		return "", _interface.ErrKeyNotFound
+	}
+
+	var val string
+	if head {
+		val = e.list[0]
+		e.list = e.list[1:]
+	} else {
+		val = e.list[len(e.list)-1]
+		e.list = e.list[:len(e.list)-1]
+	}
+	m.resize(e, -int64(len(val)))
+	if len(e.list) == 0 {
+		m.remove(kindList, key)
+	}
+	return val, nil
+}
+
+// setList 替换队列的所有元素并更新占用的字节数，元素为空时删除队列，调用方需持有锁
+func (m *MemoryDb) setList(e *entry, key string, items []string) {
+	var delta int64
+	for _, item := range e.list {
+		delta -= int64(len(item))
+	}
+	for _, item := range items {
+		delta += int64(len(item))
+	}
+	e.list = items
+	m.resize(e, delta)
+	if len(items) == 0 {
+		m.remove(kindList, key)
+	}
+}
+
+// listEntry 获取或创建队列条目，调用方需持有锁
+func (m *MemoryDb) listEntry(key string) *entry {
+	if e := m.lookup(kindList, key); e != nil {
+		return e
+	}
+	return m.add(kindList, key)
+}
+
+// lookup 查找条目并标记为最近使用，过期的条目会被删除，调用方需持有锁
+func (m *MemoryDb) lookup(kind byte, key string) *entry {
+	elem, ok := m.items[internalKey(kind, key)]
+	if !ok {
+		return nil
+	}
+	e := elem.Value.(*entry)
+	if e.expired(time.Now()) {
+		m.removeElement(elem)
+		if kind == kindString {
+			m.expiry.Notify(key)
+		}
+		return nil
+	}
+	e.hits++
+	m.lru.MoveToFront(elem)
+	return e
+}
+
+// add 创建新条目并放到LRU链表头部，调用方需持有锁
+func (m *MemoryDb) add(kind byte, key string) *entry {
+	id := internalKey(kind, key)
+	e := &entry{id: id}
+	m.items[id] = m.lru.PushFront(e)
+	m.resize(e, int64(len(id)))
+	return e
+}
+
+// remove 删除条目，调用方需持有锁
+func (m *MemoryDb) remove(kind byte, key string) {
+	if elem, ok := m.items[internalKey(kind, key)]; ok {
+		m.removeElement(elem)
+	}
+}
+
+func (m *MemoryDb) removeElement(elem *list.Element) {
+	e := m.lru.Remove(elem).(*entry)
+	delete(m.items, e.id)
+	m.bytes -= e.size
+}
+
+// resize 调整条目占用的字节数
+func (m *MemoryDb) resize(e *entry, delta int64) {
+	e.size += delta
+	m.bytes += delta
+}
+
+// evict 超出容量限制时按淘汰策略逐个淘汰条目，调用方需持有锁
+func (m *MemoryDb) evict() {
+	for m.lru.Len() > 0 &&
+		((m.maxEntries > 0 && m.lru.Len() > m.maxEntries) || (m.maxBytes > 0 && m.bytes > m.maxBytes)) {
+		m.removeElement(_interface.EvictionVictim(m.policy, m.lru, m.items, func(elem *list.Element) uint64 {
+			return elem.Value.(*entry).hits
+		}))
+		m.evictions++
+	}
+}
+
+func internalKey(kind byte, key string) string {
+	return string(kind) + key
+}
+
+// expiresAt 将TTL转换为过期时间，ttl不大于0时返回零值表示不过期
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// Stats 返回内存缓存的统计信息
+// 键数量包含键值、哈希表、队列和集合，内存占用为容量限制使用的估算字节数，淘汰数为创建以来的累计值
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 总是nil
+func (m *MemoryDb) Stats() (_interface.Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := _interface.NewStats(config.CacheDriverMemory)
+	stats.Keys = int64(len(m.items))
+	stats.MemoryBytes = m.bytes
+	stats.DiskBytes = 0
+	stats.Evictions = m.evictions
+	return stats, nil
+}
+
+// backupEntry 备份记录中条目的数据，键值、哈希表、队列和集合只有一项有值
+type backupEntry struct {
+	Value string            `json:"value,omitempty"`
+	Hash  map[string]string `json:"hash,omitempty"`
+	List  []string          `json:"list,omitempty"`
+	Set   []string          `json:"set,omitempty"`
+}
+
+// Backup 将所有未过期的条目写入记录流，记录的键为带类型前缀的内部键
+// 参数：
+//
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (m *MemoryDb) Backup(w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverMemory)
+	if err != nil {
+		return err
+	}
+	for _, rec := range m.snapshot() {
+		if err := bw.WriteRecord(rec); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// snapshot 在锁内复制所有未过期的条目，写入备份时不持有锁
+func (m *MemoryDb) snapshot() []_interface.BackupRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	records := make([]_interface.BackupRecord, 0, len(m.items))
+	for elem := m.lru.Back(); elem != nil; elem = elem.Prev() {
+		e := elem.Value.(*entry)
+		if e.expired(now) {
+			continue
+		}
+		be := backupEntry{Value: e.value, Hash: e.hash, List: e.list}
+		for member := range e.set {
+			be.Set = append(be.Set, member)
+		}
+		value, _ := json.Marshal(be)
+		rec := _interface.BackupRecord{Key: []byte(e.id), Value: value}
+		if !e.expiresAt.IsZero() {
+			rec.TTL = e.expiresAt.Sub(now)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// Restore 恢复备份中的条目，同名条目整体替换，超出容量限制时按淘汰策略淘汰
+// 参数：
+//
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是Memory的备份时返回ErrBackupFormat
+func (m *MemoryDb) Restore(r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer m.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverMemory)
+	if err != nil {
+		return err
+	}
+	for {
+		rec, err := br.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var be backupEntry
+		if len(rec.Key) == 0 || json.Unmarshal(rec.Value, &be) != nil {
+			return _interface.ErrBackupFormat
+		}
+		m.restore(rec.Key[0], string(rec.Key[1:]), be, rec.TTL)
+	}
+}
+
+// restore 写入一个备份的条目
+func (m *MemoryDb) restore(kind byte, key string, be backupEntry, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(kind, key)
+	e := m.add(kind, key)
+	size := int64(len(be.Value))
+	e.value = be.Value
+	switch kind {
+	case kindHash:
+		e.hash = make(map[string]string, len(be.Hash))
+		for field, value := range be.Hash {
+			e.hash[field] = value
+			size += int64(len(field) + len(value))
+		}
+	case kindList:
+		e.list = be.List
+		for _, value := range be.List {
+			size += int64(len(value))
+		}
+	case kindSet:
+		e.set = make(map[string]struct{}, len(be.Set))
+		for _, member := range be.Set {
+			e.set[member] = struct{}{}
+			size += int64(len(member))
+		}
+	}
+	m.resize(e, size)
+	e.expiresAt = expiresAt(ttl)
+	m.evict()
+}
+
+// NewMemoryStore 创建内存缓存，容量由config.MaxEntries和config.MaxBytes限制，淘汰策略由config.EvictionPolicy决定
+func NewMemoryStore(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	if err := _interface.CheckEvictionPolicy(config.EvictionPolicy); err != nil {
+		return nil, err
+	}
+	m := &MemoryDb{
+		items:      make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: config.MaxEntries,
+		maxBytes:   config.MaxBytes,
+		policy:     config.EvictionPolicy,
+		codec:      codec,
+	}
+	m.CtxAdapter = _interface.NewCtxAdapter(m)
+	return m, nil
+}
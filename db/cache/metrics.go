@@ -0,0 +1,755 @@
+// cache包：缓存指标采集
+// 记录每个操作的命中、未命中、错误次数和耗时，并在采集时上报队列长度，通过Prometheus暴露给运维
+//
+// 主要特性：
+// - Metrics实现prometheus.Collector，可以被多个缓存实例共享，通过driver标签区分
+// - 读取操作成功记为hit，返回ErrKeyNotFound记为miss，其他错误记为error，写入操作成功记为ok
+// - 队列长度在Prometheus采集时调用Len读取，不增加正常调用的开销
+// - 底层缓存实现了CacheCtx时，返回的实例同样实现CacheCtx
+//
+// 指标：
+// - <namespace>_cache_operations_total{driver,op,result}：操作次数
+// - <namespace>_cache_operation_duration_seconds{driver,op}：操作耗时
+// - <namespace>_cache_queue_length{driver,queue}：队列长度
+// - <namespace>_cache_failover_active{failover,backend}：故障转移缓存当前使用的缓存，使用中为1，否则为0
+// - <namespace>_cache_failovers_total{failover}：故障转移缓存切换到备用缓存的次数
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// 操作结果标签
+const (
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultError = "error"
+	resultOK    = "ok"
+)
+
+// Metrics 缓存指标，实现prometheus.Collector
+type Metrics struct {
+	ops      *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	queueLen *prometheus.Desc
+
+	failoverActive *prometheus.Desc
+	failoverTotal  *prometheus.Desc
+
+	mu        sync.Mutex
+	metered   map[*metered]struct{}  // 需要采集队列长度的缓存实例
+	failovers map[*Failover]struct{} // 需要采集当前使用的缓存的故障转移缓存
+}
+
+// NewMetrics 创建缓存指标
+// 参数：
+//
+//	namespace - 指标名称的前缀，可以为空
+//
+// 返回值：
+//
+//	*Metrics - 缓存指标，需要通过prometheus.Register注册
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		ops: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "operations_total",
+			Help:      "Number of cache operations by result (hit, miss, error, ok).",
+		}, []string{"driver", "op", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of cache operations.",
+			Buckets:   []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"driver", "op"}),
+		queueLen: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "queue_length"),
+			"Number of elements in watched cache queues.",
+			[]string{"driver", "queue"}, nil,
+		),
+		failoverActive: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "failover_active"),
+			"Whether a failover cache is currently using the backend (1) or not (0).",
+			[]string{"failover", "backend"}, nil,
+		),
+		failoverTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "failovers_total"),
+			"Number of times a failover cache switched to its secondary backend.",
+			[]string{"failover"}, nil,
+		),
+		metered:   make(map[*metered]struct{}),
+		failovers: make(map[*Failover]struct{}),
+	}
+}
+
+// Describe 实现prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.ops.Describe(ch)
+	m.duration.Describe(ch)
+	ch <- m.queueLen
+	ch <- m.failoverActive
+	ch <- m.failoverTotal
+}
+
+// Collect 实现prometheus.Collector，读取所有被监控队列的长度和故障转移缓存当前使用的缓存
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.ops.Collect(ch)
+	m.duration.Collect(ch)
+
+	m.mu.Lock()
+	instances := make([]*metered, 0, len(m.metered))
+	for c := range m.metered {
+		instances = append(instances, c)
+	}
+	failovers := make([]*Failover, 0, len(m.failovers))
+	for f := range m.failovers {
+		failovers = append(failovers, f)
+	}
+	m.mu.Unlock()
+
+	for _, f := range failovers {
+		active := f.Active()
+		for _, backend := range []string{FailoverPrimary, FailoverSecondary} {
+			v := 0.0
+			if backend == active {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(m.failoverActive, prometheus.GaugeValue, v, f.opts.Name, backend)
+		}
+		ch <- prometheus.MustNewConstMetric(m.failoverTotal, prometheus.CounterValue, float64(f.Failovers()), f.opts.Name)
+	}
+
+	for _, c := range instances {
+		for _, queue := range c.queues {
+			n, err := c.c.Len(queue)
+			if err != nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(m.queueLen, prometheus.GaugeValue, float64(n), c.driver, queue)
+		}
+	}
+}
+
+// MetricsOptions 指标装饰器的选项
+type MetricsOptions struct {
+	Driver string   // driver标签的值，为空时使用"unknown"
+	Queues []string // 采集时上报长度的队列键名
+}
+
+// WithMetrics 返回在c之上记录指标的缓存实例
+// 参数：
+//
+//	c - 底层缓存实例
+//	m - 缓存指标
+//	opts - 指标选项
+//
+// 返回值：
+//
+//	_interface.Cache - 记录指标的缓存实例，c实现了CacheCtx时同样实现CacheCtx；
+//	Close停止采集队列长度，不关闭底层缓存
+func WithMetrics(c _interface.Cache, m *Metrics, opts MetricsOptions) _interface.Cache {
+	if opts.Driver == "" {
+		opts.Driver = "unknown"
+	}
+	mc := &metered{c: c, m: m, driver: opts.Driver, queues: opts.Queues}
+	if len(opts.Queues) > 0 {
+		m.mu.Lock()
+		m.metered[mc] = struct{}{}
+		m.mu.Unlock()
+	}
+	if cc, ok := c.(_interface.CacheCtx); ok {
+		return &meteredCtx{metered: mc, cc: cc}
+	}
+	return mc
+}
+
+// metered 指标装饰器，实现Cache接口
+type metered struct {
+	c      _interface.Cache
+	m      *Metrics
+	driver string
+	queues []string
+}
+
+// read 记录读取操作，成功记为hit
+func (m *metered) read(op string, start time.Time, err *error) {
+	m.observe(op, start, *err, resultHit)
+}
+
+// call 记录其他操作，成功记为ok
+func (m *metered) call(op string, start time.Time, err *error) {
+	m.observe(op, start, *err, resultOK)
+}
+
+func (m *metered) observe(op string, start time.Time, err error, success string) {
+	result := success
+	switch {
+	case errors.Is(err, _interface.ErrKeyNotFound):
+		result = resultMiss
+	case err != nil:
+		result = resultError
+	}
+	m.m.ops.WithLabelValues(m.driver, op, result).Inc()
+	m.m.duration.WithLabelValues(m.driver, op).Observe(time.Since(start).Seconds())
+}
+
+// Close 停止采集队列长度，指标装饰器不拥有底层缓存，底层缓存由创建它的一方关闭
+func (m *metered) Close() {
+	m.m.mu.Lock()
+	delete(m.m.metered, m)
+	m.m.mu.Unlock()
+}
+
+func (m *metered) Get(key string) (value string, err error) {
+	defer m.read("get", time.Now(), &err)
+	return m.c.Get(key)
+}
+
+func (m *metered) Set(key string, value string, ttl time.Duration) (err error) {
+	defer m.call("set", time.Now(), &err)
+	return m.c.Set(key, value, ttl)
+}
+
+func (m *metered) Delete(key string) (err error) {
+	defer m.call("delete", time.Now(), &err)
+	return m.c.Delete(key)
+}
+
+func (m *metered) Exists(key string) (ok bool, err error) {
+	defer m.call("exists", time.Now(), &err)
+	return m.c.Exists(key)
+}
+
+func (m *metered) Expire(key string, ttl time.Duration) (err error) {
+	defer m.call("expire", time.Now(), &err)
+	return m.c.Expire(key, ttl)
+}
+
+func (m *metered) TTL(key string) (ttl time.Duration, err error) {
+	defer m.call("ttl", time.Now(), &err)
+	return m.c.TTL(key)
+}
+
+func (m *metered) SetNX(key string, value string, ttl time.Duration) (ok bool, err error) {
+	defer m.call("setnx", time.Now(), &err)
+	return m.c.SetNX(key, value, ttl)
+}
+
+func (m *metered) CAS(key string, oldValue string, newValue string, ttl time.Duration) (ok bool, err error) {
+	defer m.call("cas", time.Now(), &err)
+	return m.c.CAS(key, oldValue, newValue, ttl)
+}
+
+func (m *metered) GetSet(key string, value string) (old string, err error) {
+	defer m.read("getset", time.Now(), &err)
+	return m.c.GetSet(key, value)
+}
+
+func (m *metered) GetDel(key string) (value string, err error) {
+	defer m.read("getdel", time.Now(), &err)
+	return m.c.GetDel(key)
+}
+
+func (m *metered) Append(key string, value string) (n int64, err error) {
+	defer m.call("append", time.Now(), &err)
+	return m.c.Append(key, value)
+}
+
+func (m *metered) StrLen(key string) (n int64, err error) {
+	defer m.call("strlen", time.Now(), &err)
+	return m.c.StrLen(key)
+}
+
+func (m *metered) SetObject(key string, v any, ttl time.Duration) (err error) {
+	defer m.call("setobject", time.Now(), &err)
+	return m.c.SetObject(key, v, ttl)
+}
+
+func (m *metered) GetObject(key string, v any) (err error) {
+	defer m.read("getobject", time.Now(), &err)
+	return m.c.GetObject(key, v)
+}
+
+func (m *metered) Keys(pattern string, fn func(key string) bool) (err error) {
+	defer m.call("keys", time.Now(), &err)
+	return m.c.Keys(pattern, fn)
+}
+
+func (m *metered) Iterate(prefix string, fn func(key, value string) bool) (err error) {
+	defer m.call("iterate", time.Now(), &err)
+	return m.c.Iterate(prefix, fn)
+}
+
+func (m *metered) DeleteByPrefix(prefix string) (err error) {
+	defer m.call("deletebyprefix", time.Now(), &err)
+	return m.c.DeleteByPrefix(prefix)
+}
+
+func (m *metered) HGet(key, field string) (value string, err error) {
+	defer m.read("hget", time.Now(), &err)
+	return m.c.HGet(key, field)
+}
+
+func (m *metered) HSet(key, field, value string, ttl time.Duration) (err error) {
+	defer m.call("hset", time.Now(), &err)
+	return m.c.HSet(key, field, value, ttl)
+}
+
+func (m *metered) HDel(key, field string) (err error) {
+	defer m.call("hdel", time.Now(), &err)
+	return m.c.HDel(key, field)
+}
+
+func (m *metered) HGetAll(key string) (fields map[string]string, err error) {
+	defer m.read("hgetall", time.Now(), &err)
+	return m.c.HGetAll(key)
+}
+
+func (m *metered) HLen(key string) (n int64, err error) {
+	defer m.call("hlen", time.Now(), &err)
+	return m.c.HLen(key)
+}
+
+func (m *metered) HExists(key, field string) (ok bool, err error) {
+	defer m.call("hexists", time.Now(), &err)
+	return m.c.HExists(key, field)
+}
+
+func (m *metered) HKeys(key string) (fields []string, err error) {
+	defer m.read("hkeys", time.Now(), &err)
+	return m.c.HKeys(key)
+}
+
+func (m *metered) SAdd(key, member string) (err error) {
+	defer m.call("sadd", time.Now(), &err)
+	return m.c.SAdd(key, member)
+}
+
+func (m *metered) SRem(key, member string) (err error) {
+	defer m.call("srem", time.Now(), &err)
+	return m.c.SRem(key, member)
+}
+
+func (m *metered) SMembers(key string) (members []string, err error) {
+	defer m.read("smembers", time.Now(), &err)
+	return m.c.SMembers(key)
+}
+
+func (m *metered) SIsMember(key, member string) (ok bool, err error) {
+	defer m.call("sismember", time.Now(), &err)
+	return m.c.SIsMember(key, member)
+}
+
+func (m *metered) Push(key string, value string) (err error) {
+	defer m.call("push", time.Now(), &err)
+	return m.c.Push(key, value)
+}
+
+func (m *metered) LPush(key string, value string) (err error) {
+	defer m.call("lpush", time.Now(), &err)
+	return m.c.LPush(key, value)
+}
+
+func (m *metered) RPush(key string, value string) (err error) {
+	defer m.call("rpush", time.Now(), &err)
+	return m.c.RPush(key, value)
+}
+
+func (m *metered) RPushCapped(key string, value string, maxLen int64) (err error) {
+	defer m.call("rpushcapped", time.Now(), &err)
+	return m.c.RPushCapped(key, value, maxLen)
+}
+
+func (m *metered) Pop(key string) (value string, err error) {
+	defer m.read("pop", time.Now(), &err)
+	return m.c.Pop(key)
+}
+
+func (m *metered) LPop(key string) (value string, err error) {
+	defer m.read("lpop", time.Now(), &err)
+	return m.c.LPop(key)
+}
+
+func (m *metered) RPop(key string) (value string, err error) {
+	defer m.read("rpop", time.Now(), &err)
+	return m.c.RPop(key)
+}
+
+func (m *metered) PopAll(key string) (values []string, err error) {
+	defer m.read("popall", time.Now(), &err)
+	return m.c.PopAll(key)
+}
+
+func (m *metered) Len(key string) (n int64, err error) {
+	defer m.call("len", time.Now(), &err)
+	return m.c.Len(key)
+}
+
+func (m *metered) LRange(key string, start, stop int64) (values []string, err error) {
+	defer m.read("lrange", time.Now(), &err)
+	return m.c.LRange(key, start, stop)
+}
+
+func (m *metered) LIndex(key string, index int64) (value string, err error) {
+	defer m.read("lindex", time.Now(), &err)
+	return m.c.LIndex(key, index)
+}
+
+func (m *metered) LRem(key string, count int64, value string) (n int64, err error) {
+	defer m.call("lrem", time.Now(), &err)
+	return m.c.LRem(key, count, value)
+}
+
+func (m *metered) LTrim(key string, start, stop int64) (err error) {
+	defer m.call("ltrim", time.Now(), &err)
+	return m.c.LTrim(key, start, stop)
+}
+
+func (m *metered) PopAck(key string, visibility time.Duration) (value string, receipt string, err error) {
+	defer m.read("popack", time.Now(), &err)
+	return m.c.PopAck(key, visibility)
+}
+
+func (m *metered) Ack(key, receipt string) (err error) {
+	defer m.call("ack", time.Now(), &err)
+	return m.c.Ack(key, receipt)
+}
+
+func (m *metered) PushDelayed(key, value string, delay time.Duration) (err error) {
+	defer m.call("pushdelayed", time.Now(), &err)
+	return m.c.PushDelayed(key, value, delay)
+}
+
+func (m *metered) Publish(channel string, payload string) (err error) {
+	defer m.call("publish", time.Now(), &err)
+	return m.c.Publish(channel, payload)
+}
+
+func (m *metered) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return m.c.Subscribe(channel)
+}
+
+func (m *metered) SubscribeExpired() (<-chan string, func()) {
+	return m.c.SubscribeExpired()
+}
+
+func (m *metered) Stats() (_interface.Stats, error) {
+	return m.c.Stats()
+}
+
+func (m *metered) Backup(w io.Writer) (err error) {
+	defer m.call("backup", time.Now(), &err)
+	return m.c.Backup(w)
+}
+
+func (m *metered) Restore(r io.Reader) (err error) {
+	defer m.call("restore", time.Now(), &err)
+	return m.c.Restore(r)
+}
+
+func (m *metered) BeginTx() (tx _interface.Tx, err error) {
+	defer m.call("begintx", time.Now(), &err)
+	return m.c.BeginTx()
+}
+
+func (m *metered) BeginTxWatch(keys ...string) (tx _interface.Tx, err error) {
+	defer m.call("begintx", time.Now(), &err)
+	return m.c.BeginTxWatch(keys...)
+}
+
+// BeginTxOptions 与BeginTx使用相同的op标签
+func (m *metered) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (tx _interface.Tx, err error) {
+	defer m.call("begintx", time.Now(), &err)
+	return _interface.BeginTx(ctx, m.c, opts)
+}
+
+// BFReserve 底层驱动支持时使用原生的布隆过滤器，位图实现的读写只记为一次操作
+func (m *metered) BFReserve(key string, errorRate float64, capacity int64) (err error) {
+	defer m.call("bfreserve", time.Now(), &err)
+	return _interface.BFReserve(m.c, key, errorRate, capacity)
+}
+
+func (m *metered) BFAdd(key, item string) (added bool, err error) {
+	defer m.call("bfadd", time.Now(), &err)
+	return _interface.BFAdd(m.c, key, item)
+}
+
+func (m *metered) BFExists(key, item string) (exists bool, err error) {
+	defer m.call("bfexists", time.Now(), &err)
+	return _interface.BFExists(m.c, key, item)
+}
+
+// meteredCtx 底层缓存实现了CacheCtx时使用的指标装饰器，与不带上下文的操作使用相同的op标签
+type meteredCtx struct {
+	*metered
+	cc _interface.CacheCtx
+}
+
+func (m *meteredCtx) GetContext(ctx context.Context, key string) (value string, err error) {
+	defer m.read("get", time.Now(), &err)
+	return m.cc.GetContext(ctx, key)
+}
+
+func (m *meteredCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) (err error) {
+	defer m.call("set", time.Now(), &err)
+	return m.cc.SetContext(ctx, key, value, ttl)
+}
+
+func (m *meteredCtx) DeleteContext(ctx context.Context, key string) (err error) {
+	defer m.call("delete", time.Now(), &err)
+	return m.cc.DeleteContext(ctx, key)
+}
+
+func (m *meteredCtx) ExistsContext(ctx context.Context, key string) (ok bool, err error) {
+	defer m.call("exists", time.Now(), &err)
+	return m.cc.ExistsContext(ctx, key)
+}
+
+func (m *meteredCtx) ExpireContext(ctx context.Context, key string, ttl time.Duration) (err error) {
+	defer m.call("expire", time.Now(), &err)
+	return m.cc.ExpireContext(ctx, key, ttl)
+}
+
+func (m *meteredCtx) TTLContext(ctx context.Context, key string) (ttl time.Duration, err error) {
+	defer m.call("ttl", time.Now(), &err)
+	return m.cc.TTLContext(ctx, key)
+}
+
+func (m *meteredCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (ok bool, err error) {
+	defer m.call("setnx", time.Now(), &err)
+	return m.cc.SetNXContext(ctx, key, value, ttl)
+}
+
+func (m *meteredCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (ok bool, err error) {
+	defer m.call("cas", time.Now(), &err)
+	return m.cc.CASContext(ctx, key, oldValue, newValue, ttl)
+}
+
+func (m *meteredCtx) GetSetContext(ctx context.Context, key string, value string) (old string, err error) {
+	defer m.read("getset", time.Now(), &err)
+	return m.cc.GetSetContext(ctx, key, value)
+}
+
+func (m *meteredCtx) GetDelContext(ctx context.Context, key string) (value string, err error) {
+	defer m.read("getdel", time.Now(), &err)
+	return m.cc.GetDelContext(ctx, key)
+}
+
+func (m *meteredCtx) AppendContext(ctx context.Context, key string, value string) (n int64, err error) {
+	defer m.call("append", time.Now(), &err)
+	return m.cc.AppendContext(ctx, key, value)
+}
+
+func (m *meteredCtx) StrLenContext(ctx context.Context, key string) (n int64, err error) {
+	defer m.call("strlen", time.Now(), &err)
+	return m.cc.StrLenContext(ctx, key)
+}
+
+func (m *meteredCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) (err error) {
+	defer m.call("setobject", time.Now(), &err)
+	return m.cc.SetObjectContext(ctx, key, v, ttl)
+}
+
+func (m *meteredCtx) GetObjectContext(ctx context.Context, key string, v any) (err error) {
+	defer m.read("getobject", time.Now(), &err)
+	return m.cc.GetObjectContext(ctx, key, v)
+}
+
+func (m *meteredCtx) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) (err error) {
+	defer m.call("keys", time.Now(), &err)
+	return m.cc.KeysContext(ctx, pattern, fn)
+}
+
+func (m *meteredCtx) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) (err error) {
+	defer m.call("iterate", time.Now(), &err)
+	return m.cc.IterateContext(ctx, prefix, fn)
+}
+
+func (m *meteredCtx) DeleteByPrefixContext(ctx context.Context, prefix string) (err error) {
+	defer m.call("deletebyprefix", time.Now(), &err)
+	return m.cc.DeleteByPrefixContext(ctx, prefix)
+}
+
+func (m *meteredCtx) HGetContext(ctx context.Context, key, field string) (value string, err error) {
+	defer m.read("hget", time.Now(), &err)
+	return m.cc.HGetContext(ctx, key, field)
+}
+
+func (m *meteredCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) (err error) {
+	defer m.call("hset", time.Now(), &err)
+	return m.cc.HSetContext(ctx, key, field, value, ttl)
+}
+
+func (m *meteredCtx) HDelContext(ctx context.Context, key, field string) (err error) {
+	defer m.call("hdel", time.Now(), &err)
+	return m.cc.HDelContext(ctx, key, field)
+}
+
+func (m *meteredCtx) HGetAllContext(ctx context.Context, key string) (fields map[string]string, err error) {
+	defer m.read("hgetall", time.Now(), &err)
+	return m.cc.HGetAllContext(ctx, key)
+}
+
+func (m *meteredCtx) HLenContext(ctx context.Context, key string) (n int64, err error) {
+	defer m.call("hlen", time.Now(), &err)
+	return m.cc.HLenContext(ctx, key)
+}
+
+func (m *meteredCtx) HExistsContext(ctx context.Context, key, field string) (ok bool, err error) {
+	defer m.call("hexists", time.Now(), &err)
+	return m.cc.HExistsContext(ctx, key, field)
+}
+
+func (m *meteredCtx) HKeysContext(ctx context.Context, key string) (fields []string, err error) {
+	defer m.read("hkeys", time.Now(), &err)
+	return m.cc.HKeysContext(ctx, key)
+}
+
+func (m *meteredCtx) SAddContext(ctx context.Context, key, member string) (err error) {
+	defer m.call("sadd", time.Now(), &err)
+	return m.cc.SAddContext(ctx, key, member)
+}
+
+func (m *meteredCtx) SRemContext(ctx context.Context, key, member string) (err error) {
+	defer m.call("srem", time.Now(), &err)
+	return m.cc.SRemContext(ctx, key, member)
+}
+
+func (m *meteredCtx) SMembersContext(ctx context.Context, key string) (members []string, err error) {
+	defer m.read("smembers", time.Now(), &err)
+	return m.cc.SMembersContext(ctx, key)
+}
+
+func (m *meteredCtx) SIsMemberContext(ctx context.Context, key, member string) (ok bool, err error) {
+	defer m.call("sismember", time.Now(), &err)
+	return m.cc.SIsMemberContext(ctx, key, member)
+}
+
+func (m *meteredCtx) PushContext(ctx context.Context, key string, value string) (err error) {
+	defer m.call("push", time.Now(), &err)
+	return m.cc.PushContext(ctx, key, value)
+}
+
+func (m *meteredCtx) LPushContext(ctx context.Context, key string, value string) (err error) {
+	defer m.call("lpush", time.Now(), &err)
+	return m.cc.LPushContext(ctx, key, value)
+}
+
+func (m *meteredCtx) RPushContext(ctx context.Context, key string, value string) (err error) {
+	defer m.call("rpush", time.Now(), &err)
+	return m.cc.RPushContext(ctx, key, value)
+}
+
+func (m *meteredCtx) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) (err error) {
+	defer m.call("rpushcapped", time.Now(), &err)
+	return m.cc.RPushCappedContext(ctx, key, value, maxLen)
+}
+
+func (m *meteredCtx) PopContext(ctx context.Context, key string) (value string, err error) {
+	defer m.read("pop", time.Now(), &err)
+	return m.cc.PopContext(ctx, key)
+}
+
+func (m *meteredCtx) LPopContext(ctx context.Context, key string) (value string, err error) {
+	defer m.read("lpop", time.Now(), &err)
+	return m.cc.LPopContext(ctx, key)
+}
+
+func (m *meteredCtx) RPopContext(ctx context.Context, key string) (value string, err error) {
+	defer m.read("rpop", time.Now(), &err)
+	return m.cc.RPopContext(ctx, key)
+}
+
+func (m *meteredCtx) PopAllContext(ctx context.Context, key string) (values []string, err error) {
+	defer m.read("popall", time.Now(), &err)
+	return m.cc.PopAllContext(ctx, key)
+}
+
+func (m *meteredCtx) LenContext(ctx context.Context, key string) (n int64, err error) {
+	defer m.call("len", time.Now(), &err)
+	return m.cc.LenContext(ctx, key)
+}
+
+func (m *meteredCtx) LRangeContext(ctx context.Context, key string, start, stop int64) (values []string, err error) {
+	defer m.read("lrange", time.Now(), &err)
+	return m.cc.LRangeContext(ctx, key, start, stop)
+}
+
+func (m *meteredCtx) LIndexContext(ctx context.Context, key string, index int64) (value string, err error) {
+	defer m.read("lindex", time.Now(), &err)
+	return m.cc.LIndexContext(ctx, key, index)
+}
+
+func (m *meteredCtx) LRemContext(ctx context.Context, key string, count int64, value string) (n int64, err error) {
+	defer m.call("lrem", time.Now(), &err)
+	return m.cc.LRemContext(ctx, key, count, value)
+}
+
+func (m *meteredCtx) LTrimContext(ctx context.Context, key string, start, stop int64) (err error) {
+	defer m.call("ltrim", time.Now(), &err)
+	return m.cc.LTrimContext(ctx, key, start, stop)
+}
+
+func (m *meteredCtx) PopAckContext(ctx context.Context, key string, visibility time.Duration) (value string, receipt string, err error) {
+	defer m.read("popack", time.Now(), &err)
+	return m.cc.PopAckContext(ctx, key, visibility)
+}
+
+func (m *meteredCtx) AckContext(ctx context.Context, key, receipt string) (err error) {
+	defer m.call("ack", time.Now(), &err)
+	return m.cc.AckContext(ctx, key, receipt)
+}
+
+func (m *meteredCtx) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) (err error) {
+	defer m.call("pushdelayed", time.Now(), &err)
+	return m.cc.PushDelayedContext(ctx, key, value, delay)
+}
+
+func (m *meteredCtx) PublishContext(ctx context.Context, channel string, payload string) (err error) {
+	defer m.call("publish", time.Now(), &err)
+	return m.cc.PublishContext(ctx, channel, payload)
+}
+
+func (m *meteredCtx) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	return m.cc.SubscribeContext(ctx, channel)
+}
+
+func (m *meteredCtx) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return m.cc.SubscribeExpiredContext(ctx)
+}
+
+func (m *meteredCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return m.cc.StatsContext(ctx)
+}
+
+func (m *meteredCtx) BackupContext(ctx context.Context, w io.Writer) (err error) {
+	defer m.call("backup", time.Now(), &err)
+	return m.cc.BackupContext(ctx, w)
+}
+
+func (m *meteredCtx) RestoreContext(ctx context.Context, r io.Reader) (err error) {
+	defer m.call("restore", time.Now(), &err)
+	return m.cc.RestoreContext(ctx, r)
+}
+
+func (m *meteredCtx) BeginTxContext(ctx context.Context) (tx _interface.Tx, err error) {
+	defer m.call("begintx", time.Now(), &err)
+	return m.cc.BeginTxContext(ctx)
+}
+
+func (m *meteredCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (tx _interface.Tx, err error) {
+	defer m.call("begintx", time.Now(), &err)
+	return m.cc.BeginTxWatchContext(ctx, keys...)
+}
@@ -0,0 +1,702 @@
+// cache包：缓存命名空间装饰器
+// 为任意缓存实例加上统一的键前缀，多个模块可以共享同一个存储而不会发生键冲突
+//
+// 主要特性：
+// - 所有键、频道名、布隆过滤器和DeleteByPrefix的前缀都会自动加上"<namespace>:"
+// - Keys、Iterate、Subscribe和SubscribeExpired返回的键名和频道名会去掉前缀
+// - SubscribeExpired只通知本命名空间内的键
+// - 底层缓存实现了CacheCtx时，返回的实例同样实现CacheCtx
+// - 命名空间可以嵌套，WithNamespace(WithNamespace(c, "a"), "b")的键前缀为"a:b:"
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// WithNamespace 返回在c之上加了命名空间的缓存实例
+// 参数：
+//
+//	c - 底层缓存实例
+//	namespace - 命名空间，键名的前缀为"<namespace>:"
+//
+// 返回值：
+//
+//	_interface.Cache - 加了命名空间的缓存实例，c实现了CacheCtx时同样实现CacheCtx
+func WithNamespace(c _interface.Cache, namespace string) _interface.Cache {
+	n := &namespaced{c: c, prefix: namespace + ":"}
+	if cc, ok := c.(_interface.CacheCtx); ok {
+		return &namespacedCtx{namespaced: n, cc: cc}
+	}
+	return n
+}
+
+// namespaced 命名空间装饰器，实现Cache接口
+type namespaced struct {
+	c      _interface.Cache
+	prefix string // 键名前缀，"<namespace>:"
+}
+
+func (n *namespaced) key(key string) string {
+	return n.prefix + key
+}
+
+// keys 为多个键名加上前缀
+func (n *namespaced) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = n.key(key)
+	}
+	return prefixed
+}
+
+// pattern 将pattern限定在命名空间内，空pattern匹配命名空间内的所有键
+func (n *namespaced) pattern(pattern string) string {
+	if pattern == "" {
+		pattern = "*"
+	}
+	return _interface.EscapePattern(n.prefix) + pattern
+}
+
+// Close 命名空间不拥有底层缓存，不做任何操作，底层缓存由创建它的一方关闭
+func (n *namespaced) Close() {}
+
+func (n *namespaced) Get(key string) (string, error) {
+	return n.c.Get(n.key(key))
+}
+
+func (n *namespaced) Set(key string, value string, ttl time.Duration) error {
+	return n.c.Set(n.key(key), value, ttl)
+}
+
+func (n *namespaced) Delete(key string) error {
+	return n.c.Delete(n.key(key))
+}
+
+func (n *namespaced) Exists(key string) (bool, error) {
+	return n.c.Exists(n.key(key))
+}
+
+func (n *namespaced) Expire(key string, ttl time.Duration) error {
+	return n.c.Expire(n.key(key), ttl)
+}
+
+func (n *namespaced) TTL(key string) (time.Duration, error) {
+	return n.c.TTL(n.key(key))
+}
+
+func (n *namespaced) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return n.c.SetNX(n.key(key), value, ttl)
+}
+
+func (n *namespaced) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return n.c.CAS(n.key(key), oldValue, newValue, ttl)
+}
+
+func (n *namespaced) GetSet(key string, value string) (string, error) {
+	return n.c.GetSet(n.key(key), value)
+}
+
+func (n *namespaced) GetDel(key string) (string, error) {
+	return n.c.GetDel(n.key(key))
+}
+
+func (n *namespaced) Append(key string, value string) (int64, error) {
+	return n.c.Append(n.key(key), value)
+}
+
+func (n *namespaced) StrLen(key string) (int64, error) {
+	return n.c.StrLen(n.key(key))
+}
+
+func (n *namespaced) SetObject(key string, v any, ttl time.Duration) error {
+	return n.c.SetObject(n.key(key), v, ttl)
+}
+
+func (n *namespaced) GetObject(key string, v any) error {
+	return n.c.GetObject(n.key(key), v)
+}
+
+// Keys 遍历命名空间内匹配pattern的key，传给fn的key已去掉前缀
+func (n *namespaced) Keys(pattern string, fn func(key string) bool) error {
+	return n.c.Keys(n.pattern(pattern), n.stripKey(fn))
+}
+
+// Iterate 遍历命名空间内键名以prefix开头的键值，传给fn的key已去掉前缀
+func (n *namespaced) Iterate(prefix string, fn func(key, value string) bool) error {
+	return n.c.Iterate(n.key(prefix), n.stripEntry(fn))
+}
+
+// DeleteByPrefix 删除命名空间内所有以prefix开头的key，prefix为空时清空整个命名空间
+func (n *namespaced) DeleteByPrefix(prefix string) error {
+	return n.c.DeleteByPrefix(n.key(prefix))
+}
+
+func (n *namespaced) HGet(key, field string) (string, error) {
+	return n.c.HGet(n.key(key), field)
+}
+
+func (n *namespaced) HSet(key, field, value string, ttl time.Duration) error {
+	return n.c.HSet(n.key(key), field, value, ttl)
+}
+
+func (n *namespaced) HDel(key, field string) error {
+	return n.c.HDel(n.key(key), field)
+}
+
+func (n *namespaced) HGetAll(key string) (map[string]string, error) {
+	return n.c.HGetAll(n.key(key))
+}
+
+func (n *namespaced) HLen(key string) (int64, error) {
+	return n.c.HLen(n.key(key))
+}
+
+func (n *namespaced) HExists(key, field string) (bool, error) {
+	return n.c.HExists(n.key(key), field)
+}
+
+func (n *namespaced) HKeys(key string) ([]string, error) {
+	return n.c.HKeys(n.key(key))
+}
+
+func (n *namespaced) SAdd(key, member string) error {
+	return n.c.SAdd(n.key(key), member)
+}
+
+func (n *namespaced) SRem(key, member string) error {
+	return n.c.SRem(n.key(key), member)
+}
+
+func (n *namespaced) SMembers(key string) ([]string, error) {
+	return n.c.SMembers(n.key(key))
+}
+
+func (n *namespaced) SIsMember(key, member string) (bool, error) {
+	return n.c.SIsMember(n.key(key), member)
+}
+
+func (n *namespaced) Push(key string, value string) error {
+	return n.c.Push(n.key(key), value)
+}
+
+func (n *namespaced) LPush(key string, value string) error {
+	return n.c.LPush(n.key(key), value)
+}
+
+func (n *namespaced) RPush(key string, value string) error {
+	return n.c.RPush(n.key(key), value)
+}
+
+func (n *namespaced) RPushCapped(key string, value string, maxLen int64) error {
+	return n.c.RPushCapped(n.key(key), value, maxLen)
+}
+
+func (n *namespaced) Pop(key string) (string, error) {
+	return n.c.Pop(n.key(key))
+}
+
+func (n *namespaced) LPop(key string) (string, error) {
+	return n.c.LPop(n.key(key))
+}
+
+func (n *namespaced) RPop(key string) (string, error) {
+	return n.c.RPop(n.key(key))
+}
+
+func (n *namespaced) PopAll(key string) ([]string, error) {
+	return n.c.PopAll(n.key(key))
+}
+
+func (n *namespaced) Len(key string) (int64, error) {
+	return n.c.Len(n.key(key))
+}
+
+func (n *namespaced) LRange(key string, start, stop int64) ([]string, error) {
+	return n.c.LRange(n.key(key), start, stop)
+}
+
+func (n *namespaced) LIndex(key string, index int64) (string, error) {
+	return n.c.LIndex(n.key(key), index)
+}
+
+func (n *namespaced) LRem(key string, count int64, value string) (int64, error) {
+	return n.c.LRem(n.key(key), count, value)
+}
+
+func (n *namespaced) LTrim(key string, start, stop int64) error {
+	return n.c.LTrim(n.key(key), start, stop)
+}
+
+func (n *namespaced) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return n.c.PopAck(n.key(key), visibility)
+}
+
+func (n *namespaced) Ack(key, receipt string) error {
+	return n.c.Ack(n.key(key), receipt)
+}
+
+func (n *namespaced) PushDelayed(key, value string, delay time.Duration) error {
+	return n.c.PushDelayed(n.key(key), value, delay)
+}
+
+func (n *namespaced) Publish(channel string, payload string) error {
+	return n.c.Publish(n.key(channel), payload)
+}
+
+// Subscribe 订阅命名空间内的频道，收到的消息中频道名已去掉前缀
+func (n *namespaced) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	msgs, cancel := n.c.Subscribe(n.key(channel))
+	return forward(msgs, cancel, n.stripMessage)
+}
+
+// SubscribeExpired 订阅命名空间内的键过期事件，收到的键名已去掉前缀
+func (n *namespaced) SubscribeExpired() (<-chan string, func()) {
+	keys, cancel := n.c.SubscribeExpired()
+	return forward(keys, cancel, n.strip)
+}
+
+// Stats 返回底层缓存的统计信息，统计的是整个存储而不只是本命名空间
+func (n *namespaced) Stats() (_interface.Stats, error) {
+	return n.c.Stats()
+}
+
+// Backup 备份底层缓存的所有数据，不只是本命名空间内的键
+func (n *namespaced) Backup(w io.Writer) error {
+	return n.c.Backup(w)
+}
+
+// Restore 恢复底层缓存的备份，备份中的键名保持原样
+func (n *namespaced) Restore(r io.Reader) error {
+	return n.c.Restore(r)
+}
+
+func (n *namespaced) BeginTx() (_interface.Tx, error) {
+	tx, err := n.c.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedTx{tx: tx, n: n}, nil
+}
+
+func (n *namespaced) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	tx, err := n.c.BeginTxWatch(n.keys(keys)...)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedTx{tx: tx, n: n}, nil
+}
+
+// BeginTxOptions 按选项开启底层缓存的事务，底层驱动支持时使用原生的只读事务
+func (n *namespaced) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	tx, err := _interface.BeginTx(ctx, n.c, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedTx{tx: tx, n: n}, nil
+}
+
+// PipelineContext 命令管道中的键加上前缀，底层驱动支持时使用原生实现
+func (n *namespaced) PipelineContext(ctx context.Context, fn func(p _interface.Pipeliner) error) error {
+	return _interface.PipelineContext(ctx, n.c, func(p _interface.Pipeliner) error {
+		return fn(&namespacedPipeliner{p: p, n: n})
+	})
+}
+
+// BFReserve 布隆过滤器的键加上前缀，底层驱动支持时使用原生实现
+func (n *namespaced) BFReserve(key string, errorRate float64, capacity int64) error {
+	return _interface.BFReserve(n.c, n.key(key), errorRate, capacity)
+}
+
+func (n *namespaced) BFAdd(key, item string) (bool, error) {
+	return _interface.BFAdd(n.c, n.key(key), item)
+}
+
+func (n *namespaced) BFExists(key, item string) (bool, error) {
+	return _interface.BFExists(n.c, n.key(key), item)
+}
+
+// strip 去掉键名的前缀，不属于命名空间的键返回false
+func (n *namespaced) strip(key string) (string, bool) {
+	return strings.CutPrefix(key, n.prefix)
+}
+
+func (n *namespaced) stripMessage(msg _interface.Message) (_interface.Message, bool) {
+	msg.Channel = strings.TrimPrefix(msg.Channel, n.prefix)
+	return msg, true
+}
+
+func (n *namespaced) stripKey(fn func(key string) bool) func(key string) bool {
+	return func(key string) bool {
+		if key, ok := n.strip(key); ok {
+			return fn(key)
+		}
+		return true
+	}
+}
+
+func (n *namespaced) stripEntry(fn func(key, value string) bool) func(key, value string) bool {
+	return func(key, value string) bool {
+		return fn(strings.TrimPrefix(key, n.prefix), value)
+	}
+}
+
+// namespacedPipeliner 命名空间命令管道，为排队的命令的键加上前缀
+type namespacedPipeliner struct {
+	p _interface.Pipeliner
+	n *namespaced
+}
+
+func (p *namespacedPipeliner) Get(key string) *_interface.PipeCmd[string] {
+	return p.p.Get(p.n.key(key))
+}
+
+func (p *namespacedPipeliner) Set(key string, value string, ttl time.Duration) *_interface.PipeStatusCmd {
+	return p.p.Set(p.n.key(key), value, ttl)
+}
+
+func (p *namespacedPipeliner) Delete(key string) *_interface.PipeStatusCmd {
+	return p.p.Delete(p.n.key(key))
+}
+
+func (p *namespacedPipeliner) Exists(key string) *_interface.PipeCmd[bool] {
+	return p.p.Exists(p.n.key(key))
+}
+
+func (p *namespacedPipeliner) Expire(key string, ttl time.Duration) *_interface.PipeStatusCmd {
+	return p.p.Expire(p.n.key(key), ttl)
+}
+
+func (p *namespacedPipeliner) TTL(key string) *_interface.PipeCmd[time.Duration] {
+	return p.p.TTL(p.n.key(key))
+}
+
+func (p *namespacedPipeliner) HGet(key, field string) *_interface.PipeCmd[string] {
+	return p.p.HGet(p.n.key(key), field)
+}
+
+func (p *namespacedPipeliner) HSet(key, field, value string, ttl time.Duration) *_interface.PipeStatusCmd {
+	return p.p.HSet(p.n.key(key), field, value, ttl)
+}
+
+func (p *namespacedPipeliner) HDel(key, field string) *_interface.PipeStatusCmd {
+	return p.p.HDel(p.n.key(key), field)
+}
+
+func (p *namespacedPipeliner) SAdd(key, member string) *_interface.PipeStatusCmd {
+	return p.p.SAdd(p.n.key(key), member)
+}
+
+func (p *namespacedPipeliner) SRem(key, member string) *_interface.PipeStatusCmd {
+	return p.p.SRem(p.n.key(key), member)
+}
+
+func (p *namespacedPipeliner) LPush(key string, value string) *_interface.PipeStatusCmd {
+	return p.p.LPush(p.n.key(key), value)
+}
+
+func (p *namespacedPipeliner) RPush(key string, value string) *_interface.PipeStatusCmd {
+	return p.p.RPush(p.n.key(key), value)
+}
+
+// namespacedTx 命名空间事务，为事务内的键加上前缀
+type namespacedTx struct {
+	tx _interface.Tx
+	n  *namespaced
+}
+
+func (t *namespacedTx) Get(key string) (string, error) {
+	return t.tx.Get(t.n.key(key))
+}
+
+func (t *namespacedTx) Exists(key string) (bool, error) {
+	return t.tx.Exists(t.n.key(key))
+}
+
+func (t *namespacedTx) Set(key string, value string, ttl time.Duration) error {
+	return t.tx.Set(t.n.key(key), value, ttl)
+}
+
+func (t *namespacedTx) Delete(key string) error {
+	return t.tx.Delete(t.n.key(key))
+}
+
+func (t *namespacedTx) Expire(key string, ttl time.Duration) error {
+	return t.tx.Expire(t.n.key(key), ttl)
+}
+
+func (t *namespacedTx) HSet(key, field, value string, ttl time.Duration) error {
+	return t.tx.HSet(t.n.key(key), field, value, ttl)
+}
+
+func (t *namespacedTx) HDel(key, field string) error {
+	return t.tx.HDel(t.n.key(key), field)
+}
+
+func (t *namespacedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *namespacedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// namespacedCtx 底层缓存实现了CacheCtx时使用的命名空间装饰器
+type namespacedCtx struct {
+	*namespaced
+	cc _interface.CacheCtx
+}
+
+func (n *namespacedCtx) GetContext(ctx context.Context, key string) (string, error) {
+	return n.cc.GetContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return n.cc.SetContext(ctx, n.key(key), value, ttl)
+}
+
+func (n *namespacedCtx) DeleteContext(ctx context.Context, key string) error {
+	return n.cc.DeleteContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return n.cc.ExistsContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	return n.cc.ExpireContext(ctx, n.key(key), ttl)
+}
+
+func (n *namespacedCtx) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	return n.cc.TTLContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	return n.cc.SetNXContext(ctx, n.key(key), value, ttl)
+}
+
+func (n *namespacedCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return n.cc.CASContext(ctx, n.key(key), oldValue, newValue, ttl)
+}
+
+func (n *namespacedCtx) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	return n.cc.GetSetContext(ctx, n.key(key), value)
+}
+
+func (n *namespacedCtx) GetDelContext(ctx context.Context, key string) (string, error) {
+	return n.cc.GetDelContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	return n.cc.AppendContext(ctx, n.key(key), value)
+}
+
+func (n *namespacedCtx) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return n.cc.StrLenContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	return n.cc.SetObjectContext(ctx, n.key(key), v, ttl)
+}
+
+func (n *namespacedCtx) GetObjectContext(ctx context.Context, key string, v any) error {
+	return n.cc.GetObjectContext(ctx, n.key(key), v)
+}
+
+func (n *namespacedCtx) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	return n.cc.KeysContext(ctx, n.pattern(pattern), n.stripKey(fn))
+}
+
+func (n *namespacedCtx) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	return n.cc.IterateContext(ctx, n.key(prefix), n.stripEntry(fn))
+}
+
+func (n *namespacedCtx) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	return n.cc.DeleteByPrefixContext(ctx, n.key(prefix))
+}
+
+func (n *namespacedCtx) HGetContext(ctx context.Context, key, field string) (string, error) {
+	return n.cc.HGetContext(ctx, n.key(key), field)
+}
+
+func (n *namespacedCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	return n.cc.HSetContext(ctx, n.key(key), field, value, ttl)
+}
+
+func (n *namespacedCtx) HDelContext(ctx context.Context, key, field string) error {
+	return n.cc.HDelContext(ctx, n.key(key), field)
+}
+
+func (n *namespacedCtx) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	return n.cc.HGetAllContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) HLenContext(ctx context.Context, key string) (int64, error) {
+	return n.cc.HLenContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	return n.cc.HExistsContext(ctx, n.key(key), field)
+}
+
+func (n *namespacedCtx) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	return n.cc.HKeysContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) SAddContext(ctx context.Context, key, member string) error {
+	return n.cc.SAddContext(ctx, n.key(key), member)
+}
+
+func (n *namespacedCtx) SRemContext(ctx context.Context, key, member string) error {
+	return n.cc.SRemContext(ctx, n.key(key), member)
+}
+
+func (n *namespacedCtx) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return n.cc.SMembersContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	return n.cc.SIsMemberContext(ctx, n.key(key), member)
+}
+
+func (n *namespacedCtx) PushContext(ctx context.Context, key string, value string) error {
+	return n.cc.PushContext(ctx, n.key(key), value)
+}
+
+func (n *namespacedCtx) LPushContext(ctx context.Context, key string, value string) error {
+	return n.cc.LPushContext(ctx, n.key(key), value)
+}
+
+func (n *namespacedCtx) RPushContext(ctx context.Context, key string, value string) error {
+	return n.cc.RPushContext(ctx, n.key(key), value)
+}
+
+func (n *namespacedCtx) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	return n.cc.RPushCappedContext(ctx, n.key(key), value, maxLen)
+}
+
+func (n *namespacedCtx) PopContext(ctx context.Context, key string) (string, error) {
+	return n.cc.PopContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) LPopContext(ctx context.Context, key string) (string, error) {
+	return n.cc.LPopContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) RPopContext(ctx context.Context, key string) (string, error) {
+	return n.cc.RPopContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	return n.cc.PopAllContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) LenContext(ctx context.Context, key string) (int64, error) {
+	return n.cc.LenContext(ctx, n.key(key))
+}
+
+func (n *namespacedCtx) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return n.cc.LRangeContext(ctx, n.key(key), start, stop)
+}
+
+func (n *namespacedCtx) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return n.cc.LIndexContext(ctx, n.key(key), index)
+}
+
+func (n *namespacedCtx) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	return n.cc.LRemContext(ctx, n.key(key), count, value)
+}
+
+func (n *namespacedCtx) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	return n.cc.LTrimContext(ctx, n.key(key), start, stop)
+}
+
+func (n *namespacedCtx) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	return n.cc.PopAckContext(ctx, n.key(key), visibility)
+}
+
+func (n *namespacedCtx) AckContext(ctx context.Context, key, receipt string) error {
+	return n.cc.AckContext(ctx, n.key(key), receipt)
+}
+
+func (n *namespacedCtx) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	return n.cc.PushDelayedContext(ctx, n.key(key), value, delay)
+}
+
+func (n *namespacedCtx) PublishContext(ctx context.Context, channel string, payload string) error {
+	return n.cc.PublishContext(ctx, n.key(channel), payload)
+}
+
+func (n *namespacedCtx) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	msgs, cancel := n.cc.SubscribeContext(ctx, n.key(channel))
+	return forward(msgs, cancel, n.stripMessage)
+}
+
+func (n *namespacedCtx) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	keys, cancel := n.cc.SubscribeExpiredContext(ctx)
+	return forward(keys, cancel, n.strip)
+}
+
+func (n *namespacedCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return n.cc.StatsContext(ctx)
+}
+
+func (n *namespacedCtx) BackupContext(ctx context.Context, w io.Writer) error {
+	return n.cc.BackupContext(ctx, w)
+}
+
+func (n *namespacedCtx) RestoreContext(ctx context.Context, r io.Reader) error {
+	return n.cc.RestoreContext(ctx, r)
+}
+
+func (n *namespacedCtx) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	tx, err := n.cc.BeginTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedTx{tx: tx, n: n.namespaced}, nil
+}
+
+func (n *namespacedCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx, err := n.cc.BeginTxWatchContext(ctx, n.keys(keys)...)
+	if err != nil {
+		return nil, err
+	}
+	return &namespacedTx{tx: tx, n: n.namespaced}, nil
+}
+
+// forward 将in中的元素经过conv转换后转发到新的通道，conv返回false的元素被丢弃
+// 返回的取消函数同时取消底层订阅；底层通道关闭后新通道也被关闭
+func forward[T any](in <-chan T, cancel func(), conv func(T) (T, bool)) (<-chan T, func()) {
+	out := make(chan T)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+	go func() {
+		defer close(out)
+		for v := range in {
+			v, ok := conv(v)
+			if !ok {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out, stop
+}
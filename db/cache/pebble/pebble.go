@@ -0,0 +1,1376 @@
+// pebble包：基于Pebble的高性能本地缓存实现
+// 提供键值存储、哈希表操作、队列操作和事务支持
+//
+// Pebble是CockroachDB使用的LSM树Key-Value存储引擎，兼容RocksDB的调优方式
+// 本包实现了Cache接口，可以作为BadgerDB的替代方案，依赖更少
+//
+// 主要特性：
+// - 高性能读写操作，基于LSM树结构
+// - 支持TTL过期（过期时间编码在值中，读取时过滤）
+// - 队列操作（FIFO/LIFO，利用键的有序性实现）
+// - 哈希表操作（通过前缀复合键实现，HGetAll按前缀范围扫描）
+// - 集合操作（通过前缀复合键实现）
+// - 事务支持（基于Batch原子提交）
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+// - 线程安全的并发访问
+//
+// 键编码：
+// - 键值：k\x00<key>
+// - 哈希表：h\x00<key>\x00<field>
+// - 队列：l\x00<key>\x00<8字节有序位置>
+// - 集合：s\x00<key>\x00<member>
+//
+// 作者: gophertool
+package pebble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	"github.com/cockroachdb/pebble"
+)
+
+// 包初始化时注册Pebble驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverPebble, NewPebbleStore)
+}
+
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
+// 键的命名空间前缀
+const (
+	kvPrefix   = 'k'
+	hashPrefix = 'h'
+	listPrefix = 'l'
+	setPrefix  = 's'
+)
+
+// PebbleDb Pebble缓存实现结构体
+type PebbleDb struct {
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db         *pebble.DB                // Pebble实例
+	queueMutex sync.Map                  // 用于队列操作和读取-修改-写回操作的互斥锁映射
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*PebbleDb)(nil)
+
+// Close 关闭数据库
+func (p *PebbleDb) Close() {
+	p.expiry.Close()
+	_ = p.db.Close()
+	p.broker.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (p *PebbleDb) Get(key string) (string, error) {
+	return get(p.db, kvKey(key))
+}
+
+func (p *PebbleDb) Set(key string, value string, ttl time.Duration) error {
+	return p.db.Set(kvKey(key), encodeValue(value, ttl), pebble.Sync)
+}
+
+func (p *PebbleDb) Delete(key string) error {
+	return p.db.Delete(kvKey(key), pebble.Sync)
+}
+
+func (p *PebbleDb) Exists(key string) (bool, error) {
+	_, err := p.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Expire 设置key的过期时间
+// 参数：
+//
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (p *PebbleDb) Expire(key string, ttl time.Duration) error {
+	val, err := p.Get(key)
+	if err != nil {
+		return err
+	}
+	return p.Set(key, val, ttl)
+}
+
+// TTL 获取key的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在或已过期时返回TTLNotFound
+//	error - 操作错误
+func (p *PebbleDb) TTL(key string) (time.Duration, error) {
+	data, closer, err := p.db.Get(kvKey(key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return _interface.TTLNotFound, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+	return remainingTTL(data), nil
+}
+
+// SetNX key不存在或已过期时设置key-value并设置过期时间
+// 读取和写入在同一个key的锁内执行，与其他SetNX/GetSet/GetDel互斥
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (p *PebbleDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	_, err := p.Get(key)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, err
+	}
+	return true, p.Set(key, value, ttl)
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 读取和写入在同一个key的锁内执行，与其他SetNX/GetSet/GetDel互斥
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (p *PebbleDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	current, err := p.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil || current != oldValue {
+		return false, err
+	}
+	return true, p.Set(key, newValue, ttl)
+}
+
+// GetSet 设置新值并返回旧值，新值不过期
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (p *PebbleDb) GetSet(key string, value string) (string, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	old, err := p.Get(key)
+	if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return "", err
+	}
+	if setErr := p.Set(key, value, 0); setErr != nil {
+		return "", setErr
+	}
+	return old, err
+}
+
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (p *PebbleDb) GetDel(key string) (string, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	val, err := p.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return val, p.Delete(key)
+}
+
+// Append 将value追加到key的值末尾，key不存在时创建不过期的key，已有的过期时间保持不变
+// 读取和写入在同一个key的锁内执行，与其他SetNX/GetSet/GetDel/Append互斥
+// 参数：
+//
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (p *PebbleDb) Append(key string, value string) (int64, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	data, closer, err := p.db.Get(kvKey(key))
+	if err != nil && !errors.Is(err, pebble.ErrNotFound) {
+		return 0, err
+	}
+	if _, ok := decodeValue(data); ok {
+		// 保留编码在值开头的过期时间，Get返回的切片在Close之后失效
+		data = append(bytes.Clone(data), value...)
+	} else {
+		data = encodeValue(value, 0)
+	}
+	if closer != nil {
+		closer.Close()
+	}
+	if err := p.db.Set(kvKey(key), data, pebble.Sync); err != nil {
+		return 0, err
+	}
+	return int64(len(data) - 8), nil
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在或已过期时返回0
+func (p *PebbleDb) StrLen(key string) (int64, error) {
+	data, closer, err := p.db.Get(kvKey(key))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer closer.Close()
+	if _, ok := decodeValue(data); !ok {
+		return 0, nil
+	}
+	return int64(len(data) - 8), nil
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (p *PebbleDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := p.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return p.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (p *PebbleDb) GetObject(key string, v any) error {
+	raw, err := p.Get(key)
+	if err != nil {
+		return err
+	}
+	return p.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys 遍历匹配pattern的key，通配符语法与Redis一致
+// 只遍历键值，不包括哈希表、队列和集合；每批读取scanBatchSize个key后关闭迭代器再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) Keys(pattern string, fn func(key string) bool) error {
+	prefix := kvKey(_interface.PatternPrefix(pattern))
+	start := prefix
+	for {
+		keys, _, next, err := p.scan(prefix, start, false)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		start = next
+	}
+}
+
+// scan 从start开始读取最多scanBatchSize个以prefix开头的键值，跳过已过期的key
+// 返回未过期的key、withValues为true时对应的值，以及下一批的起始位置，遍历结束时next为nil
+func (p *PebbleDb) scan(prefix, start []byte, withValues bool) (keys, values []string, next []byte, err error) {
+	opts := prefixOptions(kvKey(""))
+	opts.LowerBound = start
+	iter, err := p.db.NewIter(opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer iter.Close()
+
+	scanned := 0
+	for iter.First(); iter.Valid() && bytes.HasPrefix(iter.Key(), prefix); iter.Next() {
+		if scanned == scanBatchSize {
+			next = bytes.Clone(iter.Key())
+			break
+		}
+		scanned++
+		if value, ok := decodeValue(iter.Value()); ok {
+			keys = append(keys, string(iter.Key()[len(kvKey("")):]))
+			if withValues {
+				values = append(values, value)
+			}
+		}
+	}
+	return keys, values, next, iter.Error()
+}
+
+// Iterate 遍历键名以prefix开头的键值
+// 只遍历键值，不包括哈希表、队列和集合；每批读取scanBatchSize个键值后关闭迭代器再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	start := kvKey(prefix)
+	for {
+		keys, values, next, err := p.scan(kvKey(prefix), start, true)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			if !fn(key, values[i]) {
+				return nil
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		start = next
+	}
+}
+
+// DeleteByPrefix 删除所有以prefix开头的key，包括键值、哈希表、队列和集合
+// 每个命名空间使用一次范围删除，所有范围在同一个批次中原子提交
+// 参数：
+//
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) DeleteByPrefix(prefix string) error {
+	batch := p.db.NewBatch()
+	defer batch.Close()
+
+	for _, start := range [][]byte{kvKey(prefix), append([]byte{hashPrefix, 0}, prefix...), append([]byte{listPrefix, 0}, prefix...), append([]byte{setPrefix, 0}, prefix...)} {
+		if err := batch.DeleteRange(start, prefixEnd(start), nil); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或已过期时返回ErrKeyNotFound
+func (p *PebbleDb) HGet(key, field string) (string, error) {
+	return get(p.db, hashKey(key, field))
+}
+
+func (p *PebbleDb) HSet(key, field, value string, ttl time.Duration) error {
+	return p.db.Set(hashKey(key, field), encodeValue(value, ttl), pebble.Sync)
+}
+
+func (p *PebbleDb) HDel(key, field string) error {
+	return p.db.Delete(hashKey(key, field), pebble.Sync)
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有未过期的字段和值的映射
+//	error - 操作错误
+func (p *PebbleDb) HGetAll(key string) (map[string]string, error) {
+	prefix := hashKey(key, "")
+	iter, err := p.db.NewIter(prefixOptions(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	result := make(map[string]string)
+	for iter.First(); iter.Valid(); iter.Next() {
+		val, ok := decodeValue(iter.Value())
+		if !ok {
+			continue
+		}
+		result[string(iter.Key()[len(prefix):])] = val
+	}
+	return result, iter.Error()
+}
+
+// HLen 获取哈希表中未过期的field数量，哈希表不存在时返回0
+func (p *PebbleDb) HLen(key string) (int64, error) {
+	fields, err := p.HKeys(key)
+	return int64(len(fields)), err
+}
+
+// HExists 判断哈希表中是否存在未过期的field
+func (p *PebbleDb) HExists(key, field string) (bool, error) {
+	_, err := p.HGet(key, field)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HKeys 获取哈希表中所有未过期的field，按字段名排序
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (p *PebbleDb) HKeys(key string) ([]string, error) {
+	prefix := hashKey(key, "")
+	iter, err := p.db.NewIter(prefixOptions(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	fields := []string{}
+	for iter.First(); iter.Valid(); iter.Next() {
+		if _, ok := decodeValue(iter.Value()); ok {
+			fields = append(fields, string(iter.Key()[len(prefix):]))
+		}
+	}
+	return fields, iter.Error()
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) SAdd(key, member string) error {
+	return p.db.Set(setKey(key, member), nil, pebble.Sync)
+}
+
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) SRem(key, member string) error {
+	return p.db.Delete(setKey(key, member), pebble.Sync)
+}
+
+// SMembers 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (p *PebbleDb) SMembers(key string) ([]string, error) {
+	prefix := setKey(key, "")
+	iter, err := p.db.NewIter(prefixOptions(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	members := []string{}
+	for iter.First(); iter.Valid(); iter.Next() {
+		members = append(members, string(iter.Key()[len(prefix):]))
+	}
+	return members, iter.Error()
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (p *PebbleDb) SIsMember(key, member string) (bool, error) {
+	_, closer, err := p.db.Get(setKey(key, member))
+	if errors.Is(err, pebble.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+// Push 添加元素到列表尾部
+func (p *PebbleDb) Push(key string, value string) error {
+	return p.RPush(key, value)
+}
+
+// LPush 将元素插入到列表头部
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) LPush(key string, value string) error {
+	p.lock(key)
+	defer p.unlock(key)
+
+	pos, ok, err := p.listEnd(key, true)
+	if err != nil {
+		return err
+	}
+	if ok {
+		pos--
+	}
+	return p.db.Set(listKey(key, pos), []byte(value), pebble.Sync)
+}
+
+// RPush 将元素插入到列表尾部
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) RPush(key string, value string) error {
+	p.lock(key)
+	defer p.unlock(key)
+
+	pos, ok, err := p.listEnd(key, false)
+	if err != nil {
+		return err
+	}
+	if ok {
+		pos++
+	}
+	return p.db.Set(listKey(key, pos), []byte(value), pebble.Sync)
+}
+
+// RPushCapped 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+// 参数：
+//
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) RPushCapped(key string, value string, maxLen int64) error {
+	p.lock(key)
+	defer p.unlock(key)
+
+	positions, _, err := p.listItems(key)
+	if err != nil {
+		return err
+	}
+	pos, ok, err := p.listEnd(key, false)
+	if err != nil {
+		return err
+	}
+	if ok {
+		pos++
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	if err := batch.Set(listKey(key, pos), []byte(value), nil); err != nil {
+		return err
+	}
+	if maxLen > 0 {
+		for _, old := range positions[:max(int64(len(positions))+1-maxLen, 0)] {
+			if err := batch.Delete(old, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// Pop 移除并返回列表第一个元素
+func (p *PebbleDb) Pop(key string) (string, error) {
+	return p.LPop(key)
+}
+
+// LPop 弹出列表头部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (p *PebbleDb) LPop(key string) (string, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return "", err
+	}
+	return p.pop(key, true)
+}
+
+// RPop 弹出列表尾部元素
+// 参数：
+//
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (p *PebbleDb) RPop(key string) (string, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return "", err
+	}
+	return p.pop(key, false)
+}
+
+// PopAll 取出并清空整个列表
+func (p *PebbleDb) PopAll(key string) ([]string, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return nil, err
+	}
+
+	p.lock(key)
+	defer p.unlock(key)
+
+	opts := prefixOptions(listPrefixKey(key))
+	iter, err := p.db.NewIter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	for iter.First(); iter.Valid(); iter.Next() {
+		result = append(result, string(iter.Value()))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return result, nil
+	}
+	return result, p.db.DeleteRange(opts.LowerBound, opts.UpperBound, pebble.Sync)
+}
+
+// Len 获取列表长度
+func (p *PebbleDb) Len(key string) (int64, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return 0, err
+	}
+
+	prefix := listPrefixKey(key)
+	iter, err := p.db.NewIter(prefixOptions(prefix))
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Close()
+
+	var length int64
+	for iter.First(); iter.Valid(); iter.Next() {
+		length++
+	}
+	return length, iter.Error()
+}
+
+// LRange 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (p *PebbleDb) LRange(key string, start, stop int64) ([]string, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return nil, err
+	}
+
+	_, items, err := p.listItems(key)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := _interface.ListRange(start, stop, int64(len(items)))
+	return append([]string{}, items[lo:hi]...), nil
+}
+
+// LIndex 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (p *PebbleDb) LIndex(key string, index int64) (string, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return "", err
+	}
+
+	_, items, err := p.listItems(key)
+	if err != nil {
+		return "", err
+	}
+	i, ok := _interface.ListIndex(index, int64(len(items)))
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return items[i], nil
+}
+
+// LRem 删除列表中等于value的元素
+// 保留的元素按顺序写回原有的前len(kept)个位置，多余的位置被删除，元素之间的相对顺序不变
+// 参数：
+//
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (p *PebbleDb) LRem(key string, count int64, value string) (int64, error) {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return 0, err
+	}
+
+	p.lock(key)
+	defer p.unlock(key)
+
+	positions, items, err := p.listItems(key)
+	if err != nil {
+		return 0, err
+	}
+	kept, removed := _interface.ListRem(items, count, value)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	for i, pos := range positions {
+		if i < len(kept) {
+			err = batch.Set(pos, []byte(kept[i]), nil)
+		} else {
+			err = batch.Delete(pos, nil)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return removed, batch.Commit(pebble.Sync)
+}
+
+// LTrim 只保留列表中下标在[start, stop]范围内的元素
+func (p *PebbleDb) LTrim(key string, start, stop int64) error {
+	if err := p.delayQueue.Promote(p, key); err != nil {
+		return err
+	}
+
+	p.lock(key)
+	defer p.unlock(key)
+
+	positions, _, err := p.listItems(key)
+	if err != nil || len(positions) == 0 {
+		return err
+	}
+	lo, hi := _interface.ListRange(start, stop, int64(len(positions)))
+
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	for i, pos := range positions {
+		if int64(i) >= lo && int64(i) < hi {
+			continue
+		}
+		if err := batch.Delete(pos, nil); err != nil {
+			return err
+		}
+	}
+	return batch.Commit(pebble.Sync)
+}
+
+// listItems 按从头到尾的顺序读取列表所有元素的键和值
+func (p *PebbleDb) listItems(key string) ([][]byte, []string, error) {
+	iter, err := p.db.NewIter(prefixOptions(listPrefixKey(key)))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Close()
+
+	var positions [][]byte
+	var items []string
+	for iter.First(); iter.Valid(); iter.Next() {
+		positions = append(positions, append([]byte{}, iter.Key()...))
+		items = append(items, string(iter.Value()))
+	}
+	return positions, items, iter.Error()
+}
+
+// PopAck 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，进程重启后仍然有效
+// 参数：
+//
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (p *PebbleDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return p.ackQueue.PopAck(p, key, visibility)
+}
+
+// Ack 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (p *PebbleDb) Ack(key, receipt string) error {
+	return p.ackQueue.Ack(p, key, receipt)
+}
+
+// PushDelayed 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，进程重启后仍然有效；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) PushDelayed(key, value string, delay time.Duration) error {
+	return p.delayQueue.PushDelayed(p, key, value, delay)
+}
+
+// pebbleTx Pebble事务实现，操作写入带索引的Batch，提交时原子生效
+// 事务内的读取可以看到事务内尚未提交的写入
+type pebbleTx struct {
+	batch *pebble.Batch
+	p     *PebbleDb
+	watch _interface.WatchSnapshot // BeginTxWatch监视的key，为nil表示不监视
+}
+
+func (tx *pebbleTx) Get(key string) (string, error) {
+	return get(tx.batch, kvKey(key))
+}
+
+func (tx *pebbleTx) Exists(key string) (bool, error) {
+	_, err := tx.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (tx *pebbleTx) Set(key string, value string, ttl time.Duration) error {
+	return tx.batch.Set(kvKey(key), encodeValue(value, ttl), nil)
+}
+
+func (tx *pebbleTx) Delete(key string) error {
+	return tx.batch.Delete(kvKey(key), nil)
+}
+
+// Expire key 不存在时不做任何操作
+func (tx *pebbleTx) Expire(key string, ttl time.Duration) error {
+	val, err := tx.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.batch.Set(kvKey(key), encodeValue(val, ttl), nil)
+}
+
+func (tx *pebbleTx) HSet(key, field, value string, ttl time.Duration) error {
+	return tx.batch.Set(hashKey(key, field), encodeValue(value, ttl), nil)
+}
+
+func (tx *pebbleTx) HDel(key, field string) error {
+	return tx.batch.Delete(hashKey(key, field), nil)
+}
+
+// Commit 提交Batch
+// 监视了key时在这些key的锁内比较并提交，监视的key被修改时返回ErrTxConflict
+func (tx *pebbleTx) Commit() error {
+	defer tx.batch.Close()
+
+	// 按顺序加锁，避免两个监视事务互相等待
+	keys := tx.watch.Keys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		tx.p.lock(key)
+		defer tx.p.unlock(key)
+	}
+	if err := tx.watch.Check(tx.p.Get); err != nil {
+		return err
+	}
+	return tx.batch.Commit(pebble.Sync)
+}
+
+func (tx *pebbleTx) Rollback() error {
+	return tx.batch.Close()
+}
+
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) Publish(channel string, payload string) error {
+	return p.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (p *PebbleDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return p.broker.Subscribe(channel)
+}
+
+// SubscribeExpired 订阅键值数据的过期事件
+// 订阅期间后台每秒扫描一次键值数据的过期时间；哈希表的过期不通知
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (p *PebbleDb) SubscribeExpired() (<-chan string, func()) {
+	return p.expiry.Subscribe(p.sweepExpired)
+}
+
+// sweepExpired 返回过期时间在(since, until]之间的键
+// 读取时已经过滤了过期数据，这里只读取不删除
+func (p *PebbleDb) sweepExpired(since, until time.Time) []string {
+	iter, err := p.db.NewIter(prefixOptions(kvKey("")))
+	if err != nil {
+		return nil
+	}
+	defer iter.Close()
+
+	lower, upper := uint64(since.UnixNano()), uint64(until.UnixNano())
+	var keys []string
+	for iter.First(); iter.Valid(); iter.Next() {
+		if value := iter.Value(); len(value) >= 8 {
+			if expiresAt := binary.BigEndian.Uint64(value); expiresAt > lower && expiresAt <= upper {
+				keys = append(keys, string(iter.Key()[len(kvKey("")):]))
+			}
+		}
+	}
+	return keys
+}
+
+func (p *PebbleDb) BeginTx() (_interface.Tx, error) {
+	return &pebbleTx{batch: p.db.NewIndexedBatch(), p: p}, nil
+}
+
+// BeginTxWatch 开启监视keys的事务，提交时比较keys的值，与开启时不同则返回ErrTxConflict
+// 与SetNX一样，比较和提交只与同一个key上的SetNX/GetSet/GetDel/CAS和其他监视事务互斥
+func (p *PebbleDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	watch, err := _interface.NewWatchSnapshot(p.Get, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &pebbleTx{batch: p.db.NewIndexedBatch(), p: p, watch: watch}, nil
+}
+
+// get 从数据库或事务的Batch中读取并解码值，过期的值视为不存在
+func get(r pebble.Reader, key []byte) (string, error) {
+	data, closer, err := r.Get(key)
+	// 统一错误处理：将Pebble特定错误转换为接口标准错误
+	if errors.Is(err, pebble.ErrNotFound) {
+		return "", _interface.ErrKeyNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	val, ok := decodeValue(data)
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// pop 删除并返回列表头部（head为true）或尾部的元素
+func (p *PebbleDb) pop(key string, head bool) (string, error) {
+	p.lock(key)
+	defer p.unlock(key)
+
+	pos, ok, err := p.listEnd(key, head)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+
+	elemKey := listKey(key, pos)
+	data, closer, err := p.db.Get(elemKey)
+	if err != nil {
+		return "", err
+	}
+	val := string(data)
+	_ = closer.Close()
+
+	return val, p.db.Delete(elemKey, pebble.Sync)
+}
+
+// listEnd 返回列表头部（head为true）或尾部元素的位置，列表为空时ok为false
+func (p *PebbleDb) listEnd(key string, head bool) (pos int64, ok bool, err error) {
+	prefix := listPrefixKey(key)
+	iter, err := p.db.NewIter(prefixOptions(prefix))
+	if err != nil {
+		return 0, false, err
+	}
+	defer iter.Close()
+
+	if head {
+		ok = iter.First()
+	} else {
+		ok = iter.Last()
+	}
+	if !ok {
+		return 0, false, iter.Error()
+	}
+	return decodePos(iter.Key()[len(prefix):]), true, nil
+}
+
+func (p *PebbleDb) lock(key string) {
+	actual, _ := p.queueMutex.LoadOrStore(key, &sync.Mutex{})
+	mutex := actual.(*sync.Mutex)
+	mutex.Lock()
+}
+
+func (p *PebbleDb) unlock(key string) {
+	if actual, ok := p.queueMutex.Load(key); ok {
+		mutex := actual.(*sync.Mutex)
+		mutex.Unlock()
+	}
+}
+
+func kvKey(key string) []byte {
+	return append([]byte{kvPrefix, 0}, key...)
+}
+
+func hashKey(key, field string) []byte {
+	b := append([]byte{hashPrefix, 0}, key...)
+	b = append(b, 0)
+	return append(b, field...)
+}
+
+func setKey(key, member string) []byte {
+	b := append([]byte{setPrefix, 0}, key...)
+	b = append(b, 0)
+	return append(b, member...)
+}
+
+// listPrefixKey 生成队列所有元素共同的键前缀
+func listPrefixKey(key string) []byte {
+	b := append([]byte{listPrefix, 0}, key...)
+	return append(b, 0)
+}
+
+// listKey 生成队列元素的键，位置编码为8字节大端序并翻转符号位，使负数排在正数之前
+func listKey(key string, pos int64) []byte {
+	return binary.BigEndian.AppendUint64(listPrefixKey(key), uint64(pos)^(1<<63))
+}
+
+func decodePos(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63))
+}
+
+// prefixOptions 返回只遍历指定前缀的迭代器选项
+func prefixOptions(prefix []byte) *pebble.IterOptions {
+	lower := prefix
+	upper := bytes.Clone(lower)
+	// 前缀以\x00结尾，末字节加1即为上界
+	upper[len(upper)-1]++
+	return &pebble.IterOptions{LowerBound: lower, UpperBound: upper}
+}
+
+// prefixEnd 返回所有以prefix开头的键的上界，即prefix之后第一个不以prefix开头的键
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// 前缀全部为0xff时没有上界，命名空间前缀保证不会出现这种情况
+	return nil
+}
+
+// encodeValue 编码值，前8字节为过期时间（Unix纳秒，0表示不过期）
+func encodeValue(value string, ttl time.Duration) []byte {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+	b := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(value)), uint64(expiresAt))
+	return append(b, value...)
+}
+
+// decodeValue 解码值，已过期或格式错误时ok为false
+func decodeValue(data []byte) (string, bool) {
+	if len(data) < 8 {
+		return "", false
+	}
+	expiresAt := binary.BigEndian.Uint64(data)
+	if expiresAt != 0 && expiresAt <= uint64(time.Now().UnixNano()) {
+		return "", false
+	}
+	return string(data[8:]), true
+}
+
+// remainingTTL 根据编码后的值计算剩余过期时间
+func remainingTTL(data []byte) time.Duration {
+	if _, ok := decodeValue(data); !ok {
+		return _interface.TTLNotFound
+	}
+	expiresAt := int64(binary.BigEndian.Uint64(data))
+	if expiresAt == 0 {
+		return _interface.TTLNoExpiry
+	}
+	if ttl := time.Until(time.Unix(0, expiresAt)); ttl > 0 {
+		return ttl
+	}
+	return _interface.TTLNotFound
+}
+
+// Stats 返回Pebble的统计信息
+// 键数量为SST文件中的条目数减去删除标记数，不包括尚未落盘的内存表，且包含哈希表、队列和集合的键
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 操作错误
+func (p *PebbleDb) Stats() (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverPebble)
+	metrics := p.db.Metrics()
+	stats.DiskBytes = int64(metrics.DiskSpaceUsage())
+	stats.MemoryBytes = int64(metrics.MemTable.Size) + metrics.BlockCache.Size
+	for level, lm := range metrics.Levels {
+		stats.Levels = append(stats.Levels, _interface.LevelStats{Level: level, Tables: lm.NumFiles, Bytes: lm.Size})
+	}
+
+	tables, err := p.db.SSTables(pebble.WithProperties())
+	if err != nil {
+		return stats, err
+	}
+	var keys int64
+	for _, level := range tables {
+		for _, table := range level {
+			keys += int64(table.Properties.NumEntries) - int64(table.Properties.NumDeletions)
+		}
+	}
+	stats.Keys = max(keys, 0)
+	return stats, nil
+}
+
+// restoreBatchSize Restore时每个Batch包含的记录数
+const restoreBatchSize = 1000
+
+// Backup 将所有内部键值原样写入记录流，值中已包含过期时间
+// 参数：
+//
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (p *PebbleDb) Backup(w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverPebble)
+	if err != nil {
+		return err
+	}
+	iter, err := p.db.NewIter(nil)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.First(); iter.Valid(); iter.Next() {
+		if err := bw.WriteRecord(_interface.BackupRecord{Key: iter.Key(), Value: iter.Value()}); err != nil {
+			return err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Restore 按批写入备份中的记录，每批原子提交
+// 参数：
+//
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是Pebble的备份时返回ErrBackupFormat
+func (p *PebbleDb) Restore(r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer p.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverPebble)
+	if err != nil {
+		return err
+	}
+	for {
+		done, err := p.restoreBatch(br)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// restoreBatch 读取最多restoreBatchSize条记录并提交，读到流末尾时done为true
+func (p *PebbleDb) restoreBatch(br *_interface.BackupReader) (done bool, err error) {
+	batch := p.db.NewBatch()
+	defer batch.Close()
+
+	for batch.Count() < restoreBatchSize {
+		rec, err := br.ReadRecord()
+		if err == io.EOF {
+			done = true
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := batch.Set(rec.Key, rec.Value, nil); err != nil {
+			return false, err
+		}
+	}
+	return done, batch.Commit(pebble.Sync)
+}
+
+func NewPebbleStore(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	db, err := pebble.Open(config.Path, &pebble.Options{})
+	if err != nil {
+		return nil, err
+	}
+	p := &PebbleDb{db: db, codec: codec}
+	p.CtxAdapter = _interface.NewCtxAdapter(p)
+	return p, nil
+}
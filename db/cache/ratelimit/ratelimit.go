@@ -0,0 +1,284 @@
+// ratelimit包：基于缓存的限流器
+// 限流状态保存在任意Cache中，使用同一个共享缓存（如Redis）的多个进程共享限额，
+// 插件宿主和HTTP服务可以使用同一套限流实现
+//
+// 主要特性：
+// - 令牌桶（TokenBucket）：允许不超过容量的突发请求，令牌按固定速率补充
+// - 滑动窗口（SlidingWindow）：按上一个窗口和当前窗口的计数加权估算最近一个窗口内的请求数，没有固定窗口边界处的突发
+// - 返回是否允许、剩余请求数和被拒绝时需要等待的时间（可直接用于HTTP的Retry-After）
+// - 状态通过Get和CAS更新，CAS冲突时重新读取并重试，适用于所有支持CAS的驱动
+// - 状态的过期时间为恢复到初始状态所需的时间，空闲的键不会一直占用缓存
+//
+// 时间取自调用方进程的本地时钟，多个进程共享限额时各进程的时钟应当同步
+//
+// 作者: gophertool
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// maxRetries CAS冲突时的最大重试次数
+const maxRetries = 32
+
+var (
+	// ErrInvalidState 键中保存的不是该限流器的状态
+	ErrInvalidState = errors.New("ratelimit: invalid limiter state")
+)
+
+// Result 一次限流判断的结果
+type Result struct {
+	Allowed    bool          // 是否允许本次请求
+	Remaining  int64         // 本次请求之后还允许的请求数
+	RetryAfter time.Duration // 被拒绝时至少需要等待的时间，允许时为0
+}
+
+// Limiter 限流器接口，TokenBucket和SlidingWindow都实现了该接口
+type Limiter interface {
+	// Allow 判断key的一次请求是否允许，允许时计入限额
+	Allow(key string) (Result, error)
+	// AllowN 判断key的n次请求是否允许，允许时全部计入限额，拒绝时都不计入
+	AllowN(key string, n int64) (Result, error)
+}
+
+// store 限流状态的读写，通过CAS保证并发更新不会丢失
+type store struct {
+	c      _interface.Cache
+	prefix string
+	now    func() time.Time
+}
+
+// update 读取状态并调用fn计算新状态，fn返回的ttl大于0时写入新状态，CAS冲突时重试
+func (s *store) update(key string, fn func(state string, found bool, now time.Time) (string, time.Duration, Result, error)) (Result, error) {
+	key = s.prefix + key
+	for range maxRetries {
+		old, err := s.c.Get(key)
+		found := err == nil
+		if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+			return Result{}, err
+		}
+
+		state, ttl, res, err := fn(old, found, s.now())
+		if err != nil || ttl <= 0 {
+			return res, err
+		}
+		var ok bool
+		if found {
+			ok, err = s.c.CAS(key, old, state, ttl)
+		} else {
+			ok, err = s.c.SetNX(key, state, ttl)
+		}
+		if err != nil || ok {
+			return res, err
+		}
+	}
+	return Result{}, _interface.ErrTxConflict
+}
+
+// checkN 检查一次请求的数量，超过限额的请求永远不会被允许
+func checkN(n, limit int64) error {
+	if n <= 0 || n > limit {
+		return fmt.Errorf("ratelimit: n must be in [1, %d], got %d", limit, n)
+	}
+	return nil
+}
+
+// parseState 解析以:分隔的数值状态
+func parseState(state string, n int) ([]float64, error) {
+	parts := strings.Split(state, ":")
+	if len(parts) != n {
+		return nil, ErrInvalidState
+	}
+	values := make([]float64, n)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, ErrInvalidState
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ceilDuration 将秒数向上取整为Duration，至少为1毫秒，避免写入立即过期的状态
+func ceilDuration(seconds float64) time.Duration {
+	return max(time.Duration(math.Ceil(seconds*float64(time.Second))), time.Millisecond)
+}
+
+// TokenBucket 令牌桶限流器
+// 桶的容量为limit，每period补充limit个令牌；每次请求消耗一个令牌，桶中没有足够的令牌时拒绝
+type TokenBucket struct {
+	store
+	limit int64
+	rate  float64 // 每秒补充的令牌数
+}
+
+// 确保实现了限流器接口
+var _ Limiter = (*TokenBucket)(nil)
+
+// NewTokenBucket 创建令牌桶限流器，状态保存在"ratelimit:tb:"+key中
+// 参数：
+//
+//	c - 保存状态的缓存实例
+//	limit - 桶的容量，即允许的最大突发请求数
+//	period - 补充limit个令牌所需的时间，如limit为100、period为1分钟表示平均每分钟100次
+//
+// 返回值：
+//
+//	*TokenBucket - 令牌桶限流器
+func NewTokenBucket(c _interface.Cache, limit int64, period time.Duration) *TokenBucket {
+	return &TokenBucket{
+		store: store{c: c, prefix: "ratelimit:tb:", now: time.Now},
+		limit: limit,
+		rate:  float64(limit) / period.Seconds(),
+	}
+}
+
+// Allow 判断key的一次请求是否允许
+func (b *TokenBucket) Allow(key string) (Result, error) {
+	return b.AllowN(key, 1)
+}
+
+// AllowN 判断key的n次请求是否允许
+// 参数：
+//
+//	key - 限流的对象，如用户ID、客户端IP或插件名
+//	n - 请求数，范围为[1, limit]
+//
+// 返回值：
+//
+//	Result - 判断结果
+//	error - n无效、缓存操作错误或CAS冲突次数过多时返回ErrTxConflict
+func (b *TokenBucket) AllowN(key string, n int64) (Result, error) {
+	if err := checkN(n, b.limit); err != nil {
+		return Result{}, err
+	}
+	return b.update(key, func(state string, found bool, now time.Time) (string, time.Duration, Result, error) {
+		// 状态为"剩余令牌数:上次更新的UnixNano"，不存在时桶是满的
+		tokens := float64(b.limit)
+		if found {
+			values, err := parseState(state, 2)
+			if err != nil {
+				return "", 0, Result{}, err
+			}
+			elapsed := max(now.Sub(time.Unix(0, int64(values[1]))).Seconds(), 0)
+			tokens = min(values[0]+elapsed*b.rate, float64(b.limit))
+		}
+
+		if tokens < float64(n) {
+			retry := ceilDuration((float64(n) - tokens) / b.rate)
+			return "", 0, Result{Remaining: int64(tokens), RetryAfter: retry}, nil
+		}
+		tokens -= float64(n)
+		// 令牌补满之后状态与不存在时相同，可以过期
+		ttl := ceilDuration((float64(b.limit) - tokens) / b.rate)
+		state = formatFloat(tokens) + ":" + strconv.FormatInt(now.UnixNano(), 10)
+		return state, ttl, Result{Allowed: true, Remaining: int64(tokens)}, nil
+	})
+}
+
+// SlidingWindow 滑动窗口限流器
+// 任意长度为window的时间段内最多允许limit次请求；按上一个窗口和当前窗口的计数加权估算，
+// 假设上一个窗口内的请求均匀分布
+type SlidingWindow struct {
+	store
+	limit  int64
+	window time.Duration
+}
+
+// 确保实现了限流器接口
+var _ Limiter = (*SlidingWindow)(nil)
+
+// NewSlidingWindow 创建滑动窗口限流器，状态保存在"ratelimit:sw:"+key中
+// 参数：
+//
+//	c - 保存状态的缓存实例
+//	limit - 一个窗口内允许的请求数
+//	window - 窗口长度
+//
+// 返回值：
+//
+//	*SlidingWindow - 滑动窗口限流器
+func NewSlidingWindow(c _interface.Cache, limit int64, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{
+		store:  store{c: c, prefix: "ratelimit:sw:", now: time.Now},
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow 判断key的一次请求是否允许
+func (w *SlidingWindow) Allow(key string) (Result, error) {
+	return w.AllowN(key, 1)
+}
+
+// AllowN 判断key的n次请求是否允许
+// 参数：
+//
+//	key - 限流的对象，如用户ID、客户端IP或插件名
+//	n - 请求数，范围为[1, limit]
+//
+// 返回值：
+//
+//	Result - 判断结果
+//	error - n无效、缓存操作错误或CAS冲突次数过多时返回ErrTxConflict
+func (w *SlidingWindow) AllowN(key string, n int64) (Result, error) {
+	if err := checkN(n, w.limit); err != nil {
+		return Result{}, err
+	}
+	return w.update(key, func(state string, found bool, now time.Time) (string, time.Duration, Result, error) {
+		// 状态为"当前窗口开始的UnixNano:上一个窗口的计数:当前窗口的计数"
+		start := now.Truncate(w.window)
+		var prev, curr float64
+		if found {
+			values, err := parseState(state, 3)
+			if err != nil {
+				return "", 0, Result{}, err
+			}
+			switch saved := time.Unix(0, int64(values[0])); {
+			case saved.Equal(start):
+				prev, curr = values[1], values[2]
+			case saved.Add(w.window).Equal(start):
+				prev = values[2]
+			}
+		}
+
+		limit := float64(w.limit)
+		elapsed := now.Sub(start).Seconds()
+		window := w.window.Seconds()
+		count := prev*(1-elapsed/window) + curr
+		if count+float64(n) > limit {
+			return "", 0, Result{Remaining: int64(max(limit-count, 0)), RetryAfter: w.retryAfter(prev, curr, float64(n), elapsed)}, nil
+		}
+
+		curr += float64(n)
+		// 两个窗口之后状态中的计数不再有影响
+		ttl := start.Add(2 * w.window).Sub(now)
+		state = strconv.FormatInt(start.UnixNano(), 10) + ":" + formatFloat(prev) + ":" + formatFloat(curr)
+		return state, ttl, Result{Allowed: true, Remaining: int64(limit - count - float64(n))}, nil
+	})
+}
+
+// retryAfter 计算加权计数降到允许n次请求所需的时间
+// 当前窗口内的计数足够时等待上一个窗口的权重下降，否则等到下一个窗口，由当前窗口的计数作为上一个窗口的计数
+func (w *SlidingWindow) retryAfter(prev, curr, n, elapsed float64) time.Duration {
+	limit := float64(w.limit)
+	window := w.window.Seconds()
+	if curr+n <= limit {
+		weight := (limit - curr - n) / prev
+		return ceilDuration(window*(1-weight) - elapsed)
+	}
+	weight := (limit - n) / curr
+	return ceilDuration(window - elapsed + window*(1-weight))
+}
@@ -0,0 +1,144 @@
+// ratelimit包的测试文件
+// 测试令牌桶和滑动窗口的允许、拒绝、等待时间和状态过期
+//
+// 作者: gophertool
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	// 导入内存缓存实现以确保驱动注册
+	_ "github.com/gophertool/tool/db/cache/memory"
+)
+
+// fakeClock 测试使用的可控时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newCache(t *testing.T) _interface.Cache {
+	cache, err := _interface.New(config.Cache{Driver: config.CacheDriverMemory})
+	if err != nil {
+		t.Fatalf("创建内存缓存失败: %v", err)
+	}
+	t.Cleanup(cache.Close)
+	return cache
+}
+
+// TestTokenBucket 测试令牌桶
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	bucket := NewTokenBucket(newCache(t), 3, 3*time.Second)
+	bucket.now = clock.now
+
+	for i := 0; i < 3; i++ {
+		res, err := bucket.Allow("user:1")
+		if err != nil || !res.Allowed || res.Remaining != int64(2-i) {
+			t.Fatalf("第%d次请求应被允许，剩余%d，实际: %+v, %v", i+1, 2-i, res, err)
+		}
+	}
+	res, err := bucket.Allow("user:1")
+	if err != nil || res.Allowed || res.RetryAfter != time.Second {
+		t.Errorf("令牌用完后应拒绝并等待1秒，实际: %+v, %v", res, err)
+	}
+	if res, _ := bucket.Allow("user:2"); !res.Allowed {
+		t.Error("不同的key应使用各自的令牌桶")
+	}
+
+	clock.advance(time.Second)
+	if res, _ := bucket.Allow("user:1"); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("1秒后应补充1个令牌，实际: %+v", res)
+	}
+	if res, _ := bucket.AllowN("user:1", 2); res.Allowed || res.RetryAfter != 2*time.Second {
+		t.Errorf("请求2个令牌应等待2秒，实际: %+v", res)
+	}
+	clock.advance(time.Hour)
+	if res, _ := bucket.AllowN("user:1", 3); !res.Allowed {
+		t.Errorf("令牌应补满到容量，实际: %+v", res)
+	}
+
+	if _, err := bucket.AllowN("user:1", 4); err == nil {
+		t.Error("超过容量的请求数应返回错误")
+	}
+	bucket.c.Set("ratelimit:tb:bad", "garbage", 0)
+	if _, err := bucket.Allow("bad"); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("无效的状态应返回ErrInvalidState，实际: %v", err)
+	}
+}
+
+// TestSlidingWindow 测试滑动窗口
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0).Truncate(10 * time.Second)}
+	limiter := NewSlidingWindow(newCache(t), 4, 10*time.Second)
+	limiter.now = clock.now
+
+	for i := 0; i < 4; i++ {
+		if res, err := limiter.Allow("ip"); err != nil || !res.Allowed {
+			t.Fatalf("第%d次请求应被允许，实际: %+v, %v", i+1, res, err)
+		}
+	}
+	// 当前窗口已满，需要等到下一个窗口，并且上一个窗口的权重降到3/4
+	res, err := limiter.Allow("ip")
+	if err != nil || res.Allowed || res.RetryAfter != 12500*time.Millisecond {
+		t.Errorf("窗口已满时应等待12.5秒，实际: %+v, %v", res, err)
+	}
+
+	// 下一个窗口开始时上一个窗口的4次请求仍然计满
+	clock.advance(10 * time.Second)
+	if res, _ := limiter.Allow("ip"); res.Allowed {
+		t.Errorf("窗口刚滑动时不应允许，实际: %+v", res)
+	}
+	clock.advance(2500 * time.Millisecond)
+	if res, _ := limiter.Allow("ip"); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("上一个窗口的权重降到3/4后应允许1次，实际: %+v", res)
+	}
+
+	// 两个窗口之后状态过期
+	clock.advance(20 * time.Second)
+	if res, _ := limiter.AllowN("ip", 4); !res.Allowed {
+		t.Errorf("两个窗口之后应允许全部限额，实际: %+v", res)
+	}
+}
+
+// TestConcurrentAllow 测试并发请求不会超过限额
+func TestConcurrentAllow(t *testing.T) {
+	limiter := NewTokenBucket(newCache(t), 50, time.Hour)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := limiter.Allow("shared")
+			if err != nil {
+				t.Errorf("Allow操作失败: %v", err)
+				return
+			}
+			if res.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 50 {
+		t.Errorf("并发请求应恰好允许50次，实际: %d", allowed)
+	}
+}
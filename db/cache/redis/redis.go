@@ -9,20 +9,32 @@
 // - 支持持久化
 // - 原生队列操作支持
 // - 丰富的数据结构
-// - 事务支持（Pipeline）
+// - 事务支持（MULTI/EXEC）
+// - 命令管道（Pipeline），一次往返发送多个命令
+// - 发布订阅（PUBLISH/SUBSCRIBE）
 // - 集群支持
+// - Sentinel高可用（自动故障转移）
+// - 可配置的连接池大小和连接、读写超时（config.Redis）
+// - 布隆过滤器（RedisBloom的BF.RESERVE/BF.ADD/BF.EXISTS，服务端没有加载模块时由_interface改用位图实现）
 // - 分布式缓存
 //
 // 作者: gophertool
 package redis
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/go-redis/redis"
 	"github.com/gophertool/tool/db/cache/config"
 	_interface "github.com/gophertool/tool/db/cache/interface"
+	"github.com/redis/go-redis/v9"
 )
 
 // 包初始化时注册Redis驱动
@@ -30,48 +42,92 @@ func init() {
 	_interface.RegisterDriver(config.CacheDriverRedis, NewRedisClient)
 }
 
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
 // RedisDb Redis缓存实现结构体
 type RedisDb struct {
-	db *redis.Client // Redis客户端实例
+	db      *redis.Client    // Redis客户端实例
+	codec   _interface.Codec // SetObject/GetObject的编码方式
+	noBloom atomic.Bool      // 服务端没有加载RedisBloom模块
 }
 
-// LPush 将元素插入到列表左边
+// LPushContext 将元素插入到列表左边
 // 参数：
 //
+//	ctx - 上下文
 //	key - 列表键名
 //	value - 要插入的值
 //
 // 返回值：
 //
 //	error - 操作错误
-func (r *RedisDb) LPush(key string, value string) error {
-	return r.db.LPush(key, value).Err()
+func (r *RedisDb) LPushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.LPush(ctx, key, value).Err()
 }
 
-// RPush 将元素插入到列表右边
+// RPushContext 将元素插入到列表右边
 // 参数：
 //
+//	ctx - 上下文
 //	key - 列表键名
 //	value - 要插入的值
 //
 // 返回值：
 //
 //	error - 操作错误
-func (r *RedisDb) RPush(key string, value string) error {
-	return r.db.RPush(key, value).Err()
+func (r *RedisDb) RPushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.RPush(ctx, key, value).Err()
+}
+
+// RPushCappedContext 将元素插入到列表最右边，列表长度超过maxLen时从左边删除最早的元素
+// RPUSH和LTRIM在同一个MULTI事务中执行
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	value - 要插入的值
+//	maxLen - 列表的最大长度，不大于0时不限制
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if maxLen <= 0 {
+		return r.db.RPush(ctx, key, value).Err()
+	}
+	_, err := r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, value)
+		pipe.LTrim(ctx, key, -maxLen, -1)
+		return nil
+	})
+	return err
 }
 
-// LPop 弹出列表最左边的元素
+// LPopContext 弹出列表最左边的元素
 // 参数：
 //
+//	ctx - 上下文
 //	key - 列表键名
 //
 // 返回值：
 //
 //	string - 弹出的元素值
 //	error - 操作错误，列表为空时返回ErrKeyNotFound
-func (r *RedisDb) LPop(key string) (string, error) {
-	val, err := r.db.LPop(key).Result()
+func (r *RedisDb) LPopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := lpopScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli()).Text()
 	// 统一错误处理：将Redis特定错误转换为接口标准错误
 	if errors.Is(err, redis.Nil) {
 		return "", _interface.ErrKeyNotFound
@@ -79,17 +135,21 @@ func (r *RedisDb) LPop(key string) (string, error) {
 	return val, err
 }
 
-// RPop 弹出列表最右边的元素
+// RPopContext 弹出列表最右边的元素
 // 参数：
 //
+//	ctx - 上下文
 //	key - 列表键名
 //
 // 返回值：
 //
 //	string - 弹出的元素值
 //	error - 操作错误，列表为空时返回ErrKeyNotFound
-func (r *RedisDb) RPop(key string) (string, error) {
-	val, err := r.db.RPop(key).Result()
+func (r *RedisDb) RPopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := rpopScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli()).Text()
 	// 统一错误处理：将Redis特定错误转换为接口标准错误
 	if errors.Is(err, redis.Nil) {
 		return "", _interface.ErrKeyNotFound
@@ -97,17 +157,21 @@ func (r *RedisDb) RPop(key string) (string, error) {
 	return val, err
 }
 
-// Get 获取指定key的值
+// GetContext 获取指定key的值
 // 参数：
 //
+//	ctx - 上下文
 //	key - 键名
 //
 // 返回值：
 //
 //	string - 键对应的值
 //	error - 操作错误，键不存在时返回ErrKeyNotFound
-func (r *RedisDb) Get(key string) (string, error) {
-	val, err := r.db.Get(key).Result()
+func (r *RedisDb) GetContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := r.db.Get(ctx, key).Result()
 	// 统一错误处理：将Redis特定错误转换为接口标准错误
 	if errors.Is(err, redis.Nil) {
 		return "", _interface.ErrKeyNotFound
@@ -115,9 +179,10 @@ func (r *RedisDb) Get(key string) (string, error) {
 	return val, err
 }
 
-// HGet 获取哈希表中指定field的值
+// HGetContext 获取哈希表中指定field的值
 // 参数：
 //
+//	ctx - 上下文
 //	key - 哈希表键名
 //	field - 字段名
 //
@@ -125,8 +190,11 @@ func (r *RedisDb) Get(key string) (string, error) {
 //
 //	string - 字段对应的值
 //	error - 操作错误，字段不存在时返回ErrKeyNotFound
-func (r *RedisDb) HGet(key, field string) (string, error) {
-	val, err := r.db.HGet(key, field).Result()
+func (r *RedisDb) HGetContext(ctx context.Context, key, field string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := r.db.HGet(ctx, key, field).Result()
 	// 统一错误处理：将Redis特定错误转换为接口标准错误
 	if errors.Is(err, redis.Nil) {
 		return "", _interface.ErrKeyNotFound
@@ -134,152 +202,1586 @@ func (r *RedisDb) HGet(key, field string) (string, error) {
 	return val, err
 }
 
+// RedisTx Redis事务，基于MULTI/EXEC管道，命令在Commit时才执行
 type RedisTx struct {
+	ctx  context.Context // BeginTxContext传入的上下文，随事务中的命令一起传递给客户端
+	r    *RedisDb
 	pipe redis.Pipeliner
+	conn *redis.Conn // BeginTxWatch执行WATCH的连接，MULTI/EXEC必须在同一个连接上执行；为nil表示不监视
+}
+
+// Get 读取不经过事务管道，返回的是已提交的值，看不到事务内尚未执行的写入
+func (tx *RedisTx) Get(key string) (string, error) {
+	return tx.r.GetContext(tx.ctx, key)
 }
 
+// Exists 读取不经过事务管道，与Get相同
+func (tx *RedisTx) Exists(key string) (bool, error) {
+	return tx.r.ExistsContext(tx.ctx, key)
+}
+
+// Commit 执行事务，监视的key在WATCH之后被修改时Redis放弃执行，返回ErrTxConflict
 func (tx *RedisTx) Commit() error {
-	_, err := tx.pipe.Exec()
+	defer tx.release()
+	_, err := tx.pipe.Exec(tx.ctx)
+	if errors.Is(err, redis.TxFailedErr) {
+		return _interface.ErrTxConflict
+	}
 	return err
 }
 
 func (tx *RedisTx) Rollback() error {
-	return tx.pipe.Discard()
+	defer tx.release()
+	tx.pipe.Discard()
+	return nil
+}
+
+// release 取消监视并归还WATCH使用的连接，避免连接池中的连接残留监视状态
+func (tx *RedisTx) release() {
+	if tx.conn == nil {
+		return
+	}
+	_ = tx.conn.Process(tx.ctx, redis.NewStatusCmd(tx.ctx, "unwatch"))
+	_ = tx.conn.Close()
+	tx.conn = nil
 }
 
 func (tx *RedisTx) Set(key string, value string, ttl time.Duration) error {
-	return tx.pipe.Set(key, value, ttl).Err()
+	return tx.pipe.Set(tx.ctx, key, value, expiration(ttl)).Err()
 }
 
 func (tx *RedisTx) Delete(key string) error {
-	return tx.pipe.Del(key).Err()
+	return tx.pipe.Del(tx.ctx, key).Err()
 }
 
 func (tx *RedisTx) Expire(key string, ttl time.Duration) error {
-	return tx.pipe.Expire(key, ttl).Err()
+	return tx.pipe.Expire(tx.ctx, key, ttl).Err()
 }
 
 func (tx *RedisTx) HSet(key, field, value string, ttl time.Duration) error {
-	if err := tx.pipe.HSet(key, field, value).Err(); err != nil {
+	if err := tx.pipe.HSet(tx.ctx, key, field, value).Err(); err != nil {
 		return err
 	}
 	if ttl > 0 {
-		return tx.pipe.Expire(key, ttl).Err()
+		return tx.pipe.Expire(tx.ctx, key, ttl).Err()
 	}
 	return nil
 }
 
 func (tx *RedisTx) HDel(key, field string) error {
-	return tx.pipe.HDel(key, field).Err()
+	return tx.pipe.HDel(tx.ctx, key, field).Err()
+}
+
+// PipelineContext 在一次往返中执行fn排队的所有命令
+// 命令通过非事务的管道发送，不会阻塞其他客户端；fn返回错误时不发送任何命令
+// 参数：
+//
+//	ctx - 上下文
+//	fn - 排队命令的函数
+//
+// 返回值：
+//
+//	error - fn返回的错误、网络错误或第一个失败的命令的错误，键不存在不视为错误
+func (r *RedisDb) PipelineContext(ctx context.Context, fn func(p _interface.Pipeliner) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p := &redisPipeliner{ctx: ctx, pipe: r.db.Pipeline()}
+	if err := fn(p); err != nil {
+		p.pipe.Discard()
+		return err
+	}
+	if len(p.results) == 0 {
+		return nil
+	}
+	// 每个命令的错误已经记录在各自的cmd中，Exec只返回第一个失败的命令（包括redis.Nil）的错误
+	_, _ = p.pipe.Exec(ctx)
+	errs := make([]error, len(p.results))
+	for i, result := range p.results {
+		errs[i] = result()
+	}
+	return _interface.PipelineError(errs...)
+}
+
+// redisPipeliner Redis命令管道，命令在PipelineContext中一次发送
+type redisPipeliner struct {
+	ctx     context.Context
+	pipe    redis.Pipeliner
+	results []func() error // 管道执行后设置每个命令的结果
+}
+
+// pipeStatus 排队只返回错误的命令，所有go-redis命令都执行成功时才算成功
+func (p *redisPipeliner) pipeStatus(cmds ...redis.Cmder) *_interface.PipeStatusCmd {
+	cmd := _interface.NewPipeCmd[struct{}]()
+	p.results = append(p.results, func() error {
+		var err error
+		for _, c := range cmds {
+			if err = c.Err(); err != nil {
+				break
+			}
+		}
+		cmd.SetResult(struct{}{}, err)
+		return err
+	})
+	return cmd
+}
+
+// pipeString 排队返回字符串的命令，redis.Nil转换为ErrKeyNotFound
+func (p *redisPipeliner) pipeString(c *redis.StringCmd) *_interface.PipeCmd[string] {
+	cmd := _interface.NewPipeCmd[string]()
+	p.results = append(p.results, func() error {
+		val, err := c.Result()
+		if errors.Is(err, redis.Nil) {
+			err = _interface.ErrKeyNotFound
+		}
+		cmd.SetResult(val, err)
+		return err
+	})
+	return cmd
+}
+
+func (p *redisPipeliner) Get(key string) *_interface.PipeCmd[string] {
+	return p.pipeString(p.pipe.Get(p.ctx, key))
+}
+
+func (p *redisPipeliner) Set(key string, value string, ttl time.Duration) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.Set(p.ctx, key, value, expiration(ttl)))
+}
+
+func (p *redisPipeliner) Delete(key string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.Del(p.ctx, key))
+}
+
+func (p *redisPipeliner) Exists(key string) *_interface.PipeCmd[bool] {
+	c := p.pipe.Exists(p.ctx, key)
+	cmd := _interface.NewPipeCmd[bool]()
+	p.results = append(p.results, func() error {
+		count, err := c.Result()
+		cmd.SetResult(count > 0, err)
+		return err
+	})
+	return cmd
+}
+
+func (p *redisPipeliner) Expire(key string, ttl time.Duration) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.Expire(p.ctx, key, ttl))
+}
+
+func (p *redisPipeliner) TTL(key string) *_interface.PipeCmd[time.Duration] {
+	c := p.pipe.PTTL(p.ctx, key)
+	cmd := _interface.NewPipeCmd[time.Duration]()
+	p.results = append(p.results, func() error {
+		ttl, err := c.Result()
+		if err != nil {
+			cmd.SetResult(0, err)
+			return err
+		}
+		cmd.SetResult(remainingTTL(ttl), nil)
+		return nil
+	})
+	return cmd
+}
+
+func (p *redisPipeliner) HGet(key, field string) *_interface.PipeCmd[string] {
+	return p.pipeString(p.pipe.HGet(p.ctx, key, field))
+}
+
+func (p *redisPipeliner) HSet(key, field, value string, ttl time.Duration) *_interface.PipeStatusCmd {
+	cmds := []redis.Cmder{p.pipe.HSet(p.ctx, key, field, value)}
+	if ttl > 0 {
+		cmds = append(cmds, p.pipe.Expire(p.ctx, key, ttl))
+	}
+	return p.pipeStatus(cmds...)
+}
+
+func (p *redisPipeliner) HDel(key, field string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.HDel(p.ctx, key, field))
+}
+
+func (p *redisPipeliner) SAdd(key, member string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.SAdd(p.ctx, key, member))
+}
+
+func (p *redisPipeliner) SRem(key, member string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.SRem(p.ctx, key, member))
+}
+
+func (p *redisPipeliner) LPush(key string, value string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.LPush(p.ctx, key, value))
+}
+
+func (p *redisPipeliner) RPush(key string, value string) *_interface.PipeStatusCmd {
+	return p.pipeStatus(p.pipe.RPush(p.ctx, key, value))
+}
+
+// expiration 将写入的过期时间转换为go-redis的参数
+// go-redis把-1解释为KEEPTTL（保留原有的过期时间），负数统一按不过期处理，与其他驱动一致
+func expiration(ttl time.Duration) time.Duration {
+	return max(ttl, 0)
 }
 
 func (r *RedisDb) Close() {
 	_ = r.db.Close()
 }
 
-func (r *RedisDb) Set(key string, value string, ttl time.Duration) error {
-	return r.db.Set(key, value, ttl).Err()
+func (r *RedisDb) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Set(ctx, key, value, expiration(ttl)).Err()
 }
 
-func (r *RedisDb) Delete(key string) error {
-	return r.db.Del(key).Err()
+func (r *RedisDb) DeleteContext(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Del(ctx, key).Err()
 }
 
-func (r *RedisDb) Exists(key string) (bool, error) {
-	count, err := r.db.Exists(key).Result()
+func (r *RedisDb) ExistsContext(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	count, err := r.db.Exists(ctx, key).Result()
 	return count > 0, err
 }
 
-func (r *RedisDb) Expire(key string, ttl time.Duration) error {
-	return r.db.Expire(key, ttl).Err()
+func (r *RedisDb) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Expire(ctx, key, ttl).Err()
 }
 
-// HSet 设置哈希表中的field-value，并设置过期时间
+// TTLContext 通过PTTL命令获取key的剩余过期时间
 // 参数：
 //
-//	key - 哈希表键名
-//	field - 字段名
-//	value - 字段值
-//	ttl - 过期时间
+//	ctx - 上下文
+//	key - 键名
 //
 // 返回值：
 //
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在时返回TTLNotFound
 //	error - 操作错误
-func (r *RedisDb) HSet(key, field, value string, ttl time.Duration) error {
-	if err := r.db.HSet(key, field, value).Err(); err != nil {
-		return err
+func (r *RedisDb) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
-	if ttl > 0 {
-		return r.db.Expire(key, ttl).Err()
+	ttl, err := r.db.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return remainingTTL(ttl), nil
 }
 
-// HDel 删除哈希表中的一个或多个field
+// remainingTTL 转换PTTL的结果，客户端会把PTTL返回的-1/-2按毫秒换算
+func remainingTTL(ttl time.Duration) time.Duration {
+	switch ttl {
+	case -2 * time.Millisecond:
+		return _interface.TTLNotFound
+	case -1 * time.Millisecond:
+		return _interface.TTLNoExpiry
+	}
+	return ttl
+}
+
+// KeysContext 遍历匹配pattern的key，通配符语法与Redis一致
+// 基于SCAN命令分批遍历，遍历期间被修改的key可能被重复返回或遗漏，与SCAN的语义一致
 // 参数：
 //
-//	key - 哈希表键名
-//	field - 字段名
+//	ctx - 上下文
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
 //
 // 返回值：
 //
 //	error - 操作错误
-func (r *RedisDb) HDel(key, field string) error {
-	return r.db.HDel(key, field).Err()
+func (r *RedisDb) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	if pattern == "" {
+		pattern = "*"
+	}
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		keys, next, err := r.db.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !fn(key) {
+				return nil
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
 }
 
-// HGetAll 获取哈希表中所有的field和value
+// IterateContext 遍历键名以prefix开头的键值
+// 基于SCAN分批查找，每批使用MGET读取值，哈希表、队列和集合的值为nil，会被跳过；
+// SCAN和MGET之间被删除或过期的key也会被跳过，遍历期间被修改的key可能被重复返回或遗漏，与SCAN的语义一致
 // 参数：
 //
-//	key - 哈希表键名
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
 //
 // 返回值：
 //
-//	map[string]string - 所有字段和值的映射
 //	error - 操作错误
-func (r *RedisDb) HGetAll(key string) (map[string]string, error) {
-	return r.db.HGetAll(key).Result()
+func (r *RedisDb) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	pattern := _interface.EscapePattern(prefix) + "*"
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		keys, next, err := r.db.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			values, err := r.db.MGet(ctx, keys...).Result()
+			if err != nil {
+				return err
+			}
+			for i, value := range values {
+				s, ok := value.(string)
+				if ok && !fn(keys[i], s) {
+					return nil
+				}
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
 }
 
-func (r *RedisDb) Push(key string, value string) error {
-	return r.RPush(key, value)
+// DeleteByPrefixContext 删除所有以prefix开头的key
+// 基于SCAN分批查找，每批使用UNLINK在后台释放内存；遍历期间新写入的key可能不会被删除
+// 参数：
+//
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	pattern := _interface.EscapePattern(prefix) + "*"
+	var cursor uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		keys, next, err := r.db.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := r.db.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
 }
 
-func (r *RedisDb) Pop(key string) (string, error) {
-	return r.LPop(key)
+// SetNXContext key不存在时设置key-value并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (r *RedisDb) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return r.db.SetNX(ctx, key, value, expiration(ttl)).Result()
 }
 
-func (r *RedisDb) PopAll(key string) ([]string, error) {
+// CASContext key的当前值等于oldValue时设置为newValue并设置过期时间
+// 使用WATCH监视key后比较，在MULTI中写入，读取之后key被修改时事务失败并返回false
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (r *RedisDb) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	var ok bool
+	err := r.db.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil || current != oldValue {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, newValue, expiration(ttl)).Err()
+		})
+		ok = err == nil
+		return err
+	}, key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return ok, err
+}
+
+// GetSetContext 设置新值并返回旧值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (r *RedisDb) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := r.db.GetSet(ctx, key, value).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// GetDelContext 获取并删除指定key
+// 使用MULTI/EXEC执行GET和DEL，兼容不支持GETDEL命令的Redis版本
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (r *RedisDb) GetDelContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	pipe := r.db.TxPipeline()
-	lrange := pipe.LRange(key, 0, -1)
-	pipe.Del(key)
-	_, err := pipe.Exec()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+	val, err := get.Result()
+	if errors.Is(err, redis.Nil) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// AppendContext 使用APPEND命令在服务端追加value，key不存在时创建不过期的key，已有的过期时间保持不变
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (r *RedisDb) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	return r.db.Append(ctx, key, value).Result()
+}
+
+// StrLenContext 使用STRLEN命令返回key的值的长度（字节数），key不存在时返回0
+func (r *RedisDb) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return r.db.StrLen(ctx, key).Result()
+}
+
+// SetObjectContext 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (r *RedisDb) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := r.codec.Marshal(v)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return lrange.Val(), nil
+	return r.SetContext(ctx, key, string(data), ttl)
 }
 
-func (r *RedisDb) Len(key string) (int64, error) {
-	return r.db.LLen(key).Result()
+// GetObjectContext 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (r *RedisDb) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := r.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.codec.Unmarshal([]byte(raw), v)
 }
 
-func (r *RedisDb) BeginTx() (_interface.Tx, error) {
-	txPipe := r.db.TxPipeline()
-	return &RedisTx{pipe: txPipe}, nil
+// HSetContext 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 过期时间
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := r.db.HSet(ctx, key, field, value).Err(); err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return r.db.Expire(ctx, key, ttl).Err()
+	}
+	return nil
 }
 
-func NewRedisClient(config config.Cache) (_interface.Cache, error) {
-	addr := config.Host + ":" + config.Port
-	redisDb := redis.NewClient(&redis.Options{
-		Addr:         addr,
-		Password:     config.Password,
-		DB:           config.DB,
-		PoolSize:     200,
-		MinIdleConns: 100,
-	})
-	if _, err := redisDb.Ping().Result(); err != nil {
+// HDelContext 删除哈希表中的一个或多个field
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) HDelContext(ctx context.Context, key, field string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.HDel(ctx, key, field).Err()
+}
+
+// HGetAllContext 获取哈希表中所有的field和value
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射
+//	error - 操作错误
+func (r *RedisDb) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	return &RedisDb{db: redisDb}, nil
+	return r.db.HGetAll(ctx, key).Result()
+}
+
+// HLenContext 获取哈希表中field的数量
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	int64 - 字段数量，哈希表不存在时返回0
+//	error - 操作错误
+func (r *RedisDb) HLenContext(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.db.HLen(ctx, key).Result()
+}
+
+// HExistsContext 判断哈希表中是否存在field
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	bool - 字段是否存在
+//	error - 操作错误
+func (r *RedisDb) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return r.db.HExists(ctx, key, field).Result()
+}
+
+// HKeysContext 获取哈希表中所有的field，字段顺序不固定
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (r *RedisDb) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.db.HKeys(ctx, key).Result()
+}
+
+// SAddContext 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) SAddContext(ctx context.Context, key, member string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.SAdd(ctx, key, member).Err()
+}
+
+// SRemContext 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) SRemContext(ctx context.Context, key, member string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.SRem(ctx, key, member).Err()
+}
+
+// SMembersContext 获取集合的所有成员，成员顺序不固定
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (r *RedisDb) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.db.SMembers(ctx, key).Result()
+}
+
+// SIsMemberContext 判断member是否为集合成员
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (r *RedisDb) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return r.db.SIsMember(ctx, key, member).Result()
+}
+
+func (r *RedisDb) PushContext(ctx context.Context, key string, value string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.RPushContext(ctx, key, value)
+}
+
+func (r *RedisDb) PopContext(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return r.LPopContext(ctx, key)
+}
+
+func (r *RedisDb) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	res, err := popAllScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli()).Result()
+	if err != nil {
+		return nil, err
+	}
+	items, _ := res.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.(string))
+	}
+	return result, nil
+}
+
+func (r *RedisDb) LenContext(ctx context.Context, key string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return lenScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli()).Int64()
+}
+
+// LRangeContext 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (r *RedisDb) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return lrangeScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli(), start, stop).StringSlice()
+}
+
+// LIndexContext 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (r *RedisDb) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	val, err := lindexScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli(), index).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// LRemContext 删除列表中等于value的元素
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (r *RedisDb) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return lremScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli(), count, value).Int64()
+}
+
+// LTrimContext 只保留列表中下标在[start, stop]范围内的元素
+func (r *RedisDb) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return ltrimScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key)}, time.Now().UnixMilli(), start, stop).Err()
+}
+
+// promoteDelayedLua 把延迟有序集合中已到就绪时间的元素按就绪顺序移到列表尾部，拼接在各个队列脚本之前
+// KEYS[1]为列表，KEYS[2]为延迟有序集合；ARGV[1]为当前时间（Unix毫秒）
+// 有序集合的成员为"随机编号:元素"，分值为就绪时间。
+// 先只读取分值最小的成员，有序集合不存在或最早的元素还没有就绪时不做范围查询
+const promoteDelayedLua = `
+local head = redis.call('ZRANGE', KEYS[2], 0, 0, 'WITHSCORES')
+if #head > 0 and tonumber(head[2]) <= tonumber(ARGV[1]) then
+	local ready = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+	for i = 1, #ready do
+		local sep = string.find(ready[i], ':', 1, true)
+		redis.call('RPUSH', KEYS[1], string.sub(ready[i], sep + 1))
+	end
+	redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+end
+`
+
+var (
+	lpopScript   = redis.NewScript(promoteDelayedLua + `return redis.call('LPOP', KEYS[1])`)
+	rpopScript   = redis.NewScript(promoteDelayedLua + `return redis.call('RPOP', KEYS[1])`)
+	lenScript    = redis.NewScript(promoteDelayedLua + `return redis.call('LLEN', KEYS[1])`)
+	lrangeScript = redis.NewScript(promoteDelayedLua + `return redis.call('LRANGE', KEYS[1], ARGV[2], ARGV[3])`)
+	lindexScript = redis.NewScript(promoteDelayedLua + `return redis.call('LINDEX', KEYS[1], ARGV[2])`)
+	lremScript   = redis.NewScript(promoteDelayedLua + `return redis.call('LREM', KEYS[1], ARGV[2], ARGV[3])`)
+	ltrimScript  = redis.NewScript(promoteDelayedLua + `return redis.call('LTRIM', KEYS[1], ARGV[2], ARGV[3])`)
+	popAllScript = redis.NewScript(promoteDelayedLua + `
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+redis.call('DEL', KEYS[1])
+return items
+`)
+)
+
+// popAckScript 原子地把超时未确认的元素放回列表头部、弹出头部元素并登记为未确认
+// KEYS[1]为列表，KEYS[2]为延迟有序集合，KEYS[3]为未确认哈希表；ARGV[1]为当前时间，ARGV[2]为回执，ARGV[3]为截止时间（Unix毫秒）
+var popAckScript = redis.NewScript(promoteDelayedLua + `
+local entries = redis.call('HGETALL', KEYS[3])
+for i = 1, #entries, 2 do
+	local sep = string.find(entries[i + 1], ':', 1, true)
+	if tonumber(string.sub(entries[i + 1], 1, sep - 1)) <= tonumber(ARGV[1]) then
+		redis.call('LPUSH', KEYS[1], string.sub(entries[i + 1], sep + 1))
+		redis.call('HDEL', KEYS[3], entries[i])
+	end
+end
+local value = redis.call('LPOP', KEYS[1])
+if not value then
+	return false
+end
+redis.call('HSET', KEYS[3], ARGV[2], ARGV[3] .. ':' .. value)
+return value
+`)
+
+// PopAckContext 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，通过Lua脚本原子执行
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (r *RedisDb) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	receipt := _interface.NewReceipt()
+	val, err := popAckScript.Run(ctx, r.db, []string{key, _interface.DelayedKey(key), _interface.PendingKey(key)},
+		now.UnixMilli(), receipt, now.Add(visibility).UnixMilli()).Text()
+	if errors.Is(err, redis.Nil) {
+		return "", "", _interface.ErrKeyNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return val, receipt, nil
+}
+
+// AckContext 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (r *RedisDb) AckContext(ctx context.Context, key, receipt string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	n, err := r.db.HDel(ctx, _interface.PendingKey(key), receipt).Result()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return _interface.ErrKeyNotFound
+	}
+	return nil
+}
+
+// PushDelayedContext 推入延迟元素，delay之后元素才会出现在列表右边并可以被弹出
+// 延迟元素保存在<key>:delayed有序集合中，分值为就绪时间；弹出或读取长度时在同一个Lua脚本内先把已就绪的元素移入列表
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if delay <= 0 {
+		return r.db.RPush(ctx, key, value).Err()
+	}
+	return r.db.ZAdd(ctx, _interface.DelayedKey(key), redis.Z{
+		Score:  float64(time.Now().Add(delay).UnixMilli()),
+		Member: _interface.NewReceipt() + ":" + value,
+	}).Err()
+}
+
+// PublishContext 通过PUBLISH命令向频道发布消息
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) PublishContext(ctx context.Context, channel string, payload string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.db.Publish(ctx, channel, payload).Err()
+}
+
+// SubscribeContext 通过SUBSCRIBE命令订阅频道，上下文取消或超时时自动取消订阅
+// 返回前等待订阅确认，保证之后发布的消息都能收到；订阅失败时返回已关闭的消息通道
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅后被关闭
+//	func() - 取消订阅函数
+func (r *RedisDb) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	out := make(chan _interface.Message)
+	if ctx.Err() != nil {
+		close(out)
+		return out, func() {}
+	}
+
+	ps := r.db.Subscribe(ctx, channel)
+	if _, err := ps.Receive(ctx); err != nil {
+		_ = ps.Close()
+		close(out)
+		return out, func() {}
+	}
+
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			_ = ps.Close()
+		})
+	}
+
+	msgs := ps.Channel()
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- _interface.Message{Channel: msg.Channel, Payload: msg.Payload}:
+				case <-done:
+					return
+				case <-ctx.Done():
+					stop()
+					return
+				}
+			case <-done:
+				return
+			case <-ctx.Done():
+				stop()
+				return
+			}
+		}
+	}()
+	return out, stop
+}
+
+// SubscribeExpiredContext 通过键空间通知订阅键过期事件，上下文取消或超时时自动取消订阅
+// 需要服务端的notify-keyspace-events配置包含Ex（例如CONFIG SET notify-keyspace-events Ex），否则收不到事件；
+// Redis在过期键被实际删除时才发送事件，因此事件可能晚于过期时间
+// 参数：
+//
+//	ctx - 上下文
+//
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (r *RedisDb) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	msgs, cancel := r.SubscribeContext(ctx, fmt.Sprintf("__keyevent@%d__:expired", r.db.Options().DB))
+
+	out := make(chan string)
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+		})
+	}
+	go func() {
+		defer close(out)
+		for msg := range msgs {
+			select {
+			case out <- msg.Payload:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return out, stop
+}
+
+func (r *RedisDb) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &RedisTx{ctx: ctx, r: r, pipe: r.db.TxPipeline()}, nil
+}
+
+// BeginTxWatchContext 开启监视keys的事务
+// 在独占的连接上执行WATCH，Commit时在同一个连接上执行MULTI/EXEC
+func (r *RedisDb) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn := r.db.Conn()
+	if len(keys) > 0 {
+		args := make([]any, 0, len(keys)+1)
+		args = append(args, "watch")
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		if err := conn.Process(ctx, redis.NewStatusCmd(ctx, args...)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return &RedisTx{ctx: ctx, r: r, pipe: conn.TxPipeline(), conn: conn}, nil
+}
+
+// 不带上下文的方法使用 context.Background()，与带上下文的方法共用实现
+
+func (r *RedisDb) LPush(key string, value string) error {
+	return r.LPushContext(context.Background(), key, value)
+}
+
+func (r *RedisDb) RPush(key string, value string) error {
+	return r.RPushContext(context.Background(), key, value)
+}
+
+func (r *RedisDb) RPushCapped(key string, value string, maxLen int64) error {
+	return r.RPushCappedContext(context.Background(), key, value, maxLen)
+}
+
+func (r *RedisDb) LPop(key string) (string, error) {
+	return r.LPopContext(context.Background(), key)
+}
+
+func (r *RedisDb) RPop(key string) (string, error) {
+	return r.RPopContext(context.Background(), key)
+}
+
+func (r *RedisDb) Get(key string) (string, error) {
+	return r.GetContext(context.Background(), key)
+}
+
+func (r *RedisDb) HGet(key, field string) (string, error) {
+	return r.HGetContext(context.Background(), key, field)
+}
+
+func (r *RedisDb) Set(key string, value string, ttl time.Duration) error {
+	return r.SetContext(context.Background(), key, value, ttl)
+}
+
+func (r *RedisDb) Delete(key string) error {
+	return r.DeleteContext(context.Background(), key)
+}
+
+func (r *RedisDb) Exists(key string) (bool, error) {
+	return r.ExistsContext(context.Background(), key)
+}
+
+func (r *RedisDb) Expire(key string, ttl time.Duration) error {
+	return r.ExpireContext(context.Background(), key, ttl)
+}
+
+func (r *RedisDb) Keys(pattern string, fn func(key string) bool) error {
+	return r.KeysContext(context.Background(), pattern, fn)
+}
+
+func (r *RedisDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	return r.IterateContext(context.Background(), prefix, fn)
+}
+
+func (r *RedisDb) DeleteByPrefix(prefix string) error {
+	return r.DeleteByPrefixContext(context.Background(), prefix)
+}
+
+func (r *RedisDb) TTL(key string) (time.Duration, error) {
+	return r.TTLContext(context.Background(), key)
+}
+
+func (r *RedisDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return r.SetNXContext(context.Background(), key, value, ttl)
+}
+
+func (r *RedisDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return r.CASContext(context.Background(), key, oldValue, newValue, ttl)
+}
+
+func (r *RedisDb) GetSet(key string, value string) (string, error) {
+	return r.GetSetContext(context.Background(), key, value)
+}
+
+func (r *RedisDb) GetDel(key string) (string, error) {
+	return r.GetDelContext(context.Background(), key)
+}
+
+func (r *RedisDb) Append(key string, value string) (int64, error) {
+	return r.AppendContext(context.Background(), key, value)
+}
+
+func (r *RedisDb) StrLen(key string) (int64, error) {
+	return r.StrLenContext(context.Background(), key)
+}
+
+func (r *RedisDb) SetObject(key string, v any, ttl time.Duration) error {
+	return r.SetObjectContext(context.Background(), key, v, ttl)
+}
+
+func (r *RedisDb) GetObject(key string, v any) error {
+	return r.GetObjectContext(context.Background(), key, v)
+}
+
+func (r *RedisDb) HSet(key, field, value string, ttl time.Duration) error {
+	return r.HSetContext(context.Background(), key, field, value, ttl)
+}
+
+func (r *RedisDb) HDel(key, field string) error {
+	return r.HDelContext(context.Background(), key, field)
+}
+
+func (r *RedisDb) HGetAll(key string) (map[string]string, error) {
+	return r.HGetAllContext(context.Background(), key)
+}
+
+func (r *RedisDb) HLen(key string) (int64, error) {
+	return r.HLenContext(context.Background(), key)
+}
+
+func (r *RedisDb) HExists(key, field string) (bool, error) {
+	return r.HExistsContext(context.Background(), key, field)
+}
+
+func (r *RedisDb) HKeys(key string) ([]string, error) {
+	return r.HKeysContext(context.Background(), key)
+}
+
+func (r *RedisDb) SAdd(key, member string) error {
+	return r.SAddContext(context.Background(), key, member)
+}
+
+func (r *RedisDb) SRem(key, member string) error {
+	return r.SRemContext(context.Background(), key, member)
+}
+
+func (r *RedisDb) SMembers(key string) ([]string, error) {
+	return r.SMembersContext(context.Background(), key)
+}
+
+func (r *RedisDb) SIsMember(key, member string) (bool, error) {
+	return r.SIsMemberContext(context.Background(), key, member)
+}
+
+func (r *RedisDb) Push(key string, value string) error {
+	return r.PushContext(context.Background(), key, value)
+}
+
+func (r *RedisDb) Pop(key string) (string, error) {
+	return r.PopContext(context.Background(), key)
+}
+
+func (r *RedisDb) PopAll(key string) ([]string, error) {
+	return r.PopAllContext(context.Background(), key)
+}
+
+func (r *RedisDb) Len(key string) (int64, error) {
+	return r.LenContext(context.Background(), key)
+}
+
+func (r *RedisDb) LRange(key string, start, stop int64) ([]string, error) {
+	return r.LRangeContext(context.Background(), key, start, stop)
+}
+
+func (r *RedisDb) LIndex(key string, index int64) (string, error) {
+	return r.LIndexContext(context.Background(), key, index)
+}
+
+func (r *RedisDb) LRem(key string, count int64, value string) (int64, error) {
+	return r.LRemContext(context.Background(), key, count, value)
+}
+
+func (r *RedisDb) LTrim(key string, start, stop int64) error {
+	return r.LTrimContext(context.Background(), key, start, stop)
+}
+
+func (r *RedisDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return r.PopAckContext(context.Background(), key, visibility)
+}
+
+func (r *RedisDb) Ack(key, receipt string) error {
+	return r.AckContext(context.Background(), key, receipt)
+}
+
+func (r *RedisDb) PushDelayed(key, value string, delay time.Duration) error {
+	return r.PushDelayedContext(context.Background(), key, value, delay)
+}
+
+func (r *RedisDb) Publish(channel string, payload string) error {
+	return r.PublishContext(context.Background(), channel, payload)
+}
+
+func (r *RedisDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return r.SubscribeContext(context.Background(), channel)
+}
+
+func (r *RedisDb) SubscribeExpired() (<-chan string, func()) {
+	return r.SubscribeExpiredContext(context.Background())
+}
+
+func (r *RedisDb) BeginTx() (_interface.Tx, error) {
+	return r.BeginTxContext(context.Background())
+}
+
+// Pipeline 在一次往返中执行fn排队的所有命令，见PipelineContext
+func (r *RedisDb) Pipeline(fn func(p _interface.Pipeliner) error) error {
+	return r.PipelineContext(context.Background(), fn)
+}
+
+func (r *RedisDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return r.BeginTxWatchContext(context.Background(), keys...)
+}
+
+// BFReserve 使用RedisBloom的BF.RESERVE创建布隆过滤器
+// 服务端没有加载RedisBloom模块时返回ErrUnsupported，由_interface.BFReserve改用位图实现
+// 参数：
+//
+//	key - 过滤器的键名
+//	errorRate - 误判率
+//	capacity - 预期元素数量
+//
+// 返回值：
+//
+//	error - 操作错误，键已存在时返回ErrBloomExists
+func (r *RedisDb) BFReserve(key string, errorRate float64, capacity int64) error {
+	if r.noBloom.Load() {
+		return _interface.ErrUnsupported
+	}
+	err := r.db.Do(context.Background(), "BF.RESERVE", key, errorRate, capacity).Err()
+	if err != nil && strings.Contains(err.Error(), "item exists") {
+		return _interface.ErrBloomExists
+	}
+	return r.bloomError(err)
+}
+
+// BFAdd 使用RedisBloom的BF.ADD添加元素，过滤器不存在时由RedisBloom按默认参数创建
+func (r *RedisDb) BFAdd(key, item string) (bool, error) {
+	if r.noBloom.Load() {
+		return false, _interface.ErrUnsupported
+	}
+	added, err := r.db.Do(context.Background(), "BF.ADD", key, item).Bool()
+	return added, r.bloomError(err)
+}
+
+// BFExists 使用RedisBloom的BF.EXISTS判断元素是否可能存在
+func (r *RedisDb) BFExists(key, item string) (bool, error) {
+	if r.noBloom.Load() {
+		return false, _interface.ErrUnsupported
+	}
+	exists, err := r.db.Do(context.Background(), "BF.EXISTS", key, item).Bool()
+	return exists, r.bloomError(err)
+}
+
+// bloomError 转换布隆过滤器命令的错误
+// 服务端不认识BF.*命令时记录下来，之后的调用直接返回ErrUnsupported；键的类型不是布隆过滤器时返回ErrBloomFormat
+func (r *RedisDb) bloomError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case strings.Contains(err.Error(), "unknown command"):
+		r.noBloom.Store(true)
+		return _interface.ErrUnsupported
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return _interface.ErrBloomFormat
+	}
+	return err
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*RedisDb)(nil)
+
+// 确保实现了原生的布隆过滤器
+var _ _interface.Bloom = (*RedisDb)(nil)
+
+// 确保实现了原生的命令管道
+var _ _interface.PipelineRunner = (*RedisDb)(nil)
+
+// StatsContext 返回Redis的统计信息
+// 键数量为当前数据库的DBSIZE，内存占用为INFO memory中的used_memory（整个实例）
+// 参数：
+//
+//	ctx - 上下文
+//
+// 返回值：
+//
+//	Stats - 统计信息，包含连接池统计
+//	error - 操作错误
+func (r *RedisDb) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverRedis)
+	pool := r.db.PoolStats()
+	stats.Pool = &_interface.PoolStats{
+		Hits:       pool.Hits,
+		Misses:     pool.Misses,
+		Timeouts:   pool.Timeouts,
+		TotalConns: pool.TotalConns,
+		IdleConns:  pool.IdleConns,
+		StaleConns: pool.StaleConns,
+	}
+
+	keys, err := r.db.DBSize(ctx).Result()
+	if err != nil {
+		return stats, err
+	}
+	stats.Keys = keys
+
+	info, err := r.db.Info(ctx, "memory").Result()
+	if err != nil {
+		return stats, err
+	}
+	for _, line := range strings.Split(info, "\r\n") {
+		if value, ok := strings.CutPrefix(line, "used_memory:"); ok {
+			stats.MemoryBytes, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return stats, nil
+}
+
+func (r *RedisDb) Stats() (_interface.Stats, error) {
+	return r.StatsContext(context.Background())
+}
+
+// BackupContext 使用SCAN遍历当前数据库，将每个key的DUMP结果和剩余过期时间写入记录流
+// 遍历期间写入的key可能不会被备份，遍历之后被删除或过期的key会被跳过
+// 参数：
+//
+//	ctx - 上下文
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RedisDb) BackupContext(ctx context.Context, w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverRedis)
+	if err != nil {
+		return err
+	}
+	var werr error
+	err = r.KeysContext(ctx, "*", func(key string) bool {
+		werr = r.dumpKey(ctx, bw, key)
+		return werr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	return bw.Flush()
+}
+
+// dumpKey 将一个key的序列化值和剩余过期时间写入记录流
+func (r *RedisDb) dumpKey(ctx context.Context, bw *_interface.BackupWriter, key string) error {
+	payload, err := r.db.Dump(ctx, key).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ttl, err := r.TTLContext(ctx, key)
+	if err != nil || ttl == _interface.TTLNotFound {
+		return err
+	}
+	return bw.WriteRecord(_interface.BackupRecord{Key: []byte(key), Value: []byte(payload), TTL: max(ttl, 0)})
+}
+
+// RestoreContext 使用RESTORE REPLACE写入备份中的每个key，已存在的key被替换
+// 参数：
+//
+//	ctx - 上下文
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是Redis的备份时返回ErrBackupFormat
+func (r *RedisDb) RestoreContext(ctx context.Context, rd io.Reader) error {
+	br, err := _interface.NewBackupReader(rd, config.CacheDriverRedis)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec, err := br.ReadRecord()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := r.db.RestoreReplace(ctx, string(rec.Key), rec.TTL, string(rec.Value)).Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *RedisDb) Backup(w io.Writer) error {
+	return r.BackupContext(context.Background(), w)
+}
+
+func (r *RedisDb) Restore(rd io.Reader) error {
+	return r.RestoreContext(context.Background(), rd)
+}
+
+const (
+	// defaultPoolSize 连接池的默认最大连接数
+	defaultPoolSize = 200
+	// defaultMinIdleConns 默认保持的最小空闲连接数
+	defaultMinIdleConns = 100
+)
+
+// NewRedisClient 创建Redis缓存实例
+// 设置了SentinelMasterName时通过Sentinel发现主节点，主节点故障转移后自动切换连接，
+// 此时Host和Port被忽略；连接池和超时由config.Redis配置
+func NewRedisClient(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	pool := poolOptions(config.Redis)
+	var redisDb *redis.Client
+	if config.SentinelMasterName != "" {
+		redisDb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:      config.SentinelMasterName,
+			SentinelAddrs:   config.SentinelAddrs,
+			Username:        config.Username,
+			Password:        config.Password,
+			DB:              config.DB,
+			PoolSize:        pool.PoolSize,
+			MinIdleConns:    pool.MinIdleConns,
+			MaxIdleConns:    pool.MaxIdleConns,
+			ConnMaxIdleTime: pool.ConnMaxIdleTime,
+			DialTimeout:     pool.DialTimeout,
+			ReadTimeout:     pool.ReadTimeout,
+			WriteTimeout:    pool.WriteTimeout,
+			PoolTimeout:     pool.PoolTimeout,
+		})
+	} else {
+		addr := config.Host + ":" + config.Port
+		redisDb = redis.NewClient(&redis.Options{
+			Addr:            addr,
+			Username:        config.Username,
+			Password:        config.Password,
+			DB:              config.DB,
+			PoolSize:        pool.PoolSize,
+			MinIdleConns:    pool.MinIdleConns,
+			MaxIdleConns:    pool.MaxIdleConns,
+			ConnMaxIdleTime: pool.ConnMaxIdleTime,
+			DialTimeout:     pool.DialTimeout,
+			ReadTimeout:     pool.ReadTimeout,
+			WriteTimeout:    pool.WriteTimeout,
+			PoolTimeout:     pool.PoolTimeout,
+		})
+	}
+	if _, err := redisDb.Ping(context.Background()).Result(); err != nil {
+		_ = redisDb.Close()
+		return nil, err
+	}
+	return &RedisDb{db: redisDb, codec: codec}, nil
+}
+
+// poolOptions 将配置的连接池和超时选项换算为go-redis的取值
+// 连接池大小和最小空闲连接数为0时使用本包的默认值，超时为0时使用go-redis的默认值，
+// 负数的读写超时和空闲时间统一换算为-1，表示不设置超时；建立连接必须有超时，负数按默认值处理
+func poolOptions(opts config.RedisOptions) config.RedisOptions {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = defaultPoolSize
+	}
+	if opts.MinIdleConns == 0 {
+		opts.MinIdleConns = defaultMinIdleConns
+	} else if opts.MinIdleConns < 0 {
+		opts.MinIdleConns = 0
+	}
+	if opts.MinIdleConns > opts.PoolSize {
+		opts.MinIdleConns = opts.PoolSize
+	}
+	if opts.DialTimeout < 0 {
+		opts.DialTimeout = 0
+	}
+	for _, d := range []*time.Duration{&opts.ConnMaxIdleTime, &opts.ReadTimeout, &opts.WriteTimeout} {
+		if *d < 0 {
+			*d = -1
+		}
+	}
+	return opts
 }
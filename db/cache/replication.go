@@ -0,0 +1,943 @@
+// cache包：异步复制
+// 写入先作用于主缓存，成功后再异步应用到一个或多个副本，例如用本地BadgerDB的写入预热新的Redis集群
+//
+// 主要特性：
+// - 读取只访问主缓存，副本的写入失败不影响主缓存的结果，通过OnError回调报告
+// - 每个副本有独立的队列和goroutine，同一个key的操作在分片锁内写入主缓存并入队，副本上的顺序与主缓存一致
+// - 队列满时不阻塞写入，丢弃复制的操作并通过OnError报告ErrReplicationQueueFull，之后需要用cachemigrate重新同步
+// - 过期时间在副本应用时重新计算，副本的过期时间会比主缓存晚复制延迟的时长
+// - 不复制发布订阅、PopAck/Ack和Restore，已有数据可以先用cachemigrate迁移
+// - 主缓存实现了CacheCtx时，返回的实例同样实现CacheCtx，副本的写入不受调用方上下文的影响
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+const (
+	// defaultReplicationQueueSize 每个副本默认的待复制队列长度
+	defaultReplicationQueueSize = 1024
+	// replicationStripes 写入锁的分片数
+	replicationStripes = 64
+)
+
+// ErrReplicationQueueFull 副本的待复制队列已满，操作被丢弃，副本与主缓存不再一致
+var ErrReplicationQueueFull = errors.New("cache: replication queue full")
+
+// ReplicationOptions 异步复制的选项
+type ReplicationOptions struct {
+	QueueSize int                          // 每个副本的待复制队列长度，0表示1024
+	OnError   func(replica int, err error) // 副本写入失败或队列已满的回调，replica为副本在replicas中的下标
+	Codec     _interface.Codec             // SetObject/GetObject的编码方式，为nil时使用JSONCodec
+}
+
+// replicaOp 在副本上重放的写入操作
+type replicaOp func(c _interface.Cache) error
+
+// NewReplicated 创建异步复制的缓存
+// 参数：
+//
+//	primary - 主缓存，所有读取和同步写入的目标
+//	replicas - 副本缓存
+//	opts - 复制选项
+//
+// 返回值：
+//
+//	_interface.Cache - 复制缓存实例，primary实现了CacheCtx时同样实现CacheCtx；
+//	Close等待所有副本应用完队列中的操作，primary和replicas由创建它们的一方关闭
+func NewReplicated(primary _interface.Cache, replicas []_interface.Cache, opts ReplicationOptions) _interface.Cache {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultReplicationQueueSize
+	}
+	if opts.Codec == nil {
+		opts.Codec = _interface.JSONCodec{}
+	}
+
+	r := &replicated{primary: primary, codec: opts.Codec, onError: opts.OnError}
+	for i, replica := range replicas {
+		q := make(chan replicaOp, opts.QueueSize)
+		r.queues = append(r.queues, q)
+		r.wg.Add(1)
+		go r.apply(i, replica, q)
+	}
+
+	if cc, ok := primary.(_interface.CacheCtx); ok {
+		return &replicatedCtx{replicated: r, cc: cc}
+	}
+	return r
+}
+
+// replicated 异步复制的缓存，实现Cache接口
+type replicated struct {
+	primary _interface.Cache
+	codec   _interface.Codec
+	onError func(replica int, err error)
+	queues  []chan replicaOp
+	wg      sync.WaitGroup
+	mu      sync.RWMutex // 保护closed，Close之后不再向队列发送
+	closed  bool
+	once    sync.Once
+	stripes [replicationStripes]sync.Mutex // 按key分片的写入锁，主缓存写入和入队在同一把锁内完成
+}
+
+// lock 锁住key所在的分片，返回解锁函数
+func (r *replicated) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &r.stripes[h.Sum32()%replicationStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// lockAll 按顺序锁住所有分片，用于DeleteByPrefix和事务提交等涉及多个key的写入
+func (r *replicated) lockAll() func() {
+	for i := range r.stripes {
+		r.stripes[i].Lock()
+	}
+	return func() {
+		for i := range r.stripes {
+			r.stripes[i].Unlock()
+		}
+	}
+}
+
+// apply 按顺序在副本上应用队列中的操作
+func (r *replicated) apply(i int, replica _interface.Cache, q <-chan replicaOp) {
+	defer r.wg.Done()
+	for op := range q {
+		if err := op(replica); err != nil && r.onError != nil {
+			r.onError(i, err)
+		}
+	}
+}
+
+// replicate 将操作加入所有副本的队列，队列满时丢弃并通过OnError报告
+// 调用方需持有操作的key所在的分片锁
+func (r *replicated) replicate(op replicaOp) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	for i, q := range r.queues {
+		select {
+		case q <- op:
+		default:
+			if r.onError != nil {
+				r.onError(i, ErrReplicationQueueFull)
+			}
+		}
+	}
+}
+
+// Close 停止复制并等待副本应用完队列中的操作，primary和replicas由创建它们的一方关闭
+func (r *replicated) Close() {
+	r.once.Do(func() {
+		r.mu.Lock()
+		r.closed = true
+		for _, q := range r.queues {
+			close(q)
+		}
+		r.mu.Unlock()
+		r.wg.Wait()
+	})
+}
+
+func (r *replicated) Get(key string) (string, error) {
+	return r.primary.Get(key)
+}
+
+func (r *replicated) Set(key string, value string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.primary.Set(key, value, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Set(key, value, ttl) })
+	return nil
+}
+
+func (r *replicated) Delete(key string) error {
+	defer r.lock(key)()
+	if err := r.primary.Delete(key); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Delete(key) })
+	return nil
+}
+
+func (r *replicated) Exists(key string) (bool, error) {
+	return r.primary.Exists(key)
+}
+
+func (r *replicated) Expire(key string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.primary.Expire(key, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Expire(key, ttl) })
+	return nil
+}
+
+func (r *replicated) TTL(key string) (time.Duration, error) {
+	return r.primary.TTL(key)
+}
+
+// SetNX 只在主缓存设置成功时复制，副本上以Set覆盖
+func (r *replicated) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	defer r.lock(key)()
+	ok, err := r.primary.SetNX(key, value, ttl)
+	if ok {
+		r.replicate(func(c _interface.Cache) error { return c.Set(key, value, ttl) })
+	}
+	return ok, err
+}
+
+// CAS 只在主缓存设置成功时复制，副本上以Set覆盖
+func (r *replicated) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	defer r.lock(key)()
+	ok, err := r.primary.CAS(key, oldValue, newValue, ttl)
+	if ok {
+		r.replicate(func(c _interface.Cache) error { return c.Set(key, newValue, ttl) })
+	}
+	return ok, err
+}
+
+func (r *replicated) GetSet(key string, value string) (string, error) {
+	defer r.lock(key)()
+	old, err := r.primary.GetSet(key, value)
+	r.replicate(func(c _interface.Cache) error { return c.Set(key, value, 0) })
+	return old, err
+}
+
+func (r *replicated) GetDel(key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.primary.GetDel(key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return c.Delete(key) })
+	}
+	return value, err
+}
+
+func (r *replicated) Append(key string, value string) (int64, error) {
+	defer r.lock(key)()
+	n, err := r.primary.Append(key, value)
+	if err != nil {
+		return 0, err
+	}
+	r.replicate(func(c _interface.Cache) error {
+		_, err := c.Append(key, value)
+		return err
+	})
+	return n, nil
+}
+
+func (r *replicated) StrLen(key string) (int64, error) {
+	return r.primary.StrLen(key)
+}
+
+func (r *replicated) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.Set(key, string(data), ttl)
+}
+
+func (r *replicated) GetObject(key string, v any) error {
+	raw, err := r.primary.Get(key)
+	if err != nil {
+		return err
+	}
+	return r.codec.Unmarshal([]byte(raw), v)
+}
+
+func (r *replicated) Keys(pattern string, fn func(key string) bool) error {
+	return r.primary.Keys(pattern, fn)
+}
+
+func (r *replicated) Iterate(prefix string, fn func(key, value string) bool) error {
+	return r.primary.Iterate(prefix, fn)
+}
+
+func (r *replicated) DeleteByPrefix(prefix string) error {
+	defer r.lockAll()()
+	if err := r.primary.DeleteByPrefix(prefix); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.DeleteByPrefix(prefix) })
+	return nil
+}
+
+func (r *replicated) HGet(key, field string) (string, error) {
+	return r.primary.HGet(key, field)
+}
+
+func (r *replicated) HSet(key, field, value string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.primary.HSet(key, field, value, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.HSet(key, field, value, ttl) })
+	return nil
+}
+
+func (r *replicated) HDel(key, field string) error {
+	defer r.lock(key)()
+	if err := r.primary.HDel(key, field); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.HDel(key, field) })
+	return nil
+}
+
+func (r *replicated) HGetAll(key string) (map[string]string, error) {
+	return r.primary.HGetAll(key)
+}
+
+func (r *replicated) HLen(key string) (int64, error) {
+	return r.primary.HLen(key)
+}
+
+func (r *replicated) HExists(key, field string) (bool, error) {
+	return r.primary.HExists(key, field)
+}
+
+func (r *replicated) HKeys(key string) ([]string, error) {
+	return r.primary.HKeys(key)
+}
+
+func (r *replicated) SAdd(key, member string) error {
+	defer r.lock(key)()
+	if err := r.primary.SAdd(key, member); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.SAdd(key, member) })
+	return nil
+}
+
+func (r *replicated) SRem(key, member string) error {
+	defer r.lock(key)()
+	if err := r.primary.SRem(key, member); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.SRem(key, member) })
+	return nil
+}
+
+func (r *replicated) SMembers(key string) ([]string, error) {
+	return r.primary.SMembers(key)
+}
+
+func (r *replicated) SIsMember(key, member string) (bool, error) {
+	return r.primary.SIsMember(key, member)
+}
+
+func (r *replicated) Push(key string, value string) error {
+	defer r.lock(key)()
+	if err := r.primary.Push(key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Push(key, value) })
+	return nil
+}
+
+func (r *replicated) LPush(key string, value string) error {
+	defer r.lock(key)()
+	if err := r.primary.LPush(key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.LPush(key, value) })
+	return nil
+}
+
+func (r *replicated) RPush(key string, value string) error {
+	defer r.lock(key)()
+	if err := r.primary.RPush(key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.RPush(key, value) })
+	return nil
+}
+
+func (r *replicated) RPushCapped(key string, value string, maxLen int64) error {
+	defer r.lock(key)()
+	if err := r.primary.RPushCapped(key, value, maxLen); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.RPushCapped(key, value, maxLen) })
+	return nil
+}
+
+// Pop 弹出主缓存的元素，副本上弹出同一端的元素
+func (r *replicated) Pop(key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.primary.Pop(key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.Pop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicated) LPop(key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.primary.LPop(key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.LPop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicated) RPop(key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.primary.RPop(key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.RPop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicated) PopAll(key string) ([]string, error) {
+	defer r.lock(key)()
+	values, err := r.primary.PopAll(key)
+	if err == nil && len(values) > 0 {
+		r.replicate(func(c _interface.Cache) error { return discard(c.PopAll(key)) })
+	}
+	return values, err
+}
+
+func (r *replicated) Len(key string) (int64, error) {
+	return r.primary.Len(key)
+}
+
+func (r *replicated) LRange(key string, start, stop int64) ([]string, error) {
+	return r.primary.LRange(key, start, stop)
+}
+
+func (r *replicated) LIndex(key string, index int64) (string, error) {
+	return r.primary.LIndex(key, index)
+}
+
+func (r *replicated) LRem(key string, count int64, value string) (int64, error) {
+	defer r.lock(key)()
+	removed, err := r.primary.LRem(key, count, value)
+	if err == nil && removed > 0 {
+		r.replicate(func(c _interface.Cache) error { return discard(c.LRem(key, count, value)) })
+	}
+	return removed, err
+}
+
+func (r *replicated) LTrim(key string, start, stop int64) error {
+	defer r.lock(key)()
+	err := r.primary.LTrim(key, start, stop)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return c.LTrim(key, start, stop) })
+	}
+	return err
+}
+
+func (r *replicated) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return r.primary.PopAck(key, visibility)
+}
+
+func (r *replicated) Ack(key, receipt string) error {
+	return r.primary.Ack(key, receipt)
+}
+
+func (r *replicated) PushDelayed(key, value string, delay time.Duration) error {
+	defer r.lock(key)()
+	if err := r.primary.PushDelayed(key, value, delay); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.PushDelayed(key, value, delay) })
+	return nil
+}
+
+func (r *replicated) Publish(channel string, payload string) error {
+	return r.primary.Publish(channel, payload)
+}
+
+func (r *replicated) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return r.primary.Subscribe(channel)
+}
+
+func (r *replicated) SubscribeExpired() (<-chan string, func()) {
+	return r.primary.SubscribeExpired()
+}
+
+func (r *replicated) Stats() (_interface.Stats, error) {
+	return r.primary.Stats()
+}
+
+func (r *replicated) Backup(w io.Writer) error {
+	return r.primary.Backup(w)
+}
+
+// Restore 只恢复主缓存，备份流无法重放到副本
+func (r *replicated) Restore(rd io.Reader) error {
+	return r.primary.Restore(rd)
+}
+
+func (r *replicated) BeginTx() (_interface.Tx, error) {
+	tx, err := r.primary.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	return &replicatedTx{tx: tx, r: r}, nil
+}
+
+func (r *replicated) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	tx, err := r.primary.BeginTxWatch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &replicatedTx{tx: tx, r: r}, nil
+}
+
+// BeginTxOptions 按选项开启主节点的事务
+func (r *replicated) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	tx, err := _interface.BeginTx(ctx, r.primary, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &replicatedTx{tx: tx, r: r}, nil
+}
+
+// discard 丢弃副本弹出的值，副本上队列为空不视为错误
+func discard[T any](_ T, err error) error {
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// replicatedTx 复制缓存的事务，提交成功后按顺序复制事务内的写入
+type replicatedTx struct {
+	tx  _interface.Tx
+	r   *replicated
+	ops []replicaOp
+}
+
+// Get 读取主缓存的事务
+func (tx *replicatedTx) Get(key string) (string, error) {
+	return tx.tx.Get(key)
+}
+
+func (tx *replicatedTx) Exists(key string) (bool, error) {
+	return tx.tx.Exists(key)
+}
+
+func (tx *replicatedTx) Set(key string, value string, ttl time.Duration) error {
+	if err := tx.tx.Set(key, value, ttl); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, func(c _interface.Cache) error { return c.Set(key, value, ttl) })
+	return nil
+}
+
+func (tx *replicatedTx) Delete(key string) error {
+	if err := tx.tx.Delete(key); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, func(c _interface.Cache) error { return c.Delete(key) })
+	return nil
+}
+
+func (tx *replicatedTx) Expire(key string, ttl time.Duration) error {
+	if err := tx.tx.Expire(key, ttl); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, func(c _interface.Cache) error { return c.Expire(key, ttl) })
+	return nil
+}
+
+func (tx *replicatedTx) HSet(key, field, value string, ttl time.Duration) error {
+	if err := tx.tx.HSet(key, field, value, ttl); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, func(c _interface.Cache) error { return c.HSet(key, field, value, ttl) })
+	return nil
+}
+
+func (tx *replicatedTx) HDel(key, field string) error {
+	if err := tx.tx.HDel(key, field); err != nil {
+		return err
+	}
+	tx.ops = append(tx.ops, func(c _interface.Cache) error { return c.HDel(key, field) })
+	return nil
+}
+
+func (tx *replicatedTx) Commit() error {
+	defer tx.r.lockAll()()
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	for _, op := range tx.ops {
+		tx.r.replicate(op)
+	}
+	return nil
+}
+
+func (tx *replicatedTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// replicatedCtx 主缓存实现了CacheCtx时使用的复制缓存
+type replicatedCtx struct {
+	*replicated
+	cc _interface.CacheCtx
+}
+
+func (r *replicatedCtx) GetContext(ctx context.Context, key string) (string, error) {
+	return r.cc.GetContext(ctx, key)
+}
+
+func (r *replicatedCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.cc.SetContext(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Set(key, value, ttl) })
+	return nil
+}
+
+func (r *replicatedCtx) DeleteContext(ctx context.Context, key string) error {
+	defer r.lock(key)()
+	if err := r.cc.DeleteContext(ctx, key); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Delete(key) })
+	return nil
+}
+
+func (r *replicatedCtx) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return r.cc.ExistsContext(ctx, key)
+}
+
+func (r *replicatedCtx) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.cc.ExpireContext(ctx, key, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Expire(key, ttl) })
+	return nil
+}
+
+func (r *replicatedCtx) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	return r.cc.TTLContext(ctx, key)
+}
+
+func (r *replicatedCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	defer r.lock(key)()
+	ok, err := r.cc.SetNXContext(ctx, key, value, ttl)
+	if ok {
+		r.replicate(func(c _interface.Cache) error { return c.Set(key, value, ttl) })
+	}
+	return ok, err
+}
+
+func (r *replicatedCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	defer r.lock(key)()
+	ok, err := r.cc.CASContext(ctx, key, oldValue, newValue, ttl)
+	if ok {
+		r.replicate(func(c _interface.Cache) error { return c.Set(key, newValue, ttl) })
+	}
+	return ok, err
+}
+
+func (r *replicatedCtx) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	defer r.lock(key)()
+	old, err := r.cc.GetSetContext(ctx, key, value)
+	r.replicate(func(c _interface.Cache) error { return c.Set(key, value, 0) })
+	return old, err
+}
+
+func (r *replicatedCtx) GetDelContext(ctx context.Context, key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.cc.GetDelContext(ctx, key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return c.Delete(key) })
+	}
+	return value, err
+}
+
+func (r *replicatedCtx) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	defer r.lock(key)()
+	n, err := r.cc.AppendContext(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+	r.replicate(func(c _interface.Cache) error {
+		_, err := c.Append(key, value)
+		return err
+	})
+	return n, nil
+}
+
+func (r *replicatedCtx) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return r.cc.StrLenContext(ctx, key)
+}
+
+func (r *replicatedCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.SetContext(ctx, key, string(data), ttl)
+}
+
+func (r *replicatedCtx) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := r.cc.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return r.codec.Unmarshal([]byte(raw), v)
+}
+
+func (r *replicatedCtx) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	return r.cc.KeysContext(ctx, pattern, fn)
+}
+
+func (r *replicatedCtx) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	return r.cc.IterateContext(ctx, prefix, fn)
+}
+
+func (r *replicatedCtx) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	defer r.lockAll()()
+	if err := r.cc.DeleteByPrefixContext(ctx, prefix); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.DeleteByPrefix(prefix) })
+	return nil
+}
+
+func (r *replicatedCtx) HGetContext(ctx context.Context, key, field string) (string, error) {
+	return r.cc.HGetContext(ctx, key, field)
+}
+
+func (r *replicatedCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	defer r.lock(key)()
+	if err := r.cc.HSetContext(ctx, key, field, value, ttl); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.HSet(key, field, value, ttl) })
+	return nil
+}
+
+func (r *replicatedCtx) HDelContext(ctx context.Context, key, field string) error {
+	defer r.lock(key)()
+	if err := r.cc.HDelContext(ctx, key, field); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.HDel(key, field) })
+	return nil
+}
+
+func (r *replicatedCtx) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	return r.cc.HGetAllContext(ctx, key)
+}
+
+func (r *replicatedCtx) HLenContext(ctx context.Context, key string) (int64, error) {
+	return r.cc.HLenContext(ctx, key)
+}
+
+func (r *replicatedCtx) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	return r.cc.HExistsContext(ctx, key, field)
+}
+
+func (r *replicatedCtx) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	return r.cc.HKeysContext(ctx, key)
+}
+
+func (r *replicatedCtx) SAddContext(ctx context.Context, key, member string) error {
+	defer r.lock(key)()
+	if err := r.cc.SAddContext(ctx, key, member); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.SAdd(key, member) })
+	return nil
+}
+
+func (r *replicatedCtx) SRemContext(ctx context.Context, key, member string) error {
+	defer r.lock(key)()
+	if err := r.cc.SRemContext(ctx, key, member); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.SRem(key, member) })
+	return nil
+}
+
+func (r *replicatedCtx) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return r.cc.SMembersContext(ctx, key)
+}
+
+func (r *replicatedCtx) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	return r.cc.SIsMemberContext(ctx, key, member)
+}
+
+func (r *replicatedCtx) PushContext(ctx context.Context, key string, value string) error {
+	defer r.lock(key)()
+	if err := r.cc.PushContext(ctx, key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.Push(key, value) })
+	return nil
+}
+
+func (r *replicatedCtx) LPushContext(ctx context.Context, key string, value string) error {
+	defer r.lock(key)()
+	if err := r.cc.LPushContext(ctx, key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.LPush(key, value) })
+	return nil
+}
+
+func (r *replicatedCtx) RPushContext(ctx context.Context, key string, value string) error {
+	defer r.lock(key)()
+	if err := r.cc.RPushContext(ctx, key, value); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.RPush(key, value) })
+	return nil
+}
+
+func (r *replicatedCtx) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	defer r.lock(key)()
+	if err := r.cc.RPushCappedContext(ctx, key, value, maxLen); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.RPushCapped(key, value, maxLen) })
+	return nil
+}
+
+func (r *replicatedCtx) PopContext(ctx context.Context, key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.cc.PopContext(ctx, key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.Pop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicatedCtx) LPopContext(ctx context.Context, key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.cc.LPopContext(ctx, key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.LPop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicatedCtx) RPopContext(ctx context.Context, key string) (string, error) {
+	defer r.lock(key)()
+	value, err := r.cc.RPopContext(ctx, key)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return discard(c.RPop(key)) })
+	}
+	return value, err
+}
+
+func (r *replicatedCtx) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	defer r.lock(key)()
+	values, err := r.cc.PopAllContext(ctx, key)
+	if err == nil && len(values) > 0 {
+		r.replicate(func(c _interface.Cache) error { return discard(c.PopAll(key)) })
+	}
+	return values, err
+}
+
+func (r *replicatedCtx) LenContext(ctx context.Context, key string) (int64, error) {
+	return r.cc.LenContext(ctx, key)
+}
+
+func (r *replicatedCtx) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return r.cc.LRangeContext(ctx, key, start, stop)
+}
+
+func (r *replicatedCtx) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return r.cc.LIndexContext(ctx, key, index)
+}
+
+func (r *replicatedCtx) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	defer r.lock(key)()
+	removed, err := r.cc.LRemContext(ctx, key, count, value)
+	if err == nil && removed > 0 {
+		r.replicate(func(c _interface.Cache) error { return discard(c.LRem(key, count, value)) })
+	}
+	return removed, err
+}
+
+func (r *replicatedCtx) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	defer r.lock(key)()
+	err := r.cc.LTrimContext(ctx, key, start, stop)
+	if err == nil {
+		r.replicate(func(c _interface.Cache) error { return c.LTrim(key, start, stop) })
+	}
+	return err
+}
+
+func (r *replicatedCtx) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	return r.cc.PopAckContext(ctx, key, visibility)
+}
+
+func (r *replicatedCtx) AckContext(ctx context.Context, key, receipt string) error {
+	return r.cc.AckContext(ctx, key, receipt)
+}
+
+func (r *replicatedCtx) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	defer r.lock(key)()
+	if err := r.cc.PushDelayedContext(ctx, key, value, delay); err != nil {
+		return err
+	}
+	r.replicate(func(c _interface.Cache) error { return c.PushDelayed(key, value, delay) })
+	return nil
+}
+
+func (r *replicatedCtx) PublishContext(ctx context.Context, channel string, payload string) error {
+	return r.cc.PublishContext(ctx, channel, payload)
+}
+
+func (r *replicatedCtx) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	return r.cc.SubscribeContext(ctx, channel)
+}
+
+func (r *replicatedCtx) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return r.cc.SubscribeExpiredContext(ctx)
+}
+
+func (r *replicatedCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return r.cc.StatsContext(ctx)
+}
+
+func (r *replicatedCtx) BackupContext(ctx context.Context, w io.Writer) error {
+	return r.cc.BackupContext(ctx, w)
+}
+
+func (r *replicatedCtx) RestoreContext(ctx context.Context, rd io.Reader) error {
+	return r.cc.RestoreContext(ctx, rd)
+}
+
+func (r *replicatedCtx) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	tx, err := r.cc.BeginTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &replicatedTx{tx: tx, r: r.replicated}, nil
+}
+
+func (r *replicatedCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx, err := r.cc.BeginTxWatchContext(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &replicatedTx{tx: tx, r: r.replicated}, nil
+}
@@ -0,0 +1,803 @@
+// ristretto包：基于Ristretto的高吞吐内存缓存实现
+// 提供键值存储、哈希表操作和事务支持
+//
+// Ristretto是一个高并发、高命中率的内存缓存库，适合读多写少的热点数据
+// 本包实现了Cache接口，提供统一的缓存操作API
+//
+// 主要特性：
+// - 高并发读取，无全局锁
+// - 基于开销（cost）的淘汰策略，开销为key和value的字节数之和
+// - 尽力而为的TTL过期
+// - 哈希表操作（整个哈希表作为一个条目，写入时复制）
+// - 集合操作（整个集合作为一个条目，写入时复制）
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+//
+// 限制：
+// - Ristretto是有损缓存，写入可能被准入策略拒绝，条目也可能随时被淘汰
+// - 因此不支持队列操作，Push/Pop/LPush/RPush/RPushCapped/LPop/RPop/PopAll/Len/LRange/LIndex/LRem/LTrim/PopAck/Ack返回ErrUnsupported
+// - 不支持遍历key，Keys和DeleteByPrefix返回ErrUnsupported
+// - 事务操作在提交时依次执行，不保证原子性
+//
+// 作者: gophertool
+package ristretto
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// 包初始化时注册Ristretto驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverRistretto, NewRistrettoStore)
+}
+
+const (
+	// defaultMaxBytes 未配置MaxBytes时的最大开销（64MB）
+	defaultMaxBytes = 64 << 20
+	// defaultMaxEntries 未配置MaxEntries时预估的最大条目数，用于计算计数器数量
+	defaultMaxEntries = 100000
+)
+
+// 条目类型，同时作为内部键的前缀区分命名空间
+const (
+	kindString = 's'
+	kindHash   = 'h'
+	kindSet    = 'S'
+)
+
+// RistrettoDb Ristretto缓存实现结构体
+type RistrettoDb struct {
+	_interface.CtxAdapter // 带上下文的方法，执行前检查上下文后调用对应的方法
+
+	db     *ristretto.Cache[string, any] // Ristretto实例
+	hashMu sync.Mutex                    // 哈希表和集合读取-修改-写回的互斥锁
+	kvMu   sync.Mutex                    // SetNX/GetSet/GetDel读取-修改-写回的互斥锁
+	broker _interface.Broker             // 进程内的发布订阅
+	codec  _interface.Codec              // SetObject/GetObject的编码方式
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*RistrettoDb)(nil)
+
+// Close 关闭缓存
+func (r *RistrettoDb) Close() {
+	r.db.Close()
+	r.broker.Close()
+}
+
+// Get 获取指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在、已过期或已被淘汰时返回ErrKeyNotFound
+func (r *RistrettoDb) Get(key string) (string, error) {
+	val, ok := r.db.Get(internalKey(kindString, key))
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val.(string), nil
+}
+
+// Set 设置key-value
+// Ristretto的写入是异步的，这里等待写入生效后再返回，保证随后的读取能看到新值
+func (r *RistrettoDb) Set(key string, value string, ttl time.Duration) error {
+	id := internalKey(kindString, key)
+	// Ristretto会丢弃ttl为负数的写入，负数按不过期处理
+	r.db.SetWithTTL(id, value, int64(len(id)+len(value)), max(ttl, 0))
+	r.db.Wait()
+	return nil
+}
+
+func (r *RistrettoDb) Delete(key string) error {
+	r.db.Del(internalKey(kindString, key))
+	return nil
+}
+
+func (r *RistrettoDb) Exists(key string) (bool, error) {
+	_, ok := r.db.Get(internalKey(kindString, key))
+	return ok, nil
+}
+
+// Expire 设置key的过期时间
+// 参数：
+//
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (r *RistrettoDb) Expire(key string, ttl time.Duration) error {
+	val, err := r.Get(key)
+	if err != nil {
+		return err
+	}
+	return r.Set(key, val, ttl)
+}
+
+// TTL 获取key的剩余过期时间
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在、已过期或已被淘汰时返回TTLNotFound
+//	error - 操作错误
+func (r *RistrettoDb) TTL(key string) (time.Duration, error) {
+	ttl, ok := r.db.GetTTL(internalKey(kindString, key))
+	if !ok || ttl < 0 {
+		return _interface.TTLNotFound, nil
+	}
+	if ttl == 0 {
+		return _interface.TTLNoExpiry, nil
+	}
+	return ttl, nil
+}
+
+// SetNX key不存在时设置key-value并设置过期时间
+// 读取和写入在锁内执行，与其他SetNX/GetSet/GetDel互斥；
+// 写入可能被Ristretto的准入策略拒绝，此时仍返回true
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (r *RistrettoDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	r.kvMu.Lock()
+	defer r.kvMu.Unlock()
+
+	if _, ok := r.db.Get(internalKey(kindString, key)); ok {
+		return false, nil
+	}
+	return true, r.Set(key, value, ttl)
+}
+
+// CAS key的当前值等于oldValue时设置为newValue并设置过期时间
+// 读取和写入在锁内执行，与其他SetNX/GetSet/GetDel互斥
+// 参数：
+//
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (r *RistrettoDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	r.kvMu.Lock()
+	defer r.kvMu.Unlock()
+
+	current, ok := r.db.Get(internalKey(kindString, key))
+	if !ok || current != oldValue {
+		return false, nil
+	}
+	return true, r.Set(key, newValue, ttl)
+}
+
+// GetSet 设置新值并返回旧值，新值不过期
+// 参数：
+//
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (r *RistrettoDb) GetSet(key string, value string) (string, error) {
+	r.kvMu.Lock()
+	defer r.kvMu.Unlock()
+
+	old, err := r.Get(key)
+	if setErr := r.Set(key, value, 0); setErr != nil {
+		return "", setErr
+	}
+	return old, err
+}
+
+// GetDel 获取并删除指定key
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (r *RistrettoDb) GetDel(key string) (string, error) {
+	r.kvMu.Lock()
+	defer r.kvMu.Unlock()
+
+	val, err := r.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return val, r.Delete(key)
+}
+
+// Append 将value追加到key的值末尾，读取和写入在锁内执行，与其他SetNX/GetSet/GetDel互斥
+// 追加后按剩余过期时间重新写入，写入可能被Ristretto的准入策略拒绝
+// 参数：
+//
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (r *RistrettoDb) Append(key string, value string) (int64, error) {
+	r.kvMu.Lock()
+	defer r.kvMu.Unlock()
+
+	id := internalKey(kindString, key)
+	var ttl time.Duration
+	if current, ok := r.db.Get(id); ok {
+		value = current.(string) + value
+		if remaining, ok := r.db.GetTTL(id); ok && remaining > 0 {
+			ttl = remaining
+		}
+	}
+	return int64(len(value)), r.Set(key, value, ttl)
+}
+
+// StrLen 返回key的值的长度（字节数），key不存在时返回0
+func (r *RistrettoDb) StrLen(key string) (int64, error) {
+	val, err := r.Get(key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return 0, nil
+	}
+	return int64(len(val)), err
+}
+
+// SetObject 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (r *RistrettoDb) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := r.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return r.Set(key, string(data), ttl)
+}
+
+// GetObject 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (r *RistrettoDb) GetObject(key string, v any) error {
+	raw, err := r.Get(key)
+	if err != nil {
+		return err
+	}
+	return r.codec.Unmarshal([]byte(raw), v)
+}
+
+// Keys Ristretto不支持遍历key，返回ErrUnsupported
+func (r *RistrettoDb) Keys(pattern string, fn func(key string) bool) error {
+	return _interface.ErrUnsupported
+}
+
+// Iterate Ristretto不支持遍历key，返回ErrUnsupported
+func (r *RistrettoDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	return _interface.ErrUnsupported
+}
+
+// DeleteByPrefix Ristretto不支持遍历key，返回ErrUnsupported
+func (r *RistrettoDb) DeleteByPrefix(prefix string) error {
+	return _interface.ErrUnsupported
+}
+
+// HGet 获取哈希表中指定field的值
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在时返回ErrKeyNotFound
+func (r *RistrettoDb) HGet(key, field string) (string, error) {
+	val, ok := r.getHash(key)[field]
+	if !ok {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, nil
+}
+
+// HSet 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 整个哈希表的过期时间，0表示保持原有的过期时间
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RistrettoDb) HSet(key, field, value string, ttl time.Duration) error {
+	return r.updateHash(key, ttl, func(hash map[string]string) {
+		hash[field] = value
+	})
+}
+
+// HDel 删除哈希表中的field，字段全部删除后哈希表本身也会被删除
+func (r *RistrettoDb) HDel(key, field string) error {
+	return r.updateHash(key, 0, func(hash map[string]string) {
+		delete(hash, field)
+	})
+}
+
+// HGetAll 获取哈希表中所有的field和value
+// 参数：
+//
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射（副本）
+//	error - 操作错误
+func (r *RistrettoDb) HGetAll(key string) (map[string]string, error) {
+	hash := r.getHash(key)
+	result := make(map[string]string, len(hash))
+	for field, value := range hash {
+		result[field] = value
+	}
+	return result, nil
+}
+
+// HLen 获取哈希表中field的数量，哈希表不存在时返回0
+func (r *RistrettoDb) HLen(key string) (int64, error) {
+	return int64(len(r.getHash(key))), nil
+}
+
+// HExists 判断哈希表中是否存在field
+func (r *RistrettoDb) HExists(key, field string) (bool, error) {
+	_, ok := r.getHash(key)[field]
+	return ok, nil
+}
+
+// HKeys 获取哈希表中所有的field，字段顺序不固定，哈希表不存在时返回空切片
+func (r *RistrettoDb) HKeys(key string) ([]string, error) {
+	hash := r.getHash(key)
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// getHash 返回缓存中的哈希表，返回值只读
+func (r *RistrettoDb) getHash(key string) map[string]string {
+	val, ok := r.db.Get(internalKey(kindHash, key))
+	if !ok {
+		return nil
+	}
+	return val.(map[string]string)
+}
+
+// updateHash 复制哈希表、修改后整体写回
+// 缓存中的哈希表可能正在被并发读取，因此不能原地修改
+func (r *RistrettoDb) updateHash(key string, ttl time.Duration, update func(hash map[string]string)) error {
+	r.hashMu.Lock()
+	defer r.hashMu.Unlock()
+
+	id := internalKey(kindHash, key)
+	old := r.getHash(key)
+	hash := make(map[string]string, len(old)+1)
+	cost := int64(len(id))
+	for field, value := range old {
+		hash[field] = value
+	}
+	update(hash)
+
+	if len(hash) == 0 {
+		r.db.Del(id)
+		return nil
+	}
+	for field, value := range hash {
+		cost += int64(len(field) + len(value))
+	}
+
+	// 未指定TTL时保留原有的剩余过期时间
+	if ttl <= 0 && old != nil {
+		if remaining, ok := r.db.GetTTL(id); ok {
+			ttl = remaining
+		}
+	}
+	r.db.SetWithTTL(id, hash, cost, max(ttl, 0))
+	r.db.Wait()
+	return nil
+}
+
+// SAdd 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RistrettoDb) SAdd(key, member string) error {
+	return r.updateSet(key, func(set map[string]struct{}) {
+		set[member] = struct{}{}
+	})
+}
+
+// SRem 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RistrettoDb) SRem(key, member string) error {
+	return r.updateSet(key, func(set map[string]struct{}) {
+		delete(set, member)
+	})
+}
+
+// SMembers 获取集合的所有成员，成员顺序不固定
+// 参数：
+//
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (r *RistrettoDb) SMembers(key string) ([]string, error) {
+	set := r.getSet(key)
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// SIsMember 判断member是否为集合成员
+// 参数：
+//
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (r *RistrettoDb) SIsMember(key, member string) (bool, error) {
+	_, ok := r.getSet(key)[member]
+	return ok, nil
+}
+
+// getSet 返回缓存中的集合，返回值只读
+func (r *RistrettoDb) getSet(key string) map[string]struct{} {
+	val, ok := r.db.Get(internalKey(kindSet, key))
+	if !ok {
+		return nil
+	}
+	return val.(map[string]struct{})
+}
+
+// updateSet 复制集合、修改后整体写回，与updateHash一样不能原地修改
+func (r *RistrettoDb) updateSet(key string, update func(set map[string]struct{})) error {
+	r.hashMu.Lock()
+	defer r.hashMu.Unlock()
+
+	id := internalKey(kindSet, key)
+	old := r.getSet(key)
+	set := make(map[string]struct{}, len(old)+1)
+	for member := range old {
+		set[member] = struct{}{}
+	}
+	update(set)
+
+	if len(set) == 0 {
+		r.db.Del(id)
+		return nil
+	}
+	cost := int64(len(id))
+	for member := range set {
+		cost += int64(len(member))
+	}
+	r.db.Set(id, set, cost)
+	r.db.Wait()
+	return nil
+}
+
+// Ristretto可能随时丢弃条目，不适合保存队列，队列操作统一返回ErrUnsupported
+
+func (r *RistrettoDb) Push(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LPush(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) RPush(key string, value string) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) RPushCapped(key string, value string, maxLen int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) Pop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LPop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) RPop(key string) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) PopAll(key string) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) Len(key string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LRange(key string, start, stop int64) ([]string, error) {
+	return nil, _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LIndex(key string, index int64) (string, error) {
+	return "", _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LRem(key string, count int64, value string) (int64, error) {
+	return 0, _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) LTrim(key string, start, stop int64) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return "", "", _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) Ack(key, receipt string) error {
+	return _interface.ErrUnsupported
+}
+
+func (r *RistrettoDb) PushDelayed(key, value string, delay time.Duration) error {
+	return _interface.ErrUnsupported
+}
+
+// ristrettoTx Ristretto事务实现
+// 操作先缓存在内存中，Commit时按顺序执行
+type ristrettoTx struct {
+	db    *RistrettoDb
+	ops   []func() error
+	watch _interface.WatchSnapshot // BeginTxWatch监视的key，为nil表示不监视
+}
+
+// Get 事务内的写入在提交时才执行，读取的是已提交的值
+func (tx *ristrettoTx) Get(key string) (string, error) {
+	return tx.db.Get(key)
+}
+
+func (tx *ristrettoTx) Exists(key string) (bool, error) {
+	return tx.db.Exists(key)
+}
+
+func (tx *ristrettoTx) Set(key string, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.Set(key, value, ttl)
+	})
+	return nil
+}
+
+func (tx *ristrettoTx) Delete(key string) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.Delete(key)
+	})
+	return nil
+}
+
+// Expire 在提交时执行，key 不存在时不做任何操作
+func (tx *ristrettoTx) Expire(key string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		if err := tx.db.Expire(key, ttl); !errors.Is(err, _interface.ErrKeyNotFound) {
+			return err
+		}
+		return nil
+	})
+	return nil
+}
+
+func (tx *ristrettoTx) HSet(key, field, value string, ttl time.Duration) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.HSet(key, field, value, ttl)
+	})
+	return nil
+}
+
+func (tx *ristrettoTx) HDel(key, field string) error {
+	tx.ops = append(tx.ops, func() error {
+		return tx.db.HDel(key, field)
+	})
+	return nil
+}
+
+// Commit 按顺序执行所有操作
+// 监视了key时检查和执行在SetNX/GetSet/GetDel的锁内进行，监视的key被修改时返回ErrTxConflict
+func (tx *ristrettoTx) Commit() error {
+	ops := tx.ops
+	tx.ops = nil
+	if tx.watch != nil {
+		tx.db.kvMu.Lock()
+		defer tx.db.kvMu.Unlock()
+		if err := tx.watch.Check(tx.db.Get); err != nil {
+			return err
+		}
+	}
+	for _, op := range ops {
+		if err := op(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *ristrettoTx) Rollback() error {
+	tx.ops = nil
+	return nil
+}
+
+// Publish 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (r *RistrettoDb) Publish(channel string, payload string) error {
+	return r.broker.Publish(channel, payload)
+}
+
+// Subscribe 订阅频道，只能收到同一个缓存实例发布的消息
+// 参数：
+//
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (r *RistrettoDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return r.broker.Subscribe(channel)
+}
+
+// SubscribeExpired Ristretto的过期清理和容量淘汰使用同一个回调，无法区分，返回已关闭的通道
+func (r *RistrettoDb) SubscribeExpired() (<-chan string, func()) {
+	return _interface.ClosedExpiry(), func() {}
+}
+
+func (r *RistrettoDb) BeginTx() (_interface.Tx, error) {
+	return &ristrettoTx{db: r}, nil
+}
+
+// BeginTxWatch 开启监视keys的事务，提交时比较keys的值，与开启时不同则返回ErrTxConflict
+// 与SetNX一样，比较和写入只与SetNX/GetSet/GetDel/CAS和其他监视事务互斥
+func (r *RistrettoDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	watch, err := _interface.NewWatchSnapshot(r.Get, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &ristrettoTx{db: r, watch: watch}, nil
+}
+
+func internalKey(kind byte, key string) string {
+	return string(kind) + key
+}
+
+// Stats 返回Ristretto的统计信息
+// 键数量和内存占用无法准确获取，淘汰数来自Ristretto的内部指标，Extra中包含最大开销
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 总是nil
+func (r *RistrettoDb) Stats() (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverRistretto)
+	stats.DiskBytes = 0
+	stats.Evictions = int64(r.db.Metrics.KeysEvicted())
+	stats.Extra = map[string]string{"max_cost": strconv.FormatInt(r.db.MaxCost(), 10)}
+	return stats, nil
+}
+
+// Backup Ristretto无法遍历已写入的键
+// 返回值：
+//
+//	error - 总是ErrUnsupported
+func (r *RistrettoDb) Backup(w io.Writer) error {
+	return _interface.ErrUnsupported
+}
+
+// Restore Ristretto没有可恢复的备份
+// 返回值：
+//
+//	error - 总是ErrUnsupported
+func (r *RistrettoDb) Restore(rd io.Reader) error {
+	return _interface.ErrUnsupported
+}
+
+// NewRistrettoStore 创建Ristretto缓存
+// config.MaxBytes为最大开销（字节数），config.MaxEntries为预估的最大条目数，
+// 按Ristretto的建议，计数器数量取最大条目数的10倍
+func NewRistrettoStore(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	maxBytes := config.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	maxEntries := int64(config.MaxEntries)
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	db, err := ristretto.NewCache(&ristretto.Config[string, any]{
+		NumCounters: maxEntries * 10,
+		MaxCost:     maxBytes,
+		BufferItems: 64,
+		// 开销已经按字节数计算，不再额外计入内部结构的开销
+		IgnoreInternalCost: true,
+		// 只用于Stats中的淘汰数
+		Metrics: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := &RistrettoDb{db: db, codec: codec}
+	r.CtxAdapter = _interface.NewCtxAdapter(r)
+	return r, nil
+}
@@ -0,0 +1,1588 @@
+// cache包：分片缓存
+// 数据量超过单个节点时，按一致性哈希把键分散到多个缓存实例上，分片可以是不同的驱动
+//
+// 主要特性：
+// - 每个分片按名称在哈希环上放置多个虚拟节点，增删一个分片只影响约1/N的键
+// - 单键操作（键值、哈希表、集合、队列、布隆过滤器）路由到键所在的分片，频道按频道名路由
+// - Keys、Iterate、DeleteByPrefix和SubscribeExpired作用于所有分片，Stats汇总所有分片的统计信息
+// - 后台定期检查每个分片的健康状态，访问不健康分片的操作立即返回ErrShardUnavailable；开启EjectUnhealthy时不健康的分片暂时移出哈希环，其键由哈希环上的下一个分片处理
+// - SetShards修改分片之后、Rebalance完成之前，键值的读取在新分片上未命中时回退到旧分片，Delete/GetDel/Expire同时作用于旧分片
+// - Rebalance把位置不正确的键迁移到当前哈希环上的分片
+// - 事务的所有键必须在同一个分片上，否则返回ErrCrossShard
+// - 分片可以不实现CacheCtx，Sharded总是实现CacheCtx，这些分片只在操作前检查上下文
+//
+// 使用限制：
+// - 重新平衡期间SetNX/CAS/GetSet只检查新分片，哈希表、集合和队列在Rebalance之前只能读到新分片上的数据
+// - 分片可能是不同的驱动，Backup/Restore返回ErrUnsupported，需要分别备份每个分片
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+const (
+	// defaultVirtualNodes 每个分片在哈希环上的默认虚拟节点数
+	defaultVirtualNodes = 160
+	// defaultHealthCheckInterval 默认的健康检查间隔
+	defaultHealthCheckInterval = 10 * time.Second
+	// defaultHealthCheckTimeout 默认的单次健康检查超时
+	defaultHealthCheckTimeout = 2 * time.Second
+	// healthCheckKey 健康检查时查询的键
+	healthCheckKey = "cache:sharded:health"
+)
+
+var (
+	// ErrShardUnavailable 键所在的分片不健康，或开启EjectUnhealthy时所有分片都不健康
+	ErrShardUnavailable = errors.New("cache: shard unavailable")
+
+	// ErrCrossShard 事务中的键不在同一个分片上
+	ErrCrossShard = errors.New("cache: keys belong to different shards")
+)
+
+// Shard 分片缓存中的一个分片
+type Shard struct {
+	Name  string           // 分片名称，决定分片在哈希环上的位置，增删分片时已有分片的名称不能改变
+	Cache _interface.Cache // 分片的缓存实例，由创建它的一方关闭
+}
+
+// ShardedOptions 分片缓存的选项
+type ShardedOptions struct {
+	VirtualNodes        int                                         // 每个分片的虚拟节点数，0表示160
+	HealthCheckInterval time.Duration                               // 健康检查间隔，0表示10秒，负数表示不检查
+	HealthCheckTimeout  time.Duration                               // 单次健康检查的超时，0表示2秒
+	EjectUnhealthy      bool                                        // 不健康的分片暂时移出哈希环，而不是返回ErrShardUnavailable
+	OnHealthChange      func(shard string, healthy bool, err error) // 分片健康状态变化的回调
+}
+
+// ShardHealth 分片的健康状态
+type ShardHealth struct {
+	Name      string
+	Healthy   bool
+	Err       error     // 最近一次检查的错误，健康时为nil
+	CheckedAt time.Time // 最近一次检查的时间，还没有检查过时为零值
+}
+
+// RebalanceOptions 重新平衡的选项
+// Cache接口无法区分key的类型，需要迁移的哈希表、集合和队列需要列出，
+// Keys返回的这些key以及以其加":"或"\x00"开头的复合键不会按键值迁移
+type RebalanceOptions struct {
+	Hashes []string // 需要迁移的哈希表
+	Sets   []string // 需要迁移的集合
+	Queues []string // 需要迁移的队列
+}
+
+// shardState 分片及其健康状态，同名的分片在SetShards前后共用同一个shardState
+type shardState struct {
+	name    string
+	c       _interface.CacheCtx
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+}
+
+// ctxShard 为没有实现CacheCtx的分片提供带上下文的方法
+type ctxShard struct {
+	_interface.Cache
+	_interface.CtxAdapter
+}
+
+// hashRing 一致性哈希环
+type hashRing struct {
+	points []uint64
+	owners []*shardState
+}
+
+// ringPoint 哈希环上的一个虚拟节点
+type ringPoint struct {
+	hash  uint64
+	owner *shardState
+}
+
+// newHashRing 为每个分片放置vnodes个虚拟节点
+func newHashRing(shards []*shardState, vnodes int) *hashRing {
+	points := make([]ringPoint, 0, len(shards)*vnodes)
+	for _, shard := range shards {
+		for i := range vnodes {
+			points = append(points, ringPoint{hash: ringHash(shard.name + "#" + strconv.Itoa(i)), owner: shard})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].hash != points[j].hash {
+			return points[i].hash < points[j].hash
+		}
+		return points[i].owner.name < points[j].owner.name
+	})
+
+	r := &hashRing{points: make([]uint64, len(points)), owners: make([]*shardState, len(points))}
+	for i, p := range points {
+		r.points[i], r.owners[i] = p.hash, p.owner
+	}
+	return r
+}
+
+// ringHash FNV-1a之后再做一次混合，短键在哈希环上也能分布均匀
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// locate 返回key所在的分片，skipUnhealthy为true时顺着哈希环跳过不健康的分片，没有可用的分片时返回nil
+func (r *hashRing) locate(key string, skipUnhealthy bool) *shardState {
+	if len(r.points) == 0 {
+		return nil
+	}
+	h := ringHash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	for n := range len(r.points) {
+		owner := r.owners[(i+n)%len(r.points)]
+		if !skipUnhealthy || owner.healthy.Load() {
+			return owner
+		}
+	}
+	return nil
+}
+
+// Sharded 分片缓存，实现CacheCtx接口，可以并发使用
+type Sharded struct {
+	opts ShardedOptions
+
+	mu     sync.RWMutex
+	ring   *hashRing
+	prev   *hashRing              // SetShards之前的哈希环，Rebalance完成之前用于回退读取
+	states map[string]*shardState // 当前和重新平衡期间旧哈希环上的所有分片
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// 确保实现了带上下文的缓存接口和布隆过滤器接口
+var (
+	_ _interface.CacheCtx = (*Sharded)(nil)
+	_ _interface.Bloom    = (*Sharded)(nil)
+)
+
+// NewSharded 创建分片缓存
+// 参数：
+//
+//	shards - 分片，名称不能为空且不能重复
+//	opts - 分片选项
+//
+// 返回值：
+//
+//	*Sharded - 分片缓存实例，Close停止健康检查，分片由创建它们的一方关闭
+//	error - 没有分片、分片名称为空或重复
+func NewSharded(shards []Shard, opts ShardedOptions) (*Sharded, error) {
+	if opts.VirtualNodes <= 0 {
+		opts.VirtualNodes = defaultVirtualNodes
+	}
+	if opts.HealthCheckInterval == 0 {
+		opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+
+	s := &Sharded{opts: opts, states: make(map[string]*shardState), stop: make(chan struct{})}
+	if err := s.SetShards(shards); err != nil {
+		return nil, err
+	}
+	// 新建的实例没有需要回退读取的旧分片
+	s.prev = nil
+
+	if opts.HealthCheckInterval > 0 {
+		s.wg.Add(1)
+		go s.healthLoop()
+	}
+	return s, nil
+}
+
+// SetShards 修改分片，之后调用Rebalance把位置不正确的键迁移到新的分片
+// 名称与已有分片相同且缓存实例相同的分片保留原有的健康状态；
+// 上一次修改的重新平衡还没有完成时，那一次修改之前的分片不再参与回退读取
+// 参数：
+//
+//	shards - 新的分片，名称不能为空且不能重复
+//
+// 返回值：
+//
+//	error - 没有分片、分片名称为空或重复，此时分片不变
+func (s *Sharded) SetShards(shards []Shard) error {
+	if len(shards) == 0 {
+		return errors.New("cache: sharded cache needs at least one shard")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make([]*shardState, 0, len(shards))
+	seen := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		if shard.Name == "" || shard.Cache == nil {
+			return errors.New("cache: shard needs a name and a cache")
+		}
+		if seen[shard.Name] {
+			return fmt.Errorf("cache: duplicate shard %q", shard.Name)
+		}
+		seen[shard.Name] = true
+
+		if state, ok := s.states[shard.Name]; ok && sameCache(state.c, shard.Cache) {
+			next = append(next, state)
+			continue
+		}
+		state := &shardState{name: shard.Name, c: asCacheCtx(shard.Cache)}
+		state.healthy.Store(true)
+		next = append(next, state)
+	}
+
+	s.prev = s.ring
+	s.ring = newHashRing(next, s.opts.VirtualNodes)
+	s.states = make(map[string]*shardState, len(next))
+	for _, state := range next {
+		s.states[state.name] = state
+	}
+	if s.prev != nil {
+		for _, state := range s.prev.owners {
+			if _, ok := s.states[state.name]; !ok {
+				s.states[state.name] = state
+			}
+		}
+	}
+	return nil
+}
+
+// sameCache 判断分片的缓存实例是否为c，没有实现CacheCtx的分片比较包装之前的实例
+func sameCache(cc _interface.CacheCtx, c _interface.Cache) bool {
+	if wrapped, ok := cc.(ctxShard); ok {
+		return wrapped.Cache == c
+	}
+	return _interface.Cache(cc) == c
+}
+
+// asCacheCtx 没有实现CacheCtx的分片使用CtxAdapter，带上下文的方法只在操作前检查上下文
+func asCacheCtx(c _interface.Cache) _interface.CacheCtx {
+	if cc, ok := c.(_interface.CacheCtx); ok {
+		return cc
+	}
+	return ctxShard{Cache: c, CtxAdapter: _interface.NewCtxAdapter(c)}
+}
+
+// Owner 返回key在当前哈希环上所在的分片名称，不考虑分片的健康状态
+func (s *Sharded) Owner(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ring.locate(key, false).name
+}
+
+// Health 返回所有分片的健康状态，按名称排列
+func (s *Sharded) Health() []ShardHealth {
+	health := make([]ShardHealth, 0)
+	for _, state := range s.snapshot() {
+		state.mu.Lock()
+		health = append(health, ShardHealth{Name: state.name, Healthy: state.healthy.Load(), Err: state.err, CheckedAt: state.checkedAt})
+		state.mu.Unlock()
+	}
+	return health
+}
+
+// snapshot 返回当前和旧哈希环上的所有分片，按名称排列
+func (s *Sharded) snapshot() []*shardState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	states := slices.Collect(maps.Values(s.states))
+	sort.Slice(states, func(i, j int) bool { return states[i].name < states[j].name })
+	return states
+}
+
+// healthLoop 定期检查所有分片
+func (s *Sharded) healthLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.opts.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.CheckHealth()
+		}
+	}
+}
+
+// CheckHealth 立即检查所有分片的健康状态，返回不健康的分片数
+// 检查方式是带超时地查询一个键是否存在，状态变化时调用OnHealthChange
+func (s *Sharded) CheckHealth() int {
+	var wg sync.WaitGroup
+	var unhealthy atomic.Int32
+	for _, state := range s.snapshot() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), s.opts.HealthCheckTimeout)
+			_, err := state.c.ExistsContext(ctx, healthCheckKey)
+			cancel()
+			if err != nil {
+				unhealthy.Add(1)
+			}
+			s.setHealth(state, err)
+		}()
+	}
+	wg.Wait()
+	return int(unhealthy.Load())
+}
+
+// setHealth 记录一次检查的结果
+func (s *Sharded) setHealth(state *shardState, err error) {
+	state.mu.Lock()
+	state.err = err
+	state.checkedAt = time.Now()
+	changed := state.healthy.Swap(err == nil) != (err == nil)
+	state.mu.Unlock()
+	if changed && s.opts.OnHealthChange != nil {
+		s.opts.OnHealthChange(state.name, err == nil, err)
+	}
+}
+
+// Close 停止健康检查，分片由创建它们的一方关闭
+func (s *Sharded) Close() {
+	select {
+	case <-s.stop:
+		return
+	default:
+		close(s.stop)
+	}
+	s.wg.Wait()
+}
+
+// route 返回key所在的分片
+func (s *Sharded) route(key string) (*shardState, error) {
+	s.mu.RLock()
+	ring := s.ring
+	s.mu.RUnlock()
+
+	shard := ring.locate(key, s.opts.EjectUnhealthy)
+	if shard == nil {
+		return nil, ErrShardUnavailable
+	}
+	if !shard.healthy.Load() {
+		return nil, fmt.Errorf("%w: %s", ErrShardUnavailable, shard.name)
+	}
+	return shard, nil
+}
+
+// previous 返回重新平衡期间key在旧哈希环上的分片，没有进行中的重新平衡、与current相同或不健康时返回nil
+func (s *Sharded) previous(key string, current *shardState) *shardState {
+	s.mu.RLock()
+	prev := s.prev
+	s.mu.RUnlock()
+	if prev == nil {
+		return nil
+	}
+	shard := prev.locate(key, s.opts.EjectUnhealthy)
+	if shard == nil || shard == current || !shard.healthy.Load() {
+		return nil
+	}
+	return shard
+}
+
+// Rebalance 把位置不正确的键迁移到当前哈希环上的分片，完成后停止回退读取
+// 遍历当前和旧哈希环上的所有分片，因此重启后使用新的分片配置时也可以调用；
+// 键值使用SetNX写入新分片，新分片上已经有的值（修改分片之后写入的）不会被覆盖；
+// 队列的元素放回新队列的头部，哈希表的字段和集合的成员合并到新分片
+// 参数：
+//
+//	ctx - 上下文，取消后在下一个key之前停止
+//	opts - 需要迁移的哈希表、集合和队列
+//
+// 返回值：
+//
+//	int64 - 迁移的key数
+//	error - 读取或写入错误，已迁移的key不会回滚
+func (s *Sharded) Rebalance(ctx context.Context, opts RebalanceOptions) (int64, error) {
+	s.mu.RLock()
+	ring := s.ring
+	s.mu.RUnlock()
+
+	structures := make(map[string]struct{})
+	for _, list := range [][]string{opts.Hashes, opts.Sets, opts.Queues} {
+		for _, key := range list {
+			structures[key] = struct{}{}
+		}
+	}
+
+	var moved int64
+	for _, src := range s.snapshot() {
+		var keys []string
+		err := src.c.KeysContext(ctx, "", func(key string) bool {
+			if !isStructureKey(structures, key) && ring.locate(key, false) != src {
+				keys = append(keys, key)
+			}
+			return true
+		})
+		if err != nil {
+			return moved, fmt.Errorf("cache: scan shard %s: %w", src.name, err)
+		}
+		for _, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return moved, err
+			}
+			ok, err := moveKey(ctx, src.c, ring.locate(key, false).c, key)
+			if err != nil {
+				return moved, fmt.Errorf("cache: move %q from shard %s: %w", key, src.name, err)
+			}
+			if ok {
+				moved++
+			}
+		}
+
+		for _, move := range []struct {
+			keys []string
+			fn   func(ctx context.Context, src, dst _interface.CacheCtx, key string) (bool, error)
+		}{{opts.Hashes, moveHash}, {opts.Sets, moveSet}, {opts.Queues, moveQueue}} {
+			for _, key := range move.keys {
+				dst := ring.locate(key, false)
+				if dst == src {
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					return moved, err
+				}
+				ok, err := move.fn(ctx, src.c, dst.c, key)
+				if err != nil {
+					return moved, fmt.Errorf("cache: move %q from shard %s: %w", key, src.name, err)
+				}
+				if ok {
+					moved++
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	if s.ring == ring {
+		s.prev = nil
+		s.states = make(map[string]*shardState)
+		for _, state := range ring.owners {
+			s.states[state.name] = state
+		}
+	}
+	s.mu.Unlock()
+	return moved, nil
+}
+
+// isStructureKey 判断key是否为列出的哈希表、集合、队列或其复合键
+func isStructureKey(structures map[string]struct{}, key string) bool {
+	if _, ok := structures[key]; ok {
+		return true
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] != ':' && key[i] != '\x00' {
+			continue
+		}
+		if _, ok := structures[key[:i]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// moveKey 迁移键值和剩余过期时间，目标分片上已有的值保留
+func moveKey(ctx context.Context, src, dst _interface.CacheCtx, key string) (bool, error) {
+	value, err := src.GetContext(ctx, key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	ttl, err := src.TTLContext(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case ttl == _interface.TTLNotFound:
+		return false, nil
+	case ttl < 0:
+		ttl = 0
+	}
+	if _, err := dst.SetNXContext(ctx, key, value, ttl); err != nil {
+		return false, err
+	}
+	return true, src.DeleteContext(ctx, key)
+}
+
+// moveHash 把哈希表的字段合并到目标分片
+func moveHash(ctx context.Context, src, dst _interface.CacheCtx, key string) (bool, error) {
+	fields, err := src.HGetAllContext(ctx, key)
+	if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, err
+	}
+	if len(fields) == 0 {
+		return false, nil
+	}
+	ttl, err := src.TTLContext(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	ttl = max(ttl, 0)
+	for field, value := range fields {
+		if err := dst.HSetContext(ctx, key, field, value, ttl); err != nil {
+			return false, err
+		}
+	}
+	for field := range fields {
+		if err := src.HDelContext(ctx, key, field); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// moveSet 把集合的成员合并到目标分片
+func moveSet(ctx context.Context, src, dst _interface.CacheCtx, key string) (bool, error) {
+	members, err := src.SMembersContext(ctx, key)
+	if err != nil || len(members) == 0 {
+		return false, err
+	}
+	for _, member := range members {
+		if err := dst.SAddContext(ctx, key, member); err != nil {
+			return false, err
+		}
+	}
+	for _, member := range members {
+		if err := src.SRemContext(ctx, key, member); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// moveQueue 把队列的元素按原顺序放回目标分片上队列的头部
+func moveQueue(ctx context.Context, src, dst _interface.CacheCtx, key string) (bool, error) {
+	items, err := src.PopAllContext(ctx, key)
+	if err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return false, err
+	}
+	if len(items) == 0 {
+		return false, nil
+	}
+	for i := len(items) - 1; i >= 0; i-- {
+		if err := dst.LPushContext(ctx, key, items[i]); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *Sharded) Get(key string) (string, error) {
+	return s.GetContext(context.Background(), key)
+}
+
+func (s *Sharded) Set(key string, value string, ttl time.Duration) error {
+	return s.SetContext(context.Background(), key, value, ttl)
+}
+
+func (s *Sharded) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+func (s *Sharded) Exists(key string) (bool, error) {
+	return s.ExistsContext(context.Background(), key)
+}
+
+func (s *Sharded) Expire(key string, ttl time.Duration) error {
+	return s.ExpireContext(context.Background(), key, ttl)
+}
+
+func (s *Sharded) TTL(key string) (time.Duration, error) {
+	return s.TTLContext(context.Background(), key)
+}
+
+func (s *Sharded) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return s.SetNXContext(context.Background(), key, value, ttl)
+}
+
+func (s *Sharded) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return s.CASContext(context.Background(), key, oldValue, newValue, ttl)
+}
+
+func (s *Sharded) GetSet(key string, value string) (string, error) {
+	return s.GetSetContext(context.Background(), key, value)
+}
+
+func (s *Sharded) GetDel(key string) (string, error) {
+	return s.GetDelContext(context.Background(), key)
+}
+
+func (s *Sharded) Append(key string, value string) (int64, error) {
+	return s.AppendContext(context.Background(), key, value)
+}
+
+func (s *Sharded) StrLen(key string) (int64, error) {
+	return s.StrLenContext(context.Background(), key)
+}
+
+func (s *Sharded) SetObject(key string, v any, ttl time.Duration) error {
+	return s.SetObjectContext(context.Background(), key, v, ttl)
+}
+
+func (s *Sharded) GetObject(key string, v any) error {
+	return s.GetObjectContext(context.Background(), key, v)
+}
+
+func (s *Sharded) Keys(pattern string, fn func(key string) bool) error {
+	return s.KeysContext(context.Background(), pattern, fn)
+}
+
+func (s *Sharded) Iterate(prefix string, fn func(key, value string) bool) error {
+	return s.IterateContext(context.Background(), prefix, fn)
+}
+
+func (s *Sharded) DeleteByPrefix(prefix string) error {
+	return s.DeleteByPrefixContext(context.Background(), prefix)
+}
+
+func (s *Sharded) HGet(key, field string) (string, error) {
+	return s.HGetContext(context.Background(), key, field)
+}
+
+func (s *Sharded) HSet(key, field, value string, ttl time.Duration) error {
+	return s.HSetContext(context.Background(), key, field, value, ttl)
+}
+
+func (s *Sharded) HDel(key, field string) error {
+	return s.HDelContext(context.Background(), key, field)
+}
+
+func (s *Sharded) HGetAll(key string) (map[string]string, error) {
+	return s.HGetAllContext(context.Background(), key)
+}
+
+func (s *Sharded) HLen(key string) (int64, error) {
+	return s.HLenContext(context.Background(), key)
+}
+
+func (s *Sharded) HExists(key, field string) (bool, error) {
+	return s.HExistsContext(context.Background(), key, field)
+}
+
+func (s *Sharded) HKeys(key string) ([]string, error) {
+	return s.HKeysContext(context.Background(), key)
+}
+
+func (s *Sharded) SAdd(key, member string) error {
+	return s.SAddContext(context.Background(), key, member)
+}
+
+func (s *Sharded) SRem(key, member string) error {
+	return s.SRemContext(context.Background(), key, member)
+}
+
+func (s *Sharded) SMembers(key string) ([]string, error) {
+	return s.SMembersContext(context.Background(), key)
+}
+
+func (s *Sharded) SIsMember(key, member string) (bool, error) {
+	return s.SIsMemberContext(context.Background(), key, member)
+}
+
+func (s *Sharded) Push(key string, value string) error {
+	return s.PushContext(context.Background(), key, value)
+}
+
+func (s *Sharded) LPush(key string, value string) error {
+	return s.LPushContext(context.Background(), key, value)
+}
+
+func (s *Sharded) RPush(key string, value string) error {
+	return s.RPushContext(context.Background(), key, value)
+}
+
+func (s *Sharded) RPushCapped(key string, value string, maxLen int64) error {
+	return s.RPushCappedContext(context.Background(), key, value, maxLen)
+}
+
+func (s *Sharded) Pop(key string) (string, error) {
+	return s.PopContext(context.Background(), key)
+}
+
+func (s *Sharded) LPop(key string) (string, error) {
+	return s.LPopContext(context.Background(), key)
+}
+
+func (s *Sharded) RPop(key string) (string, error) {
+	return s.RPopContext(context.Background(), key)
+}
+
+func (s *Sharded) PopAll(key string) ([]string, error) {
+	return s.PopAllContext(context.Background(), key)
+}
+
+func (s *Sharded) Len(key string) (int64, error) {
+	return s.LenContext(context.Background(), key)
+}
+
+func (s *Sharded) LRange(key string, start, stop int64) ([]string, error) {
+	return s.LRangeContext(context.Background(), key, start, stop)
+}
+
+func (s *Sharded) LIndex(key string, index int64) (string, error) {
+	return s.LIndexContext(context.Background(), key, index)
+}
+
+func (s *Sharded) LRem(key string, count int64, value string) (int64, error) {
+	return s.LRemContext(context.Background(), key, count, value)
+}
+
+func (s *Sharded) LTrim(key string, start, stop int64) error {
+	return s.LTrimContext(context.Background(), key, start, stop)
+}
+
+func (s *Sharded) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return s.PopAckContext(context.Background(), key, visibility)
+}
+
+func (s *Sharded) Ack(key, receipt string) error {
+	return s.AckContext(context.Background(), key, receipt)
+}
+
+func (s *Sharded) PushDelayed(key, value string, delay time.Duration) error {
+	return s.PushDelayedContext(context.Background(), key, value, delay)
+}
+
+func (s *Sharded) Publish(channel string, payload string) error {
+	return s.PublishContext(context.Background(), channel, payload)
+}
+
+func (s *Sharded) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return s.SubscribeContext(context.Background(), channel)
+}
+
+func (s *Sharded) SubscribeExpired() (<-chan string, func()) {
+	return s.SubscribeExpiredContext(context.Background())
+}
+
+func (s *Sharded) Stats() (_interface.Stats, error) {
+	return s.StatsContext(context.Background())
+}
+
+func (s *Sharded) Backup(w io.Writer) error {
+	return s.BackupContext(context.Background(), w)
+}
+
+func (s *Sharded) Restore(r io.Reader) error {
+	return s.RestoreContext(context.Background(), r)
+}
+
+func (s *Sharded) BeginTx() (_interface.Tx, error) {
+	return s.BeginTxContext(context.Background())
+}
+
+func (s *Sharded) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return s.BeginTxWatchContext(context.Background(), keys...)
+}
+
+// GetContext 获取key的值，重新平衡期间新分片上未命中时读取旧分片
+func (s *Sharded) GetContext(ctx context.Context, key string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	value, err := shard.c.GetContext(ctx, key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.GetContext(ctx, key)
+		}
+	}
+	return value, err
+}
+
+func (s *Sharded) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.SetContext(ctx, key, value, ttl)
+}
+
+// DeleteContext 删除key，重新平衡期间同时删除旧分片上的值
+func (s *Sharded) DeleteContext(ctx context.Context, key string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	if err := shard.c.DeleteContext(ctx, key); err != nil {
+		return err
+	}
+	if prev := s.previous(key, shard); prev != nil {
+		return prev.c.DeleteContext(ctx, key)
+	}
+	return nil
+}
+
+// ExistsContext 判断key是否存在，重新平衡期间新分片上不存在时检查旧分片
+func (s *Sharded) ExistsContext(ctx context.Context, key string) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	ok, err := shard.c.ExistsContext(ctx, key)
+	if err == nil && !ok {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.ExistsContext(ctx, key)
+		}
+	}
+	return ok, err
+}
+
+// ExpireContext 设置key的过期时间，重新平衡期间同时设置旧分片上的值
+func (s *Sharded) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	if err := shard.c.ExpireContext(ctx, key, ttl); err != nil {
+		return err
+	}
+	if prev := s.previous(key, shard); prev != nil {
+		return prev.c.ExpireContext(ctx, key, ttl)
+	}
+	return nil
+}
+
+// TTLContext 获取key的剩余过期时间，重新平衡期间新分片上不存在时查询旧分片
+func (s *Sharded) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := shard.c.TTLContext(ctx, key)
+	if err == nil && ttl == _interface.TTLNotFound {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.TTLContext(ctx, key)
+		}
+	}
+	return ttl, err
+}
+
+func (s *Sharded) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	return shard.c.SetNXContext(ctx, key, value, ttl)
+}
+
+func (s *Sharded) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	return shard.c.CASContext(ctx, key, oldValue, newValue, ttl)
+}
+
+func (s *Sharded) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.GetSetContext(ctx, key, value)
+}
+
+// GetDelContext 获取并删除key，重新平衡期间新分片上未命中时读取并删除旧分片上的值
+func (s *Sharded) GetDelContext(ctx context.Context, key string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	value, err := shard.c.GetDelContext(ctx, key)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.GetDelContext(ctx, key)
+		}
+	}
+	return value, err
+}
+
+// AppendContext 追加key的值，重新平衡期间新分片上不存在而旧分片上存在时追加到旧分片，由Rebalance迁移
+func (s *Sharded) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	if prev := s.previous(key, shard); prev != nil {
+		ok, err := shard.c.ExistsContext(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			if ok, err = prev.c.ExistsContext(ctx, key); err != nil {
+				return 0, err
+			}
+			if ok {
+				return prev.c.AppendContext(ctx, key, value)
+			}
+		}
+	}
+	return shard.c.AppendContext(ctx, key, value)
+}
+
+// StrLenContext 返回key的值的长度，重新平衡期间新分片上不存在时查询旧分片
+func (s *Sharded) StrLenContext(ctx context.Context, key string) (int64, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := shard.c.StrLenContext(ctx, key)
+	if err == nil && n == 0 {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.StrLenContext(ctx, key)
+		}
+	}
+	return n, err
+}
+
+func (s *Sharded) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.SetObjectContext(ctx, key, v, ttl)
+}
+
+// GetObjectContext 获取并解码key的值，重新平衡期间新分片上未命中时读取旧分片
+func (s *Sharded) GetObjectContext(ctx context.Context, key string, v any) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	err = shard.c.GetObjectContext(ctx, key, v)
+	if errors.Is(err, _interface.ErrKeyNotFound) {
+		if prev := s.previous(key, shard); prev != nil {
+			return prev.c.GetObjectContext(ctx, key, v)
+		}
+	}
+	return err
+}
+
+// KeysContext 依次遍历所有分片，重新平衡期间同一个key只返回一次
+func (s *Sharded) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	shards := s.snapshot()
+	s.mu.RLock()
+	rebalancing := s.prev != nil
+	s.mu.RUnlock()
+
+	var seen map[string]struct{}
+	if rebalancing {
+		seen = make(map[string]struct{})
+	}
+	stopped := false
+	for _, shard := range shards {
+		err := shard.c.KeysContext(ctx, pattern, func(key string) bool {
+			if seen != nil {
+				if _, ok := seen[key]; ok {
+					return true
+				}
+				seen[key] = struct{}{}
+			}
+			stopped = !fn(key)
+			return !stopped
+		})
+		if err != nil {
+			return fmt.Errorf("cache: keys on shard %s: %w", shard.name, err)
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
+
+// IterateContext 依次遍历所有分片上的键值
+// 重新平衡期间先遍历位置正确的键值，再遍历只存在于旧分片上的键值，同一个key只返回一次，与GetContext的回退读取一致
+func (s *Sharded) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	shards := s.snapshot()
+	s.mu.RLock()
+	ring, rebalancing := s.ring, s.prev != nil
+	s.mu.RUnlock()
+
+	if !rebalancing {
+		for _, shard := range shards {
+			stopped := false
+			err := shard.c.IterateContext(ctx, prefix, func(key, value string) bool {
+				stopped = !fn(key, value)
+				return !stopped
+			})
+			if err != nil {
+				return fmt.Errorf("cache: iterate on shard %s: %w", shard.name, err)
+			}
+			if stopped {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, owned := range []bool{true, false} {
+		for _, shard := range shards {
+			stopped := false
+			err := shard.c.IterateContext(ctx, prefix, func(key, value string) bool {
+				if _, ok := seen[key]; ok || (ring.locate(key, false) == shard) != owned {
+					return true
+				}
+				seen[key] = struct{}{}
+				stopped = !fn(key, value)
+				return !stopped
+			})
+			if err != nil {
+				return fmt.Errorf("cache: iterate on shard %s: %w", shard.name, err)
+			}
+			if stopped {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteByPrefixContext 在所有分片上删除以prefix开头的key
+func (s *Sharded) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	for _, shard := range s.snapshot() {
+		if err := shard.c.DeleteByPrefixContext(ctx, prefix); err != nil {
+			return fmt.Errorf("cache: delete by prefix on shard %s: %w", shard.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Sharded) HGetContext(ctx context.Context, key, field string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.HGetContext(ctx, key, field)
+}
+
+func (s *Sharded) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.HSetContext(ctx, key, field, value, ttl)
+}
+
+func (s *Sharded) HDelContext(ctx context.Context, key, field string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.HDelContext(ctx, key, field)
+}
+
+func (s *Sharded) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.c.HGetAllContext(ctx, key)
+}
+
+func (s *Sharded) HLenContext(ctx context.Context, key string) (int64, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.c.HLenContext(ctx, key)
+}
+
+func (s *Sharded) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	return shard.c.HExistsContext(ctx, key, field)
+}
+
+func (s *Sharded) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.c.HKeysContext(ctx, key)
+}
+
+func (s *Sharded) SAddContext(ctx context.Context, key, member string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.SAddContext(ctx, key, member)
+}
+
+func (s *Sharded) SRemContext(ctx context.Context, key, member string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.SRemContext(ctx, key, member)
+}
+
+func (s *Sharded) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.c.SMembersContext(ctx, key)
+}
+
+func (s *Sharded) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	return shard.c.SIsMemberContext(ctx, key, member)
+}
+
+func (s *Sharded) PushContext(ctx context.Context, key string, value string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.PushContext(ctx, key, value)
+}
+
+func (s *Sharded) LPushContext(ctx context.Context, key string, value string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.LPushContext(ctx, key, value)
+}
+
+func (s *Sharded) RPushContext(ctx context.Context, key string, value string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.RPushContext(ctx, key, value)
+}
+
+func (s *Sharded) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.RPushCappedContext(ctx, key, value, maxLen)
+}
+
+func (s *Sharded) PopContext(ctx context.Context, key string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.PopContext(ctx, key)
+}
+
+func (s *Sharded) LPopContext(ctx context.Context, key string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.LPopContext(ctx, key)
+}
+
+func (s *Sharded) RPopContext(ctx context.Context, key string) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.RPopContext(ctx, key)
+}
+
+func (s *Sharded) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.c.PopAllContext(ctx, key)
+}
+
+func (s *Sharded) LenContext(ctx context.Context, key string) (int64, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.c.LenContext(ctx, key)
+}
+
+func (s *Sharded) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	return shard.c.LRangeContext(ctx, key, start, stop)
+}
+
+func (s *Sharded) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", err
+	}
+	return shard.c.LIndexContext(ctx, key, index)
+}
+
+func (s *Sharded) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return 0, err
+	}
+	return shard.c.LRemContext(ctx, key, count, value)
+}
+
+func (s *Sharded) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.LTrimContext(ctx, key, start, stop)
+}
+
+func (s *Sharded) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return "", "", err
+	}
+	return shard.c.PopAckContext(ctx, key, visibility)
+}
+
+func (s *Sharded) AckContext(ctx context.Context, key, receipt string) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.AckContext(ctx, key, receipt)
+}
+
+func (s *Sharded) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	return shard.c.PushDelayedContext(ctx, key, value, delay)
+}
+
+// PublishContext 向频道名所在的分片发布消息
+func (s *Sharded) PublishContext(ctx context.Context, channel string, payload string) error {
+	shard, err := s.route(channel)
+	if err != nil {
+		return err
+	}
+	return shard.c.PublishContext(ctx, channel, payload)
+}
+
+// SubscribeContext 订阅频道名所在分片上的频道，分片不可用时返回已关闭的通道
+func (s *Sharded) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	shard, err := s.route(channel)
+	if err != nil {
+		ch := make(chan _interface.Message)
+		close(ch)
+		return ch, func() {}
+	}
+	return shard.c.SubscribeContext(ctx, channel)
+}
+
+// SubscribeExpiredContext 合并所有分片的键过期通知
+func (s *Sharded) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	out := make(chan string)
+	done := make(chan struct{})
+	var cancels []func()
+	var wg sync.WaitGroup
+	for _, shard := range s.snapshot() {
+		keys, cancel := shard.c.SubscribeExpiredContext(ctx)
+		cancels = append(cancels, cancel)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				select {
+				case out <- key:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	var once sync.Once
+	return out, func() {
+		once.Do(func() {
+			close(done)
+			for _, cancel := range cancels {
+				cancel()
+			}
+		})
+	}
+}
+
+// StatsContext 汇总当前所有分片的统计信息，任一分片无法提供的数值字段为StatsUnknown
+// Extra中的"shards"为分片数，"shard.<name>"为该分片的驱动名称
+func (s *Sharded) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	s.mu.RLock()
+	ring := s.ring
+	s.mu.RUnlock()
+
+	shards := make(map[string]*shardState)
+	for _, state := range ring.owners {
+		shards[state.name] = state
+	}
+	total := _interface.Stats{Driver: "sharded", Extra: map[string]string{"shards": strconv.Itoa(len(shards))}}
+	for _, name := range slices.Sorted(maps.Keys(shards)) {
+		stats, err := shards[name].c.StatsContext(ctx)
+		if err != nil {
+			return total, fmt.Errorf("cache: stats on shard %s: %w", name, err)
+		}
+		total.Keys = addStat(total.Keys, stats.Keys)
+		total.MemoryBytes = addStat(total.MemoryBytes, stats.MemoryBytes)
+		total.DiskBytes = addStat(total.DiskBytes, stats.DiskBytes)
+		total.Evictions = addStat(total.Evictions, stats.Evictions)
+		total.Extra["shard."+name] = stats.Driver
+	}
+	return total, nil
+}
+
+// addStat 累加统计数值，任一数值为StatsUnknown时结果为StatsUnknown
+func addStat(total, v int64) int64 {
+	if total == _interface.StatsUnknown || v == _interface.StatsUnknown {
+		return _interface.StatsUnknown
+	}
+	return total + v
+}
+
+// BackupContext 分片可能是不同的驱动，返回ErrUnsupported，需要分别备份每个分片
+func (s *Sharded) BackupContext(ctx context.Context, w io.Writer) error {
+	return _interface.ErrUnsupported
+}
+
+// RestoreContext 分片可能是不同的驱动，返回ErrUnsupported，需要分别恢复每个分片
+func (s *Sharded) RestoreContext(ctx context.Context, r io.Reader) error {
+	return _interface.ErrUnsupported
+}
+
+// BeginTxContext 开启事务，事务在第一个键所在的分片上开启，之后的键不在该分片上时返回ErrCrossShard
+func (s *Sharded) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &shardedTx{s: s, ctx: ctx}, nil
+}
+
+// BeginTxOptions 按选项开启事务，事务同样在第一个键所在的分片上开启，
+// 分片无法满足隔离级别时第一个操作返回ErrUnsupported
+func (s *Sharded) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &shardedTx{s: s, ctx: ctx, opts: opts}, nil
+}
+
+// BeginTxWatchContext 开启监视keys的事务，keys必须在同一个分片上
+func (s *Sharded) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	if len(keys) == 0 {
+		return s.BeginTxContext(ctx)
+	}
+	shard, err := s.route(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys[1:] {
+		if other, err := s.route(key); err != nil {
+			return nil, err
+		} else if other != shard {
+			return nil, fmt.Errorf("%w: %q and %q", ErrCrossShard, keys[0], key)
+		}
+	}
+	tx, err := shard.c.BeginTxWatchContext(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &shardedTx{s: s, ctx: ctx, shard: shard, tx: tx}, nil
+}
+
+// BFReserve 在键所在的分片上创建布隆过滤器，分片没有原生实现时返回ErrUnsupported
+func (s *Sharded) BFReserve(key string, errorRate float64, capacity int64) error {
+	shard, err := s.route(key)
+	if err != nil {
+		return err
+	}
+	if b, ok := shard.bloom(); ok {
+		return b.BFReserve(key, errorRate, capacity)
+	}
+	return _interface.ErrUnsupported
+}
+
+// BFAdd 向键所在分片上的布隆过滤器添加元素，分片没有原生实现时返回ErrUnsupported
+func (s *Sharded) BFAdd(key, item string) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	if b, ok := shard.bloom(); ok {
+		return b.BFAdd(key, item)
+	}
+	return false, _interface.ErrUnsupported
+}
+
+// BFExists 判断元素是否可能在键所在分片上的布隆过滤器中，分片没有原生实现时返回ErrUnsupported
+func (s *Sharded) BFExists(key, item string) (bool, error) {
+	shard, err := s.route(key)
+	if err != nil {
+		return false, err
+	}
+	if b, ok := shard.bloom(); ok {
+		return b.BFExists(key, item)
+	}
+	return false, _interface.ErrUnsupported
+}
+
+// bloom 返回分片的原生布隆过滤器实现
+func (st *shardState) bloom() (_interface.Bloom, bool) {
+	return nativeBloom(st.c)
+}
+
+// nativeBloom 返回asCacheCtx包装之前的缓存实例的原生布隆过滤器实现
+func nativeBloom(cc _interface.CacheCtx) (_interface.Bloom, bool) {
+	c := _interface.Cache(cc)
+	if wrapped, ok := cc.(ctxShard); ok {
+		c = wrapped.Cache
+	}
+	b, ok := c.(_interface.Bloom)
+	return b, ok
+}
+
+// shardedTx 分片缓存的事务，在第一个键所在的分片上开启
+type shardedTx struct {
+	s     *Sharded
+	ctx   context.Context
+	opts  _interface.TxOptions
+	shard *shardState
+	tx    _interface.Tx
+}
+
+// on 返回key所在分片上的事务，第一次调用时开启事务
+func (t *shardedTx) on(key string) (_interface.Tx, error) {
+	shard, err := t.s.route(key)
+	if err != nil {
+		return nil, err
+	}
+	if t.tx == nil {
+		tx, err := _interface.BeginTx(t.ctx, shard.c, t.opts)
+		if err != nil {
+			return nil, err
+		}
+		t.shard, t.tx = shard, tx
+		return tx, nil
+	}
+	if shard != t.shard {
+		return nil, fmt.Errorf("%w: %q is on shard %s, transaction is on shard %s", ErrCrossShard, key, shard.name, t.shard.name)
+	}
+	return t.tx, nil
+}
+
+func (t *shardedTx) Get(key string) (string, error) {
+	tx, err := t.on(key)
+	if err != nil {
+		return "", err
+	}
+	return tx.Get(key)
+}
+
+func (t *shardedTx) Exists(key string) (bool, error) {
+	tx, err := t.on(key)
+	if err != nil {
+		return false, err
+	}
+	return tx.Exists(key)
+}
+
+func (t *shardedTx) Set(key string, value string, ttl time.Duration) error {
+	tx, err := t.on(key)
+	if err != nil {
+		return err
+	}
+	return tx.Set(key, value, ttl)
+}
+
+func (t *shardedTx) Delete(key string) error {
+	tx, err := t.on(key)
+	if err != nil {
+		return err
+	}
+	return tx.Delete(key)
+}
+
+func (t *shardedTx) Expire(key string, ttl time.Duration) error {
+	tx, err := t.on(key)
+	if err != nil {
+		return err
+	}
+	return tx.Expire(key, ttl)
+}
+
+func (t *shardedTx) HSet(key, field, value string, ttl time.Duration) error {
+	tx, err := t.on(key)
+	if err != nil {
+		return err
+	}
+	return tx.HSet(key, field, value, ttl)
+}
+
+func (t *shardedTx) HDel(key, field string) error {
+	tx, err := t.on(key)
+	if err != nil {
+		return err
+	}
+	return tx.HDel(key, field)
+}
+
+// Commit 提交事务，事务中没有任何操作时不做任何操作
+func (t *shardedTx) Commit() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Commit()
+}
+
+func (t *shardedTx) Rollback() error {
+	if t.tx == nil {
+		return nil
+	}
+	return t.tx.Rollback()
+}
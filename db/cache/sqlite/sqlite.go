@@ -0,0 +1,1746 @@
+// sqlite包：基于SQLite的单文件缓存实现
+// 提供键值存储、哈希表操作、队列操作和事务支持
+//
+// SQLite是一个嵌入式的关系型数据库，所有数据保存在单个文件中
+// 本包实现了Cache和CacheCtx接口，适合需要嵌入式、可查询、崩溃安全存储的场景
+//
+// 主要特性：
+// - 单文件存储，可以直接用sqlite3命令行工具查询
+// - WAL日志模式，进程崩溃后数据不丢失
+// - 支持TTL过期（读取时过滤过期数据，写入时定期清理）
+// - 队列操作（FIFO/LIFO）
+// - 哈希表操作（独立的数据表，过期时间作用于整个哈希表）
+// - 集合操作（独立的数据表）
+// - 事务支持
+// - 进程内发布订阅（只在同一个缓存实例内传递）
+// - 纯Go实现，不依赖CGO
+//
+// 数据表：
+// - kv：键值数据
+// - hash：哈希表数据
+// - list：队列数据，按pos排序
+// - sets：集合数据
+//
+// 作者: gophertool
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	_ "modernc.org/sqlite"
+)
+
+// 包初始化时注册SQLite驱动
+func init() {
+	_interface.RegisterDriver(config.CacheDriverSqlite, NewSqliteStore)
+}
+
+// scanBatchSize Keys每批读取的key数量
+const scanBatchSize = 256
+
+// sweepInterval 清理过期数据的最小间隔
+const sweepInterval = time.Minute
+
+// schema 数据表结构，expires_at为过期时间（Unix纳秒），0表示不过期
+const schema = `
+CREATE TABLE IF NOT EXISTS kv (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	expires_at INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS hash (
+	key        TEXT NOT NULL,
+	field      TEXT NOT NULL,
+	value      TEXT NOT NULL,
+	expires_at INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (key, field)
+);
+CREATE TABLE IF NOT EXISTS sets (
+	key    TEXT NOT NULL,
+	member TEXT NOT NULL,
+	PRIMARY KEY (key, member)
+);
+CREATE TABLE IF NOT EXISTS list (
+	key   TEXT NOT NULL,
+	pos   INTEGER NOT NULL,
+	value TEXT NOT NULL,
+	PRIMARY KEY (key, pos)
+);
+CREATE INDEX IF NOT EXISTS kv_expires_at ON kv (expires_at) WHERE expires_at > 0;
+CREATE INDEX IF NOT EXISTS hash_expires_at ON hash (expires_at) WHERE expires_at > 0;
+`
+
+// SqliteDb SQLite缓存实现结构体
+type SqliteDb struct {
+	db         *sql.DB                   // SQLite数据库实例
+	readDB     *sql.DB                   // 只读事务使用的连接池，内存数据库时为nil
+	lastSweep  atomic.Int64              // 上次清理过期数据的时间（Unix纳秒）
+	broker     _interface.Broker         // 进程内的发布订阅
+	expiry     _interface.ExpiryNotifier // 键过期通知
+	ackQueue   _interface.AckQueue       // PopAck/Ack的实现
+	delayQueue _interface.DelayQueue     // PushDelayed的实现
+	codec      _interface.Codec          // SetObject/GetObject的编码方式
+}
+
+// execer 数据库和事务共同的执行接口
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// queryer 数据库和事务共同的单行查询接口
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (s *SqliteDb) Close() {
+	s.expiry.Close()
+	if s.readDB != nil {
+		_ = s.readDB.Close()
+	}
+	_ = s.db.Close()
+	s.broker.Close()
+}
+
+// GetContext 获取指定key的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 键对应的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (s *SqliteDb) GetContext(ctx context.Context, key string) (string, error) {
+	return getKey(ctx, s.db, key)
+}
+
+func (s *SqliteDb) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	s.sweep(ctx)
+	return setKey(ctx, s.db, key, value, ttl)
+}
+
+// DeleteContext 删除指定key，包括同名的键值、哈希表、队列和集合
+func (s *SqliteDb) DeleteContext(ctx context.Context, key string) error {
+	return deleteKey(ctx, s.db, key)
+}
+
+func (s *SqliteDb) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return existsKey(ctx, s.db, key)
+}
+
+// ExpireContext 设置key的过期时间，同时作用于同名的键值和哈希表
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	ttl - 过期时间，0表示取消过期
+//
+// 返回值：
+//
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (s *SqliteDb) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var affected int64
+	for _, table := range []string{"kv", "hash"} {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE `+table+` SET expires_at = ? WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+			expiresAt(ttl), key, now())
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		affected += n
+	}
+	if affected == 0 {
+		return _interface.ErrKeyNotFound
+	}
+	return tx.Commit()
+}
+
+// TTLContext 获取key的剩余过期时间，与ExpireContext一致作用于同名的键值和哈希表，队列没有过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	time.Duration - 剩余过期时间，没有过期时间时返回TTLNoExpiry，不存在时返回TTLNotFound
+//	error - 操作错误
+func (s *SqliteDb) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	var expires int64
+	n := now()
+	err := s.db.QueryRowContext(ctx,
+		`SELECT expires_at FROM kv WHERE key = ?1 AND (expires_at = 0 OR expires_at > ?2)
+			UNION ALL
+			SELECT MAX(expires_at) FROM hash WHERE key = ?1 AND (expires_at = 0 OR expires_at > ?2) HAVING COUNT(*) > 0
+			UNION ALL
+			SELECT 0 FROM list WHERE key = ?1
+			LIMIT 1`,
+		key, n).Scan(&expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return _interface.TTLNotFound, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if expires == 0 {
+		return _interface.TTLNoExpiry, nil
+	}
+	return time.Duration(expires - n), nil
+}
+
+// SetNXContext key不存在或已过期时设置key-value并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功
+//	error - 操作错误
+func (s *SqliteDb) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	s.sweep(ctx)
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO kv (key, value, expires_at) VALUES (?1, ?2, ?3)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at
+			WHERE kv.expires_at > 0 AND kv.expires_at <= ?4`,
+		key, value, expiresAt(ttl), now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CASContext key的当前值等于oldValue时设置为newValue并设置过期时间
+// 比较和写入在同一条UPDATE语句中执行
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	oldValue - 期望的当前值
+//	newValue - 新值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	bool - 是否设置成功，key不存在或当前值不等于oldValue时返回false
+//	error - 操作错误
+func (s *SqliteDb) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE kv SET value = ?, expires_at = ?
+			WHERE key = ? AND value = ? AND (expires_at = 0 OR expires_at > ?)`,
+		newValue, expiresAt(ttl), key, oldValue, now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetSetContext 设置新值并返回旧值，新值不过期
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 新值
+//
+// 返回值：
+//
+//	string - 旧值
+//	error - 操作错误，键不存在时仍会写入新值并返回ErrKeyNotFound
+func (s *SqliteDb) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var old string
+	err = tx.QueryRowContext(ctx,
+		`SELECT value FROM kv WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, now()).Scan(&old)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if err := setKey(ctx, tx, key, value, 0); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	if !found {
+		return "", _interface.ErrKeyNotFound
+	}
+	return old, nil
+}
+
+// GetDelContext 获取并删除指定key
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//
+// 返回值：
+//
+//	string - 删除前的值
+//	error - 操作错误，键不存在或已过期时返回ErrKeyNotFound
+func (s *SqliteDb) GetDelContext(ctx context.Context, key string) (string, error) {
+	var val string
+	var expires int64
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM kv WHERE key = ? RETURNING value, expires_at`, key).Scan(&val, &expires)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && expires > 0 && expires <= now()) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// AppendContext 在一条语句中将value追加到key的值末尾，key不存在或已过期时创建不过期的key，已有的过期时间保持不变
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	value - 要追加的值
+//
+// 返回值：
+//
+//	int64 - 追加后值的长度（字节数）
+//	error - 操作错误
+func (s *SqliteDb) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx,
+		`INSERT INTO kv (key, value, expires_at) VALUES (?1, ?2, 0)
+			ON CONFLICT (key) DO UPDATE SET
+				value = CASE WHEN kv.expires_at > 0 AND kv.expires_at <= ?3 THEN excluded.value ELSE kv.value || excluded.value END,
+				expires_at = CASE WHEN kv.expires_at > 0 AND kv.expires_at <= ?3 THEN 0 ELSE kv.expires_at END
+			RETURNING length(CAST(value AS BLOB))`,
+		key, value, now()).Scan(&n)
+	return n, err
+}
+
+// StrLenContext 返回key的值的长度（字节数），key不存在或已过期时返回0
+func (s *SqliteDb) StrLenContext(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT length(CAST(value AS BLOB)) FROM kv WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, now()).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return n, err
+}
+
+// SetObjectContext 使用配置的Codec编码v并设置key的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 要编码的值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (s *SqliteDb) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := s.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.SetContext(ctx, key, string(data), ttl)
+}
+
+// GetObjectContext 获取key的值并使用配置的Codec解码到v指向的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 键名
+//	v - 解码目标，必须是非nil指针
+//
+// 返回值：
+//
+//	error - 读取或解码错误，键不存在时返回ErrKeyNotFound
+func (s *SqliteDb) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := s.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.codec.Unmarshal([]byte(raw), v)
+}
+
+// KeysContext 遍历匹配pattern的key，通配符语法与Redis一致
+// 只遍历键值，不包括哈希表、队列和集合；每批查询scanBatchSize个key后再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	ctx - 上下文
+//	pattern - 通配符模式，空字符串表示所有key
+//	fn - 对每个匹配的key调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	prefix := _interface.PatternPrefix(pattern)
+	start := prefix
+	for {
+		keys, err := s.scanKeys(ctx, prefix, start)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if _interface.MatchPattern(pattern, key) && !fn(key) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// scanKeys 按key排序从start开始查询最多scanBatchSize个以prefix开头的未过期键值
+func (s *SqliteDb) scanKeys(ctx context.Context, prefix, start string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key FROM kv WHERE key >= ? AND (expires_at = 0 OR expires_at > ?) ORDER BY key LIMIT ?`,
+		start, now(), scanBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// IterateContext 遍历键名以prefix开头的键值
+// 只遍历键值，不包括哈希表、队列和集合；每批查询scanBatchSize个键值后再调用fn，fn中可以读写缓存
+// 参数：
+//
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示所有键值
+//	fn - 对每个键值调用，返回false时停止遍历
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	start := prefix
+	for {
+		keys, values, err := s.scanEntries(ctx, prefix, start)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			if !fn(key, values[i]) {
+				return nil
+			}
+		}
+		if len(keys) < scanBatchSize {
+			return nil
+		}
+		start = keys[len(keys)-1] + "\x00"
+	}
+}
+
+// scanEntries 按key排序从start开始查询最多scanBatchSize个以prefix开头的未过期键值
+func (s *SqliteDb) scanEntries(ctx context.Context, prefix, start string) (keys, values []string, err error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key, value FROM kv WHERE key >= ? AND (expires_at = 0 OR expires_at > ?) ORDER BY key LIMIT ?`,
+		start, now(), scanBatchSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, nil, err
+		}
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, rows.Err()
+}
+
+// DeleteByPrefixContext 删除所有以prefix开头的key，包括键值、哈希表、队列和集合，在同一个事务中完成
+// 参数：
+//
+//	ctx - 上下文
+//	prefix - 键名前缀，空字符串表示删除所有key
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"kv", "hash", "list", "sets"} {
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE substr(key, 1, length(?1)) = ?1`, prefix); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// HGetContext 获取哈希表中指定field的值
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	string - 字段对应的值
+//	error - 操作错误，字段不存在或已过期时返回ErrKeyNotFound
+func (s *SqliteDb) HGetContext(ctx context.Context, key, field string) (string, error) {
+	var val string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM hash WHERE key = ? AND field = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, field, now()).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// HSetContext 设置哈希表中的field-value，并设置过期时间
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//	value - 字段值
+//	ttl - 整个哈希表的过期时间，0表示保持原有的过期时间
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	s.sweep(ctx)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setField(ctx, tx, key, field, value, ttl); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SqliteDb) HDelContext(ctx context.Context, key, field string) error {
+	return deleteField(ctx, s.db, key, field)
+}
+
+// HGetAllContext 获取哈希表中所有的field和value
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	map[string]string - 所有字段和值的映射
+//	error - 操作错误
+func (s *SqliteDb) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT field, value FROM hash WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var field, value string
+		if err := rows.Scan(&field, &value); err != nil {
+			return nil, err
+		}
+		result[field] = value
+	}
+	return result, rows.Err()
+}
+
+// HLenContext 获取哈希表中未过期的field数量
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	int64 - 字段数量，哈希表不存在时返回0
+//	error - 操作错误
+func (s *SqliteDb) HLenContext(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM hash WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, now()).Scan(&n)
+	return n, err
+}
+
+// HExistsContext 判断哈希表中是否存在未过期的field
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//	field - 字段名
+//
+// 返回值：
+//
+//	bool - 字段是否存在
+//	error - 操作错误
+func (s *SqliteDb) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM hash WHERE key = ? AND field = ? AND (expires_at = 0 OR expires_at > ?))`,
+		key, field, now()).Scan(&exists)
+	return exists, err
+}
+
+// HKeysContext 获取哈希表中所有未过期的field，按字段名排序
+// 参数：
+//
+//	ctx - 上下文
+//	key - 哈希表键名
+//
+// 返回值：
+//
+//	[]string - 所有字段，哈希表不存在时返回空切片
+//	error - 操作错误
+func (s *SqliteDb) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT field FROM hash WHERE key = ? AND (expires_at = 0 OR expires_at > ?) ORDER BY field`,
+		key, now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []string{}
+	for rows.Next() {
+		var field string
+		if err := rows.Scan(&field); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, rows.Err()
+}
+
+// SAddContext 向集合添加成员，成员已存在时不做任何操作
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) SAddContext(ctx context.Context, key, member string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO sets (key, member) VALUES (?, ?) ON CONFLICT (key, member) DO NOTHING`, key, member)
+	return err
+}
+
+// SRemContext 从集合删除成员，成员全部删除后集合本身也会被删除
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) SRemContext(ctx context.Context, key, member string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM sets WHERE key = ? AND member = ?`, key, member)
+	return err
+}
+
+// SMembersContext 获取集合的所有成员，按成员排序
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//
+// 返回值：
+//
+//	[]string - 所有成员，集合不存在时返回空切片
+//	error - 操作错误
+func (s *SqliteDb) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT member FROM sets WHERE key = ? ORDER BY member`, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []string{}
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// SIsMemberContext 判断member是否为集合成员
+// 参数：
+//
+//	ctx - 上下文
+//	key - 集合键名
+//	member - 成员
+//
+// 返回值：
+//
+//	bool - 是否为集合成员
+//	error - 操作错误
+func (s *SqliteDb) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM sets WHERE key = ? AND member = ?)`, key, member).Scan(&exists)
+	return exists, err
+}
+
+func (s *SqliteDb) PushContext(ctx context.Context, key string, value string) error {
+	return s.RPushContext(ctx, key, value)
+}
+
+// LPushContext 将元素插入到列表头部
+func (s *SqliteDb) LPushContext(ctx context.Context, key string, value string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO list (key, pos, value)
+			SELECT ?1, COALESCE(MIN(pos), 1) - 1, ?2 FROM list WHERE key = ?1`,
+		key, value)
+	return err
+}
+
+// RPushContext 将元素插入到列表尾部
+func (s *SqliteDb) RPushContext(ctx context.Context, key string, value string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO list (key, pos, value)
+			SELECT ?1, COALESCE(MAX(pos), -1) + 1, ?2 FROM list WHERE key = ?1`,
+		key, value)
+	return err
+}
+
+// RPushCappedContext 将元素插入到列表尾部，列表长度超过maxLen时从头部删除最早的元素
+func (s *SqliteDb) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	if maxLen <= 0 {
+		return s.RPushContext(ctx, key, value)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO list (key, pos, value)
+			SELECT ?1, COALESCE(MAX(pos), -1) + 1, ?2 FROM list WHERE key = ?1`,
+		key, value); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM list WHERE key = ?1 AND pos NOT IN
+			(SELECT pos FROM list WHERE key = ?1 ORDER BY pos DESC LIMIT ?2)`,
+		key, maxLen); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SqliteDb) PopContext(ctx context.Context, key string) (string, error) {
+	return s.LPopContext(ctx, key)
+}
+
+// LPopContext 弹出列表头部元素
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (s *SqliteDb) LPopContext(ctx context.Context, key string) (string, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return "", err
+	}
+	return s.pop(ctx, key, "MIN")
+}
+
+// RPopContext 弹出列表尾部元素
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (s *SqliteDb) RPopContext(ctx context.Context, key string) (string, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return "", err
+	}
+	return s.pop(ctx, key, "MAX")
+}
+
+// pop 删除并返回列表中pos最小（MIN）或最大（MAX）的元素
+func (s *SqliteDb) pop(ctx context.Context, key string, end string) (string, error) {
+	var val string
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM list WHERE key = ?1 AND pos = (SELECT `+end+`(pos) FROM list WHERE key = ?1)
+			RETURNING value`,
+		key).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+func (s *SqliteDb) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT value FROM list WHERE key = ? ORDER BY pos`, key)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result = append(result, val)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM list WHERE key = ?`, key); err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+func (s *SqliteDb) LenContext(ctx context.Context, key string) (int64, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return 0, err
+	}
+
+	var length int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM list WHERE key = ?`, key).Scan(&length)
+	return length, err
+}
+
+// LRangeContext 获取列表中下标在[start, stop]范围内的元素，不移除元素
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	start - 起始下标，负数表示从尾部倒数
+//	stop - 结束下标（包含），负数表示从尾部倒数
+//
+// 返回值：
+//
+//	[]string - 范围内的元素，列表不存在时返回空切片
+//	error - 操作错误
+func (s *SqliteDb) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var length int64
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM list WHERE key = ?`, key).Scan(&length); err != nil {
+		return nil, err
+	}
+	lo, hi := _interface.ListRange(start, stop, length)
+	result := []string{}
+	if lo >= hi {
+		return result, nil
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT value FROM list WHERE key = ? ORDER BY pos LIMIT ? OFFSET ?`, key, hi-lo, lo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			return nil, err
+		}
+		result = append(result, val)
+	}
+	return result, rows.Err()
+}
+
+// LIndexContext 获取列表中指定下标的元素，下标超出范围时返回ErrKeyNotFound
+func (s *SqliteDb) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	items, err := s.LRangeContext(ctx, key, index, index)
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", _interface.ErrKeyNotFound
+	}
+	return items[0], nil
+}
+
+// LRemContext 删除列表中等于value的元素
+// 保留的元素按顺序写回原有的前len(kept)个位置，多余的位置被删除，元素之间的相对顺序不变
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	count - 大于0时从头部删除count个，小于0时从尾部删除-count个，等于0时全部删除
+//	value - 要删除的值
+//
+// 返回值：
+//
+//	int64 - 删除的数量
+//	error - 操作错误
+func (s *SqliteDb) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `SELECT pos, value FROM list WHERE key = ? ORDER BY pos`, key)
+	if err != nil {
+		return 0, err
+	}
+	var positions []int64
+	var items []string
+	for rows.Next() {
+		var pos int64
+		var val string
+		if err := rows.Scan(&pos, &val); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		positions = append(positions, pos)
+		items = append(items, val)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	kept, removed := _interface.ListRem(items, count, value)
+	if removed == 0 {
+		return 0, nil
+	}
+	for i, pos := range positions {
+		if i < len(kept) {
+			_, err = tx.ExecContext(ctx, `UPDATE list SET value = ? WHERE key = ? AND pos = ?`, kept[i], key, pos)
+		} else {
+			_, err = tx.ExecContext(ctx, `DELETE FROM list WHERE key = ? AND pos = ?`, key, pos)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return removed, tx.Commit()
+}
+
+// LTrimContext 只保留列表中下标在[start, stop]范围内的元素
+func (s *SqliteDb) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	if err := s.delayQueue.Promote(s, key); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var length int64
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM list WHERE key = ?`, key).Scan(&length); err != nil {
+		return err
+	}
+	lo, hi := _interface.ListRange(start, stop, length)
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM list WHERE key = ?1 AND pos NOT IN
+			(SELECT pos FROM list WHERE key = ?1 ORDER BY pos LIMIT ?2 OFFSET ?3)`,
+		key, hi-lo, lo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// PopAckContext 弹出列表头部元素并返回回执，visibility内没有Ack的元素会重新回到列表头部
+// 未确认的元素保存在<key>:pending哈希表中，进程重启后仍然有效
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	visibility - 可见性超时
+//
+// 返回值：
+//
+//	string - 弹出的元素值
+//	string - 回执，用于Ack
+//	error - 操作错误，列表为空时返回ErrKeyNotFound
+func (s *SqliteDb) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+	return s.ackQueue.PopAck(s, key, visibility)
+}
+
+// AckContext 确认PopAck弹出的元素已处理完成
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	receipt - PopAck返回的回执
+//
+// 返回值：
+//
+//	error - 操作错误，回执不存在或元素已超时回到列表时返回ErrKeyNotFound
+func (s *SqliteDb) AckContext(ctx context.Context, key, receipt string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.ackQueue.Ack(s, key, receipt)
+}
+
+// PushDelayedContext 推入延迟元素，delay之后元素才会出现在列表尾部并可以被弹出
+// 延迟元素以就绪时间为索引保存在<key>:delayed哈希表中，进程重启后仍然有效；弹出或读取长度前会先把已就绪的元素移入列表
+// 参数：
+//
+//	ctx - 上下文
+//	key - 列表键名
+//	value - 元素值
+//	delay - 延迟时间，小于等于0时立即推入列表
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.delayQueue.PushDelayed(s, key, value, delay)
+}
+
+// PublishContext 向频道发布消息，消息只投递给同一个缓存实例上的订阅者
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//	payload - 消息内容
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) PublishContext(ctx context.Context, channel string, payload string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.broker.Publish(channel, payload)
+}
+
+// SubscribeContext 订阅频道，只能收到同一个缓存实例发布的消息，上下文取消或超时时自动取消订阅
+// 参数：
+//
+//	ctx - 上下文
+//	channel - 频道名称
+//
+// 返回值：
+//
+//	<-chan _interface.Message - 消息通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (s *SqliteDb) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	return s.broker.SubscribeContext(ctx, channel)
+}
+
+// SubscribeExpiredContext 订阅键值数据的过期事件，上下文取消或超时时自动取消订阅
+// 订阅期间后台每秒按过期时间索引查询一次，并暂停写入时的过期数据回收；哈希表的过期不通知
+// 参数：
+//
+//	ctx - 上下文
+//
+// 返回值：
+//
+//	<-chan string - 过期键名通道，取消订阅或关闭缓存后被关闭
+//	func() - 取消订阅函数
+func (s *SqliteDb) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return s.expiry.SubscribeContext(ctx, s.sweepExpired)
+}
+
+// sweepExpired 返回过期时间在(since, until]之间的键
+func (s *SqliteDb) sweepExpired(since, until time.Time) []string {
+	rows, err := s.db.Query(`SELECT key FROM kv WHERE expires_at > ? AND expires_at <= ?`,
+		since.UnixNano(), until.UnixNano())
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if rows.Scan(&key) == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *SqliteDb) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteTx{tx: tx, ctx: ctx}, nil
+}
+
+// BeginTxWatchContext 开启监视keys的事务
+// SQLite同一时间只有一个写事务，开启时立即取得写锁，提交之前keys不会被其他连接修改
+func (s *SqliteDb) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 不影响任何行的写入语句，只用于取得写锁
+	if _, err := tx.ExecContext(ctx, `DELETE FROM kv WHERE 0`); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	return &sqliteTx{tx: tx, ctx: ctx}, nil
+}
+
+// BeginTxOptions 按选项开启事务
+// SQLite的事务是可串行化的，可以满足所有隔离级别；
+// 读写事务占用唯一的写连接，只读事务使用独立的只读连接池，在WAL模式下读取开启时的快照，
+// 多个只读事务可以与写事务并发执行；内存数据库没有只读连接池，只读事务同样占用写连接
+func (s *SqliteDb) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	if !opts.ReadOnly || s.readDB == nil {
+		tx, err := s.BeginTxContext(ctx)
+		if err != nil || !opts.ReadOnly {
+			return tx, err
+		}
+		return _interface.ReadOnlyTx(tx), nil
+	}
+	tx, err := s.readDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return _interface.ReadOnlyTx(&sqliteTx{tx: tx, ctx: ctx}), nil
+}
+
+// sqliteTx SQLite事务实现
+type sqliteTx struct {
+	tx  *sql.Tx
+	ctx context.Context
+}
+
+func (tx *sqliteTx) Get(key string) (string, error) {
+	return getKey(tx.ctx, tx.tx, key)
+}
+
+func (tx *sqliteTx) Exists(key string) (bool, error) {
+	return existsKey(tx.ctx, tx.tx, key)
+}
+
+func (tx *sqliteTx) Set(key string, value string, ttl time.Duration) error {
+	return setKey(tx.ctx, tx.tx, key, value, ttl)
+}
+
+func (tx *sqliteTx) Delete(key string) error {
+	return deleteKey(tx.ctx, tx.tx, key)
+}
+
+// Expire 同时更新同名键值和哈希表的过期时间，key 不存在时不做任何操作
+func (tx *sqliteTx) Expire(key string, ttl time.Duration) error {
+	for _, table := range []string{"kv", "hash"} {
+		if _, err := tx.tx.ExecContext(tx.ctx,
+			`UPDATE `+table+` SET expires_at = ? WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+			expiresAt(ttl), key, now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *sqliteTx) HSet(key, field, value string, ttl time.Duration) error {
+	return setField(tx.ctx, tx.tx, key, field, value, ttl)
+}
+
+func (tx *sqliteTx) HDel(key, field string) error {
+	return deleteField(tx.ctx, tx.tx, key, field)
+}
+
+func (tx *sqliteTx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx *sqliteTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// getKey 读取未过期的键值数据
+func getKey(ctx context.Context, db queryer, key string) (string, error) {
+	var val string
+	err := db.QueryRowContext(ctx,
+		`SELECT value FROM kv WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`,
+		key, now()).Scan(&val)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", _interface.ErrKeyNotFound
+	}
+	return val, err
+}
+
+// existsKey 判断同名的键值、哈希表、队列或集合是否存在
+func existsKey(ctx context.Context, db queryer, key string) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM kv WHERE key = ?1 AND (expires_at = 0 OR expires_at > ?2))
+			OR EXISTS (SELECT 1 FROM hash WHERE key = ?1 AND (expires_at = 0 OR expires_at > ?2))
+			OR EXISTS (SELECT 1 FROM list WHERE key = ?1)
+			OR EXISTS (SELECT 1 FROM sets WHERE key = ?1)`,
+		key, now()).Scan(&exists)
+	return exists, err
+}
+
+// setKey 写入键值数据
+func setKey(ctx context.Context, db execer, key string, value string, ttl time.Duration) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO kv (key, value, expires_at) VALUES (?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		key, value, expiresAt(ttl))
+	return err
+}
+
+// deleteKey 删除同名的键值、哈希表和队列
+func deleteKey(ctx context.Context, db execer, key string) error {
+	for _, table := range []string{"kv", "hash", "list", "sets"} {
+		if _, err := db.ExecContext(ctx, `DELETE FROM `+table+` WHERE key = ?`, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setField 写入哈希表字段，ttl大于0时更新整个哈希表的过期时间
+func setField(ctx context.Context, db execer, key, field, value string, ttl time.Duration) error {
+	// 已过期的哈希表视为不存在，先清理掉避免旧字段复活
+	if _, err := db.ExecContext(ctx,
+		`DELETE FROM hash WHERE key = ? AND expires_at > 0 AND expires_at <= ?`, key, now()); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx,
+		`INSERT INTO hash (key, field, value, expires_at)
+			VALUES (?1, ?2, ?3, COALESCE((SELECT MAX(expires_at) FROM hash WHERE key = ?1), 0))
+			ON CONFLICT (key, field) DO UPDATE SET value = excluded.value`,
+		key, field, value); err != nil {
+		return err
+	}
+	if ttl > 0 {
+		if _, err := db.ExecContext(ctx,
+			`UPDATE hash SET expires_at = ? WHERE key = ?`, expiresAt(ttl), key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteField 删除哈希表字段
+func deleteField(ctx context.Context, db execer, key, field string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM hash WHERE key = ? AND field = ?`, key, field)
+	return err
+}
+
+// sweep 清理过期数据
+// 读取时已经过滤了过期数据，这里只是回收空间，因此每个sweepInterval最多执行一次，失败也不影响写入
+func (s *SqliteDb) sweep(ctx context.Context) {
+	// 有过期事件订阅者时暂停回收，避免过期数据在通知之前被删除
+	if s.expiry.Active() {
+		return
+	}
+	last := s.lastSweep.Load()
+	current := now()
+	if current-last < int64(sweepInterval) || !s.lastSweep.CompareAndSwap(last, current) {
+		return
+	}
+	for _, table := range []string{"kv", "hash"} {
+		_, _ = s.db.ExecContext(ctx,
+			`DELETE FROM `+table+` WHERE expires_at > 0 AND expires_at <= ?`, current)
+	}
+}
+
+// expiresAt 将TTL转换为过期时间，ttl不大于0时返回0表示不过期
+func expiresAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+func now() int64 {
+	return time.Now().UnixNano()
+}
+
+// 不带上下文的方法使用 context.Background()，与带上下文的方法共用实现
+
+func (s *SqliteDb) Get(key string) (string, error) {
+	return s.GetContext(context.Background(), key)
+}
+
+func (s *SqliteDb) Set(key string, value string, ttl time.Duration) error {
+	return s.SetContext(context.Background(), key, value, ttl)
+}
+
+func (s *SqliteDb) Delete(key string) error {
+	return s.DeleteContext(context.Background(), key)
+}
+
+func (s *SqliteDb) Exists(key string) (bool, error) {
+	return s.ExistsContext(context.Background(), key)
+}
+
+func (s *SqliteDb) Expire(key string, ttl time.Duration) error {
+	return s.ExpireContext(context.Background(), key, ttl)
+}
+
+func (s *SqliteDb) TTL(key string) (time.Duration, error) {
+	return s.TTLContext(context.Background(), key)
+}
+
+func (s *SqliteDb) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	return s.SetNXContext(context.Background(), key, value, ttl)
+}
+
+func (s *SqliteDb) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	return s.CASContext(context.Background(), key, oldValue, newValue, ttl)
+}
+
+func (s *SqliteDb) GetSet(key string, value string) (string, error) {
+	return s.GetSetContext(context.Background(), key, value)
+}
+
+func (s *SqliteDb) GetDel(key string) (string, error) {
+	return s.GetDelContext(context.Background(), key)
+}
+
+func (s *SqliteDb) Append(key string, value string) (int64, error) {
+	return s.AppendContext(context.Background(), key, value)
+}
+
+func (s *SqliteDb) StrLen(key string) (int64, error) {
+	return s.StrLenContext(context.Background(), key)
+}
+
+func (s *SqliteDb) SetObject(key string, v any, ttl time.Duration) error {
+	return s.SetObjectContext(context.Background(), key, v, ttl)
+}
+
+func (s *SqliteDb) GetObject(key string, v any) error {
+	return s.GetObjectContext(context.Background(), key, v)
+}
+
+func (s *SqliteDb) Keys(pattern string, fn func(key string) bool) error {
+	return s.KeysContext(context.Background(), pattern, fn)
+}
+
+func (s *SqliteDb) Iterate(prefix string, fn func(key, value string) bool) error {
+	return s.IterateContext(context.Background(), prefix, fn)
+}
+
+func (s *SqliteDb) DeleteByPrefix(prefix string) error {
+	return s.DeleteByPrefixContext(context.Background(), prefix)
+}
+
+func (s *SqliteDb) HGet(key, field string) (string, error) {
+	return s.HGetContext(context.Background(), key, field)
+}
+
+func (s *SqliteDb) HSet(key, field, value string, ttl time.Duration) error {
+	return s.HSetContext(context.Background(), key, field, value, ttl)
+}
+
+func (s *SqliteDb) HDel(key, field string) error {
+	return s.HDelContext(context.Background(), key, field)
+}
+
+func (s *SqliteDb) HGetAll(key string) (map[string]string, error) {
+	return s.HGetAllContext(context.Background(), key)
+}
+
+func (s *SqliteDb) HLen(key string) (int64, error) {
+	return s.HLenContext(context.Background(), key)
+}
+
+func (s *SqliteDb) HExists(key, field string) (bool, error) {
+	return s.HExistsContext(context.Background(), key, field)
+}
+
+func (s *SqliteDb) HKeys(key string) ([]string, error) {
+	return s.HKeysContext(context.Background(), key)
+}
+
+func (s *SqliteDb) SAdd(key, member string) error {
+	return s.SAddContext(context.Background(), key, member)
+}
+
+func (s *SqliteDb) SRem(key, member string) error {
+	return s.SRemContext(context.Background(), key, member)
+}
+
+func (s *SqliteDb) SMembers(key string) ([]string, error) {
+	return s.SMembersContext(context.Background(), key)
+}
+
+func (s *SqliteDb) SIsMember(key, member string) (bool, error) {
+	return s.SIsMemberContext(context.Background(), key, member)
+}
+
+func (s *SqliteDb) Push(key string, value string) error {
+	return s.PushContext(context.Background(), key, value)
+}
+
+func (s *SqliteDb) LPush(key string, value string) error {
+	return s.LPushContext(context.Background(), key, value)
+}
+
+func (s *SqliteDb) RPush(key string, value string) error {
+	return s.RPushContext(context.Background(), key, value)
+}
+
+func (s *SqliteDb) RPushCapped(key string, value string, maxLen int64) error {
+	return s.RPushCappedContext(context.Background(), key, value, maxLen)
+}
+
+func (s *SqliteDb) Pop(key string) (string, error) {
+	return s.PopContext(context.Background(), key)
+}
+
+func (s *SqliteDb) LPop(key string) (string, error) {
+	return s.LPopContext(context.Background(), key)
+}
+
+func (s *SqliteDb) RPop(key string) (string, error) {
+	return s.RPopContext(context.Background(), key)
+}
+
+func (s *SqliteDb) PopAll(key string) ([]string, error) {
+	return s.PopAllContext(context.Background(), key)
+}
+
+func (s *SqliteDb) Len(key string) (int64, error) {
+	return s.LenContext(context.Background(), key)
+}
+
+func (s *SqliteDb) LRange(key string, start, stop int64) ([]string, error) {
+	return s.LRangeContext(context.Background(), key, start, stop)
+}
+
+func (s *SqliteDb) LIndex(key string, index int64) (string, error) {
+	return s.LIndexContext(context.Background(), key, index)
+}
+
+func (s *SqliteDb) LRem(key string, count int64, value string) (int64, error) {
+	return s.LRemContext(context.Background(), key, count, value)
+}
+
+func (s *SqliteDb) LTrim(key string, start, stop int64) error {
+	return s.LTrimContext(context.Background(), key, start, stop)
+}
+
+func (s *SqliteDb) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return s.PopAckContext(context.Background(), key, visibility)
+}
+
+func (s *SqliteDb) Ack(key, receipt string) error {
+	return s.AckContext(context.Background(), key, receipt)
+}
+
+func (s *SqliteDb) PushDelayed(key, value string, delay time.Duration) error {
+	return s.PushDelayedContext(context.Background(), key, value, delay)
+}
+
+func (s *SqliteDb) Publish(channel string, payload string) error {
+	return s.PublishContext(context.Background(), channel, payload)
+}
+
+func (s *SqliteDb) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return s.SubscribeContext(context.Background(), channel)
+}
+
+func (s *SqliteDb) SubscribeExpired() (<-chan string, func()) {
+	return s.SubscribeExpiredContext(context.Background())
+}
+
+func (s *SqliteDb) BeginTx() (_interface.Tx, error) {
+	return s.BeginTxContext(context.Background())
+}
+
+func (s *SqliteDb) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	return s.BeginTxWatchContext(context.Background(), keys...)
+}
+
+// 确保实现了带上下文的缓存接口
+var _ _interface.CacheCtx = (*SqliteDb)(nil)
+
+// 确保原生支持事务选项
+var _ _interface.TxBeginner = (*SqliteDb)(nil)
+
+// StatsContext 返回SQLite的统计信息
+// 键数量只统计未过期的键值，磁盘占用为数据库页数乘以页大小，不包括WAL文件
+// 参数：
+//
+//	ctx - 上下文
+//
+// 返回值：
+//
+//	Stats - 统计信息
+//	error - 操作错误
+func (s *SqliteDb) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	stats := _interface.NewStats(config.CacheDriverSqlite)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM kv WHERE expires_at = 0 OR expires_at > ?`, now()).Scan(&stats.Keys)
+	if err != nil {
+		return stats, err
+	}
+
+	var pages, pageSize int64
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pages); err != nil {
+		return stats, err
+	}
+	if err := s.db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return stats, err
+	}
+	stats.DiskBytes = pages * pageSize
+	return stats, nil
+}
+
+func (s *SqliteDb) Stats() (_interface.Stats, error) {
+	return s.StatsContext(context.Background())
+}
+
+// restoreStatements 从附加的备份数据库合并数据，哈希表、集合和队列按整个key替换
+var restoreStatements = []string{
+	`INSERT OR REPLACE INTO kv (key, value, expires_at) SELECT key, value, expires_at FROM backup.kv`,
+	`DELETE FROM hash WHERE key IN (SELECT key FROM backup.hash)`,
+	`INSERT INTO hash (key, field, value, expires_at) SELECT key, field, value, expires_at FROM backup.hash`,
+	`DELETE FROM sets WHERE key IN (SELECT key FROM backup.sets)`,
+	`INSERT INTO sets (key, member) SELECT key, member FROM backup.sets`,
+	`DELETE FROM list WHERE key IN (SELECT key FROM backup.list)`,
+	`INSERT INTO list (key, pos, value) SELECT key, pos, value FROM backup.list`,
+}
+
+// BackupContext 使用VACUUM INTO生成数据库文件的一致性副本并写入w
+// 参数：
+//
+//	ctx - 上下文
+//	w - 备份数据的输出
+//
+// 返回值：
+//
+//	error - 操作错误
+func (s *SqliteDb) BackupContext(ctx context.Context, w io.Writer) error {
+	bw, err := _interface.NewBackupWriter(w, config.CacheDriverSqlite)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "sqlite-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := io.Copy(bw, f); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// RestoreContext 将备份写入临时文件并附加到当前连接，在一个事务中合并所有表
+// 参数：
+//
+//	ctx - 上下文
+//	r - Backup写入的备份数据
+//
+// 返回值：
+//
+//	error - 操作错误，数据不是SQLite的备份时返回ErrBackupFormat
+func (s *SqliteDb) RestoreContext(ctx context.Context, r io.Reader) error {
+	// 恢复的数据可能包含延迟元素，重新读取延迟队列的就绪时间
+	defer s.delayQueue.Reset()
+
+	br, err := _interface.NewBackupReader(r, config.CacheDriverSqlite)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "sqlite-restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, br)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	// ATTACH只对当前连接有效，所有语句必须在同一个连接上执行
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS backup`, path); err != nil {
+		return fmt.Errorf("%w: %v", _interface.ErrBackupFormat, err)
+	}
+	defer conn.ExecContext(context.Background(), `DETACH DATABASE backup`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range restoreStatements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SqliteDb) Backup(w io.Writer) error {
+	return s.BackupContext(context.Background(), w)
+}
+
+func (s *SqliteDb) Restore(r io.Reader) error {
+	return s.RestoreContext(context.Background(), r)
+}
+
+func NewSqliteStore(config config.Cache) (_interface.Cache, error) {
+	codec, err := _interface.GetCodec(config.Codec)
+	if err != nil {
+		return nil, err
+	}
+	dsn := "file:" + config.Path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite同一时间只允许一个写入者，使用单连接串行化所有操作，避免SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	// 只读事务使用独立的连接池，WAL模式下读取不阻塞写入也不被写入阻塞；
+	// 内存数据库的每个连接都是独立的数据库，不能使用额外的连接
+	var readDB *sql.DB
+	if config.Path != ":memory:" {
+		readDB, err = sql.Open("sqlite", dsn+"&_pragma=query_only(1)")
+		if err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	return &SqliteDb{db: db, readDB: readDB, codec: codec}, nil
+}
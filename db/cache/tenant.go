@@ -0,0 +1,342 @@
+// cache包：多租户缓存
+// SaaS宿主的多个租户共享同一个缓存集群，TenantCache按租户ID返回相互隔离的缓存实例
+//
+// 路由方式：
+// - Redis驱动：TenantOptions.DBs中配置了数据库编号的租户使用独立的连接，数据保存在各自的数据库中
+// - 其他租户（以及嵌入式驱动的所有租户）共享同一个实例，键名加上"<prefix>:<tenant>:"命名空间
+//
+// 隔离保证：
+// - 租户ID不能为空，不能包含":"和通配符，一个租户的命名空间不会包含另一个租户的键
+// - 配置的数据库编号不能重复，也不能与共享实例使用的数据库相同
+// - 共享实例上的租户不能Backup/Restore（会涉及其他租户的数据），返回ErrUnsupported
+// - 租户实例的Stats只统计本租户的键，Close不做任何操作，所有连接由TenantCache.Close关闭
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// defaultTenantPrefix 共享实例上租户命名空间的默认前缀
+const defaultTenantPrefix = "tenant"
+
+var (
+	// ErrInvalidTenant 租户ID为空或包含":"、通配符
+	ErrInvalidTenant = errors.New("cache: invalid tenant id")
+
+	// ErrTenantCacheClosed TenantCache已经关闭
+	ErrTenantCacheClosed = errors.New("cache: tenant cache closed")
+)
+
+// TenantOptions 多租户缓存的选项
+type TenantOptions struct {
+	DBs    map[string]int // 租户ID到Redis数据库编号的映射，只能用于Redis驱动
+	Prefix string         // 共享实例上租户命名空间的前缀，为空时使用"tenant"
+}
+
+// TenantCache 多租户缓存，按租户ID返回隔离的缓存实例，可以并发使用
+type TenantCache struct {
+	cfg    config.Cache
+	dbs    map[string]int
+	prefix string
+	shared _interface.Cache // 没有配置数据库编号的租户共享的实例
+
+	mu      sync.Mutex
+	conns   map[string]_interface.Cache // 配置了数据库编号的租户的独立连接
+	tenants map[string]_interface.Cache
+	closed  bool
+}
+
+// NewTenantCache 创建多租户缓存
+// 参数：
+//
+//	cfg - 缓存配置，共享实例使用cfg.DB，配置了数据库编号的租户使用各自的编号
+//	opts - 多租户选项
+//
+// 返回值：
+//
+//	*TenantCache - 多租户缓存，使用完毕后需要调用Close
+//	error - 配置无效、数据库编号冲突或创建共享实例失败
+func NewTenantCache(cfg config.Cache, opts TenantOptions) (*TenantCache, error) {
+	if len(opts.DBs) > 0 && cfg.Driver != config.CacheDriverRedis {
+		return nil, fmt.Errorf("cache: tenant db routing requires the redis driver, got %q", cfg.Driver)
+	}
+	used := map[int]string{cfg.DB: ""}
+	for id, db := range opts.DBs {
+		if err := checkTenantID(id); err != nil {
+			return nil, err
+		}
+		if db < 0 {
+			return nil, fmt.Errorf("cache: invalid db %d for tenant %q", db, id)
+		}
+		if other, ok := used[db]; ok {
+			if other == "" {
+				return nil, fmt.Errorf("cache: tenant %q uses the shared db %d", id, db)
+			}
+			return nil, fmt.Errorf("cache: tenants %q and %q share db %d", other, id, db)
+		}
+		used[db] = id
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = defaultTenantPrefix
+	}
+
+	shared, err := _interface.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &TenantCache{
+		cfg:     cfg,
+		dbs:     maps.Clone(opts.DBs),
+		prefix:  opts.Prefix,
+		shared:  shared,
+		conns:   make(map[string]_interface.Cache),
+		tenants: make(map[string]_interface.Cache),
+	}, nil
+}
+
+// checkTenantID 检查租户ID，":"会让一个租户的命名空间包含另一个租户的键，通配符会影响Keys的匹配
+func checkTenantID(id string) error {
+	if id == "" || strings.ContainsAny(id, ":*?[]\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, id)
+	}
+	return nil
+}
+
+// Tenant 返回租户的缓存实例，同一个租户多次调用返回同一个实例
+// 参数：
+//
+//	id - 租户ID
+//
+// 返回值：
+//
+//	_interface.Cache - 租户的缓存实例，底层缓存实现了CacheCtx时同样实现CacheCtx；
+//	实例由TenantCache拥有，Close不做任何操作
+//	error - 租户ID无效、TenantCache已关闭或创建独立连接失败
+func (t *TenantCache) Tenant(id string) (_interface.Cache, error) {
+	if err := checkTenantID(id); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrTenantCacheClosed
+	}
+	if c, ok := t.tenants[id]; ok {
+		return c, nil
+	}
+
+	var c _interface.Cache
+	tc := &tenant{t: t, id: id}
+	if db, ok := t.dbs[id]; ok {
+		cfg := t.cfg
+		cfg.DB = db
+		conn, err := _interface.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("cache: open db %d for tenant %q: %w", db, id, err)
+		}
+		t.conns[id] = conn
+		c = conn
+	} else {
+		c = WithNamespace(t.shared, t.namespace(id))
+		tc.shared = true
+	}
+
+	tc.Cache = c
+	if cc, ok := c.(_interface.CacheCtx); ok {
+		t.tenants[id] = &tenantCtx{CacheCtx: cc, tenant: tc}
+	} else {
+		t.tenants[id] = tc
+	}
+	return t.tenants[id], nil
+}
+
+// namespace 返回共享实例上租户的命名空间
+func (t *TenantCache) namespace(id string) string {
+	return t.prefix + ":" + id
+}
+
+// Tenants 返回已经打开的租户ID，按字典序排列
+func (t *TenantCache) Tenants() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Sorted(maps.Keys(t.tenants))
+}
+
+// Stats 返回租户的统计信息
+// 使用独立数据库的租户返回该连接的统计信息；共享实例上的租户只统计命名空间内的键数，
+// 内存、磁盘占用和淘汰次数无法按租户区分，为StatsUnknown，连接池统计为共享实例的连接池
+// 参数：
+//
+//	id - 租户ID
+//
+// 返回值：
+//
+//	_interface.Stats - 统计信息，Extra中的"tenant"为租户ID，"db"或"namespace"为路由方式
+//	error - 租户ID无效或驱动错误
+func (t *TenantCache) Stats(id string) (_interface.Stats, error) {
+	return t.StatsContext(context.Background(), id)
+}
+
+// StatsContext 返回租户的统计信息，底层缓存实现了CacheCtx时ctx用于取消读取统计信息
+func (t *TenantCache) StatsContext(ctx context.Context, id string) (_interface.Stats, error) {
+	c, err := t.Tenant(id)
+	if err != nil {
+		return _interface.Stats{}, err
+	}
+	if tc, ok := c.(*tenantCtx); ok {
+		return tc.tenant.stats(ctx)
+	}
+	return c.(*tenant).stats(ctx)
+}
+
+// Close 关闭共享实例和所有独立连接，之后Tenant返回ErrTenantCacheClosed
+func (t *TenantCache) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.closed = true
+	for _, conn := range t.conns {
+		conn.Close()
+	}
+	t.shared.Close()
+}
+
+// tenant 租户的缓存实例，实现Cache接口
+type tenant struct {
+	_interface.Cache
+	t      *TenantCache
+	id     string
+	shared bool // 是否为共享实例上的命名空间
+}
+
+// Close 租户实例由TenantCache拥有，不做任何操作
+func (c *tenant) Close() {}
+
+func (c *tenant) Stats() (_interface.Stats, error) {
+	return c.stats(context.Background())
+}
+
+// stats 按路由方式返回租户的统计信息
+func (c *tenant) stats(ctx context.Context) (_interface.Stats, error) {
+	var stats _interface.Stats
+	var err error
+	if cc, ok := c.Cache.(_interface.CacheCtx); ok {
+		stats, err = cc.StatsContext(ctx)
+	} else {
+		stats, err = c.Cache.Stats()
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	extra := map[string]string{"tenant": c.id}
+	maps.Copy(extra, stats.Extra)
+	stats.Extra = extra
+	if !c.shared {
+		stats.Extra["db"] = strconv.Itoa(c.t.dbs[c.id])
+		return stats, nil
+	}
+
+	stats.Extra["namespace"] = c.t.namespace(c.id)
+	stats.Keys = 0
+	stats.MemoryBytes = _interface.StatsUnknown
+	stats.DiskBytes = _interface.StatsUnknown
+	stats.Evictions = _interface.StatsUnknown
+	stats.Levels = nil
+	err = c.Cache.Keys("", func(string) bool {
+		stats.Keys++
+		return ctx.Err() == nil
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	return stats, err
+}
+
+// Backup 共享实例上的租户返回ErrUnsupported，备份会包含其他租户的数据
+func (c *tenant) Backup(w io.Writer) error {
+	if c.shared {
+		return _interface.ErrUnsupported
+	}
+	return c.Cache.Backup(w)
+}
+
+// Restore 共享实例上的租户返回ErrUnsupported，备份中的键不受命名空间限制
+func (c *tenant) Restore(r io.Reader) error {
+	if c.shared {
+		return _interface.ErrUnsupported
+	}
+	return c.Cache.Restore(r)
+}
+
+// BeginTxOptions 按选项开启租户缓存的事务
+func (c *tenant) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	return _interface.BeginTx(ctx, c.Cache, opts)
+}
+
+// PipelineContext 在租户缓存上执行命令管道
+func (c *tenant) PipelineContext(ctx context.Context, fn func(p _interface.Pipeliner) error) error {
+	return _interface.PipelineContext(ctx, c.Cache, fn)
+}
+
+// tenantCtx 底层缓存实现了CacheCtx时使用的租户实例，不带上下文的方法与tenant一致
+type tenantCtx struct {
+	_interface.CacheCtx
+	tenant *tenant
+}
+
+func (c *tenantCtx) Close() {}
+
+func (c *tenantCtx) Stats() (_interface.Stats, error) {
+	return c.tenant.stats(context.Background())
+}
+
+func (c *tenantCtx) Backup(w io.Writer) error {
+	return c.tenant.Backup(w)
+}
+
+func (c *tenantCtx) Restore(r io.Reader) error {
+	return c.tenant.Restore(r)
+}
+
+func (c *tenantCtx) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	return c.tenant.BeginTxOptions(ctx, opts)
+}
+
+func (c *tenantCtx) PipelineContext(ctx context.Context, fn func(p _interface.Pipeliner) error) error {
+	return c.tenant.PipelineContext(ctx, fn)
+}
+
+func (c *tenantCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return c.tenant.stats(ctx)
+}
+
+func (c *tenantCtx) BackupContext(ctx context.Context, w io.Writer) error {
+	if c.tenant.shared {
+		return _interface.ErrUnsupported
+	}
+	return c.CacheCtx.BackupContext(ctx, w)
+}
+
+func (c *tenantCtx) RestoreContext(ctx context.Context, r io.Reader) error {
+	if c.tenant.shared {
+		return _interface.ErrUnsupported
+	}
+	return c.CacheCtx.RestoreContext(ctx, r)
+}
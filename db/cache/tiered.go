@@ -0,0 +1,828 @@
+// cache包：两级缓存
+// 读取时先查询进程内的L1缓存，未命中时再查询Redis、BadgerDB等L2缓存并回填L1，降低热点键的访问延迟
+//
+// 主要特性：
+// - 只有键值（Get/GetObject）经过L1，哈希表、集合、队列、布隆过滤器等操作直接访问L2
+// - 写入和删除先作用于L2，再删除本地L1中的副本，并通过L2的发布订阅通知其他进程删除各自的副本
+// - L1中副本的存活时间不超过L1TTL，也不超过L2中键的剩余过期时间
+// - 发布订阅不可用时只能依靠L1TTL过期，其他进程最多读到L1TTL之前的旧值
+// - 从L2读取期间键被写入或删除时放弃回填，L1不会保存读取之前的旧值
+// - L2实现了CacheCtx时，返回的实例同样实现CacheCtx
+//
+// 失效消息的内容为"k"+键名或"p"+前缀，分别表示删除单个键和删除以前缀开头的所有键
+//
+// 作者: gophertool
+package cache
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+const (
+	// defaultL1TTL L1中副本的默认存活时间
+	defaultL1TTL = time.Minute
+	// defaultInvalidationChannel 默认的失效通知频道
+	defaultInvalidationChannel = "cache:tiered:invalidate"
+
+	invalidateKey    = "k" // 失效消息前缀：删除单个键
+	invalidatePrefix = "p" // 失效消息前缀：删除以前缀开头的所有键
+)
+
+// TieredOptions 两级缓存的选项
+type TieredOptions struct {
+	L1TTL               time.Duration    // L1中副本的最长存活时间，0表示使用默认值1分钟
+	InvalidationChannel string           // L2上的失效通知频道，为空时使用"cache:tiered:invalidate"
+	Codec               _interface.Codec // SetObject/GetObject的编码方式，为nil时使用JSONCodec
+}
+
+// NewTiered 创建两级缓存
+// 参数：
+//
+//	l1 - 进程内的L1缓存，通常是Memory或Ristretto驱动
+//	l2 - 共享的L2缓存，通常是Redis或BadgerDB驱动
+//	opts - 两级缓存选项
+//
+// 返回值：
+//
+//	_interface.Cache - 两级缓存实例，l2实现了CacheCtx时同样实现CacheCtx；
+//	Close只停止失效通知的订阅，l1和l2由创建它们的一方关闭
+func NewTiered(l1, l2 _interface.Cache, opts TieredOptions) _interface.Cache {
+	if opts.L1TTL <= 0 {
+		opts.L1TTL = defaultL1TTL
+	}
+	if opts.InvalidationChannel == "" {
+		opts.InvalidationChannel = defaultInvalidationChannel
+	}
+	if opts.Codec == nil {
+		opts.Codec = _interface.JSONCodec{}
+	}
+
+	t := &tiered{
+		l1:      l1,
+		l2:      l2,
+		l1TTL:   opts.L1TTL,
+		channel: opts.InvalidationChannel,
+		codec:   opts.Codec,
+		done:    make(chan struct{}),
+	}
+	msgs, cancel := l2.Subscribe(t.channel)
+	t.cancel = cancel
+	go t.listen(msgs)
+
+	if cc, ok := l2.(_interface.CacheCtx); ok {
+		return &tieredCtx{tiered: t, cc: cc}
+	}
+	return t
+}
+
+// tiered 两级缓存，实现Cache接口
+type tiered struct {
+	l1      _interface.Cache
+	l2      _interface.Cache
+	l1TTL   time.Duration
+	channel string
+	codec   _interface.Codec
+	cancel  func()        // 取消失效通知的订阅
+	done    chan struct{} // 失效通知处理退出后关闭
+	once    sync.Once
+
+	fillMu sync.Mutex
+	fills  map[string]*pendingFill // 正在从L2读取、准备回填L1的键
+}
+
+// pendingFill 一个键上进行中的回填
+type pendingFill struct {
+	readers int    // 正在读取这个键的Get数量
+	gen     uint64 // 失效代数，键在本地或其他进程失效时递增
+}
+
+// listen 处理其他进程发布的失效通知
+func (t *tiered) listen(msgs <-chan _interface.Message) {
+	defer close(t.done)
+	for msg := range msgs {
+		if key, ok := strings.CutPrefix(msg.Payload, invalidateKey); ok {
+			t.drop(key)
+		} else if prefix, ok := strings.CutPrefix(msg.Payload, invalidatePrefix); ok {
+			t.dropPrefix(prefix)
+		}
+	}
+}
+
+// invalidate 删除L1中的副本并通知其他进程，通知失败时其他进程的副本在L1TTL后过期
+func (t *tiered) invalidate(key string) {
+	t.drop(key)
+	_ = t.l2.Publish(t.channel, invalidateKey+key)
+}
+
+func (t *tiered) invalidatePrefix(prefix string) {
+	t.dropPrefix(prefix)
+	_ = t.l2.Publish(t.channel, invalidatePrefix+prefix)
+}
+
+// drop 删除L1中的副本，并使进行中的回填失效
+func (t *tiered) drop(key string) {
+	t.fillMu.Lock()
+	defer t.fillMu.Unlock()
+	if f := t.fills[key]; f != nil {
+		f.gen++
+	}
+	_ = t.l1.Delete(key)
+}
+
+func (t *tiered) dropPrefix(prefix string) {
+	t.fillMu.Lock()
+	defer t.fillMu.Unlock()
+	for key, f := range t.fills {
+		if strings.HasPrefix(key, prefix) {
+			f.gen++
+		}
+	}
+	_ = t.l1.DeleteByPrefix(prefix)
+}
+
+// beginFill 在从L2读取之前调用，返回当前的失效代数，读取结束后必须调用endFill
+func (t *tiered) beginFill(key string) uint64 {
+	t.fillMu.Lock()
+	defer t.fillMu.Unlock()
+	f := t.fills[key]
+	if f == nil {
+		if t.fills == nil {
+			t.fills = make(map[string]*pendingFill)
+		}
+		f = &pendingFill{}
+		t.fills[key] = f
+	}
+	f.readers++
+	return f.gen
+}
+
+func (t *tiered) endFill(key string) {
+	t.fillMu.Lock()
+	defer t.fillMu.Unlock()
+	if f := t.fills[key]; f != nil {
+		if f.readers--; f.readers == 0 {
+			delete(t.fills, key)
+		}
+	}
+}
+
+// fill 将从L2读到的值回填L1，存活时间不超过键在L2中的剩余过期时间
+// 读取期间键已经失效（代数与beginFill返回的不同）时不回填
+func (t *tiered) fill(key string, gen uint64, value string, remaining time.Duration, err error) {
+	if err == nil && remaining == _interface.TTLNotFound {
+		return // 读取之后键已经过期或被删除
+	}
+	ttl := t.l1TTL
+	if err == nil && remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+
+	t.fillMu.Lock()
+	defer t.fillMu.Unlock()
+	if f := t.fills[key]; f != nil && f.gen == gen {
+		_ = t.l1.Set(key, value, ttl)
+	}
+}
+
+// Close 停止失效通知的订阅，l1和l2由创建它们的一方关闭
+func (t *tiered) Close() {
+	t.once.Do(func() {
+		t.cancel()
+		<-t.done
+	})
+}
+
+// Get 先查询L1，未命中时查询L2并回填L1
+func (t *tiered) Get(key string) (string, error) {
+	if value, err := t.l1.Get(key); err == nil {
+		return value, nil
+	}
+	gen := t.beginFill(key)
+	defer t.endFill(key)
+	value, err := t.l2.Get(key)
+	if err != nil {
+		return "", err
+	}
+	remaining, err := t.l2.TTL(key)
+	t.fill(key, gen, value, remaining, err)
+	return value, nil
+}
+
+func (t *tiered) Set(key string, value string, ttl time.Duration) error {
+	if err := t.l2.Set(key, value, ttl); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tiered) Delete(key string) error {
+	if err := t.l2.Delete(key); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tiered) Exists(key string) (bool, error) {
+	return t.l2.Exists(key)
+}
+
+func (t *tiered) Expire(key string, ttl time.Duration) error {
+	if err := t.l2.Expire(key, ttl); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tiered) TTL(key string) (time.Duration, error) {
+	return t.l2.TTL(key)
+}
+
+func (t *tiered) SetNX(key string, value string, ttl time.Duration) (bool, error) {
+	ok, err := t.l2.SetNX(key, value, ttl)
+	if ok {
+		t.invalidate(key)
+	}
+	return ok, err
+}
+
+func (t *tiered) CAS(key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	ok, err := t.l2.CAS(key, oldValue, newValue, ttl)
+	if ok {
+		t.invalidate(key)
+	}
+	return ok, err
+}
+
+func (t *tiered) GetSet(key string, value string) (string, error) {
+	old, err := t.l2.GetSet(key, value)
+	t.invalidate(key)
+	return old, err
+}
+
+func (t *tiered) GetDel(key string) (string, error) {
+	value, err := t.l2.GetDel(key)
+	if err == nil {
+		t.invalidate(key)
+	}
+	return value, err
+}
+
+func (t *tiered) Append(key string, value string) (int64, error) {
+	n, err := t.l2.Append(key, value)
+	t.invalidate(key)
+	return n, err
+}
+
+func (t *tiered) StrLen(key string) (int64, error) {
+	return t.l2.StrLen(key)
+}
+
+func (t *tiered) SetObject(key string, v any, ttl time.Duration) error {
+	data, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.Set(key, string(data), ttl)
+}
+
+func (t *tiered) GetObject(key string, v any) error {
+	raw, err := t.Get(key)
+	if err != nil {
+		return err
+	}
+	return t.codec.Unmarshal([]byte(raw), v)
+}
+
+func (t *tiered) Keys(pattern string, fn func(key string) bool) error {
+	return t.l2.Keys(pattern, fn)
+}
+
+// Iterate 遍历L2中的键值，不经过L1
+func (t *tiered) Iterate(prefix string, fn func(key, value string) bool) error {
+	return t.l2.Iterate(prefix, fn)
+}
+
+func (t *tiered) DeleteByPrefix(prefix string) error {
+	if err := t.l2.DeleteByPrefix(prefix); err != nil {
+		return err
+	}
+	t.invalidatePrefix(prefix)
+	return nil
+}
+
+func (t *tiered) HGet(key, field string) (string, error) {
+	return t.l2.HGet(key, field)
+}
+
+func (t *tiered) HSet(key, field, value string, ttl time.Duration) error {
+	return t.l2.HSet(key, field, value, ttl)
+}
+
+func (t *tiered) HDel(key, field string) error {
+	return t.l2.HDel(key, field)
+}
+
+func (t *tiered) HGetAll(key string) (map[string]string, error) {
+	return t.l2.HGetAll(key)
+}
+
+func (t *tiered) HLen(key string) (int64, error) {
+	return t.l2.HLen(key)
+}
+
+func (t *tiered) HExists(key, field string) (bool, error) {
+	return t.l2.HExists(key, field)
+}
+
+func (t *tiered) HKeys(key string) ([]string, error) {
+	return t.l2.HKeys(key)
+}
+
+func (t *tiered) SAdd(key, member string) error {
+	return t.l2.SAdd(key, member)
+}
+
+func (t *tiered) SRem(key, member string) error {
+	return t.l2.SRem(key, member)
+}
+
+func (t *tiered) SMembers(key string) ([]string, error) {
+	return t.l2.SMembers(key)
+}
+
+func (t *tiered) SIsMember(key, member string) (bool, error) {
+	return t.l2.SIsMember(key, member)
+}
+
+func (t *tiered) Push(key string, value string) error {
+	return t.l2.Push(key, value)
+}
+
+func (t *tiered) LPush(key string, value string) error {
+	return t.l2.LPush(key, value)
+}
+
+func (t *tiered) RPush(key string, value string) error {
+	return t.l2.RPush(key, value)
+}
+
+func (t *tiered) RPushCapped(key string, value string, maxLen int64) error {
+	return t.l2.RPushCapped(key, value, maxLen)
+}
+
+func (t *tiered) Pop(key string) (string, error) {
+	return t.l2.Pop(key)
+}
+
+func (t *tiered) LPop(key string) (string, error) {
+	return t.l2.LPop(key)
+}
+
+func (t *tiered) RPop(key string) (string, error) {
+	return t.l2.RPop(key)
+}
+
+func (t *tiered) PopAll(key string) ([]string, error) {
+	return t.l2.PopAll(key)
+}
+
+func (t *tiered) Len(key string) (int64, error) {
+	return t.l2.Len(key)
+}
+
+func (t *tiered) LRange(key string, start, stop int64) ([]string, error) {
+	return t.l2.LRange(key, start, stop)
+}
+
+func (t *tiered) LIndex(key string, index int64) (string, error) {
+	return t.l2.LIndex(key, index)
+}
+
+func (t *tiered) LRem(key string, count int64, value string) (int64, error) {
+	return t.l2.LRem(key, count, value)
+}
+
+func (t *tiered) LTrim(key string, start, stop int64) error {
+	return t.l2.LTrim(key, start, stop)
+}
+
+func (t *tiered) PopAck(key string, visibility time.Duration) (string, string, error) {
+	return t.l2.PopAck(key, visibility)
+}
+
+func (t *tiered) Ack(key, receipt string) error {
+	return t.l2.Ack(key, receipt)
+}
+
+func (t *tiered) PushDelayed(key, value string, delay time.Duration) error {
+	return t.l2.PushDelayed(key, value, delay)
+}
+
+func (t *tiered) Publish(channel string, payload string) error {
+	return t.l2.Publish(channel, payload)
+}
+
+func (t *tiered) Subscribe(channel string) (<-chan _interface.Message, func()) {
+	return t.l2.Subscribe(channel)
+}
+
+func (t *tiered) SubscribeExpired() (<-chan string, func()) {
+	return t.l2.SubscribeExpired()
+}
+
+// Stats 返回L2的统计信息，L1只是L2的副本
+func (t *tiered) Stats() (_interface.Stats, error) {
+	return t.l2.Stats()
+}
+
+// Backup 备份L2的数据
+func (t *tiered) Backup(w io.Writer) error {
+	return t.l2.Backup(w)
+}
+
+// Restore 恢复L2的数据，之后清空所有实例的L1
+func (t *tiered) Restore(r io.Reader) error {
+	if err := t.l2.Restore(r); err != nil {
+		return err
+	}
+	t.invalidatePrefix("")
+	return nil
+}
+
+func (t *tiered) BeginTx() (_interface.Tx, error) {
+	tx, err := t.l2.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	return &tieredTx{tx: tx, t: t}, nil
+}
+
+func (t *tiered) BeginTxWatch(keys ...string) (_interface.Tx, error) {
+	tx, err := t.l2.BeginTxWatch(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredTx{tx: tx, t: t}, nil
+}
+
+// BeginTxOptions 按选项开启L2的事务
+func (t *tiered) BeginTxOptions(ctx context.Context, opts _interface.TxOptions) (_interface.Tx, error) {
+	tx, err := _interface.BeginTx(ctx, t.l2, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredTx{tx: tx, t: t}, nil
+}
+
+// BFReserve 布隆过滤器直接访问L2，L2支持时使用原生实现
+func (t *tiered) BFReserve(key string, errorRate float64, capacity int64) error {
+	return _interface.BFReserve(t.l2, key, errorRate, capacity)
+}
+
+func (t *tiered) BFAdd(key, item string) (bool, error) {
+	return _interface.BFAdd(t.l2, key, item)
+}
+
+func (t *tiered) BFExists(key, item string) (bool, error) {
+	return _interface.BFExists(t.l2, key, item)
+}
+
+// tieredTx 两级缓存事务，提交成功后使事务内写入和删除的键失效
+type tieredTx struct {
+	tx   _interface.Tx
+	t    *tiered
+	keys []string
+}
+
+// Get 直接读取L2的事务，L1中的值可能与事务内的写入不一致
+func (tx *tieredTx) Get(key string) (string, error) {
+	return tx.tx.Get(key)
+}
+
+func (tx *tieredTx) Exists(key string) (bool, error) {
+	return tx.tx.Exists(key)
+}
+
+func (tx *tieredTx) Set(key string, value string, ttl time.Duration) error {
+	tx.keys = append(tx.keys, key)
+	return tx.tx.Set(key, value, ttl)
+}
+
+func (tx *tieredTx) Delete(key string) error {
+	tx.keys = append(tx.keys, key)
+	return tx.tx.Delete(key)
+}
+
+func (tx *tieredTx) Expire(key string, ttl time.Duration) error {
+	tx.keys = append(tx.keys, key)
+	return tx.tx.Expire(key, ttl)
+}
+
+// HSet 哈希表不进入L1，直接写入L2的事务
+func (tx *tieredTx) HSet(key, field, value string, ttl time.Duration) error {
+	return tx.tx.HSet(key, field, value, ttl)
+}
+
+func (tx *tieredTx) HDel(key, field string) error {
+	return tx.tx.HDel(key, field)
+}
+
+func (tx *tieredTx) Commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return err
+	}
+	for _, key := range tx.keys {
+		tx.t.invalidate(key)
+	}
+	return nil
+}
+
+func (tx *tieredTx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+// tieredCtx L2实现了CacheCtx时使用的两级缓存
+type tieredCtx struct {
+	*tiered
+	cc _interface.CacheCtx
+}
+
+func (t *tieredCtx) GetContext(ctx context.Context, key string) (string, error) {
+	if value, err := t.l1.Get(key); err == nil {
+		return value, nil
+	}
+	gen := t.beginFill(key)
+	defer t.endFill(key)
+	value, err := t.cc.GetContext(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	remaining, err := t.cc.TTLContext(ctx, key)
+	t.fill(key, gen, value, remaining, err)
+	return value, nil
+}
+
+func (t *tieredCtx) SetContext(ctx context.Context, key string, value string, ttl time.Duration) error {
+	if err := t.cc.SetContext(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tieredCtx) DeleteContext(ctx context.Context, key string) error {
+	if err := t.cc.DeleteContext(ctx, key); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tieredCtx) ExistsContext(ctx context.Context, key string) (bool, error) {
+	return t.cc.ExistsContext(ctx, key)
+}
+
+func (t *tieredCtx) ExpireContext(ctx context.Context, key string, ttl time.Duration) error {
+	if err := t.cc.ExpireContext(ctx, key, ttl); err != nil {
+		return err
+	}
+	t.invalidate(key)
+	return nil
+}
+
+func (t *tieredCtx) TTLContext(ctx context.Context, key string) (time.Duration, error) {
+	return t.cc.TTLContext(ctx, key)
+}
+
+func (t *tieredCtx) SetNXContext(ctx context.Context, key string, value string, ttl time.Duration) (bool, error) {
+	ok, err := t.cc.SetNXContext(ctx, key, value, ttl)
+	if ok {
+		t.invalidate(key)
+	}
+	return ok, err
+}
+
+func (t *tieredCtx) CASContext(ctx context.Context, key string, oldValue string, newValue string, ttl time.Duration) (bool, error) {
+	ok, err := t.cc.CASContext(ctx, key, oldValue, newValue, ttl)
+	if ok {
+		t.invalidate(key)
+	}
+	return ok, err
+}
+
+func (t *tieredCtx) GetSetContext(ctx context.Context, key string, value string) (string, error) {
+	old, err := t.cc.GetSetContext(ctx, key, value)
+	t.invalidate(key)
+	return old, err
+}
+
+func (t *tieredCtx) GetDelContext(ctx context.Context, key string) (string, error) {
+	value, err := t.cc.GetDelContext(ctx, key)
+	if err == nil {
+		t.invalidate(key)
+	}
+	return value, err
+}
+
+func (t *tieredCtx) AppendContext(ctx context.Context, key string, value string) (int64, error) {
+	n, err := t.cc.AppendContext(ctx, key, value)
+	t.invalidate(key)
+	return n, err
+}
+
+func (t *tieredCtx) StrLenContext(ctx context.Context, key string) (int64, error) {
+	return t.cc.StrLenContext(ctx, key)
+}
+
+func (t *tieredCtx) SetObjectContext(ctx context.Context, key string, v any, ttl time.Duration) error {
+	data, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.SetContext(ctx, key, string(data), ttl)
+}
+
+func (t *tieredCtx) GetObjectContext(ctx context.Context, key string, v any) error {
+	raw, err := t.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return t.codec.Unmarshal([]byte(raw), v)
+}
+
+func (t *tieredCtx) KeysContext(ctx context.Context, pattern string, fn func(key string) bool) error {
+	return t.cc.KeysContext(ctx, pattern, fn)
+}
+
+func (t *tieredCtx) IterateContext(ctx context.Context, prefix string, fn func(key, value string) bool) error {
+	return t.cc.IterateContext(ctx, prefix, fn)
+}
+
+func (t *tieredCtx) DeleteByPrefixContext(ctx context.Context, prefix string) error {
+	if err := t.cc.DeleteByPrefixContext(ctx, prefix); err != nil {
+		return err
+	}
+	t.invalidatePrefix(prefix)
+	return nil
+}
+
+func (t *tieredCtx) HGetContext(ctx context.Context, key, field string) (string, error) {
+	return t.cc.HGetContext(ctx, key, field)
+}
+
+func (t *tieredCtx) HSetContext(ctx context.Context, key, field, value string, ttl time.Duration) error {
+	return t.cc.HSetContext(ctx, key, field, value, ttl)
+}
+
+func (t *tieredCtx) HDelContext(ctx context.Context, key, field string) error {
+	return t.cc.HDelContext(ctx, key, field)
+}
+
+func (t *tieredCtx) HGetAllContext(ctx context.Context, key string) (map[string]string, error) {
+	return t.cc.HGetAllContext(ctx, key)
+}
+
+func (t *tieredCtx) HLenContext(ctx context.Context, key string) (int64, error) {
+	return t.cc.HLenContext(ctx, key)
+}
+
+func (t *tieredCtx) HExistsContext(ctx context.Context, key, field string) (bool, error) {
+	return t.cc.HExistsContext(ctx, key, field)
+}
+
+func (t *tieredCtx) HKeysContext(ctx context.Context, key string) ([]string, error) {
+	return t.cc.HKeysContext(ctx, key)
+}
+
+func (t *tieredCtx) SAddContext(ctx context.Context, key, member string) error {
+	return t.cc.SAddContext(ctx, key, member)
+}
+
+func (t *tieredCtx) SRemContext(ctx context.Context, key, member string) error {
+	return t.cc.SRemContext(ctx, key, member)
+}
+
+func (t *tieredCtx) SMembersContext(ctx context.Context, key string) ([]string, error) {
+	return t.cc.SMembersContext(ctx, key)
+}
+
+func (t *tieredCtx) SIsMemberContext(ctx context.Context, key, member string) (bool, error) {
+	return t.cc.SIsMemberContext(ctx, key, member)
+}
+
+func (t *tieredCtx) PushContext(ctx context.Context, key string, value string) error {
+	return t.cc.PushContext(ctx, key, value)
+}
+
+func (t *tieredCtx) LPushContext(ctx context.Context, key string, value string) error {
+	return t.cc.LPushContext(ctx, key, value)
+}
+
+func (t *tieredCtx) RPushContext(ctx context.Context, key string, value string) error {
+	return t.cc.RPushContext(ctx, key, value)
+}
+
+func (t *tieredCtx) RPushCappedContext(ctx context.Context, key string, value string, maxLen int64) error {
+	return t.cc.RPushCappedContext(ctx, key, value, maxLen)
+}
+
+func (t *tieredCtx) PopContext(ctx context.Context, key string) (string, error) {
+	return t.cc.PopContext(ctx, key)
+}
+
+func (t *tieredCtx) LPopContext(ctx context.Context, key string) (string, error) {
+	return t.cc.LPopContext(ctx, key)
+}
+
+func (t *tieredCtx) RPopContext(ctx context.Context, key string) (string, error) {
+	return t.cc.RPopContext(ctx, key)
+}
+
+func (t *tieredCtx) PopAllContext(ctx context.Context, key string) ([]string, error) {
+	return t.cc.PopAllContext(ctx, key)
+}
+
+func (t *tieredCtx) LenContext(ctx context.Context, key string) (int64, error) {
+	return t.cc.LenContext(ctx, key)
+}
+
+func (t *tieredCtx) LRangeContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return t.cc.LRangeContext(ctx, key, start, stop)
+}
+
+func (t *tieredCtx) LIndexContext(ctx context.Context, key string, index int64) (string, error) {
+	return t.cc.LIndexContext(ctx, key, index)
+}
+
+func (t *tieredCtx) LRemContext(ctx context.Context, key string, count int64, value string) (int64, error) {
+	return t.cc.LRemContext(ctx, key, count, value)
+}
+
+func (t *tieredCtx) LTrimContext(ctx context.Context, key string, start, stop int64) error {
+	return t.cc.LTrimContext(ctx, key, start, stop)
+}
+
+func (t *tieredCtx) PopAckContext(ctx context.Context, key string, visibility time.Duration) (string, string, error) {
+	return t.cc.PopAckContext(ctx, key, visibility)
+}
+
+func (t *tieredCtx) AckContext(ctx context.Context, key, receipt string) error {
+	return t.cc.AckContext(ctx, key, receipt)
+}
+
+func (t *tieredCtx) PushDelayedContext(ctx context.Context, key, value string, delay time.Duration) error {
+	return t.cc.PushDelayedContext(ctx, key, value, delay)
+}
+
+func (t *tieredCtx) PublishContext(ctx context.Context, channel string, payload string) error {
+	return t.cc.PublishContext(ctx, channel, payload)
+}
+
+func (t *tieredCtx) SubscribeContext(ctx context.Context, channel string) (<-chan _interface.Message, func()) {
+	return t.cc.SubscribeContext(ctx, channel)
+}
+
+func (t *tieredCtx) SubscribeExpiredContext(ctx context.Context) (<-chan string, func()) {
+	return t.cc.SubscribeExpiredContext(ctx)
+}
+
+func (t *tieredCtx) StatsContext(ctx context.Context) (_interface.Stats, error) {
+	return t.cc.StatsContext(ctx)
+}
+
+func (t *tieredCtx) BackupContext(ctx context.Context, w io.Writer) error {
+	return t.cc.BackupContext(ctx, w)
+}
+
+func (t *tieredCtx) RestoreContext(ctx context.Context, r io.Reader) error {
+	if err := t.cc.RestoreContext(ctx, r); err != nil {
+		return err
+	}
+	t.invalidatePrefix("")
+	return nil
+}
+
+func (t *tieredCtx) BeginTxContext(ctx context.Context) (_interface.Tx, error) {
+	tx, err := t.cc.BeginTxContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredTx{tx: tx, t: t.tiered}, nil
+}
+
+func (t *tieredCtx) BeginTxWatchContext(ctx context.Context, keys ...string) (_interface.Tx, error) {
+	tx, err := t.cc.BeginTxWatchContext(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &tieredTx{tx: tx, t: t.tiered}, nil
+}
@@ -0,0 +1,118 @@
+// typedcache包：泛型类型化缓存
+// 在字符串缓存之上自动编解码任意类型的值，调用方不再需要手写序列化代码
+//
+// 主要特性：
+// - 泛型API，Get直接返回T，Set直接接收T
+// - 编解码方式可替换，默认使用缓存配置的Codec
+// - GetOrLoad基于cache.Loader，在缓存未命中时调用加载函数并回写缓存，同一个key的并发加载只执行一次
+// - 可以缓存"不存在"的加载结果，避免反复访问数据源
+// - 可以包装任意Cache实例，包括WithNamespace返回的命名空间实例
+//
+// 作者: gophertool
+package typedcache
+
+import (
+	"time"
+
+	"github.com/gophertool/tool/db/cache"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// Cache 类型化缓存，值的类型为T
+type Cache[T any] struct {
+	c      _interface.Cache
+	codec  _interface.Codec // 为nil时使用缓存的SetObject/GetObject
+	loader *cache.Loader    // GetOrLoad的读穿透加载，合并同一个key的并发加载
+}
+
+// New 创建类型化缓存
+// 参数：
+//
+//	c - 底层缓存实例
+//	codec - 值编解码方式，为nil时使用缓存配置的Codec
+//
+// 返回值：
+//
+//	*Cache[T] - 类型化缓存实例
+func New[T any](c _interface.Cache, codec _interface.Codec) *Cache[T] {
+	return &Cache[T]{c: c, codec: codec, loader: cache.NewLoader(c, cache.LoaderOptions{})}
+}
+
+// WithNegativeTTL 设置"不存在"结果的缓存时间
+// 加载函数返回ErrKeyNotFound后，ttl时间内的GetOrLoad直接返回ErrKeyNotFound而不再加载
+// 参数：
+//
+//	ttl - 缓存时间，0表示不缓存
+//
+// 返回值：
+//
+//	*Cache[T] - 类型化缓存实例本身，便于链式调用
+func (t *Cache[T]) WithNegativeTTL(ttl time.Duration) *Cache[T] {
+	t.loader = cache.NewLoader(t.c, cache.LoaderOptions{NegativeTTL: ttl})
+	return t
+}
+
+// Get 获取并解码指定key的值
+// 参数：
+//
+//	key - 键名
+//
+// 返回值：
+//
+//	T - 解码后的值
+//	error - 操作错误，键不存在时返回ErrKeyNotFound
+func (t *Cache[T]) Get(key string) (T, error) {
+	var value T
+	if t.codec == nil {
+		err := t.c.GetObject(key, &value)
+		return value, err
+	}
+	raw, err := t.c.Get(key)
+	if err != nil {
+		return value, err
+	}
+	err = t.codec.Unmarshal([]byte(raw), &value)
+	return value, err
+}
+
+// Set 编码并设置key的值
+// 参数：
+//
+//	key - 键名
+//	value - 值
+//	ttl - 过期时间，0表示不过期
+//
+// 返回值：
+//
+//	error - 编码或写入错误
+func (t *Cache[T]) Set(key string, value T, ttl time.Duration) error {
+	if t.codec == nil {
+		return t.c.SetObject(key, value, ttl)
+	}
+	data, err := t.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return t.c.Set(key, string(data), ttl)
+}
+
+// GetOrLoad 获取key的值，缓存未命中时调用load加载并以ttl回写缓存
+// 同一个key的并发调用只有一个会执行load，其他调用共享其结果；回写失败不影响返回加载到的值
+// 参数：
+//
+//	key - 键名
+//	ttl - 回写缓存的过期时间
+//	load - 加载函数，数据源中不存在时应返回ErrKeyNotFound
+//
+// 返回值：
+//
+//	T - 缓存中的值或加载到的值
+//	error - 读取、解码或加载错误
+func (t *Cache[T]) GetOrLoad(key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	v, err := t.loader.Load(key,
+		func() (any, error) { return t.Get(key) },
+		func() (any, error) { return load() },
+		func(value any) error { return t.Set(key, value.(T), ttl) })
+	value, _ := v.(T)
+	return value, err
+}
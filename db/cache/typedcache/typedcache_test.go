@@ -0,0 +1,77 @@
+// typedcache包的测试文件
+// 测试类型化缓存的编解码、读穿透加载和"不存在"结果的缓存
+//
+// 作者: gophertool
+package typedcache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/db/cache/config"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+
+	// 导入内存缓存实现以确保驱动注册
+	_ "github.com/gophertool/tool/db/cache/memory"
+)
+
+// TestTypedCache 测试类型化缓存
+func TestTypedCache(t *testing.T) {
+	cache, err := _interface.New(config.Cache{Driver: config.CacheDriverMemory})
+	if err != nil {
+		t.Fatalf("创建内存缓存失败: %v", err)
+	}
+	defer cache.Close()
+
+	type user struct {
+		Name string
+		Age  int
+	}
+	users := New[user](cache, nil)
+
+	if err := users.Set("user:1", user{Name: "alice", Age: 30}, 0); err != nil {
+		t.Fatalf("Set操作失败: %v", err)
+	}
+	if got, err := users.Get("user:1"); err != nil || got != (user{Name: "alice", Age: 30}) {
+		t.Errorf("Get应返回alice，实际: %+v, %v", got, err)
+	}
+	if _, err := users.Get("user:2"); !errors.Is(err, _interface.ErrKeyNotFound) {
+		t.Errorf("不存在的key应返回ErrKeyNotFound，实际: %v", err)
+	}
+
+	loads := 0
+	load := func() (user, error) {
+		loads++
+		return user{Name: "bob"}, nil
+	}
+	for i := 0; i < 2; i++ {
+		if got, err := users.GetOrLoad("user:2", time.Minute, load); err != nil || got.Name != "bob" {
+			t.Errorf("GetOrLoad应返回bob，实际: %+v, %v", got, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("第二次GetOrLoad应命中缓存，加载次数: %d", loads)
+	}
+
+	// 无法解码的值返回解码错误，不会调用加载函数
+	cache.Set("user:3", "not json", 0)
+	if _, err := users.GetOrLoad("user:3", 0, load); err == nil || loads != 1 {
+		t.Errorf("无法解码的值应返回错误，实际: %v，加载次数: %d", err, loads)
+	}
+
+	// 缓存"不存在"的结果
+	users.WithNegativeTTL(time.Minute)
+	missing := func() (user, error) {
+		loads++
+		return user{}, _interface.ErrKeyNotFound
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := users.GetOrLoad("user:4", time.Minute, missing); !errors.Is(err, _interface.ErrKeyNotFound) {
+			t.Errorf("不存在的值应返回ErrKeyNotFound，实际: %v", err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("\"不存在\"的结果应被缓存，加载次数: %d", loads)
+	}
+}
@@ -0,0 +1,1037 @@
+// msgpack包：MessagePack编解码
+// 按MessagePack规范实现的紧凑二进制编码，不依赖第三方库，缓存的msgpack Codec和插件的msgpack RPC编解码器共用本实现
+//
+// 类型映射：
+//   - 整数、浮点数、布尔值、字符串、nil按规范使用最短的编码
+//   - []byte编码为bin，其他切片和数组编码为array，map编码为map
+//   - 结构体编码为以字段名为键的map，字段名可以通过`msgpack:"name,omitempty"`标签修改，"-"表示忽略；
+//     没有msgpack标签时使用json标签，匿名的结构体字段会展开到外层
+//   - time.Time编码为时间戳扩展类型（-1）
+//   - 实现了Marshaler/Unmarshaler的类型使用自定义的编解码
+//   - 解码到interface{}时，整数解码为int64/uint64，数组解码为[]any，map解码为map[string]any（键不全是字符串时为map[any]any）
+//
+// 作者: gophertool
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MessagePack格式码
+const (
+	mpNil      = 0xc0
+	mpFalse    = 0xc2
+	mpTrue     = 0xc3
+	mpBin8     = 0xc4
+	mpBin16    = 0xc5
+	mpBin32    = 0xc6
+	mpExt8     = 0xc7
+	mpExt16    = 0xc8
+	mpExt32    = 0xc9
+	mpFloat32  = 0xca
+	mpFloat64  = 0xcb
+	mpUint8    = 0xcc
+	mpUint16   = 0xcd
+	mpUint32   = 0xce
+	mpUint64   = 0xcf
+	mpInt8     = 0xd0
+	mpInt16    = 0xd1
+	mpInt32    = 0xd2
+	mpInt64    = 0xd3
+	mpFixExt1  = 0xd4
+	mpFixExt16 = 0xd8
+	mpStr8     = 0xd9
+	mpStr16    = 0xda
+	mpStr32    = 0xdb
+	mpArray16  = 0xdc
+	mpArray32  = 0xdd
+	mpMap16    = 0xde
+	mpMap32    = 0xdf
+
+	mpTimeExt   int8 = -1   // 时间戳扩展类型
+	timeExtByte      = 0xff // 时间戳扩展类型的编码
+)
+
+// maxDepth 解码时允许的最大嵌套深度，防止恶意数据耗尽栈空间
+const maxDepth = 1000
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+	errMsgpackShort = errors.New("msgpack: unexpected end of data")
+	errMsgpackDepth = errors.New("msgpack: exceeded max depth")
+)
+
+// Marshaler 自定义编码接口，返回值必须是一个完整的MessagePack值
+type Marshaler interface {
+	MarshalMsgpack() ([]byte, error)
+}
+
+// Unmarshaler 自定义解码接口，参数是一个完整的MessagePack值，实现需要复制后才能在返回后保留
+type Unmarshaler interface {
+	UnmarshalMsgpack(data []byte) error
+}
+
+// RawMessage 已编码的MessagePack值，用于延迟解码或直接写入已编码的数据
+type RawMessage []byte
+
+// MarshalMsgpack 返回m本身，m为空时编码为nil
+func (m RawMessage) MarshalMsgpack() ([]byte, error) {
+	if len(m) == 0 {
+		return []byte{mpNil}, nil
+	}
+	return m, nil
+}
+
+// UnmarshalMsgpack 将data复制到m
+func (m *RawMessage) UnmarshalMsgpack(data []byte) error {
+	*m = append((*m)[:0], data...)
+	return nil
+}
+
+// Marshal 将v编码为MessagePack数据
+func Marshal(v any) ([]byte, error) {
+	var e mpEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// Unmarshal 将MessagePack数据解码到v指向的值，data必须恰好是一个完整的值
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("msgpack: Unmarshal requires a non-nil pointer")
+	}
+	d := mpDecoder{data: data}
+	if err := d.decode(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errors.New("msgpack: trailing data")
+	}
+	return nil
+}
+
+// mpEncoder MessagePack编码器
+type mpEncoder struct {
+	buf bytes.Buffer
+}
+
+func (e *mpEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf.WriteByte(mpNil)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+	if m, ok := marshaler(v); ok {
+		data, err := m.MarshalMsgpack()
+		if err != nil {
+			return err
+		}
+		e.buf.Write(data)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf.WriteByte(mpTrue)
+		} else {
+			e.buf.WriteByte(mpFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.encodeUint(v.Uint())
+	case reflect.Float32:
+		e.buf.WriteByte(mpFloat32)
+		e.write32(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.buf.WriteByte(mpFloat64)
+		e.write64(math.Float64bits(v.Float()))
+	case reflect.String:
+		e.encodeString(v.String())
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf.WriteByte(mpNil)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf.WriteByte(mpNil)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeBytes(v.Bytes())
+			return nil
+		}
+		return e.encodeArray(v)
+	case reflect.Array:
+		return e.encodeArray(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf.WriteByte(mpNil)
+			return nil
+		}
+		return e.encodeMap(v)
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// marshaler 返回v实现的Marshaler，指针接收者的方法只在v可寻址时使用
+func marshaler(v reflect.Value) (Marshaler, bool) {
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, false
+	}
+	if v.Type().Implements(marshalerType) {
+		return v.Interface().(Marshaler), true
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(marshalerType) {
+		return v.Addr().Interface().(Marshaler), true
+	}
+	return nil, false
+}
+
+func (e *mpEncoder) encodeInt(n int64) {
+	switch {
+	case n >= 0:
+		e.encodeUint(uint64(n))
+	case n >= -32:
+		e.buf.WriteByte(byte(n))
+	case n >= math.MinInt8:
+		e.buf.WriteByte(mpInt8)
+		e.buf.WriteByte(byte(n))
+	case n >= math.MinInt16:
+		e.buf.WriteByte(mpInt16)
+		e.write16(uint16(n))
+	case n >= math.MinInt32:
+		e.buf.WriteByte(mpInt32)
+		e.write32(uint32(n))
+	default:
+		e.buf.WriteByte(mpInt64)
+		e.write64(uint64(n))
+	}
+}
+
+func (e *mpEncoder) encodeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint8:
+		e.buf.WriteByte(mpUint8)
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(mpUint16)
+		e.write16(uint16(n))
+	case n <= math.MaxUint32:
+		e.buf.WriteByte(mpUint32)
+		e.write32(uint32(n))
+	default:
+		e.buf.WriteByte(mpUint64)
+		e.write64(n)
+	}
+}
+
+func (e *mpEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.buf.WriteByte(mpStr8)
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(mpStr16)
+		e.write16(uint16(n))
+	default:
+		e.buf.WriteByte(mpStr32)
+		e.write32(uint32(n))
+	}
+	e.buf.WriteString(s)
+}
+
+func (e *mpEncoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf.WriteByte(mpBin8)
+		e.buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(mpBin16)
+		e.write16(uint16(n))
+	default:
+		e.buf.WriteByte(mpBin32)
+		e.write32(uint32(n))
+	}
+	e.buf.Write(b)
+}
+
+func (e *mpEncoder) encodeArrayLen(n int) {
+	switch {
+	case n < 16:
+		e.buf.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(mpArray16)
+		e.write16(uint16(n))
+	default:
+		e.buf.WriteByte(mpArray32)
+		e.write32(uint32(n))
+	}
+}
+
+func (e *mpEncoder) encodeMapLen(n int) {
+	switch {
+	case n < 16:
+		e.buf.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.buf.WriteByte(mpMap16)
+		e.write16(uint16(n))
+	default:
+		e.buf.WriteByte(mpMap32)
+		e.write32(uint32(n))
+	}
+}
+
+func (e *mpEncoder) encodeArray(v reflect.Value) error {
+	e.encodeArrayLen(v.Len())
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap 编码map，字符串键按字典序输出，保证相同的map编码结果相同
+func (e *mpEncoder) encodeMap(v reflect.Value) error {
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	}
+	e.encodeMapLen(len(keys))
+	for _, key := range keys {
+		if err := e.encode(key); err != nil {
+			return err
+		}
+		if err := e.encode(v.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *mpEncoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	present := make([]reflect.Value, len(fields))
+	n := 0
+	for i, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && fv.IsZero()) {
+			continue
+		}
+		present[i] = fv
+		n++
+	}
+
+	e.encodeMapLen(n)
+	for i, f := range fields {
+		if !present[i].IsValid() {
+			continue
+		}
+		e.encodeString(f.name)
+		if err := e.encode(present[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeTime 编码时间戳扩展类型，按规范选择32/64/96位格式
+func (e *mpEncoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	switch {
+	case sec>>34 == 0 && nsec == 0:
+		e.buf.WriteByte(0xd6) // fixext 4
+		e.buf.WriteByte(timeExtByte)
+		e.write32(uint32(sec))
+	case sec>>34 == 0:
+		e.buf.WriteByte(0xd7) // fixext 8
+		e.buf.WriteByte(timeExtByte)
+		e.write64(nsec<<34 | uint64(sec))
+	default:
+		e.buf.WriteByte(mpExt8)
+		e.buf.WriteByte(12)
+		e.buf.WriteByte(timeExtByte)
+		e.write32(uint32(nsec))
+		e.write64(uint64(sec))
+	}
+}
+
+func (e *mpEncoder) write16(n uint16) {
+	e.buf.Write(binary.BigEndian.AppendUint16(nil, n))
+}
+
+func (e *mpEncoder) write32(n uint32) {
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, n))
+}
+
+func (e *mpEncoder) write64(n uint64) {
+	e.buf.Write(binary.BigEndian.AppendUint64(nil, n))
+}
+
+// mpDecoder MessagePack解码器
+type mpDecoder struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func (d *mpDecoder) decode(v reflect.Value) error {
+	if d.pos >= len(d.data) {
+		return errMsgpackShort
+	}
+	if d.depth++; d.depth > maxDepth {
+		return errMsgpackDepth
+	}
+	defer func() { d.depth-- }()
+	code := d.data[d.pos]
+
+	if code == mpNil {
+		d.pos++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("msgpack: cannot decode into non-empty interface %s", v.Type())
+		}
+		val, err := d.decodeAny()
+		if err != nil {
+			return err
+		}
+		if val != nil {
+			v.Set(reflect.ValueOf(val))
+		}
+		return nil
+	}
+
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(unmarshalerType) {
+		start := d.pos
+		if err := d.skip(); err != nil {
+			return err
+		}
+		return v.Addr().Interface().(Unmarshaler).UnmarshalMsgpack(d.data[start:d.pos])
+	}
+
+	if v.Type() == timeType {
+		t, err := d.decodeTime()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		d.pos++
+		switch code {
+		case mpTrue:
+			v.SetBool(true)
+		case mpFalse:
+			v.SetBool(false)
+		default:
+			return d.typeError(code, v.Type())
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := d.decodeInt()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := d.decodeInt()
+		if err != nil {
+			return err
+		}
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			// 超过int64范围的uint64只能来自uint64格式
+			if code == mpUint64 && n < 0 {
+				v.SetUint(uint64(n))
+				return nil
+			}
+			return fmt.Errorf("msgpack: %d overflows %s", n, v.Type())
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := d.decodeFloat()
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		b, err := d.decodeRaw()
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.decodeRaw()
+			if err != nil {
+				return err
+			}
+			v.SetBytes(bytes.Clone(b))
+			return nil
+		}
+		n, err := d.decodeArrayLen()
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		n, err := d.decodeArrayLen()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.Zero(v.Type()))
+		for i := 0; i < n; i++ {
+			if i >= v.Len() {
+				if err := d.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		n, err := d.decodeMapLen()
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key); err != nil {
+				return err
+			}
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(val); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, val)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		return d.decodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (d *mpDecoder) decodeStruct(v reflect.Value) error {
+	n, err := d.decodeMapLen()
+	if err != nil {
+		return err
+	}
+	fields := cachedFields(v.Type())
+	for i := 0; i < n; i++ {
+		name, err := d.decodeRaw()
+		if err != nil {
+			return err
+		}
+		f := findField(fields, string(name))
+		if f == nil {
+			if err := d.skip(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(fieldByIndexAlloc(v, f.index)); err != nil {
+			return fmt.Errorf("msgpack: field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// decodeAny 解码为Go的基本类型，用于interface{}目标
+func (d *mpDecoder) decodeAny() (any, error) {
+	if d.pos >= len(d.data) {
+		return nil, errMsgpackShort
+	}
+	if d.depth++; d.depth > maxDepth {
+		return nil, errMsgpackDepth
+	}
+	defer func() { d.depth-- }()
+	code := d.data[d.pos]
+	switch {
+	case code == mpNil:
+		d.pos++
+		return nil, nil
+	case code == mpTrue || code == mpFalse:
+		d.pos++
+		return code == mpTrue, nil
+	case code <= 0x7f || code >= 0xe0 || (code >= mpInt8 && code <= mpInt64) || (code >= mpUint8 && code <= mpUint32):
+		return d.decodeInt()
+	case code == mpUint64:
+		n, err := d.decodeInt()
+		if err == nil && n < 0 {
+			return uint64(n), nil
+		}
+		return n, err
+	case code == mpFloat32 || code == mpFloat64:
+		return d.decodeFloat()
+	case code&0xe0 == 0xa0 || (code >= mpStr8 && code <= mpStr32):
+		b, err := d.decodeRaw()
+		return string(b), err
+	case code >= mpBin8 && code <= mpBin32:
+		b, err := d.decodeRaw()
+		return bytes.Clone(b), err
+	case code&0xf0 == 0x90 || code == mpArray16 || code == mpArray32:
+		n, err := d.decodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]any, n)
+		for i := range arr {
+			if arr[i], err = d.decodeAny(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case code&0xf0 == 0x80 || code == mpMap16 || code == mpMap32:
+		return d.decodeAnyMap()
+	case (code >= mpFixExt1 && code <= mpFixExt16) || (code >= mpExt8 && code <= mpExt32):
+		return d.decodeTime()
+	}
+	return nil, fmt.Errorf("msgpack: invalid code 0x%x", code)
+}
+
+// decodeAnyMap 解码map，键都是字符串时返回map[string]any，否则返回map[any]any
+func (d *mpDecoder) decodeAnyMap() (any, error) {
+	n, err := d.decodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]any, n)
+	vals := make([]any, n)
+	allStrings := true
+	for i := 0; i < n; i++ {
+		if keys[i], err = d.decodeAny(); err != nil {
+			return nil, err
+		}
+		if vals[i], err = d.decodeAny(); err != nil {
+			return nil, err
+		}
+		if _, ok := keys[i].(string); !ok {
+			allStrings = false
+		}
+	}
+
+	if allStrings {
+		m := make(map[string]any, n)
+		for i := range keys {
+			m[keys[i].(string)] = vals[i]
+		}
+		return m, nil
+	}
+	m := make(map[any]any, n)
+	for i := range keys {
+		if keys[i] != nil && !reflect.TypeOf(keys[i]).Comparable() {
+			return nil, errors.New("msgpack: map key is not comparable")
+		}
+		m[keys[i]] = vals[i]
+	}
+	return m, nil
+}
+
+// decodeInt 解码任意整数格式，uint64超过int64范围时按补码返回
+func (d *mpDecoder) decodeInt() (int64, error) {
+	code := d.data[d.pos]
+	d.pos++
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	}
+	switch code {
+	case mpUint8:
+		b, err := d.read(1)
+		return int64(b[0]), err
+	case mpUint16:
+		n, err := d.read16()
+		return int64(n), err
+	case mpUint32:
+		n, err := d.read32()
+		return int64(n), err
+	case mpUint64:
+		n, err := d.read64()
+		return int64(n), err
+	case mpInt8:
+		b, err := d.read(1)
+		if err != nil {
+			return 0, err
+		}
+		return int64(int8(b[0])), nil
+	case mpInt16:
+		n, err := d.read16()
+		return int64(int16(n)), err
+	case mpInt32:
+		n, err := d.read32()
+		return int64(int32(n)), err
+	case mpInt64:
+		n, err := d.read64()
+		return int64(n), err
+	}
+	return 0, d.typeError(code, reflect.TypeOf(int64(0)))
+}
+
+func (d *mpDecoder) decodeFloat() (float64, error) {
+	code := d.data[d.pos]
+	switch code {
+	case mpFloat32:
+		d.pos++
+		n, err := d.read32()
+		return float64(math.Float32frombits(n)), err
+	case mpFloat64:
+		d.pos++
+		n, err := d.read64()
+		return math.Float64frombits(n), err
+	}
+	n, err := d.decodeInt()
+	return float64(n), err
+}
+
+// decodeRaw 解码str或bin格式，返回的切片引用原始数据
+func (d *mpDecoder) decodeRaw() ([]byte, error) {
+	if d.pos >= len(d.data) {
+		return nil, errMsgpackShort
+	}
+	code := d.data[d.pos]
+	d.pos++
+	var n int
+	switch {
+	case code&0xe0 == 0xa0:
+		n = int(code & 0x1f)
+	case code == mpStr8 || code == mpBin8:
+		b, err := d.read(1)
+		if err != nil {
+			return nil, err
+		}
+		n = int(b[0])
+	case code == mpStr16 || code == mpBin16:
+		l, err := d.read16()
+		if err != nil {
+			return nil, err
+		}
+		n = int(l)
+	case code == mpStr32 || code == mpBin32:
+		l, err := d.read32()
+		if err != nil {
+			return nil, err
+		}
+		n = int(l)
+	default:
+		return nil, d.typeError(code, reflect.TypeOf(""))
+	}
+	return d.read(n)
+}
+
+func (d *mpDecoder) decodeArrayLen() (int, error) {
+	code := d.data[d.pos]
+	d.pos++
+	switch {
+	case code&0xf0 == 0x90:
+		return int(code & 0x0f), nil
+	case code == mpArray16:
+		n, err := d.read16()
+		return int(n), err
+	case code == mpArray32:
+		n, err := d.read32()
+		return int(n), err
+	}
+	return 0, d.typeError(code, reflect.TypeOf([]any(nil)))
+}
+
+func (d *mpDecoder) decodeMapLen() (int, error) {
+	code := d.data[d.pos]
+	d.pos++
+	switch {
+	case code&0xf0 == 0x80:
+		return int(code & 0x0f), nil
+	case code == mpMap16:
+		n, err := d.read16()
+		return int(n), err
+	case code == mpMap32:
+		n, err := d.read32()
+		return int(n), err
+	}
+	return 0, d.typeError(code, reflect.TypeOf(map[string]any(nil)))
+}
+
+// decodeExt 解码扩展类型，返回类型编号和数据
+func (d *mpDecoder) decodeExt() (int8, []byte, error) {
+	code := d.data[d.pos]
+	d.pos++
+	var n int
+	switch code {
+	case mpFixExt1, mpFixExt1 + 1, mpFixExt1 + 2, mpFixExt1 + 3, mpFixExt16:
+		n = 1 << (code - mpFixExt1)
+	case mpExt8:
+		b, err := d.read(1)
+		if err != nil {
+			return 0, nil, err
+		}
+		n = int(b[0])
+	case mpExt16:
+		l, err := d.read16()
+		if err != nil {
+			return 0, nil, err
+		}
+		n = int(l)
+	case mpExt32:
+		l, err := d.read32()
+		if err != nil {
+			return 0, nil, err
+		}
+		n = int(l)
+	default:
+		return 0, nil, d.typeError(code, timeType)
+	}
+	typ, err := d.read(1)
+	if err != nil {
+		return 0, nil, err
+	}
+	data, err := d.read(n)
+	return int8(typ[0]), data, err
+}
+
+func (d *mpDecoder) decodeTime() (time.Time, error) {
+	typ, data, err := d.decodeExt()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if typ != mpTimeExt {
+		return time.Time{}, fmt.Errorf("msgpack: unsupported extension type %d", typ)
+	}
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		n := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(n&(1<<34-1)), int64(n>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		return time.Unix(sec, int64(nsec)), nil
+	}
+	return time.Time{}, fmt.Errorf("msgpack: invalid timestamp length %d", len(data))
+}
+
+// skip 跳过一个完整的值
+func (d *mpDecoder) skip() error {
+	if d.pos >= len(d.data) {
+		return errMsgpackShort
+	}
+	if d.depth++; d.depth > maxDepth {
+		return errMsgpackDepth
+	}
+	defer func() { d.depth-- }()
+	code := d.data[d.pos]
+	switch {
+	case code&0xf0 == 0x90 || code == mpArray16 || code == mpArray32:
+		n, err := d.decodeArrayLen()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case code&0xf0 == 0x80 || code == mpMap16 || code == mpMap32:
+		n, err := d.decodeMapLen()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < 2*n; i++ {
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case (code >= mpFixExt1 && code <= mpFixExt16) || (code >= mpExt8 && code <= mpExt32):
+		_, _, err := d.decodeExt()
+		return err
+	}
+	_, err := d.decodeAny()
+	return err
+}
+
+func (d *mpDecoder) read(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *mpDecoder) read16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *mpDecoder) read32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (d *mpDecoder) read64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *mpDecoder) typeError(code byte, t reflect.Type) error {
+	return fmt.Errorf("msgpack: cannot decode code 0x%x into %s", code, t)
+}
+
+// mpField 结构体字段的编码信息
+type mpField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []mpField
+
+// cachedFields 返回结构体需要编码的字段，匿名结构体字段展开到外层
+func cachedFields(t reflect.Type) []mpField {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]mpField)
+	}
+	var fields []mpField
+	collectFields(t, nil, &fields)
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.([]mpField)
+}
+
+func collectFields(t reflect.Type, index []int, fields *[]mpField) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("msgpack")
+		if !ok {
+			tag = sf.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(append([]int(nil), index...), i)
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct && ft != timeType {
+			collectFields(ft, idx, fields)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		*fields = append(*fields, mpField{name: name, index: idx, omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty")})
+	}
+}
+
+func findField(fields []mpField, name string) *mpField {
+	for i := range fields {
+		if fields[i].name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fieldByIndex 按索引读取字段，路径上有nil指针时返回false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// fieldByIndexAlloc 按索引读取字段，路径上的nil指针会被分配
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+// msgpack包的测试文件
+// 测试编解码往返、整数精度、标签、自定义编解码和异常数据
+//
+// 作者: gophertool
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRoundTrip 测试解码到interface{}的往返结果
+func TestRoundTrip(t *testing.T) {
+	longString := strings.Repeat("长", 100)
+	items := make([]any, 20)
+	for i := range items {
+		items[i] = int64(i * 1000)
+	}
+
+	values := []any{
+		nil,
+		true,
+		false,
+		int64(0),
+		int64(127),
+		int64(-32),
+		int64(-129),
+		int64(70000),
+		int64(-3000000000),
+		int64(math.MaxInt64),
+		uint64(math.MaxUint64),
+		0.0,
+		3.1415,
+		"",
+		"短字符串",
+		longString,
+		[]byte{1, 2, 3},
+		items,
+		map[string]any{"a": int64(1), "b": []any{"x", nil, false}, "c": map[string]any{"d": "e"}},
+	}
+
+	for _, value := range values {
+		data, err := Marshal(value)
+		if err != nil {
+			t.Fatalf("编码 %v 失败: %v", value, err)
+		}
+
+		var decoded any
+		if err := Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("解码 %v 失败: %v", value, err)
+		}
+		if !reflect.DeepEqual(decoded, value) {
+			t.Errorf("往返结果不一致，期望: %#v, 实际: %#v", value, decoded)
+		}
+	}
+
+	// 截断的数据应该返回错误
+	data, _ := Marshal(map[string]any{"key": longString})
+	var decoded any
+	if err := Unmarshal(data[:len(data)-1], &decoded); err == nil {
+		t.Error("截断的数据应该返回错误")
+	}
+}
+
+// TestStructTags 测试msgpack标签优先，没有msgpack标签时使用json标签
+func TestStructTags(t *testing.T) {
+	type inner struct {
+		ID int64 `json:"id"`
+	}
+	type item struct {
+		inner
+		Name    string    `json:"name"`
+		Alias   string    `msgpack:"alias" json:"json_alias"`
+		Skipped string    `json:"-"`
+		Empty   string    `json:"empty,omitempty"`
+		Created time.Time `json:"created"`
+	}
+
+	want := item{inner: inner{ID: 1 << 60}, Name: "a", Alias: "b", Skipped: "c", Created: time.Unix(1700000000, 5)}
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+
+	var generic map[string]any
+	if err := Unmarshal(data, &generic); err != nil {
+		t.Fatalf("解码到map失败: %v", err)
+	}
+	for _, key := range []string{"id", "name", "alias", "created"} {
+		if _, ok := generic[key]; !ok {
+			t.Errorf("缺少字段%q: %v", key, generic)
+		}
+	}
+	for _, key := range []string{"json_alias", "Skipped", "empty"} {
+		if _, ok := generic[key]; ok {
+			t.Errorf("不应输出字段%q: %v", key, generic)
+		}
+	}
+
+	var got item
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatalf("解码到结构体失败: %v", err)
+	}
+	want.Skipped = ""
+	if !got.Created.Equal(want.Created) {
+		t.Errorf("时间应为%v，实际: %v", want.Created, got.Created)
+	}
+	got.Created = want.Created
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("往返结果不一致，期望: %+v, 实际: %+v", want, got)
+	}
+}
+
+// typed 自定义编解码的测试类型，编码时附加类型名
+type typed struct {
+	Value string
+}
+
+func (v typed) MarshalMsgpack() ([]byte, error) {
+	return Marshal(map[string]string{"type": "typed", "value": v.Value})
+}
+
+func (v *typed) UnmarshalMsgpack(data []byte) error {
+	var m map[string]string
+	if err := Unmarshal(data, &m); err != nil {
+		return err
+	}
+	v.Value = m["type"] + ":" + m["value"]
+	return nil
+}
+
+// TestMarshaler 测试Marshaler/Unmarshaler和RawMessage
+func TestMarshaler(t *testing.T) {
+	data, err := Marshal([]any{typed{Value: "a"}})
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+
+	var decoded []typed
+	if err := Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Value != "typed:a" {
+		t.Errorf("自定义编解码结果错误: %+v", decoded)
+	}
+
+	var raw []RawMessage
+	if err := Unmarshal(data, &raw); err != nil {
+		t.Fatalf("解码到RawMessage失败: %v", err)
+	}
+	reencoded, err := Marshal(raw)
+	if err != nil {
+		t.Fatalf("编码RawMessage失败: %v", err)
+	}
+	if !bytes.Equal(reencoded, data) {
+		t.Errorf("RawMessage应原样输出，期望: % x, 实际: % x", data, reencoded)
+	}
+}
+
+// TestMaxDepth 测试超过最大嵌套深度的数据返回错误
+func TestMaxDepth(t *testing.T) {
+	data := append(bytes.Repeat([]byte{0x91}, maxDepth+1), mpNil)
+	var decoded any
+	if err := Unmarshal(data, &decoded); err == nil {
+		t.Error("超过最大嵌套深度应该返回错误")
+	}
+}
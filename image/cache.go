@@ -0,0 +1,131 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"time"
+
+	"github.com/gophertool/tool/db/cache"
+	_interface "github.com/gophertool/tool/db/cache/interface"
+)
+
+// CacheOptions 图片缓存的选项
+type CacheOptions struct {
+	TTL    time.Duration // 缓存的过期时间，0表示不过期
+	Prefix string        // 键名前缀，为空时为"image:"
+}
+
+// CachedLoader 把从URL下载的图片数据缓存到db/cache中的加载器，其它来源直接交给被装饰的加载器
+type CachedLoader struct {
+	Loader
+	c      _interface.Cache
+	ttl    time.Duration
+	prefix string
+	loader *cache.Loader
+}
+
+// NewCachedLoader 创建带缓存的加载器
+// 参数：
+//
+//	loader - 被装饰的加载器，nil表示NewLoader()；DefaultLoader缓存下载的原始数据，其它加载器缓存解码后重新编码的PNG数据
+//	c - 缓存实例，可以是db/cache中的任意驱动
+//	opts - 缓存选项
+//
+// 返回值：
+//
+//	*CachedLoader - 加载器，同一个URL的并发加载只下载一次
+func NewCachedLoader(loader Loader, c _interface.Cache, opts CacheOptions) *CachedLoader {
+	if loader == nil {
+		loader = NewLoader()
+	}
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = "image:"
+	}
+	return &CachedLoader{
+		Loader: loader,
+		c:      c,
+		ttl:    opts.TTL,
+		prefix: prefix,
+		loader: cache.NewLoader(c, cache.LoaderOptions{}),
+	}
+}
+
+// LoadFromURL 从URL加载图片，缓存命中时不访问网络
+func (l *CachedLoader) LoadFromURL(url string) (image.Image, error) {
+	return l.LoadFromURLContext(context.Background(), url)
+}
+
+// LoadFromURLContext 从URL加载图片，缓存命中时不访问网络，ctx取消或超时后中止下载和解码
+func (l *CachedLoader) LoadFromURLContext(ctx context.Context, url string) (image.Image, error) {
+	key := l.Key(url)
+	v, err := l.loader.Load(key,
+		func() (any, error) {
+			// 缓存读取失败或数据损坏时视为未命中，重新下载
+			value, err := l.c.Get(key)
+			if err != nil {
+				return nil, _interface.ErrKeyNotFound
+			}
+			data, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, _interface.ErrKeyNotFound
+			}
+			return data, nil
+		},
+		func() (any, error) { return l.fetch(ctx, url) },
+		func(value any) error {
+			return l.c.Set(key, base64.StdEncoding.EncodeToString(value.([]byte)), l.ttl)
+		})
+	if err != nil {
+		return nil, err
+	}
+	return loadFromReaderContext(ctx, l.Loader, bytes.NewReader(v.([]byte)))
+}
+
+// Key 返回URL对应的缓存键名
+func (l *CachedLoader) Key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return l.prefix + hex.EncodeToString(sum[:])
+}
+
+// Forget 删除URL的缓存
+func (l *CachedLoader) Forget(url string) error {
+	if err := l.c.Delete(l.Key(url)); err != nil && !errors.Is(err, _interface.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
+// fetch 下载图片数据，确认是可以识别的图片后才会写入缓存
+func (l *CachedLoader) fetch(ctx context.Context, url string) ([]byte, error) {
+	fetcher, ok := l.Loader.(interface {
+		fetchURL(ctx context.Context, url string) ([]byte, error)
+	})
+	if !ok {
+		img, err := l.Loader.LoadFromURLContext(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("编码缓存图片失败: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	data, err := fetcher.fetchURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("解码图片失败: %w", err)
+	}
+	return data, nil
+}
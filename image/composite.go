@@ -0,0 +1,128 @@
+package image
+
+import (
+	"image"
+	"math"
+)
+
+// BlendMode 图层的混合模式，公式与CSS和Photoshop中的同名模式一致
+type BlendMode int
+
+const (
+	BlendNormal     BlendMode = iota // 正常，上层覆盖下层
+	BlendMultiply                    // 正片叠底，结果变暗
+	BlendScreen                      // 滤色，结果变亮
+	BlendOverlay                     // 叠加，下层暗处正片叠底、亮处滤色
+	BlendDarken                      // 变暗，取较暗的颜色
+	BlendLighten                     // 变亮，取较亮的颜色
+	BlendColorDodge                  // 颜色减淡
+	BlendColorBurn                   // 颜色加深
+	BlendHardLight                   // 强光，上层暗处正片叠底、亮处滤色
+	BlendSoftLight                   // 柔光
+	BlendDifference                  // 差值
+	BlendExclusion                   // 排除
+	BlendAdd                         // 线性减淡（相加）
+)
+
+// Composite 将上层图片按混合模式叠加到底图上
+// 参数：
+//
+//	base - 底图
+//	overlay - 上层图片
+//	offset - 上层图片左上角在底图中的位置，超出底图的部分被裁剪
+//	mode - 混合模式
+//	opacity - 上层图片的不透明度，0~1
+//
+// 返回值：
+//
+//	*image.NRGBA - 叠加后的新图片，尺寸与底图相同，底图不会被修改
+func Composite(base, overlay image.Image, offset image.Point, mode BlendMode, opacity float64) *image.NRGBA {
+	dst := toNRGBA(base)
+	opacity = math.Min(math.Max(opacity, 0), 1)
+	if opacity == 0 {
+		return dst
+	}
+	src := toNRGBA(overlay)
+	r := dst.Rect.Intersect(src.Rect.Add(offset))
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s := src.Pix[src.PixOffset(x-offset.X, y-offset.Y):][:4]
+			as := float64(s[3]) / 255 * opacity
+			if as == 0 {
+				continue
+			}
+			d := dst.Pix[dst.PixOffset(x, y):][:4]
+			ab := float64(d[3]) / 255
+
+			// W3C合成规范：先按底图的不透明度混合颜色，再按source-over合成
+			ao := as + ab*(1-as)
+			for i := 0; i < 3; i++ {
+				cs, cb := float64(s[i])/255, float64(d[i])/255
+				mixed := (1-ab)*cs + ab*blend(mode, cb, cs)
+				co := mixed*as + cb*ab*(1-as)
+				d[i] = clampUint8(co / ao * 255)
+			}
+			d[3] = clampUint8(ao * 255)
+		}
+	}
+	return dst
+}
+
+// blend 返回混合模式下底层颜色cb与上层颜色cs混合后的颜色，取值都为0~1
+func blend(mode BlendMode, cb, cs float64) float64 {
+	switch mode {
+	case BlendMultiply:
+		return cb * cs
+	case BlendScreen:
+		return cb + cs - cb*cs
+	case BlendOverlay:
+		return blend(BlendHardLight, cs, cb)
+	case BlendDarken:
+		return math.Min(cb, cs)
+	case BlendLighten:
+		return math.Max(cb, cs)
+	case BlendColorDodge:
+		switch {
+		case cb == 0:
+			return 0
+		case cs >= 1:
+			return 1
+		default:
+			return math.Min(1, cb/(1-cs))
+		}
+	case BlendColorBurn:
+		switch {
+		case cb >= 1:
+			return 1
+		case cs <= 0:
+			return 0
+		default:
+			return 1 - math.Min(1, (1-cb)/cs)
+		}
+	case BlendHardLight:
+		if cs <= 0.5 {
+			return cb * 2 * cs
+		}
+		return blend(BlendScreen, cb, 2*cs-1)
+	case BlendSoftLight:
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		var d float64
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		} else {
+			d = math.Sqrt(cb)
+		}
+		return cb + (2*cs-1)*(d-cb)
+	case BlendDifference:
+		return math.Abs(cb - cs)
+	case BlendExclusion:
+		return cb + cs - 2*cb*cs
+	case BlendAdd:
+		return math.Min(cb+cs, 1)
+	default:
+		return cs
+	}
+}
@@ -0,0 +1,122 @@
+package image
+
+import (
+	"image"
+	"image/color"
+)
+
+// defaultDiffThreshold 默认的颜色差异阈值
+const defaultDiffThreshold = 0.1
+
+// maxYIQDelta 黑色与白色之间的YIQ色差，即色差的最大值
+const maxYIQDelta = 35215.0
+
+// DiffOptions 图片比较选项
+type DiffOptions struct {
+	Threshold      float64     // 颜色差异阈值，0~1，超过时像素视为不同；0表示0.1，需要完全一致时使用负数
+	HighlightColor color.Color // 差异图中不同像素的颜色，nil表示红色
+	FadeAlpha      float64     // 差异图中相同像素的不透明度，0~1，0表示0.1
+}
+
+// DiffResult 图片比较结果
+type DiffResult struct {
+	DiffPixels   int             // 不同的像素数
+	TotalPixels  int             // 比较的像素总数
+	Ratio        float64         // 不同像素的比例，0~1
+	Bounds       image.Rectangle // 包含所有不同像素的最小矩形，没有差异时为空
+	SizeMismatch bool            // 两张图片尺寸不同
+}
+
+// Equal 返回两张图片是否没有差异
+func (r DiffResult) Equal() bool {
+	return r.DiffPixels == 0
+}
+
+// Diff 逐像素比较两张图片，用于视觉回归测试
+// 参数：
+//
+//	a - 基准图片
+//	b - 待比较的图片
+//	opts - 比较选项
+//
+// 返回值：
+//
+//	DiffResult - 比较结果；尺寸不同时按两者的最大尺寸比较，只有一张图片覆盖的像素视为不同
+//	image.Image - 差异图，相同的像素显示为淡化的基准图片灰度，不同的像素使用HighlightColor
+func Diff(a, b image.Image, opts DiffOptions) (DiffResult, image.Image) {
+	threshold := opts.Threshold
+	if threshold == 0 {
+		threshold = defaultDiffThreshold
+	}
+	maxDelta := maxYIQDelta * threshold * threshold
+	if threshold < 0 {
+		maxDelta = 0
+	}
+	highlight := color.NRGBA{255, 0, 0, 255}
+	if opts.HighlightColor != nil {
+		highlight = color.NRGBAModel.Convert(opts.HighlightColor).(color.NRGBA)
+	}
+	fade := opts.FadeAlpha
+	if fade <= 0 {
+		fade = 0.1
+	}
+
+	na, nb := toNRGBA(a), toNRGBA(b)
+	w, h := max(na.Rect.Dx(), nb.Rect.Dx()), max(na.Rect.Dy(), nb.Rect.Dy())
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	result := DiffResult{
+		TotalPixels:  w * h,
+		SizeMismatch: na.Rect.Size() != nb.Rect.Size(),
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			inA, inB := image.Pt(x, y).In(na.Rect), image.Pt(x, y).In(nb.Rect)
+			var pa, pb []uint8
+			if inA {
+				pa = na.Pix[na.PixOffset(x, y):][:4]
+			}
+			if inB {
+				pb = nb.Pix[nb.PixOffset(x, y):][:4]
+			}
+
+			d := out.Pix[out.PixOffset(x, y):][:4]
+			if inA && inB && yiqDelta(pa, pb) <= maxDelta {
+				// 相同的像素显示为与白色混合的灰度
+				gray := clampUint8(255 + (yiqLuma(pa)-255)*fade)
+				d[0], d[1], d[2], d[3] = gray, gray, gray, 255
+				continue
+			}
+
+			d[0], d[1], d[2], d[3] = highlight.R, highlight.G, highlight.B, highlight.A
+			result.DiffPixels++
+			result.Bounds = result.Bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	if result.TotalPixels > 0 {
+		result.Ratio = float64(result.DiffPixels) / float64(result.TotalPixels)
+	}
+	return result, out
+}
+
+// blendWhite 将像素与白色背景混合，使透明度的差异体现为颜色的差异
+func blendWhite(p []uint8) (r, g, b float64) {
+	a := float64(p[3]) / 255
+	return 255 + (float64(p[0])-255)*a, 255 + (float64(p[1])-255)*a, 255 + (float64(p[2])-255)*a
+}
+
+// yiqLuma 返回与白色混合后的亮度
+func yiqLuma(p []uint8) float64 {
+	r, g, b := blendWhite(p)
+	return r*0.29889531 + g*0.58662247 + b*0.11448223
+}
+
+// yiqDelta 返回两个像素在YIQ色彩空间中的加权色差，比RGB距离更接近人眼感知
+func yiqDelta(p, q []uint8) float64 {
+	r1, g1, b1 := blendWhite(p)
+	r2, g2, b2 := blendWhite(q)
+	y := (r1-r2)*0.29889531 + (g1-g2)*0.58662247 + (b1-b2)*0.11448223
+	i := (r1-r2)*0.59597799 - (g1-g2)*0.27417610 - (b1-b2)*0.32180189
+	q2 := (r1-r2)*0.21147017 - (g1-g2)*0.52261711 + (b1-b2)*0.31114694
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q2*q2
+}
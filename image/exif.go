@@ -0,0 +1,422 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoEXIF 图片中没有EXIF信息
+	ErrNoEXIF = errors.New("图片中没有EXIF信息")
+	// ErrInvalidEXIF EXIF数据格式错误
+	ErrInvalidEXIF = errors.New("无效的EXIF数据")
+)
+
+// EXIF 图片的EXIF元数据，图片中没有的字段为零值
+type EXIF struct {
+	Orientation int    // 方向，1~8，见ApplyOrientation
+	Make        string // 相机厂商
+	Model       string // 相机型号
+	LensModel   string // 镜头型号
+	Software    string // 处理软件
+
+	// 时间有OffsetTime*字段时使用其中的时区，否则按UTC解析，此时只有年月日时分秒有意义
+	DateTime          time.Time // 文件修改时间
+	DateTimeOriginal  time.Time // 拍摄时间
+	DateTimeDigitized time.Time // 数字化时间
+
+	ExposureTime float64 // 曝光时间，单位为秒
+	FNumber      float64 // 光圈值
+	ISO          int     // 感光度
+	FocalLength  float64 // 焦距，单位为毫米
+	Width        int     // 图像宽度（PixelXDimension）
+	Height       int     // 图像高度（PixelYDimension）
+
+	GPS *GPSInfo // GPS信息，没有时为nil
+}
+
+// GPSInfo EXIF中的GPS信息
+type GPSInfo struct {
+	Latitude  float64   // 纬度，南纬为负数
+	Longitude float64   // 经度，西经为负数
+	Altitude  float64   // 海拔，单位为米，海平面以下为负数
+	Time      time.Time // GPS时间（UTC），没有时为零值
+}
+
+// EXIF标签
+const (
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagOrientation       = 0x0112
+	tagSoftware          = 0x0131
+	tagDateTime          = 0x0132
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagExposureTime      = 0x829A
+	tagFNumber           = 0x829D
+	tagISO               = 0x8827
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTime        = 0x9010
+	tagOffsetOriginal    = 0x9011
+	tagOffsetDigitized   = 0x9012
+	tagFocalLength       = 0x920A
+	tagPixelXDimension   = 0xA002
+	tagPixelYDimension   = 0xA003
+	tagLensModel         = 0xA434
+	tagGPSLatitudeRef    = 0x01
+	tagGPSLatitude       = 0x02
+	tagGPSLongitudeRef   = 0x03
+	tagGPSLongitude      = 0x04
+	tagGPSAltitudeRef    = 0x05
+	tagGPSAltitude       = 0x06
+	tagGPSTimeStamp      = 0x07
+	tagGPSDateStamp      = 0x1D
+)
+
+// exifHeader JPEG的APP1段和HEIC、WebP中EXIF数据的前缀
+var exifHeader = []byte("Exif\x00\x00")
+
+// ReadEXIF 读取图片的EXIF元数据
+// 参数：
+//
+//	data - JPEG、PNG、TIFF、HEIC或WebP图片的数据，也可以是以"Exif\0\0"或TIFF头开始的EXIF数据
+//
+// 返回值：
+//
+//	EXIF - EXIF元数据
+//	error - 没有EXIF时返回ErrNoEXIF，格式错误时返回ErrInvalidEXIF
+func ReadEXIF(data []byte) (EXIF, error) {
+	tiff, err := findEXIF(data)
+	if err != nil {
+		return EXIF{}, err
+	}
+	return parseEXIF(tiff)
+}
+
+// ApplyOrientation 根据EXIF方向将图片转换为正常显示的方向
+// 参数：
+//
+//	img - 原图片
+//	orientation - EXIF方向，1~8，其他值视为1
+//
+// 返回值：
+//
+//	*image.NRGBA - 转换后的图片，方向为5~8时宽高互换
+func ApplyOrientation(img image.Image, orientation int) *image.NRGBA {
+	switch orientation {
+	case 2:
+		return FlipHorizontal(img)
+	case 3:
+		return Rotate180(img)
+	case 4:
+		return FlipVertical(img)
+	case 5:
+		return FlipHorizontal(Rotate90(img))
+	case 6:
+		return Rotate90(img)
+	case 7:
+		return FlipHorizontal(Rotate270(img))
+	case 8:
+		return Rotate270(img)
+	default:
+		return toNRGBA(img)
+	}
+}
+
+// findEXIF 在图片数据中找到以TIFF头开始的EXIF数据
+func findEXIF(data []byte) ([]byte, error) {
+	switch {
+	case isTIFFHeader(data):
+		return data, nil
+	case bytes.HasPrefix(data, exifHeader):
+		return data[len(exifHeader):], nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return findJPEGEXIF(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return findPNGEXIF(data)
+	}
+
+	// HEIC和WebP中的EXIF以"Exif\0\0"开头，直接搜索比解析容器简单
+	for off := 0; ; {
+		i := bytes.Index(data[off:], exifHeader)
+		if i < 0 {
+			return nil, ErrNoEXIF
+		}
+		off += i + len(exifHeader)
+		if isTIFFHeader(data[off:]) {
+			return data[off:], nil
+		}
+	}
+}
+
+// findJPEGEXIF 读取JPEG中APP1段的EXIF数据
+func findJPEGEXIF(data []byte) ([]byte, error) {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: JPEG段标记错误", ErrInvalidEXIF)
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// 段之间的填充字节
+			i++
+			continue
+		case marker == 0xD8 || marker >= 0xD0 && marker <= 0xD7:
+			i += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// 图像数据开始之后不会再有EXIF
+			return nil, ErrNoEXIF
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(data) {
+			return nil, fmt.Errorf("%w: JPEG段长度错误", ErrInvalidEXIF)
+		}
+		if seg := data[i+4 : end]; marker == 0xE1 && bytes.HasPrefix(seg, exifHeader) {
+			return seg[len(exifHeader):], nil
+		}
+		i = end
+	}
+	return nil, ErrNoEXIF
+}
+
+// findPNGEXIF 读取PNG中eXIf块的EXIF数据
+func findPNGEXIF(data []byte) ([]byte, error) {
+	for i := 8; i+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		end := i + 8 + size
+		if end+4 > len(data) {
+			return nil, fmt.Errorf("%w: PNG块长度错误", ErrInvalidEXIF)
+		}
+		switch typ {
+		case "eXIf":
+			return data[i+8 : end], nil
+		case "IDAT", "IEND":
+			// eXIf块必须位于图像数据之前
+			return nil, ErrNoEXIF
+		}
+		i = end + 4
+	}
+	return nil, ErrNoEXIF
+}
+
+func isTIFFHeader(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))
+}
+
+// tiffEntry IFD中的一个条目
+type tiffEntry struct {
+	typ   uint16
+	count int
+	raw   []byte
+}
+
+// tiffTypeSizes 各数据类型每个值的字节数
+var tiffTypeSizes = map[uint16]int{
+	1:  1, // BYTE
+	2:  1, // ASCII
+	3:  2, // SHORT
+	4:  4, // LONG
+	5:  8, // RATIONAL
+	7:  1, // UNDEFINED
+	9:  4, // SLONG
+	10: 8, // SRATIONAL
+}
+
+// tiffReader 读取TIFF格式的EXIF数据
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifd 读取指定偏移处的IFD，忽略类型未知或数据越界的条目
+func (t *tiffReader) ifd(off uint32) (map[uint16]tiffEntry, error) {
+	if uint64(off)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("%w: IFD偏移越界", ErrInvalidEXIF)
+	}
+	n := int(t.order.Uint16(t.data[off:]))
+	start := int(off) + 2
+	if start+12*n > len(t.data) {
+		return nil, fmt.Errorf("%w: IFD条目越界", ErrInvalidEXIF)
+	}
+
+	entries := make(map[uint16]tiffEntry, n)
+	for i := 0; i < n; i++ {
+		e := t.data[start+12*i:]
+		tag, typ := t.order.Uint16(e), t.order.Uint16(e[2:])
+		count := uint64(t.order.Uint32(e[4:]))
+		size, ok := tiffTypeSizes[typ]
+		if !ok {
+			continue
+		}
+		total := count * uint64(size)
+		raw := e[8:12]
+		if total > 4 {
+			valueOff := uint64(t.order.Uint32(e[8:]))
+			if valueOff+total > uint64(len(t.data)) {
+				continue
+			}
+			raw = t.data[valueOff : valueOff+total]
+		}
+		entries[tag] = tiffEntry{typ: typ, count: int(count), raw: raw[:total]}
+	}
+	return entries, nil
+}
+
+// str 返回ASCII条目的字符串，去掉结尾的NUL和空白
+func (t *tiffReader) str(e tiffEntry) string {
+	if e.typ != 2 && e.typ != 7 {
+		return ""
+	}
+	s := string(e.raw)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// uint 返回整数条目的第i个值
+func (t *tiffReader) uint(e tiffEntry, i int) (int, bool) {
+	if i >= e.count {
+		return 0, false
+	}
+	switch e.typ {
+	case 1, 7:
+		return int(e.raw[i]), true
+	case 3:
+		return int(t.order.Uint16(e.raw[2*i:])), true
+	case 4:
+		return int(t.order.Uint32(e.raw[4*i:])), true
+	}
+	return 0, false
+}
+
+// rational 返回分数条目的第i个值
+func (t *tiffReader) rational(e tiffEntry, i int) (float64, bool) {
+	if i >= e.count || e.typ != 5 && e.typ != 10 {
+		return 0, false
+	}
+	num, den := t.order.Uint32(e.raw[8*i:]), t.order.Uint32(e.raw[8*i+4:])
+	if den == 0 {
+		return 0, false
+	}
+	if e.typ == 10 {
+		return float64(int32(num)) / float64(int32(den)), true
+	}
+	return float64(num) / float64(den), true
+}
+
+// parseEXIF 解析以TIFF头开始的EXIF数据
+func parseEXIF(data []byte) (EXIF, error) {
+	if !isTIFFHeader(data) || len(data) < 8 {
+		return EXIF{}, fmt.Errorf("%w: TIFF头错误", ErrInvalidEXIF)
+	}
+	t := &tiffReader{data: data, order: binary.LittleEndian}
+	if data[0] == 'M' {
+		t.order = binary.BigEndian
+	}
+	ifd0, err := t.ifd(t.order.Uint32(data[4:]))
+	if err != nil {
+		return EXIF{}, err
+	}
+
+	var x EXIF
+	x.Make = t.str(ifd0[tagMake])
+	x.Model = t.str(ifd0[tagModel])
+	x.Software = t.str(ifd0[tagSoftware])
+	x.Orientation, _ = t.uint(ifd0[tagOrientation], 0)
+
+	// 子IFD损坏时保留已经读到的字段
+	exif := map[uint16]tiffEntry{}
+	if off, ok := t.uint(ifd0[tagExifIFD], 0); ok {
+		if exif, err = t.ifd(uint32(off)); err != nil {
+			return x, err
+		}
+	}
+	x.DateTime = parseEXIFTime(t.str(ifd0[tagDateTime]), t.str(exif[tagOffsetTime]))
+	x.DateTimeOriginal = parseEXIFTime(t.str(exif[tagDateTimeOriginal]), t.str(exif[tagOffsetOriginal]))
+	x.DateTimeDigitized = parseEXIFTime(t.str(exif[tagDateTimeDigitized]), t.str(exif[tagOffsetDigitized]))
+	x.ExposureTime, _ = t.rational(exif[tagExposureTime], 0)
+	x.FNumber, _ = t.rational(exif[tagFNumber], 0)
+	x.ISO, _ = t.uint(exif[tagISO], 0)
+	x.FocalLength, _ = t.rational(exif[tagFocalLength], 0)
+	x.Width, _ = t.uint(exif[tagPixelXDimension], 0)
+	x.Height, _ = t.uint(exif[tagPixelYDimension], 0)
+	x.LensModel = t.str(exif[tagLensModel])
+
+	if off, ok := t.uint(ifd0[tagGPSIFD], 0); ok {
+		gps, err := t.ifd(uint32(off))
+		if err != nil {
+			return x, err
+		}
+		x.GPS = parseGPS(t, gps)
+	}
+	return x, nil
+}
+
+// parseGPS 解析GPS IFD，没有经纬度时返回nil
+func parseGPS(t *tiffReader, gps map[uint16]tiffEntry) *GPSInfo {
+	// degrees 将度、分、秒三个分数转换为度
+	degrees := func(e tiffEntry) (float64, bool) {
+		var v float64
+		for i, unit := range []float64{1, 60, 3600} {
+			n, ok := t.rational(e, i)
+			if !ok {
+				return 0, false
+			}
+			v += n / unit
+		}
+		return v, true
+	}
+	lat, ok1 := degrees(gps[tagGPSLatitude])
+	lon, ok2 := degrees(gps[tagGPSLongitude])
+	if !ok1 || !ok2 {
+		return nil
+	}
+	if strings.EqualFold(t.str(gps[tagGPSLatitudeRef]), "S") {
+		lat = -lat
+	}
+	if strings.EqualFold(t.str(gps[tagGPSLongitudeRef]), "W") {
+		lon = -lon
+	}
+
+	info := &GPSInfo{Latitude: lat, Longitude: lon}
+	if alt, ok := t.rational(gps[tagGPSAltitude], 0); ok {
+		if ref, _ := t.uint(gps[tagGPSAltitudeRef], 0); ref == 1 {
+			alt = -alt
+		}
+		info.Altitude = alt
+	}
+	if date, err := time.Parse("2006:01:02", t.str(gps[tagGPSDateStamp])); err == nil {
+		if secs, ok := degrees(gps[tagGPSTimeStamp]); ok {
+			// 时分秒与度分秒的换算相同
+			info.Time = date.Add(time.Duration(math.Round(secs * float64(time.Hour))))
+		} else {
+			info.Time = date
+		}
+	}
+	return info
+}
+
+// parseEXIFTime 解析"2006:01:02 15:04:05"格式的时间，offset为"+08:00"格式的时区
+func parseEXIFTime(s, offset string) time.Time {
+	loc := time.UTC
+	if off, err := time.Parse("-07:00", offset); err == nil {
+		_, secs := off.Zone()
+		loc = time.FixedZone(offset, secs)
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", s, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
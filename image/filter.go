@@ -0,0 +1,185 @@
+package image
+
+import (
+	"image"
+	"math"
+)
+
+// Filter 图片滤镜，可以直接修改传入的图片并返回它，也可以返回新的图片
+type Filter func(img *image.NRGBA) *image.NRGBA
+
+// Apply 按顺序对图片应用一组滤镜
+// 参数：
+//
+//	img - 原图片，不会被修改
+//	filters - 滤镜，如Apply(img, GaussianBlur(2), Grayscale(), Brightness(10))
+//
+// 返回值：
+//
+//	*image.NRGBA - 应用所有滤镜后的新图片
+func Apply(img image.Image, filters ...Filter) *image.NRGBA {
+	dst := toNRGBA(img)
+	for _, f := range filters {
+		if f != nil {
+			dst = f(dst)
+		}
+	}
+	return dst
+}
+
+// GaussianBlur 高斯模糊，sigma为标准差（像素），不大于0时不做处理
+func GaussianBlur(sigma float64) Filter {
+	return func(img *image.NRGBA) *image.NRGBA {
+		if sigma <= 0 {
+			return img
+		}
+		return convolveSeparable(img, gaussianKernel(sigma))
+	}
+}
+
+// Sharpen 使用USM（非锐化掩模）锐化，amount为锐化强度，常用0.5~2，不大于0时不做处理
+func Sharpen(amount float64) Filter {
+	return func(img *image.NRGBA) *image.NRGBA {
+		if amount <= 0 {
+			return img
+		}
+		blurred := convolveSeparable(img, gaussianKernel(1))
+		for i := 0; i+3 < len(img.Pix); i += 4 {
+			for c := 0; c < 3; c++ {
+				v := float64(img.Pix[i+c])
+				img.Pix[i+c] = clampUint8(v + amount*(v-float64(blurred.Pix[i+c])))
+			}
+		}
+		return img
+	}
+}
+
+// Grayscale 转换为灰度，按ITU-R BT.601计算亮度，保留透明度
+func Grayscale() Filter {
+	return Saturation(-100)
+}
+
+// Saturation 调整饱和度，percent为-100~100，-100为灰度，正数使颜色更鲜艳
+func Saturation(percent float64) Filter {
+	return func(img *image.NRGBA) *image.NRGBA {
+		factor := 1 + math.Max(percent, -100)/100
+		for i := 0; i+3 < len(img.Pix); i += 4 {
+			p := img.Pix[i : i+3]
+			luma := 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+			for c := range p {
+				p[c] = clampUint8(luma + (float64(p[c])-luma)*factor)
+			}
+		}
+		return img
+	}
+}
+
+// Brightness 调整亮度，percent为-100~100，每个颜色通道增加255*percent/100
+func Brightness(percent float64) Filter {
+	shift := 255 * percent / 100
+	return mapChannels(func(v float64) float64 { return v + shift })
+}
+
+// Contrast 调整对比度，percent为-100~100，-100时所有像素变为中灰色
+func Contrast(percent float64) Filter {
+	factor := 1 + math.Max(percent, -100)/100
+	return mapChannels(func(v float64) float64 { return 128 + (v-128)*factor })
+}
+
+// Gamma 伽马校正，gamma大于1时变亮，小于1时变暗，不大于0时不做处理
+func Gamma(gamma float64) Filter {
+	if gamma <= 0 {
+		gamma = 1
+	}
+	return mapChannels(func(v float64) float64 { return 255 * math.Pow(v/255, 1/gamma) })
+}
+
+// Invert 反色，保留透明度
+func Invert() Filter {
+	return mapChannels(func(v float64) float64 { return 255 - v })
+}
+
+// mapChannels 按查找表逐个映射颜色通道，不修改透明度
+func mapChannels(fn func(v float64) float64) Filter {
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = clampUint8(fn(float64(i)))
+	}
+	return func(img *image.NRGBA) *image.NRGBA {
+		for i := 0; i+3 < len(img.Pix); i += 4 {
+			img.Pix[i] = lut[img.Pix[i]]
+			img.Pix[i+1] = lut[img.Pix[i+1]]
+			img.Pix[i+2] = lut[img.Pix[i+2]]
+		}
+		return img
+	}
+}
+
+// gaussianKernel 返回归一化的一维高斯核，半径为3倍标准差
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// convolveSeparable 依次在水平和垂直方向上卷积，使用预乘alpha避免透明边缘出现色晕，超出边界的像素取边缘像素
+func convolveSeparable(img *image.NRGBA, kernel []float64) *image.NRGBA {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	radius := len(kernel) / 2
+	src := make([]float64, 4*w*h)
+	for y := 0; y < h; y++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):]
+		for x := 0; x < w; x++ {
+			p, q := row[4*x:4*x+4], src[4*(y*w+x):]
+			a := float64(p[3]) / 255
+			q[0], q[1], q[2], q[3] = float64(p[0])*a, float64(p[1])*a, float64(p[2])*a, float64(p[3])
+		}
+	}
+
+	tmp := make([]float64, len(src))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var c [4]float64
+			for k, wt := range kernel {
+				sx := min(max(x+k-radius, 0), w-1)
+				p := src[4*(y*w+sx):]
+				c[0] += p[0] * wt
+				c[1] += p[1] * wt
+				c[2] += p[2] * wt
+				c[3] += p[3] * wt
+			}
+			copy(tmp[4*(y*w+x):], c[:])
+		}
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var c [4]float64
+			for k, wt := range kernel {
+				sy := min(max(y+k-radius, 0), h-1)
+				p := tmp[4*(sy*w+x):]
+				c[0] += p[0] * wt
+				c[1] += p[1] * wt
+				c[2] += p[2] * wt
+				c[3] += p[3] * wt
+			}
+			if c[3] <= 0 {
+				continue
+			}
+			d := dst.Pix[dst.PixOffset(x, y):]
+			a := c[3] / 255
+			d[0], d[1], d[2], d[3] = clampUint8(c[0]/a), clampUint8(c[1]/a), clampUint8(c[2]/a), clampUint8(c[3])
+		}
+	}
+	return dst
+}
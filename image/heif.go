@@ -0,0 +1,57 @@
+package image
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// ErrHEIFUnsupported 当前构建不支持解码HEIC/AVIF图片
+var ErrHEIFUnsupported = errors.New("解码HEIC/AVIF图片需要安装libheif并使用heif构建标签编译")
+
+// HEIF容器格式的解码函数，使用heif构建标签编译时由heif_cgo.go设置
+var (
+	heifDecode       func(data []byte) (image.Image, error)
+	heifDecodeConfig func(data []byte) (image.Config, error)
+)
+
+// HEIF容器以ftyp盒开头，主品牌决定具体格式：
+// HEIC为HEVC编码（iPhone默认的照片格式），AVIF为AV1编码，mif1/msf1为未指定编码的通用HEIF
+var heifBrands = map[string][]string{
+	"heic": {"heic", "heix", "hevc", "hevx", "heim", "heis", "hevm", "hevs"},
+	"avif": {"avif", "avis"},
+	"heif": {"mif1", "msf1"},
+}
+
+func init() {
+	// 未使用heif构建标签编译时同样注册这些格式，使image.Decode返回ErrHEIFUnsupported而不是image.ErrFormat
+	for name, brands := range heifBrands {
+		for _, brand := range brands {
+			image.RegisterFormat(name, "????ftyp"+brand, decodeHEIF, decodeHEIFConfig)
+		}
+	}
+}
+
+// decodeHEIF 解码HEIC/AVIF图片
+func decodeHEIF(r io.Reader) (image.Image, error) {
+	if heifDecode == nil {
+		return nil, ErrHEIFUnsupported
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return heifDecode(data)
+}
+
+// decodeHEIFConfig 读取HEIC/AVIF图片的尺寸
+func decodeHEIFConfig(r io.Reader) (image.Config, error) {
+	if heifDecodeConfig == nil {
+		return image.Config{}, ErrHEIFUnsupported
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return heifDecodeConfig(data)
+}
@@ -0,0 +1,98 @@
+//go:build heif && cgo
+
+package image
+
+/*
+#cgo pkg-config: libheif
+#include <stdlib.h>
+#include <libheif/heif.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"unsafe"
+)
+
+// 使用libheif解码HEIC/AVIF，AVIF需要libheif编译时启用libaom或dav1d
+func init() {
+	heifDecode = libheifDecode
+	heifDecodeConfig = libheifDecodeConfig
+}
+
+// heifError 将libheif的错误转换为Go错误
+func heifError(err C.struct_heif_error) error {
+	if err.code == C.heif_error_Ok {
+		return nil
+	}
+	return fmt.Errorf("libheif: %s", C.GoString(err.message))
+}
+
+// openHEIF 读取数据并返回主图像的句柄，调用方负责释放句柄和上下文
+func openHEIF(data []byte) (*C.struct_heif_context, *C.struct_heif_image_handle, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("libheif: 数据为空")
+	}
+	ctx := C.heif_context_alloc()
+	// 使用会复制数据的读取函数，libheif在调用返回后不能持有Go内存
+	if err := heifError(C.heif_context_read_from_memory(ctx, unsafe.Pointer(&data[0]), C.size_t(len(data)), nil)); err != nil {
+		C.heif_context_free(ctx)
+		return nil, nil, err
+	}
+	var handle *C.struct_heif_image_handle
+	if err := heifError(C.heif_context_get_primary_image_handle(ctx, &handle)); err != nil {
+		C.heif_context_free(ctx)
+		return nil, nil, err
+	}
+	return ctx, handle, nil
+}
+
+// libheifDecode 解码主图像，libheif会应用容器中的旋转和镜像属性
+func libheifDecode(data []byte) (image.Image, error) {
+	ctx, handle, err := openHEIF(data)
+	if err != nil {
+		return nil, err
+	}
+	defer C.heif_context_free(ctx)
+	defer C.heif_image_handle_release(handle)
+
+	var img *C.struct_heif_image
+	if err := heifError(C.heif_decode_image(handle, &img, C.heif_colorspace_RGB, C.heif_chroma_interleaved_RGBA, nil)); err != nil {
+		return nil, err
+	}
+	defer C.heif_image_release(img)
+
+	w := int(C.heif_image_get_width(img, C.heif_channel_interleaved))
+	h := int(C.heif_image_get_height(img, C.heif_channel_interleaved))
+	var cstride C.int
+	plane := C.heif_image_get_plane_readonly(img, C.heif_channel_interleaved, &cstride)
+	if plane == nil || w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("libheif: 解码结果为空")
+	}
+	stride := int(cstride)
+	src := unsafe.Slice((*byte)(unsafe.Pointer(plane)), stride*(h-1)+4*w)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		copy(dst.Pix[y*dst.Stride:][:4*w], src[y*stride:][:4*w])
+	}
+	return dst, nil
+}
+
+// libheifDecodeConfig 读取主图像的尺寸，不解码像素
+func libheifDecodeConfig(data []byte) (image.Config, error) {
+	ctx, handle, err := openHEIF(data)
+	if err != nil {
+		return image.Config{}, err
+	}
+	defer C.heif_context_free(ctx)
+	defer C.heif_image_handle_release(handle)
+
+	return image.Config{
+		ColorModel: color.NRGBAModel,
+		Width:      int(C.heif_image_handle_get_width(handle)),
+		Height:     int(C.heif_image_handle_get_height(handle)),
+	}, nil
+}
@@ -0,0 +1,99 @@
+package image
+
+import (
+	"image"
+	"math"
+)
+
+// HistogramResult 图片各通道的直方图，下标为0~255的取值，值为像素数
+type HistogramResult struct {
+	Red       [256]int // 红色通道
+	Green     [256]int // 绿色通道
+	Blue      [256]int // 蓝色通道
+	Alpha     [256]int // 透明度
+	Luminance [256]int // 亮度，按ITU-R BT.601计算，与color.GrayModel相同
+	Total     int      // 像素总数
+}
+
+// StatsResult 图片的亮度统计
+type StatsResult struct {
+	Mean     float64 // 平均亮度，0~255
+	Contrast float64 // 对比度，即亮度的标准差（RMS对比度），0~127.5
+	Median   int     // 亮度的中位数
+	Min      int     // 最低亮度
+	Max      int     // 最高亮度
+}
+
+// Histogram 统计图片各通道的直方图
+// 参数：
+//
+//	img - 图片，颜色通道使用未预乘alpha的值
+//
+// 返回值：
+//
+//	HistogramResult - 各通道的直方图
+func Histogram(img image.Image) HistogramResult {
+	var h HistogramResult
+	src := toNRGBA(img)
+	for i := 0; i+3 < len(src.Pix); i += 4 {
+		r, g, b, a := src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3]
+		h.Red[r]++
+		h.Green[g]++
+		h.Blue[b]++
+		h.Alpha[a]++
+		h.Luminance[(19595*uint32(r)+38470*uint32(g)+7471*uint32(b)+1<<15)>>16]++
+	}
+	h.Total = src.Rect.Dx() * src.Rect.Dy()
+	return h
+}
+
+// Stats 统计图片的亮度，相当于Histogram(img).Stats()
+func Stats(img image.Image) StatsResult {
+	return Histogram(img).Stats()
+}
+
+// Stats 根据亮度直方图计算统计值，图片为空时返回零值
+func (h HistogramResult) Stats() StatsResult {
+	if h.Total == 0 {
+		return StatsResult{}
+	}
+	var s StatsResult
+	var sum, sumSq float64
+	s.Min, s.Max = -1, 0
+	count := 0
+	for v, n := range h.Luminance {
+		if n == 0 {
+			continue
+		}
+		if s.Min < 0 {
+			s.Min = v
+		}
+		s.Max = v
+		// 中位数为累计数量首次超过一半的取值
+		if count*2 < h.Total && (count+n)*2 >= h.Total {
+			s.Median = v
+		}
+		count += n
+		sum += float64(v) * float64(n)
+		sumSq += float64(v) * float64(v) * float64(n)
+	}
+	s.Mean = sum / float64(h.Total)
+	s.Contrast = math.Sqrt(math.Max(sumSq/float64(h.Total)-s.Mean*s.Mean, 0))
+	return s
+}
+
+// Percentile 返回亮度的百分位数，p为0~100，用于自动色阶等按比例裁剪的场景
+func (h HistogramResult) Percentile(p float64) int {
+	if h.Total == 0 {
+		return 0
+	}
+	target := math.Ceil(math.Min(math.Max(p, 0), 100) / 100 * float64(h.Total))
+	count := 0
+	for v, n := range h.Luminance {
+		count += n
+		if n > 0 && float64(count) >= target {
+			return v
+		}
+	}
+	return 255
+}
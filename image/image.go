@@ -3,6 +3,7 @@ package image
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -11,8 +12,10 @@ import (
 	"image/png"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 )
 
 // Loader 是图片加载器接口，提供从不同来源加载图片的方法
@@ -20,9 +23,15 @@ type Loader interface {
 	// LoadFromFile 从文件加载图片
 	LoadFromFile(filePath string) (image.Image, error)
 
+	// LoadFromFileContext 从文件加载图片，ctx取消后中止读取和解码
+	LoadFromFileContext(ctx context.Context, filePath string) (image.Image, error)
+
 	// LoadFromURL 从URL加载图片
 	LoadFromURL(url string) (image.Image, error)
 
+	// LoadFromURLContext 从URL加载图片，ctx取消或超时后中止下载和解码
+	LoadFromURLContext(ctx context.Context, url string) (image.Image, error)
+
 	// LoadFromBase64 从Base64字符串加载图片
 	LoadFromBase64(base64Str string) (image.Image, error)
 
@@ -31,40 +40,221 @@ type Loader interface {
 
 	// LoadFromReader 从io.Reader加载图片
 	LoadFromReader(reader io.Reader) (image.Image, error)
+
+	// LoadSVG 按加载器的大小限制光栅化SVG
+	LoadSVG(r io.Reader, width, height int) (image.Image, error)
+}
+
+// DefaultLoader 是默认的图片加载器实现，零值使用http.DefaultClient且不重试
+type DefaultLoader struct {
+	opts   LoaderOptions
+	client *http.Client
 }
 
-// DefaultLoader 是默认的图片加载器实现
-type DefaultLoader struct{}
+// LoaderOptions 加载图片的选项，包括从URL加载时的HTTP选项和防止解压炸弹的大小限制
+type LoaderOptions struct {
+	Client       *http.Client      // 自定义HTTP客户端，设置后Timeout、Proxy和MaxRedirects不生效
+	Timeout      time.Duration     // 单次请求的超时时间，包括读取响应，0表示30秒，负数表示不限制
+	Proxy        string            // 代理地址，如http://127.0.0.1:8080或socks5://127.0.0.1:1080，为空时使用环境变量HTTP_PROXY等
+	MaxRedirects int               // 最大重定向次数，0表示10次，负数表示不跟随重定向
+	Headers      map[string]string // 附加的请求头
+	UserAgent    string            // User-Agent请求头
+	Username     string            // Basic认证的用户名，为空时不使用Basic认证
+	Password     string            // Basic认证的密码
+	BearerToken  string            // Bearer认证的令牌，与Basic认证同时设置时使用Bearer认证
+	Retries      int               // 网络错误、429和5xx响应的重试次数，0表示不重试
+	RetryBackoff time.Duration     // 第一次重试前的等待时间，之后每次翻倍，0表示500毫秒
 
-// NewLoader 创建一个新的默认图片加载器
+	MaxBytes  int64 // 读取或下载的最大字节数，0表示不限制
+	MaxWidth  int   // 最大宽度，0表示不限制
+	MaxHeight int   // 最大高度，0表示不限制
+	MaxPixels int64 // 最大像素数（宽×高），0表示不限制；解码前先读取图片头检查尺寸，不会为超限的图片分配内存
+}
+
+// NewLoader 创建一个新的默认图片加载器，从URL加载时超时时间为30秒
 func NewLoader() Loader {
-	return &DefaultLoader{}
+	return newLoader(LoaderOptions{}, nil)
+}
+
+// NewLoaderWithOptions 创建使用指定HTTP选项的图片加载器
+// 参数：
+//
+//	opts - 从URL加载图片时的HTTP选项
+//
+// 返回值：
+//
+//	Loader - 图片加载器
+//	error - 代理地址无效时返回错误
+func NewLoaderWithOptions(opts LoaderOptions) (Loader, error) {
+	var proxy *url.URL
+	if opts.Proxy != "" && opts.Client == nil {
+		var err error
+		if proxy, err = url.Parse(opts.Proxy); err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("代理地址无效: %s", opts.Proxy)
+		}
+	}
+	return newLoader(opts, proxy), nil
+}
+
+// newLoader 按选项创建加载器，proxy为nil时使用环境变量中的代理
+func newLoader(opts LoaderOptions, proxy *url.URL) *DefaultLoader {
+	client := opts.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if proxy != nil {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+		client = &http.Client{Transport: transport}
+		switch {
+		case opts.Timeout == 0:
+			client.Timeout = 30 * time.Second
+		case opts.Timeout > 0:
+			client.Timeout = opts.Timeout
+		}
+		switch maxRedirects := opts.MaxRedirects; {
+		case maxRedirects < 0:
+			client.CheckRedirect = func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+		case maxRedirects > 0:
+			client.CheckRedirect = func(_ *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("重定向次数超过%d次", maxRedirects)
+				}
+				return nil
+			}
+		}
+	}
+	return &DefaultLoader{opts: opts, client: client}
 }
 
 // LoadFromFile 从文件加载图片
 func (l *DefaultLoader) LoadFromFile(filePath string) (image.Image, error) {
+	return l.LoadFromFileContext(context.Background(), filePath)
+}
+
+// LoadFromFileContext 从文件加载图片，ctx取消后中止读取和解码
+func (l *DefaultLoader) LoadFromFileContext(ctx context.Context, filePath string) (image.Image, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("打开图片文件失败: %w", err)
 	}
 	defer file.Close()
 
-	return l.LoadFromReader(file)
+	return loadFromReaderContext(ctx, l, file)
 }
 
 // LoadFromURL 从URL加载图片
 func (l *DefaultLoader) LoadFromURL(url string) (image.Image, error) {
-	resp, err := http.Get(url)
+	return l.LoadFromURLContext(context.Background(), url)
+}
+
+// LoadFromURLContext 从URL加载图片，ctx取消或超时后中止下载和解码
+func (l *DefaultLoader) LoadFromURLContext(ctx context.Context, url string) (image.Image, error) {
+	resp, err := l.openURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return loadFromReaderContext(ctx, l, resp.Body)
+}
+
+// fetchURL 下载URL的原始数据，不解码
+func (l *DefaultLoader) fetchURL(ctx context.Context, url string) ([]byte, error) {
+	resp, err := l.openURL(ctx, url)
 	if err != nil {
-		return nil, fmt.Errorf("获取URL图片失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("获取URL图片失败，状态码: %d", resp.StatusCode)
+	limited := &limitReader{r: resp.Body, remaining: l.opts.MaxBytes, max: l.opts.MaxBytes}
+	var reader io.Reader = resp.Body
+	if l.opts.MaxBytes > 0 {
+		reader = limited
+	}
+	data, err := io.ReadAll(contextReader{ctx: ctx, r: reader})
+	if err != nil {
+		if limited.exceeded {
+			return nil, fmt.Errorf("%w: 数据超过%d字节", ErrImageTooLarge, l.opts.MaxBytes)
+		}
+		return nil, fmt.Errorf("读取URL图片失败: %w", err)
+	}
+	return data, nil
+}
+
+// openURL 发送请求并检查响应声明的长度是否超过MaxBytes
+func (l *DefaultLoader) openURL(ctx context.Context, url string) (*http.Response, error) {
+	resp, err := l.get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if l.opts.MaxBytes > 0 && resp.ContentLength > l.opts.MaxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: 数据%d字节超过限制%d字节", ErrImageTooLarge, resp.ContentLength, l.opts.MaxBytes)
+	}
+	return resp, nil
+}
+
+// get 按选项发送GET请求，网络错误、429和5xx响应按退避时间重试
+func (l *DefaultLoader) get(ctx context.Context, url string) (*http.Response, error) {
+	client := l.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	backoff := l.opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建URL图片请求失败: %w", err)
+		}
+		l.setHeaders(req)
+
+		var retryable bool
+		resp, err := client.Do(req)
+		switch {
+		case err != nil:
+			err = fmt.Errorf("获取URL图片失败: %w", err)
+			retryable = ctx.Err() == nil
+		case resp.StatusCode != http.StatusOK:
+			resp.Body.Close()
+			err = fmt.Errorf("获取URL图片失败，状态码: %d", resp.StatusCode)
+			retryable = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		default:
+			return resp, nil
+		}
+		if !retryable || attempt >= l.opts.Retries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(backoff << attempt)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("获取URL图片失败: %w", ctx.Err())
+		}
 	}
+}
 
-	return l.LoadFromReader(resp.Body)
+// setHeaders 设置附加请求头、User-Agent和认证信息
+func (l *DefaultLoader) setHeaders(req *http.Request) {
+	for k, v := range l.opts.Headers {
+		req.Header.Set(k, v)
+	}
+	if l.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", l.opts.UserAgent)
+	}
+	switch {
+	case l.opts.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+l.opts.BearerToken)
+	case l.opts.Username != "":
+		req.SetBasicAuth(l.opts.Username, l.opts.Password)
+	}
 }
 
 // LoadFromBase64 从Base64字符串加载图片
@@ -96,9 +286,27 @@ func (l *DefaultLoader) LoadFromBytes(data []byte) (image.Image, error) {
 
 // LoadFromReader 从io.Reader加载图片
 func (l *DefaultLoader) LoadFromReader(reader io.Reader) (image.Image, error) {
+	var limited *limitReader
+	if l.opts.MaxBytes > 0 {
+		limited = &limitReader{r: reader, remaining: l.opts.MaxBytes, max: l.opts.MaxBytes}
+		reader = limited
+	}
+	if l.opts.MaxWidth > 0 || l.opts.MaxHeight > 0 || l.opts.MaxPixels > 0 {
+		// 先读取图片头检查尺寸，已经读取的数据保存下来供完整解码使用
+		var header bytes.Buffer
+		config, _, err := image.DecodeConfig(io.TeeReader(reader, &header))
+		if err != nil {
+			return nil, l.decodeError(err, limited)
+		}
+		if err := l.checkSize(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+		reader = io.MultiReader(&header, reader)
+	}
+
 	img, format, err := image.Decode(reader)
 	if err != nil {
-		return nil, fmt.Errorf("解码图片失败: %w", err)
+		return nil, l.decodeError(err, limited)
 	}
 
 	// 可以根据format做一些额外处理，这里只是简单返回解码后的图片
@@ -106,9 +314,80 @@ func (l *DefaultLoader) LoadFromReader(reader io.Reader) (image.Image, error) {
 	return img, nil
 }
 
+// checkSize 检查图片尺寸是否超过限制
+func (l *DefaultLoader) checkSize(width, height int) error {
+	if l.opts.MaxWidth > 0 && width > l.opts.MaxWidth || l.opts.MaxHeight > 0 && height > l.opts.MaxHeight {
+		return fmt.Errorf("%w: 尺寸%dx%d超过限制%dx%d", ErrImageTooLarge, width, height, l.opts.MaxWidth, l.opts.MaxHeight)
+	}
+	if pixels := int64(width) * int64(height); l.opts.MaxPixels > 0 && pixels > l.opts.MaxPixels {
+		return fmt.Errorf("%w: 像素数%d超过限制%d", ErrImageTooLarge, pixels, l.opts.MaxPixels)
+	}
+	return nil
+}
+
+// decodeError 包装解码错误，数据超过字节数限制时返回ErrImageTooLarge
+func (l *DefaultLoader) decodeError(err error, limited *limitReader) error {
+	// 解码器可能把读取错误变为其它错误，这里按是否超限判断
+	if limited != nil && limited.exceeded {
+		return fmt.Errorf("%w: 数据超过%d字节", ErrImageTooLarge, limited.max)
+	}
+	return fmt.Errorf("解码图片失败: %w", err)
+}
+
+// limitReader 读取的数据超过max字节时返回ErrImageTooLarge
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+	exceeded  bool
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// 已经读满限制，此时还能读到数据说明超过了限制
+		var b [1]byte
+		n, err := r.r.Read(b[:])
+		if n > 0 {
+			r.exceeded = true
+			return 0, ErrImageTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// loadFromReaderContext 使用加载器从reader加载图片，ctx取消后中止读取，返回的错误包含ctx.Err()
+func loadFromReaderContext(ctx context.Context, loader Loader, reader io.Reader) (image.Image, error) {
+	img, err := loader.LoadFromReader(contextReader{ctx: ctx, r: reader})
+	// image.Decode识别格式时会把读取错误变为未知格式，这里改为返回取消的原因
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("解码图片失败: %w", ctx.Err())
+	}
+	return img, err
+}
+
+// contextReader 在每次读取前检查ctx，使解码大图片的过程可以被取消
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
 // 支持的图片格式
 var (
 	ErrUnsupportedFormat = errors.New("不支持的图片格式")
+	ErrImageTooLarge     = errors.New("图片超过大小限制")
 )
 
 // SaveImage 保存图片到文件
@@ -2,13 +2,36 @@ package image_test
 
 import (
 	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"image"
 	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gophertool/tool/db/cache/config"
+	"github.com/gophertool/tool/db/cache/memory"
 	imageutil "github.com/gophertool/tool/image"
+	"github.com/gophertool/tool/plugin"
+	"golang.org/x/image/font/gofont/goregular"
 )
 
 const (
@@ -264,3 +287,1400 @@ func TestEmptyReader(t *testing.T) {
 		t.Fatal("期望从空Reader加载图片时返回错误，但没有")
 	}
 }
+
+// newTestImage 创建一个3x2的测试图片，每个像素的颜色各不相同
+func newTestImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 100), uint8(y * 100), 0, 255})
+		}
+	}
+	return img
+}
+
+// 测试旋转和翻转
+func TestRotateAndFlip(t *testing.T) {
+	src := newTestImage()
+	at := func(x, y int) color.NRGBA { return src.NRGBAAt(x, y) }
+
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+		w, h int
+		want func(x, y int) color.NRGBA
+	}{
+		{"Rotate90", imageutil.Rotate90(src), 2, 3, func(x, y int) color.NRGBA { return at(y, 1-x) }},
+		{"Rotate180", imageutil.Rotate180(src), 3, 2, func(x, y int) color.NRGBA { return at(2-x, 1-y) }},
+		{"Rotate270", imageutil.Rotate270(src), 2, 3, func(x, y int) color.NRGBA { return at(2-y, x) }},
+		{"FlipHorizontal", imageutil.FlipHorizontal(src), 3, 2, func(x, y int) color.NRGBA { return at(2-x, y) }},
+		{"FlipVertical", imageutil.FlipVertical(src), 3, 2, func(x, y int) color.NRGBA { return at(x, 1-y) }},
+		{"Rotate-90", imageutil.Rotate(src, -90, nil), 2, 3, func(x, y int) color.NRGBA { return at(2-y, x) }},
+		{"Rotate450", imageutil.Rotate(src, 450, nil), 2, 3, func(x, y int) color.NRGBA { return at(y, 1-x) }},
+	}
+	for _, tt := range tests {
+		if b := tt.img.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Fatalf("%s: 图片尺寸不正确，期望%dx%d，实际%v", tt.name, tt.w, tt.h, b)
+		}
+		for y := 0; y < tt.h; y++ {
+			for x := 0; x < tt.w; x++ {
+				if got, want := tt.img.NRGBAAt(x, y), tt.want(x, y); got != want {
+					t.Fatalf("%s: 像素(%d,%d)不正确，期望%v，实际%v", tt.name, x, y, want, got)
+				}
+			}
+		}
+	}
+
+	// 旋转不应修改原图
+	if src.NRGBAAt(0, 0) != (color.NRGBA{0, 0, 0, 255}) || src.NRGBAAt(2, 1) != (color.NRGBA{200, 100, 0, 255}) {
+		t.Fatal("旋转修改了原图")
+	}
+}
+
+// 测试任意角度旋转
+func TestRotateArbitrary(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 100, 50))
+	for i := 0; i < len(src.Pix); i += 4 {
+		src.Pix[i], src.Pix[i+3] = 255, 255
+	}
+
+	white := color.NRGBA{255, 255, 255, 255}
+	dst := imageutil.Rotate(src, 45, white)
+
+	// 画布应能容纳旋转后的原图：(100+50)*cos45 ≈ 106.07
+	if b := dst.Bounds(); b.Dx() != 107 || b.Dy() != 107 {
+		t.Fatalf("图片尺寸不正确，期望107x107，实际%v", b)
+	}
+	if got := dst.NRGBAAt(0, 0); got != white {
+		t.Fatalf("角落应为背景色，实际%v", got)
+	}
+	if got := dst.NRGBAAt(53, 53); got != (color.NRGBA{255, 0, 0, 255}) {
+		t.Fatalf("中心应为原图颜色，实际%v", got)
+	}
+
+	// 未指定背景色时空出区域透明
+	if got := imageutil.Rotate(src, 30, nil).NRGBAAt(0, 0); got.A != 0 {
+		t.Fatalf("未指定背景色时角落应透明，实际%v", got)
+	}
+}
+
+// 测试缩放图片
+func TestResize(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(10, 10, 110, 60))
+	for i := 0; i < len(src.Pix); i += 4 {
+		src.Pix[i], src.Pix[i+2], src.Pix[i+3] = 200, 50, 255
+	}
+
+	dst, err := imageutil.Resize(src, 40, 20)
+	if err != nil {
+		t.Fatalf("缩放图片失败: %v", err)
+	}
+	if b := dst.Bounds(); b != image.Rect(0, 0, 40, 20) {
+		t.Fatalf("图片尺寸不正确: %v", b)
+	}
+	// 纯色图片缩放后颜色不变
+	for _, p := range []image.Point{{0, 0}, {20, 10}, {39, 19}} {
+		if got := dst.NRGBAAt(p.X, p.Y); got != (color.NRGBA{200, 0, 50, 255}) {
+			t.Fatalf("像素%v颜色不正确: %v", p, got)
+		}
+	}
+
+	if _, err := imageutil.Resize(src, 0, 10); err != imageutil.ErrInvalidSize {
+		t.Fatalf("期望尺寸无效错误，实际得到: %v", err)
+	}
+}
+
+// 测试生成缩略图
+func TestThumbnail(t *testing.T) {
+	// 黑白相间的棋盘格，缩小后应接近灰色
+	src := image.NewGray(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+
+	thumb, data, err := imageutil.Thumbnail(src, 100, 100, "png")
+	if err != nil {
+		t.Fatalf("生成缩略图失败: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 100 || b.Dy() != 50 {
+		t.Fatalf("缩略图尺寸不正确，期望100x50，实际%v", b)
+	}
+	r, _, _, _ := thumb.At(50, 25).RGBA()
+	if v := r >> 8; v < 120 || v > 135 {
+		t.Fatalf("缩略图应平滑为灰色，实际亮度%d", v)
+	}
+
+	format, err := imageutil.GetImageFormat(data)
+	if err != nil || format != "png" {
+		t.Fatalf("缩略图编码格式不正确: %s, %v", format, err)
+	}
+	decoded, err := imageutil.NewLoader().LoadFromBytes(data)
+	if err != nil {
+		t.Fatalf("解码缩略图失败: %v", err)
+	}
+	if decoded.Bounds() != thumb.Bounds() {
+		t.Fatalf("编码后的缩略图尺寸不一致: %v", decoded.Bounds())
+	}
+
+	// 小图不放大
+	small, _, err := imageutil.Thumbnail(image.NewRGBA(image.Rect(0, 0, 30, 20)), 100, 100, "jpeg")
+	if err != nil {
+		t.Fatalf("生成缩略图失败: %v", err)
+	}
+	if b := small.Bounds(); b.Dx() != 30 || b.Dy() != 20 {
+		t.Fatalf("小图不应放大，实际%v", b)
+	}
+
+	if _, _, err := imageutil.Thumbnail(src, 100, 100, "bmp"); err != imageutil.ErrUnsupportedFormat {
+		t.Fatalf("期望不支持的格式错误，实际得到: %v", err)
+	}
+}
+
+// 测试识别HEIC/AVIF格式
+func TestHEIFFormat(t *testing.T) {
+	for _, brand := range []string{"heic", "avif", "mif1"} {
+		// 只有ftyp盒的文件，格式能被识别但没有可解码的图像
+		data := append([]byte{0, 0, 0, 16, 'f', 't', 'y', 'p'}, brand+"\x00\x00\x00\x00"...)
+
+		_, err := imageutil.NewLoader().LoadFromBytes(data)
+		if err == nil {
+			t.Fatalf("%s: 期望解码不完整的图片时返回错误，但没有", brand)
+		}
+		if errors.Is(err, image.ErrFormat) {
+			t.Fatalf("%s: 格式应被识别，实际得到: %v", brand, err)
+		}
+	}
+}
+
+// 测试SVG光栅化
+func TestLoadSVG(t *testing.T) {
+	const svg = `<?xml version="1.0"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="20" height="10" viewBox="0 0 200 100">
+  <rect width="100" height="100" fill="#ff0000"/>
+  <g transform="translate(100 0)" style="fill: blue">
+    <path d="M0 0h100v100H0z M25 25v50h50v-50z" fill-rule="evenodd"/>
+  </g>
+  <circle cx="150" cy="50" r="10" fill="none" stroke="lime" stroke-width="4" display="none"/>
+</svg>`
+
+	// 未指定尺寸时使用width和height
+	img, err := imageutil.LoadSVG(strings.NewReader(svg), 0, 0)
+	if err != nil {
+		t.Fatalf("光栅化SVG失败: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 10 {
+		t.Fatalf("图片尺寸不正确，期望20x10，实际%v", b)
+	}
+
+	// 只指定宽度时按比例计算高度
+	img, err = imageutil.LoadSVG(strings.NewReader(svg), 400, 0)
+	if err != nil {
+		t.Fatalf("光栅化SVG失败: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Fatalf("图片尺寸不正确，期望400x200，实际%v", b)
+	}
+	tests := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{100, 100, color.NRGBA{255, 0, 0, 255}}, // 红色矩形
+		{210, 10, color.NRGBA{0, 0, 255, 255}},  // 蓝色矩形
+		{300, 100, color.NRGBA{0, 0, 0, 0}},     // evenodd挖空的区域，隐藏的圆不绘制
+	}
+	for _, tt := range tests {
+		if got := color.NRGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.want {
+			t.Fatalf("像素(%d,%d)颜色不正确，期望%v，实际%v", tt.x, tt.y, tt.want, got)
+		}
+	}
+	// 与画布边缘对齐的像素完全覆盖
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0xffff {
+		t.Fatalf("矩形角落应完全覆盖，实际alpha为%d", a>>8)
+	}
+
+	// 描边
+	const stroke = `<svg viewBox="0 0 10 10"><line x1="0" y1="5" x2="10" y2="5" stroke="black" stroke-width="2"/></svg>`
+	img, err = imageutil.LoadSVG(strings.NewReader(stroke), 10, 10)
+	if err != nil {
+		t.Fatalf("光栅化SVG失败: %v", err)
+	}
+	if _, _, _, a := img.At(5, 4).RGBA(); a != 0xffff {
+		t.Fatalf("描边区域应完全覆盖，实际alpha为%d", a>>8)
+	}
+	if _, _, _, a := img.At(5, 7).RGBA(); a != 0 {
+		t.Fatalf("描边以外的区域应透明，实际alpha为%d", a>>8)
+	}
+
+	for _, bad := range []string{"<html></html>", "<svg><rect/>", "<svg></svg>"} {
+		if _, err := imageutil.LoadSVG(strings.NewReader(bad), 10, 10); !errors.Is(err, imageutil.ErrInvalidSVG) {
+			t.Fatalf("%q: 期望无效SVG错误，实际得到: %v", bad, err)
+		}
+	}
+}
+
+// 测试SVG尺寸校验和大小限制
+func TestLoadSVGLimits(t *testing.T) {
+	for _, bad := range []string{
+		`<svg width="NaN" height="10"/>`,
+		`<svg width="10" height="Inf"/>`,
+		`<svg width="-10" height="10"/>`,
+		`<svg width="0" height="10" viewBox="0 0 10 10"/>`,
+		`<svg viewBox="0 0 -10 10"/>`,
+		`<svg viewBox="0 0 NaN 10"/>`,
+		`<svg viewBox="0 0 1e400 10"/>`,
+	} {
+		if _, err := imageutil.LoadSVG(strings.NewReader(bad), 0, 0); !errors.Is(err, imageutil.ErrInvalidSVG) {
+			t.Fatalf("%q: 期望无效SVG错误，实际得到: %v", bad, err)
+		}
+	}
+
+	huge := []struct {
+		svg           string
+		width, height int
+	}{
+		{`<svg width="1e9" height="1e9"/>`, 0, 0},
+		{`<svg width="1e300" height="1e300"/>`, 0, 0},
+		{`<svg viewBox="0 0 1e-300 1e300"/>`, 10, 0},
+		{`<svg width="10000" height="10000"/>`, 0, 0},
+		{`<svg viewBox="0 0 10 10"/>`, 100000, 100000},
+	}
+	for _, tc := range huge {
+		if _, err := imageutil.LoadSVG(strings.NewReader(tc.svg), tc.width, tc.height); !errors.Is(err, imageutil.ErrImageTooLarge) {
+			t.Fatalf("%q: 期望ErrImageTooLarge，实际得到: %v", tc.svg, err)
+		}
+	}
+
+	// 加载器的限制
+	const svg = `<svg width="40" height="20"><rect width="40" height="20"/></svg>`
+	for _, opts := range []imageutil.LoaderOptions{{MaxPixels: 799}, {MaxWidth: 30}, {MaxBytes: 10}} {
+		loader, err := imageutil.NewLoaderWithOptions(opts)
+		if err != nil {
+			t.Fatalf("创建加载器失败: %v", err)
+		}
+		if _, err := loader.LoadSVG(strings.NewReader(svg), 0, 0); !errors.Is(err, imageutil.ErrImageTooLarge) {
+			t.Fatalf("%+v: 应返回ErrImageTooLarge，实际%v", opts, err)
+		}
+	}
+	loader, _ := imageutil.NewLoaderWithOptions(imageutil.LoaderOptions{MaxPixels: 800})
+	img, err := loader.LoadSVG(strings.NewReader(svg), 0, 0)
+	if err != nil {
+		t.Fatalf("未超过限制时应光栅化成功: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Fatalf("尺寸错误: %v", b)
+	}
+}
+
+// exifEntry 测试用的IFD条目，sub不为0时值为第sub个IFD的偏移
+type exifEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+	sub      int
+}
+
+// buildTIFF 按顺序排列IFD和它们的数据，生成大端序的EXIF数据
+func buildTIFF(ifds ...[]exifEntry) []byte {
+	be := binary.BigEndian
+	offsets := make([]uint32, len(ifds))
+	off := uint32(8)
+	for i, entries := range ifds {
+		offsets[i] = off
+		off += 2 + 12*uint32(len(entries)) + 4
+		for _, e := range entries {
+			if len(e.value) > 4 {
+				off += uint32(len(e.value))
+			}
+		}
+	}
+
+	out := []byte("MM\x00*\x00\x00\x00\x08")
+	for i, entries := range ifds {
+		data := offsets[i] + 2 + 12*uint32(len(entries)) + 4
+		var extra []byte
+		out = be.AppendUint16(out, uint16(len(entries)))
+		for _, e := range entries {
+			out = be.AppendUint16(out, e.tag)
+			out = be.AppendUint16(out, e.typ)
+			out = be.AppendUint32(out, e.count)
+			switch {
+			case e.sub > 0:
+				out = be.AppendUint32(out, offsets[e.sub])
+			case len(e.value) > 4:
+				out = be.AppendUint32(out, data+uint32(len(extra)))
+				extra = append(extra, e.value...)
+			default:
+				out = append(out, append(e.value, make([]byte, 4-len(e.value))...)...)
+			}
+		}
+		out = be.AppendUint32(out, 0)
+		out = append(out, extra...)
+	}
+	return out
+}
+
+func exifASCII(tag uint16, s string) exifEntry {
+	return exifEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func exifShort(tag uint16, v uint16) exifEntry {
+	return exifEntry{tag: tag, typ: 3, count: 1, value: binary.BigEndian.AppendUint16(nil, v)}
+}
+
+func exifRational(tag uint16, v ...uint32) exifEntry {
+	var b []byte
+	for _, n := range v {
+		b = binary.BigEndian.AppendUint32(b, n)
+	}
+	return exifEntry{tag: tag, typ: 5, count: uint32(len(v) / 2), value: b}
+}
+
+// 测试读取EXIF
+func TestReadEXIF(t *testing.T) {
+	tiff := buildTIFF(
+		[]exifEntry{
+			exifASCII(0x010F, "Apple"),
+			exifASCII(0x0110, "iPhone 15 Pro"),
+			exifShort(0x0112, 6),
+			exifASCII(0x0132, "2024:05:06 10:11:12"),
+			{tag: 0x8769, typ: 4, count: 1, sub: 1},
+			{tag: 0x8825, typ: 4, count: 1, sub: 2},
+		},
+		[]exifEntry{
+			exifRational(0x829A, 1, 120),
+			exifRational(0x829D, 178, 100),
+			exifShort(0x8827, 64),
+			exifASCII(0x9003, "2024:05:06 10:11:12"),
+			exifASCII(0x9011, "+08:00"),
+			exifRational(0x920A, 686, 100),
+			exifASCII(0xA434, "iPhone 15 Pro back camera"),
+		},
+		[]exifEntry{
+			exifASCII(0x01, "N"),
+			exifRational(0x02, 31, 1, 14, 1, 1512, 100),
+			exifASCII(0x03, "W"),
+			exifRational(0x04, 121, 1, 30, 1, 0, 1),
+			{tag: 0x05, typ: 1, count: 1, value: []byte{1}},
+			exifRational(0x06, 1250, 100),
+			exifRational(0x07, 2, 1, 11, 1, 12, 1),
+			exifASCII(0x1D, "2024:05:06"),
+		},
+	)
+
+	// 把EXIF放入JPEG的APP1段
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	jpeg := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00, 0xFF, 0xE1}
+	jpeg = binary.BigEndian.AppendUint16(jpeg, uint16(len(app1)+2))
+	jpeg = append(append(jpeg, app1...), 0xFF, 0xD9)
+
+	for name, data := range map[string][]byte{"jpeg": jpeg, "tiff": tiff} {
+		x, err := imageutil.ReadEXIF(data)
+		if err != nil {
+			t.Fatalf("%s: 读取EXIF失败: %v", name, err)
+		}
+		if x.Make != "Apple" || x.Model != "iPhone 15 Pro" || x.LensModel != "iPhone 15 Pro back camera" || x.Orientation != 6 {
+			t.Fatalf("%s: 相机字段不正确: %+v", name, x)
+		}
+		if x.ExposureTime != 1.0/120 || x.FNumber != 1.78 || x.ISO != 64 || x.FocalLength != 6.86 {
+			t.Fatalf("%s: 拍摄参数不正确: %+v", name, x)
+		}
+		want := time.Date(2024, 5, 6, 2, 11, 12, 0, time.UTC)
+		if !x.DateTimeOriginal.Equal(want) {
+			t.Fatalf("%s: 拍摄时间不正确，期望%v，实际%v", name, want, x.DateTimeOriginal)
+		}
+		if x.DateTime != time.Date(2024, 5, 6, 10, 11, 12, 0, time.UTC) {
+			t.Fatalf("%s: 没有时区的时间应按UTC解析，实际%v", name, x.DateTime)
+		}
+		if x.GPS == nil {
+			t.Fatalf("%s: 缺少GPS信息", name)
+		}
+		if d := x.GPS.Latitude - (31 + 14.0/60 + 15.12/3600); d > 1e-9 || d < -1e-9 {
+			t.Fatalf("%s: 纬度不正确: %v", name, x.GPS.Latitude)
+		}
+		if x.GPS.Longitude != -121.5 || x.GPS.Altitude != -12.5 {
+			t.Fatalf("%s: 经度或海拔不正确: %+v", name, x.GPS)
+		}
+		if x.GPS.Time != time.Date(2024, 5, 6, 2, 11, 12, 0, time.UTC) {
+			t.Fatalf("%s: GPS时间不正确: %v", name, x.GPS.Time)
+		}
+	}
+
+	// 没有EXIF的图片
+	data, _ := base64.StdEncoding.DecodeString(testImageBase64)
+	if _, err := imageutil.ReadEXIF(data); err != imageutil.ErrNoEXIF {
+		t.Fatalf("期望没有EXIF错误，实际得到: %v", err)
+	}
+	// 截断的EXIF
+	if _, err := imageutil.ReadEXIF(tiff[:20]); !errors.Is(err, imageutil.ErrInvalidEXIF) {
+		t.Fatalf("期望EXIF格式错误，实际得到: %v", err)
+	}
+}
+
+// 测试按EXIF方向转换图片
+func TestApplyOrientation(t *testing.T) {
+	src := newTestImage()
+	for orientation := 1; orientation <= 8; orientation++ {
+		// 按方向写入的图片经过转换后应与原图相同
+		var stored image.Image
+		switch orientation {
+		case 1:
+			stored = src
+		case 2:
+			stored = imageutil.FlipHorizontal(src)
+		case 3:
+			stored = imageutil.Rotate180(src)
+		case 4:
+			stored = imageutil.FlipVertical(src)
+		case 5:
+			stored = imageutil.Rotate270(imageutil.FlipHorizontal(src))
+		case 6:
+			stored = imageutil.Rotate270(src)
+		case 7:
+			stored = imageutil.Rotate90(imageutil.FlipHorizontal(src))
+		case 8:
+			stored = imageutil.Rotate90(src)
+		}
+		got := imageutil.ApplyOrientation(stored, orientation)
+		if !bytes.Equal(got.Pix, src.Pix) || got.Bounds() != src.Bounds() {
+			t.Fatalf("方向%d转换后的图片与原图不同", orientation)
+		}
+	}
+}
+
+// 测试图片比较
+func TestDiff(t *testing.T) {
+	a := newTestImage()
+
+	result, diff := imageutil.Diff(a, imageutil.FlipHorizontal(imageutil.FlipHorizontal(a)), imageutil.DiffOptions{})
+	if !result.Equal() || result.Ratio != 0 || !result.Bounds.Empty() {
+		t.Fatalf("相同的图片应没有差异: %+v", result)
+	}
+	if diff.Bounds() != a.Bounds() {
+		t.Fatalf("差异图尺寸不正确: %v", diff.Bounds())
+	}
+
+	// 修改一个像素，并做一个低于阈值的细微修改
+	b := imageutil.FlipVertical(imageutil.FlipVertical(a))
+	b.Set(1, 1, color.NRGBA{0, 0, 255, 255})
+	b.Set(0, 0, color.NRGBA{2, 1, 0, 255})
+	result, diff = imageutil.Diff(a, b, imageutil.DiffOptions{HighlightColor: color.NRGBA{255, 0, 255, 255}})
+	if result.DiffPixels != 1 || result.TotalPixels != 6 || result.Bounds != image.Rect(1, 1, 2, 2) {
+		t.Fatalf("比较结果不正确: %+v", result)
+	}
+	if got := color.NRGBAModel.Convert(diff.At(1, 1)); got != (color.NRGBA{255, 0, 255, 255}) {
+		t.Fatalf("不同的像素应使用高亮颜色，实际%v", got)
+	}
+	if r, g, b, _ := diff.At(0, 0).RGBA(); r != g || g != b {
+		t.Fatal("相同的像素应显示为灰度")
+	}
+
+	// 阈值为负数时要求完全一致
+	if result, _ = imageutil.Diff(a, b, imageutil.DiffOptions{Threshold: -1}); result.DiffPixels != 2 {
+		t.Fatalf("期望2个不同的像素，实际%d", result.DiffPixels)
+	}
+
+	// 尺寸不同时多出的部分视为不同
+	result, diff = imageutil.Diff(a, image.NewNRGBA(image.Rect(0, 0, 3, 3)), imageutil.DiffOptions{})
+	if !result.SizeMismatch || result.TotalPixels != 9 || diff.Bounds().Dy() != 3 {
+		t.Fatalf("尺寸不同的比较结果不正确: %+v", result)
+	}
+}
+
+// 测试直方图和亮度统计
+func TestHistogramAndStats(t *testing.T) {
+	// 左半黑色，右半白色
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		img.SetGray(2, y, color.Gray{255})
+		img.SetGray(3, y, color.Gray{255})
+	}
+
+	h := imageutil.Histogram(img)
+	if h.Total != 8 || h.Luminance[0] != 4 || h.Luminance[255] != 4 || h.Red[255] != 4 || h.Alpha[255] != 8 {
+		t.Fatalf("直方图不正确: total=%d luma0=%d luma255=%d", h.Total, h.Luminance[0], h.Luminance[255])
+	}
+
+	s := imageutil.Stats(img)
+	if s.Mean != 127.5 || s.Contrast != 127.5 || s.Min != 0 || s.Max != 255 || s.Median != 0 {
+		t.Fatalf("统计结果不正确: %+v", s)
+	}
+	if p := h.Percentile(90); p != 255 {
+		t.Fatalf("90百分位数应为255，实际%d", p)
+	}
+	if p := h.Percentile(50); p != 0 {
+		t.Fatalf("50百分位数应为0，实际%d", p)
+	}
+
+	// 纯色图片的对比度为0
+	if s := imageutil.Stats(&image.Gray{Pix: []uint8{100, 100}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}); s.Mean != 100 || s.Contrast != 0 {
+		t.Fatalf("纯色图片的统计结果不正确: %+v", s)
+	}
+}
+
+// 测试图片水印
+func TestWatermark(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	mark := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for i := 0; i < len(mark.Pix); i += 4 {
+		mark.Pix[i], mark.Pix[i+3] = 255, 255
+	}
+
+	// 默认位于右下角，与边缘的距离为短边的2%
+	dst := imageutil.Watermark(img, mark, imageutil.PositionBottomRight, 1)
+	if got := dst.NRGBAAt(95, 95); got != (color.NRGBA{255, 0, 0, 255}) {
+		t.Fatalf("右下角应有水印，实际%v", got)
+	}
+	if got := dst.NRGBAAt(99, 99); got.A != 0 {
+		t.Fatalf("边距内不应有水印，实际%v", got)
+	}
+	if img.NRGBAAt(95, 95).A != 0 {
+		t.Fatal("水印修改了原图")
+	}
+
+	// 半透明水印叠加在白色背景上
+	white := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	for i := range white.Pix {
+		white.Pix[i] = 255
+	}
+	dst = imageutil.Watermark(white, mark, imageutil.PositionTopLeft, 0.5)
+	if got := dst.NRGBAAt(5, 5); got.R != 255 || got.G < 126 || got.G > 129 || got.B != got.G {
+		t.Fatalf("半透明水印颜色不正确: %v", got)
+	}
+
+	// 平铺
+	dst = imageutil.Watermark(img, mark, imageutil.PositionTile, 1)
+	if dst.NRGBAAt(5, 5).A == 0 || dst.NRGBAAt(5, 55).A == 0 || dst.NRGBAAt(95, 5).A == 0 {
+		t.Fatal("平铺的水印应覆盖整张图片")
+	}
+}
+
+// 测试文字水印
+func TestWatermarkText(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	dst := imageutil.WatermarkText(img, "(c) gophertool\n2024", nil, imageutil.PositionBottomRight, imageutil.TextWatermarkOptions{
+		Color:   color.NRGBA{255, 255, 0, 255},
+		Opacity: 1,
+		Margin:  4,
+	})
+
+	// 文字只出现在右下角的区域内
+	drawn := image.Rectangle{}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			if c := dst.NRGBAAt(x, y); c.A != 0 {
+				if c.R != 255 || c.G != 255 || c.B != 0 {
+					t.Fatalf("文字颜色不正确: %v", c)
+				}
+				drawn = drawn.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if drawn.Empty() || drawn.Max.X > 196 || drawn.Max.Y > 96 || drawn.Min.X < 100 || drawn.Min.Y < 60 {
+		t.Fatalf("文字水印的位置不正确: %v", drawn)
+	}
+
+	// 空文字不修改图片
+	if dst := imageutil.WatermarkText(img, "", nil, imageutil.PositionCenter, imageutil.TextWatermarkOptions{}); !bytes.Equal(dst.Pix, img.Pix) {
+		t.Fatal("空文字不应绘制水印")
+	}
+}
+
+// 测试加载字体和绘制文字
+func TestDrawText(t *testing.T) {
+	f, err := imageutil.LoadFont(goregular.TTF)
+	if err != nil {
+		t.Fatalf("加载字体失败: %v", err)
+	}
+	face, err := f.Face(20)
+	if err != nil {
+		t.Fatalf("创建字体失败: %v", err)
+	}
+	if _, err := imageutil.LoadFont([]byte("不是字体")); err == nil {
+		t.Fatal("期望加载无效字体时返回错误，但没有")
+	}
+
+	// 自动换行
+	lines := imageutil.WrapText("the quick brown fox jumps over the lazy dog", face, 120)
+	if len(lines) < 3 {
+		t.Fatalf("期望拆分为多行，实际%q", lines)
+	}
+	if strings.Join(lines, " ") != "the quick brown fox jumps over the lazy dog" {
+		t.Fatalf("换行后的文字不正确: %q", lines)
+	}
+	// 中文按字换行，过长的单词按字符拆分
+	width := imageutil.MeasureText("你好", imageutil.TextOptions{Face: face}).X
+	if lines := imageutil.WrapText("你好世界", face, width); len(lines) != 2 || lines[0] != "你好" {
+		t.Fatalf("中文换行不正确: %q", lines)
+	}
+	for _, line := range imageutil.WrapText("supercalifragilistic\nok", face, 50) {
+		if w := imageutil.MeasureText(line, imageutil.TextOptions{Face: face}).X; w > 50 {
+			t.Fatalf("行%q的宽度%d超过最大宽度", line, w)
+		}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 100))
+	opts := imageutil.TextOptions{Face: face, Color: color.NRGBA{0, 0, 255, 255}, Align: imageutil.AlignRight, MaxWidth: 180}
+	r := imageutil.DrawText(img, "Hello\nWorld", image.Pt(10, 10), opts)
+	if size := imageutil.MeasureText("Hello\nWorld", opts); r != image.Rect(10, 10, 10+size.X, 10+size.Y) || size.X != 180 {
+		t.Fatalf("文本框不正确: %v", r)
+	}
+
+	// 右对齐时文字位于文本框右侧
+	drawn := image.Rectangle{}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			if img.NRGBAAt(x, y).A != 0 {
+				drawn = drawn.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if !drawn.In(r) || drawn.Min.X < 100 || drawn.Max.X < 180 {
+		t.Fatalf("右对齐的文字位置不正确: %v", drawn)
+	}
+}
+
+// 测试图层混合
+func TestComposite(t *testing.T) {
+	uniform := func(w, h int, c color.NRGBA) *image.NRGBA {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	base := uniform(4, 4, color.NRGBA{200, 100, 50, 255})
+	overlay := uniform(2, 2, color.NRGBA{100, 100, 100, 255})
+
+	tests := []struct {
+		mode imageutil.BlendMode
+		want color.NRGBA
+	}{
+		{imageutil.BlendNormal, color.NRGBA{100, 100, 100, 255}},
+		{imageutil.BlendMultiply, color.NRGBA{78, 39, 20, 255}},
+		{imageutil.BlendScreen, color.NRGBA{222, 161, 130, 255}},
+		{imageutil.BlendDarken, color.NRGBA{100, 100, 50, 255}},
+		{imageutil.BlendLighten, color.NRGBA{200, 100, 100, 255}},
+		{imageutil.BlendDifference, color.NRGBA{100, 0, 50, 255}},
+		{imageutil.BlendAdd, color.NRGBA{255, 200, 150, 255}},
+	}
+	for _, tt := range tests {
+		dst := imageutil.Composite(base, overlay, image.Pt(1, 1), tt.mode, 1)
+		if got := dst.NRGBAAt(1, 1); got != tt.want {
+			t.Fatalf("混合模式%d的结果不正确，期望%v，实际%v", tt.mode, tt.want, got)
+		}
+		// 上层图片以外的区域不变
+		if got := dst.NRGBAAt(0, 0); got != base.NRGBAAt(0, 0) {
+			t.Fatalf("混合模式%d修改了上层图片以外的区域: %v", tt.mode, got)
+		}
+	}
+
+	// 半透明叠加
+	dst := imageutil.Composite(base, overlay, image.Point{}, imageutil.BlendNormal, 0.5)
+	if got := dst.NRGBAAt(0, 0); got != (color.NRGBA{150, 100, 75, 255}) {
+		t.Fatalf("半透明叠加的结果不正确: %v", got)
+	}
+
+	// 叠加到透明底图上时保留上层图片的颜色
+	dst = imageutil.Composite(image.NewNRGBA(image.Rect(0, 0, 4, 4)), overlay, image.Pt(3, 3), imageutil.BlendMultiply, 1)
+	if got := dst.NRGBAAt(3, 3); got != (color.NRGBA{100, 100, 100, 255}) {
+		t.Fatalf("透明底图上的结果不正确: %v", got)
+	}
+	if dst.Bounds() != base.Bounds() {
+		t.Fatalf("结果的尺寸应与底图相同: %v", dst.Bounds())
+	}
+}
+
+// 测试滤镜
+func TestFilters(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			v := uint8(0)
+			if x >= 10 {
+				v = 200
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v / 2, 0, 255})
+		}
+	}
+
+	// 颜色调整
+	dst := imageutil.Apply(src, imageutil.Brightness(10))
+	if got := dst.NRGBAAt(15, 0); got != (color.NRGBA{226, 126, 26, 255}) {
+		t.Fatalf("调整亮度的结果不正确: %v", got)
+	}
+	dst = imageutil.Apply(src, imageutil.Grayscale())
+	if got := dst.NRGBAAt(15, 0); got.R != got.G || got.G != got.B || got.R != 119 {
+		t.Fatalf("灰度的结果不正确: %v", got)
+	}
+	dst = imageutil.Apply(src, imageutil.Invert(), imageutil.Contrast(-100))
+	if got := dst.NRGBAAt(0, 0); got != (color.NRGBA{128, 128, 128, 255}) {
+		t.Fatalf("对比度为-100时应为中灰色: %v", got)
+	}
+	if src.NRGBAAt(0, 0) != (color.NRGBA{0, 0, 0, 255}) {
+		t.Fatal("滤镜修改了原图")
+	}
+
+	// 模糊使边缘平滑过渡，远离边缘的像素不变
+	dst = imageutil.Apply(src, imageutil.GaussianBlur(2))
+	if got := dst.NRGBAAt(9, 10).R; got <= 0 || got >= 100 {
+		t.Fatalf("边缘左侧应平滑过渡，实际%d", got)
+	}
+	if got := dst.NRGBAAt(0, 10).R; got != 0 {
+		t.Fatalf("远离边缘的像素不应改变，实际%d", got)
+	}
+
+	// 锐化增强边缘两侧的反差
+	dst = imageutil.Apply(src, imageutil.Sharpen(1))
+	if dst.NRGBAAt(10, 10).R <= 200 || dst.NRGBAAt(19, 10).R != 200 {
+		t.Fatalf("锐化的结果不正确: %v %v", dst.NRGBAAt(10, 10), dst.NRGBAAt(19, 10))
+	}
+
+	// 模糊透明图片时颜色不会混入黑色
+	transparent := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	transparent.SetNRGBA(5, 5, color.NRGBA{255, 0, 0, 255})
+	if got := imageutil.Apply(transparent, imageutil.GaussianBlur(1)).NRGBAAt(6, 5); got.R != 255 || got.A == 0 {
+		t.Fatalf("模糊透明图片的颜色不正确: %v", got)
+	}
+}
+
+// 测试批量处理流水线
+func TestPipeline(t *testing.T) {
+	var pngData bytes.Buffer
+	src := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	if err := imageutil.SaveImageToWriter(src, &pngData, "png"); err != nil {
+		t.Fatalf("编码测试图片失败: %v", err)
+	}
+
+	var sources []imageutil.Source
+	for i := 0; i < 5; i++ {
+		sources = append(sources, imageutil.ReaderSource("", bytes.NewReader(pngData.Bytes())))
+	}
+	sources = append(sources, imageutil.ReaderSource("bad.png", strings.NewReader("不是图片")), imageutil.Source{})
+
+	var (
+		mu       sync.Mutex
+		sizes    = map[string]image.Point{}
+		progress []imageutil.Progress
+	)
+	p := &imageutil.Pipeline{
+		Workers:    3,
+		Operations: []imageutil.Operation{imageutil.FitOperation(10, 10), imageutil.FilterOperation(imageutil.Grayscale())},
+		Sink: func(src imageutil.Source, img image.Image) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sizes[src.Name] = img.Bounds().Size()
+			return nil
+		},
+		OnProgress: func(p imageutil.Progress) { progress = append(progress, p) },
+	}
+	result, err := p.Run(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("批量处理失败: %v", err)
+	}
+	if result.Total != 7 || result.Succeeded != 5 || len(result.Errors) != 2 {
+		t.Fatalf("处理结果不正确: %+v", result)
+	}
+	if result.Errors[0].Index != 5 || result.Errors[0].Source.Name != "bad.png" || !errors.Is(result.Errors[1], imageutil.ErrInvalidSource) {
+		t.Fatalf("失败记录不正确: %v, %v", result.Errors[0], result.Errors[1])
+	}
+	if len(sizes) != 5 || sizes["0"] != image.Pt(10, 5) {
+		t.Fatalf("输出不正确: %v", sizes)
+	}
+	if len(progress) != 7 || progress[6].Done != 7 || progress[6].Failed != 2 {
+		t.Fatalf("进度回调不正确: %+v", progress)
+	}
+
+	// 上下文已取消时不处理任何来源
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = p.Run(ctx, sources)
+	if !errors.Is(err, context.Canceled) || result.Succeeded != 0 || len(result.Errors) != 0 {
+		t.Fatalf("取消后不应处理来源: %+v, %v", result, err)
+	}
+
+	// 保存到目录
+	dir := t.TempDir()
+	p = &imageutil.Pipeline{Sink: imageutil.DirSink(dir, "jpeg")}
+	if _, err := p.Run(context.Background(), []imageutil.Source{imageutil.ReaderSource("a.png", bytes.NewReader(pngData.Bytes()))}); err != nil {
+		t.Fatalf("批量处理失败: %v", err)
+	}
+	if _, err := imageutil.NewLoader().LoadFromFile(filepath.Join(dir, "a.jpeg")); err != nil {
+		t.Fatalf("输出文件不正确: %v", err)
+	}
+}
+
+// 测试可取消的加载
+func TestLoadContext(t *testing.T) {
+	loader := imageutil.NewLoader()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := loader.LoadFromFileContext(ctx, "test.png"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消后加载文件应返回context.Canceled，实际%v", err)
+	}
+	if _, err := loader.LoadFromFileContext(context.Background(), "test.png"); err != nil {
+		t.Fatalf("加载文件失败: %v", err)
+	}
+
+	// 下载超时
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := loader.LoadFromURLContext(ctx, server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("下载超时应返回context.DeadlineExceeded，实际%v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("超时后没有及时中止下载")
+	}
+}
+
+// 测试从URL加载图片时的HTTP选项
+func TestLoaderOptions(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := imageutil.SaveImageToWriter(newTestImage(), &pngData, "png"); err != nil {
+		t.Fatalf("编码测试图片失败: %v", err)
+	}
+
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/redirect", http.StatusFound)
+			return
+		case "/notfound":
+			attempts++
+			http.NotFound(w, r)
+			return
+		}
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("User-Agent") != "gophertool" || r.Header.Get("X-Test") != "1" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write(pngData.Bytes())
+	}))
+	defer server.Close()
+
+	loader, err := imageutil.NewLoaderWithOptions(imageutil.LoaderOptions{
+		Timeout:      time.Second,
+		Headers:      map[string]string{"X-Test": "1"},
+		UserAgent:    "gophertool",
+		Username:     "user",
+		BearerToken:  "token",
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+		MaxRedirects: 3,
+	})
+	if err != nil {
+		t.Fatalf("创建加载器失败: %v", err)
+	}
+	img, err := loader.LoadFromURL(server.URL + "/image.png")
+	if err != nil || attempts != 3 {
+		t.Fatalf("重试后应加载成功: %v, 请求%d次", err, attempts)
+	}
+	if img.Bounds().Size() != image.Pt(3, 2) {
+		t.Fatalf("图片尺寸不正确: %v", img.Bounds())
+	}
+
+	// 404不重试
+	attempts = 0
+	if _, err := loader.LoadFromURL(server.URL + "/notfound"); err == nil || attempts != 1 {
+		t.Fatalf("404应直接失败: %v, 请求%d次", err, attempts)
+	}
+
+	// 重定向次数超过限制
+	if _, err := loader.LoadFromURL(server.URL + "/redirect"); err == nil || !strings.Contains(err.Error(), "重定向次数超过3次") {
+		t.Fatalf("应返回重定向次数超过限制的错误，实际%v", err)
+	}
+
+	if _, err := imageutil.NewLoaderWithOptions(imageutil.LoaderOptions{Proxy: "://bad"}); err == nil {
+		t.Fatal("代理地址无效时应返回错误")
+	}
+}
+
+// 测试图片大小限制
+func TestLoaderLimits(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := imageutil.SaveImageToWriter(image.NewNRGBA(image.Rect(0, 0, 40, 20)), &pngData, "png"); err != nil {
+		t.Fatalf("编码测试图片失败: %v", err)
+	}
+	load := func(opts imageutil.LoaderOptions, data []byte) error {
+		loader, err := imageutil.NewLoaderWithOptions(opts)
+		if err != nil {
+			t.Fatalf("创建加载器失败: %v", err)
+		}
+		_, err = loader.LoadFromBytes(data)
+		return err
+	}
+
+	for _, opts := range []imageutil.LoaderOptions{
+		{MaxWidth: 30},
+		{MaxHeight: 10},
+		{MaxPixels: 799},
+		{MaxBytes: int64(pngData.Len() - 1)},
+	} {
+		if err := load(opts, pngData.Bytes()); !errors.Is(err, imageutil.ErrImageTooLarge) {
+			t.Fatalf("%+v: 应返回ErrImageTooLarge，实际%v", opts, err)
+		}
+	}
+	if err := load(imageutil.LoaderOptions{MaxWidth: 40, MaxHeight: 20, MaxPixels: 800, MaxBytes: int64(pngData.Len())}, pngData.Bytes()); err != nil {
+		t.Fatalf("未超过限制时应加载成功: %v", err)
+	}
+
+	// 图片头声明的尺寸超过限制时不会尝试解码
+	bomb := bytes.Clone(pngData.Bytes())
+	binary.BigEndian.PutUint32(bomb[16:], 100000)
+	binary.BigEndian.PutUint32(bomb[20:], 100000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+	if err := load(imageutil.LoaderOptions{MaxPixels: 50_000_000}, bomb); !errors.Is(err, imageutil.ErrImageTooLarge) {
+		t.Fatalf("解压炸弹应返回ErrImageTooLarge，实际%v", err)
+	}
+}
+
+// 测试带缓存的加载器
+func TestCachedLoader(t *testing.T) {
+	var pngData bytes.Buffer
+	if err := imageutil.SaveImageToWriter(newTestImage(), &pngData, "png"); err != nil {
+		t.Fatalf("编码测试图片失败: %v", err)
+	}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/text" {
+			w.Write([]byte("不是图片"))
+			return
+		}
+		w.Write(pngData.Bytes())
+	}))
+	defer server.Close()
+
+	c, err := memory.NewMemoryStore(config.Cache{Driver: config.CacheDriverMemory})
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+	loader := imageutil.NewCachedLoader(nil, c, imageutil.CacheOptions{TTL: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := loader.LoadFromURL(server.URL + "/a.png"); err != nil {
+				t.Errorf("加载图片失败: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	img, err := loader.LoadFromURL(server.URL + "/a.png")
+	if err != nil || img.Bounds().Size() != image.Pt(3, 2) {
+		t.Fatalf("从缓存加载图片失败: %v", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("同一个URL应只下载一次，实际%d次", n)
+	}
+	if ok, _ := c.Exists(loader.Key(server.URL + "/a.png")); !ok {
+		t.Fatal("图片数据应写入缓存")
+	}
+
+	// 删除缓存后重新下载
+	if err := loader.Forget(server.URL + "/a.png"); err != nil {
+		t.Fatalf("删除缓存失败: %v", err)
+	}
+	if _, err := loader.LoadFromURL(server.URL + "/a.png"); err != nil || requests.Load() != 2 {
+		t.Fatalf("删除缓存后应重新下载: %v", err)
+	}
+
+	// 不是图片的响应不写入缓存
+	if _, err := loader.LoadFromURL(server.URL + "/text"); err == nil {
+		t.Fatal("加载非图片数据应失败")
+	}
+	if ok, _ := c.Exists(loader.Key(server.URL + "/text")); ok {
+		t.Fatal("非图片数据不应写入缓存")
+	}
+}
+
+// 测试条带解码和流式缩放
+func TestStripDecoder(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 53, 37))
+	for y := 0; y < 37; y++ {
+		for x := 0; x < 53; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x * 4), uint8(y * 6), uint8(x * y), uint8(100 + x)})
+		}
+	}
+	pal := image.NewPaletted(src.Rect, color.Palette{color.Transparent, color.NRGBA{255, 0, 0, 128}, color.White, color.Black})
+	draw.Draw(pal, pal.Rect, src, image.Point{}, draw.Src)
+	gray16 := image.NewGray16(src.Rect)
+	draw.Draw(gray16, gray16.Rect, src, image.Point{}, draw.Src)
+
+	decodeStrips := func(data []byte) (*image.NRGBA, bool) {
+		d, err := imageutil.NewStripDecoder(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("创建条带解码器失败: %v", err)
+		}
+		out := image.NewNRGBA(image.Rect(0, 0, d.Width, d.Height))
+		for {
+			strip, err := d.Next(10)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("解码条带失败: %v", err)
+			}
+			draw.Draw(out, strip.Rect, strip, strip.Rect.Min, draw.Src)
+		}
+		return out, d.Streaming
+	}
+	check := func(name string, data []byte, tolerance int, streaming bool) {
+		ref, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: 解码失败: %v", name, err)
+		}
+		got, s := decodeStrips(data)
+		if s != streaming {
+			t.Fatalf("%s: 流式解码应为%v", name, streaming)
+		}
+		want := imageutil.Apply(ref)
+		for i := range got.Pix {
+			if d := int(got.Pix[i]) - int(want.Pix[i]); d > tolerance || d < -tolerance {
+				t.Fatalf("%s: 第%d字节为%d，应为%d", name, i, got.Pix[i], want.Pix[i])
+			}
+		}
+	}
+
+	for name, img := range map[string]image.Image{"NRGBA": src, "Paletted": pal, "Gray16": gray16} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatalf("编码PNG失败: %v", err)
+		}
+		check("PNG "+name, buf.Bytes(), 0, true)
+	}
+	for name, img := range map[string]image.Image{"YCbCr": src, "Gray": gray16} {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+			t.Fatalf("编码JPEG失败: %v", err)
+		}
+		// IDCT的实现不同，允许少量误差
+		check("JPEG "+name, buf.Bytes(), 3, true)
+	}
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, pal, nil); err != nil {
+		t.Fatalf("编码GIF失败: %v", err)
+	}
+	check("GIF", buf.Bytes(), 0, false)
+
+	// 流式缩放与完整解码后缩放的结果接近
+	buf.Reset()
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("编码PNG失败: %v", err)
+	}
+	got, err := imageutil.FitStream(bytes.NewReader(buf.Bytes()), 20, 20)
+	if err != nil || got.Rect.Size() != image.Pt(20, 14) {
+		t.Fatalf("流式缩放失败: %v", err)
+	}
+	want, _ := imageutil.Fit(src, 20, 20)
+	if diff, _ := imageutil.Diff(got, want, imageutil.DiffOptions{Threshold: 0.1}); diff.DiffPixels > 0 {
+		t.Fatalf("流式缩放的结果与完整缩放相差过大: %+v", diff)
+	}
+	if _, err := imageutil.ResizeStream(bytes.NewReader(buf.Bytes()), 0, 10); !errors.Is(err, imageutil.ErrInvalidSize) {
+		t.Fatalf("尺寸无效时应返回ErrInvalidSize，实际%v", err)
+	}
+
+	// 数据不完整
+	if _, err := imageutil.ResizeStream(bytes.NewReader(buf.Bytes()[:buf.Len()/2]), 10, 10); err == nil {
+		t.Fatal("数据不完整时应返回错误")
+	}
+}
+
+// stripTestImage 条带解码测试用的带噪声的图片，噪声使压缩后的数据足够长
+func stripTestImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i*7919>>3) ^ uint8(i)
+	}
+	return img
+}
+
+// decodeAllStrips 逐条带解码全部数据，返回遇到的第一个错误
+func decodeAllStrips(data []byte) error {
+	d, err := imageutil.NewStripDecoder(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := d.Next(7); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// pngChunk 生成带CRC的PNG块
+func pngChunk(typ string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, typ...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// buildPNG 用未压缩的行数据（每行以滤波类型开头）生成PNG，plte不为nil时写入PLTE块
+func buildPNG(t testing.TB, w, h uint32, depth, colorType byte, plte, raw []byte) []byte {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(raw)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("压缩PNG数据失败: %v", err)
+	}
+	ihdr := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, w), h)
+	ihdr = append(ihdr, depth, colorType, 0, 0, 0)
+	data := append([]byte("\x89PNG\r\n\x1a\n"), pngChunk("IHDR", ihdr)...)
+	if plte != nil {
+		data = append(data, pngChunk("PLTE", plte)...)
+	}
+	data = append(data, pngChunk("IDAT", z.Bytes())...)
+	return append(data, pngChunk("IEND", nil)...)
+}
+
+// jpegSegment 返回第一个marker段的起始位置（0xFF所在位置）
+func jpegSegment(t testing.TB, data []byte, marker byte) int {
+	i := bytes.Index(data, []byte{0xFF, marker})
+	if i < 0 {
+		t.Fatalf("JPEG中没有0x%02X段", marker)
+	}
+	return i
+}
+
+// 测试条带解码器处理不完整和损坏的数据
+func TestStripDecoderCorrupt(t *testing.T) {
+	src := stripTestImage(40, 30)
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, src); err != nil {
+		t.Fatalf("编码PNG失败: %v", err)
+	}
+	if err := jpeg.Encode(&jpegBuf, src, &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("编码JPEG失败: %v", err)
+	}
+	pngData, jpegData := pngBuf.Bytes(), jpegBuf.Bytes()
+	for name, data := range map[string][]byte{"PNG": pngData, "JPEG": jpegData} {
+		if err := decodeAllStrips(data); err != nil {
+			t.Fatalf("%s: 解码完整数据失败: %v", name, err)
+		}
+	}
+
+	expectError := func(name string, data []byte) {
+		t.Helper()
+		if err := decodeAllStrips(data); err == nil {
+			t.Fatalf("%s: 应返回错误", name)
+		}
+	}
+
+	// 数据在头部、图像数据中途和末尾之前截断，PNG的最后12字节为IEND块，JPEG的最后2字节为EOI
+	for _, n := range []int{0, 1, 8, 20, 33, len(pngData) / 2, len(pngData) - 13} {
+		expectError(fmt.Sprintf("PNG截断为%d字节", n), pngData[:n])
+	}
+	for _, n := range []int{0, 1, 2, 20, jpegSegment(t, jpegData, 0xDA) + 4, len(jpegData) / 2, len(jpegData) - 10} {
+		expectError(fmt.Sprintf("JPEG截断为%d字节", n), jpegData[:n])
+	}
+
+	// PNG的IDAT块
+	idat := bytes.Index(pngData, []byte("IDAT")) - 4
+	corrupt := func(name string, modify func(data []byte)) {
+		data := bytes.Clone(pngData)
+		modify(data)
+		expectError(name, data)
+	}
+	corrupt("IDAT长度超过数据", func(data []byte) { binary.BigEndian.PutUint32(data[idat:], 0x7ffffff0) })
+	corrupt("IDAT长度超过2^31", func(data []byte) { binary.BigEndian.PutUint32(data[idat:], 0xfffffff0) })
+	corrupt("IDAT长度小于数据", func(data []byte) {
+		binary.BigEndian.PutUint32(data[idat:], binary.BigEndian.Uint32(data[idat:])-10)
+	})
+	corrupt("IDAT数据损坏", func(data []byte) { data[idat+20] ^= 0xFF })
+	corrupt("IHDR的CRC错误", func(data []byte) { data[29] ^= 0xFF })
+	corrupt("IHDR的位深度无效", func(data []byte) {
+		data[24] = 3
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	})
+
+	// 手工构造的行数据
+	row := func(filter byte, pix ...byte) []byte { return append([]byte{filter}, pix...) }
+	expectError("PNG滤波类型无效", buildPNG(t, 2, 1, 8, 0, nil, row(5, 1, 2)))
+	expectError("PNG行数据不足", buildPNG(t, 2, 3, 8, 0, nil, append(row(0, 1, 2), row(0, 3, 4)...)))
+	expectError("PNG调色板索引越界", buildPNG(t, 2, 1, 8, 3, []byte{0, 0, 0, 255, 255, 255}, row(0, 1, 2)))
+	expectError("PNG缺少调色板", buildPNG(t, 2, 1, 8, 3, nil, row(0, 0, 0)))
+	expectError("PNG调色板长度错误", buildPNG(t, 2, 1, 8, 3, []byte{0, 0}, row(0, 0, 0)))
+	if err := decodeAllStrips(buildPNG(t, 2, 1, 8, 3, []byte{0, 0, 0, 255, 255, 255}, row(0, 0, 1))); err != nil {
+		t.Fatalf("解码手工构造的PNG失败: %v", err)
+	}
+
+	// JPEG的哈夫曼表，DHT段的长度之后是表编号和16个码长的数量
+	dht := jpegSegment(t, jpegData, 0xC4)
+	corruptJPEG := func(name string, modify func(data []byte)) {
+		data := bytes.Clone(jpegData)
+		modify(data)
+		expectError(name, data)
+	}
+	corruptJPEG("哈夫曼码超出码空间", func(data []byte) { data[dht+5] = 3 })
+	corruptJPEG("哈夫曼表符号数超过段长度", func(data []byte) { data[dht+20] = 255 })
+	corruptJPEG("哈夫曼表编号无效", func(data []byte) { data[dht+4] = 0x27 })
+	corruptJPEG("DHT段长度不足", func(data []byte) { binary.BigEndian.PutUint16(data[dht+2:], 10) })
+	corruptJPEG("扫描引用不存在的哈夫曼表", func(data []byte) {
+		sos := jpegSegment(t, data, 0xDA)
+		data[sos+6] = 0x33
+	})
+	corruptJPEG("量化表编号无效", func(data []byte) { data[jpegSegment(t, data, 0xDB)+4] = 0x07 })
+
+	// 全为1的位序列不是任何哈夫曼码
+	sos := jpegSegment(t, jpegData, 0xDA)
+	scan := sos + 2 + int(binary.BigEndian.Uint16(jpegData[sos+2:]))
+	data := append(bytes.Clone(jpegData[:scan]), bytes.Repeat([]byte{0xFF, 0x00}, 64)...)
+	expectError("JPEG熵编码数据无效", append(data, 0xFF, 0xD9))
+}
+
+// FuzzDecodeStrips 条带解码器处理任意输入时只返回错误，不会panic
+func FuzzDecodeStrips(f *testing.F) {
+	src := stripTestImage(23, 17)
+	gray := image.NewGray(src.Rect)
+	draw.Draw(gray, gray.Rect, src, image.Point{}, draw.Src)
+	gray16 := image.NewGray16(src.Rect)
+	draw.Draw(gray16, gray16.Rect, src, image.Point{}, draw.Src)
+	rgba64 := image.NewNRGBA64(src.Rect)
+	draw.Draw(rgba64, rgba64.Rect, src, image.Point{}, draw.Src)
+	opaque := image.NewNRGBA(src.Rect)
+	draw.Draw(opaque, opaque.Rect, src, image.Point{}, draw.Over)
+	for i := 3; i < len(opaque.Pix); i += 4 {
+		opaque.Pix[i] = 0xFF
+	}
+	pal := image.NewPaletted(src.Rect, color.Palette{color.Transparent, color.NRGBA{255, 0, 0, 128}, color.White, color.Black})
+	draw.Draw(pal, pal.Rect, src, image.Point{}, draw.Src)
+
+	for _, img := range []image.Image{src, gray, gray16, rgba64, opaque, pal} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			f.Fatalf("编码PNG失败: %v", err)
+		}
+		f.Add(buf.Bytes())
+	}
+	for _, img := range []image.Image{src, gray} {
+		for _, quality := range []int{10, 90} {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+				f.Fatalf("编码JPEG失败: %v", err)
+			}
+			f.Add(buf.Bytes())
+		}
+	}
+	f.Add(buildPNG(f, 3, 2, 1, 0, nil, []byte{0, 0xA0, 4, 0x40}))
+	f.Add(buildPNG(f, 2, 2, 4, 3, []byte{0, 0, 0, 255, 0, 0}, []byte{1, 0x01, 2, 0x10}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// 跳过声明的尺寸过大的输入，避免分配过多内存
+		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil && config.Width*config.Height > 1<<20 {
+			return
+		}
+		_ = decodeAllStrips(data)
+	})
+}
+
+// 测试读取图片信息
+func TestInspect(t *testing.T) {
+	src := newTestImage()
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		t.Fatalf("编码PNG失败: %v", err)
+	}
+	info, err := imageutil.Inspect(bytes.NewReader(pngData.Bytes()))
+	if err != nil || info.Format != "png" || info.Width != 3 || info.Height != 2 || info.Size != 24 || info.Animated {
+		t.Fatalf("PNG信息不正确: %+v, %v", info, err)
+	}
+
+	// 在IHDR之后插入acTL块即为APNG
+	actl := []byte{0, 0, 0, 8, 'a', 'c', 'T', 'L', 0, 0, 0, 2, 0, 0, 0, 0}
+	actl = binary.BigEndian.AppendUint32(actl, crc32.ChecksumIEEE(actl[4:]))
+	apng := append(append(bytes.Clone(pngData.Bytes()[:33]), actl...), pngData.Bytes()[33:]...)
+	if info, err := imageutil.Inspect(bytes.NewReader(apng)); err != nil || !info.Animated {
+		t.Fatalf("APNG应识别为动图: %+v, %v", info, err)
+	}
+
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewGray(image.Rect(0, 0, 10, 20)), nil); err != nil {
+		t.Fatalf("编码JPEG失败: %v", err)
+	}
+	if info, err := imageutil.Inspect(&jpegData); err != nil || info.Format != "jpeg" || info.ColorModel != color.GrayModel || info.Size != 200 {
+		t.Fatalf("JPEG信息不正确: %+v, %v", info, err)
+	}
+
+	pal := image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black, color.White})
+	for _, frames := range []int{1, 2} {
+		var gifData bytes.Buffer
+		anim := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			anim.Image = append(anim.Image, pal)
+			anim.Delay = append(anim.Delay, 10)
+		}
+		if err := gif.EncodeAll(&gifData, anim); err != nil {
+			t.Fatalf("编码GIF失败: %v", err)
+		}
+		info, err := imageutil.Inspect(&gifData)
+		if err != nil || info.Format != "gif" || info.Size != 16 || info.Animated != (frames > 1) {
+			t.Fatalf("%d帧GIF信息不正确: %+v, %v", frames, info, err)
+		}
+	}
+
+	// 带动画和透明度标志的WebP
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x12\x00\x00\x00\x3f\x01\x00\xef\x00\x00")
+	info, err = imageutil.Inspect(bytes.NewReader(webp))
+	if err != nil || info.Format != "webp" || info.Width != 320 || info.Height != 240 || !info.Animated || info.ColorModel != color.NRGBAModel {
+		t.Fatalf("WebP信息不正确: %+v, %v", info, err)
+	}
+
+	if _, err := imageutil.Inspect(strings.NewReader("不是图片")); err == nil {
+		t.Fatal("无法识别的格式应返回错误")
+	}
+}
+
+// 测试转换为插件文件内容
+func TestToFileContent(t *testing.T) {
+	src := newTestImage()
+	content, err := imageutil.ToFileContent(src, "PNG", "thumb.png")
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(content.Data)
+	if err != nil {
+		t.Fatalf("Base64数据无效: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if content.FileType != plugin.FileTypeImage || content.MimeType != "image/png" || content.Name != "thumb.png" ||
+		content.Width != 3 || content.Height != 2 || content.Size != int64(len(data)) ||
+		content.Checksum != "sha256:"+hex.EncodeToString(sum[:]) {
+		t.Fatalf("文件内容不正确: %+v", content)
+	}
+	if img, err := imageutil.NewLoader().LoadFromBytes(data); err != nil || img.Bounds().Size() != image.Pt(3, 2) {
+		t.Fatalf("文件数据不是有效的图片: %v", err)
+	}
+
+	if content, err := imageutil.ToFileContent(src, "jpg", ""); err != nil || content.MimeType != "image/jpeg" {
+		t.Fatalf("JPEG的MIME类型不正确: %+v, %v", content, err)
+	}
+	if _, err := imageutil.ToFileContent(src, "bmp", ""); !errors.Is(err, imageutil.ErrUnsupportedFormat) {
+		t.Fatalf("格式不支持时应返回ErrUnsupportedFormat，实际%v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Info 不解码像素即可得到的图片信息
+type Info struct {
+	Format     string      // 图片格式，如"jpeg"、"png"、"gif"、"webp"
+	Width      int         // 宽度
+	Height     int         // 高度
+	ColorModel color.Model // 解码后的颜色模型
+	Size       int64       // 解码后位图大约占用的字节数，动图为一帧的大小
+	Animated   bool        // 是否为多帧GIF、APNG或动画WebP
+}
+
+// Inspect 读取图片头获取图片信息，不解码像素
+// 参数：
+//
+//	r - 图片数据，支持已注册的所有格式；WebP即使没有注册解码器也可以识别
+//
+// 返回值：
+//
+//	Info - 图片信息，可以在解码前按尺寸拒绝图片或选择处理方式
+//	error - 格式无法识别或图片头无效时返回错误
+func Inspect(r io.Reader) (Info, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(30); len(head) >= 16 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP" {
+		info, err := inspectWebP(head)
+		if err != nil {
+			return Info{}, fmt.Errorf("读取图片信息失败: %w", err)
+		}
+		return info, nil
+	}
+
+	// 记录读取图片头时消耗的数据，判断是否为动图时从头开始扫描
+	rec := &recordReader{r: br}
+	config, format, err := image.DecodeConfig(rec)
+	if err != nil {
+		return Info{}, fmt.Errorf("读取图片信息失败: %w", err)
+	}
+	info := Info{
+		Format:     format,
+		Width:      config.Width,
+		Height:     config.Height,
+		ColorModel: config.ColorModel,
+		Size:       int64(config.Width) * int64(config.Height) * int64(bytesPerPixel(config.ColorModel)),
+	}
+
+	full := io.MultiReader(bytes.NewReader(rec.buf.Bytes()), br)
+	switch format {
+	case "gif":
+		info.Animated, err = gifAnimated(bufio.NewReader(full))
+	case "png":
+		info.Animated, err = pngAnimated(full)
+	}
+	if err != nil {
+		return Info{}, fmt.Errorf("读取图片信息失败: %w", err)
+	}
+	return info, nil
+}
+
+// bytesPerPixel 返回颜色模型下每个像素大约占用的字节数
+func bytesPerPixel(m color.Model) int {
+	switch m {
+	case color.GrayModel, color.AlphaModel:
+		return 1
+	case color.Gray16Model, color.Alpha16Model:
+		return 2
+	case color.YCbCrModel:
+		// 按不做色度抽样估计
+		return 3
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 8
+	}
+	if _, ok := m.(color.Palette); ok {
+		return 1
+	}
+	return 4
+}
+
+// gifAnimated 扫描GIF的数据块，有多于一帧时返回true
+func gifAnimated(r *bufio.Reader) (bool, error) {
+	var header [13]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return false, unexpectedEOF(err)
+	}
+	if header[10]&0x80 != 0 {
+		if _, err := r.Discard(3 << (header[10]&0x07 + 1)); err != nil {
+			return false, unexpectedEOF(err)
+		}
+	}
+	frames := 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return false, unexpectedEOF(err)
+		}
+		switch b {
+		case 0x21: // 扩展块
+			if _, err := r.ReadByte(); err != nil {
+				return false, unexpectedEOF(err)
+			}
+		case 0x2C: // 图像描述符
+			if frames++; frames > 1 {
+				return true, nil
+			}
+			var desc [9]byte
+			if _, err := io.ReadFull(r, desc[:]); err != nil {
+				return false, unexpectedEOF(err)
+			}
+			n := 1 // LZW最小码长
+			if desc[8]&0x80 != 0 {
+				n += 3 << (desc[8]&0x07 + 1)
+			}
+			if _, err := r.Discard(n); err != nil {
+				return false, unexpectedEOF(err)
+			}
+		case 0x3B: // 结束
+			return false, nil
+		default:
+			return false, fmt.Errorf("GIF数据块类型0x%02X无效", b)
+		}
+		// 跳过数据子块
+		for {
+			size, err := r.ReadByte()
+			if err != nil {
+				return false, unexpectedEOF(err)
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := r.Discard(int(size)); err != nil {
+				return false, unexpectedEOF(err)
+			}
+		}
+	}
+}
+
+// pngAnimated 扫描IDAT之前的块，有acTL块且帧数大于1时为APNG动图
+func pngAnimated(r io.Reader) (bool, error) {
+	if _, err := io.CopyN(io.Discard, r, 8); err != nil {
+		return false, unexpectedEOF(err)
+	}
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return false, unexpectedEOF(err)
+		}
+		length, typ := binary.BigEndian.Uint32(header[:4]), string(header[4:])
+		switch typ {
+		case "IDAT", "IEND":
+			return false, nil
+		case "acTL":
+			var frames [4]byte
+			if _, err := io.ReadFull(r, frames[:]); err != nil {
+				return false, unexpectedEOF(err)
+			}
+			return binary.BigEndian.Uint32(frames[:]) > 1, nil
+		}
+		if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+			return false, unexpectedEOF(err)
+		}
+	}
+}
+
+// inspectWebP 从WebP的第一个块读取尺寸、透明度和动画标志
+func inspectWebP(head []byte) (Info, error) {
+	info := Info{Format: "webp", ColorModel: color.YCbCrModel}
+	chunk := head[12:]
+	switch string(chunk[:4]) {
+	case "VP8X":
+		if len(chunk) < 18 {
+			return Info{}, io.ErrUnexpectedEOF
+		}
+		flags := chunk[8]
+		info.Animated = flags&0x02 != 0
+		if flags&0x10 != 0 {
+			info.ColorModel = color.NRGBAModel
+		}
+		info.Width = int(uint32(chunk[12])|uint32(chunk[13])<<8|uint32(chunk[14])<<16) + 1
+		info.Height = int(uint32(chunk[15])|uint32(chunk[16])<<8|uint32(chunk[17])<<16) + 1
+	case "VP8 ":
+		// 有损格式：3字节帧标记、3字节起始码，之后是14位的宽和高
+		if len(chunk) < 18 {
+			return Info{}, io.ErrUnexpectedEOF
+		}
+		if !bytes.Equal(chunk[11:14], []byte{0x9D, 0x01, 0x2A}) {
+			return Info{}, fmt.Errorf("WebP VP8起始码无效")
+		}
+		info.Width = int(binary.LittleEndian.Uint16(chunk[14:]) & 0x3FFF)
+		info.Height = int(binary.LittleEndian.Uint16(chunk[16:]) & 0x3FFF)
+	case "VP8L":
+		// 无损格式：1字节签名，之后是14位的宽减1和14位的高减1
+		if len(chunk) < 13 {
+			return Info{}, io.ErrUnexpectedEOF
+		}
+		if chunk[8] != 0x2F {
+			return Info{}, fmt.Errorf("WebP VP8L签名无效")
+		}
+		bits := binary.LittleEndian.Uint32(chunk[9:])
+		info.Width = int(bits&0x3FFF) + 1
+		info.Height = int(bits>>14&0x3FFF) + 1
+		info.ColorModel = color.NRGBAModel
+	default:
+		return Info{}, fmt.Errorf("WebP块类型%q无效", chunk[:4])
+	}
+	info.Size = int64(info.Width) * int64(info.Height) * int64(bytesPerPixel(info.ColorModel))
+	return info, nil
+}
@@ -0,0 +1,239 @@
+package image
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidSource 图片来源无效
+var ErrInvalidSource = errors.New("图片来源无效")
+
+// Source 批量处理的图片来源，Path、URL、Reader只需设置一个
+type Source struct {
+	Name   string    // 名称，用于错误信息和输出文件名，为空时取文件名或URL路径的最后一段
+	Path   string    // 本地文件路径
+	URL    string    // 图片URL
+	Reader io.Reader // 图片数据，处理完成后不会被关闭
+}
+
+// FileSource 创建本地文件来源
+func FileSource(filePath string) Source {
+	return Source{Path: filePath}
+}
+
+// URLSource 创建URL来源
+func URLSource(rawURL string) Source {
+	return Source{URL: rawURL}
+}
+
+// ReaderSource 创建io.Reader来源
+func ReaderSource(name string, reader io.Reader) Source {
+	return Source{Name: name, Reader: reader}
+}
+
+// defaultName 返回来源的默认名称，无法从路径或URL得到时使用序号
+func (s Source) defaultName(index int) string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Path != "":
+		return filepath.Base(s.Path)
+	case s.URL != "":
+		if u, err := url.Parse(s.URL); err == nil && strings.Trim(u.Path, "/") != "" {
+			return path.Base(u.Path)
+		}
+	}
+	return strconv.Itoa(index)
+}
+
+// load 使用加载器从来源加载图片，ctx取消后中止读取和解码
+func (s Source) load(ctx context.Context, loader Loader) (image.Image, error) {
+	switch {
+	case s.Path != "":
+		return loader.LoadFromFileContext(ctx, s.Path)
+	case s.URL != "":
+		return loader.LoadFromURLContext(ctx, s.URL)
+	case s.Reader != nil:
+		return loadFromReaderContext(ctx, loader, s.Reader)
+	default:
+		return nil, ErrInvalidSource
+	}
+}
+
+// Operation 批量处理中的一步操作，不应修改传入的图片
+type Operation func(img image.Image) (image.Image, error)
+
+// FilterOperation 将一组滤镜包装为操作
+func FilterOperation(filters ...Filter) Operation {
+	return func(img image.Image) (image.Image, error) {
+		return Apply(img, filters...), nil
+	}
+}
+
+// FitOperation 按比例缩小图片使其不超过指定尺寸，见Fit
+func FitOperation(maxW, maxH int) Operation {
+	return func(img image.Image) (image.Image, error) {
+		return Fit(img, maxW, maxH)
+	}
+}
+
+// Sink 接收处理完成的图片，会被多个协程并发调用
+type Sink func(src Source, img image.Image) error
+
+// DirSink 将图片保存到目录中，文件名为来源名称去掉扩展名后加上format，目录不存在时自动创建
+func DirSink(dir, format string) Sink {
+	return func(src Source, img image.Image) error {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+		name := strings.TrimSuffix(src.Name, filepath.Ext(src.Name))
+		return SaveImage(img, filepath.Join(dir, name+"."+format), format)
+	}
+}
+
+// Progress 批量处理的进度
+type Progress struct {
+	Done   int    // 已完成的数量，包括失败的
+	Failed int    // 失败的数量
+	Total  int    // 总数
+	Source Source // 刚完成的来源
+	Err    error  // 刚完成的来源的错误，成功时为nil
+}
+
+// SourceError 单个来源处理失败的错误
+type SourceError struct {
+	Index  int    // 来源在输入中的序号
+	Source Source // 来源
+	Err    error  // 原始错误
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("处理图片%s失败: %v", e.Source.Name, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// PipelineResult 批量处理的结果
+type PipelineResult struct {
+	Total     int            // 来源总数
+	Succeeded int            // 成功的数量
+	Errors    []*SourceError // 失败的来源，按序号排列；被取消而未处理的来源不在其中
+}
+
+// Pipeline 批量图片处理流水线，按顺序对每个来源执行加载、操作和输出，多个来源并发处理
+type Pipeline struct {
+	Loader     Loader         // 图片加载器，nil表示NewLoader()
+	Workers    int            // 并发数，不大于0时为CPU核数
+	Operations []Operation    // 依次执行的操作
+	Sink       Sink           // 输出，nil表示丢弃处理结果
+	OnProgress func(Progress) // 每个来源完成后调用，调用是串行的
+}
+
+// Run 处理一批图片来源
+// 参数：
+//
+//	ctx - 上下文，取消后不再开始处理新的来源，正在加载的来源会中止并记录为失败
+//	sources - 图片来源
+//
+// 返回值：
+//
+//	PipelineResult - 处理结果，单个来源的失败记录在Errors中，不会中断其它来源
+//	error - 上下文被取消时返回ctx.Err()
+func (p *Pipeline) Run(ctx context.Context, sources []Source) (PipelineResult, error) {
+	loader := p.Loader
+	if loader == nil {
+		loader = NewLoader()
+	}
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(sources))
+
+	result := PipelineResult{Total: len(sources)}
+	errs := make([]*SourceError, len(sources))
+	var (
+		mu     sync.Mutex
+		done   int
+		failed int
+		wg     sync.WaitGroup
+	)
+	indexes := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				src := sources[index]
+				src.Name = src.defaultName(index)
+				err := p.process(ctx, loader, src)
+
+				mu.Lock()
+				done++
+				if err != nil {
+					failed++
+					errs[index] = &SourceError{Index: index, Source: src, Err: err}
+				} else {
+					result.Succeeded++
+				}
+				if p.OnProgress != nil {
+					p.OnProgress(Progress{Done: done, Failed: failed, Total: len(sources), Source: src, Err: err})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range sources {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, e := range errs {
+		if e != nil {
+			result.Errors = append(result.Errors, e)
+		}
+	}
+	return result, ctx.Err()
+}
+
+// process 加载单个来源，执行所有操作并输出
+func (p *Pipeline) process(ctx context.Context, loader Loader, src Source) error {
+	img, err := src.load(ctx, loader)
+	if err != nil {
+		return err
+	}
+	for _, op := range p.Operations {
+		if img, err = op(img); err != nil {
+			return fmt.Errorf("处理图片失败: %w", err)
+		}
+	}
+	if p.Sink != nil {
+		if err := p.Sink(src, img); err != nil {
+			return fmt.Errorf("输出图片失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package image
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/gophertool/tool/plugin"
+)
+
+// ToFileContent 将图片编码为插件调用结果中的文件内容
+// 参数：
+//
+//	img - 图片
+//	format - 编码格式，与SaveImageToWriter相同
+//	name - 文件名，为空时不设置
+//
+// 返回值：
+//
+//	plugin.FileContent - 图片文件内容，已填写Base64数据、MIME类型、宽高、编码后的大小和"sha256:"开头的校验和
+//	error - 格式不支持时返回ErrUnsupportedFormat
+func ToFileContent(img image.Image, format, name string) (plugin.FileContent, error) {
+	var buf bytes.Buffer
+	if err := SaveImageToWriter(img, &buf, format); err != nil {
+		if err == ErrUnsupportedFormat {
+			return plugin.FileContent{}, err
+		}
+		return plugin.FileContent{}, fmt.Errorf("编码图片失败: %w", err)
+	}
+
+	mimeType := "image/png"
+	if f := strings.ToLower(format); f == "jpeg" || f == "jpg" {
+		mimeType = "image/jpeg"
+	}
+	data := buf.Bytes()
+	sum := sha256.Sum256(data)
+	content := plugin.NewImageContent(base64.StdEncoding.EncodeToString(data), mimeType)
+	content.Name = name
+	content.Size = int64(len(data))
+	content.Checksum = "sha256:" + hex.EncodeToString(sum[:])
+	return content.SetImageProperties(img.Bounds().Dx(), img.Bounds().Dy()), nil
+}
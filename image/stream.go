@@ -0,0 +1,256 @@
+package image
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+)
+
+// rowReader 逐行输出NRGBA像素的解码器
+type rowReader interface {
+	// readRow 把下一行的像素写入dst，dst长度为宽度的4倍
+	readRow(dst []uint8) error
+}
+
+// StripDecoder 按条带逐段解码图片，处理超大图片时不需要把整张位图放入内存
+// 非隔行的PNG和基线JPEG（灰度、YCbCr、RGB）边读取边解码，只保存少量行；
+// 其它格式（包括渐进式JPEG和隔行PNG）先完整解码，再按条带输出
+type StripDecoder struct {
+	Width  int    // 图片宽度
+	Height int    // 图片高度
+	Format string // 图片格式，如"png"、"jpeg"
+	// Streaming 是否为边读取边解码，为false时整张图片已经解码到内存中
+	Streaming bool
+
+	rows  rowReader
+	y     int
+	strip *image.NRGBA
+}
+
+// NewStripDecoder 读取图片头并创建条带解码器
+// 参数：
+//
+//	r - 图片数据
+//
+// 返回值：
+//
+//	*StripDecoder - 条带解码器，调用Next获取每个条带
+//	error - 图片头无效或格式不支持时返回错误
+func NewStripDecoder(r io.Reader) (*StripDecoder, error) {
+	// 记录读取图片头时消耗的数据，不支持流式解码时拼接到剩余数据前面完整解码
+	br := bufio.NewReader(r)
+	rec := &recordReader{r: br}
+	magic, _ := br.Peek(8)
+
+	var (
+		d   = &StripDecoder{Streaming: true}
+		err error
+	)
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x89PNG\r\n\x1a\n")):
+		d.Format = "png"
+		d.rows, d.Width, d.Height, err = newPNGRowReader(bufio.NewReader(rec))
+	case bytes.HasPrefix(magic, []byte{0xFF, 0xD8}):
+		d.Format = "jpeg"
+		d.rows, d.Width, d.Height, err = newJPEGRowReader(bufio.NewReader(rec))
+	default:
+		err = errStreamUnsupported
+	}
+	if err == nil {
+		rec.stop()
+		return d, nil
+	}
+	if !errors.Is(err, errStreamUnsupported) {
+		return nil, fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	img, format, err := image.Decode(io.MultiReader(&rec.buf, br))
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败: %w", err)
+	}
+	b := img.Bounds()
+	return &StripDecoder{
+		Width:  b.Dx(),
+		Height: b.Dy(),
+		Format: format,
+		rows:   &imageRowReader{img: img},
+	}, nil
+}
+
+// Next 解码下一个条带
+// 参数：
+//
+//	rows - 条带的最大行数，不大于0时为1
+//
+// 返回值：
+//
+//	*image.NRGBA - 条带，Bounds为其在整张图片中的范围；下一次调用Next时会被覆盖，需要保留时应复制
+//	error - 所有行都已输出时返回io.EOF
+func (d *StripDecoder) Next(rows int) (*image.NRGBA, error) {
+	if d.y >= d.Height {
+		return nil, io.EOF
+	}
+	rows = min(max(rows, 1), d.Height-d.y)
+	stride := 4 * d.Width
+	if d.strip == nil || cap(d.strip.Pix) < rows*stride {
+		d.strip = &image.NRGBA{Pix: make([]uint8, rows*stride), Stride: stride}
+	}
+	d.strip.Pix = d.strip.Pix[:rows*stride]
+	d.strip.Rect = image.Rect(0, d.y, d.Width, d.y+rows)
+	for i := 0; i < rows; i++ {
+		if err := d.rows.readRow(d.strip.Pix[i*stride : (i+1)*stride]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("解码图片第%d行失败: %w", d.y+i, err)
+		}
+	}
+	d.y += rows
+	return d.strip, nil
+}
+
+// recordReader 记录读取过的数据，直到调用stop
+type recordReader struct {
+	r       io.Reader
+	buf     bytes.Buffer
+	stopped bool
+}
+
+func (r *recordReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if !r.stopped {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// stop 停止记录并释放已记录的数据
+func (r *recordReader) stop() {
+	r.stopped = true
+	r.buf = bytes.Buffer{}
+}
+
+// errStreamUnsupported 图片格式或编码方式不支持流式解码
+var errStreamUnsupported = errors.New("不支持流式解码")
+
+// imageRowReader 从已经完整解码的图片逐行输出
+type imageRowReader struct {
+	img image.Image
+	y   int
+}
+
+func (r *imageRowReader) readRow(dst []uint8) error {
+	b := r.img.Bounds()
+	row := &image.NRGBA{Pix: dst, Stride: len(dst), Rect: image.Rect(0, 0, b.Dx(), 1)}
+	draw.Draw(row, row.Rect, r.img, image.Pt(b.Min.X, b.Min.Y+r.y), draw.Src)
+	r.y++
+	return nil
+}
+
+// ResizeStream 流式解码并缩放图片，内存占用只与目标尺寸有关
+// 参数：
+//
+//	r - 图片数据，非隔行PNG和基线JPEG边读取边缩放，其它格式先完整解码
+//	width - 目标宽度
+//	height - 目标高度
+//
+// 返回值：
+//
+//	*image.NRGBA - 缩放后的图片，先按区域平均缩小到不超过目标尺寸的2倍，再使用Catmull-Rom滤波缩放到目标尺寸
+//	error - 目标尺寸不是正数时返回ErrInvalidSize，解码失败时返回错误
+func ResizeStream(r io.Reader, width, height int) (*image.NRGBA, error) {
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidSize
+	}
+	d, err := NewStripDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	return resizeStrips(d, width, height)
+}
+
+// FitStream 流式解码并按比例缩小图片使其不超过指定尺寸，见Fit和ResizeStream
+func FitStream(r io.Reader, maxW, maxH int) (*image.NRGBA, error) {
+	if maxW <= 0 || maxH <= 0 {
+		return nil, ErrInvalidSize
+	}
+	d, err := NewStripDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	w, h, err := fitSize(d.Width, d.Height, maxW, maxH)
+	if err != nil {
+		return nil, err
+	}
+	return resizeStrips(d, w, h)
+}
+
+// resizeStrips 逐条带按区域平均缩小到中间尺寸，再缩放到目标尺寸
+func resizeStrips(d *StripDecoder, width, height int) (*image.NRGBA, error) {
+	sw, sh := d.Width, d.Height
+	if sw <= 0 || sh <= 0 {
+		return nil, ErrInvalidSize
+	}
+	iw, ih := min(sw, 2*width), min(sh, 2*height)
+	mid := image.NewNRGBA(image.Rect(0, 0, iw, ih))
+
+	// 每个原像素累加到按比例对应的中间像素，颜色按alpha预乘
+	xmap := make([]int, sw)
+	counts := make([]uint64, iw)
+	for x := range xmap {
+		xmap[x] = x * iw / sw
+		counts[xmap[x]]++
+	}
+	acc := make([]uint64, 4*iw)
+	rowsInAcc := uint64(0)
+	cur := 0
+	flush := func() {
+		row := mid.Pix[cur*mid.Stride:]
+		for ix := 0; ix < iw; ix++ {
+			a := acc[4*ix+3]
+			if a > 0 {
+				p := row[4*ix:]
+				p[0] = uint8((acc[4*ix] + a/2) / a)
+				p[1] = uint8((acc[4*ix+1] + a/2) / a)
+				p[2] = uint8((acc[4*ix+2] + a/2) / a)
+				n := counts[ix] * rowsInAcc
+				p[3] = uint8((a + n/2) / n)
+			}
+		}
+		clear(acc)
+		rowsInAcc = 0
+	}
+
+	for {
+		strip, err := d.Next(64)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for y := strip.Rect.Min.Y; y < strip.Rect.Max.Y; y++ {
+			if iy := y * ih / sh; iy != cur {
+				flush()
+				cur = iy
+			}
+			row := strip.Pix[strip.PixOffset(0, y):]
+			for x, ix := range xmap {
+				p := row[4*x : 4*x+4]
+				a := uint64(p[3])
+				q := acc[4*ix : 4*ix+4]
+				q[0] += uint64(p[0]) * a
+				q[1] += uint64(p[1]) * a
+				q[2] += uint64(p[2]) * a
+				q[3] += a
+			}
+			rowsInAcc++
+		}
+	}
+	flush()
+	return Resize(mid, width, height)
+}
@@ -0,0 +1,563 @@
+package image
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// jpegZigzag 之字形顺序到自然顺序的映射
+var jpegZigzag = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// jpegIDCTTable 一维IDCT的系数，jpegIDCTTable[x][u] = C(u)/2 * cos((2x+1)uπ/16)
+var jpegIDCTTable = func() (t [8][8]float64) {
+	for x := range t {
+		for u := range t[x] {
+			c := 0.5
+			if u == 0 {
+				c = 0.5 / math.Sqrt2
+			}
+			t[x][u] = c * math.Cos(float64(2*x+1)*float64(u)*math.Pi/16)
+		}
+	}
+	return t
+}()
+
+// jpegComponent JPEG的一个颜色分量
+type jpegComponent struct {
+	id     byte
+	h, v   int // 采样因子
+	tq     int // 量化表
+	td, ta int // DC和AC哈夫曼表
+	pred   int32
+	plane  []uint8 // 一个MCU行的采样值
+	stride int
+	xmap   []int // 像素列对应的采样列
+}
+
+// jpegRowReader 逐个MCU行解码基线JPEG，只保存一个MCU行的采样值
+type jpegRowReader struct {
+	bits       jpegBits
+	width      int
+	comps      []jpegComponent
+	quant      [4][64]int32
+	dc, ac     [4]*jpegHuffman
+	rgb        bool
+	hmax, vmax int
+	mcusX      int
+	mcusY      int
+	mcuRow     int // 下一个要解码的MCU行
+	row        int // 当前MCU行中下一个要输出的像素行
+	interval   int // 重启间隔，0表示没有
+	mcusLeft   int // 距离下一个重启标记的MCU数
+	nextRST    byte
+}
+
+// newJPEGRowReader 读取JPEG头直到第一个扫描，返回逐行解码器和图片尺寸；
+// 渐进式、非交错扫描、CMYK等返回errStreamUnsupported
+func newJPEGRowReader(r *bufio.Reader) (rowReader, int, int, error) {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		return nil, 0, 0, err
+	}
+	d := &jpegRowReader{}
+	var (
+		height         int
+		quantSeen      [4]bool
+		adobe          bool
+		adobeTransform byte
+		sofSeen        bool
+	)
+	for {
+		marker, err := readJPEGMarker(r)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		switch {
+		case marker == 0x01 || marker >= 0xD0 && marker <= 0xD8:
+			continue
+		case marker == 0xD9:
+			return nil, 0, 0, errors.New("JPEG缺少图像数据")
+		}
+		var lb [2]byte
+		if _, err := io.ReadFull(r, lb[:]); err != nil {
+			return nil, 0, 0, unexpectedEOF(err)
+		}
+		n := int(binary.BigEndian.Uint16(lb[:])) - 2
+		if n < 0 {
+			return nil, 0, 0, errors.New("JPEG段长度错误")
+		}
+		if marker != 0xC0 && marker != 0xC1 && marker != 0xC4 && marker != 0xDB &&
+			marker != 0xDD && marker != 0xDA && marker != 0xEE {
+			if marker >= 0xC2 && marker <= 0xCF {
+				// 渐进式、无损和算术编码
+				return nil, 0, 0, errStreamUnsupported
+			}
+			if _, err := r.Discard(n); err != nil {
+				return nil, 0, 0, unexpectedEOF(err)
+			}
+			continue
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, 0, 0, unexpectedEOF(err)
+		}
+
+		switch marker {
+		case 0xC0, 0xC1:
+			if len(data) < 6 || data[0] != 8 {
+				return nil, 0, 0, errStreamUnsupported
+			}
+			height, d.width = int(binary.BigEndian.Uint16(data[1:])), int(binary.BigEndian.Uint16(data[3:]))
+			ncomp := int(data[5])
+			if height == 0 || d.width == 0 {
+				return nil, 0, 0, errStreamUnsupported
+			}
+			if ncomp != 1 && ncomp != 3 {
+				return nil, 0, 0, errStreamUnsupported
+			}
+			if len(data) != 6+3*ncomp {
+				return nil, 0, 0, errors.New("JPEG SOF段长度错误")
+			}
+			d.comps = make([]jpegComponent, ncomp)
+			for i := range d.comps {
+				c := &d.comps[i]
+				p := data[6+3*i:]
+				c.id, c.h, c.v, c.tq = p[0], int(p[1]>>4), int(p[1]&0x0F), int(p[2])
+				if c.h < 1 || c.h > 4 || c.v < 1 || c.v > 4 || c.tq > 3 {
+					return nil, 0, 0, errors.New("JPEG采样因子或量化表错误")
+				}
+				if ncomp == 1 {
+					// 单分量图片的扫描不交错，每个MCU只有一个块
+					c.h, c.v = 1, 1
+				}
+			}
+			sofSeen = true
+		case 0xC4:
+			for len(data) > 0 {
+				if len(data) < 17 {
+					return nil, 0, 0, errors.New("JPEG哈夫曼表长度错误")
+				}
+				class, id := data[0]>>4, data[0]&0x0F
+				if class > 1 || id > 3 {
+					return nil, 0, 0, errors.New("JPEG哈夫曼表编号错误")
+				}
+				total := 0
+				for _, c := range data[1:17] {
+					total += int(c)
+				}
+				if len(data) < 17+total {
+					return nil, 0, 0, errors.New("JPEG哈夫曼表长度错误")
+				}
+				h, err := newJPEGHuffman(data[1:17], data[17:17+total])
+				if err != nil {
+					return nil, 0, 0, err
+				}
+				if class == 0 {
+					d.dc[id] = h
+				} else {
+					d.ac[id] = h
+				}
+				data = data[17+total:]
+			}
+		case 0xDB:
+			for len(data) > 0 {
+				pq, tq := data[0]>>4, data[0]&0x0F
+				size := 64 * (1 + int(pq))
+				if pq > 1 || tq > 3 || len(data) < 1+size {
+					return nil, 0, 0, errors.New("JPEG量化表错误")
+				}
+				for i := range d.quant[tq] {
+					if pq == 0 {
+						d.quant[tq][i] = int32(data[1+i])
+					} else {
+						d.quant[tq][i] = int32(binary.BigEndian.Uint16(data[1+2*i:]))
+					}
+				}
+				quantSeen[tq] = true
+				data = data[1+size:]
+			}
+		case 0xDD:
+			if len(data) != 2 {
+				return nil, 0, 0, errors.New("JPEG DRI段长度错误")
+			}
+			d.interval = int(binary.BigEndian.Uint16(data))
+		case 0xEE:
+			if len(data) >= 12 && string(data[:5]) == "Adobe" {
+				adobe, adobeTransform = true, data[11]
+			}
+		case 0xDA:
+			if !sofSeen {
+				return nil, 0, 0, errors.New("JPEG缺少SOF段")
+			}
+			if len(data) < 1 || int(data[0]) != len(d.comps) || len(data) != 4+2*len(d.comps) {
+				// 每个分量单独扫描的顺序JPEG
+				return nil, 0, 0, errStreamUnsupported
+			}
+			for i := 0; i < len(d.comps); i++ {
+				id, tables := data[1+2*i], data[2+2*i]
+				c := &d.comps[i]
+				if c.id != id {
+					return nil, 0, 0, errStreamUnsupported
+				}
+				c.td, c.ta = int(tables>>4), int(tables&0x0F)
+				if c.td > 3 || c.ta > 3 || d.dc[c.td] == nil || d.ac[c.ta] == nil || !quantSeen[c.tq] {
+					return nil, 0, 0, errors.New("JPEG缺少哈夫曼表或量化表")
+				}
+			}
+			if len(d.comps) == 3 {
+				d.rgb = adobe && adobeTransform == 0 ||
+					!adobe && d.comps[0].id == 'R' && d.comps[1].id == 'G' && d.comps[2].id == 'B'
+			}
+			d.bits.r = r
+			d.init(height)
+			return d, d.width, height, nil
+		}
+	}
+}
+
+// readJPEGMarker 读取下一个段标记，跳过标记前的填充字节
+func readJPEGMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	if b != 0xFF {
+		return 0, errors.New("JPEG段标记错误")
+	}
+	for b == 0xFF {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+	}
+	return b, nil
+}
+
+// init 计算MCU布局并分配采样缓冲区
+func (d *jpegRowReader) init(height int) {
+	for _, c := range d.comps {
+		d.hmax, d.vmax = max(d.hmax, c.h), max(d.vmax, c.v)
+	}
+	d.mcusX = (d.width + 8*d.hmax - 1) / (8 * d.hmax)
+	d.mcusY = (height + 8*d.vmax - 1) / (8 * d.vmax)
+	for i := range d.comps {
+		c := &d.comps[i]
+		c.stride = d.mcusX * c.h * 8
+		c.plane = make([]uint8, c.stride*c.v*8)
+		c.xmap = make([]int, d.width)
+		for x := range c.xmap {
+			c.xmap[x] = x * c.h / d.hmax
+		}
+	}
+	d.row = 8 * d.vmax
+	d.mcusLeft = d.interval
+}
+
+func (d *jpegRowReader) readRow(dst []uint8) error {
+	if d.row == 8*d.vmax {
+		if d.mcuRow >= d.mcusY {
+			return io.EOF
+		}
+		if err := d.decodeMCURow(); err != nil {
+			return err
+		}
+		d.row = 0
+	}
+
+	// 色度分量按最近邻上采样
+	var rows [3][]uint8
+	for i := range d.comps {
+		c := &d.comps[i]
+		rows[i] = c.plane[d.row*c.v/d.vmax*c.stride:]
+	}
+	for x := 0; x < d.width; x++ {
+		p := dst[4*x : 4*x+4]
+		p[3] = 0xFF
+		if len(d.comps) == 1 {
+			p[0], p[1], p[2] = rows[0][x], rows[0][x], rows[0][x]
+			continue
+		}
+		y, cb, cr := rows[0][d.comps[0].xmap[x]], rows[1][d.comps[1].xmap[x]], rows[2][d.comps[2].xmap[x]]
+		if d.rgb {
+			p[0], p[1], p[2] = y, cb, cr
+		} else {
+			p[0], p[1], p[2] = color.YCbCrToRGB(y, cb, cr)
+		}
+	}
+	d.row++
+	return nil
+}
+
+// decodeMCURow 解码一个MCU行到各分量的采样缓冲区
+func (d *jpegRowReader) decodeMCURow() error {
+	var blk [64]int32
+	for mx := 0; mx < d.mcusX; mx++ {
+		for i := range d.comps {
+			c := &d.comps[i]
+			for by := 0; by < c.v; by++ {
+				for bx := 0; bx < c.h; bx++ {
+					if err := d.decodeBlock(c, &blk); err != nil {
+						return err
+					}
+					jpegIDCT(&blk, c.plane[by*8*c.stride+(mx*c.h+bx)*8:], c.stride)
+				}
+			}
+		}
+
+		if d.interval > 0 {
+			d.mcusLeft--
+			last := d.mcuRow == d.mcusY-1 && mx == d.mcusX-1
+			if d.mcusLeft == 0 && !last {
+				if err := d.restart(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	d.mcuRow++
+	return nil
+}
+
+// decodeBlock 解码一个8x8块的系数并反量化，结果按自然顺序排列
+func (d *jpegRowReader) decodeBlock(c *jpegComponent, blk *[64]int32) error {
+	*blk = [64]int32{}
+	q := &d.quant[c.tq]
+
+	t, err := d.bits.decode(d.dc[c.td])
+	if err != nil {
+		return err
+	}
+	if t > 16 {
+		return errors.New("JPEG DC系数错误")
+	}
+	if t > 0 {
+		v, err := d.bits.receive(int(t))
+		if err != nil {
+			return err
+		}
+		c.pred += v
+	}
+	blk[0] = c.pred * q[0]
+
+	for k := 1; k < 64; {
+		rs, err := d.bits.decode(d.ac[c.ta])
+		if err != nil {
+			return err
+		}
+		run, size := int(rs>>4), int(rs&0x0F)
+		if size == 0 {
+			if run != 15 {
+				break // 块结束
+			}
+			k += 16
+			continue
+		}
+		k += run
+		if k > 63 {
+			return errors.New("JPEG AC系数越界")
+		}
+		v, err := d.bits.receive(size)
+		if err != nil {
+			return err
+		}
+		blk[jpegZigzag[k]] = v * q[k]
+		k++
+	}
+	return nil
+}
+
+// restart 读取重启标记，重置位缓冲区和DC预测值
+func (d *jpegRowReader) restart() error {
+	b := &d.bits
+	marker := b.marker
+	for marker == 0 {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if c != 0xFF {
+			continue
+		}
+		for c == 0xFF {
+			if c, err = b.r.ReadByte(); err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+		marker = c
+	}
+	if marker != 0xD0+d.nextRST {
+		return fmt.Errorf("JPEG重启标记错误: 0x%02X", marker)
+	}
+	b.acc, b.n, b.marker = 0, 0, 0
+	for i := range d.comps {
+		d.comps[i].pred = 0
+	}
+	d.nextRST = (d.nextRST + 1) & 7
+	d.mcusLeft = d.interval
+	return nil
+}
+
+// jpegIDCT 对反量化后的系数做二维IDCT，加上128后写入dst
+func jpegIDCT(blk *[64]int32, dst []uint8, stride int) {
+	var tmp [64]float64
+	for v := 0; v < 8; v++ {
+		row := blk[8*v : 8*v+8]
+		if [8]int32(row) == [8]int32{} {
+			continue
+		}
+		for x := 0; x < 8; x++ {
+			var s float64
+			for u, f := range row {
+				if f != 0 {
+					s += jpegIDCTTable[x][u] * float64(f)
+				}
+			}
+			tmp[8*v+x] = s
+		}
+	}
+	for y := 0; y < 8; y++ {
+		out := dst[y*stride : y*stride+8]
+		for x := range out {
+			var s float64
+			for v := 0; v < 8; v++ {
+				s += jpegIDCTTable[y][v] * tmp[8*v+x]
+			}
+			out[x] = clampUint8(s + 128)
+		}
+	}
+}
+
+// jpegHuffmanLUTBits 哈夫曼查找表覆盖的码长
+const jpegHuffmanLUTBits = 9
+
+// jpegHuffman JPEG哈夫曼表，短码查表，长码按码长逐个比较
+type jpegHuffman struct {
+	lut     [1 << jpegHuffmanLUTBits]uint16 // 高8位为码长，低8位为符号，0表示不在表中
+	maxCode [17]int32                       // 每种码长的最大码，没有该码长时为-1
+	offset  [17]int32                       // 码值加上offset为符号的下标
+	vals    []byte
+}
+
+// newJPEGHuffman 根据每种码长的数量和符号创建哈夫曼表
+func newJPEGHuffman(counts, vals []byte) (*jpegHuffman, error) {
+	if len(vals) > 256 {
+		return nil, errors.New("JPEG哈夫曼表符号过多")
+	}
+	h := &jpegHuffman{vals: vals}
+	code, k := int32(0), int32(0)
+	for l := 1; l <= 16; l++ {
+		n := int32(counts[l-1])
+		h.maxCode[l] = -1
+		if n > 0 {
+			h.maxCode[l] = code + n - 1
+			h.offset[l] = k - code
+		}
+		if code+n > 1<<l {
+			return nil, errors.New("JPEG哈夫曼表错误")
+		}
+		if l <= jpegHuffmanLUTBits {
+			for i := int32(0); i < n; i++ {
+				entry := uint16(l)<<8 | uint16(vals[k+i])
+				start := (code + i) << (jpegHuffmanLUTBits - l)
+				for j := int32(0); j < 1<<(jpegHuffmanLUTBits-l); j++ {
+					h.lut[start+j] = entry
+				}
+			}
+		}
+		code, k = (code+n)<<1, k+n
+	}
+	return h, nil
+}
+
+// jpegBits 熵编码数据的位读取器，处理0xFF00填充，遇到标记后补0
+type jpegBits struct {
+	r      *bufio.Reader
+	acc    uint32 // 高位对齐的未读位
+	n      uint   // acc中的有效位数
+	marker byte   // 遇到的标记，0表示还没有遇到
+}
+
+// fill 填充acc直到至少有25位
+func (b *jpegBits) fill() error {
+	for b.n <= 24 {
+		var c byte
+		if b.marker == 0 {
+			v, err := b.r.ReadByte()
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if v == 0xFF {
+				m, err := b.r.ReadByte()
+				for err == nil && m == 0xFF {
+					m, err = b.r.ReadByte()
+				}
+				if err != nil {
+					return unexpectedEOF(err)
+				}
+				if m == 0 {
+					c = 0xFF
+				} else {
+					b.marker = m
+				}
+			} else {
+				c = v
+			}
+		}
+		b.acc |= uint32(c) << (24 - b.n)
+		b.n += 8
+	}
+	return nil
+}
+
+// decode 读取一个哈夫曼编码的符号
+func (b *jpegBits) decode(h *jpegHuffman) (byte, error) {
+	if b.n < 16 {
+		if err := b.fill(); err != nil {
+			return 0, err
+		}
+	}
+	if e := h.lut[b.acc>>(32-jpegHuffmanLUTBits)]; e != 0 {
+		l := uint(e >> 8)
+		b.acc <<= l
+		b.n -= l
+		return byte(e), nil
+	}
+	for l := jpegHuffmanLUTBits + 1; l <= 16; l++ {
+		code := int32(b.acc >> (32 - l))
+		if code <= h.maxCode[l] {
+			b.acc <<= uint(l)
+			b.n -= uint(l)
+			return h.vals[code+h.offset[l]], nil
+		}
+	}
+	return 0, errors.New("JPEG哈夫曼编码错误")
+}
+
+// receive 读取size位并按JPEG规则扩展为有符号数
+func (b *jpegBits) receive(size int) (int32, error) {
+	if b.n < uint(size) {
+		if err := b.fill(); err != nil {
+			return 0, err
+		}
+	}
+	v := int32(b.acc >> (32 - uint(size)))
+	b.acc <<= uint(size)
+	b.n -= uint(size)
+	if v < 1<<(size-1) {
+		v += -1<<size + 1
+	}
+	return v, nil
+}
@@ -0,0 +1,371 @@
+package image
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"image/color"
+	"io"
+)
+
+// pngRowReader 逐行解码非隔行PNG，只保存当前行和上一行
+type pngRowReader struct {
+	z         io.Reader
+	idat      *pngIDATReader
+	rows      int // 还没有读取的行数
+	width     int
+	depth     int
+	colorType int
+	bpp       int // 滤波时一个像素的字节数，不足1字节时为1
+	cur, prev []byte
+	palette   []color.NRGBA
+	trns      []uint16 // 灰度或RGB图片的透明色
+}
+
+// newPNGRowReader 读取PNG头和IDAT之前的块，返回逐行解码器和图片尺寸，隔行PNG返回errStreamUnsupported
+func newPNGRowReader(r *bufio.Reader) (rowReader, int, int, error) {
+	var sig [8]byte
+	if _, err := io.ReadFull(r, sig[:]); err != nil {
+		return nil, 0, 0, err
+	}
+	d := &pngRowReader{}
+	var height int
+	for seenIHDR := false; ; {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, 0, 0, unexpectedEOF(err)
+		}
+		length, typ := binary.BigEndian.Uint32(header[:4]), string(header[4:])
+		if length > 0x7fffffff {
+			return nil, 0, 0, errors.New("PNG块长度错误")
+		}
+		if !seenIHDR && typ != "IHDR" {
+			return nil, 0, 0, errors.New("PNG缺少IHDR块")
+		}
+
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		if typ == "IDAT" {
+			d.idat = &pngIDATReader{r: r, remaining: length, crc: crc}
+			z, err := zlib.NewReader(d.idat)
+			if err != nil {
+				return nil, 0, 0, fmt.Errorf("PNG数据错误: %w", err)
+			}
+			d.z = z
+			break
+		}
+		if typ != "IHDR" && typ != "PLTE" && typ != "tRNS" {
+			if typ == "IEND" {
+				return nil, 0, 0, errors.New("PNG缺少IDAT块")
+			}
+			// 其它块只校验CRC后跳过
+			if _, err := io.CopyN(crc, r, int64(length)); err != nil {
+				return nil, 0, 0, unexpectedEOF(err)
+			}
+			if err := checkPNGCRC(r, crc); err != nil {
+				return nil, 0, 0, err
+			}
+			continue
+		}
+
+		if length > 3*256 {
+			return nil, 0, 0, fmt.Errorf("PNG %s块长度错误", typ)
+		}
+		data := make([]byte, length)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, 0, 0, unexpectedEOF(err)
+		}
+		crc.Write(data)
+		if err := checkPNGCRC(r, crc); err != nil {
+			return nil, 0, 0, err
+		}
+		switch typ {
+		case "IHDR":
+			if len(data) != 13 {
+				return nil, 0, 0, errors.New("PNG IHDR块长度错误")
+			}
+			w, h := binary.BigEndian.Uint32(data[0:4]), binary.BigEndian.Uint32(data[4:8])
+			if w == 0 || h == 0 || w > 0x7fffffff || h > 0x7fffffff {
+				return nil, 0, 0, errors.New("PNG尺寸错误")
+			}
+			if data[10] != 0 || data[11] != 0 {
+				return nil, 0, 0, errors.New("PNG压缩或滤波方法错误")
+			}
+			if data[12] != 0 {
+				return nil, 0, 0, errStreamUnsupported
+			}
+			d.width, height = int(w), int(h)
+			d.depth, d.colorType = int(data[8]), int(data[9])
+			if err := d.init(); err != nil {
+				return nil, 0, 0, err
+			}
+			seenIHDR = true
+		case "PLTE":
+			if len(data)%3 != 0 || len(data) == 0 {
+				return nil, 0, 0, errors.New("PNG调色板长度错误")
+			}
+			d.palette = make([]color.NRGBA, len(data)/3)
+			for i := range d.palette {
+				d.palette[i] = color.NRGBA{data[3*i], data[3*i+1], data[3*i+2], 0xFF}
+			}
+		case "tRNS":
+			switch d.colorType {
+			case 3:
+				for i, a := range data {
+					if i < len(d.palette) {
+						d.palette[i].A = a
+					}
+				}
+			case 0, 2:
+				if len(data) != 2*pngChannels(d.colorType) {
+					return nil, 0, 0, errors.New("PNG tRNS块长度错误")
+				}
+				d.trns = make([]uint16, len(data)/2)
+				for i := range d.trns {
+					d.trns[i] = binary.BigEndian.Uint16(data[2*i:])
+				}
+			}
+		}
+	}
+	if d.colorType == 3 && d.palette == nil {
+		return nil, 0, 0, errors.New("PNG缺少调色板")
+	}
+	d.rows = height
+	return d, d.width, height, nil
+}
+
+// init 检查位深度和颜色类型并分配行缓冲区
+func (d *pngRowReader) init() error {
+	valid := false
+	switch d.colorType {
+	case 0:
+		valid = d.depth == 1 || d.depth == 2 || d.depth == 4 || d.depth == 8 || d.depth == 16
+	case 3:
+		valid = d.depth == 1 || d.depth == 2 || d.depth == 4 || d.depth == 8
+	case 2, 4, 6:
+		valid = d.depth == 8 || d.depth == 16
+	}
+	if !valid {
+		return fmt.Errorf("PNG位深度%d和颜色类型%d的组合无效", d.depth, d.colorType)
+	}
+	bits := d.depth * pngChannels(d.colorType)
+	rowBytes := (d.width*bits + 7) / 8
+	d.bpp = max(1, bits/8)
+	d.cur, d.prev = make([]byte, rowBytes), make([]byte, rowBytes)
+	return nil
+}
+
+// pngChannels 返回颜色类型的通道数
+func pngChannels(colorType int) int {
+	switch colorType {
+	case 2:
+		return 3
+	case 4:
+		return 2
+	case 6:
+		return 4
+	default:
+		return 1
+	}
+}
+
+func (d *pngRowReader) readRow(dst []uint8) error {
+	d.cur, d.prev = d.prev, d.cur
+	var filter [1]byte
+	if _, err := io.ReadFull(d.z, filter[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	if _, err := io.ReadFull(d.z, d.cur); err != nil {
+		return unexpectedEOF(err)
+	}
+	if err := unfilterPNG(filter[0], d.cur, d.prev, d.bpp); err != nil {
+		return err
+	}
+	if d.rows--; d.rows == 0 {
+		// 最后一行之后读完压缩数据和IDAT块，校验zlib的Adler-32和最后一个IDAT块的CRC
+		if _, err := io.Copy(io.Discard, d.z); err != nil {
+			return fmt.Errorf("PNG数据错误: %w", err)
+		}
+		if _, err := io.Copy(io.Discard, d.idat); err != nil {
+			return err
+		}
+	}
+
+	cur := d.cur
+	switch {
+	case d.colorType == 0 || d.colorType == 3:
+		mask := 1<<d.depth - 1
+		for x := 0; x < d.width; x++ {
+			var v int
+			switch d.depth {
+			case 16:
+				v = int(binary.BigEndian.Uint16(cur[2*x:]))
+			case 8:
+				v = int(cur[x])
+			default:
+				bit := x * d.depth
+				v = int(cur[bit/8]>>(8-d.depth-bit%8)) & mask
+			}
+			p := dst[4*x : 4*x+4]
+			if d.colorType == 3 {
+				if v >= len(d.palette) {
+					return errors.New("PNG调色板索引越界")
+				}
+				c := d.palette[v]
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+				continue
+			}
+			g := uint8(v * 255 / mask)
+			if d.depth == 16 {
+				g = uint8(v >> 8)
+			}
+			p[0], p[1], p[2], p[3] = g, g, g, 0xFF
+			if d.trns != nil && uint16(v) == d.trns[0] {
+				p[3] = 0
+			}
+		}
+	case d.depth == 8:
+		for x := 0; x < d.width; x++ {
+			p := dst[4*x : 4*x+4]
+			switch d.colorType {
+			case 2:
+				s := cur[3*x : 3*x+3]
+				p[0], p[1], p[2], p[3] = s[0], s[1], s[2], 0xFF
+				if d.trns != nil && uint16(s[0]) == d.trns[0] && uint16(s[1]) == d.trns[1] && uint16(s[2]) == d.trns[2] {
+					p[3] = 0
+				}
+			case 4:
+				p[0], p[1], p[2], p[3] = cur[2*x], cur[2*x], cur[2*x], cur[2*x+1]
+			default:
+				copy(p, cur[4*x:4*x+4])
+			}
+		}
+	default:
+		// 16位只保留高8位
+		channels := pngChannels(d.colorType)
+		for x := 0; x < d.width; x++ {
+			s := cur[2*channels*x:]
+			p := dst[4*x : 4*x+4]
+			switch d.colorType {
+			case 2:
+				p[0], p[1], p[2], p[3] = s[0], s[2], s[4], 0xFF
+				if d.trns != nil && binary.BigEndian.Uint16(s) == d.trns[0] &&
+					binary.BigEndian.Uint16(s[2:]) == d.trns[1] && binary.BigEndian.Uint16(s[4:]) == d.trns[2] {
+					p[3] = 0
+				}
+			case 4:
+				p[0], p[1], p[2], p[3] = s[0], s[0], s[0], s[2]
+			default:
+				p[0], p[1], p[2], p[3] = s[0], s[2], s[4], s[6]
+			}
+		}
+	}
+	return nil
+}
+
+// unfilterPNG 按滤波类型还原一行数据
+func unfilterPNG(filter byte, cur, prev []byte, bpp int) error {
+	switch filter {
+	case 0:
+	case 1:
+		for i := bpp; i < len(cur); i++ {
+			cur[i] += cur[i-bpp]
+		}
+	case 2:
+		for i := range cur {
+			cur[i] += prev[i]
+		}
+	case 3:
+		for i := range cur {
+			left := 0
+			if i >= bpp {
+				left = int(cur[i-bpp])
+			}
+			cur[i] += uint8((left + int(prev[i])) / 2)
+		}
+	case 4:
+		for i := range cur {
+			var a, c int
+			if i >= bpp {
+				a, c = int(cur[i-bpp]), int(prev[i-bpp])
+			}
+			b := int(prev[i])
+			pa, pb, pc := abs(b-c), abs(a-c), abs(a+b-2*c)
+			switch {
+			case pa <= pb && pa <= pc:
+				cur[i] += uint8(a)
+			case pb <= pc:
+				cur[i] += uint8(b)
+			default:
+				cur[i] += uint8(c)
+			}
+		}
+	default:
+		return fmt.Errorf("PNG滤波类型%d无效", filter)
+	}
+	return nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// pngIDATReader 依次读取连续的IDAT块中的数据并校验CRC
+type pngIDATReader struct {
+	r         io.Reader
+	remaining uint32
+	crc       hash.Hash32
+}
+
+func (d *pngIDATReader) Read(p []byte) (int, error) {
+	for d.remaining == 0 {
+		if d.crc == nil {
+			return 0, io.EOF
+		}
+		if err := checkPNGCRC(d.r, d.crc); err != nil {
+			return 0, err
+		}
+		var header [8]byte
+		if _, err := io.ReadFull(d.r, header[:]); err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		if string(header[4:]) != "IDAT" {
+			d.crc = nil
+			return 0, io.EOF
+		}
+		d.remaining = binary.BigEndian.Uint32(header[:4])
+		d.crc.Reset()
+		d.crc.Write(header[4:])
+	}
+	n, err := d.r.Read(p[:min(uint32(len(p)), d.remaining)])
+	d.remaining -= uint32(n)
+	d.crc.Write(p[:n])
+	return n, unexpectedEOF(err)
+}
+
+// checkPNGCRC 读取块末尾的CRC并与计算结果比较
+func checkPNGCRC(r io.Reader, crc hash.Hash32) error {
+	var sum [4]byte
+	if _, err := io.ReadFull(r, sum[:]); err != nil {
+		return unexpectedEOF(err)
+	}
+	if binary.BigEndian.Uint32(sum[:]) != crc.Sum32() {
+		return errors.New("PNG块CRC校验失败")
+	}
+	return nil
+}
+
+// unexpectedEOF 把数据中途结束时的io.EOF变为io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,1255 @@
+package image
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSVG SVG图片无效
+var ErrInvalidSVG = errors.New("无效的SVG图片")
+
+// svgMaxPixels LoadSVG默认允许的最大输出像素数，8192×8192
+const svgMaxPixels = 8192 * 8192
+
+// LoadSVG 将SVG矢量图光栅化为图片
+//
+// 支持图标和示意图中常见的子集：path、rect、circle、ellipse、line、polyline、polygon和g元素，
+// transform、viewBox和preserveAspectRatio，纯色的fill和stroke（包括透明度、fill-rule和stroke-linecap），
+// 以及style属性中的同名声明。渐变、文本、滤镜、use引用、虚线和<style>样式表会被忽略；描边的拐角统一按圆角绘制。
+// 输出最多8192×8192像素，需要其它限制时使用DefaultLoader.LoadSVG。
+// 参数：
+//
+//	r - SVG数据
+//	width - 输出宽度，0表示根据高度按比例计算，都为0时使用SVG自身的尺寸
+//	height - 输出高度，0表示根据宽度按比例计算
+//
+// 返回值：
+//
+//	image.Image - 光栅化后的*image.RGBA，未绘制的区域透明
+//	error - 数据不是有效的SVG、无法确定尺寸或尺寸不是有限的正数时返回ErrInvalidSVG，
+//	输出尺寸超过限制时返回ErrImageTooLarge，width或height为负数时返回ErrInvalidSize
+func LoadSVG(r io.Reader, width, height int) (image.Image, error) {
+	return loadSVG(r, width, height, checkSVGSize)
+}
+
+// LoadSVG 按加载器的大小限制光栅化SVG，见包函数LoadSVG
+// MaxBytes限制读取的数据，MaxWidth、MaxHeight和MaxPixels限制输出尺寸，MaxPixels为0时使用LoadSVG的默认限制
+func (l *DefaultLoader) LoadSVG(r io.Reader, width, height int) (image.Image, error) {
+	var limited *limitReader
+	if l.opts.MaxBytes > 0 {
+		limited = &limitReader{r: r, remaining: l.opts.MaxBytes, max: l.opts.MaxBytes}
+		r = limited
+	}
+	img, err := loadSVG(r, width, height, func(w, h int) error {
+		if err := l.checkSize(w, h); err != nil || l.opts.MaxPixels > 0 {
+			return err
+		}
+		return checkSVGSize(w, h)
+	})
+	if err != nil && limited != nil && limited.exceeded {
+		return nil, fmt.Errorf("%w: 数据超过%d字节", ErrImageTooLarge, limited.max)
+	}
+	return img, err
+}
+
+// checkSVGSize 检查输出尺寸是否超过LoadSVG的默认限制
+func checkSVGSize(width, height int) error {
+	if pixels := int64(width) * int64(height); pixels > svgMaxPixels {
+		return fmt.Errorf("%w: 像素数%d超过限制%d", ErrImageTooLarge, pixels, svgMaxPixels)
+	}
+	return nil
+}
+
+// loadSVG 光栅化SVG，check在分配画布之前检查输出尺寸
+func loadSVG(r io.Reader, width, height int, check func(width, height int) error) (image.Image, error) {
+	if width < 0 || height < 0 {
+		return nil, ErrInvalidSize
+	}
+
+	dec := xml.NewDecoder(r)
+	dec.Entity = xml.HTMLEntity
+	var (
+		canvas *svgCanvas
+		stack  []svgFrame
+	)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSVG, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			attrs := make(map[string]string, len(t.Attr))
+			for _, a := range t.Attr {
+				attrs[a.Name.Local] = a.Value
+			}
+			if canvas == nil {
+				if t.Name.Local != "svg" {
+					return nil, fmt.Errorf("%w: 根元素不是svg", ErrInvalidSVG)
+				}
+				if canvas, err = newSVGCanvas(attrs, width, height, check); err != nil {
+					return nil, err
+				}
+				frame := svgFrame{style: defaultSVGStyle(), ctm: canvas.viewport, alpha: 1, ref: canvas.ref}
+				frame.apply(attrs)
+				stack = append(stack, frame)
+				continue
+			}
+
+			frame := stack[len(stack)-1]
+			if frame.skip {
+				stack = append(stack, frame)
+				continue
+			}
+			frame.apply(attrs)
+			switch t.Name.Local {
+			case "g", "a", "switch", "svg":
+			case "path", "rect", "circle", "ellipse", "line", "polyline", "polygon":
+				if !frame.skip {
+					canvas.draw(t.Name.Local, attrs, frame)
+				}
+				frame.skip = true
+			default:
+				frame.skip = true
+			}
+			stack = append(stack, frame)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if canvas == nil {
+		return nil, fmt.Errorf("%w: 缺少svg元素", ErrInvalidSVG)
+	}
+	return canvas.img, nil
+}
+
+// svgPt 二维坐标
+type svgPt struct{ x, y float64 }
+
+// svgMatrix 仿射变换矩阵[a b c d e f]，x' = a*x + c*y + e，y' = b*x + d*y + f
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+// mul 返回先应用n再应用m的变换
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1], m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3], m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4], m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(p svgPt) svgPt {
+	return svgPt{m[0]*p.x + m[2]*p.y + m[4], m[1]*p.x + m[3]*p.y + m[5]}
+}
+
+// scale 返回变换的近似缩放倍数，用于确定曲线展平的精度
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Max(m[0]*m[0]+m[1]*m[1], m[2]*m[2]+m[3]*m[3]))
+}
+
+// svgPaint fill或stroke的取值
+type svgPaint struct {
+	kind int // svgPaintNone、svgPaintColor或svgPaintCurrent
+	c    color.NRGBA
+}
+
+const (
+	svgPaintNone = iota
+	svgPaintColor
+	svgPaintCurrent
+)
+
+// svgStyle 元素的绘制属性，除opacity外都会被子元素继承
+type svgStyle struct {
+	fill, stroke  svgPaint
+	color         color.NRGBA
+	strokeWidth   float64
+	fillOpacity   float64
+	strokeOpacity float64
+	evenOdd       bool
+	lineCap       string
+	hidden        bool
+}
+
+func defaultSVGStyle() svgStyle {
+	black := color.NRGBA{0, 0, 0, 255}
+	return svgStyle{
+		fill:          svgPaint{kind: svgPaintColor, c: black},
+		color:         black,
+		strokeWidth:   1,
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		lineCap:       "butt",
+	}
+}
+
+// svgFrame 元素栈中的一层
+type svgFrame struct {
+	style svgStyle
+	ctm   svgMatrix // 用户坐标到画布坐标的变换
+	alpha float64   // 累积的opacity
+	ref   svgPt     // 百分比长度参照的视口尺寸
+	skip  bool      // 不绘制该元素及其子元素
+}
+
+// apply 应用元素的transform、展示属性和style属性，style中的声明优先
+func (f *svgFrame) apply(attrs map[string]string) {
+	if v, ok := attrs["transform"]; ok {
+		if m, ok := parseSVGTransform(v); ok {
+			f.ctm = f.ctm.mul(m)
+		}
+	}
+	for name, value := range attrs {
+		f.set(name, value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		if name, value, ok := strings.Cut(decl, ":"); ok {
+			f.set(strings.TrimSpace(name), value)
+		}
+	}
+}
+
+// set 设置一个绘制属性，无法解析的值保持继承的值
+func (f *svgFrame) set(name, value string) {
+	value = strings.TrimSpace(value)
+	s := &f.style
+	switch name {
+	case "fill":
+		if p, ok := parseSVGPaint(value); ok {
+			s.fill = p
+		}
+	case "stroke":
+		if p, ok := parseSVGPaint(value); ok {
+			s.stroke = p
+		}
+	case "color":
+		if c, ok := parseSVGColor(value); ok {
+			s.color = c
+		}
+	case "stroke-width":
+		if w, ok := parseSVGLength(value, math.Hypot(f.ref.x, f.ref.y)/math.Sqrt2); ok && w >= 0 {
+			s.strokeWidth = w
+		}
+	case "fill-opacity":
+		if v, ok := parseSVGOpacity(value); ok {
+			s.fillOpacity = v
+		}
+	case "stroke-opacity":
+		if v, ok := parseSVGOpacity(value); ok {
+			s.strokeOpacity = v
+		}
+	case "opacity":
+		if v, ok := parseSVGOpacity(value); ok {
+			f.alpha *= v
+		}
+	case "fill-rule":
+		s.evenOdd = value == "evenodd"
+	case "stroke-linecap":
+		if value == "butt" || value == "round" || value == "square" {
+			s.lineCap = value
+		}
+	case "visibility":
+		s.hidden = value == "hidden" || value == "collapse"
+	case "display":
+		if value == "none" {
+			f.skip = true
+		}
+	}
+}
+
+// svgCanvas 光栅化的目标画布
+type svgCanvas struct {
+	img      *image.RGBA
+	viewport svgMatrix // viewBox到画布的变换
+	ref      svgPt     // viewBox的尺寸
+}
+
+// newSVGCanvas 根据svg根元素的尺寸和viewBox创建画布，check在分配画布之前检查输出尺寸
+func newSVGCanvas(attrs map[string]string, width, height int, check func(width, height int) error) (*svgCanvas, error) {
+	// 只使用绝对长度的width和height，百分比由输出尺寸决定
+	iw, err := parseSVGDimension("width", attrs["width"])
+	if err != nil {
+		return nil, err
+	}
+	ih, err := parseSVGDimension("height", attrs["height"])
+	if err != nil {
+		return nil, err
+	}
+
+	var vb [4]float64
+	nums := parseSVGNumbers(attrs["viewBox"])
+	hasViewBox := len(nums) == 4
+	if hasViewBox {
+		for _, n := range nums {
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				return nil, fmt.Errorf("%w: viewBox无效: %s", ErrInvalidSVG, attrs["viewBox"])
+			}
+		}
+		if nums[2] <= 0 || nums[3] <= 0 {
+			return nil, fmt.Errorf("%w: viewBox的宽高必须大于0: %s", ErrInvalidSVG, attrs["viewBox"])
+		}
+	}
+	switch {
+	case hasViewBox:
+		copy(vb[:], nums)
+	case iw > 0 && ih > 0:
+		vb = [4]float64{0, 0, iw, ih}
+	default:
+		return nil, fmt.Errorf("%w: 缺少viewBox或width、height", ErrInvalidSVG)
+	}
+	switch {
+	case iw <= 0 && ih <= 0:
+		iw, ih = vb[2], vb[3]
+	case iw <= 0:
+		iw = ih * vb[2] / vb[3]
+	case ih <= 0:
+		ih = iw * vb[3] / vb[2]
+	}
+
+	// 先按浮点数计算输出尺寸，超大的值转换为int之前拒绝
+	fw, fh := float64(width), float64(height)
+	switch {
+	case width == 0 && height == 0:
+		fw, fh = math.Ceil(iw-1e-9), math.Ceil(ih-1e-9)
+	case width == 0:
+		fw = math.Round(fh * iw / ih)
+	case height == 0:
+		fh = math.Round(fw * ih / iw)
+	}
+	fw, fh = math.Max(fw, 1), math.Max(fh, 1)
+	if !(fw*fh <= math.MaxInt32) {
+		return nil, fmt.Errorf("%w: 尺寸%gx%g过大", ErrImageTooLarge, fw, fh)
+	}
+	width, height = int(fw), int(fh)
+	if err := check(width, height); err != nil {
+		return nil, err
+	}
+
+	// preserveAspectRatio默认为xMidYMid meet
+	sx, sy := float64(width)/vb[2], float64(height)/vb[3]
+	align, mode, _ := strings.Cut(strings.TrimSpace(attrs["preserveAspectRatio"]), " ")
+	var ax, ay float64 = 0.5, 0.5
+	if align != "none" {
+		if strings.TrimSpace(mode) == "slice" {
+			sx = math.Max(sx, sy)
+		} else {
+			sx = math.Min(sx, sy)
+		}
+		sy = sx
+		if len(align) == 8 {
+			ax = map[string]float64{"xMin": 0, "xMid": 0.5, "xMax": 1}[align[:4]]
+			ay = map[string]float64{"YMin": 0, "YMid": 0.5, "YMax": 1}[align[4:]]
+		}
+	}
+	tx := -vb[0]*sx + (float64(width)-vb[2]*sx)*ax
+	ty := -vb[1]*sy + (float64(height)-vb[3]*sy)*ay
+
+	return &svgCanvas{
+		img:      image.NewRGBA(image.Rect(0, 0, width, height)),
+		viewport: svgMatrix{sx, 0, 0, sy, tx, ty},
+		ref:      svgPt{vb[2], vb[3]},
+	}, nil
+}
+
+// draw 绘制一个图形元素
+func (c *svgCanvas) draw(name string, attrs map[string]string, f svgFrame) {
+	s := f.style
+	if s.hidden {
+		return
+	}
+	scale := f.ctm.scale()
+	if scale == 0 {
+		return
+	}
+	b := &svgPathBuilder{tol: 0.1 / scale}
+	length := func(attr string, ref float64) float64 {
+		v, _ := parseSVGLength(attrs[attr], ref)
+		return v
+	}
+	rw, rh := f.ref.x, f.ref.y
+
+	switch name {
+	case "path":
+		parseSVGPath(attrs["d"], b)
+	case "rect":
+		x, y, w, h := length("x", rw), length("y", rh), length("width", rw), length("height", rh)
+		if w <= 0 || h <= 0 {
+			return
+		}
+		rx, okx := parseSVGLength(attrs["rx"], rw)
+		ry, oky := parseSVGLength(attrs["ry"], rh)
+		if !okx {
+			rx = ry
+		}
+		if !oky {
+			ry = rx
+		}
+		rx, ry = math.Min(math.Max(rx, 0), w/2), math.Min(math.Max(ry, 0), h/2)
+		if rx == 0 || ry == 0 {
+			b.moveTo(svgPt{x, y})
+			b.lineTo(svgPt{x + w, y})
+			b.lineTo(svgPt{x + w, y + h})
+			b.lineTo(svgPt{x, y + h})
+		} else {
+			b.moveTo(svgPt{x + rx, y})
+			b.lineTo(svgPt{x + w - rx, y})
+			b.arcTo(rx, ry, 0, false, true, svgPt{x + w, y + ry})
+			b.lineTo(svgPt{x + w, y + h - ry})
+			b.arcTo(rx, ry, 0, false, true, svgPt{x + w - rx, y + h})
+			b.lineTo(svgPt{x + rx, y + h})
+			b.arcTo(rx, ry, 0, false, true, svgPt{x, y + h - ry})
+			b.lineTo(svgPt{x, y + ry})
+			b.arcTo(rx, ry, 0, false, true, svgPt{x + rx, y})
+		}
+		b.close()
+	case "circle":
+		r := length("r", math.Hypot(rw, rh)/math.Sqrt2)
+		b.ellipse(length("cx", rw), length("cy", rh), r, r)
+	case "ellipse":
+		b.ellipse(length("cx", rw), length("cy", rh), length("rx", rw), length("ry", rh))
+	case "line":
+		b.moveTo(svgPt{length("x1", rw), length("y1", rh)})
+		b.lineTo(svgPt{length("x2", rw), length("y2", rh)})
+	case "polyline", "polygon":
+		nums := parseSVGNumbers(attrs["points"])
+		for i := 0; i+1 < len(nums); i += 2 {
+			if i == 0 {
+				b.moveTo(svgPt{nums[0], nums[1]})
+			} else {
+				b.lineTo(svgPt{nums[i], nums[i+1]})
+			}
+		}
+		if name == "polygon" {
+			b.close()
+		}
+	}
+	if len(b.subs) == 0 {
+		return
+	}
+
+	if p, ok := s.paint(s.fill); ok {
+		polys := make([][]svgPt, 0, len(b.subs))
+		for _, sub := range b.subs {
+			polys = append(polys, transformSVGPoints(f.ctm, sub.pts))
+		}
+		c.fill(polys, s.evenOdd, p, s.fillOpacity*f.alpha)
+	}
+	if p, ok := s.paint(s.stroke); ok && s.strokeWidth > 0 {
+		outline := strokeSVGPath(b.subs, s.strokeWidth/2, s.lineCap, b.tol)
+		for i, poly := range outline {
+			outline[i] = transformSVGPoints(f.ctm, poly)
+		}
+		c.fill(outline, false, p, s.strokeOpacity*f.alpha)
+	}
+}
+
+// paint 返回绘制使用的颜色，none时返回false
+func (s svgStyle) paint(p svgPaint) (color.NRGBA, bool) {
+	switch p.kind {
+	case svgPaintColor:
+		return p.c, true
+	case svgPaintCurrent:
+		return s.color, true
+	default:
+		return color.NRGBA{}, false
+	}
+}
+
+// svgSamples 每行像素的垂直采样次数，水平方向按精确的覆盖长度计算
+const svgSamples = 16
+
+// svgEdge 多边形的一条边，y0 < y1
+type svgEdge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// fill 用颜色填充多边形，多边形自动闭合
+func (c *svgCanvas) fill(polys [][]svgPt, evenOdd bool, col color.NRGBA, opacity float64) {
+	alpha := opacity * float64(col.A) / 255
+	if alpha <= 0 {
+		return
+	}
+	var edges []svgEdge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, poly := range polys {
+		for i, a := range poly {
+			b := poly[(i+1)%len(poly)]
+			if a.y == b.y || math.IsNaN(a.y) || math.IsNaN(b.y) {
+				continue
+			}
+			e := svgEdge{a.x, a.y, b.x, b.y, 1}
+			if a.y > b.y {
+				e = svgEdge{b.x, b.y, a.x, a.y, -1}
+			}
+			edges = append(edges, e)
+			minY, maxY = math.Min(minY, e.y0), math.Max(maxY, e.y1)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	w, h := c.img.Rect.Dx(), c.img.Rect.Dy()
+	y0 := max(int(math.Floor(minY)), 0)
+	y1 := min(int(math.Ceil(maxY)), h)
+	cov := make([]float64, w+1)
+	var (
+		active    []svgEdge
+		crossings []svgCrossing
+		next      int
+	)
+	for py := y0; py < y1; py++ {
+		clear(cov)
+		for s := 0; s < svgSamples; s++ {
+			sy := float64(py) + (float64(s)+0.5)/svgSamples
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, edges[next])
+				next++
+			}
+			crossings = crossings[:0]
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 <= sy {
+					continue
+				}
+				kept = append(kept, e)
+				if e.y0 <= sy {
+					crossings = append(crossings, svgCrossing{e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0), e.dir})
+				}
+			}
+			active = kept
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i, cr := range crossings {
+				winding += cr.dir
+				inside := winding != 0
+				if evenOdd {
+					inside = winding%2 != 0
+				}
+				if inside && i+1 < len(crossings) {
+					addSVGSpan(cov, cr.x, crossings[i+1].x, w)
+				}
+			}
+		}
+
+		row := c.img.Pix[c.img.PixOffset(0, py):]
+		for x := 0; x < w; x++ {
+			if cov[x] <= 0 {
+				continue
+			}
+			a := math.Min(cov[x]/svgSamples, 1) * alpha
+			p := row[4*x : 4*x+4]
+			p[0] = clampUint8(float64(col.R)*a + float64(p[0])*(1-a))
+			p[1] = clampUint8(float64(col.G)*a + float64(p[1])*(1-a))
+			p[2] = clampUint8(float64(col.B)*a + float64(p[2])*(1-a))
+			p[3] = clampUint8(255*a + float64(p[3])*(1-a))
+		}
+	}
+}
+
+// svgCrossing 扫描线与边的交点
+type svgCrossing struct {
+	x   float64
+	dir int
+}
+
+// addSVGSpan 将扫描线上[xa, xb)区间按像素累加覆盖长度
+func addSVGSpan(cov []float64, xa, xb float64, w int) {
+	xa, xb = math.Max(xa, 0), math.Min(xb, float64(w))
+	if xa >= xb {
+		return
+	}
+	ia, ib := int(xa), int(xb)
+	if ia == ib {
+		cov[ia] += xb - xa
+		return
+	}
+	cov[ia] += float64(ia+1) - xa
+	for x := ia + 1; x < ib; x++ {
+		cov[x]++
+	}
+	cov[ib] += xb - float64(ib)
+}
+
+// transformSVGPoints 返回变换后的点
+func transformSVGPoints(m svgMatrix, pts []svgPt) []svgPt {
+	out := make([]svgPt, len(pts))
+	for i, p := range pts {
+		out[i] = m.apply(p)
+	}
+	return out
+}
+
+// svgSubpath 展平为折线的子路径
+type svgSubpath struct {
+	pts    []svgPt
+	closed bool
+}
+
+// svgPathBuilder 在用户坐标中构建路径，曲线按tol展平为折线
+type svgPathBuilder struct {
+	subs       []svgSubpath
+	cur, start svgPt
+	tol        float64
+}
+
+func (b *svgPathBuilder) moveTo(p svgPt) {
+	b.subs = append(b.subs, svgSubpath{pts: []svgPt{p}})
+	b.cur, b.start = p, p
+}
+
+func (b *svgPathBuilder) lineTo(p svgPt) {
+	// 闭合之后没有moveTo时，新的子路径从闭合点开始
+	if len(b.subs) == 0 || b.subs[len(b.subs)-1].closed {
+		b.subs = append(b.subs, svgSubpath{pts: []svgPt{b.cur}})
+		b.start = b.cur
+	}
+	sub := &b.subs[len(b.subs)-1]
+	sub.pts = append(sub.pts, p)
+	b.cur = p
+}
+
+func (b *svgPathBuilder) close() {
+	if len(b.subs) > 0 {
+		b.subs[len(b.subs)-1].closed = true
+	}
+	b.cur = b.start
+}
+
+// curveSegments 根据二阶差分估计展平曲线需要的线段数
+func (b *svgPathBuilder) curveSegments(dd float64) int {
+	n := math.Ceil(math.Sqrt(dd / (8 * b.tol)))
+	return int(math.Min(math.Max(n, 1), 1000))
+}
+
+func (b *svgPathBuilder) quadTo(c, p svgPt) {
+	p0 := b.cur
+	n := b.curveSegments(2 * math.Hypot(p0.x-2*c.x+p.x, p0.y-2*c.y+p.y))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		b.lineTo(svgPt{u*u*p0.x + 2*u*t*c.x + t*t*p.x, u*u*p0.y + 2*u*t*c.y + t*t*p.y})
+	}
+}
+
+func (b *svgPathBuilder) cubicTo(c1, c2, p svgPt) {
+	p0 := b.cur
+	dd := math.Max(math.Hypot(p0.x-2*c1.x+c2.x, p0.y-2*c1.y+c2.y), math.Hypot(c1.x-2*c2.x+p.x, c1.y-2*c2.y+p.y))
+	n := b.curveSegments(6 * dd)
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		b.lineTo(svgPt{
+			u*u*u*p0.x + 3*u*u*t*c1.x + 3*u*t*t*c2.x + t*t*t*p.x,
+			u*u*u*p0.y + 3*u*u*t*c1.y + 3*u*t*t*c2.y + t*t*t*p.y,
+		})
+	}
+}
+
+// arcSegments 返回半径为r、角度为sweep的圆弧需要的线段数
+func (b *svgPathBuilder) arcSegments(r, sweep float64) int {
+	step := 2 * math.Acos(math.Max(1-b.tol/math.Max(r, b.tol), -1))
+	n := math.Ceil(math.Abs(sweep) / math.Max(step, 1e-3))
+	return int(math.Min(math.Max(n, 1), 1000))
+}
+
+// arcTo 按SVG规范的端点参数添加椭圆弧
+func (b *svgPathBuilder) arcTo(rx, ry, phi float64, large, sweep bool, p svgPt) {
+	p0 := b.cur
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if p0 == p {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		b.lineTo(p)
+		return
+	}
+	sin, cos := math.Sincos(phi * math.Pi / 180)
+	dx, dy := (p0.x-p.x)/2, (p0.y-p.y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	co := math.Sqrt(math.Max(num/den, 0))
+	if large == sweep {
+		co = -co
+	}
+	cxp, cyp := co*rx*y1/ry, -co*ry*x1/rx
+	cx := cos*cxp - sin*cyp + (p0.x+p.x)/2
+	cy := sin*cxp + cos*cyp + (p0.y+p.y)/2
+
+	th1 := math.Atan2((y1-cyp)/ry, (x1-cxp)/rx)
+	dth := math.Atan2((-y1-cyp)/ry, (-x1-cxp)/rx) - th1
+	if sweep && dth < 0 {
+		dth += 2 * math.Pi
+	} else if !sweep && dth > 0 {
+		dth -= 2 * math.Pi
+	}
+
+	n := b.arcSegments(math.Max(rx, ry), dth)
+	for i := 1; i < n; i++ {
+		st, ct := math.Sincos(th1 + dth*float64(i)/float64(n))
+		b.lineTo(svgPt{cx + rx*ct*cos - ry*st*sin, cy + rx*ct*sin + ry*st*cos})
+	}
+	b.lineTo(p)
+}
+
+// ellipse 添加一个闭合的椭圆
+func (b *svgPathBuilder) ellipse(cx, cy, rx, ry float64) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	n := max(b.arcSegments(math.Max(rx, ry), 2*math.Pi), 8)
+	b.moveTo(svgPt{cx + rx, cy})
+	for i := 1; i < n; i++ {
+		st, ct := math.Sincos(2 * math.Pi * float64(i) / float64(n))
+		b.lineTo(svgPt{cx + rx*ct, cy + ry*st})
+	}
+	b.close()
+}
+
+// strokeSVGPath 生成描边的轮廓多边形，所有多边形方向一致，按非零规则填充即为描边区域
+func strokeSVGPath(subs []svgSubpath, hw float64, lineCap string, tol float64) [][]svgPt {
+	var out [][]svgPt
+	add := func(poly []svgPt) {
+		if svgArea(poly) < 0 {
+			for i, j := 0, len(poly)-1; i < j; i, j = i+1, j-1 {
+				poly[i], poly[j] = poly[j], poly[i]
+			}
+		}
+		out = append(out, poly)
+	}
+	// arc 以c为圆心、hw为半径，从方向a扫过angle弧度的扇形
+	arc := func(c svgPt, a, angle float64) []svgPt {
+		n := (&svgPathBuilder{tol: tol}).arcSegments(hw, angle)
+		poly := make([]svgPt, 0, n+2)
+		if math.Abs(angle) < 2*math.Pi {
+			poly = append(poly, c)
+		}
+		for i := 0; i <= n; i++ {
+			s, co := math.Sincos(a + angle*float64(i)/float64(n))
+			poly = append(poly, svgPt{c.x + hw*co, c.y + hw*s})
+		}
+		return poly
+	}
+
+	for _, sub := range subs {
+		// 去掉重复的点
+		pts := sub.pts[:1:1]
+		for _, p := range sub.pts[1:] {
+			if p != pts[len(pts)-1] {
+				pts = append(pts, p)
+			}
+		}
+		if sub.closed && len(pts) > 2 && pts[0] == pts[len(pts)-1] {
+			pts = pts[:len(pts)-1]
+		}
+		if len(pts) == 1 {
+			p := pts[0]
+			switch lineCap {
+			case "round":
+				add(arc(p, 0, 2*math.Pi))
+			case "square":
+				add([]svgPt{{p.x - hw, p.y - hw}, {p.x + hw, p.y - hw}, {p.x + hw, p.y + hw}, {p.x - hw, p.y + hw}})
+			}
+			continue
+		}
+
+		closed := sub.closed && len(pts) > 2
+		nseg := len(pts) - 1
+		if closed {
+			nseg = len(pts)
+		}
+		dirs := make([]svgPt, nseg)
+		for i := range dirs {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			l := math.Hypot(b.x-a.x, b.y-a.y)
+			dirs[i] = svgPt{(b.x - a.x) / l, (b.y - a.y) / l}
+		}
+
+		for i, d := range dirs {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			if !closed && lineCap == "square" {
+				if i == 0 {
+					a = svgPt{a.x - d.x*hw, a.y - d.y*hw}
+				}
+				if i == nseg-1 {
+					b = svgPt{b.x + d.x*hw, b.y + d.y*hw}
+				}
+			}
+			n := svgPt{-d.y * hw, d.x * hw}
+			add([]svgPt{{a.x + n.x, a.y + n.y}, {b.x + n.x, b.y + n.y}, {b.x - n.x, b.y - n.y}, {a.x - n.x, a.y - n.y}})
+		}
+
+		// 圆角拐角：在拐角外侧补上扇形
+		for i := range dirs {
+			if !closed && i == 0 {
+				continue
+			}
+			d0, d1 := dirs[(i-1+nseg)%nseg], dirs[i]
+			cross := d0.x*d1.y - d0.y*d1.x
+			dot := d0.x*d1.x + d0.y*d1.y
+			turn := math.Atan2(cross, dot)
+			if turn == 0 {
+				continue
+			}
+			// 法线(-d.y, d.x)位于转向一侧，外侧是它的反方向
+			start := math.Atan2(d0.x, -d0.y)
+			if turn > 0 {
+				start += math.Pi
+			}
+			add(arc(pts[i], start, turn))
+		}
+
+		if !closed && lineCap == "round" {
+			first, last := dirs[0], dirs[nseg-1]
+			add(arc(pts[0], math.Atan2(first.x, -first.y), math.Pi))
+			add(arc(pts[len(pts)-1], math.Atan2(-last.x, last.y), math.Pi))
+		}
+	}
+	return out
+}
+
+// svgArea 返回多边形的有向面积
+func svgArea(poly []svgPt) float64 {
+	var a float64
+	for i, p := range poly {
+		q := poly[(i+1)%len(poly)]
+		a += p.x*q.y - q.x*p.y
+	}
+	return a / 2
+}
+
+// svgScanner 解析路径数据和数字列表
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (s *svgScanner) skipSep() {
+	for s.i < len(s.s) && strings.IndexByte(" \t\r\n,", s.s[s.i]) >= 0 {
+		s.i++
+	}
+}
+
+func (s *svgScanner) done() bool {
+	s.skipSep()
+	return s.i >= len(s.s)
+}
+
+func isSVGDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// number 读取一个数字，路径数据中相邻的数字可以没有分隔符，如"1.5.5"和"1-2"
+func (s *svgScanner) number() (float64, bool) {
+	s.skipSep()
+	start, i := s.i, s.i
+	if i < len(s.s) && (s.s[i] == '+' || s.s[i] == '-') {
+		i++
+	}
+	digits := false
+	for ; i < len(s.s) && isSVGDigit(s.s[i]); i++ {
+		digits = true
+	}
+	if i < len(s.s) && s.s[i] == '.' {
+		for i++; i < len(s.s) && isSVGDigit(s.s[i]); i++ {
+			digits = true
+		}
+	}
+	if !digits {
+		return 0, false
+	}
+	if i < len(s.s) && (s.s[i] == 'e' || s.s[i] == 'E') {
+		j := i + 1
+		if j < len(s.s) && (s.s[j] == '+' || s.s[j] == '-') {
+			j++
+		}
+		if j < len(s.s) && isSVGDigit(s.s[j]) {
+			for i = j; i < len(s.s) && isSVGDigit(s.s[i]); i++ {
+			}
+		}
+	}
+	v, err := strconv.ParseFloat(s.s[start:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	s.i = i
+	return v, true
+}
+
+// flag 读取椭圆弧的标志位，标志位只有一个字符，可以与后面的数字相连
+func (s *svgScanner) flag() (bool, bool) {
+	s.skipSep()
+	if s.i < len(s.s) && (s.s[s.i] == '0' || s.s[s.i] == '1') {
+		s.i++
+		return s.s[s.i-1] == '1', true
+	}
+	return false, false
+}
+
+// numbers 读取n个数字
+func (s *svgScanner) numbers(n int) ([]float64, bool) {
+	out := make([]float64, n)
+	for i := range out {
+		v, ok := s.number()
+		if !ok {
+			return nil, false
+		}
+		out[i] = v
+	}
+	return out, true
+}
+
+// parseSVGNumbers 解析以空白或逗号分隔的数字列表，遇到无法解析的内容时停止
+func parseSVGNumbers(v string) []float64 {
+	s := &svgScanner{s: v}
+	var out []float64
+	for {
+		n, ok := s.number()
+		if !ok {
+			return out
+		}
+		out = append(out, n)
+	}
+}
+
+// parseSVGPath 解析路径数据，按规范在出错的位置停止，保留之前的部分
+func parseSVGPath(d string, b *svgPathBuilder) {
+	s := &svgScanner{s: d}
+	var (
+		cmd      byte
+		lastCtrl svgPt
+		lastCmd  byte
+	)
+	for !s.done() {
+		if c := s.s[s.i]; (c|0x20) >= 'a' && (c|0x20) <= 'z' {
+			cmd = c
+			s.i++
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return
+		}
+		rel := cmd >= 'a'
+		origin := svgPt{}
+		if rel {
+			origin = b.cur
+		}
+		pt := func(x, y float64) svgPt { return svgPt{origin.x + x, origin.y + y} }
+		// S和T的第一个控制点是上一个控制点的反射，上一个命令不是同类曲线时为当前点
+		reflect := func(kinds string) svgPt {
+			if strings.IndexByte(kinds, lastCmd|0x20) >= 0 {
+				return svgPt{2*b.cur.x - lastCtrl.x, 2*b.cur.y - lastCtrl.y}
+			}
+			return b.cur
+		}
+
+		var args []float64
+		var ok bool
+		switch cmd | 0x20 {
+		case 'm':
+			if args, ok = s.numbers(2); !ok {
+				return
+			}
+			b.moveTo(pt(args[0], args[1]))
+			// moveTo之后的坐标对是隐式的lineTo
+			cmd-- // M变为L，m变为l
+		case 'l':
+			if args, ok = s.numbers(2); !ok {
+				return
+			}
+			b.lineTo(pt(args[0], args[1]))
+		case 'h':
+			if args, ok = s.numbers(1); !ok {
+				return
+			}
+			p := pt(args[0], 0)
+			b.lineTo(svgPt{p.x, b.cur.y})
+		case 'v':
+			if args, ok = s.numbers(1); !ok {
+				return
+			}
+			p := pt(0, args[0])
+			b.lineTo(svgPt{b.cur.x, p.y})
+		case 'c':
+			if args, ok = s.numbers(6); !ok {
+				return
+			}
+			lastCtrl = pt(args[2], args[3])
+			b.cubicTo(pt(args[0], args[1]), lastCtrl, pt(args[4], args[5]))
+		case 's':
+			if args, ok = s.numbers(4); !ok {
+				return
+			}
+			c1 := reflect("cs")
+			lastCtrl = pt(args[0], args[1])
+			b.cubicTo(c1, lastCtrl, pt(args[2], args[3]))
+		case 'q':
+			if args, ok = s.numbers(4); !ok {
+				return
+			}
+			lastCtrl = pt(args[0], args[1])
+			b.quadTo(lastCtrl, pt(args[2], args[3]))
+		case 't':
+			if args, ok = s.numbers(2); !ok {
+				return
+			}
+			lastCtrl = reflect("qt")
+			b.quadTo(lastCtrl, pt(args[0], args[1]))
+		case 'a':
+			if args, ok = s.numbers(3); !ok {
+				return
+			}
+			large, ok1 := s.flag()
+			sweep, ok2 := s.flag()
+			end, ok3 := s.numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return
+			}
+			b.arcTo(args[0], args[1], args[2], large, sweep, pt(end[0], end[1]))
+		case 'z':
+			b.close()
+		default:
+			return
+		}
+		lastCmd = cmd
+	}
+}
+
+// parseSVGTransform 解析transform属性
+func parseSVGTransform(v string) (svgMatrix, bool) {
+	m := svgIdentity
+	for {
+		v = strings.TrimLeft(v, " \t\r\n,")
+		if v == "" {
+			return m, true
+		}
+		open := strings.IndexByte(v, '(')
+		end := strings.IndexByte(v, ')')
+		if open < 0 || end < open {
+			return svgIdentity, false
+		}
+		name := strings.TrimSpace(v[:open])
+		a := parseSVGNumbers(v[open+1 : end])
+		v = v[end+1:]
+
+		var t svgMatrix
+		switch {
+		case name == "matrix" && len(a) == 6:
+			t = svgMatrix{a[0], a[1], a[2], a[3], a[4], a[5]}
+		case name == "translate" && len(a) == 1:
+			t = svgMatrix{1, 0, 0, 1, a[0], 0}
+		case name == "translate" && len(a) == 2:
+			t = svgMatrix{1, 0, 0, 1, a[0], a[1]}
+		case name == "scale" && len(a) == 1:
+			t = svgMatrix{a[0], 0, 0, a[0], 0, 0}
+		case name == "scale" && len(a) == 2:
+			t = svgMatrix{a[0], 0, 0, a[1], 0, 0}
+		case name == "rotate" && (len(a) == 1 || len(a) == 3):
+			sin, cos := math.Sincos(a[0] * math.Pi / 180)
+			t = svgMatrix{cos, sin, -sin, cos, 0, 0}
+			if len(a) == 3 {
+				t = svgMatrix{1, 0, 0, 1, a[1], a[2]}.mul(t).mul(svgMatrix{1, 0, 0, 1, -a[1], -a[2]})
+			}
+		case name == "skewX" && len(a) == 1:
+			t = svgMatrix{1, 0, math.Tan(a[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(a) == 1:
+			t = svgMatrix{1, math.Tan(a[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return svgIdentity, false
+		}
+		m = m.mul(t)
+	}
+}
+
+// svgUnits 绝对长度单位换算为像素的倍数
+var svgUnits = map[string]float64{
+	"px": 1, "pt": 4.0 / 3, "pc": 16, "in": 96, "cm": 96 / 2.54, "mm": 96 / 25.4, "em": 16, "ex": 8,
+}
+
+// parseSVGLength 解析长度，百分比相对于ref
+func parseSVGLength(v string, ref float64) (float64, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	factor := 1.0
+	if strings.HasSuffix(v, "%") {
+		factor, v = ref/100, v[:len(v)-1]
+	} else if len(v) > 2 {
+		if f, ok := svgUnits[v[len(v)-2:]]; ok {
+			factor, v = f, v[:len(v)-2]
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * factor, true
+}
+
+// parseSVGDimension 解析svg根元素的width或height，百分比和无法解析的值返回0，不是有限的正数时返回ErrInvalidSVG
+func parseSVGDimension(name, v string) (float64, error) {
+	if strings.HasSuffix(strings.TrimSpace(v), "%") {
+		return 0, nil
+	}
+	n, ok := parseSVGLength(v, 0)
+	if !ok {
+		return 0, nil
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) || n <= 0 {
+		return 0, fmt.Errorf("%w: %s必须是大于0的有限值: %s", ErrInvalidSVG, name, v)
+	}
+	return n, nil
+}
+
+// parseSVGOpacity 解析0~1之间的透明度或百分比
+func parseSVGOpacity(v string) (float64, bool) {
+	n, ok := parseSVGLength(v, 1)
+	if !ok {
+		return 0, false
+	}
+	return math.Min(math.Max(n, 0), 1), true
+}
+
+// parseSVGPaint 解析fill或stroke，引用渐变等绘制服务器时使用其后的备用颜色，没有备用颜色时不绘制
+func parseSVGPaint(v string) (svgPaint, bool) {
+	switch {
+	case v == "none":
+		return svgPaint{kind: svgPaintNone}, true
+	case v == "currentColor":
+		return svgPaint{kind: svgPaintCurrent}, true
+	case strings.HasPrefix(v, "url("):
+		if end := strings.IndexByte(v, ')'); end >= 0 {
+			if fallback := strings.TrimSpace(v[end+1:]); fallback != "" {
+				return parseSVGPaint(fallback)
+			}
+		}
+		return svgPaint{kind: svgPaintNone}, true
+	}
+	c, ok := parseSVGColor(v)
+	return svgPaint{kind: svgPaintColor, c: c}, ok
+}
+
+// parseSVGColor 解析#rgb、#rgba、#rrggbb、#rrggbbaa、rgb()、rgba()和常用的颜色名称
+func parseSVGColor(v string) (color.NRGBA, bool) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if strings.HasPrefix(v, "#") {
+		hex := v[1:]
+		if len(hex) == 3 || len(hex) == 4 {
+			var sb strings.Builder
+			for _, c := range hex {
+				sb.WriteRune(c)
+				sb.WriteRune(c)
+			}
+			hex = sb.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			return color.NRGBA{}, false
+		}
+		return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, true
+	}
+	if strings.HasPrefix(v, "rgb") && strings.HasSuffix(v, ")") {
+		open := strings.IndexByte(v, '(')
+		if open < 0 {
+			return color.NRGBA{}, false
+		}
+		parts := strings.FieldsFunc(v[open+1:len(v)-1], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(parts) < 3 || len(parts) > 4 {
+			return color.NRGBA{}, false
+		}
+		var ch [4]uint8
+		ch[3] = 255
+		for i, p := range parts {
+			ref := 255.0
+			if i == 3 && !strings.HasSuffix(p, "%") {
+				ref = 1
+			}
+			n, ok := parseSVGLength(p, 255)
+			if !ok {
+				return color.NRGBA{}, false
+			}
+			if !strings.HasSuffix(p, "%") {
+				n = n * 255 / ref
+			}
+			ch[i] = clampUint8(n)
+		}
+		return color.NRGBA{ch[0], ch[1], ch[2], ch[3]}, true
+	}
+	c, ok := svgColorNames[v]
+	return c, ok
+}
+
+// svgColorNames 常用的CSS颜色名称
+var svgColorNames = map[string]color.NRGBA{
+	"transparent": {0, 0, 0, 0},
+	"black":       {0, 0, 0, 255},
+	"white":       {255, 255, 255, 255},
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"yellow":      {255, 255, 0, 255},
+	"cyan":        {0, 255, 255, 255},
+	"aqua":        {0, 255, 255, 255},
+	"magenta":     {255, 0, 255, 255},
+	"fuchsia":     {255, 0, 255, 255},
+	"gray":        {128, 128, 128, 255},
+	"grey":        {128, 128, 128, 255},
+	"darkgray":    {169, 169, 169, 255},
+	"darkgrey":    {169, 169, 169, 255},
+	"lightgray":   {211, 211, 211, 255},
+	"lightgrey":   {211, 211, 211, 255},
+	"silver":      {192, 192, 192, 255},
+	"maroon":      {128, 0, 0, 255},
+	"olive":       {128, 128, 0, 255},
+	"lime":        {0, 255, 0, 255},
+	"navy":        {0, 0, 128, 255},
+	"purple":      {128, 0, 128, 255},
+	"teal":        {0, 128, 128, 255},
+	"orange":      {255, 165, 0, 255},
+	"pink":        {255, 192, 203, 255},
+	"brown":       {165, 42, 42, 255},
+	"gold":        {255, 215, 0, 255},
+	"indigo":      {75, 0, 130, 255},
+	"violet":      {238, 130, 238, 255},
+	"darkred":     {139, 0, 0, 255},
+	"darkgreen":   {0, 100, 0, 255},
+	"darkblue":    {0, 0, 139, 255},
+	"skyblue":     {135, 206, 235, 255},
+	"steelblue":   {70, 130, 180, 255},
+	"tomato":      {255, 99, 71, 255},
+	"crimson":     {220, 20, 60, 255},
+	"coral":       {255, 127, 80, 255},
+	"salmon":      {250, 128, 114, 255},
+	"whitesmoke":  {245, 245, 245, 255},
+	"gainsboro":   {220, 220, 220, 255},
+	"beige":       {245, 245, 220, 255},
+	"ivory":       {255, 255, 240, 255},
+	"khaki":       {240, 230, 140, 255},
+	"orchid":      {218, 112, 214, 255},
+	"turquoise":   {64, 224, 208, 255},
+	"slategray":   {112, 128, 144, 255},
+	"slategrey":   {112, 128, 144, 255},
+}
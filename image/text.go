@@ -0,0 +1,242 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font 从TTF或OTF文件加载的字体，可以创建不同字号的font.Face
+type Font struct {
+	font *opentype.Font
+}
+
+// LoadFont 从字节数组加载TTF、OTF字体或TTC字体集合中的第一个字体
+func LoadFont(data []byte) (*Font, error) {
+	var (
+		f   *opentype.Font
+		err error
+	)
+	if bytes.HasPrefix(data, []byte("ttcf")) {
+		var c *opentype.Collection
+		if c, err = opentype.ParseCollection(data); err == nil {
+			f, err = c.Font(0)
+		}
+	} else {
+		f, err = opentype.Parse(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析字体失败: %w", err)
+	}
+	return &Font{font: f}, nil
+}
+
+// LoadFontFile 从文件加载字体
+func LoadFontFile(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取字体文件失败: %w", err)
+	}
+	return LoadFont(data)
+}
+
+// Face 创建指定字号的字体，size为像素大小，同一个Face不能并发使用
+func (f *Font) Face(size float64) (font.Face, error) {
+	face, err := opentype.NewFace(f.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("创建字体失败: %w", err)
+	}
+	return face, nil
+}
+
+// Align 文字的水平对齐方式
+type Align int
+
+const (
+	AlignLeft   Align = iota // 左对齐
+	AlignCenter              // 居中
+	AlignRight               // 右对齐
+)
+
+// TextOptions 文字绘制选项
+type TextOptions struct {
+	Face        font.Face   // 字体，nil表示内置的7x13点阵字体（只支持ASCII字符）
+	Color       color.Color // 文字颜色，nil表示黑色
+	Align       Align       // 每行文字在文本框中的对齐方式
+	MaxWidth    int         // 文本框宽度，大于0时超出的文字自动换行，否则文本框宽度为最长一行的宽度
+	LineSpacing float64     // 行距倍数，0表示1
+}
+
+func (o TextOptions) face() font.Face {
+	if o.Face == nil {
+		return basicfont.Face7x13
+	}
+	return o.Face
+}
+
+// lineHeight 返回一行的高度
+func (o TextOptions) lineHeight(face font.Face) int {
+	spacing := o.LineSpacing
+	if spacing <= 0 {
+		spacing = 1
+	}
+	return int(math.Ceil(float64(face.Metrics().Height) / 64 * spacing))
+}
+
+// WrapText 将文字按最大宽度拆分为多行
+// 参数：
+//
+//	text - 文字，\n为强制换行
+//	face - 用于测量宽度的字体
+//	maxWidth - 每行的最大宽度，不大于0时只按\n拆分
+//
+// 返回值：
+//
+//	[]string - 拆分后的各行；优先在空白处换行，中日韩文字可以在任意两个字之间换行，单个词超过最大宽度时按字符拆分
+func WrapText(text string, face font.Face, maxWidth int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		if maxWidth <= 0 {
+			lines = append(lines, para)
+			continue
+		}
+		limit := fixed.I(maxWidth)
+		line := ""
+		for _, word := range splitWords(para) {
+			candidate := line + word
+			if line == "" || font.MeasureString(face, strings.TrimRight(candidate, " ")) <= limit {
+				line = candidate
+			} else {
+				lines = append(lines, strings.TrimRight(line, " "))
+				line = strings.TrimLeft(word, " ")
+			}
+			// 单个词超过最大宽度时按字符拆分
+			for font.MeasureString(face, strings.TrimRight(line, " ")) > limit {
+				head, tail := splitAtWidth(line, face, limit)
+				lines = append(lines, strings.TrimRight(head, " "))
+				line = tail
+			}
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return lines
+}
+
+// splitWords 将段落拆分为换行的最小单位，每个单位带有其后的空白，中日韩文字每个字单独为一个单位
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	for i, r := range s {
+		switch {
+		case isWideRune(r):
+			if i > start {
+				words = append(words, s[start:i])
+			}
+			words = append(words, string(r))
+			start = i + len(string(r))
+		case r == ' ' || r == '\t':
+			words = append(words, s[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	// 将只有空白的单位合并到前一个单位
+	out := words[:0]
+	for _, w := range words {
+		if strings.TrimSpace(w) == "" && len(out) > 0 {
+			out[len(out)-1] += w
+		} else {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// isWideRune 返回字符是否为可以在任意位置换行的中日韩文字
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF
+}
+
+// splitAtWidth 在不超过limit的最后一个字符处拆分，至少保留一个字符
+func splitAtWidth(s string, face font.Face, limit fixed.Int26_6) (string, string) {
+	end := 0
+	for i, r := range s {
+		next := i + len(string(r))
+		if end > 0 && font.MeasureString(face, s[:next]) > limit {
+			break
+		}
+		end = next
+	}
+	return s[:end], s[end:]
+}
+
+// MeasureText 返回文字按选项排版后的文本框尺寸
+func MeasureText(text string, opts TextOptions) image.Point {
+	face := opts.face()
+	lines := WrapText(text, face, opts.MaxWidth)
+	width := opts.MaxWidth
+	if width <= 0 {
+		for _, line := range lines {
+			width = max(width, font.MeasureString(face, line).Ceil())
+		}
+	}
+	return image.Pt(width, opts.lineHeight(face)*len(lines))
+}
+
+// DrawText 在图片上绘制文字
+// 参数：
+//
+//	dst - 目标图片，文字直接绘制在上面
+//	text - 文字，\n为强制换行
+//	pt - 文本框的左上角
+//	opts - 文字绘制选项
+//
+// 返回值：
+//
+//	image.Rectangle - 文本框的范围，可以用于在下方继续排版
+func DrawText(dst draw.Image, text string, pt image.Point, opts TextOptions) image.Rectangle {
+	face := opts.face()
+	c := opts.Color
+	if c == nil {
+		c = color.Black
+	}
+	lines := WrapText(text, face, opts.MaxWidth)
+	widths := make([]int, len(lines))
+	width := opts.MaxWidth
+	for i, line := range lines {
+		widths[i] = font.MeasureString(face, line).Ceil()
+		if opts.MaxWidth <= 0 {
+			width = max(width, widths[i])
+		}
+	}
+
+	lineHeight := opts.lineHeight(face)
+	ascent := face.Metrics().Ascent.Ceil()
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+	for i, line := range lines {
+		x := pt.X
+		switch opts.Align {
+		case AlignCenter:
+			x += (width - widths[i]) / 2
+		case AlignRight:
+			x += width - widths[i]
+		}
+		d.Dot = fixed.P(x, pt.Y+i*lineHeight+ascent)
+		d.DrawString(line)
+	}
+	return image.Rect(pt.X, pt.Y, pt.X+width, pt.Y+lineHeight*len(lines))
+}
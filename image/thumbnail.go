@@ -0,0 +1,184 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// ErrInvalidSize 图片尺寸无效
+var ErrInvalidSize = errors.New("图片尺寸无效")
+
+// Resize 将图片缩放到指定尺寸
+// 参数：
+//
+//	img - 原图片
+//	width - 目标宽度
+//	height - 目标高度
+//
+// 返回值：
+//
+//	*image.NRGBA - 缩放后的图片，使用Catmull-Rom滤波，缩小时按缩放比例扩大滤波范围以避免锯齿和摩尔纹
+//	error - 目标尺寸或原图尺寸不是正数时返回ErrInvalidSize
+func Resize(img image.Image, width, height int) (*image.NRGBA, error) {
+	if width <= 0 || height <= 0 || img.Bounds().Empty() {
+		return nil, ErrInvalidSize
+	}
+	src := toNRGBA(img)
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if sw == width && sh == height {
+		return src, nil
+	}
+
+	// 先水平缩放到sh行width列的预乘alpha缓冲区，再垂直缩放到目标尺寸
+	xw := resampleWeights(sw, width)
+	tmp := make([]float64, 4*width*sh)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[src.PixOffset(0, y):]
+		for x, ws := range xw {
+			var c [4]float64
+			for _, w := range ws {
+				p := row[4*w.index:]
+				a := float64(p[3]) * w.weight
+				c[0] += float64(p[0]) * a
+				c[1] += float64(p[1]) * a
+				c[2] += float64(p[2]) * a
+				c[3] += a
+			}
+			copy(tmp[4*(y*width+x):], c[:])
+		}
+	}
+
+	yw := resampleWeights(sh, height)
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y, ws := range yw {
+		for x := 0; x < width; x++ {
+			var c [4]float64
+			for _, w := range ws {
+				p := tmp[4*(w.index*width+x):]
+				c[0] += p[0] * w.weight
+				c[1] += p[1] * w.weight
+				c[2] += p[2] * w.weight
+				c[3] += p[3] * w.weight
+			}
+			if c[3] <= 0 {
+				continue
+			}
+			d := dst.Pix[dst.PixOffset(x, y):]
+			d[0] = clampUint8(c[0] / c[3])
+			d[1] = clampUint8(c[1] / c[3])
+			d[2] = clampUint8(c[2] / c[3])
+			d[3] = clampUint8(c[3])
+		}
+	}
+	return dst, nil
+}
+
+// Fit 按比例缩小图片使其不超过指定尺寸，原图已经不超过指定尺寸时不放大
+// 参数：
+//
+//	img - 原图片
+//	maxW - 最大宽度
+//	maxH - 最大高度
+//
+// 返回值：
+//
+//	*image.NRGBA - 保持原图宽高比的新图片
+//	error - 尺寸不是正数时返回ErrInvalidSize
+func Fit(img image.Image, maxW, maxH int) (*image.NRGBA, error) {
+	w, h, err := fitSize(img.Bounds().Dx(), img.Bounds().Dy(), maxW, maxH)
+	if err != nil {
+		return nil, err
+	}
+	return Resize(img, w, h)
+}
+
+// fitSize 返回保持宽高比缩小到不超过最大尺寸后的尺寸，不放大
+func fitSize(w, h, maxW, maxH int) (int, int, error) {
+	if maxW <= 0 || maxH <= 0 || w <= 0 || h <= 0 {
+		return 0, 0, ErrInvalidSize
+	}
+	if w > maxW || h > maxH {
+		scale := math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+		w = max(1, int(math.Round(float64(w)*scale)))
+		h = max(1, int(math.Round(float64(h)*scale)))
+	}
+	return w, h, nil
+}
+
+// Thumbnail 生成不超过指定尺寸的缩略图，并编码为指定格式
+// 参数：
+//
+//	img - 原图片
+//	maxW - 缩略图的最大宽度
+//	maxH - 缩略图的最大高度
+//	format - 编码格式，与SaveImageToWriter相同
+//
+// 返回值：
+//
+//	image.Image - 保持原图宽高比的缩略图，原图已经不超过指定尺寸时不放大
+//	[]byte - 缩略图编码后的数据
+//	error - 尺寸无效时返回ErrInvalidSize，格式不支持时返回ErrUnsupportedFormat
+func Thumbnail(img image.Image, maxW, maxH int, format string) (image.Image, []byte, error) {
+	thumb, err := Fit(img, maxW, maxH)
+	if err != nil {
+		return nil, nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := SaveImageToWriter(thumb, buf, format); err != nil {
+		if err == ErrUnsupportedFormat {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("编码缩略图失败: %w", err)
+	}
+	return thumb, buf.Bytes(), nil
+}
+
+// resampleWeight 目标像素在一个原像素上的权重
+type resampleWeight struct {
+	index  int
+	weight float64
+}
+
+// resampleWeights 计算一维缩放时每个目标像素对应的原像素及权重，超出边界的原像素取边缘像素
+func resampleWeights(srcLen, dstLen int) [][]resampleWeight {
+	scale := float64(srcLen) / float64(dstLen)
+	filterScale := math.Max(scale, 1)
+	support := 2 * filterScale
+	weights := make([][]resampleWeight, dstLen)
+	for i := range weights {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+		ws := make([]resampleWeight, 0, end-start+1)
+		var sum float64
+		for j := start; j <= end; j++ {
+			w := catmullRom((float64(j) - center) / filterScale)
+			if w == 0 {
+				continue
+			}
+			ws = append(ws, resampleWeight{index: min(max(j, 0), srcLen-1), weight: w})
+			sum += w
+		}
+		for k := range ws {
+			ws[k].weight /= sum
+		}
+		weights[i] = ws
+	}
+	return weights
+}
+
+// catmullRom Catmull-Rom三次卷积核
+func catmullRom(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,171 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Rotate90 将图片顺时针旋转90度
+func Rotate90(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(y, h-1-x):][:4])
+		}
+	}
+	return dst
+}
+
+// Rotate180 将图片旋转180度
+func Rotate180(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(w-1-x, h-1-y):][:4])
+		}
+	}
+	return dst
+}
+
+// Rotate270 将图片顺时针旋转270度，即逆时针旋转90度
+func Rotate270(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, h, w))
+	for y := 0; y < w; y++ {
+		for x := 0; x < h; x++ {
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(w-1-y, x):][:4])
+		}
+	}
+	return dst
+}
+
+// FlipHorizontal 水平翻转图片（左右镜像）
+func FlipHorizontal(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(w-1-x, y):][:4])
+		}
+	}
+	return dst
+}
+
+// FlipVertical 垂直翻转图片（上下镜像）
+func FlipVertical(img image.Image) *image.NRGBA {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		copy(dst.Pix[dst.PixOffset(0, y):][:4*w], src.Pix[src.PixOffset(0, h-1-y):][:4*w])
+	}
+	return dst
+}
+
+// Rotate 将图片顺时针旋转任意角度
+// 参数：
+//
+//	img - 原图片
+//	angle - 旋转角度，单位为度，正数为顺时针，负数为逆时针
+//	bg - 旋转后空出区域的填充色，nil表示透明
+//
+// 返回值：
+//
+//	*image.NRGBA - 旋转后的图片，画布扩大到能完整容纳旋转后的原图；90度的整数倍时不做插值，结果与Rotate90等相同
+func Rotate(img image.Image, angle float64, bg color.Color) *image.NRGBA {
+	angle = math.Mod(angle, 360)
+	if angle < 0 {
+		angle += 360
+	}
+	switch angle {
+	case 0:
+		return toNRGBA(img)
+	case 90:
+		return Rotate90(img)
+	case 180:
+		return Rotate180(img)
+	case 270:
+		return Rotate270(img)
+	}
+	if bg == nil {
+		bg = color.Transparent
+	}
+
+	src := toNRGBA(img)
+	w, h := float64(src.Rect.Dx()), float64(src.Rect.Dy())
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	// 去掉浮点误差，避免画布多出一行或一列
+	dw := int(math.Ceil(math.Round((math.Abs(w*cos)+math.Abs(h*sin))*1e6) / 1e6))
+	dh := int(math.Ceil(math.Round((math.Abs(w*sin)+math.Abs(h*cos))*1e6) / 1e6))
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	// 背景色按预乘alpha的形式参与插值，使边缘平滑过渡到背景
+	br, bgc, bb, ba := bg.RGBA()
+	back := [4]float64{float64(br >> 8), float64(bgc >> 8), float64(bb >> 8), float64(ba >> 8)}
+	sample := func(x, y int) [4]float64 {
+		if x < 0 || y < 0 || x >= src.Rect.Dx() || y >= src.Rect.Dy() {
+			return back
+		}
+		p := src.Pix[src.PixOffset(x, y):]
+		a := float64(p[3])
+		return [4]float64{float64(p[0]) * a / 255, float64(p[1]) * a / 255, float64(p[2]) * a / 255, a}
+	}
+
+	cx, cy := w/2, h/2
+	dcx, dcy := float64(dw)/2, float64(dh)/2
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// 将目标像素中心逆向旋转回原图坐标
+			tx, ty := float64(x)+0.5-dcx, float64(y)+0.5-dcy
+			sx := tx*cos + ty*sin + cx - 0.5
+			sy := -tx*sin + ty*cos + cy - 0.5
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			p00, p10 := sample(x0, y0), sample(x0+1, y0)
+			p01, p11 := sample(x0, y0+1), sample(x0+1, y0+1)
+			var c [4]float64
+			for i := range c {
+				top := p00[i]*(1-fx) + p10[i]*fx
+				bottom := p01[i]*(1-fx) + p11[i]*fx
+				c[i] = top*(1-fy) + bottom*fy
+			}
+			d := dst.Pix[dst.PixOffset(x, y):]
+			if c[3] <= 0 {
+				continue
+			}
+			d[0] = clampUint8(c[0] * 255 / c[3])
+			d[1] = clampUint8(c[1] * 255 / c[3])
+			d[2] = clampUint8(c[2] * 255 / c[3])
+			d[3] = clampUint8(c[3])
+		}
+	}
+	return dst
+}
+
+// toNRGBA 将图片复制为左上角位于原点的NRGBA图片，不修改原图
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, img, b.Min, draw.Src)
+	return dst
+}
+
+// clampUint8 将浮点数四舍五入并限制在0~255之间
+func clampUint8(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
@@ -0,0 +1,132 @@
+package image
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+)
+
+// Position 水印的位置，零值为右下角
+type Position int
+
+const (
+	PositionBottomRight Position = iota // 右下角
+	PositionBottomLeft                  // 左下角
+	PositionTopRight                    // 右上角
+	PositionTopLeft                     // 左上角
+	PositionCenter                      // 居中
+	PositionTile                        // 平铺整张图片
+)
+
+// TextWatermarkOptions 文字水印选项
+type TextWatermarkOptions struct {
+	Color   color.Color // 文字颜色，nil表示白色
+	Opacity float64     // 不透明度，0~1，0表示0.5
+	Margin  int         // 水印与图片边缘的距离，平铺时为水印之间的间距；0表示按图片尺寸自动计算
+	Shadow  bool        // 在文字右下方绘制一像素的黑色阴影，使文字在浅色背景上也清晰可见
+}
+
+// Watermark 在图片上叠加图片水印
+// 参数：
+//
+//	img - 原图片
+//	mark - 水印图片，透明区域不会覆盖原图
+//	position - 水印的位置
+//	opacity - 水印的不透明度，0~1
+//
+// 返回值：
+//
+//	*image.NRGBA - 叠加水印后的新图片，原图不会被修改；水印与边缘的距离为图片短边的2%
+func Watermark(img, mark image.Image, position Position, opacity float64) *image.NRGBA {
+	return watermark(img, mark, position, opacity, 0)
+}
+
+// WatermarkText 在图片上叠加文字水印
+// 参数：
+//
+//	img - 原图片
+//	text - 水印文字，可以使用\n换行
+//	face - 字体，nil表示内置的7x13点阵字体（只支持ASCII字符）
+//	position - 水印的位置
+//	opts - 文字水印选项
+//
+// 返回值：
+//
+//	*image.NRGBA - 叠加水印后的新图片，原图不会被修改
+func WatermarkText(img image.Image, text string, face font.Face, position Position, opts TextWatermarkOptions) *image.NRGBA {
+	c := opts.Color
+	if c == nil {
+		c = color.White
+	}
+	opacity := opts.Opacity
+	if opacity == 0 {
+		opacity = 0.5
+	}
+
+	// 多行文字按水印位置对齐：右侧的水印右对齐，居中的水印居中对齐
+	topts := TextOptions{Face: face, Color: c}
+	switch position {
+	case PositionBottomRight, PositionTopRight:
+		topts.Align = AlignRight
+	case PositionCenter, PositionTile:
+		topts.Align = AlignCenter
+	}
+	size := MeasureText(text, topts)
+	if size.X == 0 {
+		return toNRGBA(img)
+	}
+	shadow := 0
+	if opts.Shadow {
+		shadow = 1
+	}
+	mark := image.NewNRGBA(image.Rect(0, 0, size.X+shadow, size.Y+shadow))
+	if opts.Shadow {
+		shadowOpts := topts
+		shadowOpts.Color = color.Black
+		DrawText(mark, text, image.Pt(1, 1), shadowOpts)
+	}
+	DrawText(mark, text, image.Point{}, topts)
+	return watermark(img, mark, position, opacity, opts.Margin)
+}
+
+// watermark 按位置叠加水印，margin为0时使用图片短边的2%
+func watermark(img, mark image.Image, position Position, opacity float64, margin int) *image.NRGBA {
+	dst := toNRGBA(img)
+	if opacity <= 0 {
+		return dst
+	}
+	b, mb := dst.Bounds(), mark.Bounds()
+	if margin <= 0 {
+		margin = min(b.Dx(), b.Dy()) / 50
+	}
+	mask := image.NewUniform(color.Alpha{clampUint8(min(opacity, 1) * 255)})
+
+	drawAt := func(x, y int) {
+		r := image.Rect(x, y, x+mb.Dx(), y+mb.Dy())
+		draw.DrawMask(dst, r, mark, mb.Min, mask, image.Point{}, draw.Over)
+	}
+	var x, y int
+	switch position {
+	case PositionTile:
+		for y := margin; y < b.Dy(); y += mb.Dy() + margin {
+			for x := margin; x < b.Dx(); x += mb.Dx() + margin {
+				drawAt(x, y)
+			}
+		}
+		return dst
+	case PositionBottomLeft:
+		x, y = margin, b.Dy()-mb.Dy()-margin
+	case PositionTopRight:
+		x, y = b.Dx()-mb.Dx()-margin, margin
+	case PositionTopLeft:
+		x, y = margin, margin
+	case PositionCenter:
+		x, y = (b.Dx()-mb.Dx())/2, (b.Dy()-mb.Dy())/2
+	default:
+		x, y = b.Dx()-mb.Dx()-margin, b.Dy()-mb.Dy()-margin
+	}
+	drawAt(x, y)
+	return dst
+}
@@ -0,0 +1,141 @@
+// plugin/acl.go - 工具级别的访问控制
+// 每次调用工具前依次检查禁止列表、允许列表和授权函数
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPermissionDenied 调用方没有权限调用工具时返回的错误
+var ErrPermissionDenied = errors.New("没有权限调用工具")
+
+// Identity 表示工具的调用方
+// 通过 WithIdentity 放入上下文，使用带上下文的调用方法时生效
+// 不带上下文的调用方法使用匿名调用方（零值）
+type Identity struct {
+	ID         string            `json:"id"`                   // 调用方标识，例如用户ID或租户ID
+	Roles      []string          `json:"roles,omitempty"`      // 调用方角色
+	Attributes map[string]string `json:"attributes,omitempty"` // 其他属性，供授权函数使用
+}
+
+// HasRole 判断调用方是否拥有指定角色
+func (id Identity) HasRole(role string) bool {
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAnonymous 判断是否为匿名调用方
+func (id Identity) IsAnonymous() bool {
+	return id.ID == "" && len(id.Roles) == 0
+}
+
+// matches 判断调用方的ID或任一角色是否在列表中
+func (id Identity) matches(list []string) bool {
+	for _, item := range list {
+		if (id.ID != "" && item == id.ID) || id.HasRole(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// identityKey 上下文中存放调用方的key
+type identityKey struct{}
+
+// WithIdentity 返回携带调用方信息的上下文
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext 从上下文中获取调用方，不存在时返回匿名调用方
+func IdentityFromContext(ctx context.Context) Identity {
+	if ctx == nil {
+		return Identity{}
+	}
+	id, _ := ctx.Value(identityKey{}).(Identity)
+	return id
+}
+
+// Authorizer 授权函数类型，返回非nil错误表示拒绝调用
+type Authorizer func(caller Identity, tool string) error
+
+// ToolACL 单个工具的访问控制列表
+// 列表项可以是调用方ID或角色名称
+type ToolACL struct {
+	Allow []string `json:"allow,omitempty"` // 允许调用的ID或角色，为空表示不限制
+	Deny  []string `json:"deny,omitempty"`  // 禁止调用的ID或角色，优先于 Allow
+}
+
+// WithAuthorizer 设置授权函数的插件管理器选项
+func WithAuthorizer(authorizer Authorizer) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.authorizer = authorizer
+	}
+}
+
+// WithToolACL 设置工具访问控制列表的插件管理器选项
+func WithToolACL(tool string, acl ToolACL) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.toolACLs[tool] = acl
+	}
+}
+
+// SetAuthorizer 设置授权函数，每次调用工具前都会调用
+// 传入 nil 表示取消授权函数
+func (pm *PluginManager) SetAuthorizer(authorizer Authorizer) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.authorizer = authorizer
+}
+
+// SetToolACL 设置工具的访问控制列表
+func (pm *PluginManager) SetToolACL(tool string, acl ToolACL) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.toolACLs[tool] = acl
+}
+
+// RemoveToolACL 删除工具的访问控制列表
+func (pm *PluginManager) RemoveToolACL(tool string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	delete(pm.toolACLs, tool)
+}
+
+// Authorize 检查调用方是否有权限调用指定工具
+// 依次检查禁止列表、允许列表和授权函数，拒绝时返回包装了 ErrPermissionDenied 的错误
+func (pm *PluginManager) Authorize(caller Identity, tool string) error {
+	pm.mu.RLock()
+	acl, hasACL := pm.toolACLs[tool]
+	authorizer := pm.authorizer
+	pm.mu.RUnlock()
+
+	if hasACL {
+		if caller.matches(acl.Deny) {
+			return fmt.Errorf("%w: 调用方 '%s' 被禁止调用工具 '%s'", ErrPermissionDenied, caller.ID, tool)
+		}
+		if len(acl.Allow) > 0 && !caller.matches(acl.Allow) {
+			return fmt.Errorf("%w: 调用方 '%s' 不在工具 '%s' 的允许列表中", ErrPermissionDenied, caller.ID, tool)
+		}
+	}
+
+	if authorizer != nil {
+		if err := authorizer(caller, tool); err != nil {
+			if errors.Is(err, ErrPermissionDenied) {
+				return err
+			}
+			return fmt.Errorf("%w: %w", ErrPermissionDenied, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// acl_test.go
+// 工具访问控制测试文件
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newEchoFake 创建提供 echo 和 rm 两个工具的假插件
+func newEchoFake() *plugintest.FakePlugin {
+	fake := plugintest.NewFakePlugin("echo")
+	fake.AddTool(plugin.NewTool("echo", "回显"))
+	fake.AddTool(plugin.NewTool("rm", "删除"))
+	return fake
+}
+
+// TestToolACL 测试工具的允许和禁止列表
+func TestToolACL(t *testing.T) {
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{
+		plugin.WithToolACL("rm", plugin.ToolACL{Allow: []string{"admin"}, Deny: []string{"mallory"}}),
+	}, newEchoFake())
+
+	admin := plugin.Identity{ID: "alice", Roles: []string{"admin"}}
+	user := plugin.Identity{ID: "bob", Roles: []string{"user"}}
+	banned := plugin.Identity{ID: "mallory", Roles: []string{"admin"}}
+
+	tests := []struct {
+		name    string
+		caller  plugin.Identity
+		tool    string
+		allowed bool
+	}{
+		{"管理员调用受限工具", admin, "rm", true},
+		{"普通用户调用受限工具", user, "rm", false},
+		{"禁止列表优先", banned, "rm", false},
+		{"匿名调用受限工具", plugin.Identity{}, "rm", false},
+		{"调用不受限工具", user, "echo", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := plugin.WithIdentity(context.Background(), tt.caller)
+			_, err := manager.CallToolWithContext(ctx, tt.tool, map[string]any{"text": "x"})
+			if tt.allowed && err != nil {
+				t.Errorf("应该允许调用，实际错误: %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, plugin.ErrPermissionDenied) {
+				t.Errorf("应该返回 ErrPermissionDenied，实际: %v", err)
+			}
+		})
+	}
+
+	// 不带上下文的调用使用匿名调用方
+	if _, err := manager.CallTool("rm", nil); !errors.Is(err, plugin.ErrPermissionDenied) {
+		t.Errorf("匿名调用应该被拒绝，实际: %v", err)
+	}
+	if _, err := manager.CallToolWithStruct("rm", struct{}{}); !errors.Is(err, plugin.ErrPermissionDenied) {
+		t.Errorf("匿名结构化调用应该被拒绝，实际: %v", err)
+	}
+
+	manager.RemoveToolACL("rm")
+	if _, err := manager.CallTool("rm", nil); err != nil {
+		t.Errorf("删除访问控制列表后应该允许调用，实际: %v", err)
+	}
+}
+
+// TestAuthorizer 测试授权函数
+func TestAuthorizer(t *testing.T) {
+	manager := plugintest.NewManager(newEchoFake())
+
+	var calls []string
+	manager.SetAuthorizer(func(caller plugin.Identity, tool string) error {
+		calls = append(calls, caller.ID+":"+tool)
+		if caller.Attributes["tenant"] != "acme" {
+			return errors.New("租户不匹配")
+		}
+		return nil
+	})
+
+	ctx := plugin.WithIdentity(context.Background(), plugin.Identity{ID: "alice", Attributes: map[string]string{"tenant": "acme"}})
+	if _, err := manager.CallToolWithStructContext(ctx, "echo", map[string]any{"text": "x"}); err != nil {
+		t.Errorf("授权函数应该允许调用，实际错误: %v", err)
+	}
+
+	ctx = plugin.WithIdentity(context.Background(), plugin.Identity{ID: "bob"})
+	_, err := manager.CallToolWithContext(ctx, "echo", nil)
+	if !errors.Is(err, plugin.ErrPermissionDenied) {
+		t.Errorf("应该返回 ErrPermissionDenied，实际: %v", err)
+	}
+
+	if len(calls) != 2 || calls[0] != "alice:echo" || calls[1] != "bob:echo" {
+		t.Errorf("授权函数调用记录错误: %v", calls)
+	}
+
+	manager.SetAuthorizer(nil)
+	if err := manager.Authorize(plugin.Identity{}, "echo"); err != nil {
+		t.Errorf("取消授权函数后应该允许调用，实际: %v", err)
+	}
+}
+
+// TestIdentityFromContext 测试上下文中的调用方
+func TestIdentityFromContext(t *testing.T) {
+	if id := plugin.IdentityFromContext(context.Background()); !id.IsAnonymous() {
+		t.Errorf("没有调用方时应该返回匿名调用方: %+v", id)
+	}
+
+	ctx := plugin.WithIdentity(context.Background(), plugin.Identity{ID: "alice", Roles: []string{"admin"}})
+	id := plugin.IdentityFromContext(ctx)
+	if id.ID != "alice" || !id.HasRole("admin") || id.HasRole("user") || id.IsAnonymous() {
+		t.Errorf("调用方信息错误: %+v", id)
+	}
+}
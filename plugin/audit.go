@@ -0,0 +1,204 @@
+// plugin/audit.go - 工具调用审计
+// 记录每次工具调用的调用方、工具、参数摘要、耗时、结果状态和错误
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	tlog "github.com/gophertool/tool/log"
+)
+
+// 审计记录的结果状态
+const (
+	// AuditStatusSuccess 调用成功
+	AuditStatusSuccess = "success"
+	// AuditStatusError 调用失败或工具返回错误结果
+	AuditStatusError = "error"
+	// AuditStatusDenied 没有权限调用
+	AuditStatusDenied = "denied"
+)
+
+// AuditRecord 单次工具调用的审计记录
+// 出于隐私考虑只记录参数摘要，不记录参数原文
+type AuditRecord struct {
+	Time         time.Time     `json:"time"`                 // 调用开始时间
+	Caller       Identity      `json:"caller"`               // 调用方
+	Tool         string        `json:"tool"`                 // 工具名称
+	Plugin       string        `json:"plugin,omitempty"`     // 工具所属插件
+	ParamsDigest string        `json:"params_digest"`        // 参数的 SHA-256 摘要
+	DryRun       bool          `json:"dry_run,omitempty"`    // 是否为试运行
+	SessionID    string        `json:"session_id,omitempty"` // 会话ID
+	Duration     time.Duration `json:"duration"`             // 调用耗时
+	Status       string        `json:"status"`               // 结果状态
+	Error        string        `json:"error,omitempty"`      // 错误信息
+}
+
+// AuditSink 审计记录的输出目标
+type AuditSink interface {
+	// WriteAudit 写入一条审计记录
+	WriteAudit(record AuditRecord) error
+}
+
+// AuditSinkFunc 将普通函数适配为 AuditSink
+type AuditSinkFunc func(record AuditRecord) error
+
+// WriteAudit 实现 AuditSink 接口
+func (f AuditSinkFunc) WriteAudit(record AuditRecord) error {
+	return f(record)
+}
+
+// NewWriterAuditSink 创建以JSON行格式写入 w 的审计输出
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	return AuditSinkFunc(func(record AuditRecord) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err = w.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// NewLogAuditSink 创建写入 log 包的审计输出
+func NewLogAuditSink(level tlog.Level) AuditSink {
+	return AuditSinkFunc(func(record AuditRecord) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		tlog.Printf(level, "[audit] %s", data)
+		return nil
+	})
+}
+
+// AuditQueue 审计队列接口，db/cache 的 Cache 实现了该接口
+type AuditQueue interface {
+	Push(key string, value string) error
+}
+
+// NewQueueAuditSink 创建将审计记录以JSON格式推入队列的审计输出
+// 例如：NewQueueAuditSink(cacheInstance, "audit:tools")
+func NewQueueAuditSink(queue AuditQueue, key string) AuditSink {
+	return AuditSinkFunc(func(record AuditRecord) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return queue.Push(key, string(data))
+	})
+}
+
+// MultiAuditSink 将审计记录依次写入多个输出，返回第一个错误
+func MultiAuditSink(sinks ...AuditSink) AuditSink {
+	return AuditSinkFunc(func(record AuditRecord) error {
+		var firstErr error
+		for _, sink := range sinks {
+			if err := sink.WriteAudit(record); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// WithAuditSink 设置审计输出的插件管理器选项
+func WithAuditSink(sink AuditSink) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.auditSink = sink
+	}
+}
+
+// SetAuditSink 设置审计输出，传入 nil 表示关闭审计
+func (pm *PluginManager) SetAuditSink(sink AuditSink) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.auditSink = sink
+}
+
+// callInfo 一次工具调用的基本信息，用于权限检查和审计
+type callInfo struct {
+	caller    Identity // 调用方
+	tool      string   // 工具名称
+	params    any      // 调用参数
+	dryRun    bool     // 是否为试运行
+	sessionID string   // 会话ID
+}
+
+// guardCall 检查调用权限、执行调用并记录审计日志
+// 所有对外的工具调用方法都通过该方法执行
+func (pm *PluginManager) guardCall(info callInfo, call func() (*CallToolResult, error)) (*CallToolResult, error) {
+	start := time.Now()
+
+	var result *CallToolResult
+	err := pm.Authorize(info.caller, info.tool)
+	if err == nil {
+		result, err = call()
+	}
+
+	pm.mu.RLock()
+	sink := pm.auditSink
+	pm.mu.RUnlock()
+	if sink == nil {
+		return result, err
+	}
+
+	record := AuditRecord{
+		Time:         start,
+		Caller:       info.caller,
+		Tool:         info.tool,
+		ParamsDigest: paramsDigest(info.params),
+		DryRun:       info.dryRun,
+		SessionID:    info.sessionID,
+		Duration:     time.Since(start),
+		Status:       AuditStatusSuccess,
+	}
+	if plugin, exists := pm.GetPluginByTool(info.tool); exists {
+		record.Plugin = plugin.Name
+	}
+	switch {
+	case errors.Is(err, ErrPermissionDenied):
+		record.Status = AuditStatusDenied
+		record.Error = err.Error()
+	case err != nil:
+		record.Status = AuditStatusError
+		record.Error = err.Error()
+	case result != nil && result.IsError:
+		record.Status = AuditStatusError
+		for _, content := range result.Content {
+			if text, ok := content.(TextContent); ok {
+				record.Error = text.Text
+				break
+			}
+		}
+	}
+
+	if auditErr := sink.WriteAudit(record); auditErr != nil {
+		log.Printf("写入审计记录失败: %v", auditErr)
+	}
+	return result, err
+}
+
+// paramsDigest 计算参数的 SHA-256 摘要
+// 结构体参数先转换为map，保证与等价的map参数摘要一致
+func paramsDigest(params any) string {
+	if _, ok := params.(map[string]any); !ok && params != nil {
+		params = structToMap(params)
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
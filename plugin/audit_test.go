@@ -0,0 +1,111 @@
+// audit_test.go
+// 工具调用审计测试文件
+package plugin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// memoryQueue 测试用的内存队列
+type memoryQueue struct {
+	items map[string][]string
+}
+
+func (q *memoryQueue) Push(key string, value string) error {
+	q.items[key] = append(q.items[key], value)
+	return nil
+}
+
+// TestAuditSink 测试每次调用都会记录审计日志
+func TestAuditSink(t *testing.T) {
+	var records []plugin.AuditRecord
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{
+		plugin.WithToolACL("rm", plugin.ToolACL{Allow: []string{"admin"}}),
+		plugin.WithAuditSink(plugin.AuditSinkFunc(func(record plugin.AuditRecord) error {
+			records = append(records, record)
+			return nil
+		})),
+	}, newEchoFake())
+
+	ctx := plugin.WithIdentity(context.Background(), plugin.Identity{ID: "alice"})
+	if _, err := manager.CallToolWithContext(ctx, "echo", map[string]any{"text": "x"}); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if _, err := manager.CallToolWithContext(ctx, "rm", nil); !errors.Is(err, plugin.ErrPermissionDenied) {
+		t.Fatalf("应该拒绝调用: %v", err)
+	}
+	if _, err := manager.CallTool("missing", nil); err == nil {
+		t.Fatal("调用不存在的工具应该返回错误")
+	}
+	if _, err := manager.CallToolWithStruct("echo", struct {
+		Text string `json:"text"`
+	}{Text: "x"}); err != nil {
+		t.Fatalf("结构化调用失败: %v", err)
+	}
+
+	if len(records) != 4 {
+		t.Fatalf("审计记录数量错误，期望: 4, 实际: %d", len(records))
+	}
+
+	first := records[0]
+	if first.Caller.ID != "alice" || first.Tool != "echo" || first.Plugin != "echo" || first.Status != plugin.AuditStatusSuccess {
+		t.Errorf("成功调用的审计记录错误: %+v", first)
+	}
+	if first.ParamsDigest == "" || first.Time.IsZero() {
+		t.Errorf("审计记录缺少参数摘要或时间: %+v", first)
+	}
+	if records[1].Status != plugin.AuditStatusDenied || records[1].Error == "" {
+		t.Errorf("拒绝调用的审计记录错误: %+v", records[1])
+	}
+	if records[2].Status != plugin.AuditStatusError || records[2].Plugin != "" {
+		t.Errorf("失败调用的审计记录错误: %+v", records[2])
+	}
+	if records[3].ParamsDigest != first.ParamsDigest {
+		t.Error("等价的结构体参数和map参数应该有相同的摘要")
+	}
+
+	// 关闭审计后不再记录
+	manager.SetAuditSink(nil)
+	manager.CallTool("echo", nil)
+	if len(records) != 4 {
+		t.Errorf("关闭审计后不应该记录，实际: %d", len(records))
+	}
+}
+
+// TestAuditSinkOutputs 测试内置的审计输出
+func TestAuditSinkOutputs(t *testing.T) {
+	var buf bytes.Buffer
+	queue := &memoryQueue{items: make(map[string][]string)}
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{plugin.WithAuditSink(plugin.MultiAuditSink(
+		plugin.NewWriterAuditSink(&buf),
+		plugin.NewQueueAuditSink(queue, "audit:tools"),
+	))}, newEchoFake())
+
+	if _, err := manager.CallTool("echo", map[string]any{"text": "secret"}); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	line := strings.TrimSpace(buf.String())
+	var record plugin.AuditRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatalf("审计记录不是有效的JSON: %v, %s", err, line)
+	}
+	if record.Tool != "echo" || record.Status != plugin.AuditStatusSuccess {
+		t.Errorf("审计记录内容错误: %+v", record)
+	}
+	if strings.Contains(line, "secret") {
+		t.Error("审计记录不应该包含参数原文")
+	}
+
+	if items := queue.items["audit:tools"]; len(items) != 1 || items[0] != line {
+		t.Errorf("队列中的审计记录错误: %v", items)
+	}
+}
@@ -0,0 +1,126 @@
+// plugin/cancel.go - 插件内可感知的协作式取消
+// 主程序取消调用时通过RPC通知插件，插件实现中的长时间循环可以通过上下文及时退出并返回部分结果
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CancelGracePeriod 调用被取消后等待插件返回部分结果的最长时间
+// 超过该时间插件仍未返回时，直接返回取消错误
+var CancelGracePeriod = 5 * time.Second
+
+// ToolPluginContextInterface 定义了支持取消的工具插件接口
+// 主程序取消调用时 ctx 会被取消，插件应在 ctx.Done() 关闭后尽快返回
+// 被取消后返回的结果会作为部分结果交给主程序，并在元数据中标记 cancelled
+type ToolPluginContextInterface interface {
+	ToolPluginInterface
+
+	// CallToolContext 带可取消上下文调用工具
+	CallToolContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error)
+}
+
+// CancelCallArgs 取消调用的参数
+type CancelCallArgs struct {
+	CallID string `json:"call_id"` // 要取消的调用ID
+}
+
+// CallToolContext 实现 ToolPluginContextInterface 接口的 CallToolContext 方法
+// ctx 取消时通知插件取消调用，插件确认后在 CancelGracePeriod 内等待插件返回部分结果
+func (t *ToolPluginRPC) CallToolContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	args := CallToolArgs{
+		ToolName: toolName,
+		Params:   params,
+		CallID:   newRandomID(),
+	}
+	var result CallToolResult
+	done := t.goCall("Plugin.CallTool", args, &result)
+
+	select {
+	case call := <-done:
+		return &result, call.Error
+	case <-ctx.Done():
+	}
+
+	// 通知插件取消，插件确认后等待其返回部分结果
+	// 旧版本插件或不支持取消的插件不会确认，此时立即返回
+	cancelErr := fmt.Errorf("工具调用被取消: %w", ctx.Err())
+	var acknowledged bool
+	cancelDone := t.goCall("Plugin.CancelCall", CancelCallArgs{CallID: args.CallID}, &acknowledged)
+
+	timer := time.NewTimer(CancelGracePeriod)
+	defer timer.Stop()
+	for {
+		select {
+		case call := <-done:
+			if call.Error != nil {
+				return nil, cancelErr
+			}
+			return &result, nil
+		case cancelCall := <-cancelDone:
+			if cancelCall.Error != nil || !acknowledged {
+				return nil, cancelErr
+			}
+			cancelDone = nil
+		case <-timer.C:
+			return nil, cancelErr
+		}
+	}
+}
+
+// pendingCancelTTL 提前到达的取消请求的保留时间
+// 超过该时间调用仍未开始时丢弃，避免已经结束或不存在的调用留下记录
+const pendingCancelTTL = time.Minute
+
+// pendingCancel 在调用开始前到达的取消请求，调用登记时发现该记录会立即取消
+type pendingCancel struct {
+	at time.Time
+}
+
+// CancelCall 处理来自客户端的 CancelCall RPC 调用
+// 取消请求可能早于调用到达插件，此时记录下来，调用开始时立即取消
+func (s *ToolPluginRPCServer) CancelCall(args CancelCallArgs, resp *bool) error {
+	if _, ok := s.Impl.(ToolPluginContextInterface); !ok {
+		return nil
+	}
+
+	s.prunePendingCancels()
+	if actual, loaded := s.calls.LoadOrStore(args.CallID, pendingCancel{at: time.Now()}); loaded {
+		if cancel, ok := actual.(context.CancelFunc); ok {
+			cancel()
+		}
+	}
+	*resp = true
+	return nil
+}
+
+// prunePendingCancels 删除超过 pendingCancelTTL 的取消记录
+func (s *ToolPluginRPCServer) prunePendingCancels() {
+	s.calls.Range(func(key, value any) bool {
+		if pending, ok := value.(pendingCancel); ok && time.Since(pending.at) > pendingCancelTTL {
+			s.calls.CompareAndDelete(key, value)
+		}
+		return true
+	})
+}
+
+// callToolContext 使用可取消的上下文调用插件实现
+// 调用期间上下文登记在 calls 中，收到 CancelCall 时取消；登记前已收到取消请求时直接以已取消的上下文调用
+func (s *ToolPluginRPCServer) callToolContext(impl ToolPluginContextInterface, args CallToolArgs) (*CallToolResult, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, loaded := s.calls.LoadOrStore(args.CallID, cancel); loaded {
+		cancel()
+	}
+	defer func() {
+		s.calls.Delete(args.CallID)
+		cancel()
+	}()
+
+	result, err := impl.CallToolContext(ctx, args.ToolName, args.Params)
+	if err == nil && result != nil && ctx.Err() != nil {
+		result.SetMeta("cancelled", true)
+	}
+	return result, err
+}
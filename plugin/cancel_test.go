@@ -0,0 +1,127 @@
+// cancel_test.go
+// 协作式取消测试文件
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// loopPlugin 测试用的长时间运行插件，取消时返回部分结果
+type loopPlugin struct {
+	echoPlugin
+}
+
+func (p *loopPlugin) CallToolContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return NewCallToolResult().SetMeta("count", count), nil
+		case <-ticker.C:
+			count++
+		}
+	}
+}
+
+// slowPlugin 测试用的不支持取消的慢速插件
+type slowPlugin struct {
+	echoPlugin
+}
+
+func (p *slowPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	time.Sleep(time.Second)
+	return NewCallToolResult(), nil
+}
+
+// TestCallToolContextPartialResult 测试取消后插件返回部分结果
+func TestCallToolContextPartialResult(t *testing.T) {
+	manager := NewPluginManager()
+	loaded := &LoadedPlugin{Name: "loop", Instance: newPipeClient(t, &ToolPluginRPCServer{Impl: &loopPlugin{}})}
+	manager.plugins["loop"] = loaded
+	manager.toolMap["loop"] = loaded
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	result, err := manager.CallToolWithContext(ctx, "loop", nil)
+	if err != nil {
+		t.Fatalf("取消后应该返回部分结果，实际错误: %v", err)
+	}
+	if result.Meta["cancelled"] != true {
+		t.Errorf("部分结果应该标记为已取消: %v", result.Meta)
+	}
+	if count, _ := result.Meta["count"].(int); count == 0 {
+		t.Errorf("部分结果应该包含已完成的工作: %v", result.Meta)
+	}
+}
+
+// TestCallToolContextNotSupported 测试不支持取消的插件立即返回取消错误
+func TestCallToolContextNotSupported(t *testing.T) {
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: &slowPlugin{}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.CallToolContext(ctx, "slow", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("应该返回取消错误，实际: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("不支持取消的插件不应该等待，实际耗时: %v", elapsed)
+	}
+}
+
+// TestCallToolContextCompleted 测试未取消时正常返回结果
+func TestCallToolContextCompleted(t *testing.T) {
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: &echoPlugin{}})
+
+	result, err := client.CallToolContext(context.Background(), "echo", map[string]any{"text": "x"})
+	if err != nil || len(result.Content) != 3 {
+		t.Errorf("调用结果错误: %+v, %v", result, err)
+	}
+	if _, ok := result.Meta["cancelled"]; ok {
+		t.Error("未取消的调用不应该标记为已取消")
+	}
+}
+
+// TestCancelCallBeforeStart 测试取消请求早于调用到达时调用开始后立即被取消
+func TestCancelCallBeforeStart(t *testing.T) {
+	server := &ToolPluginRPCServer{Impl: &loopPlugin{}}
+
+	var acknowledged bool
+	if err := server.CancelCall(CancelCallArgs{CallID: "early"}, &acknowledged); err != nil || !acknowledged {
+		t.Fatalf("支持取消的插件应该确认提前到达的取消请求: %v, %v", acknowledged, err)
+	}
+
+	done := make(chan *CallToolResult, 1)
+	go func() {
+		result, _ := server.callToolContext(&loopPlugin{}, CallToolArgs{ToolName: "loop", CallID: "early"})
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		if result.Meta["cancelled"] != true {
+			t.Errorf("提前取消的调用应该标记为已取消: %v", result.Meta)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("提前取消的调用应该立即返回")
+	}
+	if _, ok := server.calls.Load("early"); ok {
+		t.Error("调用结束后应该删除取消记录")
+	}
+
+	// 不支持取消的插件不确认取消请求
+	slow := &ToolPluginRPCServer{Impl: &slowPlugin{}}
+	acknowledged = false
+	if err := slow.CancelCall(CancelCallArgs{CallID: "slow"}, &acknowledged); err != nil || acknowledged {
+		t.Errorf("不支持取消的插件不应该确认取消请求: %v, %v", acknowledged, err)
+	}
+}
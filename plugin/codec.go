@@ -0,0 +1,287 @@
+// plugin/codec.go - RPC传输层编解码器
+// 主程序和插件在建立连接后协商使用的编解码器，协商失败时回退到 net/rpc 默认的 gob
+package plugin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/rpc"
+
+	"github.com/hashicorp/go-plugin"
+
+	"github.com/gophertool/tool/encoding/msgpack"
+)
+
+// 内置编解码器名称
+const (
+	// CodecGob net/rpc 默认的 gob 编解码器，需要注册类型且仅支持Go
+	CodecGob = "gob"
+	// CodecJSON JSON 编解码器
+	CodecJSON = "json"
+	// CodecMsgpack MessagePack 编解码器，数据体积小于JSON
+	CodecMsgpack = "msgpack"
+)
+
+// maxFrameSize 单个数据帧允许的最大字节数
+const maxFrameSize = 256 << 20
+
+// PreferredCodecs 主程序默认的编解码器偏好顺序
+// 插件不支持协商（旧版本插件）或不支持其中任何一个时，回退到 gob
+var PreferredCodecs = []string{CodecMsgpack, CodecJSON}
+
+// Codec 定义RPC传输层的编解码器
+// 实现该接口并通过 RegisterCodec 注册后，即可在主程序和插件的协商中使用
+type Codec interface {
+	// Name 返回编解码器名称，用于协商
+	Name() string
+	// NewClientCodec 基于连接创建客户端编解码器
+	NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec
+	// NewServerCodec 基于连接创建服务端编解码器
+	NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec
+}
+
+// 已注册的编解码器，key为编解码器名称
+var codecs = make(map[string]Codec)
+
+func init() {
+	RegisterCodec(NewFramedCodec(CodecJSON, json.Marshal, json.Unmarshal))
+	RegisterCodec(NewFramedCodec(CodecMsgpack, msgpack.Marshal, msgpack.Unmarshal))
+}
+
+// RegisterCodec 注册编解码器
+// 主程序和插件都需要注册同名的编解码器才能协商成功
+func RegisterCodec(codec Codec) {
+	codecs[codec.Name()] = codec
+}
+
+// GetRegisteredCodecs 获取已注册的所有编解码器名称
+func GetRegisteredCodecs() []string {
+	names := make([]string, 0, len(codecs))
+	for name := range codecs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// NewFramedCodec 基于序列化函数创建编解码器
+// 每个RPC消息由头部帧和消息体帧组成，帧格式为4字节大端长度加序列化数据
+// 可用于接入 protobuf 等其他序列化方式
+func NewFramedCodec(name string, marshal func(any) ([]byte, error), unmarshal func([]byte, any) error) Codec {
+	return &framedCodec{name: name, marshal: marshal, unmarshal: unmarshal}
+}
+
+// framedCodec 基于长度前缀帧的编解码器
+type framedCodec struct {
+	name      string
+	marshal   func(any) ([]byte, error)
+	unmarshal func([]byte, any) error
+}
+
+// Name 返回编解码器名称
+func (c *framedCodec) Name() string {
+	return c.name
+}
+
+// NewClientCodec 创建客户端编解码器
+func (c *framedCodec) NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return c.newStream(conn)
+}
+
+// NewServerCodec 创建服务端编解码器
+func (c *framedCodec) NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return c.newStream(conn)
+}
+
+func (c *framedCodec) newStream(conn io.ReadWriteCloser) *framedStream {
+	return &framedStream{
+		codec: c,
+		conn:  conn,
+		r:     bufio.NewReader(conn),
+		w:     bufio.NewWriter(conn),
+	}
+}
+
+// framedStream 同时实现 rpc.ClientCodec 和 rpc.ServerCodec
+// net/rpc 保证读写各自串行调用，因此不需要额外加锁
+type framedStream struct {
+	codec *framedCodec
+	conn  io.ReadWriteCloser
+	r     *bufio.Reader
+	w     *bufio.Writer
+}
+
+// WriteRequest 写入请求头和请求体
+func (s *framedStream) WriteRequest(r *rpc.Request, body any) error {
+	header, err := s.codec.marshal(r)
+	if err != nil {
+		return err
+	}
+	payload, err := s.codec.marshal(body)
+	if err != nil {
+		return err
+	}
+	return s.writeFrames(header, payload)
+}
+
+// ReadResponseHeader 读取响应头
+func (s *framedStream) ReadResponseHeader(r *rpc.Response) error {
+	return s.readFrame(r)
+}
+
+// ReadResponseBody 读取响应体，body 为 nil 时丢弃数据
+func (s *framedStream) ReadResponseBody(body any) error {
+	return s.readFrame(body)
+}
+
+// ReadRequestHeader 读取请求头
+func (s *framedStream) ReadRequestHeader(r *rpc.Request) error {
+	return s.readFrame(r)
+}
+
+// ReadRequestBody 读取请求体，body 为 nil 时丢弃数据
+func (s *framedStream) ReadRequestBody(body any) error {
+	return s.readFrame(body)
+}
+
+// WriteResponse 写入响应头和响应体
+// 响应体序列化失败时将错误写入响应头，保证连接上的数据帧完整
+func (s *framedStream) WriteResponse(r *rpc.Response, body any) error {
+	payload, err := s.codec.marshal(body)
+	if err != nil {
+		r.Error = fmt.Sprintf("序列化响应失败: %v", err)
+		if payload, err = s.codec.marshal(nil); err != nil {
+			return err
+		}
+	}
+	header, err := s.codec.marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.writeFrames(header, payload)
+}
+
+// Close 关闭底层连接
+func (s *framedStream) Close() error {
+	return s.conn.Close()
+}
+
+// writeFrames 依次写入多个数据帧并刷新缓冲区
+func (s *framedStream) writeFrames(frames ...[]byte) error {
+	for _, frame := range frames {
+		if err := binary.Write(s.w, binary.BigEndian, uint32(len(frame))); err != nil {
+			return err
+		}
+		if _, err := s.w.Write(frame); err != nil {
+			return err
+		}
+	}
+	return s.w.Flush()
+}
+
+// readFrame 读取一个数据帧并反序列化到 v 中
+func (s *framedStream) readFrame(v any) error {
+	var size uint32
+	if err := binary.Read(s.r, binary.BigEndian, &size); err != nil {
+		return err
+	}
+	if size > maxFrameSize {
+		return fmt.Errorf("数据帧过大: %d 字节", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(s.r, data); err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return s.codec.unmarshal(data, v)
+}
+
+// NegotiateCodecArgs 编解码器协商参数
+type NegotiateCodecArgs struct {
+	Codecs   []string `json:"codecs"`    // 主程序支持的编解码器，按偏好排序
+	StreamID uint32   `json:"stream_id"` // 用于建立新连接的 MuxBroker 流ID
+}
+
+// NegotiateCodecReply 编解码器协商结果
+type NegotiateCodecReply struct {
+	Codec string `json:"codec"` // 插件选择的编解码器，为 gob 时继续使用原连接
+}
+
+// NegotiateCodec 处理来自主程序的编解码器协商请求
+// 选择第一个双方都支持的编解码器，并在 MuxBroker 的新流上启动对应的RPC服务
+func (s *ToolPluginRPCServer) NegotiateCodec(args NegotiateCodecArgs, resp *NegotiateCodecReply) error {
+	resp.Codec = CodecGob
+	if s.broker == nil {
+		return nil
+	}
+
+	for _, name := range args.Codecs {
+		codec, ok := codecs[name]
+		if !ok {
+			continue
+		}
+		resp.Codec = name
+		go s.serveCodec(args.StreamID, codec)
+		return nil
+	}
+	return nil
+}
+
+// serveCodec 在指定的流上使用编解码器提供RPC服务
+func (s *ToolPluginRPCServer) serveCodec(id uint32, codec Codec) {
+	conn, err := s.broker.Accept(id)
+	if err != nil {
+		log.Printf("接受 %s 编解码器连接失败: %v", codec.Name(), err)
+		return
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("Plugin", s); err != nil {
+		log.Printf("注册 %s 编解码器RPC服务失败: %v", codec.Name(), err)
+		conn.Close()
+		return
+	}
+	server.ServeCodec(codec.NewServerCodec(conn))
+}
+
+// negotiateCodec 与插件协商编解码器
+// 协商成功时返回使用新编解码器的客户端，否则返回原有的 gob 客户端
+func negotiateCodec(broker *plugin.MuxBroker, client *rpc.Client, preferred []string) (*rpc.Client, string) {
+	supported := make([]string, 0, len(preferred))
+	for _, name := range preferred {
+		if _, ok := codecs[name]; ok {
+			supported = append(supported, name)
+		}
+	}
+	if broker == nil || len(supported) == 0 {
+		return client, CodecGob
+	}
+
+	args := NegotiateCodecArgs{
+		Codecs:   supported,
+		StreamID: broker.NextId(),
+	}
+	var reply NegotiateCodecReply
+	if err := client.Call("Plugin.NegotiateCodec", args, &reply); err != nil {
+		// 旧版本插件没有协商方法，继续使用 gob
+		return client, CodecGob
+	}
+
+	codec, ok := codecs[reply.Codec]
+	if !ok {
+		return client, CodecGob
+	}
+
+	conn, err := broker.Dial(args.StreamID)
+	if err != nil {
+		log.Printf("建立 %s 编解码器连接失败，回退到 gob: %v", reply.Codec, err)
+		return client, CodecGob
+	}
+	return rpc.NewClientWithCodec(codec.NewClientCodec(conn)), reply.Codec
+}
@@ -0,0 +1,111 @@
+// codec_test.go
+// RPC编解码器测试文件
+// 测试各编解码器下的RPC调用
+package plugin
+
+import (
+	"net"
+	"net/rpc"
+	"reflect"
+	"testing"
+)
+
+// echoPlugin 测试用的插件实现，将参数原样返回
+type echoPlugin struct{}
+
+func (p *echoPlugin) GetPluginInfo() (PluginInfo, error) {
+	return PluginInfo{Name: "echo", Version: "1.0.0"}, nil
+}
+
+func (p *echoPlugin) GetTools() ([]Tool, error) {
+	tool := NewTool("echo", "回显参数", WithString("text", Required()))
+	return []Tool{*tool}, nil
+}
+
+func (p *echoPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	result := NewCallToolResult()
+	result.AddTextContent(toolName, "tool")
+	result.AddStructContent(params, "params")
+	result.AddImageContent("aW1hZ2U=", "image/png", "image.png")
+	result.SetMeta("count", len(params))
+	return result, nil
+}
+
+// TestCodecRPC 测试使用各编解码器进行RPC调用
+func TestCodecRPC(t *testing.T) {
+	// JSON 不区分整数和浮点数，msgpack 保留整数类型
+	numbers := map[string]any{CodecJSON: 3.0, CodecMsgpack: int64(3)}
+	counts := map[string]any{CodecJSON: 2.0, CodecMsgpack: int64(2)}
+
+	for _, name := range []string{CodecJSON, CodecMsgpack} {
+		t.Run(name, func(t *testing.T) {
+			codec := codecs[name]
+			serverConn, clientConn := net.Pipe()
+
+			server := rpc.NewServer()
+			if err := server.RegisterName("Plugin", &ToolPluginRPCServer{Impl: &echoPlugin{}}); err != nil {
+				t.Fatalf("注册RPC服务失败: %v", err)
+			}
+			go server.ServeCodec(codec.NewServerCodec(serverConn))
+
+			client := &ToolPluginRPC{client: rpc.NewClientWithCodec(codec.NewClientCodec(clientConn)), codec: name}
+			defer client.client.Close()
+
+			info, err := client.GetPluginInfo()
+			if err != nil || info.Name != "echo" {
+				t.Fatalf("获取插件信息失败: %v, %v", info, err)
+			}
+
+			tools, err := client.GetTools()
+			if err != nil || len(tools) != 1 {
+				t.Fatalf("获取工具失败: %v, %v", tools, err)
+			}
+			if !reflect.DeepEqual(tools[0].InputSchema.Required, []string{"text"}) {
+				t.Errorf("工具模式不一致: %+v", tools[0].InputSchema)
+			}
+
+			result, err := client.CallTool("echo", map[string]any{"text": "你好", "n": 3})
+			if err != nil {
+				t.Fatalf("调用工具失败: %v", err)
+			}
+			if len(result.Content) != 3 {
+				t.Fatalf("内容数量错误: %d", len(result.Content))
+			}
+			if text, ok := result.Content[0].(TextContent); !ok || text.Text != "echo" {
+				t.Errorf("文本内容错误: %#v", result.Content[0])
+			}
+			structContent, ok := result.Content[1].(StructContent)
+			if !ok {
+				t.Fatalf("结构体内容类型错误: %#v", result.Content[1])
+			}
+			params := structContent.Data.(map[string]any)
+			if params["text"] != "你好" || params["n"] != numbers[name] {
+				t.Errorf("参数往返不一致: %v", params)
+			}
+			if file, ok := result.Content[2].(FileContent); !ok || file.FileType != FileTypeImage || file.Name != "image.png" {
+				t.Errorf("文件内容错误: %#v", result.Content[2])
+			}
+			if result.Meta["count"] != counts[name] {
+				t.Errorf("元数据错误: %v", result.Meta)
+			}
+		})
+	}
+}
+
+// TestNegotiateCodecWithoutBroker 测试没有 MuxBroker 时协商回退到 gob
+func TestNegotiateCodecWithoutBroker(t *testing.T) {
+	server := &ToolPluginRPCServer{Impl: &echoPlugin{}}
+
+	var reply NegotiateCodecReply
+	if err := server.NegotiateCodec(NegotiateCodecArgs{Codecs: []string{CodecMsgpack}}, &reply); err != nil {
+		t.Fatalf("协商失败: %v", err)
+	}
+	if reply.Codec != CodecGob {
+		t.Errorf("没有 MuxBroker 时应该回退到 gob，实际: %s", reply.Codec)
+	}
+
+	client, codec := negotiateCodec(nil, nil, PreferredCodecs)
+	if client != nil || codec != CodecGob {
+		t.Errorf("没有 MuxBroker 时应该使用原客户端，实际: %v, %s", client, codec)
+	}
+}
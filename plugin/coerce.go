@@ -0,0 +1,158 @@
+// plugin/coerce.go - 按输入模式转换参数类型
+// JSON 中的数字总是 float64，前端传来的值也经常是字符串，
+// 由主程序按工具声明的类型统一转换，插件不需要各自处理 float64/int/string 的各种组合
+package plugin
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// CoercionMode 参数类型转换模式
+type CoercionMode int
+
+const (
+	// CoercionOff 不检查也不转换参数类型（默认）
+	CoercionOff CoercionMode = iota
+	// CoercionLenient 按输入模式转换参数类型，例如 "42"→42、1→true、3→"3"，无法转换的值原样传递
+	CoercionLenient
+	// CoercionStrict 不转换参数类型，类型与输入模式不一致时返回 ParamsError
+	CoercionStrict
+)
+
+// WithParamCoercion 设置调用插件前的参数类型转换模式
+// 类型为 integer 的参数转换为 int，number 转换为 float64；嵌套对象和数组元素按各自的模式处理
+// 调用方传入的参数不会被修改，结构化参数（CallToolWithStruct）不做处理
+func WithParamCoercion(mode CoercionMode) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.coercion = mode
+	}
+}
+
+// coerceParams 按属性模式转换参数，返回转换后的参数副本
+func coerceParams(properties map[string]any, params map[string]any, mode CoercionMode) (map[string]any, error) {
+	var errs ParamsError
+	result := coerceObject(properties, params, "", mode, &errs)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// coerceObject 转换对象的各个属性，错误追加到 errs
+func coerceObject(properties map[string]any, object map[string]any, prefix string, mode CoercionMode, errs *ParamsError) map[string]any {
+	if object == nil {
+		return nil
+	}
+	result := make(map[string]any, len(object))
+	for name, value := range object {
+		schema, _ := properties[name].(map[string]any)
+		result[name] = coerceValue(schema, value, prefix+name, mode, errs)
+	}
+	return result
+}
+
+// coerceValue 按模式转换单个值，没有模式或值为 nil 时原样返回
+func coerceValue(schema map[string]any, value any, path string, mode CoercionMode, errs *ParamsError) any {
+	if schema == nil || value == nil {
+		return value
+	}
+	schemaType, _ := schema["type"].(string)
+
+	switch schemaType {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			break
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		return coerceObject(properties, object, path+".", mode, errs)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			break
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		result := make([]any, len(items))
+		for i, item := range items {
+			result[i] = coerceValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), mode, errs)
+		}
+		return result
+	}
+
+	if mode == CoercionStrict {
+		if !matchesSchemaType(schemaType, value) {
+			*errs = append(*errs, &ParamError{Field: path, Err: fmt.Errorf("类型应为 %s，实际为 %T", schemaType, value)})
+		}
+		return value
+	}
+
+	converted, err := convertToSchemaType(schemaType, value)
+	if err != nil {
+		return value
+	}
+	return converted
+}
+
+// matchesSchemaType 判断值是否符合模式类型，未知类型总是符合
+func matchesSchemaType(schemaType string, value any) bool {
+	kind := reflect.TypeOf(value).Kind()
+	switch schemaType {
+	case "string":
+		return kind == reflect.String
+	case "boolean":
+		return kind == reflect.Bool
+	case "number":
+		return isNumberKind(kind)
+	case "integer":
+		if !isNumberKind(kind) {
+			return false
+		}
+		_, err := toInt64(value)
+		return err == nil
+	case "array":
+		return kind == reflect.Slice || kind == reflect.Array
+	case "object":
+		return kind == reflect.Map || kind == reflect.Struct
+	}
+	return true
+}
+
+// isNumberKind 判断是否为数值类型
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// convertToSchemaType 将值转换为模式类型对应的Go类型，未知类型原样返回
+func convertToSchemaType(schemaType string, value any) (any, error) {
+	switch schemaType {
+	case "string":
+		return toString(value)
+	case "number":
+		return toFloat64(value)
+	case "integer":
+		i, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		if i != int64(int(i)) {
+			return nil, fmt.Errorf("数值 %d 超出整数范围", i)
+		}
+		return int(i), nil
+	case "boolean":
+		if s, ok := value.(string); ok {
+			return strconv.ParseBool(s)
+		}
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		// 只有 0 和 1 可以转换为布尔值
+		f, err := toFloat64(value)
+		if err != nil || (f != 0 && f != 1) {
+			return nil, fmt.Errorf("无法将 %v 转换为布尔值", value)
+		}
+		return f == 1, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,96 @@
+// coerce_test.go
+// 参数类型转换测试文件
+package plugin_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newCoerceTool 创建测试用的工具定义
+func newCoerceTool() *plugin.Tool {
+	return plugin.NewTool("search", "搜索",
+		plugin.WithString("query"),
+		plugin.WithInteger("limit"),
+		plugin.WithNumber("score"),
+		plugin.WithBoolean("exact"),
+		plugin.WithArray("ids", plugin.WithIntegerItems()),
+		plugin.WithObject("filter", plugin.Properties(map[string]any{
+			"enabled": map[string]any{"type": "boolean"},
+		})),
+	)
+}
+
+// TestParamCoercionLenient 测试宽松模式下的类型转换
+func TestParamCoercionLenient(t *testing.T) {
+	fake := plugintest.NewFakePlugin("record")
+	fake.AddTool(newCoerceTool())
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{plugin.WithParamCoercion(plugin.CoercionLenient)}, fake)
+
+	params := map[string]any{
+		"query":  42.0,
+		"limit":  "10",
+		"score":  "0.5",
+		"exact":  1.0,
+		"ids":    []any{"1", 2.0},
+		"filter": map[string]any{"enabled": "true"},
+		"extra":  "保持不变",
+	}
+	if _, err := manager.CallTool("search", params); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	want := map[string]any{
+		"query":  "42",
+		"limit":  10,
+		"score":  0.5,
+		"exact":  true,
+		"ids":    []any{1, 2},
+		"filter": map[string]any{"enabled": true},
+		"extra":  "保持不变",
+	}
+	if !reflect.DeepEqual(lastParams(fake), want) {
+		t.Errorf("类型转换错误\n期望: %#v\n实际: %#v", want, lastParams(fake))
+	}
+	if params["limit"] != "10" {
+		t.Errorf("调用方的参数不应该被修改: %v", params)
+	}
+
+	// 无法转换的值原样传递
+	manager.CallTool("search", map[string]any{"limit": "abc", "exact": 2.0})
+	if lastParams(fake)["limit"] != "abc" || lastParams(fake)["exact"] != 2.0 {
+		t.Errorf("无法转换的值应该原样传递: %v", lastParams(fake))
+	}
+}
+
+// TestParamCoercionStrict 测试严格模式下的类型校验
+func TestParamCoercionStrict(t *testing.T) {
+	fake := plugintest.NewFakePlugin("record")
+	fake.AddTool(newCoerceTool())
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{plugin.WithParamCoercion(plugin.CoercionStrict)}, fake)
+
+	if _, err := manager.CallTool("search", map[string]any{"limit": 10.0, "score": 1, "ids": []any{3.0}}); err != nil {
+		t.Errorf("类型一致的参数不应该报错: %v", err)
+	}
+	if lastParams(fake)["limit"] != 10.0 {
+		t.Errorf("严格模式不应该转换参数: %v", lastParams(fake))
+	}
+
+	_, err := manager.CallTool("search", map[string]any{"limit": "10", "ids": []any{1.5}, "filter": map[string]any{"enabled": 1}})
+	var paramsErr plugin.ParamsError
+	if !errors.As(err, &paramsErr) {
+		t.Fatalf("应该返回 ParamsError，实际: %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, e := range paramsErr {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"limit", "ids[0]", "filter.enabled"} {
+		if !fields[field] {
+			t.Errorf("缺少字段 %s 的错误: %v", field, err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package plugin
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -518,3 +519,39 @@ func TestTool(t *testing.T) {
 		t.Error("缺少必需参数应该返回错误")
 	}
 }
+
+// TestToolExamples 测试工具使用示例
+func TestToolExamples(t *testing.T) {
+	tool := NewTool("add", "加法",
+		WithNumber("a", Required()),
+		WithNumber("b", Required()),
+		WithExample("两个整数相加", map[string]any{"a": 1, "b": 2}, "3"),
+		WithExamples(ToolExample{Params: map[string]any{"a": 0.5, "b": 0.25}, Result: "0.75"}),
+	)
+
+	if len(tool.Examples) != 2 {
+		t.Fatalf("示例数量错误，期望: 2, 实际: %d", len(tool.Examples))
+	}
+	if tool.Examples[0].Description != "两个整数相加" || tool.Examples[0].Result != "3" {
+		t.Errorf("示例内容错误: %+v", tool.Examples[0])
+	}
+
+	// 测试JSON序列化
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("工具序列化失败: %v", err)
+	}
+	var decoded Tool
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("工具反序列化失败: %v", err)
+	}
+	if len(decoded.Examples) != 2 || decoded.Examples[1].Params["a"] != 0.5 {
+		t.Errorf("示例序列化错误: %s", data)
+	}
+
+	// 没有示例时不输出 examples 字段
+	data, _ = json.Marshal(NewTool("empty", "空工具"))
+	if strings.Contains(string(data), "examples") {
+		t.Errorf("没有示例时不应该输出 examples 字段: %s", data)
+	}
+}
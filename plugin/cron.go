@@ -0,0 +1,212 @@
+// plugin/cron.go - cron 表达式解析
+// 支持标准的5字段表达式（分 时 日 月 周）以及 @daily、@every 1m 等描述符
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 计划任务的执行时间表
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间，没有下一次时返回零值
+	Next(t time.Time) time.Time
+}
+
+// cronField cron 表达式单个字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{name: "分钟", min: 0, max: 59}
+	cronHour   = cronField{name: "小时", min: 0, max: 23}
+	cronDom    = cronField{name: "日", min: 1, max: 31}
+	cronMonth  = cronField{name: "月", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期允许 7 表示周日，解析后统一为 0
+	cronDow = cronField{name: "星期", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// cronDescriptors 预定义的描述符
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule 解析后的 cron 表达式，每个字段用位图表示允许的取值
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和星期都有限制时满足其一即可，与标准 cron 一致
+	domStar, dowStar bool
+}
+
+// everySchedule 固定间隔执行的时间表
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next 实现 Schedule 接口
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// ParseSchedule 解析 cron 表达式
+// 支持5字段表达式（分 时 日 月 周），字段可以使用 *、?、列表（1,3）、范围（1-5）、步长（*/15、1-30/5）
+// 以及月份和星期的英文缩写（JAN、MON）；也支持 @yearly、@monthly、@weekly、@daily、@hourly 和 @every <时长>
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("解析 @every 间隔失败: %w", err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("@every 间隔必须大于0: %s", rest)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("未知的描述符: %s", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式应该包含5个字段，实际为 %d 个: %s", len(fields), spec)
+	}
+
+	var s cronSchedule
+	var err error
+	parsers := []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.minute, cronMinute},
+		{&s.hour, cronHour},
+		{&s.dom, cronDom},
+		{&s.month, cronMonth},
+		{&s.dow, cronDow},
+	}
+	for i, p := range parsers {
+		if *p.bits, err = parseCronField(fields[i], p.field); err != nil {
+			return nil, err
+		}
+	}
+	// 周日可以写作 0 或 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	// 以 * 开头的字段（包括 */n）视为不限制，与标准 cron 一致
+	s.domStar = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	s.dowStar = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+	return &s, nil
+}
+
+// parseCronField 解析单个字段，返回允许取值的位图
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		start, end, step := field.min, field.max, 1
+
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s字段的步长无效: %s", field.name, part)
+			}
+			step = n
+		}
+
+		if rangeExpr != "*" && rangeExpr != "?" {
+			low, high, isRange := strings.Cut(rangeExpr, "-")
+			var err error
+			if start, err = parseCronValue(low, field); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseCronValue(high, field); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 形如 5/10 表示从5开始到最大值
+				end = field.max
+			}
+			if start > end {
+				return 0, fmt.Errorf("%s字段的范围无效: %s", field.name, part)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue 解析字段中的单个值，支持英文缩写
+func parseCronValue(expr string, field cronField) (int, error) {
+	if v, ok := field.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < field.min || v > field.max {
+		return 0, fmt.Errorf("%s字段的值无效: %s（范围 %d-%d）", field.name, expr, field.min, field.max)
+	}
+	return v, nil
+}
+
+// Next 实现 Schedule 接口，返回 t 之后第一个满足表达式的整分钟
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// 表达式可能永远不满足（例如2月30日），最多查找5年
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 判断日期是否满足日和星期字段
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
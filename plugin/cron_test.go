@@ -0,0 +1,55 @@
+// cron_test.go
+// cron 表达式解析测试文件
+package plugin
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSchedule 测试计算下一次执行时间
+func TestParseSchedule(t *testing.T) {
+	// 2024-01-15 是周一
+	from := time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * MON-FRI", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2024, 1, 16, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"5,10 12 * * *", time.Date(2024, 1, 15, 12, 5, 0, 0, time.UTC)},
+		// 日和星期都有限制时满足其一即可
+		{"0 0 20 * 3", time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)},
+		// 以 * 开头的步长字段视为不限制，日和星期需要同时满足
+		{"0 0 */2 * 1", time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * */2", time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q 的下一次执行时间错误，期望: %v, 实际: %v", tt.spec, tt.want, got)
+		}
+	}
+}
+
+// TestParseScheduleInvalid 测试无效的表达式
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@weekdays", "@every -1s", "* * * foo *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("表达式 %q 应该解析失败", spec)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// plugin/dryrun.go - 工具试运行
+// 试运行时工具只校验参数并说明将要执行的操作，不产生任何副作用
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+)
+
+// ErrDryRunNotSupported 插件不支持试运行时返回的错误
+var ErrDryRunNotSupported = errors.New("插件不支持试运行")
+
+// ToolPluginDryRunInterface 定义了支持试运行的工具插件接口
+// 插件实现该接口后，主程序可以通过 PluginManager.CallToolDryRun 试运行工具
+type ToolPluginDryRunInterface interface {
+	ToolPluginInterface
+
+	// DryRunTool 校验参数并返回将要执行的操作说明，不能产生副作用
+	DryRunTool(toolName string, params map[string]any) (*CallToolResult, error)
+}
+
+// DryRunTool 实现 ToolPluginDryRunInterface 接口的 DryRunTool 方法
+// 使用独立的RPC方法，旧版本插件会因找不到方法而返回 ErrDryRunNotSupported，不会误执行工具
+func (t *ToolPluginRPC) DryRunTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	args := CallToolArgs{
+		ToolName: toolName,
+		Params:   params,
+		DryRun:   true,
+	}
+	var result CallToolResult
+	err := t.call("Plugin.CallToolDryRun", args, &result)
+	if err != nil {
+		var serverErr rpc.ServerError
+		if isMethodNotFound(err) || (errors.As(err, &serverErr) && string(serverErr) == ErrDryRunNotSupported.Error()) {
+			return nil, ErrDryRunNotSupported
+		}
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CallToolDryRun 处理来自客户端的 CallToolDryRun RPC 调用
+func (s *ToolPluginRPCServer) CallToolDryRun(args CallToolArgs, resp *CallToolResult) error {
+	if !s.gate.enter() {
+		return ErrPluginStopping
+	}
+	defer s.gate.leave()
+
+	return s.dryRunTool(args, resp)
+}
+
+// dryRunTool 将试运行请求转发给插件实现，插件不支持时返回 ErrDryRunNotSupported
+func (s *ToolPluginRPCServer) dryRunTool(args CallToolArgs, resp *CallToolResult) error {
+	impl, ok := s.Impl.(ToolPluginDryRunInterface)
+	if !ok {
+		return ErrDryRunNotSupported
+	}
+
+	result, err := impl.DryRunTool(args.ToolName, args.Params)
+	if err != nil {
+		*resp = *NewErrorResult(fmt.Sprintf("试运行工具失败: %v", err))
+	} else {
+		*resp = *result
+	}
+	resp.SetMeta("dry_run", true)
+	return nil
+}
+
+// CallToolDryRun 试运行指定的工具
+// 插件会校验参数并说明将要执行的操作，但不会真正执行
+// 插件不支持试运行时返回 ErrDryRunNotSupported
+func (pm *PluginManager) CallToolDryRun(toolName string, params map[string]any) (*CallToolResult, error) {
+	return pm.guardCall(callInfo{tool: toolName, params: params, dryRun: true}, func() (*CallToolResult, error) {
+		return pm.invokeToolDryRun(toolName, params)
+	})
+}
+
+// invokeToolDryRun 试运行工具，调用方需要先通过 guardCall 完成权限检查
+func (pm *PluginManager) invokeToolDryRun(toolName string, params map[string]any) (*CallToolResult, error) {
+	plugin, exists := pm.GetPluginByTool(toolName)
+	if !exists {
+		return nil, fmt.Errorf("工具 '%s' 不存在", toolName)
+	}
+
+	dryRunner, ok := plugin.Instance.(ToolPluginDryRunInterface)
+	if !ok {
+		return nil, ErrDryRunNotSupported
+	}
+
+	params, err := pm.prepareParams(toolName, params)
+	if err != nil {
+		return nil, err
+	}
+	return dryRunner.DryRunTool(toolName, params)
+}
@@ -0,0 +1,125 @@
+// dryrun_test.go
+// 工具试运行测试文件
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"testing"
+)
+
+// dryRunPlugin 测试用的支持试运行的插件实现
+type dryRunPlugin struct {
+	echoPlugin
+	executed bool
+}
+
+func (p *dryRunPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	p.executed = true
+	return p.echoPlugin.CallTool(toolName, params)
+}
+
+func (p *dryRunPlugin) DryRunTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	if _, ok := params["text"].(string); !ok {
+		return nil, fmt.Errorf("缺少必需参数: text")
+	}
+	return NewCallToolResult().AddTextContent("将回显文本", "plan"), nil
+}
+
+// legacyRPCServer 模拟没有试运行方法的旧版本插件
+type legacyRPCServer struct {
+	executed bool
+}
+
+func (s *legacyRPCServer) CallTool(args CallToolArgs, resp *CallToolResult) error {
+	s.executed = true
+	*resp = *NewCallToolResult()
+	return nil
+}
+
+// newPipeClient 在内存连接上启动RPC服务并返回客户端
+func newPipeClient(t *testing.T, rcvr any) *ToolPluginRPC {
+	serverConn, clientConn := net.Pipe()
+	server := rpc.NewServer()
+	if err := server.RegisterName("Plugin", rcvr); err != nil {
+		t.Fatalf("注册RPC服务失败: %v", err)
+	}
+	go server.ServeConn(serverConn)
+
+	client := &ToolPluginRPC{client: rpc.NewClient(clientConn), codec: CodecGob}
+	t.Cleanup(func() { client.client.Close() })
+	return client
+}
+
+// TestDryRunTool 测试通过RPC试运行工具
+func TestDryRunTool(t *testing.T) {
+	impl := &dryRunPlugin{}
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: impl})
+
+	result, err := client.DryRunTool("echo", map[string]any{"text": "你好"})
+	if err != nil {
+		t.Fatalf("试运行失败: %v", err)
+	}
+	if result.IsError || result.Meta["dry_run"] != true {
+		t.Errorf("试运行结果错误: %+v", result)
+	}
+	if impl.executed {
+		t.Error("试运行不应该执行工具")
+	}
+
+	// 参数校验失败时返回错误结果
+	result, err = client.DryRunTool("echo", map[string]any{})
+	if err != nil || !result.IsError {
+		t.Errorf("参数无效时应该返回错误结果: %+v, %v", result, err)
+	}
+
+	// 通过 CallToolArgs 的 DryRun 标记同样不会执行工具
+	result, err = client.CallTool("echo", map[string]any{"text": "你好"})
+	if err != nil || !impl.executed {
+		t.Fatalf("正常调用失败: %v", err)
+	}
+	impl.executed = false
+	var resp CallToolResult
+	err = client.client.Call("Plugin.CallTool", CallToolArgs{ToolName: "echo", Params: map[string]any{"text": "x"}, DryRun: true}, &resp)
+	if err != nil || impl.executed || resp.Meta["dry_run"] != true {
+		t.Errorf("带 DryRun 标记的调用不应该执行工具: %+v, %v", resp, err)
+	}
+}
+
+// TestDryRunNotSupported 测试不支持试运行的插件返回标准错误
+func TestDryRunNotSupported(t *testing.T) {
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: &echoPlugin{}})
+	if _, err := client.DryRunTool("echo", map[string]any{"text": "你好"}); !errors.Is(err, ErrDryRunNotSupported) {
+		t.Errorf("不支持试运行的插件应该返回 ErrDryRunNotSupported，实际: %v", err)
+	}
+
+	legacy := &legacyRPCServer{}
+	client = newPipeClient(t, legacy)
+	if _, err := client.DryRunTool("echo", map[string]any{}); !errors.Is(err, ErrDryRunNotSupported) {
+		t.Errorf("旧版本插件应该返回 ErrDryRunNotSupported，实际: %v", err)
+	}
+	if legacy.executed {
+		t.Error("旧版本插件不应该执行工具")
+	}
+}
+
+// TestPluginManagerCallToolDryRun 测试插件管理器的试运行调用
+func TestPluginManagerCallToolDryRun(t *testing.T) {
+	manager := NewPluginManager()
+	supported := &LoadedPlugin{Name: "dry", Instance: &dryRunPlugin{}}
+	unsupported := &LoadedPlugin{Name: "echo", Instance: &echoPlugin{}}
+	manager.toolMap["dry_tool"] = supported
+	manager.toolMap["echo"] = unsupported
+
+	if _, err := manager.CallToolDryRun("dry_tool", map[string]any{"text": "x"}); err != nil {
+		t.Errorf("试运行失败: %v", err)
+	}
+	if _, err := manager.CallToolDryRun("echo", map[string]any{}); !errors.Is(err, ErrDryRunNotSupported) {
+		t.Errorf("应该返回 ErrDryRunNotSupported，实际: %v", err)
+	}
+	if _, err := manager.CallToolDryRun("missing", nil); err == nil {
+		t.Error("不存在的工具应该返回错误")
+	}
+}
@@ -32,6 +32,9 @@ func main() {
 	fmt.Println("\n可用的工具:")
 	for _, tool := range tools {
 		fmt.Printf("- %s: %s\n", tool.Name, tool.Description)
+		for _, example := range tool.Examples {
+			fmt.Printf("  示例: %s %v => %s\n", example.Description, example.Params, example.Result)
+		}
 	}
 
 	// 调用时间工具
@@ -65,40 +65,14 @@ func (t *TimeTool) GetTools() ([]plugin.Tool, error) {
 		),
 	)
 
-	// 定义时间计算工具
-	timeCalcTool := plugin.NewTool(
+	// 定义时间计算工具，参数模式由 timeCalcParams 生成
+	timeCalcTool := plugin.NewToolFromStruct(
 		"time_calc",
 		"时间计算（加减天数、小时等）",
-		plugin.WithString("time",
-			plugin.Description("基准时间，如果为空则使用当前时间"),
-		),
-		plugin.WithString("format",
-			plugin.Description("时间格式"),
-			plugin.Default("2006-01-02 15:04:05"),
-		),
-		plugin.WithInteger("years",
-			plugin.Description("要加减的年数"),
-			plugin.Default(0),
-		),
-		plugin.WithInteger("months",
-			plugin.Description("要加减的月数"),
-			plugin.Default(0),
-		),
-		plugin.WithInteger("days",
-			plugin.Description("要加减的天数"),
-			plugin.Default(0),
-		),
-		plugin.WithInteger("hours",
-			plugin.Description("要加减的小时数"),
-			plugin.Default(0),
-		),
-		plugin.WithInteger("minutes",
-			plugin.Description("要加减的分钟数"),
-			plugin.Default(0),
-		),
-		plugin.WithInteger("seconds",
-			plugin.Description("要加减的秒数"),
-			plugin.Default(0),
+		timeCalcParams{},
+		plugin.WithExample("计算指定时间三天后的时间",
+			map[string]any{"time": "2024-01-01 08:00:00", "days": 3},
+			"2024-01-04 08:00:00",
 		),
 	)
 
@@ -193,93 +167,63 @@ func (t *TimeTool) convertTime(params map[string]interface{}) (*plugin.CallToolR
 	return result, nil
 }
 
+// timeCalcParams 时间计算工具的参数
+type timeCalcParams struct {
+	Time    string `json:"time" description:"基准时间，如果为空则使用当前时间"`
+	Format  string `json:"format" description:"时间格式" schema:"default=2006-01-02 15:04:05"`
+	Years   int    `json:"years" description:"要加减的年数" schema:"default=0"`
+	Months  int    `json:"months" description:"要加减的月数" schema:"default=0"`
+	Days    int    `json:"days" description:"要加减的天数" schema:"default=0"`
+	Hours   int    `json:"hours" description:"要加减的小时数" schema:"default=0"`
+	Minutes int    `json:"minutes" description:"要加减的分钟数" schema:"default=0"`
+	Seconds int    `json:"seconds" description:"要加减的秒数" schema:"default=0"`
+}
+
 // calculateTime 计算时间
 func (t *TimeTool) calculateTime(params map[string]interface{}) (*plugin.CallToolResult, error) {
-	// 获取基准时间，如果没有则使用当前时间
-	var baseTime time.Time
-	timeStr, ok := params["time"].(string)
-	if ok && timeStr != "" {
-		// 获取时间格式
-		format, ok := params["format"].(string)
-		if !ok || format == "" {
-			format = "2006-01-02 15:04:05"
-		}
+	var p timeCalcParams
+	if err := plugin.DecodeParams(params, &p); err != nil {
+		return plugin.NewErrorResult(err.Error()), nil
+	}
+	if p.Format == "" {
+		p.Format = "2006-01-02 15:04:05"
+	}
 
-		// 解析时间
+	// 获取基准时间，如果没有则使用当前时间
+	baseTime := time.Now()
+	if p.Time != "" {
 		var err error
-		baseTime, err = time.Parse(format, timeStr)
+		baseTime, err = time.Parse(p.Format, p.Time)
 		if err != nil {
 			return plugin.NewErrorResult(fmt.Sprintf("时间解析失败: %v", err)), nil
 		}
-	} else {
-		// 使用当前时间
-		baseTime = time.Now()
 	}
 
-	// 获取各个时间单位的增量
-	years := getIntParam(params, "years", 0)
-	months := getIntParam(params, "months", 0)
-	days := getIntParam(params, "days", 0)
-	hours := getIntParam(params, "hours", 0)
-	minutes := getIntParam(params, "minutes", 0)
-	seconds := getIntParam(params, "seconds", 0)
-
 	// 计算新时间
-	newTime := baseTime.AddDate(years, months, days)
-	newTime = newTime.Add(time.Duration(hours) * time.Hour)
-	newTime = newTime.Add(time.Duration(minutes) * time.Minute)
-	newTime = newTime.Add(time.Duration(seconds) * time.Second)
-
-	// 获取输出格式
-	format, ok := params["format"].(string)
-	if !ok || format == "" {
-		format = "2006-01-02 15:04:05"
-	}
+	newTime := baseTime.AddDate(p.Years, p.Months, p.Days)
+	newTime = newTime.Add(time.Duration(p.Hours) * time.Hour)
+	newTime = newTime.Add(time.Duration(p.Minutes) * time.Minute)
+	newTime = newTime.Add(time.Duration(p.Seconds) * time.Second)
 
 	// 格式化结果
-	resultTime := newTime.Format(format)
+	resultTime := newTime.Format(p.Format)
 
 	// 创建结果
 	result := plugin.NewCallToolResult()
 	result.AddTextContent(resultTime, "calculated_time")
 
 	// 添加一些元数据
-	result.SetMeta("base_time", baseTime.Format(format))
-	result.SetMeta("years", years)
-	result.SetMeta("months", months)
-	result.SetMeta("days", days)
-	result.SetMeta("hours", hours)
-	result.SetMeta("minutes", minutes)
-	result.SetMeta("seconds", seconds)
+	result.SetMeta("base_time", baseTime.Format(p.Format))
+	result.SetMeta("years", p.Years)
+	result.SetMeta("months", p.Months)
+	result.SetMeta("days", p.Days)
+	result.SetMeta("hours", p.Hours)
+	result.SetMeta("minutes", p.Minutes)
+	result.SetMeta("seconds", p.Seconds)
 
 	return result, nil
 }
 
-// getIntParam 从参数中获取整数值，如果获取失败则返回默认值
-func getIntParam(params map[string]interface{}, name string, defaultValue int) int {
-	value, ok := params[name]
-	if !ok {
-		return defaultValue
-	}
-
-	// 尝试转换为整数
-	switch v := value.(type) {
-	case int:
-		return v
-	case float64:
-		return int(v)
-	case string:
-		// 尝试将字符串转换为整数
-		var intValue int
-		_, err := fmt.Sscanf(v, "%d", &intValue)
-		if err == nil {
-			return intValue
-		}
-	}
-
-	return defaultValue
-}
-
 func main() {
 	// 创建一个时间工具插件实例
 	timeToolPlugin := NewTimeTool()
@@ -0,0 +1,129 @@
+// plugin/i18n.go - 工具和参数描述的多语言支持
+package plugin
+
+import "strings"
+
+// LocalizedDescriptionsKey 属性模式中存放多语言描述的扩展关键字
+const LocalizedDescriptionsKey = "x-descriptions"
+
+// WithLocalizedDescription 添加指定语言的工具描述
+// lang 为语言标签，例如：en、zh-CN
+func WithLocalizedDescription(lang, description string) ToolOption {
+	return func(t *Tool) {
+		if t.Descriptions == nil {
+			t.Descriptions = make(map[string]string)
+		}
+		t.Descriptions[lang] = description
+	}
+}
+
+// LocalizedDescription 添加指定语言的属性描述
+// 多语言描述保存在属性模式的 x-descriptions 扩展关键字中
+func LocalizedDescription(lang, description string) PropertyOption {
+	return func(schema map[string]any) {
+		descriptions, ok := schema[LocalizedDescriptionsKey].(map[string]string)
+		if !ok {
+			descriptions = make(map[string]string)
+			for k, v := range toStringMap(schema[LocalizedDescriptionsKey]) {
+				descriptions[k] = v
+			}
+			schema[LocalizedDescriptionsKey] = descriptions
+		}
+		descriptions[lang] = description
+	}
+}
+
+// Localized 返回使用指定语言描述的工具副本
+// 查找顺序为完整语言标签、主语言（例如 zh-CN 回退到 zh），都不存在时保留原描述
+// 属性的多语言描述同样会被替换，返回的副本不包含 x-descriptions 关键字
+func (t Tool) Localized(lang string) Tool {
+	if desc, ok := lookupLocalized(t.Descriptions, lang); ok {
+		t.Description = desc
+	}
+	if t.InputSchema.Properties != nil {
+		t.InputSchema.Properties = localizeProperties(t.InputSchema.Properties, lang)
+	}
+	return t
+}
+
+// ListToolsLocalized 按选项列出工具，并将描述替换为指定语言
+func (pm *PluginManager) ListToolsLocalized(lang string, options ...ListOption) []Tool {
+	tools := pm.ListToolsWithOptions(options...)
+	for i := range tools {
+		tools[i] = tools[i].Localized(lang)
+	}
+	return tools
+}
+
+// lookupLocalized 按语言标签查找描述
+func lookupLocalized(descriptions map[string]string, lang string) (string, bool) {
+	if len(descriptions) == 0 || lang == "" {
+		return "", false
+	}
+	if desc, ok := descriptions[lang]; ok {
+		return desc, true
+	}
+	lang = strings.ReplaceAll(lang, "_", "-")
+	for k, desc := range descriptions {
+		if strings.EqualFold(k, lang) {
+			return desc, true
+		}
+	}
+	if base, _, found := strings.Cut(lang, "-"); found {
+		return lookupLocalized(descriptions, base)
+	}
+	return "", false
+}
+
+// localizeProperties 复制属性模式并替换其中的描述，包括嵌套对象和数组项目
+func localizeProperties(properties map[string]any, lang string) map[string]any {
+	result := make(map[string]any, len(properties))
+	for name, prop := range properties {
+		if schema, ok := prop.(map[string]any); ok {
+			result[name] = localizeSchema(schema, lang)
+		} else {
+			result[name] = prop
+		}
+	}
+	return result
+}
+
+// localizeSchema 复制单个属性模式并替换描述
+func localizeSchema(schema map[string]any, lang string) map[string]any {
+	result := make(map[string]any, len(schema))
+	for k, v := range schema {
+		result[k] = v
+	}
+
+	if descriptions, ok := result[LocalizedDescriptionsKey]; ok {
+		if desc, ok := lookupLocalized(toStringMap(descriptions), lang); ok {
+			result["description"] = desc
+		}
+		delete(result, LocalizedDescriptionsKey)
+	}
+	if properties, ok := result["properties"].(map[string]any); ok {
+		result["properties"] = localizeProperties(properties, lang)
+	}
+	if items, ok := result["items"].(map[string]any); ok {
+		result["items"] = localizeSchema(items, lang)
+	}
+	return result
+}
+
+// toStringMap 将多语言描述转换为 map[string]string
+// 经过 JSON 或 msgpack 传输后描述会变为 map[string]any
+func toStringMap(v any) map[string]string {
+	switch m := v.(type) {
+	case map[string]string:
+		return m
+	case map[string]any:
+		result := make(map[string]string, len(m))
+		for k, val := range m {
+			if s, ok := val.(string); ok {
+				result[k] = s
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// i18n_test.go
+// 多语言描述测试文件
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestToolLocalized 测试工具和属性描述的多语言替换
+func TestToolLocalized(t *testing.T) {
+	tool := NewTool("greet", "打招呼",
+		WithLocalizedDescription("en", "Say hello"),
+		WithLocalizedDescription("zh-TW", "打招呼（繁體）"),
+		WithString("name",
+			Description("名称"),
+			LocalizedDescription("en", "Name"),
+		),
+		WithObject("options",
+			Properties(map[string]any{
+				"loud": map[string]any{
+					"type":                   "boolean",
+					"description":            "大声",
+					LocalizedDescriptionsKey: map[string]any{"en": "Loud"},
+				},
+			}),
+		),
+	)
+
+	en := tool.Localized("en-US")
+	if en.Description != "Say hello" {
+		t.Errorf("工具描述应该回退到主语言，实际: %s", en.Description)
+	}
+	name := en.InputSchema.Properties["name"].(map[string]any)
+	if name["description"] != "Name" {
+		t.Errorf("属性描述错误: %v", name["description"])
+	}
+	if _, ok := name[LocalizedDescriptionsKey]; ok {
+		t.Error("本地化后的属性不应该包含多语言描述")
+	}
+	loud := en.InputSchema.Properties["options"].(map[string]any)["properties"].(map[string]any)["loud"].(map[string]any)
+	if loud["description"] != "Loud" {
+		t.Errorf("嵌套属性描述错误: %v", loud["description"])
+	}
+
+	if tw := tool.Localized("zh_tw"); tw.Description != "打招呼（繁體）" {
+		t.Errorf("语言标签应该忽略大小写和分隔符，实际: %s", tw.Description)
+	}
+
+	// 不存在的语言保留原描述，且原工具不受影响
+	fr := tool.Localized("fr")
+	if fr.Description != "打招呼" || fr.InputSchema.Properties["name"].(map[string]any)["description"] != "名称" {
+		t.Errorf("不存在的语言应该保留原描述: %+v", fr)
+	}
+	if tool.InputSchema.Properties["name"].(map[string]any)["description"] != "名称" {
+		t.Error("原工具的属性描述不应该被修改")
+	}
+}
+
+// TestListToolsLocalized 测试本地化的工具列表以及序列化
+func TestListToolsLocalized(t *testing.T) {
+	manager := NewPluginManager()
+	tool := NewTool("greet", "打招呼", WithLocalizedDescription("en", "Say hello"))
+	manager.plugins["greeter"] = &LoadedPlugin{Name: "greeter", Tools: []Tool{*tool}}
+
+	tools := manager.ListToolsLocalized("en")
+	if len(tools) != 1 || tools[0].Description != "Say hello" {
+		t.Errorf("本地化工具列表错误: %+v", tools)
+	}
+
+	data, err := json.Marshal(tool)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	var decoded Tool
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("反序列化失败: %v", err)
+	}
+	if decoded.Descriptions["en"] != "Say hello" {
+		t.Errorf("多语言描述序列化错误: %s", data)
+	}
+}
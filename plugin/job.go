@@ -0,0 +1,328 @@
+// plugin/job.go - 异步任务
+// 长时间运行的工具可以提交为异步任务：提交后立即返回任务ID，
+// 由管理器的工作协程执行，调用方之后通过任务ID查询状态和结果，不需要一直保持连接
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultJobWorkers 默认的异步任务工作协程数
+const DefaultJobWorkers = 4
+
+// DefaultJobQueueSize 默认的待执行任务队列长度，队列已满时提交失败
+const DefaultJobQueueSize = 1024
+
+var (
+	// ErrJobNotFound 任务不存在或已过期
+	ErrJobNotFound = errors.New("任务不存在")
+	// ErrJobNotFinished 任务尚未执行完成
+	ErrJobNotFinished = errors.New("任务尚未完成")
+	// ErrJobQueueFull 待执行任务过多
+	ErrJobQueueFull = errors.New("任务队列已满")
+)
+
+// JobState 异步任务状态
+type JobState string
+
+const (
+	// JobPending 等待执行
+	JobPending JobState = "pending"
+	// JobRunning 正在执行
+	JobRunning JobState = "running"
+	// JobSucceeded 执行完成，工具返回的错误结果同样视为完成
+	JobSucceeded JobState = "succeeded"
+	// JobFailed 调用失败，例如工具不存在、没有权限或插件出错
+	JobFailed JobState = "failed"
+)
+
+// Finished 判断任务是否已结束
+func (s JobState) Finished() bool {
+	return s == JobSucceeded || s == JobFailed
+}
+
+// Job 异步任务记录
+type Job struct {
+	ID         string          `json:"id"`                   // 任务ID
+	Tool       string          `json:"tool"`                 // 工具名称
+	Params     map[string]any  `json:"params,omitempty"`     // 调用参数
+	Caller     Identity        `json:"caller"`               // 提交任务的调用方，执行时按该身份做权限检查
+	State      JobState        `json:"state"`                // 任务状态
+	Result     *CallToolResult `json:"result,omitempty"`     // 执行完成时的结果
+	Error      string          `json:"error,omitempty"`      // 调用失败时的错误信息
+	CreatedAt  time.Time       `json:"created_at"`           // 提交时间
+	StartedAt  time.Time       `json:"started_at,omitzero"`  // 开始执行时间
+	FinishedAt time.Time       `json:"finished_at,omitzero"` // 结束时间
+}
+
+// JobStore 异步任务的持久化存储
+// 默认保存在内存中，需要在进程重启后保留或跨进程查询时使用 NewCacheJobStore
+type JobStore interface {
+	// SaveJob 保存任务记录，已存在时覆盖
+	SaveJob(job *Job) error
+	// LoadJob 加载任务记录，不存在时返回 ErrJobNotFound
+	LoadJob(id string) (*Job, error)
+	// DeleteJob 删除任务记录
+	DeleteJob(id string) error
+}
+
+// memoryJobStore 保存在内存中的任务存储
+type memoryJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewMemoryJobStore 创建保存在内存中的任务存储
+func NewMemoryJobStore() JobStore {
+	return &memoryJobStore{jobs: make(map[string]Job)}
+}
+
+// SaveJob 实现 JobStore 接口
+func (s *memoryJobStore) SaveJob(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// LoadJob 实现 JobStore 接口
+func (s *memoryJobStore) LoadJob(id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[id]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	return &job, nil
+}
+
+// DeleteJob 实现 JobStore 接口
+func (s *memoryJobStore) DeleteJob(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, id)
+	return nil
+}
+
+// JobKV 任务存储使用的键值接口，db/cache 的 Cache 实现了该接口
+// 键不存在时 Get 返回任意错误，任务存储通过 Exists 区分键不存在和读取失败
+type JobKV interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttl time.Duration) error
+	Delete(key string) error
+	Exists(key string) (bool, error)
+}
+
+// cacheJobStore 以JSON格式保存在键值缓存中的任务存储
+type cacheJobStore struct {
+	kv     JobKV
+	prefix string
+	ttl    time.Duration
+}
+
+// NewCacheJobStore 创建保存在键值缓存中的任务存储
+// 任务记录以 prefix+任务ID 为键保存，ttl 为记录的过期时间，为0时不过期
+// 例如：NewCacheJobStore(cacheInstance, "jobs:", 24*time.Hour)
+func NewCacheJobStore(kv JobKV, prefix string, ttl time.Duration) JobStore {
+	return &cacheJobStore{kv: kv, prefix: prefix, ttl: ttl}
+}
+
+// SaveJob 实现 JobStore 接口
+func (s *cacheJobStore) SaveJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("序列化任务失败: %w", err)
+	}
+	return s.kv.Set(s.prefix+job.ID, string(data), s.ttl)
+}
+
+// LoadJob 实现 JobStore 接口
+func (s *cacheJobStore) LoadJob(id string) (*Job, error) {
+	data, err := s.kv.Get(s.prefix + id)
+	if err != nil {
+		if exists, existsErr := s.kv.Exists(s.prefix + id); existsErr == nil && !exists {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("解析任务失败: %w", err)
+	}
+	return &job, nil
+}
+
+// DeleteJob 实现 JobStore 接口
+func (s *cacheJobStore) DeleteJob(id string) error {
+	return s.kv.Delete(s.prefix + id)
+}
+
+// WithJobStore 设置异步任务的存储，默认保存在内存中
+func WithJobStore(store JobStore) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.jobStore = store
+	}
+}
+
+// WithJobWorkers 设置执行异步任务的工作协程数，默认为 DefaultJobWorkers
+// n 小于1时忽略该选项，NewPluginManagerE 返回错误
+func WithJobWorkers(n int) PluginManagerOption {
+	return func(pm *PluginManager) {
+		if n < 1 {
+			pm.invalidOption("WithJobWorkers 工作协程数必须大于0，实际: %d", n)
+			return
+		}
+		pm.jobWorkers = n
+	}
+}
+
+// jobTask 等待执行的任务
+type jobTask struct {
+	ctx context.Context // 提交时的上下文，用于传递调用方身份
+	job *Job
+}
+
+// jobRunner 执行异步任务的工作协程池，第一次提交任务时启动
+type jobRunner struct {
+	once   sync.Once
+	tasks  chan jobTask
+	ctx    context.Context // 关闭管理器时取消正在执行的任务
+	cancel context.CancelFunc
+}
+
+// SubmitTool 提交异步任务，返回任务ID
+func (pm *PluginManager) SubmitTool(toolName string, params map[string]any) (string, error) {
+	return pm.SubmitToolWithContext(context.Background(), toolName, params)
+}
+
+// SubmitToolWithContext 提交异步任务，返回任务ID
+// ctx 只用于传递调用方身份等值，取消 ctx 不会取消已提交的任务
+func (pm *PluginManager) SubmitToolWithContext(ctx context.Context, toolName string, params map[string]any) (string, error) {
+	if _, exists := pm.GetPluginByTool(toolName); !exists {
+		return "", fmt.Errorf("未找到工具: %s", toolName)
+	}
+
+	job := newJob(toolName, params, IdentityFromContext(ctx))
+	if err := pm.jobStore.SaveJob(job); err != nil {
+		return "", fmt.Errorf("保存任务失败: %w", err)
+	}
+
+	runner := pm.startJobRunner()
+	select {
+	case runner.tasks <- jobTask{ctx: context.WithoutCancel(ctx), job: job}:
+		return job.ID, nil
+	default:
+		pm.jobStore.DeleteJob(job.ID)
+		return "", ErrJobQueueFull
+	}
+}
+
+// newJob 创建等待执行的任务记录
+func newJob(toolName string, params map[string]any, caller Identity) *Job {
+	return &Job{
+		ID:        newRandomID(),
+		Tool:      toolName,
+		Params:    params,
+		Caller:    caller,
+		State:     JobPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// JobStatus 查询异步任务的状态
+func (pm *PluginManager) JobStatus(jobID string) (JobState, error) {
+	job, err := pm.jobStore.LoadJob(jobID)
+	if err != nil {
+		return "", err
+	}
+	return job.State, nil
+}
+
+// JobResult 获取异步任务的结果
+// 任务未完成时返回 ErrJobNotFinished，调用失败时返回调用的错误信息
+func (pm *PluginManager) JobResult(jobID string) (*CallToolResult, error) {
+	job, err := pm.jobStore.LoadJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	switch job.State {
+	case JobSucceeded:
+		return job.Result, nil
+	case JobFailed:
+		return nil, errors.New(job.Error)
+	}
+	return nil, ErrJobNotFinished
+}
+
+// GetJob 获取异步任务的完整记录
+func (pm *PluginManager) GetJob(jobID string) (*Job, error) {
+	return pm.jobStore.LoadJob(jobID)
+}
+
+// DeleteJob 删除异步任务记录，不会取消正在执行的任务
+func (pm *PluginManager) DeleteJob(jobID string) error {
+	return pm.jobStore.DeleteJob(jobID)
+}
+
+// startJobRunner 启动工作协程池，只在第一次调用时启动
+func (pm *PluginManager) startJobRunner() *jobRunner {
+	runner := pm.jobs
+	runner.once.Do(func() {
+		runner.tasks = make(chan jobTask, DefaultJobQueueSize)
+		for i := 0; i < pm.jobWorkers; i++ {
+			go pm.jobWorker(runner)
+		}
+	})
+	return runner
+}
+
+// jobWorker 依次执行队列中的任务，管理器关闭时退出
+func (pm *PluginManager) jobWorker(runner *jobRunner) {
+	for {
+		select {
+		case <-runner.ctx.Done():
+			return
+		case task := <-runner.tasks:
+			pm.runJob(runner.ctx, task)
+		}
+	}
+}
+
+// runJob 执行任务并保存结果
+func (pm *PluginManager) runJob(stop context.Context, task jobTask) {
+	job := task.job
+	job.State = JobRunning
+	job.StartedAt = time.Now()
+	if err := pm.jobStore.SaveJob(job); err != nil {
+		log.Printf("保存任务 %s 失败: %v", job.ID, err)
+	}
+
+	// 管理器关闭时取消正在执行的任务
+	ctx, cancel := context.WithCancel(task.ctx)
+	defer cancel()
+	stopCancel := context.AfterFunc(stop, cancel)
+	defer stopCancel()
+
+	result, err := pm.CallToolWithContext(ctx, job.Tool, job.Params)
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.State = JobFailed
+		job.Error = err.Error()
+	} else {
+		job.State = JobSucceeded
+		job.Result = result
+	}
+	if err := pm.jobStore.SaveJob(job); err != nil {
+		log.Printf("保存任务 %s 失败: %v", job.ID, err)
+	}
+}
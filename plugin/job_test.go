@@ -0,0 +1,168 @@
+// job_test.go
+// 异步任务测试文件
+package plugin_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newJobFake 创建提供 wait 和 fail 工具的假插件，wait 工具在 release 关闭前阻塞
+func newJobFake(release chan struct{}) *plugintest.FakePlugin {
+	fake := plugintest.NewFakePlugin("jobs")
+	fake.AddTool(plugin.NewTool("wait", "等待")).Handle(func(params map[string]any) (*plugin.CallToolResult, error) {
+		<-release
+		return plugin.NewCallToolResult().AddTextContent("完成"), nil
+	})
+	fake.AddTool(plugin.NewTool("fail", "失败")).ReturnError(errors.New("执行出错"))
+	return fake
+}
+
+// waitJob 等待任务结束
+func waitJob(t *testing.T, manager *plugin.PluginManager, jobID string) plugin.JobState {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := manager.JobStatus(jobID)
+		if err != nil {
+			t.Fatalf("查询任务状态失败: %v", err)
+		}
+		if state.Finished() {
+			return state
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("任务 %s 没有在规定时间内结束", jobID)
+	return ""
+}
+
+// TestSubmitTool 测试提交任务、查询状态和获取结果
+func TestSubmitTool(t *testing.T) {
+	release := make(chan struct{})
+	manager := plugintest.NewManager(newJobFake(release))
+	defer manager.Shutdown()
+
+	jobID, err := manager.SubmitTool("wait", map[string]any{"n": 1})
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	if state, _ := manager.JobStatus(jobID); state.Finished() {
+		t.Errorf("任务不应该已经结束: %s", state)
+	}
+	if _, err := manager.JobResult(jobID); !errors.Is(err, plugin.ErrJobNotFinished) {
+		t.Errorf("任务未完成时应该返回 ErrJobNotFinished，实际: %v", err)
+	}
+
+	close(release)
+	if state := waitJob(t, manager, jobID); state != plugin.JobSucceeded {
+		t.Fatalf("任务状态错误: %s", state)
+	}
+	result, err := manager.JobResult(jobID)
+	if err != nil || result.Content[0].(plugin.TextContent).Text != "完成" {
+		t.Errorf("任务结果错误: %v, %v", result, err)
+	}
+
+	job, _ := manager.GetJob(jobID)
+	if job.Tool != "wait" || job.StartedAt.IsZero() || job.FinishedAt.Before(job.StartedAt) {
+		t.Errorf("任务记录错误: %+v", job)
+	}
+
+	manager.DeleteJob(jobID)
+	if _, err := manager.JobStatus(jobID); !errors.Is(err, plugin.ErrJobNotFound) {
+		t.Errorf("删除后应该返回 ErrJobNotFound，实际: %v", err)
+	}
+}
+
+// TestSubmitToolFailed 测试调用失败的任务
+func TestSubmitToolFailed(t *testing.T) {
+	manager := plugintest.NewManager(newJobFake(nil))
+	defer manager.Shutdown()
+
+	if _, err := manager.SubmitTool("missing", nil); err == nil {
+		t.Error("提交不存在的工具应该返回错误")
+	}
+
+	jobID, err := manager.SubmitTool("fail", nil)
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	if state := waitJob(t, manager, jobID); state != plugin.JobFailed {
+		t.Fatalf("任务状态错误: %s", state)
+	}
+	if _, err := manager.JobResult(jobID); err == nil || err.Error() != "执行出错" {
+		t.Errorf("应该返回调用的错误信息，实际: %v", err)
+	}
+}
+
+// errKeyNotFound 测试用的键值缓存在键不存在时返回的错误
+var errKeyNotFound = errors.New("键不存在")
+
+// memoryKV 测试用的键值缓存
+type memoryKV struct {
+	mu   sync.Mutex
+	data map[string]string
+}
+
+func (kv *memoryKV) Get(key string) (string, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	value, ok := kv.data[key]
+	if !ok {
+		return "", errKeyNotFound
+	}
+	return value, nil
+}
+
+func (kv *memoryKV) Set(key string, value string, ttl time.Duration) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.data[key] = value
+	return nil
+}
+
+func (kv *memoryKV) Delete(key string) error {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	delete(kv.data, key)
+	return nil
+}
+
+func (kv *memoryKV) Exists(key string) (bool, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	_, ok := kv.data[key]
+	return ok, nil
+}
+
+// TestCacheJobStore 测试保存在键值缓存中的任务
+func TestCacheJobStore(t *testing.T) {
+	kv := &memoryKV{data: make(map[string]string)}
+	release := make(chan struct{})
+	close(release)
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{
+		plugin.WithJobStore(plugin.NewCacheJobStore(kv, "jobs:", time.Hour)),
+		plugin.WithJobWorkers(1),
+	}, newJobFake(release))
+	defer manager.Shutdown()
+
+	jobID, err := manager.SubmitTool("wait", map[string]any{"n": 1})
+	if err != nil {
+		t.Fatalf("提交任务失败: %v", err)
+	}
+	waitJob(t, manager, jobID)
+
+	if _, err := kv.Get("jobs:" + jobID); err != nil {
+		t.Errorf("任务应该保存在缓存中: %v", err)
+	}
+	result, err := manager.JobResult(jobID)
+	if err != nil || result.Content[0].(plugin.TextContent).Text != "完成" {
+		t.Errorf("从缓存还原的结果错误: %v, %v", result, err)
+	}
+	if _, err := manager.JobStatus("missing"); !errors.Is(err, plugin.ErrJobNotFound) {
+		t.Errorf("不存在的任务应该返回 ErrJobNotFound，实际: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+// plugin/jobqueue.go - 基于队列的分布式任务执行
+// 提交方把任务推入共享队列（例如 db/cache 的 Redis 队列），
+// 多个工作进程各自加载插件并从队列中取出任务执行，结果写回共享的任务存储，
+// 提交方通过 JobStatus/JobResult 查询，耗时的工具可以通过增加工作进程水平扩展
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultJobQueuePollInterval 队列为空时工作进程再次检查的默认间隔
+const DefaultJobQueuePollInterval = 500 * time.Millisecond
+
+// DefaultJobQueueVisibility 工作进程取出任务后确认的默认期限，
+// 超过期限没有确认（例如工作进程崩溃）的任务重新回到队列，由其他工作进程执行
+const DefaultJobQueueVisibility = 10 * time.Minute
+
+// ErrNoJobQueue 未配置任务队列
+var ErrNoJobQueue = errors.New("未配置任务队列")
+
+// JobQueue 任务队列接口，db/cache 的 Cache 实现了该接口
+// 工作进程通过 PopAck 取出任务，执行结束后 Ack，没有确认的任务在 visibility 之后重新回到队列；
+// 队列为空时 PopAck 返回任意错误，工作进程通过 Len 区分队列为空和读取失败
+type JobQueue interface {
+	Push(key string, value string) error
+	PopAck(key string, visibility time.Duration) (string, string, error)
+	Ack(key, receipt string) error
+	Len(key string) (int64, error)
+}
+
+// queuedJob 推入队列的任务消息，任务的工具、参数和调用方保存在任务存储中
+type queuedJob struct {
+	ID string `json:"id"` // 任务ID
+}
+
+// WithJobQueue 设置分布式执行使用的任务队列，key 为队列的键
+// 提交方和工作进程必须使用相同的队列和共享的任务存储（参见 NewCacheJobStore），
+// 否则提交方无法查询到工作进程写回的结果
+func WithJobQueue(queue JobQueue, key string) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.jobQueue = queue
+		pm.jobQueueKey = key
+	}
+}
+
+// WithJobQueuePollInterval 设置队列为空时工作进程再次检查的间隔，默认为 DefaultJobQueuePollInterval
+// interval 不大于0时忽略该选项，NewPluginManagerE 返回错误
+func WithJobQueuePollInterval(interval time.Duration) PluginManagerOption {
+	return func(pm *PluginManager) {
+		if interval <= 0 {
+			pm.invalidOption("WithJobQueuePollInterval 检查间隔必须大于0，实际: %v", interval)
+			return
+		}
+		pm.jobQueuePoll = interval
+	}
+}
+
+// WithJobQueueVisibility 设置工作进程取出任务后确认的期限，默认为 DefaultJobQueueVisibility
+// 期限应大于工具的最长执行时间，否则执行中的任务会被其他工作进程重复取出；
+// visibility 不大于0时忽略该选项，NewPluginManagerE 返回错误
+func WithJobQueueVisibility(visibility time.Duration) PluginManagerOption {
+	return func(pm *PluginManager) {
+		if visibility <= 0 {
+			pm.invalidOption("WithJobQueueVisibility 确认期限必须大于0，实际: %v", visibility)
+			return
+		}
+		pm.jobQueueVisibility = visibility
+	}
+}
+
+// EnqueueTool 将工具调用推入任务队列，由工作进程执行，返回任务ID
+func (pm *PluginManager) EnqueueTool(toolName string, params map[string]any) (string, error) {
+	return pm.EnqueueToolWithContext(context.Background(), toolName, params)
+}
+
+// EnqueueToolWithContext 将工具调用推入任务队列，由工作进程执行，返回任务ID
+// 提交方不需要加载提供该工具的插件；ctx 中的调用方身份保存在任务记录中，工作进程按该身份做权限检查
+func (pm *PluginManager) EnqueueToolWithContext(ctx context.Context, toolName string, params map[string]any) (string, error) {
+	if pm.jobQueue == nil {
+		return "", ErrNoJobQueue
+	}
+
+	job := newJob(toolName, params, IdentityFromContext(ctx))
+	if err := pm.jobStore.SaveJob(job); err != nil {
+		return "", fmt.Errorf("保存任务失败: %w", err)
+	}
+
+	data, err := json.Marshal(queuedJob{ID: job.ID})
+	if err != nil {
+		return "", fmt.Errorf("序列化任务失败: %w", err)
+	}
+	if err := pm.jobQueue.Push(pm.jobQueueKey, string(data)); err != nil {
+		pm.jobStore.DeleteJob(job.ID)
+		return "", fmt.Errorf("推入任务队列失败: %w", err)
+	}
+	return job.ID, nil
+}
+
+// RunJobQueueWorker 从任务队列中取出任务并执行，直到 ctx 取消
+// 启动 WithJobWorkers 设置的数量的工作协程，ctx 取消时正在执行的任务同样被取消，
+// 所有工作协程退出后返回
+func (pm *PluginManager) RunJobQueueWorker(ctx context.Context) error {
+	if pm.jobQueue == nil {
+		return ErrNoJobQueue
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < pm.jobWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pm.pollJobQueue(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// pollJobQueue 循环取出并执行任务，队列为空或出错时等待一段时间后重试
+// 任务执行结束并写回结果后才确认，工作进程在执行中退出时任务在确认期限后重新回到队列
+func (pm *PluginManager) pollJobQueue(ctx context.Context) {
+	interval := pm.jobQueuePoll
+	if interval <= 0 {
+		interval = DefaultJobQueuePollInterval
+	}
+
+	for ctx.Err() == nil {
+		task, receipt, err := pm.popJob()
+		if err == nil {
+			pm.runJob(ctx, task)
+			if ctx.Err() == nil {
+				pm.ackJob(receipt)
+			}
+			continue
+		}
+		if n, lenErr := pm.jobQueue.Len(pm.jobQueueKey); lenErr != nil || n > 0 {
+			log.Printf("获取队列任务失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
+
+// popJob 从队列中取出一个任务并加载任务记录，返回任务和确认回执
+// 无法解析、已被删除或已经结束的任务直接确认并跳过，继续取下一个
+func (pm *PluginManager) popJob() (jobTask, string, error) {
+	visibility := pm.jobQueueVisibility
+	if visibility <= 0 {
+		visibility = DefaultJobQueueVisibility
+	}
+
+	for {
+		data, receipt, err := pm.jobQueue.PopAck(pm.jobQueueKey, visibility)
+		if err != nil {
+			return jobTask{}, "", err
+		}
+
+		var message queuedJob
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			log.Printf("解析队列任务失败: %v", err)
+			pm.ackJob(receipt)
+			continue
+		}
+		job, err := pm.jobStore.LoadJob(message.ID)
+		if errors.Is(err, ErrJobNotFound) {
+			log.Printf("任务 %s 不存在，已跳过", message.ID)
+			pm.ackJob(receipt)
+			continue
+		}
+		if err != nil {
+			// 任务存储暂时不可用，不确认，任务在确认期限后重新回到队列
+			log.Printf("加载任务 %s 失败: %v", message.ID, err)
+			continue
+		}
+		if job.State.Finished() {
+			pm.ackJob(receipt)
+			continue
+		}
+		return jobTask{ctx: WithIdentity(context.Background(), job.Caller), job: job}, receipt, nil
+	}
+}
+
+// ackJob 确认队列中的任务已处理完成
+func (pm *PluginManager) ackJob(receipt string) {
+	if err := pm.jobQueue.Ack(pm.jobQueueKey, receipt); err != nil {
+		log.Printf("确认队列任务失败: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+// jobqueue_test.go
+// 分布式任务执行测试文件
+package plugin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// sharedCache 测试用的共享缓存，同时作为任务存储和任务队列
+type sharedCache struct {
+	memoryKV
+	lists   map[string][]string
+	pending map[string]pendingItem // 已弹出未确认的元素，key为回执
+	acks    int
+}
+
+// pendingItem 已弹出未确认的队列元素
+type pendingItem struct {
+	value    string
+	deadline time.Time
+}
+
+func newSharedCache() *sharedCache {
+	return &sharedCache{
+		memoryKV: memoryKV{data: make(map[string]string)},
+		lists:    make(map[string][]string),
+		pending:  make(map[string]pendingItem),
+	}
+}
+
+func (q *sharedCache) Push(key string, value string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lists[key] = append(q.lists[key], value)
+	return nil
+}
+
+func (q *sharedCache) PopAck(key string, visibility time.Duration) (string, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for receipt, item := range q.pending {
+		if time.Now().After(item.deadline) {
+			q.lists[key] = append([]string{item.value}, q.lists[key]...)
+			delete(q.pending, receipt)
+		}
+	}
+	if len(q.lists[key]) == 0 {
+		return "", "", errKeyNotFound
+	}
+	value := q.lists[key][0]
+	q.lists[key] = q.lists[key][1:]
+	receipt := fmt.Sprint(len(q.pending), time.Now().UnixNano())
+	q.pending[receipt] = pendingItem{value: value, deadline: time.Now().Add(visibility)}
+	return value, receipt, nil
+}
+
+func (q *sharedCache) Ack(key, receipt string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.pending[receipt]; !ok {
+		return errKeyNotFound
+	}
+	delete(q.pending, receipt)
+	q.acks++
+	return nil
+}
+
+func (q *sharedCache) Len(key string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.lists[key])), nil
+}
+
+// TestJobQueue 测试提交方入队、工作进程执行并写回结果
+func TestJobQueue(t *testing.T) {
+	shared := newSharedCache()
+	options := []plugin.PluginManagerOption{
+		plugin.WithJobStore(plugin.NewCacheJobStore(shared, "jobs:", 0)),
+		plugin.WithJobQueue(shared, "queue:jobs"),
+		plugin.WithJobQueuePollInterval(5 * time.Millisecond),
+	}
+
+	// 提交方没有加载任何插件
+	producer := plugintest.NewManagerWithOptions(options)
+	defer producer.Shutdown()
+
+	release := make(chan struct{})
+	close(release)
+	worker := plugintest.NewManagerWithOptions(append(options, plugin.WithToolACL("wait", plugin.ToolACL{Allow: []string{"alice"}})), newJobFake(release))
+	defer worker.Shutdown()
+
+	allowed, err := producer.EnqueueToolWithContext(plugin.WithIdentity(context.Background(), plugin.Identity{ID: "alice"}), "wait", nil)
+	if err != nil {
+		t.Fatalf("任务入队失败: %v", err)
+	}
+	denied, _ := producer.EnqueueTool("wait", nil)
+	if job, _ := producer.GetJob(allowed); job.Caller.ID != "alice" {
+		t.Errorf("任务记录应该保存调用方: %+v", job.Caller)
+	}
+
+	// 队列消息中伪造的调用方不应该生效，权限检查使用任务记录中的调用方
+	shared.Push("queue:jobs", fmt.Sprintf(`{"id":%q,"caller":{"id":"alice"}}`, denied))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.RunJobQueueWorker(ctx) }()
+
+	if state := waitJob(t, producer, allowed); state != plugin.JobSucceeded {
+		t.Errorf("任务状态错误: %s", state)
+	}
+	if result, err := producer.JobResult(allowed); err != nil || result.Content[0].(plugin.TextContent).Text != "完成" {
+		t.Errorf("任务结果错误: %v, %v", result, err)
+	}
+	if state := waitJob(t, producer, denied); state != plugin.JobFailed {
+		t.Errorf("匿名调用方的任务应该因为没有权限而失败: %s", state)
+	}
+
+	// 所有消息（包括重复的消息）处理后都应该被确认
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		shared.mu.Lock()
+		acks, pending := shared.acks, len(shared.pending)+len(shared.lists["queue:jobs"])
+		shared.mu.Unlock()
+		if acks == 3 && pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("队列消息没有全部确认，已确认: %d, 未处理: %d", acks, pending)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("工作进程应该返回 context.Canceled，实际: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后工作进程应该退出")
+	}
+}
+
+// TestJobQueueNotConfigured 测试未配置队列
+func TestJobQueueNotConfigured(t *testing.T) {
+	manager := plugintest.NewManager()
+	if _, err := manager.EnqueueTool("wait", nil); !errors.Is(err, plugin.ErrNoJobQueue) {
+		t.Errorf("未配置队列时应该返回 ErrNoJobQueue，实际: %v", err)
+	}
+	if err := manager.RunJobQueueWorker(context.Background()); !errors.Is(err, plugin.ErrNoJobQueue) {
+		t.Errorf("未配置队列时应该返回 ErrNoJobQueue，实际: %v", err)
+	}
+}
+
+// TestJobQueueRedelivery 测试工作进程没有确认的任务在确认期限后重新执行
+func TestJobQueueRedelivery(t *testing.T) {
+	shared := newSharedCache()
+	options := []plugin.PluginManagerOption{
+		plugin.WithJobStore(plugin.NewCacheJobStore(shared, "jobs:", 0)),
+		plugin.WithJobQueue(shared, "queue:jobs"),
+		plugin.WithJobQueuePollInterval(5 * time.Millisecond),
+		plugin.WithJobQueueVisibility(20 * time.Millisecond),
+	}
+	producer := plugintest.NewManagerWithOptions(options)
+	defer producer.Shutdown()
+
+	jobID, err := producer.EnqueueTool("wait", nil)
+	if err != nil {
+		t.Fatalf("任务入队失败: %v", err)
+	}
+
+	// 模拟取出任务后崩溃的工作进程
+	if _, _, err := shared.PopAck("queue:jobs", 20*time.Millisecond); err != nil {
+		t.Fatalf("取出任务失败: %v", err)
+	}
+
+	release := make(chan struct{})
+	close(release)
+	worker := plugintest.NewManagerWithOptions(options, newJobFake(release))
+	defer worker.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go worker.RunJobQueueWorker(ctx)
+
+	if state := waitJob(t, producer, jobID); state != plugin.JobSucceeded {
+		t.Errorf("超过确认期限的任务应该重新执行: %s", state)
+	}
+}
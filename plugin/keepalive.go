@@ -0,0 +1,131 @@
+// plugin/keepalive.go - 插件连接心跳检测
+// 定期向每个插件的所有连接发送轻量的心跳请求，避免空闲连接被静默断开，
+// 心跳失败时重启插件
+package plugin
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// pinger 支持心跳检测的插件实例
+type pinger interface {
+	Ping() error
+}
+
+// WithKeepalive 启用心跳检测，每隔 interval 检测一次所有已启动的插件
+// 心跳失败的插件会被重启（延迟加载的插件在下次调用时重新启动），Shutdown 后停止检测
+// interval 不大于0时忽略该选项，NewPluginManagerE 返回错误
+func WithKeepalive(interval time.Duration) PluginManagerOption {
+	return func(pm *PluginManager) {
+		if interval <= 0 {
+			pm.invalidOption("WithKeepalive 心跳间隔必须大于0，实际: %v", interval)
+			return
+		}
+		pm.keepaliveInterval = interval
+	}
+}
+
+// PingArgs 心跳请求的参数
+type PingArgs struct {
+	Time int64 `json:"time"` // 发送时间，Unix纳秒
+}
+
+// Ping 向连接池中的每个连接发送心跳请求
+// 旧版本插件没有心跳方法，能收到方法不存在的响应同样说明连接正常
+func (t *ToolPluginRPC) Ping() error {
+	for i := 0; i < t.PoolSize(); i++ {
+		var ok bool
+		err := t.connAt(i).Call("Plugin.Ping", PingArgs{Time: time.Now().UnixNano()}, &ok)
+		if err != nil && !isMethodNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping 处理来自客户端的 Ping RPC 调用
+func (s *ToolPluginRPCServer) Ping(args PingArgs, resp *bool) error {
+	*resp = true
+	return nil
+}
+
+// PingPlugin 检测指定插件的连接
+// 进程内注册的插件和未启动的延迟加载插件总是返回 nil
+func (pm *PluginManager) PingPlugin(name string) error {
+	loaded, exists := pm.GetPlugin(name)
+	if !exists {
+		return fmt.Errorf("插件 %s 不存在", name)
+	}
+	if p, ok := loaded.Instance.(pinger); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+// keepalive 定期检测所有插件，直到 stop 被关闭
+func (pm *PluginManager) keepalive(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			pm.pingAll()
+		}
+	}
+}
+
+// pingAll 检测所有插件，心跳失败时重启插件
+func (pm *PluginManager) pingAll() {
+	for _, loaded := range pm.ListPlugins() {
+		p, ok := loaded.Instance.(pinger)
+		if !ok {
+			continue
+		}
+		if err := p.Ping(); err != nil {
+			log.Printf("插件 %s 心跳失败，正在重启: %v", loaded.Name, err)
+			pm.restartPlugin(loaded)
+		}
+	}
+}
+
+// restartPlugin 重启心跳失败的插件
+// 延迟加载的插件只停止进程，下次调用时重新启动；其他插件立即重新加载并替换
+func (pm *PluginManager) restartPlugin(old *LoadedPlugin) {
+	if lazy, ok := old.Instance.(*lazyPlugin); ok {
+		lazy.stop()
+		return
+	}
+	if old.Path == "" {
+		return
+	}
+
+	loaded, err := pm.LoadPlugin(old.Path)
+	if err != nil {
+		log.Printf("重启插件 %s 失败: %v", old.Name, err)
+		return
+	}
+
+	pm.mu.Lock()
+	// 重启期间插件可能已被关闭或替换
+	if pm.plugins[old.Name] != old {
+		pm.mu.Unlock()
+		if loaded.Client != nil {
+			loaded.Client.Kill()
+		}
+		return
+	}
+	pm.plugins[loaded.Name] = loaded
+	for _, tool := range loaded.Tools {
+		pm.toolMap[tool.Name] = loaded
+	}
+	pm.mu.Unlock()
+
+	if old.Client != nil {
+		old.Client.Kill()
+	}
+}
@@ -0,0 +1,134 @@
+// keepalive_test.go
+// 心跳检测、延迟加载和预热测试文件
+package plugin
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// failingPinger 测试用的心跳失败的插件实例
+type failingPinger struct {
+	echoPlugin
+}
+
+func (p *failingPinger) Ping() error {
+	return errors.New("连接已断开")
+}
+
+// newTestLazyPlugin 创建通过 net.Pipe 启动的延迟加载插件，starts 记录启动次数
+func newTestLazyPlugin(t *testing.T, starts *atomic.Int32, instance func() ToolPluginInterface) *LoadedPlugin {
+	impl := &echoPlugin{}
+	tools, _ := impl.GetTools()
+	info, _ := impl.GetPluginInfo()
+	return &LoadedPlugin{
+		Name: "echo",
+		Info: info,
+		Instance: &lazyPlugin{
+			name:  "echo",
+			info:  info,
+			tools: tools,
+			start: func() (*plugin.Client, ToolPluginInterface, error) {
+				starts.Add(1)
+				return nil, instance(), nil
+			},
+		},
+		Tools: tools,
+	}
+}
+
+// TestPing 测试心跳请求
+func TestPing(t *testing.T) {
+	client := newPipePool(t, &ToolPluginRPCServer{Impl: &echoPlugin{}}, 2)
+	if err := client.Ping(); err != nil {
+		t.Errorf("心跳失败: %v", err)
+	}
+
+	legacy := newPipeClient(t, &legacyRPCServer{})
+	if err := legacy.Ping(); err != nil {
+		t.Errorf("旧版本插件的心跳不应该失败: %v", err)
+	}
+
+	client.client.Close()
+	if err := client.Ping(); err == nil {
+		t.Error("连接断开后心跳应该失败")
+	}
+}
+
+// TestLazyPluginAndPrewarm 测试延迟加载插件在首次调用或预热时启动
+func TestLazyPluginAndPrewarm(t *testing.T) {
+	var starts atomic.Int32
+	manager := NewPluginManager()
+	loaded := newTestLazyPlugin(t, &starts, func() ToolPluginInterface {
+		return newPipeClient(t, &ToolPluginRPCServer{Impl: &echoPlugin{}})
+	})
+	manager.plugins["echo"] = loaded
+	manager.toolMap["echo"] = loaded
+
+	if len(manager.ListTools()) != 1 || starts.Load() != 0 {
+		t.Fatalf("列出工具不应该启动插件，启动次数: %d", starts.Load())
+	}
+	if err := manager.PingPlugin("echo"); err != nil || starts.Load() != 0 {
+		t.Errorf("未启动的插件不需要心跳: %v, 启动次数: %d", err, starts.Load())
+	}
+
+	if err := manager.Prewarm(); err != nil {
+		t.Fatalf("预热失败: %v", err)
+	}
+	if starts.Load() != 1 {
+		t.Errorf("预热应该启动插件，启动次数: %d", starts.Load())
+	}
+	if _, err := manager.CallTool("echo", map[string]any{"text": "x"}); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if starts.Load() != 1 {
+		t.Errorf("已启动的插件不应该重复启动，启动次数: %d", starts.Load())
+	}
+
+	if err := manager.Prewarm("missing"); err == nil {
+		t.Error("预热不存在的插件应该返回错误")
+	}
+
+	manager.Shutdown()
+	if loaded.Instance.(*lazyPlugin).started() != nil {
+		t.Error("关闭后插件应该停止")
+	}
+}
+
+// TestKeepaliveRestartsLazyPlugin 测试心跳失败时停止延迟加载的插件，下次调用时重新启动
+func TestKeepaliveRestartsLazyPlugin(t *testing.T) {
+	var starts atomic.Int32
+	manager := NewPluginManager(WithKeepalive(5 * time.Millisecond))
+	defer manager.Shutdown()
+
+	loaded := newTestLazyPlugin(t, &starts, func() ToolPluginInterface {
+		return &failingPinger{}
+	})
+	manager.mu.Lock()
+	manager.plugins["echo"] = loaded
+	manager.toolMap["echo"] = loaded
+	manager.mu.Unlock()
+
+	if err := manager.Prewarm("echo"); err != nil {
+		t.Fatalf("预热失败: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for loaded.Instance.(*lazyPlugin).started() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("心跳失败后插件应该被停止")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := manager.CallTool("echo", map[string]any{"text": "x"}); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	if starts.Load() != 2 {
+		t.Errorf("停止后调用应该重新启动插件，启动次数: %d", starts.Load())
+	}
+}
@@ -0,0 +1,199 @@
+// plugin/lazy.go - 插件延迟启动和预热
+// 延迟加载模式下，加载插件时只读取插件信息和工具定义，随后停止插件进程，
+// 首次调用工具时再启动。流量高峰前可以调用 Prewarm 提前启动插件，避免首次调用的启动延迟
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// WithLazyLoading 启用延迟加载，插件进程在首次调用工具或 Prewarm 时才启动
+func WithLazyLoading() PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.lazy = true
+	}
+}
+
+// lazyPlugin 延迟启动的插件实例
+// 插件信息和工具定义在加载时已经读取，只有调用工具时才需要启动插件进程
+type lazyPlugin struct {
+	name  string
+	info  PluginInfo
+	tools []Tool
+	start func() (*plugin.Client, ToolPluginInterface, error) // 启动插件进程
+
+	mu       sync.Mutex
+	client   *plugin.Client      // 插件客户端，未启动时为 nil
+	instance ToolPluginInterface // 插件实例，未启动时为 nil
+}
+
+// makeLazy 停止已加载插件的进程，改为首次调用时再启动
+func (pm *PluginManager) makeLazy(loaded *LoadedPlugin) {
+	if loaded.Client != nil {
+		loaded.Client.Kill()
+	}
+	name, path, logger := loaded.Name, loaded.Path, loaded.Logger
+	loaded.Instance = &lazyPlugin{
+		name:  name,
+		info:  loaded.Info,
+		tools: loaded.Tools,
+		start: func() (*plugin.Client, ToolPluginInterface, error) {
+			return pm.startPlugin(name, path, logger)
+		},
+	}
+	loaded.Client = nil
+}
+
+// get 返回插件实例，插件进程未启动时启动
+func (l *lazyPlugin) get() (ToolPluginInterface, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.instance != nil {
+		return l.instance, nil
+	}
+
+	log.Printf("正在启动插件: %s", l.name)
+	client, instance, err := l.start()
+	if err != nil {
+		return nil, fmt.Errorf("启动插件 %s 失败: %w", l.name, err)
+	}
+	l.client, l.instance = client, instance
+	return instance, nil
+}
+
+// started 返回已启动的插件实例，未启动时返回 nil
+func (l *lazyPlugin) started() ToolPluginInterface {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.instance
+}
+
+// stop 停止插件进程，下次调用时重新启动
+func (l *lazyPlugin) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.client != nil {
+		l.client.Kill()
+	}
+	l.client, l.instance = nil, nil
+}
+
+// GetTools 返回加载时读取的工具定义，不会启动插件进程
+func (l *lazyPlugin) GetTools() ([]Tool, error) {
+	return l.tools, nil
+}
+
+// GetPluginInfo 返回加载时读取的插件信息，不会启动插件进程
+func (l *lazyPlugin) GetPluginInfo() (PluginInfo, error) {
+	return l.info, nil
+}
+
+// CallTool 启动插件进程后调用工具
+func (l *lazyPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	instance, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	return instance.CallTool(toolName, params)
+}
+
+// CallToolWithStruct 启动插件进程后使用结构化参数调用工具
+func (l *lazyPlugin) CallToolWithStruct(toolName string, params any) (*CallToolResult, error) {
+	instance, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	if genericPlugin, ok := instance.(ToolPluginGenericInterface); ok {
+		return genericPlugin.CallToolWithStruct(toolName, params)
+	}
+	return instance.CallTool(toolName, structToMap(params))
+}
+
+// CallToolContext 启动插件进程后带上下文调用工具
+func (l *lazyPlugin) CallToolContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	instance, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	if contextPlugin, ok := instance.(ToolPluginContextInterface); ok {
+		return contextPlugin.CallToolContext(ctx, toolName, params)
+	}
+	return instance.CallTool(toolName, params)
+}
+
+// DryRunTool 启动插件进程后试运行工具
+func (l *lazyPlugin) DryRunTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	instance, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	if dryRunner, ok := instance.(ToolPluginDryRunInterface); ok {
+		return dryRunner.DryRunTool(toolName, params)
+	}
+	return nil, ErrDryRunNotSupported
+}
+
+// CallToolInSession 启动插件进程后在会话中调用工具
+func (l *lazyPlugin) CallToolInSession(sessionID string, toolName string, params map[string]any) (*CallToolResult, error) {
+	instance, err := l.get()
+	if err != nil {
+		return nil, err
+	}
+	if sessionPlugin, ok := instance.(ToolPluginSessionInterface); ok {
+		return sessionPlugin.CallToolInSession(sessionID, toolName, params)
+	}
+	return instance.CallTool(toolName, params)
+}
+
+// CloseSession 通知已启动的插件释放会话资源
+// 插件进程未启动时没有需要释放的资源
+func (l *lazyPlugin) CloseSession(sessionID string) error {
+	if sessionPlugin, ok := l.started().(ToolPluginSessionInterface); ok {
+		return sessionPlugin.CloseSession(sessionID)
+	}
+	return nil
+}
+
+// Ping 检测已启动的插件连接，插件进程未启动时不需要检测
+func (l *lazyPlugin) Ping() error {
+	if p, ok := l.started().(pinger); ok {
+		return p.Ping()
+	}
+	return nil
+}
+
+// Prewarm 提前启动延迟加载的插件进程
+// names 为插件名称，不传时启动所有插件；已启动的插件和非延迟加载的插件会被忽略
+func (pm *PluginManager) Prewarm(names ...string) error {
+	var plugins []*LoadedPlugin
+	if len(names) == 0 {
+		plugins = pm.ListPlugins()
+	}
+
+	var errs []error
+	for _, name := range names {
+		loaded, exists := pm.GetPlugin(name)
+		if !exists {
+			errs = append(errs, fmt.Errorf("插件 %s 不存在", name))
+			continue
+		}
+		plugins = append(plugins, loaded)
+	}
+
+	for _, loaded := range plugins {
+		if lazy, ok := loaded.Instance.(*lazyPlugin); ok {
+			if _, err := lazy.get(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
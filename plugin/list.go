@@ -0,0 +1,226 @@
+// plugin/list.go - 工具和插件列表的分页、排序和过滤
+package plugin
+
+import (
+	"sort"
+	"strings"
+)
+
+// listOptions 列表查询选项
+type listOptions struct {
+	offset     int      // 跳过的条目数
+	limit      int      // 返回的最大条目数，0 表示不限制
+	namePrefix string   // 名称前缀
+	plugins    []string // 插件名称
+	tags       []string // 标签，需要全部包含
+	sorted     bool     // 是否按名称排序
+	desc       bool     // 是否降序
+}
+
+// ListOption 是配置列表查询的函数选项类型
+type ListOption func(*listOptions)
+
+// WithOffset 跳过前 offset 个条目
+func WithOffset(offset int) ListOption {
+	return func(o *listOptions) {
+		o.offset = offset
+	}
+}
+
+// WithLimit 最多返回 limit 个条目，小于等于0表示不限制
+func WithLimit(limit int) ListOption {
+	return func(o *listOptions) {
+		o.limit = limit
+	}
+}
+
+// WithNamePrefix 只返回名称以 prefix 开头的条目
+func WithNamePrefix(prefix string) ListOption {
+	return func(o *listOptions) {
+		o.namePrefix = prefix
+	}
+}
+
+// WithPluginFilter 只返回指定插件（或指定插件提供的工具）
+func WithPluginFilter(names ...string) ListOption {
+	return func(o *listOptions) {
+		o.plugins = append(o.plugins, names...)
+	}
+}
+
+// WithTagFilter 只返回包含所有指定标签的工具
+// 用于插件列表时，只返回至少提供一个匹配工具的插件
+func WithTagFilter(tags ...string) ListOption {
+	return func(o *listOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// SortByName 按名称排序，desc 为 true 时降序
+// 使用 WithOffset 或 WithLimit 分页时，未指定排序也会按名称升序排列以保证分页稳定
+func SortByName(desc bool) ListOption {
+	return func(o *listOptions) {
+		o.sorted = true
+		o.desc = desc
+	}
+}
+
+// newListOptions 应用所有列表查询选项
+func newListOptions(options []ListOption) *listOptions {
+	o := &listOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	if o.offset > 0 || o.limit > 0 {
+		o.sorted = true
+	}
+	return o
+}
+
+// matchPlugin 判断插件名称是否满足插件过滤条件
+func (o *listOptions) matchPlugin(name string) bool {
+	if len(o.plugins) == 0 {
+		return true
+	}
+	for _, p := range o.plugins {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTool 判断工具是否满足标签过滤条件
+func (o *listOptions) matchTool(tool *Tool) bool {
+	for _, tag := range o.tags {
+		if !tool.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// less 按名称比较两个条目
+func (o *listOptions) less(a, b string) bool {
+	if o.desc {
+		return a > b
+	}
+	return a < b
+}
+
+// page 计算分页后的区间
+func (o *listOptions) page(total int) (int, int) {
+	start := o.offset
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if o.limit > 0 && start+o.limit < end {
+		end = start + o.limit
+	}
+	return start, end
+}
+
+// filterTools 过滤并排序所有插件提供的工具，调用方需持有读锁
+func (pm *PluginManager) filterTools(o *listOptions) []Tool {
+	tools := make([]Tool, 0)
+	for name, plugin := range pm.plugins {
+		if !o.matchPlugin(name) {
+			continue
+		}
+		for i := range plugin.Tools {
+			tool := &plugin.Tools[i]
+			if strings.HasPrefix(tool.Name, o.namePrefix) && o.matchTool(tool) {
+				tools = append(tools, *tool)
+			}
+		}
+	}
+
+	if o.sorted {
+		sort.SliceStable(tools, func(i, j int) bool {
+			return o.less(tools[i].Name, tools[j].Name)
+		})
+	}
+	return tools
+}
+
+// filterPlugins 过滤并排序已加载的插件，调用方需持有读锁
+func (pm *PluginManager) filterPlugins(o *listOptions) []*LoadedPlugin {
+	plugins := make([]*LoadedPlugin, 0, len(pm.plugins))
+	for name, plugin := range pm.plugins {
+		if !o.matchPlugin(name) || !strings.HasPrefix(name, o.namePrefix) {
+			continue
+		}
+		if len(o.tags) > 0 {
+			matched := false
+			for i := range plugin.Tools {
+				if o.matchTool(&plugin.Tools[i]) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	if o.sorted {
+		sort.SliceStable(plugins, func(i, j int) bool {
+			return o.less(plugins[i].Name, plugins[j].Name)
+		})
+	}
+	return plugins
+}
+
+// ListToolsWithOptions 按选项列出工具，支持分页、排序和过滤
+// 例如：ListToolsWithOptions(WithPluginFilter("timetool"), WithLimit(10), WithOffset(20))
+func (pm *PluginManager) ListToolsWithOptions(options ...ListOption) []Tool {
+	o := newListOptions(options)
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	tools := pm.filterTools(o)
+	start, end := o.page(len(tools))
+	return tools[start:end]
+}
+
+// CountTools 统计满足过滤条件的工具数量，忽略分页选项
+func (pm *PluginManager) CountTools(options ...ListOption) int {
+	o := newListOptions(options)
+	o.sorted = false
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return len(pm.filterTools(o))
+}
+
+// ListPluginsWithOptions 按选项列出插件，支持分页、排序和过滤
+// 名称前缀和插件过滤作用于插件名称，标签过滤作用于插件提供的工具
+func (pm *PluginManager) ListPluginsWithOptions(options ...ListOption) []*LoadedPlugin {
+	o := newListOptions(options)
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	plugins := pm.filterPlugins(o)
+	start, end := o.page(len(plugins))
+	return plugins[start:end]
+}
+
+// CountPlugins 统计满足过滤条件的插件数量，忽略分页选项
+func (pm *PluginManager) CountPlugins(options ...ListOption) int {
+	o := newListOptions(options)
+	o.sorted = false
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return len(pm.filterPlugins(o))
+}
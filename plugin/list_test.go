@@ -0,0 +1,101 @@
+// list_test.go
+// 列表查询测试文件
+// 测试工具和插件列表的分页、排序和过滤
+package plugin_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newListFakes 创建列表测试用的假插件：math 和 text 各提供几个带标签的工具，empty 没有工具
+func newListFakes() []*plugintest.FakePlugin {
+	math := plugintest.NewFakePlugin("math")
+	math.AddTool(plugin.NewTool("math_add", "加法", plugin.WithTags("math", "basic")))
+	math.AddTool(plugin.NewTool("math_pow", "乘方", plugin.WithTags("math")))
+	math.AddTool(plugin.NewTool("math_sub", "减法", plugin.WithTags("math", "basic")))
+
+	text := plugintest.NewFakePlugin("text")
+	text.AddTool(plugin.NewTool("text_upper", "转大写", plugin.WithTags("text", "basic")))
+	text.AddTool(plugin.NewTool("text_lower", "转小写", plugin.WithTags("text")))
+
+	return []*plugintest.FakePlugin{math, text, plugintest.NewFakePlugin("empty")}
+}
+
+// toolNames 获取工具名称列表
+func toolNames(tools []plugin.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+// TestListToolsWithOptions 测试工具列表的分页、排序和过滤
+func TestListToolsWithOptions(t *testing.T) {
+	manager := plugintest.NewManager(newListFakes()...)
+
+	tests := []struct {
+		name    string
+		options []plugin.ListOption
+		want    []string
+	}{
+		{"排序", []plugin.ListOption{plugin.SortByName(false)}, []string{"math_add", "math_pow", "math_sub", "text_lower", "text_upper"}},
+		{"降序", []plugin.ListOption{plugin.SortByName(true), plugin.WithLimit(2)}, []string{"text_upper", "text_lower"}},
+		{"分页", []plugin.ListOption{plugin.WithOffset(1), plugin.WithLimit(2)}, []string{"math_pow", "math_sub"}},
+		{"超出范围", []plugin.ListOption{plugin.WithOffset(10)}, []string{}},
+		{"名称前缀", []plugin.ListOption{plugin.WithNamePrefix("text_"), plugin.SortByName(false)}, []string{"text_lower", "text_upper"}},
+		{"插件过滤", []plugin.ListOption{plugin.WithPluginFilter("math"), plugin.WithLimit(10)}, []string{"math_add", "math_pow", "math_sub"}},
+		{"标签过滤", []plugin.ListOption{plugin.WithTagFilter("basic"), plugin.SortByName(false)}, []string{"math_add", "math_sub", "text_upper"}},
+		{"多个标签", []plugin.ListOption{plugin.WithTagFilter("basic", "text"), plugin.SortByName(false)}, []string{"text_upper"}},
+		{"组合过滤", []plugin.ListOption{plugin.WithPluginFilter("math", "text"), plugin.WithTagFilter("basic"), plugin.WithNamePrefix("math"), plugin.WithOffset(1)}, []string{"math_sub"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toolNames(manager.ListToolsWithOptions(tt.options...))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("期望: %v, 实际: %v", tt.want, got)
+			}
+		})
+	}
+
+	if n := len(manager.ListTools()); n != 5 {
+		t.Errorf("ListTools 应该返回所有工具，实际: %d", n)
+	}
+	if n := manager.CountTools(plugin.WithTagFilter("basic"), plugin.WithLimit(1)); n != 3 {
+		t.Errorf("CountTools 应该忽略分页选项，实际: %d", n)
+	}
+}
+
+// TestListPluginsWithOptions 测试插件列表的分页、排序和过滤
+func TestListPluginsWithOptions(t *testing.T) {
+	manager := plugintest.NewManager(newListFakes()...)
+
+	pluginNames := func(plugins []*plugin.LoadedPlugin) []string {
+		names := make([]string, 0, len(plugins))
+		for _, p := range plugins {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	if got := pluginNames(manager.ListPluginsWithOptions(plugin.WithLimit(2))); !reflect.DeepEqual(got, []string{"empty", "math"}) {
+		t.Errorf("分页结果错误: %v", got)
+	}
+	if got := pluginNames(manager.ListPluginsWithOptions(plugin.WithTagFilter("text"))); !reflect.DeepEqual(got, []string{"text"}) {
+		t.Errorf("标签过滤结果错误: %v", got)
+	}
+	if got := pluginNames(manager.ListPluginsWithOptions(plugin.WithNamePrefix("m"))); !reflect.DeepEqual(got, []string{"math"}) {
+		t.Errorf("名称前缀过滤结果错误: %v", got)
+	}
+	if n := len(manager.ListPlugins()); n != 3 {
+		t.Errorf("ListPlugins 应该返回所有插件，实际: %d", n)
+	}
+	if n := manager.CountPlugins(plugin.WithTagFilter("basic")); n != 2 {
+		t.Errorf("CountPlugins 结果错误: %d", n)
+	}
+}
@@ -0,0 +1,77 @@
+// plugin/logging.go - 插件日志配置
+// 每个插件使用独立的 hclog 日志器，可以单独屏蔽或调高某个插件的日志级别
+package plugin
+
+import (
+	"io"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// WithLogOutput 设置插件日志的输出目标，默认为标准错误输出
+func WithLogOutput(w io.Writer) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.logOutput = w
+	}
+}
+
+// WithLogLevel 设置所有插件的默认日志级别，默认为 hclog.Trace（与 go-plugin 一致）
+func WithLogLevel(level hclog.Level) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.logLevel = level
+	}
+}
+
+// WithJSONLog 以JSON格式输出插件日志
+func WithJSONLog() PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.logJSON = true
+	}
+}
+
+// WithPluginLogLevel 单独设置某个插件的日志级别，不影响其他插件
+// name 为插件名称（去掉 .tool.plugin 后缀的文件名），使用 hclog.Off 可以屏蔽该插件的所有日志
+func WithPluginLogLevel(name string, level hclog.Level) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.pluginLogLevels[name] = level
+	}
+}
+
+// SetPluginLogLevel 在运行时调整某个插件的日志级别
+// 插件已加载时立即生效，否则在插件加载时生效
+func (pm *PluginManager) SetPluginLogLevel(name string, level hclog.Level) {
+	pm.logMu.Lock()
+	pm.pluginLogLevels[name] = level
+	pm.logMu.Unlock()
+
+	if loaded, exists := pm.GetPlugin(name); exists && loaded.Logger != nil {
+		loaded.Logger.SetLevel(level)
+	}
+}
+
+// PluginLogLevel 获取某个插件生效的日志级别
+func (pm *PluginManager) PluginLogLevel(name string) hclog.Level {
+	pm.logMu.Lock()
+	defer pm.logMu.Unlock()
+
+	if level, ok := pm.pluginLogLevels[name]; ok {
+		return level
+	}
+	return pm.logLevel
+}
+
+// pluginLogger 为指定插件创建独立的日志器
+// go-plugin 会将插件的标准错误输出按日志级别转发到该日志器
+func (pm *PluginManager) pluginLogger(name string) hclog.Logger {
+	level := pm.PluginLogLevel(name)
+
+	pm.logMu.Lock()
+	defer pm.logMu.Unlock()
+
+	return hclog.New(&hclog.LoggerOptions{
+		Name:       "plugin." + name,
+		Level:      level,
+		Output:     pm.logOutput,
+		JSONFormat: pm.logJSON,
+	})
+}
@@ -0,0 +1,66 @@
+// logging_test.go
+// 插件日志配置测试文件
+package plugin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// TestPluginLogLevel 测试插件日志级别的配置
+func TestPluginLogLevel(t *testing.T) {
+	var output bytes.Buffer
+	manager := NewPluginManager(
+		WithLogOutput(&output),
+		WithLogLevel(hclog.Info),
+		WithPluginLogLevel("noisy", hclog.Off),
+		WithPluginLogLevel("debug", hclog.Debug),
+	)
+
+	if level := manager.PluginLogLevel("other"); level != hclog.Info {
+		t.Errorf("默认日志级别错误: %v", level)
+	}
+	if level := manager.PluginLogLevel("noisy"); level != hclog.Off {
+		t.Errorf("插件日志级别错误: %v", level)
+	}
+
+	noisy := manager.pluginLogger("noisy")
+	noisy.Error("不应该输出")
+	if output.Len() != 0 {
+		t.Errorf("屏蔽的插件不应该输出日志: %s", output.String())
+	}
+
+	debug := manager.pluginLogger("debug")
+	if !debug.IsDebug() {
+		t.Error("debug 插件应该启用调试日志")
+	}
+	other := manager.pluginLogger("other")
+	if other.IsDebug() || !other.IsInfo() {
+		t.Error("其他插件应该使用默认日志级别")
+	}
+
+	// 调整一个插件的日志级别不影响其他插件
+	debug.SetLevel(hclog.Error)
+	if !manager.pluginLogger("debug").IsDebug() || !other.IsInfo() {
+		t.Error("日志器之间不应该共享日志级别")
+	}
+}
+
+// TestSetPluginLogLevel 测试运行时调整已加载插件的日志级别
+func TestSetPluginLogLevel(t *testing.T) {
+	manager := NewPluginManager(WithLogOutput(&bytes.Buffer{}))
+	manager.plugins["loaded"] = &LoadedPlugin{Name: "loaded", Logger: manager.pluginLogger("loaded")}
+
+	manager.SetPluginLogLevel("loaded", hclog.Warn)
+	logger := manager.plugins["loaded"].Logger
+	if logger.IsInfo() || !logger.IsWarn() {
+		t.Error("已加载插件的日志级别应该立即生效")
+	}
+
+	manager.SetPluginLogLevel("pending", hclog.Error)
+	if manager.PluginLogLevel("pending") != hclog.Error {
+		t.Error("未加载插件的日志级别应该在加载时生效")
+	}
+}
@@ -0,0 +1,89 @@
+// plugin/manager.go - 插件管理器接口
+// 主程序依赖 Manager 接口而不是 *PluginManager，单元测试中可以替换为其他实现，
+// 或者通过 RegisterPlugin 注册进程内的插件实现（参见 plugintest 包）
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Manager 定义了主程序使用插件时依赖的管理器接口，*PluginManager 实现了该接口
+type Manager interface {
+	// GetPlugin 获取指定名称的插件
+	GetPlugin(name string) (*LoadedPlugin, bool)
+
+	// GetPluginByTool 根据工具名称获取对应的插件
+	GetPluginByTool(toolName string) (*LoadedPlugin, bool)
+
+	// ListPlugins 列出所有已加载的插件
+	ListPlugins() []*LoadedPlugin
+
+	// ListTools 列出所有可用的工具
+	ListTools() []Tool
+
+	// ListToolsWithOptions 按选项列出工具
+	ListToolsWithOptions(options ...ListOption) []Tool
+
+	// CountTools 统计符合过滤条件的工具数量
+	CountTools(options ...ListOption) int
+
+	// CallTool 调用指定的工具
+	CallTool(toolName string, params map[string]any) (*CallToolResult, error)
+
+	// CallToolWithStruct 使用结构化参数调用指定的工具
+	CallToolWithStruct(toolName string, params any) (*CallToolResult, error)
+
+	// CallToolWithContext 带上下文调用指定的工具
+	CallToolWithContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error)
+
+	// CallToolWithStructContext 使用结构化参数和上下文调用指定的工具
+	CallToolWithStructContext(ctx context.Context, toolName string, params any) (*CallToolResult, error)
+
+	// CallToolDryRun 以试运行模式调用指定的工具
+	CallToolDryRun(toolName string, params map[string]any) (*CallToolResult, error)
+
+	// NewSession 创建一个新的会话
+	NewSession() *Session
+
+	// Shutdown 关闭所有插件
+	Shutdown()
+}
+
+var _ Manager = (*PluginManager)(nil)
+
+// RegisterPlugin 注册一个进程内的插件实现，不需要编译插件二进制文件
+// 主要用于测试以及将工具直接编译进主程序的场景，插件名称已存在时返回错误
+func (pm *PluginManager) RegisterPlugin(name string, impl ToolPluginInterface) (*LoadedPlugin, error) {
+	info, err := impl.GetPluginInfo()
+	if err != nil {
+		return nil, fmt.Errorf("获取插件信息 %s 失败: %w", name, err)
+	}
+	tools, err := impl.GetTools()
+	if err != nil {
+		return nil, fmt.Errorf("获取插件工具 %s 失败: %w", name, err)
+	}
+
+	loadedPlugin := &LoadedPlugin{
+		Name:     name,
+		Instance: impl,
+		Info:     info,
+		Tools:    tools,
+		Logger:   pm.pluginLogger(name),
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if _, exists := pm.plugins[name]; exists {
+		return nil, fmt.Errorf("插件 %s 已存在", name)
+	}
+	pm.plugins[name] = loadedPlugin
+	for _, tool := range tools {
+		pm.toolMap[tool.Name] = loadedPlugin
+	}
+
+	log.Printf("插件 %s 注册成功! 提供 %d 个工具", name, len(tools))
+	return loadedPlugin, nil
+}
@@ -0,0 +1,102 @@
+// plugin/pagination.go - 分页的工具结果
+// 返回大量数据的工具可以分多次返回：结果元数据中携带下一页的游标，
+// 调用方把游标作为 cursor 参数再次调用，直到没有下一页为止
+package plugin
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// NextCursorKey 结果元数据中下一页游标的键，没有下一页时不设置
+	NextCursorKey = "next_cursor"
+	// CursorParam 获取下一页时传给工具的游标参数名
+	CursorParam = "cursor"
+)
+
+// MaxResultPages CallToolAllPages 最多获取的页数，防止插件返回的游标不结束
+var MaxResultPages = 1000
+
+// SetNextCursor 设置下一页的游标，cursor 为空表示没有下一页
+func (ctr *CallToolResult) SetNextCursor(cursor string) *CallToolResult {
+	if cursor == "" {
+		delete(ctr.Meta, NextCursorKey)
+		return ctr
+	}
+	return ctr.SetMeta(NextCursorKey, cursor)
+}
+
+// NextCursor 返回下一页的游标，没有下一页时返回空字符串
+func (ctr *CallToolResult) NextCursor() string {
+	cursor, _ := ctr.Meta[NextCursorKey].(string)
+	return cursor
+}
+
+// CallToolPages 依次获取工具结果的每一页并交给 fn 处理
+// 第一页使用 params 调用，之后每页在 params 的基础上设置 cursor 参数；
+// fn 返回错误或某一页为错误结果时停止，错误结果同样会交给 fn
+func (pm *PluginManager) CallToolPages(ctx context.Context, toolName string, params map[string]any, fn func(page *CallToolResult) error) error {
+	seen := make(map[string]bool)
+	pageParams := params
+	for pages := 0; ; pages++ {
+		if pages >= MaxResultPages {
+			return fmt.Errorf("工具 '%s' 的结果超过 %d 页", toolName, MaxResultPages)
+		}
+
+		page, err := pm.CallToolWithContext(ctx, toolName, pageParams)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+
+		cursor := page.NextCursor()
+		if cursor == "" || page.IsError {
+			return nil
+		}
+		if seen[cursor] {
+			return fmt.Errorf("工具 '%s' 返回了重复的游标: %s", toolName, cursor)
+		}
+		seen[cursor] = true
+
+		pageParams = make(map[string]any, len(params)+1)
+		for k, v := range params {
+			pageParams[k] = v
+		}
+		pageParams[CursorParam] = cursor
+	}
+}
+
+// CallToolAllPages 获取工具结果的所有页并合并为一个结果
+// 合并后的内容按页的顺序排列，元数据逐页合并（同名键以后面的页为准，不包含游标），并在 pages 中记录页数；
+// 某一页为错误结果时直接返回该页
+func (pm *PluginManager) CallToolAllPages(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	merged := NewCallToolResult()
+	var errorPage *CallToolResult
+	pages := 0
+
+	err := pm.CallToolPages(ctx, toolName, params, func(page *CallToolResult) error {
+		if page.IsError {
+			errorPage = page
+			return nil
+		}
+		pages++
+		merged.Content = append(merged.Content, page.Content...)
+		for k, v := range page.Meta {
+			merged.SetMeta(k, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if errorPage != nil {
+		return errorPage, nil
+	}
+
+	merged.SetNextCursor("")
+	merged.SetMeta("pages", pages)
+	return merged, nil
+}
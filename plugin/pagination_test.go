@@ -0,0 +1,77 @@
+// pagination_test.go
+// 分页结果测试文件
+package plugin_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newPagedFake 创建提供分页工具的假插件，每页一个文本内容
+func newPagedFake(pages int, cursorFor func(page int) string) *plugintest.FakePlugin {
+	fake := plugintest.NewFakePlugin("paged")
+	fake.AddTool(plugin.NewTool("list", "分页列表")).Handle(func(params map[string]any) (*plugin.CallToolResult, error) {
+		page := 0
+		if cursor, ok := params[plugin.CursorParam].(string); ok {
+			fmt.Sscanf(cursor, "page-%d", &page)
+		}
+		result := plugin.NewCallToolResult().AddTextContent(fmt.Sprint(page)).SetMeta("last_page", page)
+		if page+1 < pages {
+			result.SetNextCursor(cursorFor(page + 1))
+		}
+		return result, nil
+	})
+	return fake
+}
+
+// TestCallToolAllPages 测试自动获取所有页
+func TestCallToolAllPages(t *testing.T) {
+	manager := plugintest.NewManager(newPagedFake(3, func(page int) string { return fmt.Sprintf("page-%d", page) }))
+
+	result, err := manager.CallToolAllPages(context.Background(), "list", map[string]any{"q": "x"})
+	if err != nil {
+		t.Fatalf("获取所有页失败: %v", err)
+	}
+	if len(result.Content) != 3 {
+		t.Fatalf("内容数量错误: %d", len(result.Content))
+	}
+	for i, content := range result.Content {
+		if text := content.(plugin.TextContent).Text; text != fmt.Sprint(i) {
+			t.Errorf("第 %d 页内容错误: %s", i, text)
+		}
+	}
+	if result.Meta["pages"] != 3 || result.Meta["last_page"] != 2 || result.NextCursor() != "" {
+		t.Errorf("合并后的元数据错误: %v", result.Meta)
+	}
+}
+
+// TestCallToolPagesRepeatedCursor 测试游标重复时停止
+func TestCallToolPagesRepeatedCursor(t *testing.T) {
+	manager := plugintest.NewManager(newPagedFake(10, func(page int) string { return "page-1" }))
+
+	if _, err := manager.CallToolAllPages(context.Background(), "list", nil); err == nil {
+		t.Error("游标重复时应该返回错误")
+	}
+}
+
+// TestCallToolPagesStop 测试处理函数返回错误时停止
+func TestCallToolPagesStop(t *testing.T) {
+	manager := plugintest.NewManager(newPagedFake(5, func(page int) string { return fmt.Sprintf("page-%d", page) }))
+
+	stop := fmt.Errorf("停止")
+	count := 0
+	err := manager.CallToolPages(context.Background(), "list", nil, func(page *plugin.CallToolResult) error {
+		count++
+		if count == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || count != 2 {
+		t.Errorf("处理函数返回错误时应该停止: %v, %d", err, count)
+	}
+}
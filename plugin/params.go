@@ -0,0 +1,414 @@
+// plugin/params.go - 将工具调用参数解码为Go结构体
+package plugin
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ParamError 单个参数的解码错误
+type ParamError struct {
+	Field string // 参数路径，嵌套字段以 . 分隔，数组元素以 [i] 表示
+	Err   error  // 具体错误
+}
+
+// Error 实现error接口
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("参数 %s: %v", e.Field, e.Err)
+}
+
+// Unwrap 返回具体错误
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// ParamsError 参数解码过程中的所有字段错误
+type ParamsError []*ParamError
+
+// Error 实现error接口
+func (e ParamsError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "参数解码失败: " + strings.Join(messages, "; ")
+}
+
+// DecodeParams 将工具调用参数解码到结构体中
+// out 必须是指向结构体的非空指针，字段名称和标签规则与 NewToolFromStruct 相同：
+// 缺失的参数使用 schema 标签中的 default 值，缺失的 required 参数会报错
+//
+// 解码时会按字段类型进行转换：
+//
+//	整数字段     - 接受没有小数部分的 float64（JSON数字）以及数字字符串
+//	浮点数字段   - 接受各种数字类型以及数字字符串
+//	布尔字段     - 接受 bool 以及 "true"/"false" 等字符串
+//	time.Duration - 接受 "1h30m" 形式的字符串，数字按纳秒处理
+//	time.Time    - 接受 RFC3339 格式的字符串
+//	[]byte       - 接受 base64 编码的字符串
+//
+// 所有字段都会被尝试解码，返回的错误为 ParamsError，包含每个失败字段的路径和原因
+func DecodeParams(params map[string]any, out any) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeParams: out 必须是指向结构体的非空指针，实际类型: %T", out)
+	}
+
+	var errs ParamsError
+	decodeStruct(params, v.Elem(), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// decodeStruct 将参数解码到结构体的各个字段中，错误追加到 errs
+func decodeStruct(params map[string]any, v reflect.Value, prefix string, errs *ParamsError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		// 与 NewToolFromStruct 一致，展开未指定json名称的匿名结构体字段
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						continue
+					}
+					fv.Set(reflect.New(ft.Elem()))
+				}
+				decodeStruct(params, fv.Elem(), prefix, errs)
+				continue
+			}
+			if ft.Kind() == reflect.Struct {
+				decodeStruct(params, fv, prefix, errs)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		path := prefix + name
+
+		raw, exists := params[name]
+		if !exists || raw == nil {
+			schema, required, err := fieldSchema(field, map[reflect.Type]bool{t: true})
+			if err != nil {
+				*errs = append(*errs, &ParamError{Field: path, Err: err})
+				continue
+			}
+			if def, ok := schema["default"]; ok {
+				raw = def
+			} else {
+				if required {
+					*errs = append(*errs, &ParamError{Field: path, Err: fmt.Errorf("缺少必需参数")})
+				}
+				continue
+			}
+		}
+
+		if err := decodeValue(raw, fv, path, errs); err != nil {
+			*errs = append(*errs, &ParamError{Field: path, Err: err})
+		}
+	}
+}
+
+// decodeValue 将单个参数值转换为目标类型并写入 v
+// 数组、map和结构体的元素错误直接追加到 errs，其余错误通过返回值返回
+func decodeValue(raw any, v reflect.Value, path string, errs *ParamsError) error {
+	t := v.Type()
+	if raw == nil {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+
+	switch t {
+	case durationType:
+		d, err := toDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		if s, ok := raw.(string); ok {
+			parsed, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return fmt.Errorf("时间格式无效: %w", err)
+			}
+			v.Set(reflect.ValueOf(parsed))
+			return nil
+		}
+	case bytesType:
+		if s, ok := raw.(string); ok {
+			data, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return fmt.Errorf("base64解码失败: %w", err)
+			}
+			v.SetBytes(data)
+			return nil
+		}
+	}
+
+	rv := reflect.ValueOf(raw)
+	if rv.Type().AssignableTo(t) {
+		v.Set(rv)
+		return nil
+	}
+
+	if s, ok := raw.(string); ok && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := decodeValue(raw, elem.Elem(), path, errs); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		s, err := toString(raw)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := toBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(raw)
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("数值 %d 超出 %s 的范围", i, t)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := toInt64(raw)
+		if err != nil {
+			return err
+		}
+		if i < 0 || v.OverflowUint(uint64(i)) {
+			return fmt.Errorf("数值 %d 超出 %s 的范围", i, t)
+		}
+		v.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(raw)
+		if err != nil {
+			return err
+		}
+		if v.OverflowFloat(f) {
+			return fmt.Errorf("数值 %v 超出 %s 的范围", f, t)
+		}
+		v.SetFloat(f)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return fmt.Errorf("无法将 %T 转换为 %s", raw, t)
+		}
+		n := rv.Len()
+		if t.Kind() == reflect.Array {
+			if n != t.Len() {
+				return fmt.Errorf("数组长度应为 %d，实际为 %d", t.Len(), n)
+			}
+		} else {
+			v.Set(reflect.MakeSlice(t, n, n))
+		}
+		for i := 0; i < n; i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := decodeValue(rv.Index(i).Interface(), v.Index(i), elemPath, errs); err != nil {
+				*errs = append(*errs, &ParamError{Field: elemPath, Err: err})
+			}
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String || rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("无法将 %T 转换为 %s", raw, t)
+		}
+		m := reflect.MakeMapWithSize(t, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			elemPath := path + "." + key
+			elem := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(iter.Value().Interface(), elem, elemPath, errs); err != nil {
+				*errs = append(*errs, &ParamError{Field: elemPath, Err: err})
+				continue
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		params, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("无法将 %T 转换为 %s", raw, t)
+		}
+		decodeStruct(params, v, path+".", errs)
+	default:
+		return fmt.Errorf("无法将 %T 转换为 %s", raw, t)
+	}
+	return nil
+}
+
+// toString 将字符串、数字或布尔值转换为字符串
+func toString(raw any) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("无法将 %T 转换为字符串", raw)
+}
+
+// toBool 将布尔值或字符串转换为布尔值
+func toBool(raw any) (bool, error) {
+	switch v := raw.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("无法将 %q 转换为布尔值", v)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("无法将 %T 转换为布尔值", raw)
+}
+
+// toInt64 将数字或数字字符串转换为整数，带小数部分的数值会报错
+func toInt64(raw any) (int64, error) {
+	switch v := raw.(type) {
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return uintToInt64(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return uintToInt64(v)
+	case float32:
+		return floatToInt64(float64(v))
+	case float64:
+		return floatToInt64(v)
+	case json.Number:
+		return stringToInt64(v.String())
+	case string:
+		return stringToInt64(v)
+	}
+	return 0, fmt.Errorf("无法将 %T 转换为整数", raw)
+}
+
+func uintToInt64(v uint64) (int64, error) {
+	if v > math.MaxInt64 {
+		return 0, fmt.Errorf("数值 %d 超出整数范围", v)
+	}
+	return int64(v), nil
+}
+
+func floatToInt64(f float64) (int64, error) {
+	if f != math.Trunc(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("数值 %v 不是整数", f)
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("数值 %v 超出整数范围", f)
+	}
+	return int64(f), nil
+}
+
+func stringToInt64(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法将 %q 转换为整数", s)
+	}
+	return floatToInt64(f)
+}
+
+// toFloat64 将数字或数字字符串转换为浮点数
+func toFloat64(raw any) (float64, error) {
+	switch v := raw.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法将 %q 转换为数字", v)
+		}
+		return f, nil
+	case bool:
+		return 0, fmt.Errorf("无法将 %T 转换为数字", raw)
+	}
+	i, err := toInt64(raw)
+	if err != nil {
+		return 0, fmt.Errorf("无法将 %T 转换为数字", raw)
+	}
+	return float64(i), nil
+}
+
+// toDuration 将 "1h30m" 形式的字符串或纳秒数转换为 time.Duration
+func toDuration(raw any) (time.Duration, error) {
+	if s, ok := raw.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("时长格式无效: %w", err)
+		}
+		return d, nil
+	}
+	if d, ok := raw.(time.Duration); ok {
+		return d, nil
+	}
+	i, err := toInt64(raw)
+	if err != nil {
+		return 0, fmt.Errorf("无法将 %T 转换为时长", raw)
+	}
+	return time.Duration(i), nil
+}
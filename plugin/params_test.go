@@ -0,0 +1,206 @@
+// params_test.go
+// 参数解码测试文件
+// 测试 DecodeParams 的类型转换、默认值和错误报告
+package plugin
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDecodeParams 测试参数解码和类型转换
+func TestDecodeParams(t *testing.T) {
+	type Nested struct {
+		Enabled bool `json:"enabled"`
+		Level   int  `json:"level" schema:"default=3"`
+	}
+	type Params struct {
+		Name     string            `json:"name" schema:"required"`
+		Count    int               `json:"count"`
+		Ratio    float32           `json:"ratio"`
+		Limit    uint8             `json:"limit" schema:"default=10"`
+		Timeout  time.Duration     `json:"timeout"`
+		Interval time.Duration     `json:"interval"`
+		At       time.Time         `json:"at"`
+		Data     []byte            `json:"data"`
+		Tags     []string          `json:"tags"`
+		Labels   map[string]int    `json:"labels"`
+		Nested   Nested            `json:"nested"`
+		Ptr      *int              `json:"ptr"`
+		Extra    map[string]any    `json:"extra"`
+		Skipped  string            `json:"-"`
+		Format   string            `json:"format" schema:"default=json,enum=json|xml"`
+		Unset    map[string]string `json:"unset"`
+	}
+
+	params := map[string]any{
+		"name":     "test",
+		"count":    float64(42),
+		"ratio":    "0.5",
+		"timeout":  "1m30s",
+		"interval": float64(time.Second),
+		"at":       "2024-01-02T03:04:05Z",
+		"data":     "aGVsbG8=",
+		"tags":     []any{"a", "b"},
+		"labels":   map[string]any{"x": 1.0, "y": "2"},
+		"nested":   map[string]any{"enabled": "true"},
+		"ptr":      7.0,
+		"extra":    map[string]any{"k": "v"},
+		"Skipped":  "ignored",
+		"unknown":  "ignored",
+	}
+
+	var p Params
+	if err := DecodeParams(params, &p); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+
+	if p.Name != "test" || p.Count != 42 || p.Ratio != 0.5 || p.Limit != 10 {
+		t.Errorf("基本类型解码错误: %+v", p)
+	}
+	if p.Timeout != 90*time.Second || p.Interval != time.Second {
+		t.Errorf("时长解码错误: %v, %v", p.Timeout, p.Interval)
+	}
+	if !p.At.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("时间解码错误: %v", p.At)
+	}
+	if string(p.Data) != "hello" {
+		t.Errorf("字节解码错误: %q", p.Data)
+	}
+	if !reflect.DeepEqual(p.Tags, []string{"a", "b"}) {
+		t.Errorf("数组解码错误: %v", p.Tags)
+	}
+	if !reflect.DeepEqual(p.Labels, map[string]int{"x": 1, "y": 2}) {
+		t.Errorf("map解码错误: %v", p.Labels)
+	}
+	if !p.Nested.Enabled || p.Nested.Level != 3 {
+		t.Errorf("嵌套结构体解码错误: %+v", p.Nested)
+	}
+	if p.Ptr == nil || *p.Ptr != 7 {
+		t.Errorf("指针解码错误: %v", p.Ptr)
+	}
+	if p.Extra["k"] != "v" {
+		t.Errorf("任意类型map解码错误: %v", p.Extra)
+	}
+	if p.Skipped != "" {
+		t.Errorf("忽略的字段不应该被解码: %q", p.Skipped)
+	}
+	if p.Format != "json" {
+		t.Errorf("默认值错误: %q", p.Format)
+	}
+	if p.Unset != nil {
+		t.Errorf("缺失的参数应该保持零值: %v", p.Unset)
+	}
+}
+
+// TestDecodeParamsEmbedded 测试匿名结构体字段的展开
+func TestDecodeParamsEmbedded(t *testing.T) {
+	type Base struct {
+		ID string `json:"id"`
+	}
+	type Params struct {
+		Base
+		*PageFixture
+		Query string `json:"query"`
+	}
+
+	var p Params
+	err := DecodeParams(map[string]any{"id": "1", "page": 2.0, "query": "q"}, &p)
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if p.ID != "1" || p.PageFixture == nil || p.Page != 2 || p.Query != "q" {
+		t.Errorf("匿名字段解码错误: %+v", p)
+	}
+}
+
+// PageFixture 测试用的匿名指针字段类型
+type PageFixture struct {
+	Page int `json:"page"`
+}
+
+// TestDecodeParamsErrors 测试逐字段的错误报告
+func TestDecodeParamsErrors(t *testing.T) {
+	type Params struct {
+		Name    string         `json:"name" schema:"required"`
+		Count   int            `json:"count"`
+		Small   int8           `json:"small"`
+		Size    uint           `json:"size"`
+		Timeout time.Duration  `json:"timeout"`
+		Tags    []int          `json:"tags"`
+		Labels  map[string]int `json:"labels"`
+		Ok      bool           `json:"ok"`
+	}
+
+	params := map[string]any{
+		"count":   1.5,
+		"small":   1000.0,
+		"size":    -1.0,
+		"timeout": "forever",
+		"tags":    []any{1.0, "x"},
+		"labels":  map[string]any{"a": true},
+		"ok":      true,
+	}
+
+	var p Params
+	err := DecodeParams(params, &p)
+	if err == nil {
+		t.Fatal("应该返回错误")
+	}
+
+	var paramsErr ParamsError
+	if !errors.As(err, &paramsErr) {
+		t.Fatalf("错误类型应该为 ParamsError: %T", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range paramsErr {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"name", "count", "small", "size", "timeout", "tags[1]", "labels.a"} {
+		if !fields[field] {
+			t.Errorf("缺少字段 %s 的错误: %v", field, err)
+		}
+	}
+	if len(paramsErr) != 7 {
+		t.Errorf("错误数量应该为 7，实际为 %d: %v", len(paramsErr), err)
+	}
+
+	// 其他字段仍然会被解码
+	if !p.Ok {
+		t.Error("有效的字段应该被解码")
+	}
+}
+
+// TestDecodeParamsInvalidTarget 测试无效的解码目标
+func TestDecodeParamsInvalidTarget(t *testing.T) {
+	var s struct{}
+	for _, out := range []any{nil, s, &[]string{}, (*struct{})(nil)} {
+		if err := DecodeParams(map[string]any{}, out); err == nil {
+			t.Errorf("目标 %T 应该返回错误", out)
+		}
+	}
+}
+
+// TestDecodeParamsStructRoundTrip 测试与 CallToolWithStruct 的参数转换配合使用
+func TestDecodeParamsStructRoundTrip(t *testing.T) {
+	type Params struct {
+		Name    string        `json:"name"`
+		Count   int64         `json:"count"`
+		Timeout time.Duration `json:"timeout"`
+		Tags    []string      `json:"tags"`
+	}
+
+	original := Params{Name: "n", Count: 1 << 40, Timeout: 3 * time.Second, Tags: []string{"x"}}
+	params := structToMap(original)
+
+	var decoded Params
+	if err := DecodeParams(params, &decoded); err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("往返结果不一致，期望: %+v, 实际: %+v", original, decoded)
+	}
+}
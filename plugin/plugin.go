@@ -5,9 +5,12 @@ package plugin
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/rpc"
 	"os"
@@ -17,7 +20,10 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 )
 
@@ -64,13 +70,28 @@ type PluginInfo struct {
 // ToolPluginRPC RPC客户端实现
 // 将接口调用转换为跨进程的RPC调用
 type ToolPluginRPC struct {
-	client *rpc.Client
+	client   *rpc.Client
+	codec    string         // 协商后使用的编解码器
+	pool     []*rpc.Client  // 连接池中除 client 以外的连接
+	inflight []atomic.Int32 // 每个连接上正在进行的调用数，序号0为 client
+	next     atomic.Uint32  // 轮询计数
+}
+
+// Codec 返回与插件通信使用的编解码器名称
+func (t *ToolPluginRPC) Codec() string {
+	return t.codec
+}
+
+// isMethodNotFound 判断RPC错误是否由插件缺少对应方法引起（旧版本插件）
+func isMethodNotFound(err error) bool {
+	var serverErr rpc.ServerError
+	return errors.As(err, &serverErr) && strings.HasPrefix(string(serverErr), "rpc: can't find method")
 }
 
 // GetTools 实现 ToolPluginInterface 接口的 GetTools 方法
 func (t *ToolPluginRPC) GetTools() ([]Tool, error) {
 	var tools []Tool
-	err := t.client.Call("Plugin.GetTools", new(any), &tools)
+	err := t.call("Plugin.GetTools", new(any), &tools)
 	return tools, err
 }
 
@@ -81,7 +102,7 @@ func (t *ToolPluginRPC) CallTool(toolName string, params map[string]any) (*CallT
 		Params:   params,
 	}
 	var result CallToolResult
-	err := t.client.Call("Plugin.CallTool", args, &result)
+	err := t.call("Plugin.CallTool", args, &result)
 	return &result, err
 }
 
@@ -107,14 +128,17 @@ func (t *ToolPluginRPC) CallToolWithStruct(toolName string, params any) (*CallTo
 // GetPluginInfo 实现 ToolPluginInterface 接口的 GetPluginInfo 方法
 func (t *ToolPluginRPC) GetPluginInfo() (PluginInfo, error) {
 	var info PluginInfo
-	err := t.client.Call("Plugin.GetPluginInfo", new(any), &info)
+	err := t.call("Plugin.GetPluginInfo", new(any), &info)
 	return info, err
 }
 
 // CallToolArgs 工具调用参数结构体
 type CallToolArgs struct {
-	ToolName string         `json:"tool_name"` // 工具名称
-	Params   map[string]any `json:"params"`    // 调用参数
+	ToolName  string         `json:"tool_name"`            // 工具名称
+	Params    map[string]any `json:"params"`               // 调用参数
+	DryRun    bool           `json:"dry_run,omitempty"`    // 是否试运行，试运行时只校验参数不产生副作用
+	SessionID string         `json:"session_id,omitempty"` // 会话ID，为空表示不属于任何会话
+	CallID    string         `json:"call_id,omitempty"`    // 调用ID，用于取消正在执行的调用
 }
 
 // StructCallToolArgs 支持结构化参数的工具调用参数结构体
@@ -128,7 +152,10 @@ type StructCallToolArgs struct {
 // ToolPluginRPCServer RPC服务器端实现
 // 接收RPC调用并转发给实际的插件实现
 type ToolPluginRPCServer struct {
-	Impl ToolPluginInterface // 实际的插件实现
+	Impl   ToolPluginInterface // 实际的插件实现
+	broker *plugin.MuxBroker   // 用于协商编解码器后建立新连接
+	calls  sync.Map            // 正在执行的可取消调用，key为调用ID，value为 context.CancelFunc 或提前到达的 pendingCancel
+	gate   *callGate           // 关闭时拒绝新调用，为 nil 时不限制
 }
 
 // GetTools 处理来自客户端的 GetTools RPC 调用
@@ -140,7 +167,27 @@ func (s *ToolPluginRPCServer) GetTools(args any, resp *[]Tool) error {
 
 // CallTool 处理来自客户端的 CallTool RPC 调用
 func (s *ToolPluginRPCServer) CallTool(args CallToolArgs, resp *CallToolResult) error {
-	result, err := s.Impl.CallTool(args.ToolName, args.Params)
+	if !s.gate.enter() {
+		return ErrPluginStopping
+	}
+	defer s.gate.leave()
+
+	if args.DryRun {
+		return s.dryRunTool(args, resp)
+	}
+
+	var result *CallToolResult
+	var err error
+	sessionImpl, isSession := s.Impl.(ToolPluginSessionInterface)
+	contextImpl, isContext := s.Impl.(ToolPluginContextInterface)
+	switch {
+	case isSession && args.SessionID != "":
+		result, err = sessionImpl.CallToolInSession(args.SessionID, args.ToolName, args.Params)
+	case isContext && args.CallID != "":
+		result, err = s.callToolContext(contextImpl, args)
+	default:
+		result, err = s.Impl.CallTool(args.ToolName, args.Params)
+	}
 	if err != nil {
 		// 创建错误结果
 		*resp = *NewErrorResult(fmt.Sprintf("调用工具失败: %v", err))
@@ -155,6 +202,11 @@ func (s *ToolPluginRPCServer) CallTool(args CallToolArgs, resp *CallToolResult)
 // 注意：由于客户端已经将结构体转换为map，这个方法现在实际上不会被调用
 // 保留此方法是为了接口完整性
 func (s *ToolPluginRPCServer) CallToolWithStruct(args StructCallToolArgs, resp *CallToolResult) error {
+	if !s.gate.enter() {
+		return ErrPluginStopping
+	}
+	defer s.gate.leave()
+
 	// 检查是否实现了泛型接口
 	if genericImpl, ok := s.Impl.(ToolPluginGenericInterface); ok {
 		// 调用结构化参数方法
@@ -193,19 +245,39 @@ func (s *ToolPluginRPCServer) GetPluginInfo(args any, resp *PluginInfo) error {
 // ToolPlugin 实现了 hashicorp/go-plugin 的 Plugin 接口
 // 这是插件系统的核心，负责客户端和服务器端的创建
 type ToolPlugin struct {
-	Impl ToolPluginInterface // 插件的实际实现
+	Impl     ToolPluginInterface // 插件的实际实现
+	Codecs   []string            // 主程序的编解码器偏好顺序，为空时使用 PreferredCodecs
+	PoolSize int                 // 主程序与插件之间的RPC连接数，为0时使用 DefaultPoolSize
+
+	gate *callGate // 插件端的调用控制，由 ServePluginWithOptions 设置
 }
 
 // Server 返回插件的RPC服务器实现
 // 这个方法在插件进程中被调用
-func (p *ToolPlugin) Server(*plugin.MuxBroker) (any, error) {
-	return &ToolPluginRPCServer{Impl: p.Impl}, nil
+func (p *ToolPlugin) Server(b *plugin.MuxBroker) (any, error) {
+	return &ToolPluginRPCServer{Impl: p.Impl, broker: b, gate: p.gate}, nil
 }
 
 // Client 返回插件的RPC客户端实现
 // 这个方法在主程序中被调用，用于与插件通信
-func (ToolPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (any, error) {
-	return &ToolPluginRPC{client: c}, nil
+// 创建客户端时会与插件协商编解码器，插件不支持时继续使用 gob
+// 协商成功时按 PoolSize 建立连接池，插件不支持协商时只使用原始连接
+func (p ToolPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (any, error) {
+	preferred := p.Codecs
+	if len(preferred) == 0 {
+		preferred = PreferredCodecs
+	}
+	poolSize := p.PoolSize
+	if poolSize == 0 {
+		poolSize = DefaultPoolSize
+	}
+
+	client, codec := negotiateCodec(b, c, preferred)
+	rpcClient := &ToolPluginRPC{client: client, codec: codec}
+	if codec != CodecGob {
+		rpcClient.setPool(dialPool(b, c, codec, poolSize))
+	}
+	return rpcClient, nil
 }
 
 // HandshakeConfig 定义了主程序和插件之间的握手配置
@@ -230,6 +302,8 @@ type LoadedPlugin struct {
 	Instance ToolPluginInterface // 插件实例
 	Info     PluginInfo          // 插件信息
 	Tools    []Tool              // 插件提供的工具
+	Codec    string              // 与插件通信使用的编解码器
+	Logger   hclog.Logger        // 插件的日志器
 }
 
 // PluginManager 插件管理器
@@ -238,13 +312,102 @@ type PluginManager struct {
 	mu      sync.RWMutex             // 读写锁
 	plugins map[string]*LoadedPlugin // 插件映射表，key为插件名称
 	toolMap map[string]*LoadedPlugin // 工具到插件的映射表，key为工具名称
+
+	authorizer Authorizer          // 授权函数，每次调用工具前检查
+	toolACLs   map[string]ToolACL  // 工具访问控制列表，key为工具名称
+	auditSink  AuditSink           // 审计输出，为 nil 时不记录
+	sessions   map[string]*Session // 未关闭的会话，key为会话ID
+
+	logMu           sync.Mutex             // 日志配置锁，加载插件时 mu 可能已被持有
+	logOutput       io.Writer              // 插件日志输出目标
+	logLevel        hclog.Level            // 插件默认日志级别
+	logJSON         bool                   // 是否以JSON格式输出插件日志
+	pluginLogLevels map[string]hclog.Level // 单独设置的插件日志级别，key为插件名称
+
+	poolSize int // 每个插件的RPC连接数，为0时使用 DefaultPoolSize
+
+	lazy              bool          // 是否延迟启动插件进程
+	keepaliveInterval time.Duration // 心跳检测间隔，为0时不检测
+	stopKeepalive     chan struct{} // 关闭时停止心跳检测
+	shutdownOnce      sync.Once     // 保证心跳检测只停止一次
+
+	tlsConfig *tls.Config // 连接插件使用的TLS配置，为 nil 时不加密
+	autoMTLS  bool        // 是否由 go-plugin 自动生成证书启用双向TLS
+
+	schemaDefaults bool         // 调用前是否补全参数默认值
+	coercion       CoercionMode // 调用前的参数类型转换模式
+
+	jobStore   JobStore   // 异步任务存储
+	jobWorkers int        // 异步任务工作协程数
+	jobs       *jobRunner // 异步任务工作协程池
+
+	jobQueue           JobQueue      // 分布式执行的任务队列，为 nil 时不启用
+	jobQueueKey        string        // 任务队列的键
+	jobQueuePoll       time.Duration // 队列为空时再次检查的间隔
+	jobQueueVisibility time.Duration // 取出任务后确认的期限，为0时使用 DefaultJobQueueVisibility
+
+	optionErrs []error // 应用配置选项时发现的无效参数
+}
+
+// ErrInvalidOption 插件管理器配置选项的参数无效
+var ErrInvalidOption = errors.New("插件管理器配置无效")
+
+// PluginManagerOption 插件管理器配置选项函数类型
+type PluginManagerOption func(*PluginManager)
+
+// invalidOption 记录无效的配置选项，NewPluginManagerE 在应用完所有选项后返回
+func (pm *PluginManager) invalidOption(format string, args ...any) {
+	pm.optionErrs = append(pm.optionErrs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidOption}, args...)...))
 }
 
 // NewPluginManager 创建新的插件管理器
-func NewPluginManager() *PluginManager {
-	return &PluginManager{
-		plugins: make(map[string]*LoadedPlugin),
-		toolMap: make(map[string]*LoadedPlugin),
+// options 用于配置日志等可选行为，不传时与 go-plugin 的默认行为一致
+// 参数无效的选项被忽略，对应的配置保持默认值；需要检查选项时使用 NewPluginManagerE
+func NewPluginManager(options ...PluginManagerOption) *PluginManager {
+	pm := newPluginManager(options)
+	pm.start()
+	return pm
+}
+
+// NewPluginManagerE 创建新的插件管理器，与 NewPluginManager 相同，
+// 但选项的参数无效时返回包装了 ErrInvalidOption 的错误
+func NewPluginManagerE(options ...PluginManagerOption) (*PluginManager, error) {
+	pm := newPluginManager(options)
+	if len(pm.optionErrs) > 0 {
+		pm.jobs.cancel()
+		return nil, errors.Join(pm.optionErrs...)
+	}
+	pm.start()
+	return pm, nil
+}
+
+// newPluginManager 创建插件管理器并应用配置选项，无效的选项记录在 optionErrs 中
+func newPluginManager(options []PluginManagerOption) *PluginManager {
+	pm := &PluginManager{
+		plugins:         make(map[string]*LoadedPlugin),
+		toolMap:         make(map[string]*LoadedPlugin),
+		logOutput:       os.Stderr,
+		logLevel:        hclog.Trace,
+		pluginLogLevels: make(map[string]hclog.Level),
+		toolACLs:        make(map[string]ToolACL),
+		sessions:        make(map[string]*Session),
+		jobStore:        NewMemoryJobStore(),
+		jobWorkers:      DefaultJobWorkers,
+		jobs:            &jobRunner{},
+	}
+	pm.jobs.ctx, pm.jobs.cancel = context.WithCancel(context.Background())
+
+	for _, option := range options {
+		option(pm)
+	}
+	return pm
+}
+
+// start 启动配置选项要求的后台任务
+func (pm *PluginManager) start() {
+	if pm.keepaliveInterval > 0 {
+		pm.stopKeepalive = make(chan struct{})
+		go pm.keepalive(pm.keepaliveInterval, pm.stopKeepalive)
 	}
 }
 
@@ -287,34 +450,14 @@ func (pm *PluginManager) LoadPlugin(pluginPath string) (*LoadedPlugin, error) {
 
 	log.Printf("正在加载插件: %s (路径: %s)", pluginName, pluginPath)
 
-	// 创建插件客户端配置
-	config := &plugin.ClientConfig{
-		HandshakeConfig:  HandshakeConfig,                          // 握手配置，确保版本兼容
-		Plugins:          PluginMap,                                // 插件映射表
-		Cmd:              exec.Command(pluginPath),                 // 插件可执行文件命令
-		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC}, // 允许的协议
-	}
+	// 每个插件使用独立的日志器，便于单独调整日志级别
+	logger := pm.pluginLogger(pluginName)
 
-	// 创建插件客户端
-	client := plugin.NewClient(config)
-
-	// 连接到插件
-	rpcClient, err := client.Client()
+	client, toolPlugin, err := pm.startPlugin(pluginName, pluginPath, logger)
 	if err != nil {
-		client.Kill()
-		return nil, fmt.Errorf("连接插件 %s 失败: %v", pluginName, err)
-	}
-
-	// 获取插件实例
-	raw, err := rpcClient.Dispense("tool")
-	if err != nil {
-		client.Kill()
-		return nil, fmt.Errorf("获取插件实例 %s 失败: %v", pluginName, err)
+		return nil, err
 	}
 
-	// 将插件实例转换为我们的接口类型
-	toolPlugin := raw.(ToolPluginInterface)
-
 	// 获取插件信息
 	pluginInfo, err := toolPlugin.GetPluginInfo()
 	if err != nil {
@@ -329,6 +472,12 @@ func (pm *PluginManager) LoadPlugin(pluginPath string) (*LoadedPlugin, error) {
 		return nil, fmt.Errorf("获取插件工具 %s 失败: %v", pluginName, err)
 	}
 
+	// 记录协商后的编解码器
+	codec := CodecGob
+	if rpcPlugin, ok := toolPlugin.(*ToolPluginRPC); ok {
+		codec = rpcPlugin.Codec()
+	}
+
 	// 创建已加载插件信息
 	loadedPlugin := &LoadedPlugin{
 		Name:     pluginName,
@@ -337,12 +486,53 @@ func (pm *PluginManager) LoadPlugin(pluginPath string) (*LoadedPlugin, error) {
 		Instance: toolPlugin,
 		Info:     pluginInfo,
 		Tools:    tools,
+		Codec:    codec,
+		Logger:   logger,
+	}
+
+	// 延迟加载模式下读取工具定义后停止插件进程，首次调用时再启动
+	if pm.lazy {
+		pm.makeLazy(loadedPlugin)
 	}
 
-	log.Printf("插件 %s 加载成功! 提供 %d 个工具", pluginName, len(tools))
+	log.Printf("插件 %s 加载成功! 提供 %d 个工具, 编解码器: %s", pluginName, len(tools), codec)
 	return loadedPlugin, nil
 }
 
+// startPlugin 启动插件进程并建立RPC连接，返回插件客户端和插件实例
+func (pm *PluginManager) startPlugin(pluginName, pluginPath string, logger hclog.Logger) (*plugin.Client, ToolPluginInterface, error) {
+	// 创建插件客户端配置
+	config := &plugin.ClientConfig{
+		HandshakeConfig:  HandshakeConfig,                          // 握手配置，确保版本兼容
+		Plugins:          pm.pluginMap(),                           // 插件映射表
+		Cmd:              exec.Command(pluginPath),                 // 插件可执行文件命令
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolNetRPC}, // 允许的协议
+		Logger:           logger,                                   // 插件日志器
+		TLSConfig:        pm.tlsConfig,                             // 自定义TLS配置
+		AutoMTLS:         pm.autoMTLS && pm.tlsConfig == nil,       // 自动双向TLS
+	}
+
+	// 创建插件客户端
+	client := plugin.NewClient(config)
+
+	// 连接到插件
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("连接插件 %s 失败: %v", pluginName, err)
+	}
+
+	// 获取插件实例
+	raw, err := rpcClient.Dispense("tool")
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("获取插件实例 %s 失败: %v", pluginName, err)
+	}
+
+	// 将插件实例转换为我们的接口类型
+	return client, raw.(ToolPluginInterface), nil
+}
+
 // LoadAllPlugins 加载所有扫描到的插件
 // pluginDir: 插件目录路径
 func (pm *PluginManager) LoadAllPlugins(pluginDir string) error {
@@ -414,37 +604,38 @@ func (pm *PluginManager) GetPluginByTool(toolName string) (*LoadedPlugin, bool)
 }
 
 // ListPlugins 列出所有已加载的插件
+// 需要分页、排序或过滤时使用 ListPluginsWithOptions
 func (pm *PluginManager) ListPlugins() []*LoadedPlugin {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	plugins := make([]*LoadedPlugin, 0, len(pm.plugins))
-	for _, plugin := range pm.plugins {
-		plugins = append(plugins, plugin)
-	}
-	return plugins
+	return pm.ListPluginsWithOptions()
 }
 
 // ListTools 列出所有可用的工具
+// 需要分页、排序或过滤时使用 ListToolsWithOptions
 func (pm *PluginManager) ListTools() []Tool {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-
-	var tools []Tool
-	for _, plugin := range pm.plugins {
-		tools = append(tools, plugin.Tools...)
-	}
-	return tools
+	return pm.ListToolsWithOptions()
 }
 
 // CallTool 调用指定的工具
+// 使用匿名调用方进行权限检查，需要指定调用方时使用 CallToolWithContext 和 WithIdentity
 func (pm *PluginManager) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	return pm.guardCall(callInfo{tool: toolName, params: params}, func() (*CallToolResult, error) {
+		return pm.invokeTool(toolName, params)
+	})
+}
+
+// invokeTool 查找工具对应的插件并调用，调用方需要先通过 guardCall 完成权限检查
+func (pm *PluginManager) invokeTool(toolName string, params map[string]any) (*CallToolResult, error) {
 	// 查找工具对应的插件
 	plugin, exists := pm.GetPluginByTool(toolName)
 	if !exists {
 		return nil, fmt.Errorf("工具 '%s' 不存在", toolName)
 	}
 
+	params, err := pm.prepareParams(toolName, params)
+	if err != nil {
+		return nil, err
+	}
+
 	// 调用插件的工具
 	return plugin.Instance.CallTool(toolName, params)
 }
@@ -485,6 +676,13 @@ func structToMap(obj any) map[string]any {
 // CallToolWithStruct 使用结构化参数调用指定的工具
 // 这个方法允许使用强类型参数而不是 map[string]any
 func (pm *PluginManager) CallToolWithStruct(toolName string, params any) (*CallToolResult, error) {
+	return pm.guardCall(callInfo{tool: toolName, params: params}, func() (*CallToolResult, error) {
+		return pm.invokeToolWithStruct(toolName, params)
+	})
+}
+
+// invokeToolWithStruct 使用结构化参数调用工具，调用方需要先通过 guardCall 完成权限检查
+func (pm *PluginManager) invokeToolWithStruct(toolName string, params any) (*CallToolResult, error) {
 	// 获取工具对应的插件
 	plugin, found := pm.GetPluginByTool(toolName)
 	if !found {
@@ -513,14 +711,33 @@ func (pm *PluginManager) CallToolWithStruct(toolName string, params any) (*CallT
 }
 
 // CallToolWithContext 带上下文调用指定的工具
+// 使用 WithIdentity 放入上下文的调用方进行权限检查
 func (pm *PluginManager) CallToolWithContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	return pm.guardCall(callInfo{caller: IdentityFromContext(ctx), tool: toolName, params: params}, func() (*CallToolResult, error) {
+		return pm.invokeToolWithContext(ctx, toolName, params)
+	})
+}
+
+// invokeToolWithContext 带上下文调用工具，调用方需要先通过 guardCall 完成权限检查
+func (pm *PluginManager) invokeToolWithContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	// 插件支持取消时由插件感知上下文，可以返回部分结果
+	if plugin, exists := pm.GetPluginByTool(toolName); exists {
+		if contextPlugin, ok := plugin.Instance.(ToolPluginContextInterface); ok {
+			params, err := pm.prepareParams(toolName, params)
+			if err != nil {
+				return nil, err
+			}
+			return contextPlugin.CallToolContext(ctx, toolName, params)
+		}
+	}
+
 	// 创建带取消功能的通道
 	resultChan := make(chan *CallToolResult, 1)
 	errorChan := make(chan error, 1)
 
 	// 在goroutine中执行工具调用
 	go func() {
-		result, err := pm.CallTool(toolName, params)
+		result, err := pm.invokeTool(toolName, params)
 		if err != nil {
 			errorChan <- err
 		} else {
@@ -551,55 +768,55 @@ func (pm *PluginManager) CallToolWithStructContext(ctx context.Context, toolName
 		// 继续执行
 	}
 
-	// 查找工具对应的插件
-	plugin, exists := pm.GetPluginByTool(toolName)
-	if !exists {
-		return nil, fmt.Errorf("工具 '%s' 不存在", toolName)
-	}
-
-	// 检查插件是否实现了泛型接口
-	if genericPlugin, ok := plugin.Instance.(ToolPluginGenericInterface); ok {
-		// 使用结构化参数接口调用
-		// 注意：这里我们没有传递上下文，因为插件接口没有定义带上下文的方法
-		// 但我们已经在调用前检查了上下文状态
-		return genericPlugin.CallToolWithStruct(toolName, params)
-	}
-
-	// 如果插件没有实现泛型接口，尝试将参数转换为 map[string]any
-	var paramsMap map[string]any
-
-	// 检查是否已经是map类型
-	if mapParams, ok := params.(map[string]any); ok {
-		paramsMap = mapParams
-	} else {
-		// 尝试将结构体转换为map
-		paramsMap = structToMap(params)
-	}
-
-	// 使用标准接口调用
-	return plugin.Instance.CallTool(toolName, paramsMap)
+	return pm.guardCall(callInfo{caller: IdentityFromContext(ctx), tool: toolName, params: params}, func() (*CallToolResult, error) {
+		// 注意：插件接口没有定义带上下文的方法，这里只在调用前检查了上下文状态
+		return pm.invokeToolWithStruct(toolName, params)
+	})
 }
 
 // Shutdown 关闭所有插件
 func (pm *PluginManager) Shutdown() {
+	pm.shutdownOnce.Do(func() {
+		if pm.stopKeepalive != nil {
+			close(pm.stopKeepalive)
+		}
+		pm.jobs.cancel()
+	})
+
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
 	log.Println("正在关闭所有插件...")
 	for name, plugin := range pm.plugins {
 		log.Printf("关闭插件: %s", name)
-		plugin.Client.Kill()
+		// 通过 RegisterPlugin 注册的进程内插件没有插件进程
+		if plugin.Client != nil {
+			plugin.Client.Kill()
+		}
+		if lazy, ok := plugin.Instance.(*lazyPlugin); ok {
+			lazy.stop()
+		}
+	}
+
+	// 插件进程退出后会话资源随之释放，只需将会话标记为关闭
+	for _, session := range pm.sessions {
+		session.mu.Lock()
+		session.closed = true
+		session.plugins = nil
+		session.mu.Unlock()
 	}
 
 	// 清空映射表
 	pm.plugins = make(map[string]*LoadedPlugin)
 	pm.toolMap = make(map[string]*LoadedPlugin)
+	pm.sessions = make(map[string]*Session)
 
 	log.Println("所有插件已关闭")
 }
 
 // registerGobTypes 注册所有需要的 gob 类型，用于 RPC 通信
 // 这个函数会在 ServePlugin 中自动调用，插件开发者不需要手动注册
+// 仅在编解码器协商回退到 gob 时需要，msgpack/json 编解码器不依赖类型注册
 func registerGobTypes() {
 	// 注册基础类型
 	gob.Register(map[string]any{}) // 注意：这里使用了空的map而不是nil
@@ -623,13 +840,17 @@ func registerGobTypes() {
 	// 注册工具相关类型
 	gob.RegisterName("github.com/gophertool/tool/plugin.Tool", Tool{})
 	gob.RegisterName("github.com/gophertool/tool/plugin.ToolInputSchema", ToolInputSchema{})
+	gob.RegisterName("github.com/gophertool/tool/plugin.ToolExample", ToolExample{})
 	gob.RegisterName("github.com/gophertool/tool/plugin.PluginInfo", PluginInfo{})
 	gob.RegisterName("github.com/gophertool/tool/plugin.CallToolArgs", CallToolArgs{})
 	gob.RegisterName("github.com/gophertool/tool/plugin.StructCallToolArgs", StructCallToolArgs{})
+	gob.RegisterName("github.com/gophertool/tool/plugin.CloseSessionArgs", CloseSessionArgs{})
+	gob.RegisterName("github.com/gophertool/tool/plugin.CancelCallArgs", CancelCallArgs{})
 }
 
 // RegisterStructType 注册自定义结构体类型，用于 RPC 通信
 // 注意：由于客户端现在会自动将结构体转换为map，通常不再需要调用此函数
+// 使用 msgpack/json 编解码器时不需要注册类型
 // 此函数保留用于特殊情况下需要传递原始结构体的场景
 // 例如：RegisterStructType(MyCustomStruct{}) 将注册 MyCustomStruct 类型
 func RegisterStructType(structType any) {
@@ -643,6 +864,7 @@ func RegisterStructType(structType any) {
 // ServePlugin 启动插件服务器
 // 这个函数应该在插件的main函数中调用
 // 它会自动注册所有需要的 gob 类型，插件开发者不需要手动注册
+// 需要TLS、自定义日志器、测试模式或关闭函数时使用 ServePluginWithOptions
 func ServePlugin(impl ToolPluginInterface) {
 	// 自动注册所有需要的 gob 类型，用于 RPC 通信
 	registerGobTypes()
@@ -5,6 +5,7 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,6 +13,28 @@ import (
 	"time"
 )
 
+// TestNewPluginManagerInvalidOption 测试无效的配置选项被 NewPluginManager 忽略，NewPluginManagerE 返回错误
+func TestNewPluginManagerInvalidOption(t *testing.T) {
+	options := map[string]PluginManagerOption{
+		"WithKeepalive":            WithKeepalive(0),
+		"WithConnectionPoolSize":   WithConnectionPoolSize(0),
+		"WithJobWorkers":           WithJobWorkers(0),
+		"WithJobQueuePollInterval": WithJobQueuePollInterval(0),
+	}
+	for name, option := range options {
+		manager, err := NewPluginManagerE(option)
+		if !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("%s 参数无效时应该返回 ErrInvalidOption，实际: %v", name, err)
+		}
+		if manager != nil {
+			t.Errorf("%s 参数无效时不应该返回管理器", name)
+		}
+		if NewPluginManager(option) == nil {
+			t.Errorf("%s 参数无效时 NewPluginManager 应该忽略该选项", name)
+		}
+	}
+}
+
 // TestPluginManager 测试插件管理器的基本功能
 func TestPluginManager(t *testing.T) {
 	// 创建测试插件目录
@@ -0,0 +1,262 @@
+// plugin/plugintest/plugintest.go - 主程序单元测试用的假插件
+// FakePlugin 是进程内的 ToolPluginInterface 实现，可以为每个工具设定返回结果、错误和延迟，
+// 并记录收到的所有调用，主程序的测试不需要编译插件二进制文件
+//
+//	fake := plugintest.NewFakePlugin("time_tool")
+//	fake.AddTool(plugin.NewTool("current_time", "获取当前时间")).
+//		Return(plugin.NewCallToolResult().AddTextContent("2024-01-01 00:00:00"))
+//	manager := plugintest.NewManager(fake)
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gophertool/tool/plugin"
+)
+
+// Call 假插件收到的一次工具调用
+type Call struct {
+	Tool   string         // 工具名称
+	Params map[string]any // 调用参数
+	Time   time.Time      // 调用时间
+}
+
+// Response 工具调用的预设响应，通过 FakePlugin.AddTool 或 FakePlugin.On 获取
+// 没有设定时返回空的成功结果
+type Response struct {
+	plugin  *FakePlugin
+	result  *plugin.CallToolResult
+	err     error
+	latency time.Duration
+	handler func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error)
+}
+
+// Return 设定调用返回的结果
+func (r *Response) Return(result *plugin.CallToolResult) *Response {
+	r.plugin.mu.Lock()
+	defer r.plugin.mu.Unlock()
+	r.result, r.err, r.handler = result, nil, nil
+	return r
+}
+
+// ReturnError 设定调用返回的错误
+func (r *Response) ReturnError(err error) *Response {
+	r.plugin.mu.Lock()
+	defer r.plugin.mu.Unlock()
+	r.result, r.err, r.handler = nil, err, nil
+	return r
+}
+
+// Handle 设定处理函数，根据调用参数动态生成结果
+func (r *Response) Handle(handler func(params map[string]any) (*plugin.CallToolResult, error)) *Response {
+	return r.HandleContext(func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error) {
+		return handler(params)
+	})
+}
+
+// HandleContext 设定接收调用上下文的处理函数，可以读取调用方身份或响应取消
+func (r *Response) HandleContext(handler func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error)) *Response {
+	r.plugin.mu.Lock()
+	defer r.plugin.mu.Unlock()
+	r.result, r.err, r.handler = nil, nil, handler
+	return r
+}
+
+// Delay 设定返回结果之前的延迟，用于模拟慢速插件
+// 通过 CallToolContext 调用时，上下文取消会提前结束等待
+func (r *Response) Delay(latency time.Duration) *Response {
+	r.plugin.mu.Lock()
+	defer r.plugin.mu.Unlock()
+	r.latency = latency
+	return r
+}
+
+// FakePlugin 进程内的假插件，实现了 plugin.ToolPluginContextInterface 接口
+// 所有方法都可以并发调用
+type FakePlugin struct {
+	mu        sync.Mutex
+	info      plugin.PluginInfo
+	tools     []plugin.Tool
+	responses map[string]*Response // 预设响应，key为工具名称
+	calls     []Call
+	toolsErr  error
+}
+
+var _ plugin.ToolPluginContextInterface = (*FakePlugin)(nil)
+
+// NewFakePlugin 创建一个指定名称的假插件
+func NewFakePlugin(name string) *FakePlugin {
+	return &FakePlugin{
+		info: plugin.PluginInfo{
+			Name:    name,
+			Version: "0.0.0",
+		},
+		responses: make(map[string]*Response),
+	}
+}
+
+// SetInfo 设置 GetPluginInfo 返回的插件信息
+func (f *FakePlugin) SetInfo(info plugin.PluginInfo) *FakePlugin {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.info = info
+	return f
+}
+
+// SetToolsError 设置 GetTools 返回的错误，用于模拟插件加载失败
+func (f *FakePlugin) SetToolsError(err error) *FakePlugin {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.toolsErr = err
+	return f
+}
+
+// AddTool 添加工具定义，返回该工具的预设响应
+func (f *FakePlugin) AddTool(tool *plugin.Tool) *Response {
+	f.mu.Lock()
+	f.tools = append(f.tools, *tool)
+	f.mu.Unlock()
+	return f.On(tool.Name)
+}
+
+// On 返回指定工具的预设响应，不存在时创建
+// 可以为没有在 GetTools 中声明的工具设定响应
+func (f *FakePlugin) On(toolName string) *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response, exists := f.responses[toolName]
+	if !exists {
+		response = &Response{plugin: f}
+		f.responses[toolName] = response
+	}
+	return response
+}
+
+// Calls 返回收到的所有调用
+func (f *FakePlugin) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// LastCall 返回最近一次调用，没有调用时 ok 为 false
+func (f *FakePlugin) LastCall() (call Call, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) == 0 {
+		return Call{}, false
+	}
+	return f.calls[len(f.calls)-1], true
+}
+
+// CallCount 返回指定工具被调用的次数
+func (f *FakePlugin) CallCount(toolName string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+	for _, call := range f.calls {
+		if call.Tool == toolName {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset 清空调用记录，预设响应保持不变
+func (f *FakePlugin) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// GetPluginInfo 实现 plugin.ToolPluginInterface 接口
+func (f *FakePlugin) GetPluginInfo() (plugin.PluginInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.info, nil
+}
+
+// GetTools 实现 plugin.ToolPluginInterface 接口
+func (f *FakePlugin) GetTools() ([]plugin.Tool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.toolsErr != nil {
+		return nil, f.toolsErr
+	}
+	return append([]plugin.Tool(nil), f.tools...), nil
+}
+
+// CallTool 实现 plugin.ToolPluginInterface 接口
+func (f *FakePlugin) CallTool(toolName string, params map[string]any) (*plugin.CallToolResult, error) {
+	return f.CallToolContext(context.Background(), toolName, params)
+}
+
+// CallToolContext 实现 plugin.ToolPluginContextInterface 接口
+// 记录调用后按预设响应返回，没有预设响应的工具返回错误结果
+func (f *FakePlugin) CallToolContext(ctx context.Context, toolName string, params map[string]any) (*plugin.CallToolResult, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Tool: toolName, Params: params, Time: time.Now()})
+	response, exists := f.responses[toolName]
+	var (
+		result  *plugin.CallToolResult
+		err     error
+		latency time.Duration
+		handler func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error)
+	)
+	if exists {
+		result, err, latency, handler = response.result, response.err, response.latency, response.handler
+	}
+	f.mu.Unlock()
+
+	if !exists {
+		return plugin.NewErrorResult(fmt.Sprintf("未知的工具: %s", toolName)), nil
+	}
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("工具调用被取消: %w", ctx.Err())
+		}
+	}
+
+	switch {
+	case handler != nil:
+		return handler(ctx, params)
+	case err != nil:
+		return nil, err
+	case result != nil:
+		return result, nil
+	default:
+		return plugin.NewCallToolResult(), nil
+	}
+}
+
+// NewManager 创建插件管理器并注册假插件，插件名称取自 PluginInfo.Name
+// 注册失败时panic
+func NewManager(plugins ...*FakePlugin) *plugin.PluginManager {
+	return NewManagerWithOptions(nil, plugins...)
+}
+
+// NewManagerWithOptions 使用指定的配置选项创建插件管理器并注册假插件
+// 选项无效或注册失败时panic
+func NewManagerWithOptions(options []plugin.PluginManagerOption, plugins ...*FakePlugin) *plugin.PluginManager {
+	manager, err := plugin.NewPluginManagerE(options...)
+	if err != nil {
+		panic(fmt.Sprintf("plugintest.NewManager: %v", err))
+	}
+	for _, fake := range plugins {
+		info, _ := fake.GetPluginInfo()
+		if _, err := manager.RegisterPlugin(info.Name, fake); err != nil {
+			panic(fmt.Sprintf("plugintest.NewManager: %v", err))
+		}
+	}
+	return manager
+}
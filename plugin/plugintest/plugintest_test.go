@@ -0,0 +1,131 @@
+// plugintest_test.go
+// 假插件测试文件
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/plugin"
+)
+
+// describeTime 模拟依赖 plugin.Manager 接口的主程序代码
+func describeTime(manager plugin.Manager) (string, error) {
+	result, err := manager.CallTool("current_time", map[string]any{"timezone": "UTC"})
+	if err != nil {
+		return "", err
+	}
+	text, _ := result.Content[0].(plugin.TextContent)
+	return text.Text, nil
+}
+
+// TestFakePlugin 测试预设响应和调用记录
+func TestFakePlugin(t *testing.T) {
+	fake := NewFakePlugin("time_tool")
+	fake.AddTool(plugin.NewTool("current_time", "获取当前时间")).
+		Return(plugin.NewCallToolResult().AddTextContent("2024-01-01 00:00:00"))
+	fake.AddTool(plugin.NewTool("broken", "总是失败")).
+		ReturnError(errors.New("插件崩溃"))
+
+	manager := NewManager(fake)
+	defer manager.Shutdown()
+
+	text, err := describeTime(manager)
+	if err != nil || text != "2024-01-01 00:00:00" {
+		t.Errorf("预设结果错误: %q, %v", text, err)
+	}
+	if _, err := manager.CallTool("broken", nil); err == nil {
+		t.Error("应该返回预设错误")
+	}
+	if len(manager.ListTools()) != 2 {
+		t.Errorf("工具数量错误: %d", len(manager.ListTools()))
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 2 || calls[0].Params["timezone"] != "UTC" {
+		t.Errorf("调用记录错误: %+v", calls)
+	}
+	if fake.CallCount("current_time") != 1 {
+		t.Errorf("调用次数错误: %d", fake.CallCount("current_time"))
+	}
+	fake.Reset()
+	if len(fake.Calls()) != 0 {
+		t.Error("Reset 后调用记录应该为空")
+	}
+}
+
+// TestFakePluginHandle 测试根据参数动态生成结果
+func TestFakePluginHandle(t *testing.T) {
+	fake := NewFakePlugin("echo")
+	fake.AddTool(plugin.NewTool("echo", "回显")).Handle(func(params map[string]any) (*plugin.CallToolResult, error) {
+		return plugin.NewCallToolResult().SetMeta("text", params["text"]), nil
+	})
+
+	result, err := fake.CallTool("echo", map[string]any{"text": "hi"})
+	if err != nil || result.Meta["text"] != "hi" {
+		t.Errorf("处理函数结果错误: %+v, %v", result, err)
+	}
+
+	result, _ = fake.CallTool("missing", nil)
+	if !result.IsError {
+		t.Error("没有预设响应的工具应该返回错误结果")
+	}
+}
+
+// TestFakePluginDelay 测试延迟和上下文取消
+func TestFakePluginDelay(t *testing.T) {
+	fake := NewFakePlugin("slow")
+	fake.AddTool(plugin.NewTool("slow", "慢速工具")).Delay(time.Second)
+	manager := NewManager(fake)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := manager.CallToolWithContext(ctx, "slow", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("应该返回取消错误，实际: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("取消后不应该继续等待，实际耗时: %v", elapsed)
+	}
+}
+
+// TestRegisterPluginErrors 测试注册失败的情况
+func TestRegisterPluginErrors(t *testing.T) {
+	manager := NewManager(NewFakePlugin("dup"))
+	if _, err := manager.RegisterPlugin("dup", NewFakePlugin("dup")); err == nil {
+		t.Error("重复注册应该返回错误")
+	}
+
+	failing := NewFakePlugin("failing").SetToolsError(errors.New("加载失败"))
+	if _, err := manager.RegisterPlugin("failing", failing); err == nil {
+		t.Error("获取工具失败时应该返回错误")
+	}
+}
+
+// TestFakePluginHandleContext 测试处理函数收到调用上下文
+func TestFakePluginHandleContext(t *testing.T) {
+	fake := NewFakePlugin("whoami")
+	fake.AddTool(plugin.NewTool("whoami", "返回调用方")).HandleContext(func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error) {
+		return plugin.NewCallToolResult().AddTextContent(plugin.IdentityFromContext(ctx).ID), nil
+	})
+	manager := NewManagerWithOptions([]plugin.PluginManagerOption{plugin.WithSchemaDefaults()}, fake)
+
+	ctx := plugin.WithIdentity(context.Background(), plugin.Identity{ID: "alice"})
+	result, err := manager.CallToolWithContext(ctx, "whoami", map[string]any{"n": 1})
+	if err != nil || result.Content[0].(plugin.TextContent).Text != "alice" {
+		t.Errorf("处理函数应该收到调用方身份: %+v, %v", result, err)
+	}
+
+	call, ok := fake.LastCall()
+	if !ok || call.Tool != "whoami" || call.Params["n"] != 1 {
+		t.Errorf("最近一次调用记录错误: %+v, %v", call, ok)
+	}
+	fake.Reset()
+	if _, ok := fake.LastCall(); ok {
+		t.Error("Reset 后不应该有调用记录")
+	}
+}
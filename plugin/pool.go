@@ -0,0 +1,129 @@
+// plugin/pool.go - 每个插件的RPC连接池
+// net/rpc 在单个连接上已经可以并发调用，但所有请求和响应共用一个连接依次读写，
+// 大参数或大结果会阻塞其后的调用。连接池在协商编解码器时建立多个连接，每次调用选择正在进行的调用最少的连接
+package plugin
+
+import (
+	"log"
+	"net/rpc"
+	"sync/atomic"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// DefaultPoolSize 每个插件默认建立的RPC连接数
+// 插件不支持编解码器协商（旧版本插件）时只使用 go-plugin 建立的原始连接
+var DefaultPoolSize = 4
+
+// WithConnectionPoolSize 设置每个插件建立的RPC连接数，默认为 DefaultPoolSize
+// size 为 1 时所有调用共用一个连接，小于 1 时忽略该选项，NewPluginManagerE 返回错误
+func WithConnectionPoolSize(size int) PluginManagerOption {
+	return func(pm *PluginManager) {
+		if size < 1 {
+			pm.invalidOption("WithConnectionPoolSize 连接数必须大于0，实际: %d", size)
+			return
+		}
+		pm.poolSize = size
+	}
+}
+
+// PoolSize 返回与插件之间的RPC连接数
+func (t *ToolPluginRPC) PoolSize() int {
+	return 1 + len(t.pool)
+}
+
+// setPool 设置连接池中除 client 以外的连接
+func (t *ToolPluginRPC) setPool(pool []*rpc.Client) {
+	t.pool = pool
+	t.inflight = make([]atomic.Int32, len(pool)+1)
+}
+
+// connAt 返回指定序号的连接，序号0为 client
+func (t *ToolPluginRPC) connAt(i int) *rpc.Client {
+	if i == 0 {
+		return t.client
+	}
+	return t.pool[i-1]
+}
+
+// acquire 选择正在进行的调用最少的连接，避免新调用排在大参数或大结果的调用之后
+// 正在进行的调用数相同时按轮询选择，调用结束后需要调用 release
+func (t *ToolPluginRPC) acquire() int {
+	if len(t.pool) == 0 {
+		return 0
+	}
+
+	size := len(t.inflight)
+	start := int(t.next.Add(1) % uint32(size))
+	best := start
+	for offset := 1; offset < size; offset++ {
+		i := (start + offset) % size
+		if t.inflight[i].Load() < t.inflight[best].Load() {
+			best = i
+		}
+	}
+	t.inflight[best].Add(1)
+	return best
+}
+
+// release 标记连接上的一个调用已结束
+func (t *ToolPluginRPC) release(i int) {
+	if len(t.pool) == 0 {
+		return
+	}
+	t.inflight[i].Add(-1)
+}
+
+// call 在连接池中选择一个连接进行同步RPC调用
+func (t *ToolPluginRPC) call(method string, args any, reply any) error {
+	i := t.acquire()
+	defer t.release(i)
+	return t.connAt(i).Call(method, args, reply)
+}
+
+// goCall 在连接池中选择一个连接进行异步RPC调用，调用结束时返回的通道收到结果
+func (t *ToolPluginRPC) goCall(method string, args any, reply any) <-chan *rpc.Call {
+	i := t.acquire()
+	call := t.connAt(i).Go(method, args, reply, make(chan *rpc.Call, 1))
+	if len(t.pool) == 0 {
+		return call.Done
+	}
+
+	done := make(chan *rpc.Call, 1)
+	go func() {
+		finished := <-call.Done
+		t.release(i)
+		done <- finished
+	}()
+	return done
+}
+
+// dialPool 在第一个连接协商成功后，使用相同的编解码器建立其余的连接
+// 某个连接建立失败时记录日志并使用已建立的连接
+func dialPool(broker *plugin.MuxBroker, client *rpc.Client, codec string, size int) []*rpc.Client {
+	var pool []*rpc.Client
+	for len(pool) < size-1 {
+		conn, negotiated := negotiateCodec(broker, client, []string{codec})
+		if negotiated != codec {
+			log.Printf("建立 %s 连接池失败，已建立 %d 个连接", codec, len(pool)+1)
+			break
+		}
+		pool = append(pool, conn)
+	}
+	return pool
+}
+
+// pluginMap 返回加载插件使用的插件映射表
+// 没有设置连接数时使用全局的 PluginMap
+func (pm *PluginManager) pluginMap() map[string]plugin.Plugin {
+	if pm.poolSize == 0 {
+		return PluginMap
+	}
+
+	toolPlugin := ToolPlugin{}
+	if p, ok := PluginMap["tool"].(*ToolPlugin); ok {
+		toolPlugin = *p
+	}
+	toolPlugin.PoolSize = pm.poolSize
+	return map[string]plugin.Plugin{"tool": &toolPlugin}
+}
@@ -0,0 +1,146 @@
+// pool_test.go
+// RPC连接池测试文件
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newPipePool 创建通过 net.Pipe 连接到同一个RPC服务的连接池客户端
+func newPipePool(tb testing.TB, rcvr any, size int) *ToolPluginRPC {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Plugin", rcvr); err != nil {
+		tb.Fatalf("注册RPC服务失败: %v", err)
+	}
+
+	codec := codecs[CodecMsgpack]
+	conns := make([]*rpc.Client, size)
+	for i := range conns {
+		serverConn, clientConn := net.Pipe()
+		go server.ServeCodec(codec.NewServerCodec(serverConn))
+		conns[i] = rpc.NewClientWithCodec(codec.NewClientCodec(clientConn))
+	}
+
+	client := &ToolPluginRPC{client: conns[0], codec: CodecMsgpack}
+	client.setPool(conns[1:])
+	tb.Cleanup(func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	return client
+}
+
+// TestConnectionPool 测试调用按轮询分配到各个连接
+func TestConnectionPool(t *testing.T) {
+	client := newPipePool(t, &ToolPluginRPCServer{Impl: &echoPlugin{}}, 3)
+	if client.PoolSize() != 3 {
+		t.Fatalf("连接数错误: %d", client.PoolSize())
+	}
+
+	// 前三个调用未结束时应该分配到不同的连接
+	used := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		used[client.acquire()] = true
+	}
+	if len(used) != 3 {
+		t.Errorf("调用应该分配到空闲的连接: %v", used)
+	}
+	for i := range used {
+		client.release(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			text := fmt.Sprint(i)
+			result, err := client.CallTool("echo", map[string]any{"text": text})
+			if err != nil {
+				t.Errorf("并发调用失败: %v", err)
+				return
+			}
+			params := result.Content[1].(StructContent).Data.(map[string]any)
+			if params["text"] != text {
+				t.Errorf("并发调用结果错乱，期望: %s, 实际: %v", text, params["text"])
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	single := &ToolPluginRPC{client: client.client}
+	if single.PoolSize() != 1 || single.connAt(single.acquire()) != client.client {
+		t.Error("没有连接池时应该使用原始连接")
+	}
+}
+
+// TestWithConnectionPoolSize 测试连接数配置
+func TestWithConnectionPoolSize(t *testing.T) {
+	if NewPluginManager().pluginMap()["tool"] != PluginMap["tool"] {
+		t.Error("没有设置连接数时应该使用全局插件映射表")
+	}
+
+	toolPlugin := NewPluginManager(WithConnectionPoolSize(2)).pluginMap()["tool"].(*ToolPlugin)
+	if toolPlugin.PoolSize != 2 {
+		t.Errorf("连接数错误: %d", toolPlugin.PoolSize)
+	}
+
+	if NewPluginManager(WithConnectionPoolSize(0)).pluginMap()["tool"] != PluginMap["tool"] {
+		t.Error("连接数小于1时应该忽略该选项")
+	}
+	if _, err := NewPluginManagerE(WithConnectionPoolSize(0)); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("连接数小于1时应该返回 ErrInvalidOption，实际: %v", err)
+	}
+}
+
+// BenchmarkCallToolParallel 比较单连接和连接池在并发调用下的性能
+// 后台持续进行大参数调用，测量同时进行的小参数调用的耗时
+func BenchmarkCallToolParallel(b *testing.B) {
+	large := map[string]any{"text": strings.Repeat("x", 256<<10)}
+	small := map[string]any{"text": "x"}
+	for _, size := range []int{1, 4} {
+		b.Run(fmt.Sprintf("pool=%d", size), func(b *testing.B) {
+			client := newPipePool(b, &ToolPluginRPCServer{Impl: &echoPlugin{}}, size)
+
+			done := make(chan struct{})
+			var largeCalls atomic.Int64
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						client.CallTool("echo", large)
+						largeCalls.Add(1)
+					}
+				}
+			}()
+			defer func() {
+				close(done)
+				wg.Wait()
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := client.CallTool("echo", small); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.ReportMetric(float64(largeCalls.Load())/float64(b.N), "large/op")
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// plugin/prepare.go - 调用插件前按工具的输入模式处理参数
+// 启用后由主程序统一补全缺失参数的默认值、转换参数类型，插件不需要各自实现这些逻辑
+package plugin
+
+import "fmt"
+
+// WithSchemaDefaults 调用插件前将工具输入模式中声明的 default 值填入缺失的参数
+// 参数值为 nil 时同样视为缺失；嵌套对象参数按其 properties 递归补全，调用方传入的参数不会被修改
+// 结构化参数（CallToolWithStruct）不做处理
+func WithSchemaDefaults() PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.schemaDefaults = true
+	}
+}
+
+// findTool 获取工具定义
+func (pm *PluginManager) findTool(toolName string) (*Tool, bool) {
+	plugin, exists := pm.GetPluginByTool(toolName)
+	if !exists {
+		return nil, false
+	}
+	for i := range plugin.Tools {
+		if plugin.Tools[i].Name == toolName {
+			return &plugin.Tools[i], true
+		}
+	}
+	return nil, false
+}
+
+// prepareParams 按工具的输入模式处理调用参数，返回交给插件的参数
+// 没有启用任何处理或找不到工具定义时原样返回
+func (pm *PluginManager) prepareParams(toolName string, params map[string]any) (map[string]any, error) {
+	if !pm.schemaDefaults && pm.coercion == CoercionOff {
+		return params, nil
+	}
+	tool, exists := pm.findTool(toolName)
+	if !exists {
+		return params, nil
+	}
+
+	if pm.schemaDefaults {
+		params, _ = applyDefaults(tool.InputSchema.Properties, params)
+	}
+	if pm.coercion != CoercionOff {
+		coerced, err := coerceParams(tool.InputSchema.Properties, params, pm.coercion)
+		if err != nil {
+			return nil, fmt.Errorf("工具 '%s' 参数校验失败: %w", toolName, err)
+		}
+		params = coerced
+	}
+	return params, nil
+}
+
+// applyDefaults 返回补全了默认值的参数，changed 表示是否有参数被补全
+// 有参数被补全时返回副本，否则返回原参数
+func applyDefaults(properties map[string]any, params map[string]any) (result map[string]any, changed bool) {
+	result = params
+	set := func(name string, value any) {
+		if !changed {
+			result = make(map[string]any, len(params)+1)
+			for k, v := range params {
+				result[k] = v
+			}
+			changed = true
+		}
+		result[name] = value
+	}
+
+	for name, raw := range properties {
+		schema, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		value, exists := params[name]
+		if !exists || value == nil {
+			if def, ok := schema["default"]; ok {
+				set(name, def)
+			}
+			continue
+		}
+
+		nested, ok := schema["properties"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if object, ok := value.(map[string]any); ok {
+			if filled, nestedChanged := applyDefaults(nested, object); nestedChanged {
+				set(name, filled)
+			}
+		}
+	}
+	return result, changed
+}
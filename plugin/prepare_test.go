@@ -0,0 +1,73 @@
+// prepare_test.go
+// 调用前参数处理测试文件
+package plugin_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// lastParams 返回假插件最近一次调用收到的参数
+func lastParams(fake *plugintest.FakePlugin) map[string]any {
+	call, _ := fake.LastCall()
+	return call.Params
+}
+
+// TestSchemaDefaults 测试按输入模式补全默认值
+func TestSchemaDefaults(t *testing.T) {
+	tool := plugin.NewTool("fetch", "获取数据",
+		plugin.WithString("format", plugin.Default("json")),
+		plugin.WithNumber("limit", plugin.Default(10)),
+		plugin.WithString("query", plugin.Required()),
+		plugin.WithObject("retry", plugin.Properties(map[string]any{
+			"times": map[string]any{"type": "number", "default": 3},
+		})),
+	)
+	fake := plugintest.NewFakePlugin("record")
+	fake.AddTool(tool)
+	manager := plugintest.NewManagerWithOptions([]plugin.PluginManagerOption{plugin.WithSchemaDefaults()}, fake)
+
+	params := map[string]any{"query": "x", "limit": nil, "retry": map[string]any{}}
+	if _, err := manager.CallTool("fetch", params); err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+	want := map[string]any{
+		"query":  "x",
+		"format": "json",
+		"limit":  10,
+		"retry":  map[string]any{"times": 3},
+	}
+	if !reflect.DeepEqual(lastParams(fake), want) {
+		t.Errorf("默认值补全错误，期望: %v, 实际: %v", want, lastParams(fake))
+	}
+	if len(params) != 3 || params["limit"] != nil || len(params["retry"].(map[string]any)) != 0 {
+		t.Errorf("调用方的参数不应该被修改: %v", params)
+	}
+
+	// 调用方提供的值保持不变
+	manager.CallTool("fetch", map[string]any{"query": "x", "format": "xml"})
+	if lastParams(fake)["format"] != "xml" {
+		t.Errorf("不应该覆盖调用方提供的值: %v", lastParams(fake))
+	}
+
+	// 参数为 nil 时同样补全
+	manager.CallTool("fetch", nil)
+	if lastParams(fake)["format"] != "json" {
+		t.Errorf("参数为 nil 时应该补全默认值: %v", lastParams(fake))
+	}
+}
+
+// TestSchemaDefaultsDisabled 测试未启用时不处理参数
+func TestSchemaDefaultsDisabled(t *testing.T) {
+	fake := plugintest.NewFakePlugin("record")
+	fake.AddTool(plugin.NewTool("fetch", "获取数据", plugin.WithString("format", plugin.Default("json"))))
+	manager := plugintest.NewManager(fake)
+
+	manager.CallTool("fetch", map[string]any{})
+	if _, ok := lastParams(fake)["format"]; ok {
+		t.Errorf("未启用时不应该补全默认值: %v", lastParams(fake))
+	}
+}
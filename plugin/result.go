@@ -2,8 +2,11 @@
 package plugin
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+
+	"github.com/gophertool/tool/encoding/msgpack"
 )
 
 // validateStructType 验证数据类型是否为结构化数据类型
@@ -85,6 +88,20 @@ func (tc TextContent) GetType() ContentType {
 	return ContentTypeText
 }
 
+// MarshalJSON 自定义TextContent的JSON序列化方法，确保输出 type 字段
+func (tc TextContent) MarshalJSON() ([]byte, error) {
+	type alias TextContent
+	tc.Type = ContentTypeText
+	return json.Marshal(alias(tc))
+}
+
+// MarshalMsgpack 自定义TextContent的MessagePack序列化方法，确保输出 type 字段
+func (tc TextContent) MarshalMsgpack() ([]byte, error) {
+	type alias TextContent
+	tc.Type = ContentTypeText
+	return msgpack.Marshal(alias(tc))
+}
+
 // FileType 定义文件类型的枚举
 type FileType string
 
@@ -140,6 +157,20 @@ func (fc FileContent) GetType() ContentType {
 	return ContentTypeFile
 }
 
+// MarshalJSON 自定义FileContent的JSON序列化方法，确保输出 type 字段
+func (fc FileContent) MarshalJSON() ([]byte, error) {
+	type alias FileContent
+	fc.Type = ContentTypeFile
+	return json.Marshal(alias(fc))
+}
+
+// MarshalMsgpack 自定义FileContent的MessagePack序列化方法，确保输出 type 字段
+func (fc FileContent) MarshalMsgpack() ([]byte, error) {
+	type alias FileContent
+	fc.Type = ContentTypeFile
+	return msgpack.Marshal(alias(fc))
+}
+
 // SetImageProperties 设置图片属性
 func (fc FileContent) SetImageProperties(width, height int) FileContent {
 	fc.Width = width
@@ -195,6 +226,20 @@ func (sc StructContent) GetType() ContentType {
 	return ContentTypeStruct
 }
 
+// MarshalJSON 自定义StructContent的JSON序列化方法，确保输出 type 字段
+func (sc StructContent) MarshalJSON() ([]byte, error) {
+	type alias StructContent
+	sc.Type = ContentTypeStruct
+	return json.Marshal(alias(sc))
+}
+
+// MarshalMsgpack 自定义StructContent的MessagePack序列化方法，确保输出 type 字段
+func (sc StructContent) MarshalMsgpack() ([]byte, error) {
+	type alias StructContent
+	sc.Type = ContentTypeStruct
+	return msgpack.Marshal(alias(sc))
+}
+
 // SetStructSchema 设置结构体模式定义
 func (sc StructContent) SetStructSchema(schema string) StructContent {
 	sc.Schema = schema
@@ -223,6 +268,90 @@ type CallToolResult struct {
 	IsError bool `json:"isError,omitempty"`
 }
 
+// UnmarshalJSON 自定义CallToolResult的JSON反序列化方法
+// 根据内容的 type 字段还原为具体的内容类型
+func (ctr *CallToolResult) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Meta    map[string]any    `json:"_meta,omitempty"`
+		Content []json.RawMessage `json:"content"`
+		IsError bool              `json:"isError,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	contents, err := unmarshalContents(raw.Content, json.Unmarshal)
+	if err != nil {
+		return err
+	}
+	ctr.Meta = raw.Meta
+	ctr.Content = contents
+	ctr.IsError = raw.IsError
+	return nil
+}
+
+// UnmarshalMsgpack 自定义CallToolResult的MessagePack反序列化方法
+// 根据内容的 type 字段还原为具体的内容类型
+func (ctr *CallToolResult) UnmarshalMsgpack(data []byte) error {
+	var raw struct {
+		Meta    map[string]any       `json:"_meta,omitempty"`
+		Content []msgpack.RawMessage `json:"content"`
+		IsError bool                 `json:"isError,omitempty"`
+	}
+	if err := msgpack.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	contents, err := unmarshalContents(raw.Content, msgpack.Unmarshal)
+	if err != nil {
+		return err
+	}
+	ctr.Meta = raw.Meta
+	ctr.Content = contents
+	ctr.IsError = raw.IsError
+	return nil
+}
+
+// unmarshalContents 使用 unmarshal 逐个反序列化原始内容
+func unmarshalContents[T ~[]byte](items []T, unmarshal func([]byte, any) error) ([]Content, error) {
+	contents := make([]Content, 0, len(items))
+	for _, item := range items {
+		content, err := unmarshalContent(item, unmarshal)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// unmarshalContent 根据 type 字段将原始数据反序列化为具体的内容类型
+func unmarshalContent(data []byte, unmarshal func([]byte, any) error) (Content, error) {
+	var head struct {
+		Type ContentType `json:"type"`
+	}
+	if err := unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+
+	switch head.Type {
+	case ContentTypeText:
+		var content TextContent
+		err := unmarshal(data, &content)
+		return content, err
+	case ContentTypeFile:
+		var content FileContent
+		err := unmarshal(data, &content)
+		return content, err
+	case ContentTypeStruct:
+		var content StructContent
+		err := unmarshal(data, &content)
+		return content, err
+	default:
+		return nil, fmt.Errorf("未知的内容类型: %q", head.Type)
+	}
+}
+
 // NewCallToolResult 创建一个新的工具调用结果
 func NewCallToolResult() *CallToolResult {
 	return &CallToolResult{
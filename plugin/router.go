@@ -0,0 +1,204 @@
+// plugin/router.go - 插件端的工具路由
+// 插件作者通过 Handle 为每个工具注册处理函数，不再需要在 CallTool 中编写 switch 语句
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	contextType   = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	resultPtrType = reflect.TypeOf((*CallToolResult)(nil))
+	paramsMapType = reflect.TypeOf(map[string]any(nil))
+)
+
+// Router 插件端的工具路由，实现了 ToolPluginContextInterface 接口
+// 可以直接传给 ServePlugin：
+//
+//	router := plugin.NewRouter(plugin.WithPluginInfo(info))
+//	router.Handle("current_time", currentTime, nil)
+//	plugin.ServePlugin(router)
+type Router struct {
+	info PluginInfo
+
+	mu     sync.RWMutex
+	routes map[string]*route
+	order  []string // 工具注册顺序，GetTools 按该顺序返回
+}
+
+// route 单个工具的路由信息
+type route struct {
+	tool      Tool
+	handler   reflect.Value
+	withCtx   bool         // 处理函数的第一个参数是否为 context.Context
+	paramType reflect.Type // 参数类型，map[string]any、结构体或指向结构体的指针
+}
+
+// RouterOption 路由配置选项
+type RouterOption func(*Router)
+
+// WithPluginInfo 设置 GetPluginInfo 返回的插件信息
+func WithPluginInfo(info PluginInfo) RouterOption {
+	return func(r *Router) {
+		r.info = info
+	}
+}
+
+// NewRouter 创建一个新的工具路由
+func NewRouter(options ...RouterOption) *Router {
+	r := &Router{
+		routes: make(map[string]*route),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Handle 注册工具及其处理函数，返回路由本身以便链式调用
+// 处理函数支持以下形式，其中 T 为结构体或指向结构体的指针：
+//
+//	func(params map[string]any) (*CallToolResult, error)
+//	func(ctx context.Context, params map[string]any) (*CallToolResult, error)
+//	func(params T) (*CallToolResult, error)
+//	func(ctx context.Context, params T) (*CallToolResult, error)
+//
+// 参数类型为 T 时，调用参数通过 DecodeParams 绑定，解码失败时返回错误结果；
+// tool 为 nil 时根据 T 自动生成工具定义（参数为 map 时生成没有参数的定义）
+// 带 ctx 的处理函数在主程序取消调用时会收到取消通知
+// 工具名称重复或处理函数形式不正确时会panic
+func (r *Router) Handle(name string, handler any, tool *Tool) *Router {
+	rt := newRoute(name, handler)
+
+	if tool == nil {
+		if rt.paramType == paramsMapType {
+			tool = NewTool(name, "")
+		} else {
+			tool = NewToolFromStruct(name, "", reflect.New(rt.paramType).Elem().Interface())
+		}
+	}
+	if tool.Name != name {
+		panic(fmt.Sprintf("Router.Handle: 工具定义名称 '%s' 与注册名称 '%s' 不一致", tool.Name, name))
+	}
+	rt.tool = *tool
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.routes[name]; exists {
+		panic(fmt.Sprintf("Router.Handle: 工具 '%s' 已注册", name))
+	}
+	r.routes[name] = rt
+	r.order = append(r.order, name)
+	return r
+}
+
+// newRoute 检查处理函数的形式并创建路由信息
+func newRoute(name string, handler any) *route {
+	v := reflect.ValueOf(handler)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		panic(fmt.Sprintf("Router.Handle: 工具 '%s' 的处理函数必须是函数，实际类型: %T", name, handler))
+	}
+
+	t := v.Type()
+	rt := &route{handler: v}
+	in := 0
+	if t.NumIn() == 2 && t.In(0) == contextType {
+		rt.withCtx = true
+		in = 1
+	}
+	if t.NumIn() != in+1 || t.NumOut() != 2 || t.Out(0) != resultPtrType || t.Out(1) != errorType {
+		panic(fmt.Sprintf("Router.Handle: 工具 '%s' 的处理函数形式不正确: %v", name, t))
+	}
+
+	rt.paramType = t.In(in)
+	structType := rt.paramType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if rt.paramType != paramsMapType && structType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("Router.Handle: 工具 '%s' 的参数必须是 map[string]any 或结构体，实际类型: %v", name, rt.paramType))
+	}
+	return rt
+}
+
+// GetTools 实现 ToolPluginInterface 接口，按注册顺序返回所有工具定义
+func (r *Router) GetTools() ([]Tool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.routes[name].tool)
+	}
+	return tools, nil
+}
+
+// GetPluginInfo 实现 ToolPluginInterface 接口，返回 WithPluginInfo 设置的插件信息
+func (r *Router) GetPluginInfo() (PluginInfo, error) {
+	return r.info, nil
+}
+
+// CallTool 实现 ToolPluginInterface 接口
+func (r *Router) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	return r.CallToolContext(context.Background(), toolName, params)
+}
+
+// CallToolContext 实现 ToolPluginContextInterface 接口，将调用分发给注册的处理函数
+// 处理函数panic时记录堆栈并返回错误结果，插件进程不会退出
+func (r *Router) CallToolContext(ctx context.Context, toolName string, params map[string]any) (result *CallToolResult, err error) {
+	r.mu.RLock()
+	rt, exists := r.routes[toolName]
+	r.mu.RUnlock()
+	if !exists {
+		return NewErrorResult(fmt.Sprintf("未知的工具: %s", toolName)), nil
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("工具 %s 发生panic: %v\n%s", toolName, p, debug.Stack())
+			result, err = NewErrorResult(fmt.Sprintf("工具 %s 执行失败: %v", toolName, p)), nil
+		}
+	}()
+
+	var arg reflect.Value
+	if rt.paramType == paramsMapType {
+		if params == nil {
+			params = map[string]any{}
+		}
+		arg = reflect.ValueOf(params)
+	} else {
+		structType := rt.paramType
+		if structType.Kind() == reflect.Ptr {
+			structType = structType.Elem()
+		}
+		ptr := reflect.New(structType)
+		if err := DecodeParams(params, ptr.Interface()); err != nil {
+			return NewErrorResult(err.Error()), nil
+		}
+		arg = ptr
+		if rt.paramType.Kind() != reflect.Ptr {
+			arg = ptr.Elem()
+		}
+	}
+
+	args := []reflect.Value{arg}
+	if rt.withCtx {
+		args = []reflect.Value{reflect.ValueOf(ctx), arg}
+	}
+	out := rt.handler.Call(args)
+
+	if errValue := out[1].Interface(); errValue != nil {
+		return nil, errValue.(error)
+	}
+	result = out[0].Interface().(*CallToolResult)
+	if result == nil {
+		result = NewCallToolResult()
+	}
+	return result, nil
+}
@@ -0,0 +1,127 @@
+// router_test.go
+// 插件端工具路由测试文件
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// greetParams 测试用的问候工具参数
+type greetParams struct {
+	Name  string `json:"name" description:"名称" schema:"required"`
+	Times int    `json:"times" schema:"default=1"`
+}
+
+// TestRouter 测试工具注册、参数绑定和 GetTools 聚合
+func TestRouter(t *testing.T) {
+	router := NewRouter(WithPluginInfo(PluginInfo{Name: "router", Version: "1.0.0"}))
+	router.
+		Handle("greet", func(p greetParams) (*CallToolResult, error) {
+			return NewCallToolResult().SetMeta("name", p.Name).SetMeta("times", p.Times), nil
+		}, nil).
+		Handle("raw", func(params map[string]any) (*CallToolResult, error) {
+			return NewCallToolResult().SetMeta("count", len(params)), nil
+		}, NewTool("raw", "原始参数")).
+		Handle("fail", func(ctx context.Context, p *greetParams) (*CallToolResult, error) {
+			return nil, errors.New("失败")
+		}, nil)
+
+	tools, _ := router.GetTools()
+	if len(tools) != 3 || tools[0].Name != "greet" || tools[1].Description != "原始参数" {
+		t.Fatalf("工具定义聚合错误: %+v", tools)
+	}
+	if len(tools[0].InputSchema.Required) != 1 || tools[0].InputSchema.Required[0] != "name" {
+		t.Errorf("应该根据参数结构体生成工具定义: %+v", tools[0].InputSchema)
+	}
+	if info, _ := router.GetPluginInfo(); info.Name != "router" {
+		t.Errorf("插件信息错误: %+v", info)
+	}
+
+	// 通过RPC调用，验证路由可以直接作为插件实现
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: router})
+
+	result, err := client.CallTool("greet", map[string]any{"name": "gopher"})
+	if err != nil || result.IsError {
+		t.Fatalf("调用失败: %+v, %v", result, err)
+	}
+	if result.Meta["name"] != "gopher" || result.Meta["times"] != 1 {
+		t.Errorf("参数绑定错误: %v", result.Meta)
+	}
+
+	result, _ = client.CallTool("greet", map[string]any{})
+	if !result.IsError {
+		t.Error("缺少必填参数时应该返回错误结果")
+	}
+
+	result, _ = client.CallTool("raw", map[string]any{"a": 1, "b": 2})
+	if result.Meta["count"] != 2 {
+		t.Errorf("map 参数应该原样传递: %v", result.Meta)
+	}
+
+	result, _ = client.CallTool("fail", map[string]any{"name": "x"})
+	if !result.IsError {
+		t.Error("处理函数返回错误时应该返回错误结果")
+	}
+
+	result, _ = client.CallTool("missing", nil)
+	if !result.IsError {
+		t.Error("未注册的工具应该返回错误结果")
+	}
+}
+
+// TestRouterPanicRecovery 测试处理函数panic时返回错误结果
+func TestRouterPanicRecovery(t *testing.T) {
+	router := NewRouter().Handle("boom", func(params map[string]any) (*CallToolResult, error) {
+		panic("boom")
+	}, nil)
+
+	result, err := router.CallTool("boom", nil)
+	if err != nil || result == nil || !result.IsError {
+		t.Errorf("panic 应该转换为错误结果: %+v, %v", result, err)
+	}
+}
+
+// TestRouterContext 测试带 ctx 的处理函数可以感知取消
+func TestRouterContext(t *testing.T) {
+	router := NewRouter().Handle("wait", func(ctx context.Context, params map[string]any) (*CallToolResult, error) {
+		<-ctx.Done()
+		return NewCallToolResult().SetMeta("done", true), nil
+	}, nil)
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: router})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	result, err := client.CallToolContext(ctx, "wait", nil)
+	if err != nil || result.Meta["cancelled"] != true {
+		t.Errorf("取消后应该返回部分结果: %+v, %v", result, err)
+	}
+}
+
+// TestRouterHandlePanics 测试注册错误时panic
+func TestRouterHandlePanics(t *testing.T) {
+	cases := map[string]func(r *Router){
+		"不是函数":   func(r *Router) { r.Handle("a", "x", nil) },
+		"返回值错误":  func(r *Router) { r.Handle("a", func(map[string]any) error { return nil }, nil) },
+		"参数类型错误": func(r *Router) { r.Handle("a", func(string) (*CallToolResult, error) { return nil, nil }, nil) },
+		"名称不一致": func(r *Router) {
+			r.Handle("a", func(map[string]any) (*CallToolResult, error) { return nil, nil }, NewTool("b", ""))
+		},
+		"重复注册": func(r *Router) {
+			handler := func(map[string]any) (*CallToolResult, error) { return nil, nil }
+			r.Handle("a", handler, nil).Handle("a", handler, nil)
+		},
+	}
+	for name, register := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("应该panic")
+				}
+			}()
+			register(NewRouter())
+		})
+	}
+}
@@ -0,0 +1,367 @@
+// plugin/scheduler.go - 定时调用工具
+// 按 cron 表达式定时调用工具，适合实现了轮询或维护任务的插件，
+// 支持随机延迟、重叠执行策略以及结果处理函数
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// OverlapPolicy 上一次调用尚未结束时的处理策略
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 跳过本次调用（默认）
+	OverlapSkip OverlapPolicy = iota
+	// OverlapAllow 允许多次调用同时执行
+	OverlapAllow
+	// OverlapWait 等待上一次调用结束后立即执行，等待期间到期的调用合并为一次
+	OverlapWait
+)
+
+// ScheduledCall 定时调用的配置
+type ScheduledCall struct {
+	Name    string         // 名称，在调度器内唯一
+	Spec    string         // cron 表达式，参见 ParseSchedule
+	Tool    string         // 工具名称
+	Params  map[string]any // 调用参数
+	Caller  Identity       // 调用方身份，用于权限检查和审计
+	Jitter  time.Duration  // 每次调用前随机延迟 [0, Jitter)，避免多个实例同时调用
+	Timeout time.Duration  // 单次调用的超时时间，为0时不限制
+	Overlap OverlapPolicy  // 上一次调用尚未结束时的处理策略
+
+	// OnResult 每次调用结束（包括跳过）后调用，为 nil 时使用调度器的结果处理函数
+	OnResult func(run ScheduledRun)
+}
+
+// ScheduledRun 一次定时调用的结果
+type ScheduledRun struct {
+	Name     string          // 定时调用的名称
+	Tool     string          // 工具名称
+	Time     time.Time       // 计划执行时间
+	Start    time.Time       // 实际开始时间，跳过时为零值
+	Duration time.Duration   // 调用耗时
+	Result   *CallToolResult // 调用结果
+	Err      error           // 调用错误
+	Skipped  bool            // 是否因为上一次调用尚未结束而跳过
+}
+
+// SchedulerOption 调度器配置选项函数类型
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerLocation 设置计算 cron 表达式使用的时区，默认为本地时区
+func WithSchedulerLocation(loc *time.Location) SchedulerOption {
+	return func(s *Scheduler) {
+		s.location = loc
+	}
+}
+
+// WithSchedulerResultHandler 设置默认的结果处理函数，未设置时调用失败会写入日志
+func WithSchedulerResultHandler(handler func(run ScheduledRun)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onResult = handler
+	}
+}
+
+// scheduleEntry 调度器中的一个定时调用
+type scheduleEntry struct {
+	call     ScheduledCall
+	schedule Schedule
+	next     time.Time // 下一次执行时间，为零值时不再执行
+
+	mu      sync.Mutex
+	running int  // 正在执行的调用数
+	pending bool // OverlapWait 策略下是否有等待执行的调用
+}
+
+// Scheduler 按 cron 表达式定时调用工具的调度器
+type Scheduler struct {
+	manager  Manager
+	location *time.Location
+	onResult func(run ScheduledRun)
+
+	mu       sync.Mutex
+	entries  map[string]*scheduleEntry
+	wake     chan struct{} // 定时调用变化时唤醒调度循环
+	started  bool
+	stopLoop chan struct{} // 关闭后调度循环退出
+	loopDone chan struct{} // 调度循环退出后关闭
+	stopOnce sync.Once
+
+	ctx    context.Context // 取消后正在执行的调用随之取消
+	cancel context.CancelFunc
+	runs   sync.WaitGroup // 正在执行的调用
+}
+
+// NewScheduler 创建调度器，调用 Start 后开始执行
+func NewScheduler(manager Manager, options ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		manager:  manager,
+		location: time.Local,
+		entries:  make(map[string]*scheduleEntry),
+		wake:     make(chan struct{}, 1),
+		stopLoop: make(chan struct{}),
+		loopDone: make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Add 添加定时调用，名称已存在或 cron 表达式无效时返回错误
+func (s *Scheduler) Add(call ScheduledCall) error {
+	if call.Name == "" || call.Tool == "" {
+		return errors.New("定时调用的名称和工具不能为空")
+	}
+	schedule, err := ParseSchedule(call.Spec)
+	if err != nil {
+		return fmt.Errorf("定时调用 '%s' 的表达式无效: %w", call.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[call.Name]; exists {
+		return fmt.Errorf("定时调用 '%s' 已存在", call.Name)
+	}
+	s.entries[call.Name] = &scheduleEntry{
+		call:     call,
+		schedule: schedule,
+		next:     schedule.Next(time.Now().In(s.location)),
+	}
+	s.notify()
+	return nil
+}
+
+// Remove 删除定时调用，正在执行的调用不受影响
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[name]; !exists {
+		return false
+	}
+	delete(s.entries, name)
+	s.notify()
+	return true
+}
+
+// Entries 返回每个定时调用的下一次执行时间，key为名称
+func (s *Scheduler) Entries() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make(map[string]time.Time, len(s.entries))
+	for name, entry := range s.entries {
+		entries[name] = entry.next
+	}
+	return entries
+}
+
+// Start 启动调度循环，重复调用或停止后调用无效
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	select {
+	case <-s.stopLoop:
+		return
+	default:
+	}
+	s.started = true
+	go s.run()
+}
+
+// Stop 停止调度并等待正在执行的调用结束
+// ctx 结束时取消正在执行的调用，等待其返回后返回 ctx 的错误
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopOnce.Do(func() { close(s.stopLoop) })
+	started := s.started
+	s.mu.Unlock()
+
+	if started {
+		<-s.loopDone
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+	defer s.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// notify 唤醒调度循环重新计算下一次执行时间，调用方需持有 s.mu
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度循环，等待最近的执行时间并发起到期的调用
+func (s *Scheduler) run() {
+	defer close(s.loopDone)
+
+	for {
+		// 没有定时调用时只等待唤醒
+		var timer *time.Timer
+		var fired <-chan time.Time
+		if next, ok := s.nextTime(); ok {
+			timer = time.NewTimer(time.Until(next))
+			fired = timer.C
+		}
+
+		select {
+		case <-s.stopLoop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-s.wake:
+		case now := <-fired:
+			s.fire(now)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextTime 返回所有定时调用中最近的执行时间
+func (s *Scheduler) nextTime() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, entry := range s.entries {
+		if !entry.next.IsZero() && (next.IsZero() || entry.next.Before(next)) {
+			next = entry.next
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// fire 发起所有已到期的调用并计算下一次执行时间
+func (s *Scheduler) fire(now time.Time) {
+	var skipped []ScheduledRun
+	var skippedCalls []ScheduledCall
+
+	s.mu.Lock()
+	for _, entry := range s.entries {
+		if entry.next.IsZero() || entry.next.After(now) {
+			continue
+		}
+		if !s.dispatch(entry, entry.next) {
+			skipped = append(skipped, ScheduledRun{Name: entry.call.Name, Tool: entry.call.Tool, Time: entry.next, Skipped: true})
+			skippedCalls = append(skippedCalls, entry.call)
+		}
+		entry.next = entry.schedule.Next(now.In(s.location))
+	}
+	s.mu.Unlock()
+
+	for i, run := range skipped {
+		s.report(skippedCalls[i], run)
+	}
+}
+
+// dispatch 按重叠策略发起一次调用，因为上一次调用尚未结束而跳过时返回 false
+func (s *Scheduler) dispatch(entry *scheduleEntry, scheduled time.Time) bool {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.running > 0 {
+		switch entry.call.Overlap {
+		case OverlapSkip:
+			return false
+		case OverlapWait:
+			entry.pending = true
+			return true
+		}
+	}
+	entry.running++
+	s.runs.Add(1)
+	go s.execute(entry, scheduled)
+	return true
+}
+
+// execute 执行调用，OverlapWait 策略下执行期间到期的调用在结束后立即执行
+func (s *Scheduler) execute(entry *scheduleEntry, scheduled time.Time) {
+	defer s.runs.Done()
+
+	for {
+		s.report(entry.call, s.callOnce(entry.call, scheduled))
+
+		entry.mu.Lock()
+		if !entry.pending || s.ctx.Err() != nil {
+			entry.running--
+			entry.mu.Unlock()
+			return
+		}
+		entry.pending = false
+		entry.mu.Unlock()
+		scheduled = time.Now()
+	}
+}
+
+// callOnce 随机延迟后调用一次工具
+func (s *Scheduler) callOnce(call ScheduledCall, scheduled time.Time) ScheduledRun {
+	run := ScheduledRun{Name: call.Name, Tool: call.Tool, Time: scheduled}
+
+	if call.Jitter > 0 {
+		select {
+		case <-time.After(rand.N(call.Jitter)):
+		case <-s.ctx.Done():
+			run.Err = s.ctx.Err()
+			return run
+		}
+	}
+
+	ctx := WithIdentity(s.ctx, call.Caller)
+	if call.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.Timeout)
+		defer cancel()
+	}
+
+	run.Start = time.Now()
+	run.Result, run.Err = s.manager.CallToolWithContext(ctx, call.Tool, call.Params)
+	run.Duration = time.Since(run.Start)
+	return run
+}
+
+// report 将结果交给结果处理函数，都未设置时调用失败写入日志
+func (s *Scheduler) report(call ScheduledCall, run ScheduledRun) {
+	handler := call.OnResult
+	if handler == nil {
+		handler = s.onResult
+	}
+	if handler != nil {
+		handler(run)
+		return
+	}
+	if run.Err != nil {
+		log.Printf("定时调用 '%s' 失败: %v", run.Name, run.Err)
+	}
+}
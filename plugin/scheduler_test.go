@@ -0,0 +1,104 @@
+// scheduler_test.go
+// 定时调用测试文件
+package plugin_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gophertool/tool/plugin"
+	"github.com/gophertool/tool/plugin/plugintest"
+)
+
+// newTickFake 创建提供 tick 工具的假插件，每次调用阻塞 delay 后返回调用方ID
+func newTickFake(delay time.Duration) *plugintest.FakePlugin {
+	fake := plugintest.NewFakePlugin("cron")
+	fake.AddTool(plugin.NewTool("tick", "定时任务")).HandleContext(func(ctx context.Context, params map[string]any) (*plugin.CallToolResult, error) {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return plugin.NewCallToolResult().AddTextContent(plugin.IdentityFromContext(ctx).ID), nil
+	})
+	return fake
+}
+
+// TestScheduler 测试定时调用和结果处理函数
+func TestScheduler(t *testing.T) {
+	fake := newTickFake(0)
+	manager := plugintest.NewManager(fake)
+
+	runs := make(chan plugin.ScheduledRun, 10)
+	scheduler := plugin.NewScheduler(manager, plugin.WithSchedulerResultHandler(func(run plugin.ScheduledRun) {
+		select {
+		case runs <- run:
+		default:
+		}
+	}))
+	err := scheduler.Add(plugin.ScheduledCall{Name: "tick", Spec: "@every 10ms", Tool: "tick", Caller: plugin.Identity{ID: "cron"}})
+	if err != nil {
+		t.Fatalf("添加定时调用失败: %v", err)
+	}
+	if err := scheduler.Add(plugin.ScheduledCall{Name: "tick", Spec: "@every 10ms", Tool: "tick"}); err == nil {
+		t.Error("名称重复时应该返回错误")
+	}
+	if err := scheduler.Add(plugin.ScheduledCall{Name: "bad", Spec: "bad", Tool: "tick"}); err == nil {
+		t.Error("表达式无效时应该返回错误")
+	}
+
+	scheduler.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case run := <-runs:
+			if run.Err != nil || run.Result.Content[0].(plugin.TextContent).Text != "cron" {
+				t.Errorf("定时调用结果错误: %+v", run)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("定时调用没有执行")
+		}
+	}
+
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Errorf("停止调度器失败: %v", err)
+	}
+	stopped := fake.CallCount("tick")
+	time.Sleep(30 * time.Millisecond)
+	if fake.CallCount("tick") != stopped {
+		t.Error("停止后不应该继续调用")
+	}
+}
+
+// TestSchedulerOverlapSkip 测试上一次调用未结束时跳过
+func TestSchedulerOverlapSkip(t *testing.T) {
+	fake := newTickFake(time.Hour)
+	manager := plugintest.NewManager(fake)
+
+	var mu sync.Mutex
+	skipped := 0
+	scheduler := plugin.NewScheduler(manager)
+	scheduler.Add(plugin.ScheduledCall{Name: "slow", Spec: "@every 5ms", Tool: "tick", OnResult: func(run plugin.ScheduledRun) {
+		mu.Lock()
+		defer mu.Unlock()
+		if run.Skipped {
+			skipped++
+		}
+	}})
+	scheduler.Start()
+	time.Sleep(50 * time.Millisecond)
+
+	// 超时后取消正在执行的调用
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("调用未结束时应该返回超时错误，实际: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fake.CallCount("tick") != 1 || skipped == 0 {
+		t.Errorf("重叠的调用应该被跳过，调用次数: %d, 跳过次数: %d", fake.CallCount("tick"), skipped)
+	}
+}
@@ -0,0 +1,322 @@
+// plugin/schema.go - 根据Go结构体生成工具输入参数模式
+package plugin
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+// NewToolFromStruct 根据参数结构体创建一个新的工具实例
+// params 必须是结构体或指向结构体的指针，字段名取自 json 标签（与 structToMap 保持一致）
+// 支持的标签：
+//
+//	json:"name,omitempty"             - 参数名称，"-" 表示忽略该字段
+//	description:"参数描述"             - 参数描述
+//	schema:"required,min=1,max=10"    - 参数约束，多个约束以逗号分隔
+//
+// schema 标签支持的约束：required、min、max（按类型映射为 minimum/minLength/minItems 等）、
+// minLength、maxLength、minimum、maximum、exclusiveMinimum、exclusiveMaximum、multipleOf、
+// minItems、maxItems、uniqueItems、pattern、format、default、enum（多个值以 | 分隔）
+//
+// options 在结构体模式生成之后应用，可用于补充或覆盖生成的属性
+// 如果 params 不是结构体或标签无效，函数会panic并提供错误信息
+func NewToolFromStruct(name, description string, params any, options ...ToolOption) *Tool {
+	t := reflect.TypeOf(params)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("NewToolFromStruct: 参数必须是结构体或指向结构体的指针，实际类型: %v", reflect.TypeOf(params)))
+	}
+
+	properties, required, err := structSchema(t, map[reflect.Type]bool{})
+	if err != nil {
+		panic(fmt.Sprintf("NewToolFromStruct: %v", err))
+	}
+
+	tool := NewTool(name, description)
+	tool.InputSchema.Properties = properties
+	tool.InputSchema.Required = required
+
+	for _, option := range options {
+		option(tool)
+	}
+
+	return tool
+}
+
+// structSchema 生成结构体各字段的属性模式和必填字段列表
+// visiting 记录正在处理的结构体类型，用于避免自引用类型的无限递归
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, []string, error) {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := make(map[string]any)
+	required := make([]string, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+
+		// 未指定json名称的匿名结构体字段与 encoding/json 一样展开到外层
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				props, req, err := structSchema(ft, visiting)
+				if err != nil {
+					return nil, nil, err
+				}
+				for k, v := range props {
+					properties[k] = v
+				}
+				required = append(required, req...)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		schema, isRequired, err := fieldSchema(field, visiting)
+		if err != nil {
+			return nil, nil, err
+		}
+		if isRequired {
+			required = append(required, name)
+		}
+
+		properties[name] = schema
+	}
+
+	return properties, required, nil
+}
+
+// fieldSchema 根据字段类型和标签生成单个字段的属性模式
+// 返回属性模式和字段是否为必填
+func fieldSchema(field reflect.StructField, visiting map[reflect.Type]bool) (map[string]any, bool, error) {
+	schema, err := typeSchema(field.Type, visiting)
+	if err != nil {
+		return nil, false, fmt.Errorf("字段 %s: %w", field.Name, err)
+	}
+
+	if desc := field.Tag.Get("description"); desc != "" {
+		schema["description"] = desc
+	}
+
+	required, err := applySchemaTag(schema, field.Type, field.Tag.Get("schema"))
+	if err != nil {
+		return nil, false, fmt.Errorf("字段 %s 的 schema 标签无效: %w", field.Name, err)
+	}
+	return schema, required, nil
+}
+
+// jsonFieldName 根据json标签获取字段对应的参数名称
+// 返回 false 表示该字段不参与序列化
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// typeSchema 根据Go类型生成对应的JSON Schema
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case durationType:
+		return map[string]any{"type": "string", "format": "duration"}, nil
+	case bytesType:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("不支持键类型为 %s 的map", t.Key().Kind())
+		}
+		values, err := typeSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]any{"type": "object"}
+		if len(values) > 0 {
+			schema["additionalProperties"] = values
+		}
+		return schema, nil
+	case reflect.Struct:
+		if visiting[t] {
+			// 自引用类型不再展开
+			return map[string]any{"type": "object"}, nil
+		}
+		properties, required, err := structSchema(t, visiting)
+		if err != nil {
+			return nil, err
+		}
+		schema := map[string]any{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	case reflect.Interface:
+		// 任意类型，不限制
+		return map[string]any{}, nil
+	default:
+		return nil, fmt.Errorf("不支持的字段类型: %s", t.Kind())
+	}
+}
+
+// applySchemaTag 解析schema标签并写入属性模式
+// 返回字段是否为必填
+func applySchemaTag(schema map[string]any, t reflect.Type, tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+
+	schemaType, _ := schema["type"].(string)
+	required := false
+
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, hasValue := strings.Cut(part, "=")
+
+		var err error
+		switch key {
+		case "required":
+			required = true
+		case "min", "max":
+			err = applyBound(schema, schemaType, key, value)
+		case "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+			schema[key], err = strconv.Atoi(value)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			schema[key], err = strconv.ParseFloat(value, 64)
+		case "uniqueItems":
+			unique := true
+			if hasValue {
+				unique, err = strconv.ParseBool(value)
+			}
+			schema[key] = unique
+		case "pattern", "format":
+			schema[key] = value
+		case "default":
+			schema[key], err = parseTagValue(t, schemaType, value)
+		case "enum":
+			values := make([]any, 0)
+			for _, item := range strings.Split(value, "|") {
+				v, e := parseTagValue(t, schemaType, item)
+				if e != nil {
+					err = e
+					break
+				}
+				values = append(values, v)
+			}
+			schema[key] = values
+		default:
+			err = fmt.Errorf("未知的约束: %s", key)
+		}
+
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", part, err)
+		}
+	}
+
+	return required, nil
+}
+
+// applyBound 根据属性类型将 min/max 映射为对应的JSON Schema关键字
+func applyBound(schema map[string]any, schemaType, key, value string) error {
+	switch schemaType {
+	case "integer", "number":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if key == "min" {
+			schema["minimum"] = v
+		} else {
+			schema["maximum"] = v
+		}
+	case "string", "array", "object":
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		suffix := map[string]string{"string": "Length", "array": "Items", "object": "Properties"}[schemaType]
+		schema[key+suffix] = v
+	default:
+		return fmt.Errorf("类型 %q 不支持 %s 约束", schemaType, key)
+	}
+	return nil
+}
+
+// parseTagValue 按属性类型解析标签中的默认值或枚举值
+func parseTagValue(t reflect.Type, schemaType, value string) (any, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch schemaType {
+	case "integer":
+		if k := t.Kind(); k >= reflect.Uint && k <= reflect.Uint64 {
+			return strconv.ParseUint(value, 10, 64)
+		}
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,168 @@
+// schema_test.go
+// 结构体生成工具模式的测试文件
+// 测试 NewToolFromStruct 对字段类型和标签的解析
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// timeCalcParams 测试用的参数结构体
+type timeCalcParams struct {
+	Time    string        `json:"time" description:"基准时间" schema:"required,minLength=1"`
+	Format  string        `json:"format,omitempty" schema:"default=2006-01-02 15:04:05"`
+	Days    int           `json:"days" schema:"min=-365,max=365,default=0"`
+	Unit    string        `json:"unit" schema:"enum=day|hour|minute"`
+	Tags    []string      `json:"tags" schema:"min=1,uniqueItems"`
+	Timeout time.Duration `json:"timeout"`
+	Nested  struct {
+		Enabled bool `json:"enabled" schema:"required"`
+	} `json:"nested"`
+	Ignored string `json:"-"`
+	private string
+}
+
+// TestNewToolFromStruct 测试根据结构体生成工具模式
+func TestNewToolFromStruct(t *testing.T) {
+	tool := NewToolFromStruct("time_calc", "时间计算", &timeCalcParams{})
+
+	if tool.Name != "time_calc" || tool.Description != "时间计算" {
+		t.Errorf("工具名称或描述错误: %s, %s", tool.Name, tool.Description)
+	}
+	if tool.InputSchema.Type != "object" {
+		t.Errorf("输入模式类型应该为object，实际: %s", tool.InputSchema.Type)
+	}
+
+	if !reflect.DeepEqual(tool.InputSchema.Required, []string{"time"}) {
+		t.Errorf("必填字段错误，期望: [time]，实际: %v", tool.InputSchema.Required)
+	}
+
+	props := tool.InputSchema.Properties
+	if len(props) != 7 {
+		t.Errorf("属性数量错误，期望: 7，实际: %d", len(props))
+	}
+	for _, name := range []string{"Ignored", "private"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("字段 %s 不应该出现在模式中", name)
+		}
+	}
+
+	timeProp := props["time"].(map[string]any)
+	if timeProp["type"] != "string" || timeProp["description"] != "基准时间" || timeProp["minLength"] != 1 {
+		t.Errorf("time 属性错误: %v", timeProp)
+	}
+
+	formatProp := props["format"].(map[string]any)
+	if formatProp["default"] != "2006-01-02 15:04:05" {
+		t.Errorf("format 默认值错误: %v", formatProp["default"])
+	}
+
+	daysProp := props["days"].(map[string]any)
+	if daysProp["type"] != "integer" || daysProp["minimum"] != -365.0 || daysProp["maximum"] != 365.0 || daysProp["default"] != int64(0) {
+		t.Errorf("days 属性错误: %v", daysProp)
+	}
+
+	unitProp := props["unit"].(map[string]any)
+	if !reflect.DeepEqual(unitProp["enum"], []any{"day", "hour", "minute"}) {
+		t.Errorf("unit 枚举值错误: %v", unitProp["enum"])
+	}
+
+	tagsProp := props["tags"].(map[string]any)
+	if tagsProp["type"] != "array" || tagsProp["minItems"] != 1 || tagsProp["uniqueItems"] != true {
+		t.Errorf("tags 属性错误: %v", tagsProp)
+	}
+	if items := tagsProp["items"].(map[string]any); items["type"] != "string" {
+		t.Errorf("tags 项目类型错误: %v", items)
+	}
+
+	timeoutProp := props["timeout"].(map[string]any)
+	if timeoutProp["type"] != "string" || timeoutProp["format"] != "duration" {
+		t.Errorf("timeout 属性错误: %v", timeoutProp)
+	}
+
+	nestedProp := props["nested"].(map[string]any)
+	if nestedProp["type"] != "object" || !reflect.DeepEqual(nestedProp["required"], []string{"enabled"}) {
+		t.Errorf("nested 属性错误: %v", nestedProp)
+	}
+
+	// 验证生成的模式可以正常序列化
+	if _, err := json.Marshal(tool); err != nil {
+		t.Errorf("序列化工具失败: %v", err)
+	}
+}
+
+// TestNewToolFromStructOptions 测试生成模式后追加选项
+func TestNewToolFromStructOptions(t *testing.T) {
+	type params struct {
+		Name string `json:"name"`
+	}
+
+	tool := NewToolFromStruct("greet", "问候", params{},
+		WithString("name", Description("覆盖后的描述"), Required()),
+		WithBoolean("loud"),
+	)
+
+	if desc := tool.InputSchema.Properties["name"].(map[string]any)["description"]; desc != "覆盖后的描述" {
+		t.Errorf("选项应该覆盖生成的属性，实际描述: %v", desc)
+	}
+	if _, ok := tool.InputSchema.Properties["loud"]; !ok {
+		t.Error("选项应该能够追加新属性")
+	}
+	if !reflect.DeepEqual(tool.InputSchema.Required, []string{"name"}) {
+		t.Errorf("必填字段错误: %v", tool.InputSchema.Required)
+	}
+}
+
+// TestNewToolFromStructEmbedded 测试匿名嵌入结构体字段的展开
+func TestNewToolFromStructEmbedded(t *testing.T) {
+	type Base struct {
+		ID string `json:"id" schema:"required"`
+	}
+	type params struct {
+		Base
+		Count uint `json:"count" schema:"default=3"`
+	}
+
+	tool := NewToolFromStruct("embedded", "嵌入", params{})
+
+	if _, ok := tool.InputSchema.Properties["id"]; !ok {
+		t.Error("嵌入结构体的字段应该展开到外层")
+	}
+	if !reflect.DeepEqual(tool.InputSchema.Required, []string{"id"}) {
+		t.Errorf("必填字段错误: %v", tool.InputSchema.Required)
+	}
+	if def := tool.InputSchema.Properties["count"].(map[string]any)["default"]; def != uint64(3) {
+		t.Errorf("count 默认值错误: %v", def)
+	}
+}
+
+// TestNewToolFromStructInvalid 测试无效参数和标签
+func TestNewToolFromStructInvalid(t *testing.T) {
+	cases := map[string]any{
+		"非结构体": 123,
+		"nil":  nil,
+		"未知约束": struct {
+			A string `json:"a" schema:"unknown"`
+		}{},
+		"无效数值": struct {
+			A int `json:"a" schema:"min=abc"`
+		}{},
+		"不支持的类型": struct {
+			A chan int `json:"a"`
+		}{},
+	}
+
+	for name, params := range cases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("应该panic")
+				}
+			}()
+			NewToolFromStruct("invalid", "无效", params)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// plugin/serve.go - 可配置的插件服务
+// ServePluginWithOptions 在后台启动插件服务并返回关闭函数，便于将插件服务嵌入到更大的程序中
+package plugin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+// ErrPluginStopping 插件正在关闭时拒绝新调用返回的错误
+var ErrPluginStopping = errors.New("插件正在关闭")
+
+// ServeOption 插件服务配置选项
+type ServeOption func(*serveOptions)
+
+// serveOptions 插件服务配置
+type serveOptions struct {
+	tlsProvider func() (*tls.Config, error)
+	logger      hclog.Logger
+	reattach    chan<- *plugin.ReattachConfig // 测试模式下接收连接信息的通道，为 nil 时不使用测试模式
+}
+
+// WithServeTLS 使用指定的TLS配置加密主程序与插件之间的连接
+func WithServeTLS(config *tls.Config) ServeOption {
+	return WithServeTLSProvider(func() (*tls.Config, error) {
+		return config, nil
+	})
+}
+
+// WithServeTLSProvider 在启动服务时通过 provider 获取TLS配置，可用于从文件或密钥服务加载证书
+func WithServeTLSProvider(provider func() (*tls.Config, error)) ServeOption {
+	return func(o *serveOptions) {
+		o.tlsProvider = provider
+	}
+}
+
+// WithServeLogger 设置插件服务使用的日志器，默认使用 go-plugin 的日志器
+func WithServeLogger(logger hclog.Logger) ServeOption {
+	return func(o *serveOptions) {
+		o.logger = logger
+	}
+}
+
+// WithServeTestMode 以测试模式启动服务
+// 测试模式下不通过标准输出与主程序握手，连接信息发送到 reattach，
+// 主程序通过 ClientConfig.Reattach 连接，插件和主程序可以运行在同一个进程中
+func WithServeTestMode(reattach chan<- *plugin.ReattachConfig) ServeOption {
+	return func(o *serveOptions) {
+		o.reattach = reattach
+	}
+}
+
+// callGate 记录正在进行的调用，关闭时拒绝新调用并等待已有调用完成
+// 为 nil 时所有调用都被允许
+type callGate struct {
+	mu     sync.Mutex
+	closed bool
+	active int
+	idle   chan struct{} // 关闭后所有调用完成时关闭
+}
+
+// enter 开始一次调用，已关闭时返回 false
+func (g *callGate) enter() bool {
+	if g == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return false
+	}
+	g.active++
+	return true
+}
+
+// leave 结束一次调用
+func (g *callGate) leave() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	if g.closed && g.active == 0 {
+		close(g.idle)
+	}
+}
+
+// close 拒绝新调用，并等待已有调用完成或 ctx 结束
+func (g *callGate) close(ctx context.Context) error {
+	g.mu.Lock()
+	if !g.closed {
+		g.closed = true
+		g.idle = make(chan struct{})
+		if g.active == 0 {
+			close(g.idle)
+		}
+	}
+	idle := g.idle
+	g.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newServeConfig 根据选项创建 go-plugin 的服务配置
+func newServeConfig(ctx context.Context, impl ToolPluginInterface, gate *callGate, o *serveOptions) *plugin.ServeConfig {
+	config := &plugin.ServeConfig{
+		HandshakeConfig: HandshakeConfig,
+		Plugins: map[string]plugin.Plugin{
+			"tool": &ToolPlugin{Impl: impl, gate: gate},
+		},
+		TLSProvider: o.tlsProvider,
+		Logger:      o.logger,
+	}
+	if o.reattach != nil {
+		config.Test = &plugin.ServeTestConfig{
+			Context:          ctx,
+			ReattachConfigCh: o.reattach,
+		}
+	}
+	return config
+}
+
+// ServePluginWithOptions 在后台启动插件服务，返回关闭函数
+// 关闭函数拒绝新的工具调用，并等待正在进行的调用完成或 ctx 结束；
+// 测试模式下还会停止服务并等待服务退出。非测试模式下连接由主程序管理，关闭函数返回后程序可以安全退出
+func ServePluginWithOptions(impl ToolPluginInterface, options ...ServeOption) (shutdown func(ctx context.Context) error) {
+	o := &serveOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	// 自动注册所有需要的 gob 类型，用于 RPC 通信
+	registerGobTypes()
+
+	gate := &callGate{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		plugin.Serve(newServeConfig(ctx, impl, gate, o))
+	}()
+
+	return func(shutdownCtx context.Context) error {
+		err := gate.close(shutdownCtx)
+		cancel()
+		if o.reattach == nil {
+			return err
+		}
+
+		select {
+		case <-done:
+			return err
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// serve_test.go
+// 可配置插件服务测试文件
+package plugin
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+)
+
+// blockingPlugin 测试用的插件，调用在 release 关闭前不会返回
+type blockingPlugin struct {
+	echoPlugin
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	close(p.started)
+	<-p.release
+	return NewCallToolResult(), nil
+}
+
+// TestCallGateGracefulStop 测试关闭时拒绝新调用并等待已有调用完成
+func TestCallGateGracefulStop(t *testing.T) {
+	impl := &blockingPlugin{started: make(chan struct{}), release: make(chan struct{})}
+	gate := &callGate{}
+	client := newPipeClient(t, &ToolPluginRPCServer{Impl: impl, gate: gate})
+
+	callDone := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool("block", nil)
+		callDone <- err
+	}()
+	<-impl.started
+
+	// 调用未完成时关闭会超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := gate.close(ctx); err == nil {
+		t.Error("调用未完成时关闭应该等待")
+	}
+
+	// 关闭后拒绝新调用
+	if _, err := client.CallTool("echo", nil); err == nil || !strings.Contains(err.Error(), ErrPluginStopping.Error()) {
+		t.Errorf("关闭后应该拒绝新调用，实际: %v", err)
+	}
+
+	close(impl.release)
+	if err := <-callDone; err != nil {
+		t.Errorf("已开始的调用应该正常完成: %v", err)
+	}
+	if err := gate.close(context.Background()); err != nil {
+		t.Errorf("调用完成后关闭应该成功: %v", err)
+	}
+}
+
+// TestNewServeConfig 测试服务选项
+func TestNewServeConfig(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	reattach := make(chan *plugin.ReattachConfig, 1)
+
+	o := &serveOptions{}
+	for _, option := range []ServeOption{WithServeTLS(tlsConfig), WithServeTestMode(reattach)} {
+		option(o)
+	}
+	config := newServeConfig(context.Background(), &echoPlugin{}, &callGate{}, o)
+
+	if config.TLSProvider == nil {
+		t.Fatal("应该设置 TLSProvider")
+	}
+	if got, err := config.TLSProvider(); err != nil || got != tlsConfig {
+		t.Errorf("TLS配置错误: %v, %v", got, err)
+	}
+	if config.Test == nil || config.Test.ReattachConfigCh == nil {
+		t.Error("应该启用测试模式")
+	}
+	if toolPlugin := config.Plugins["tool"].(*ToolPlugin); toolPlugin.gate == nil {
+		t.Error("插件服务应该使用调用控制")
+	}
+
+	if config := newServeConfig(context.Background(), &echoPlugin{}, nil, &serveOptions{}); config.Test != nil || config.TLSProvider != nil {
+		t.Error("没有选项时不应该启用测试模式和TLS")
+	}
+}
+
+// TestServePluginWithOptionsShutdown 测试测试模式下的关闭函数
+func TestServePluginWithOptionsShutdown(t *testing.T) {
+	reattach := make(chan *plugin.ReattachConfig, 1)
+	shutdown := ServePluginWithOptions(&echoPlugin{}, WithServeTestMode(reattach))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		t.Errorf("关闭服务失败: %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+// plugin/session.go - 跨多次工具调用的会话
+// 会话ID随每次调用传递给插件，有状态的插件（例如浏览器、数据库连接）可以按会话保存资源，
+// 并在会话关闭时释放
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSessionClosed 在已关闭的会话上调用工具时返回的错误
+var ErrSessionClosed = errors.New("会话已关闭")
+
+// ToolPluginSessionInterface 定义了支持会话的工具插件接口
+// 没有实现该接口的插件会忽略会话ID，按普通调用处理
+type ToolPluginSessionInterface interface {
+	ToolPluginInterface
+
+	// CallToolInSession 在指定会话中调用工具
+	CallToolInSession(sessionID string, toolName string, params map[string]any) (*CallToolResult, error)
+
+	// CloseSession 释放会话占用的资源
+	CloseSession(sessionID string) error
+}
+
+// CloseSessionArgs 关闭会话的参数
+type CloseSessionArgs struct {
+	SessionID string `json:"session_id"` // 会话ID
+}
+
+// CallToolInSession 实现 ToolPluginSessionInterface 接口的 CallToolInSession 方法
+func (t *ToolPluginRPC) CallToolInSession(sessionID string, toolName string, params map[string]any) (*CallToolResult, error) {
+	args := CallToolArgs{
+		ToolName:  toolName,
+		Params:    params,
+		SessionID: sessionID,
+	}
+	var result CallToolResult
+	err := t.call("Plugin.CallTool", args, &result)
+	return &result, err
+}
+
+// CloseSession 实现 ToolPluginSessionInterface 接口的 CloseSession 方法
+// 旧版本插件没有该方法，视为没有需要释放的资源
+func (t *ToolPluginRPC) CloseSession(sessionID string) error {
+	var ok bool
+	err := t.call("Plugin.CloseSession", CloseSessionArgs{SessionID: sessionID}, &ok)
+	if isMethodNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// CloseSession 处理来自客户端的 CloseSession RPC 调用
+func (s *ToolPluginRPCServer) CloseSession(args CloseSessionArgs, resp *bool) error {
+	sessionImpl, ok := s.Impl.(ToolPluginSessionInterface)
+	if !ok {
+		*resp = true
+		return nil
+	}
+	if err := sessionImpl.CloseSession(args.SessionID); err != nil {
+		return err
+	}
+	*resp = true
+	return nil
+}
+
+// Session 表示跨多次工具调用的会话
+// 通过 PluginManager.NewSession 创建，使用完毕后必须调用 Close 释放插件中的资源
+type Session struct {
+	id      string
+	manager *PluginManager
+
+	mu      sync.Mutex
+	closed  bool
+	plugins map[string]*LoadedPlugin // 会话中调用过的插件，key为插件名称
+}
+
+// NewSession 创建一个新的会话
+func (pm *PluginManager) NewSession() *Session {
+	session := &Session{
+		id:      newRandomID(),
+		manager: pm,
+		plugins: make(map[string]*LoadedPlugin),
+	}
+
+	pm.mu.Lock()
+	pm.sessions[session.id] = session
+	pm.mu.Unlock()
+
+	return session
+}
+
+// GetSession 获取指定ID的未关闭会话
+func (pm *PluginManager) GetSession(id string) (*Session, bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	session, exists := pm.sessions[id]
+	return session, exists
+}
+
+// ID 返回会话ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// CallTool 在会话中调用指定的工具
+func (s *Session) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	return s.CallToolWithContext(context.Background(), toolName, params)
+}
+
+// CallToolWithContext 在会话中带上下文调用指定的工具
+// 使用 WithIdentity 放入上下文的调用方进行权限检查
+func (s *Session) CallToolWithContext(ctx context.Context, toolName string, params map[string]any) (*CallToolResult, error) {
+	info := callInfo{caller: IdentityFromContext(ctx), tool: toolName, params: params, sessionID: s.id}
+	return s.manager.guardCall(info, func() (*CallToolResult, error) {
+		plugin, exists := s.manager.GetPluginByTool(toolName)
+		if !exists {
+			return nil, fmt.Errorf("工具 '%s' 不存在", toolName)
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			return nil, ErrSessionClosed
+		}
+		s.plugins[plugin.Name] = plugin
+		s.mu.Unlock()
+
+		params, err := s.manager.prepareParams(toolName, params)
+		if err != nil {
+			return nil, err
+		}
+
+		sessionPlugin, ok := plugin.Instance.(ToolPluginSessionInterface)
+		if !ok {
+			return plugin.Instance.CallTool(toolName, params)
+		}
+
+		resultChan := make(chan *CallToolResult, 1)
+		errorChan := make(chan error, 1)
+		go func() {
+			result, err := sessionPlugin.CallToolInSession(s.id, toolName, params)
+			if err != nil {
+				errorChan <- err
+			} else {
+				resultChan <- result
+			}
+		}()
+
+		select {
+		case result := <-resultChan:
+			return result, nil
+		case err := <-errorChan:
+			return nil, err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("工具调用被取消: %w", ctx.Err())
+		}
+	})
+}
+
+// Close 关闭会话，通知会话中调用过的插件释放资源
+// 重复关闭不会报错
+func (s *Session) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	plugins := s.plugins
+	s.plugins = nil
+	s.mu.Unlock()
+
+	s.manager.mu.Lock()
+	delete(s.manager.sessions, s.id)
+	s.manager.mu.Unlock()
+
+	var errs []error
+	for name, plugin := range plugins {
+		sessionPlugin, ok := plugin.Instance.(ToolPluginSessionInterface)
+		if !ok {
+			continue
+		}
+		if err := sessionPlugin.CloseSession(s.id); err != nil {
+			errs = append(errs, fmt.Errorf("插件 %s 关闭会话失败: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newRandomID 生成随机ID，用于会话ID和调用ID
+func newRandomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("生成随机ID失败: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,107 @@
+// session_test.go
+// 会话测试文件
+package plugin
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// counterPlugin 测试用的有状态插件，按会话计数
+type counterPlugin struct {
+	echoPlugin
+	mu       sync.Mutex
+	counters map[string]int
+	closed   []string
+}
+
+func newCounterPlugin() *counterPlugin {
+	return &counterPlugin{counters: make(map[string]int)}
+}
+
+func (p *counterPlugin) CallToolInSession(sessionID string, toolName string, params map[string]any) (*CallToolResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.counters[sessionID]++
+	return NewCallToolResult().SetMeta("count", p.counters[sessionID]), nil
+}
+
+func (p *counterPlugin) CloseSession(sessionID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.counters, sessionID)
+	p.closed = append(p.closed, sessionID)
+	return nil
+}
+
+// TestSession 测试会话ID随调用传递以及会话关闭
+func TestSession(t *testing.T) {
+	impl := newCounterPlugin()
+	manager := NewPluginManager()
+	// 通过RPC连接插件，验证会话ID在传输层的传递
+	loaded := &LoadedPlugin{Name: "counter", Instance: newPipeClient(t, &ToolPluginRPCServer{Impl: impl})}
+	manager.plugins["counter"] = loaded
+	manager.toolMap["count"] = loaded
+
+	s1 := manager.NewSession()
+	s2 := manager.NewSession()
+	if s1.ID() == "" || s1.ID() == s2.ID() {
+		t.Fatalf("会话ID应该唯一: %s, %s", s1.ID(), s2.ID())
+	}
+	if got, ok := manager.GetSession(s1.ID()); !ok || got != s1 {
+		t.Error("应该能通过ID获取会话")
+	}
+
+	for i := 1; i <= 3; i++ {
+		result, err := s1.CallTool("count", nil)
+		if err != nil {
+			t.Fatalf("会话调用失败: %v", err)
+		}
+		if result.Meta["count"] != float64(i) && result.Meta["count"] != i {
+			t.Errorf("会话状态错误，期望: %d, 实际: %v", i, result.Meta["count"])
+		}
+	}
+	result, err := s2.CallTool("count", nil)
+	if err != nil || (result.Meta["count"] != 1 && result.Meta["count"] != 1.0) {
+		t.Errorf("不同会话的状态应该相互独立: %v, %v", result.Meta, err)
+	}
+
+	if err := s1.Close(); err != nil {
+		t.Fatalf("关闭会话失败: %v", err)
+	}
+	if err := s1.Close(); err != nil {
+		t.Errorf("重复关闭不应该报错: %v", err)
+	}
+	if len(impl.closed) != 1 || impl.closed[0] != s1.ID() {
+		t.Errorf("插件应该收到关闭会话通知: %v", impl.closed)
+	}
+	if _, ok := manager.GetSession(s1.ID()); ok {
+		t.Error("关闭后的会话应该被移除")
+	}
+	if _, err := s1.CallTool("count", nil); !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("在已关闭的会话上调用应该返回 ErrSessionClosed，实际: %v", err)
+	}
+}
+
+// TestSessionStatelessPlugin 测试不支持会话的插件
+func TestSessionStatelessPlugin(t *testing.T) {
+	manager := NewPluginManager()
+	loaded := &LoadedPlugin{Name: "echo", Instance: newPipeClient(t, &ToolPluginRPCServer{Impl: &echoPlugin{}})}
+	manager.plugins["echo"] = loaded
+	manager.toolMap["echo"] = loaded
+
+	session := manager.NewSession()
+	if _, err := session.CallTool("echo", map[string]any{"text": "x"}); err != nil {
+		t.Fatalf("不支持会话的插件应该按普通调用处理: %v", err)
+	}
+	if err := session.Close(); err != nil {
+		t.Errorf("关闭会话失败: %v", err)
+	}
+
+	// 旧版本插件没有 CloseSession 方法
+	legacy := newPipeClient(t, &legacyRPCServer{})
+	if err := legacy.CloseSession("id"); err != nil {
+		t.Errorf("旧版本插件关闭会话不应该报错: %v", err)
+	}
+}
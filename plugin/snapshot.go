@@ -0,0 +1,215 @@
+// plugin/snapshot.go - 插件管理器状态快照
+// 快照以可序列化的形式描述已加载的插件、工具映射和管理器配置，
+// 监控程序可以保存快照，在重启后恢复插件拓扑，或比较两个快照之间的差异
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Snapshot 插件管理器的状态快照
+type Snapshot struct {
+	Time    time.Time         `json:"time"`    // 生成快照的时间
+	Plugins []PluginSnapshot  `json:"plugins"` // 已加载的插件，按名称排序
+	Tools   map[string]string `json:"tools"`   // 工具到插件的映射，key为工具名称
+	Config  ManagerConfig     `json:"config"`  // 管理器配置
+}
+
+// PluginSnapshot 单个插件的快照
+type PluginSnapshot struct {
+	Name      string     `json:"name"`                 // 插件名称
+	Path      string     `json:"path,omitempty"`       // 插件文件路径，进程内插件为空
+	Info      PluginInfo `json:"info"`                 // 插件信息
+	Codec     string     `json:"codec,omitempty"`      // 与插件通信使用的编解码器
+	Tools     []string   `json:"tools"`                // 插件提供的工具名称，按名称排序
+	InProcess bool       `json:"in_process,omitempty"` // 是否为通过 RegisterPlugin 注册的进程内插件
+}
+
+// ManagerConfig 快照中记录的管理器配置
+// Restore 只恢复可以在运行时修改的工具访问控制列表和插件日志级别，
+// 其他配置只用于记录和比较，需要在创建管理器时通过选项设置
+type ManagerConfig struct {
+	PoolSize          int                `json:"pool_size"`                    // 每个插件的RPC连接数
+	Lazy              bool               `json:"lazy,omitempty"`               // 是否延迟启动插件进程
+	KeepaliveInterval time.Duration      `json:"keepalive_interval,omitempty"` // 心跳检测间隔
+	SchemaDefaults    bool               `json:"schema_defaults,omitempty"`    // 调用前是否补全参数默认值
+	Coercion          CoercionMode       `json:"coercion,omitempty"`           // 参数类型转换模式
+	JobWorkers        int                `json:"job_workers"`                  // 异步任务工作协程数
+	LogLevel          string             `json:"log_level"`                    // 插件默认日志级别
+	PluginLogLevels   map[string]string  `json:"plugin_log_levels,omitempty"`  // 单独设置的插件日志级别
+	ToolACLs          map[string]ToolACL `json:"tool_acls,omitempty"`          // 工具访问控制列表
+}
+
+// Snapshot 生成插件管理器当前状态的快照
+func (pm *PluginManager) Snapshot() *Snapshot {
+	pm.mu.RLock()
+	snapshot := &Snapshot{
+		Time:  time.Now(),
+		Tools: make(map[string]string, len(pm.toolMap)),
+		Config: ManagerConfig{
+			PoolSize:          pm.poolSize,
+			Lazy:              pm.lazy,
+			KeepaliveInterval: pm.keepaliveInterval,
+			SchemaDefaults:    pm.schemaDefaults,
+			Coercion:          pm.coercion,
+			JobWorkers:        pm.jobWorkers,
+			ToolACLs:          make(map[string]ToolACL, len(pm.toolACLs)),
+		},
+	}
+	if snapshot.Config.PoolSize == 0 {
+		snapshot.Config.PoolSize = DefaultPoolSize
+	}
+	for _, loaded := range pm.plugins {
+		tools := make([]string, 0, len(loaded.Tools))
+		for _, tool := range loaded.Tools {
+			tools = append(tools, tool.Name)
+		}
+		sort.Strings(tools)
+		snapshot.Plugins = append(snapshot.Plugins, PluginSnapshot{
+			Name:      loaded.Name,
+			Path:      loaded.Path,
+			Info:      loaded.Info,
+			Codec:     loaded.Codec,
+			Tools:     tools,
+			InProcess: loaded.Path == "",
+		})
+	}
+	for tool, loaded := range pm.toolMap {
+		snapshot.Tools[tool] = loaded.Name
+	}
+	for tool, acl := range pm.toolACLs {
+		snapshot.Config.ToolACLs[tool] = acl
+	}
+	pm.mu.RUnlock()
+
+	sort.Slice(snapshot.Plugins, func(i, j int) bool {
+		return snapshot.Plugins[i].Name < snapshot.Plugins[j].Name
+	})
+
+	pm.logMu.Lock()
+	snapshot.Config.LogLevel = pm.logLevel.String()
+	snapshot.Config.PluginLogLevels = make(map[string]string, len(pm.pluginLogLevels))
+	for name, level := range pm.pluginLogLevels {
+		snapshot.Config.PluginLogLevels[name] = level.String()
+	}
+	pm.logMu.Unlock()
+
+	return snapshot
+}
+
+// Restore 按快照恢复插件拓扑
+// 依次恢复工具访问控制列表和插件日志级别，然后加载快照中尚未加载的插件；
+// 进程内插件需要由主程序重新注册，恢复时跳过。部分插件加载失败时继续加载其余插件，返回合并的错误
+func (pm *PluginManager) Restore(snapshot *Snapshot) error {
+	pm.mu.Lock()
+	pm.toolACLs = make(map[string]ToolACL, len(snapshot.Config.ToolACLs))
+	for tool, acl := range snapshot.Config.ToolACLs {
+		pm.toolACLs[tool] = acl
+	}
+	pm.mu.Unlock()
+
+	for name, level := range snapshot.Config.PluginLogLevels {
+		pm.SetPluginLogLevel(name, hclog.LevelFromString(level))
+	}
+
+	var errs []error
+	for _, saved := range snapshot.Plugins {
+		if _, exists := pm.GetPlugin(saved.Name); exists {
+			continue
+		}
+		if saved.InProcess {
+			log.Printf("插件 %s 为进程内插件，需要由主程序重新注册", saved.Name)
+			continue
+		}
+
+		loaded, err := pm.LoadPlugin(saved.Path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("恢复插件 %s 失败: %w", saved.Name, err))
+			continue
+		}
+
+		pm.mu.Lock()
+		if _, exists := pm.plugins[loaded.Name]; exists {
+			pm.mu.Unlock()
+			if loaded.Client != nil {
+				loaded.Client.Kill()
+			}
+			continue
+		}
+		pm.plugins[loaded.Name] = loaded
+		for _, tool := range loaded.Tools {
+			pm.toolMap[tool.Name] = loaded
+		}
+		pm.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// SnapshotDiff 两个快照之间的差异，各列表按名称排序
+type SnapshotDiff struct {
+	AddedPlugins   []string `json:"added_plugins,omitempty"`   // 新增的插件
+	RemovedPlugins []string `json:"removed_plugins,omitempty"` // 移除的插件
+	ChangedPlugins []string `json:"changed_plugins,omitempty"` // 路径、版本、编解码器或工具发生变化的插件
+	AddedTools     []string `json:"added_tools,omitempty"`     // 新增的工具
+	RemovedTools   []string `json:"removed_tools,omitempty"`   // 移除的工具
+	MovedTools     []string `json:"moved_tools,omitempty"`     // 改由其他插件提供的工具
+}
+
+// IsEmpty 判断两个快照的插件拓扑是否一致
+func (d SnapshotDiff) IsEmpty() bool {
+	return len(d.AddedPlugins) == 0 && len(d.RemovedPlugins) == 0 && len(d.ChangedPlugins) == 0 &&
+		len(d.AddedTools) == 0 && len(d.RemovedTools) == 0 && len(d.MovedTools) == 0
+}
+
+// Diff 比较快照与较新的快照 newer 之间插件拓扑的差异，不比较管理器配置
+func (s *Snapshot) Diff(newer *Snapshot) SnapshotDiff {
+	var diff SnapshotDiff
+
+	oldPlugins := make(map[string]PluginSnapshot, len(s.Plugins))
+	for _, p := range s.Plugins {
+		oldPlugins[p.Name] = p
+	}
+	newPlugins := make(map[string]PluginSnapshot, len(newer.Plugins))
+	for _, p := range newer.Plugins {
+		newPlugins[p.Name] = p
+		old, exists := oldPlugins[p.Name]
+		switch {
+		case !exists:
+			diff.AddedPlugins = append(diff.AddedPlugins, p.Name)
+		case old.Path != p.Path || old.Info.Version != p.Info.Version || old.Codec != p.Codec || !slices.Equal(old.Tools, p.Tools):
+			diff.ChangedPlugins = append(diff.ChangedPlugins, p.Name)
+		}
+	}
+	for name := range oldPlugins {
+		if _, exists := newPlugins[name]; !exists {
+			diff.RemovedPlugins = append(diff.RemovedPlugins, name)
+		}
+	}
+
+	for tool, plugin := range newer.Tools {
+		old, exists := s.Tools[tool]
+		switch {
+		case !exists:
+			diff.AddedTools = append(diff.AddedTools, tool)
+		case old != plugin:
+			diff.MovedTools = append(diff.MovedTools, tool)
+		}
+	}
+	for tool := range s.Tools {
+		if _, exists := newer.Tools[tool]; !exists {
+			diff.RemovedTools = append(diff.RemovedTools, tool)
+		}
+	}
+
+	for _, list := range [][]string{diff.AddedPlugins, diff.RemovedPlugins, diff.ChangedPlugins, diff.AddedTools, diff.RemovedTools, diff.MovedTools} {
+		sort.Strings(list)
+	}
+	return diff
+}
@@ -0,0 +1,110 @@
+// snapshot_test.go
+// 状态快照测试文件
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// recordPlugin 测试用的插件，记录收到的参数
+type recordPlugin struct {
+	tools  []Tool
+	params map[string]any
+}
+
+func (p *recordPlugin) GetTools() ([]Tool, error) {
+	return p.tools, nil
+}
+
+func (p *recordPlugin) CallTool(toolName string, params map[string]any) (*CallToolResult, error) {
+	p.params = params
+	return NewCallToolResult(), nil
+}
+
+func (p *recordPlugin) GetPluginInfo() (PluginInfo, error) {
+	return PluginInfo{Name: "record"}, nil
+}
+
+// newSnapshotManager 创建注册了指定插件的管理器，每个插件提供给定的工具
+func newSnapshotManager(t *testing.T, plugins map[string][]string, options ...PluginManagerOption) *PluginManager {
+	manager := NewPluginManager(options...)
+	for name, tools := range plugins {
+		impl := &recordPlugin{}
+		for _, tool := range tools {
+			impl.tools = append(impl.tools, *NewTool(tool, tool))
+		}
+		if _, err := manager.RegisterPlugin(name, impl); err != nil {
+			t.Fatalf("注册插件失败: %v", err)
+		}
+	}
+	return manager
+}
+
+// TestSnapshot 测试生成快照及JSON序列化
+func TestSnapshot(t *testing.T) {
+	manager := newSnapshotManager(t, map[string][]string{"b": {"fetch"}, "a": {"search", "list"}},
+		WithToolACL("fetch", ToolACL{Allow: []string{"admin"}}), WithSchemaDefaults())
+
+	snapshot := manager.Snapshot()
+	if len(snapshot.Plugins) != 2 || snapshot.Plugins[0].Name != "a" || !snapshot.Plugins[0].InProcess {
+		t.Fatalf("插件快照错误: %+v", snapshot.Plugins)
+	}
+	if !reflect.DeepEqual(snapshot.Plugins[0].Tools, []string{"list", "search"}) {
+		t.Errorf("工具应该按名称排序: %v", snapshot.Plugins[0].Tools)
+	}
+	if snapshot.Tools["fetch"] != "b" || !snapshot.Config.SchemaDefaults || snapshot.Config.PoolSize != DefaultPoolSize {
+		t.Errorf("快照内容错误: %+v", snapshot)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("序列化快照失败: %v", err)
+	}
+	var decoded Snapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("解析快照失败: %v", err)
+	}
+	if diff := snapshot.Diff(&decoded); !diff.IsEmpty() {
+		t.Errorf("序列化前后的快照不应该有差异: %+v", diff)
+	}
+	if !reflect.DeepEqual(decoded.Config.ToolACLs, snapshot.Config.ToolACLs) {
+		t.Errorf("访问控制列表序列化错误: %v", decoded.Config.ToolACLs)
+	}
+}
+
+// TestSnapshotDiff 测试比较快照
+func TestSnapshotDiff(t *testing.T) {
+	before := newSnapshotManager(t, map[string][]string{"a": {"search"}, "b": {"fetch", "list"}}).Snapshot()
+	after := newSnapshotManager(t, map[string][]string{"a": {"search", "list"}, "c": {"fetch"}}).Snapshot()
+
+	want := SnapshotDiff{
+		AddedPlugins:   []string{"c"},
+		RemovedPlugins: []string{"b"},
+		ChangedPlugins: []string{"a"},
+		MovedTools:     []string{"fetch", "list"},
+	}
+	if diff := before.Diff(after); !reflect.DeepEqual(diff, want) {
+		t.Errorf("快照差异错误\n期望: %+v\n实际: %+v", want, diff)
+	}
+}
+
+// TestRestore 测试按快照恢复
+func TestRestore(t *testing.T) {
+	snapshot := newSnapshotManager(t, map[string][]string{"a": {"search"}},
+		WithToolACL("search", ToolACL{Deny: []string{"guest"}})).Snapshot()
+	snapshot.Plugins = append(snapshot.Plugins, PluginSnapshot{Name: "missing", Path: "/nonexistent/missing.tool.plugin"})
+
+	manager := NewPluginManager()
+	err := manager.Restore(snapshot)
+	if err == nil {
+		t.Error("插件文件不存在时应该返回错误")
+	}
+	if _, exists := manager.GetPlugin("a"); exists {
+		t.Error("进程内插件不应该被恢复")
+	}
+	if err := manager.Authorize(Identity{ID: "guest"}, "search"); err == nil {
+		t.Error("应该恢复工具访问控制列表")
+	}
+}
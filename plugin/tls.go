@@ -0,0 +1,83 @@
+// plugin/tls.go - 主程序与插件之间的双向TLS
+// 插件通过网络运行在其他进程或主机上时，工具参数（经常包含凭据）需要加密传输，
+// 双方都使用同一个CA签发的证书，互相验证身份
+package plugin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// LoadServerMTLS 加载插件端的双向TLS配置
+// certFile/keyFile 为插件的证书和私钥，caFile 为用于验证主程序证书的CA证书，主程序必须提供证书
+func LoadServerMTLS(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, pool, err := loadCertAndCA(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// LoadClientMTLS 加载主程序端的双向TLS配置
+// certFile/keyFile 为主程序的证书和私钥，caFile 为用于验证插件证书的CA证书，
+// serverName 为插件证书中的主机名
+func LoadClientMTLS(certFile, keyFile, caFile, serverName string) (*tls.Config, error) {
+	cert, pool, err := loadCertAndCA(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// loadCertAndCA 加载证书、私钥和CA证书
+func loadCertAndCA(certFile, keyFile, caFile string) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("加载证书失败: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("读取CA证书失败: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, fmt.Errorf("CA证书 %s 中没有有效的证书", caFile)
+	}
+	return cert, pool, nil
+}
+
+// WithServeMTLS 插件端启用双向TLS，证书在服务启动时加载，加载失败时服务无法启动
+func WithServeMTLS(certFile, keyFile, caFile string) ServeOption {
+	return WithServeTLSProvider(func() (*tls.Config, error) {
+		return LoadServerMTLS(certFile, keyFile, caFile)
+	})
+}
+
+// WithPluginTLS 主程序连接插件时使用的TLS配置，通常由 LoadClientMTLS 创建
+// 插件需要使用 WithServeTLS 或 WithServeMTLS 启动服务
+func WithPluginTLS(config *tls.Config) PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.tlsConfig = config
+	}
+}
+
+// WithAutoMTLS 由 go-plugin 为每个插件进程自动生成一次性证书并启用双向TLS
+// 适用于插件运行在本机的情况，不需要管理证书；设置了 WithPluginTLS 时该选项无效
+func WithAutoMTLS() PluginManagerOption {
+	return func(pm *PluginManager) {
+		pm.autoMTLS = true
+	}
+}
@@ -0,0 +1,163 @@
+// tls_test.go
+// 双向TLS测试文件
+package plugin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA 测试用的CA
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+// newTestCA 创建测试用的CA，证书写入临时目录
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("创建CA证书失败: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	ca.writePEM(t, "ca.pem", "CERTIFICATE", der)
+	return ca
+}
+
+// writePEM 将PEM数据写入临时目录，返回文件路径
+func (ca *testCA) writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("写入 %s 失败: %v", name, err)
+	}
+	return path
+}
+
+// issue 签发证书，返回证书和私钥文件路径
+func (ca *testCA) issue(t *testing.T, name string, serial int64) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("签发证书失败: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("序列化私钥失败: %v", err)
+	}
+	return ca.writePEM(t, name+".pem", "CERTIFICATE", der), ca.writePEM(t, name+"-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+// handshake 在 net.Pipe 上进行TLS握手，返回双方的错误
+func handshake(serverConfig, clientConfig *tls.Config) (error, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	server := tls.Server(serverConn, serverConfig)
+	serverErr := make(chan error, 1)
+	go func() {
+		err := server.Handshake()
+		serverConn.Close()
+		serverErr <- err
+	}()
+	clientErr := tls.Client(clientConn, clientConfig).Handshake()
+	clientConn.Close()
+	return <-serverErr, clientErr
+}
+
+// TestMTLS 测试双向TLS配置
+func TestMTLS(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(ca.dir, "ca.pem")
+	pluginCert, pluginKey := ca.issue(t, "plugin.local", 2)
+	hostCert, hostKey := ca.issue(t, "host.local", 3)
+
+	serverConfig, err := LoadServerMTLS(pluginCert, pluginKey, caFile)
+	if err != nil {
+		t.Fatalf("加载插件端配置失败: %v", err)
+	}
+	clientConfig, err := LoadClientMTLS(hostCert, hostKey, caFile, "plugin.local")
+	if err != nil {
+		t.Fatalf("加载主程序端配置失败: %v", err)
+	}
+
+	if serverErr, clientErr := handshake(serverConfig, clientConfig); serverErr != nil || clientErr != nil {
+		t.Errorf("双向TLS握手失败: %v, %v", serverErr, clientErr)
+	}
+
+	// 主程序没有证书时插件拒绝连接
+	anonymous := clientConfig.Clone()
+	anonymous.Certificates = nil
+	if serverErr, _ := handshake(serverConfig, anonymous); serverErr == nil {
+		t.Error("没有客户端证书时握手应该失败")
+	}
+
+	// 其他CA签发的插件证书不被信任
+	other := newTestCA(t)
+	otherCert, otherKey := other.issue(t, "plugin.local", 2)
+	otherConfig, err := LoadServerMTLS(otherCert, otherKey, filepath.Join(other.dir, "ca.pem"))
+	if err != nil {
+		t.Fatalf("加载插件端配置失败: %v", err)
+	}
+	if _, clientErr := handshake(otherConfig, clientConfig); clientErr == nil {
+		t.Error("不受信任的插件证书应该握手失败")
+	}
+}
+
+// TestMTLSOptions 测试双向TLS选项
+func TestMTLSOptions(t *testing.T) {
+	if _, err := LoadClientMTLS("missing.pem", "missing-key.pem", "ca.pem", "x"); err == nil {
+		t.Error("证书不存在时应该返回错误")
+	}
+
+	o := &serveOptions{}
+	WithServeMTLS("missing.pem", "missing-key.pem", "ca.pem")(o)
+	if _, err := o.tlsProvider(); err == nil {
+		t.Error("证书不存在时 TLSProvider 应该返回错误")
+	}
+
+	config := &tls.Config{}
+	manager := NewPluginManager(WithPluginTLS(config), WithAutoMTLS())
+	if manager.tlsConfig != config || !manager.autoMTLS {
+		t.Error("TLS选项没有生效")
+	}
+}
@@ -1,15 +1,72 @@
 // plugin/tool.go - 工具相关类型定义和工具选项函数
 package plugin
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/gophertool/tool/encoding/msgpack"
+)
 
 // Tool 表示一个工具的完整定义
 // 包含工具的名称、描述和输入参数模式
 type Tool struct {
-	Name           string          `json:"name"`         // 工具名称
-	Description    string          `json:"description"`  // 工具描述
-	InputSchema    ToolInputSchema `json:"input_schema"` // 工具输入参数 与 RawInputSchema 二选一
-	RawInputSchema json.RawMessage `json:"-"`            // 工具输入参数的原始JSON Schema 与 InputSchema 二选一
+	Name           string            `json:"name"`                   // 工具名称
+	Description    string            `json:"description"`            // 工具描述
+	InputSchema    ToolInputSchema   `json:"input_schema"`           // 工具输入参数 与 RawInputSchema 二选一
+	RawInputSchema json.RawMessage   `json:"-"`                      // 工具输入参数的原始JSON Schema 与 InputSchema 二选一
+	Tags           []string          `json:"tags,omitempty"`         // 工具标签，用于分类和过滤
+	Descriptions   map[string]string `json:"descriptions,omitempty"` // 多语言工具描述，key为语言标签
+	Examples       []ToolExample     `json:"examples,omitempty"`     // 工具使用示例
+}
+
+// ToolExample 表示工具的一个使用示例
+// 可作为大模型的少样本示例，也可用于生成文档
+type ToolExample struct {
+	Description string         `json:"description,omitempty"` // 示例说明
+	Params      map[string]any `json:"params"`                // 调用参数
+	Result      string         `json:"result,omitempty"`      // 预期结果摘要
+}
+
+// MarshalJSON 自定义Tool的JSON序列化方法
+// 设置了 RawInputSchema 时使用原始JSON Schema作为 input_schema
+func (t Tool) MarshalJSON() ([]byte, error) {
+	var inputSchema any = &t.InputSchema
+	if len(t.RawInputSchema) > 0 {
+		inputSchema = t.RawInputSchema
+	}
+	return json.Marshal(t.wireFields(inputSchema))
+}
+
+// MarshalMsgpack 自定义Tool的MessagePack序列化方法
+// 设置了 RawInputSchema 时将原始JSON Schema解析后作为 input_schema
+func (t Tool) MarshalMsgpack() ([]byte, error) {
+	if len(t.RawInputSchema) == 0 {
+		return msgpack.Marshal(t.wireFields(&t.InputSchema))
+	}
+	var inputSchema any
+	if err := json.Unmarshal(t.RawInputSchema, &inputSchema); err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(t.wireFields(inputSchema))
+}
+
+// wireFields 返回序列化时输出的字段，input_schema 的值由调用方决定
+func (t Tool) wireFields(inputSchema any) map[string]any {
+	data := map[string]any{
+		"name":         t.Name,
+		"description":  t.Description,
+		"input_schema": inputSchema,
+	}
+	if len(t.Tags) > 0 {
+		data["tags"] = t.Tags
+	}
+	if len(t.Descriptions) > 0 {
+		data["descriptions"] = t.Descriptions
+	}
+	if len(t.Examples) > 0 {
+		data["examples"] = t.Examples
+	}
+	return data
 }
 
 // ToolInputSchema 表示工具输入参数的JSON Schema结构
@@ -54,6 +111,42 @@ func WithDescription(description string) ToolOption {
 	}
 }
 
+// WithTags 添加工具标签的选项函数
+func WithTags(tags ...string) ToolOption {
+	return func(t *Tool) {
+		t.Tags = append(t.Tags, tags...)
+	}
+}
+
+// WithExample 添加工具使用示例的选项函数
+// description 为示例说明，params 为调用参数，result 为预期结果摘要
+func WithExample(description string, params map[string]any, result string) ToolOption {
+	return func(t *Tool) {
+		t.Examples = append(t.Examples, ToolExample{
+			Description: description,
+			Params:      params,
+			Result:      result,
+		})
+	}
+}
+
+// WithExamples 批量添加工具使用示例的选项函数
+func WithExamples(examples ...ToolExample) ToolOption {
+	return func(t *Tool) {
+		t.Examples = append(t.Examples, examples...)
+	}
+}
+
+// HasTag 判断工具是否包含指定标签
+func (t *Tool) HasTag(tag string) bool {
+	for _, tt := range t.Tags {
+		if tt == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // Description 设置属性描述的选项函数
 func Description(desc string) PropertyOption {
 	return func(schema map[string]any) {